@@ -0,0 +1,155 @@
+// This file serves for saving the artifacts of the failures for post-mortem debugging.
+
+package rod
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Fromsko/rodPro/lib/proto"
+	"github.com/Fromsko/rodPro/lib/utils"
+)
+
+// ArtifactsTimeout is the max duration to capture the artifacts of a page
+var ArtifactsTimeout = 10 * time.Second
+
+// maxConsoleLines is the max number of the console lines kept for each page
+const maxConsoleLines = 1000
+
+// Artifacts sets the dir to save the artifacts of the failures. When a Must method of a page or an element
+// fails, or an error is wrapped by [Capture], a full-page screenshot, the HTML, the console log, and the error
+// of the page will be saved into a timestamped folder under the dir, such as:
+//
+//	artifacts/20060102-150405.000000-1A2B3C4D/
+//		error.txt
+//		screenshot.png
+//		page.html
+//		console.log
+//
+// It's opt-in, only the pages created after the dir is set save the artifacts into the dir.
+// Set it to empty to disable for the pages created afterwards.
+func (b *Browser) Artifacts(dir string) *Browser {
+	b.artifacts = dir
+	return b
+}
+
+// Capture saves the artifacts of the page if [Browser.Artifacts] is set, then returns an [ErrArtifacts] that wraps
+// the err. It returns the err as it is if err is nil, the dir is not set, or the artifacts can't be saved.
+func Capture(err error, p *Page) error {
+	if err == nil || p == nil || p.browser.artifacts == "" {
+		return err
+	}
+
+	dir, e := p.CaptureArtifacts(err)
+	if e != nil {
+		return err
+	}
+	return &ErrArtifacts{Err: err, Dir: dir}
+}
+
+// CaptureArtifacts saves the artifacts of the page with the err into a new folder under the dir
+// of [Browser.Artifacts], it returns the path of the folder. The artifacts that can't be captured are
+// skipped, the first error of them is returned.
+func (p *Page) CaptureArtifacts(failure error) (dir string, err error) {
+	if p.browser.artifacts == "" {
+		return "", errors.New("the artifacts dir is not set, check Browser.Artifacts")
+	}
+	return p.captureArtifacts(p.browser.artifacts, failure)
+}
+
+func (p *Page) captureArtifacts(root string, failure error) (dir string, err error) {
+	if p.root != nil {
+		p = p.root
+	}
+
+	id := string(p.TargetID)
+	if len(id) > 8 {
+		id = id[:8]
+	}
+	dir = filepath.Join(root, time.Now().Format("20060102-150405.000000")+"-"+id)
+
+	pg := p.Context(p.browser.ctx).Timeout(ArtifactsTimeout).Sleeper(NotFoundSleeper)
+	defer pg.CancelTimeout()
+
+	save := func(name string, data interface{}, e error) {
+		if e == nil {
+			e = utils.OutputFile(filepath.Join(dir, name), data)
+		}
+		if err == nil {
+			err = e
+		}
+	}
+
+	msg := ""
+	if failure != nil {
+		msg = failure.Error()
+	}
+	save("error.txt", msg, nil)
+
+	img, e := pg.Screenshot(true, nil)
+	save("screenshot.png", img, e)
+
+	html, e := pg.HTML()
+	save("page.html", html, e)
+
+	save("console.log", strings.Join(p.console.lines(), "\n"), nil)
+
+	return dir, err
+}
+
+// withArtifacts returns an eFunc that captures the artifacts into the dir before the e fails
+func (p *Page) withArtifacts(e eFunc, dir string) eFunc {
+	return func(args ...interface{}) {
+		if err, ok := args[len(args)-1].(error); ok {
+			_, _ = p.captureArtifacts(dir, err)
+		}
+		e(args...)
+	}
+}
+
+type consoleLog struct {
+	lock sync.Mutex
+	list []string
+}
+
+func (c *consoleLog) add(line string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.list = append(c.list, line)
+	if len(c.list) > maxConsoleLines {
+		c.list = c.list[len(c.list)-maxConsoleLines:]
+	}
+}
+
+func (c *consoleLog) lines() []string {
+	if c == nil {
+		return nil
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return append([]string{}, c.list...)
+}
+
+// collectConsole keeps the recent console messages of the page for the artifacts
+func (p *Page) collectConsole() {
+	p.console = &consoleLog{}
+
+	go p.EachEvent(func(e *proto.RuntimeConsoleAPICalled) {
+		args := []string{}
+		for _, arg := range e.Args {
+			if arg.ObjectID == "" {
+				args = append(args, arg.Value.String())
+			} else {
+				args = append(args, arg.Description)
+			}
+		}
+		p.console.add(fmt.Sprintf("%s [%s] %s", time.UnixMilli(int64(e.Timestamp)).Format(time.RFC3339Nano), e.Type, strings.Join(args, " ")))
+	})()
+}
@@ -0,0 +1,65 @@
+package rod_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Fromsko/rodPro"
+	"github.com/Fromsko/rodPro/lib/proto"
+	"github.com/Fromsko/rodPro/lib/utils"
+)
+
+func TestArtifacts(t *testing.T) {
+	g := setup(t)
+
+	dir := t.TempDir()
+	g.browser.Artifacts(dir)
+	defer g.browser.Artifacts("")
+
+	p := g.newPage(g.srcFile("fixtures/click.html"))
+
+	wait := p.WaitEvent(&proto.RuntimeConsoleAPICalled{})
+	p.MustEval(`() => console.log("hello", 1)`)
+	wait()
+	utils.Sleep(0.1)
+
+	err := rod.Capture(errors.New("boom"), p)
+	g.Is(err, &rod.ErrArtifacts{})
+
+	var e *rod.ErrArtifacts
+	g.True(errors.As(err, &e))
+	g.Eq(e.Err.Error(), "boom")
+	g.Has(err.Error(), "boom (artifacts: "+dir)
+
+	g.Eq(g.Read(filepath.Join(e.Dir, "error.txt")).String(), "boom")
+	g.Has(g.Read(filepath.Join(e.Dir, "page.html")).String(), "<button")
+	g.Has(g.Read(filepath.Join(e.Dir, "console.log")).String(), "[log] hello 1")
+	g.Gt(g.Read(filepath.Join(e.Dir, "screenshot.png")).Len(), 0)
+
+	g.Panic(func() {
+		p.Sleeper(rod.NotFoundSleeper).MustElement("not-exists")
+	})
+	list, _ := os.ReadDir(dir)
+	g.Len(list, 2)
+
+	// the page created before the dir is set doesn't save the artifacts on failures
+	g.Panic(func() {
+		g.page.Sleeper(rod.NotFoundSleeper).MustElement("not-exists")
+	})
+	list, _ = os.ReadDir(dir)
+	g.Len(list, 2)
+
+	g.Nil(rod.Capture(nil, p))
+
+	g.browser.Artifacts("")
+	g.Eq(rod.Capture(errors.New("boom"), p).Error(), "boom")
+	_, err = p.CaptureArtifacts(nil)
+	g.Err(err)
+
+	g.browser.Artifacts(filepath.Join(dir, "error.txt"))
+	g.mc.stubErr(1, proto.PageGetLayoutMetrics{})
+	_, err = p.CaptureArtifacts(nil)
+	g.Err(err)
+}
@@ -0,0 +1,116 @@
+// This file serves for checking how the page looks like a bot to the headless detection scripts.
+
+package rod
+
+// BotCheck is a check of [Page.BotScore]
+type BotCheck struct {
+	// Name of the check, such as "webdriver" or "ua-ch-platform"
+	Name string
+
+	Passed bool
+
+	// Detail is the detected value that makes the check pass or fail
+	Detail string
+}
+
+// BotReport of [Page.BotScore]
+type BotReport struct {
+	Checks []*BotCheck
+
+	// Score is the ratio of the passed checks from 0 to 1, 1 means no known headless signal is detected
+	Score float64
+}
+
+// Failed returns the checks that failed
+func (r *BotReport) Failed() []*BotCheck {
+	list := []*BotCheck{}
+	for _, c := range r.Checks {
+		if !c.Passed {
+			list = append(list, c)
+		}
+	}
+	return list
+}
+
+// BotScore runs a battery of the well-known headless detection checks on the page, so that you can verify
+// the stealth configuration, such as the flags of the launcher or the scripts of [Page.EvalOnNewDocument],
+// before visiting the real targets. The checks are:
+//
+//	webdriver       navigator.webdriver is true
+//	headless-ua     the user agent contains "HeadlessChrome"
+//	plugins         navigator.plugins is empty
+//	languages       navigator.languages is empty
+//	chrome-object   the user agent claims Chrome but window.chrome is missing
+//	permissions     Notification.permission is "denied" while the permission query says "prompt"
+//	ua-ch-brands    navigator.userAgentData brands contain "HeadlessChrome"
+//	ua-ch-platform  navigator.userAgentData platform mismatches the platform of the user agent
+//	window-size     the outer size of the window is zero
+//	webgl           the WebGL renderer is a software one, such as SwiftShader
+//	cdp-console     the console serializes the error objects, which means the Runtime domain is observed
+func (p *Page) BotScore() (*BotReport, error) {
+	res, err := p.Eval(`async () => {
+		const list = []
+		const check = (name, passed, detail) => list.push({ name, passed, detail: String(detail) })
+		const ua = navigator.userAgent
+
+		check('webdriver', navigator.webdriver !== true, navigator.webdriver)
+		check('headless-ua', !/HeadlessChrome/.test(ua), ua)
+		check('plugins', navigator.plugins.length > 0, navigator.plugins.length)
+		check('languages', !!navigator.languages && navigator.languages.length > 0, navigator.languages)
+		check('chrome-object', !/Chrome/.test(ua) || !!window.chrome, typeof window.chrome)
+
+		try {
+			const { state } = await navigator.permissions.query({ name: 'notifications' })
+			check('permissions', !(Notification.permission === 'denied' && state === 'prompt'), Notification.permission + ',' + state)
+		} catch (e) {
+			check('permissions', false, e)
+		}
+
+		const data = navigator.userAgentData
+		if (data) {
+			const brands = data.brands.map((b) => b.brand).join(',')
+			check('ua-ch-brands', !/HeadlessChrome/.test(brands), brands)
+
+			const platforms = { Windows: /Windows/, macOS: /Mac OS X|Macintosh/, Linux: /Linux|X11/, Android: /Android/, 'Chrome OS': /CrOS/ }
+			const reg = platforms[data.platform]
+			check('ua-ch-platform', !reg || (reg.test(ua) && !(data.platform === 'Linux' && /Android/.test(ua))), data.platform)
+		}
+
+		check('window-size', window.outerWidth > 0 && window.outerHeight > 0, window.outerWidth + 'x' + window.outerHeight)
+
+		const gl = document.createElement('canvas').getContext('webgl')
+		const info = gl && gl.getExtension('WEBGL_debug_renderer_info')
+		list.push({ name: 'webgl', renderer: gl ? gl.getParameter(info ? info.UNMASKED_RENDERER_WEBGL : gl.RENDERER) : '' })
+
+		let serialized = false
+		const err = new Error()
+		Object.defineProperty(err, 'stack', { get: () => { serialized = true; return '' } })
+		console.debug(err)
+		check('cdp-console', !serialized, serialized)
+
+		return list
+	}`)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &BotReport{Checks: []*BotCheck{}}
+	passed := 0
+	for _, c := range res.Value.Arr() {
+		check := &BotCheck{Name: c.Get("name").Str(), Passed: c.Get("passed").Bool(), Detail: c.Get("detail").Str()}
+
+		if check.Name == "webgl" {
+			renderer := c.Get("renderer").Str()
+			check.Passed = renderer != "" && !regSoftwareGL.MatchString(renderer)
+			check.Detail = renderer
+		}
+
+		if check.Passed {
+			passed++
+		}
+		report.Checks = append(report.Checks, check)
+	}
+	report.Score = float64(passed) / float64(len(report.Checks))
+
+	return report, nil
+}
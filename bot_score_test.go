@@ -0,0 +1,42 @@
+package rod_test
+
+import (
+	"testing"
+
+	"github.com/Fromsko/rodPro"
+	"github.com/Fromsko/rodPro/lib/proto"
+)
+
+func TestPageBotScore(t *testing.T) {
+	g := setup(t)
+
+	p := g.newPage(g.blank())
+
+	find := func(r *rod.BotReport, name string) *rod.BotCheck {
+		for _, c := range r.Checks {
+			if c.Name == name {
+				return c
+			}
+		}
+		return nil
+	}
+
+	r := p.MustBotScore()
+	g.Gte(len(r.Checks), 10)
+	g.Gt(r.Score, 0.0)
+	g.Lte(r.Score, 1.0)
+	g.Eq(find(r, "webdriver").Passed, !p.MustEval(`() => navigator.webdriver`).Bool())
+	g.Eq(r.Score, float64(len(r.Checks)-len(r.Failed()))/float64(len(r.Checks)))
+
+	p.MustEvalOnNewDocument(`Object.defineProperty(Navigator.prototype, 'webdriver', { get: () => false })`)
+	p.MustEvalOnNewDocument(`Object.defineProperty(Navigator.prototype, 'userAgent', { get: () => 'HeadlessChrome' })`)
+	p.MustReload()
+
+	r = p.MustBotScore()
+	g.True(find(r, "webdriver").Passed)
+	g.False(find(r, "headless-ua").Passed)
+	g.Eq(find(r, "headless-ua").Detail, "HeadlessChrome")
+
+	g.mc.stubErr(1, proto.RuntimeCallFunctionOn{})
+	g.Err(p.BotScore())
+}
@@ -1,5 +1,5 @@
 //go:generate go run ./lib/utils/setup
-//go:generate go run ./lib/proto/generate
+//go:generate go run ./cmd/protogen
 //go:generate go run ./lib/js/generate
 //go:generate go run ./lib/assets/generate
 //go:generate go run ./lib/utils/lint
@@ -9,6 +9,7 @@ package rod
 
 import (
 	"context"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"sync"
@@ -46,14 +47,26 @@ type Browser struct {
 	logger utils.Logger
 
 	slowMotion time.Duration // see defaults.slow
-	trace      bool          // see defaults.Trace
+	slowFor    map[string]time.Duration
+	slowFunc   func(action string) time.Duration
+	trace      bool // see defaults.Trace
 	monitor    string
+	instrument *instrumentation
+	metrics    *Metrics
+	timeline   *Timeline
+	artifacts  string
+	polite     *politeness
+	challenge  *challengeHooks
 
 	defaultDevice devices.Device
 
 	controlURL  string
+	wsURL       string // the resolved websocket url of the browser, empty if the client is set directly
 	client      CDPClient
 	event       *goob.Observable // all the browser events from cdp client
+	history     *eventHistory    // the recent events for replaying
+	demands     *eventDemands    // see [Page.FilterEvents]
+	eventBuffer *eventBuffer     // see [Browser.EventBuffer]
 	targetsLock *sync.Mutex
 
 	// stores all the previous cdp call of same type. Browser doesn't have enough API
@@ -78,6 +91,9 @@ func New() *Browser {
 		defaultDevice: devices.LaptopWithMDPIScreen.Landscape(),
 		targetsLock:   &sync.Mutex{},
 		states:        &sync.Map{},
+		history:       newEventHistory(),
+		demands:       newEventDemands(),
+		polite:        newPoliteness(),
 	}).WithPanic(utils.Panic)
 }
 
@@ -100,12 +116,57 @@ func (b *Browser) ControlURL(url string) *Browser {
 	return b
 }
 
-// SlowMotion set the delay for each control action, such as the simulation of the human inputs
+// SlowMotion set the delay for each control action, such as the simulation of the human inputs.
+// Use [Browser.SlowMotionFor] to set the delays of the other categories.
 func (b *Browser) SlowMotion(delay time.Duration) *Browser {
 	b.slowMotion = delay
 	return b
 }
 
+// The categories of the actions for [Browser.SlowMotionFor]
+const (
+	SlowMotionInput      = "input"
+	SlowMotionNavigation = "navigation"
+	SlowMotionEval       = "eval"
+	SlowMotionWait       = "wait"
+)
+
+// SlowMotionFor set the delay for the category of actions, such as [SlowMotionNavigation].
+// It overrides the [Browser.SlowMotion] for the [SlowMotionInput].
+func (b *Browser) SlowMotionFor(category string, delay time.Duration) *Browser {
+	list := map[string]time.Duration{}
+	for k, v := range b.slowFor {
+		list[k] = v
+	}
+	list[category] = delay
+	b.slowFor = list
+	return b
+}
+
+// SlowMotionFunc set the function to decide the delay of each action, it overrides the
+// [Browser.SlowMotion] and [Browser.SlowMotionFor]. The action is the category and the name
+// joined by a dot, such as:
+//
+//	input.click, input.tap, input.key, input.insert_text, input.move, input.scroll,
+//	input.select, input.select_text, input.set_files,
+//	navigation.navigate, navigation.back, navigation.forward, navigation.reload,
+//	eval.eval, wait.wait, wait.load
+//
+// So a demo can slow the clicks only:
+//
+//	browser.SlowMotionFunc(func(action string) time.Duration {
+//		if action == "input.click" {
+//			return time.Second
+//		}
+//		return 0
+//	})
+//
+// Set it to nil to disable.
+func (b *Browser) SlowMotionFunc(fn func(action string) time.Duration) *Browser {
+	b.slowFunc = fn
+	return b
+}
+
 // Trace enables/disables the visual tracing of the input actions on the page
 func (b *Browser) Trace(enable bool) *Browser {
 	b.trace = enable
@@ -161,6 +222,7 @@ func (b *Browser) Connect() error {
 			return err
 		}
 		b.client = c
+		b.wsURL = u
 	} else if b.controlURL != "" {
 		panic("Browser.Client and Browser.ControlURL can't be set at the same time")
 	}
@@ -238,15 +300,26 @@ func (b *Browser) Pages() (Pages, error) {
 
 // Call implements the [proto.Client] to call raw cdp interface directly.
 func (b *Browser) Call(ctx context.Context, sessionID, methodName string, params interface{}) (res []byte, err error) {
+	ctx, end := b.startCDPSpan(ctx, sessionID, methodName)
+	start := time.Now()
 	res, err = b.client.Call(ctx, sessionID, methodName, params)
+	b.metrics.cdpCall(time.Since(start), err)
+	end(err)
 	if err != nil {
-		return nil, err
+		return nil, unsupported(methodName, err)
 	}
 
 	b.set(proto.TargetSessionID(sessionID), methodName, params)
 	return
 }
 
+// CallRaw calls the cdp method on the browser level with the context of the browser, the response will be decoded
+// into the result if it's not nil. It's useful to use the protocol methods that are not in the lib/proto yet.
+// If the browser doesn't have the method, [ErrUnsupportedCapability] will be returned.
+func (b *Browser) CallRaw(method string, params, result interface{}) error {
+	return callRaw(b.ctx, b, "", method, params, result)
+}
+
 // PageFromSession is used for low-level debugging
 func (b *Browser) PageFromSession(sessionID proto.TargetSessionID) *Page {
 	sessionCtx, cancel := context.WithCancel(b.ctx)
@@ -257,6 +330,11 @@ func (b *Browser) PageFromSession(sessionID proto.TargetSessionID) *Page {
 		sleeper:       b.sleeper,
 		browser:       b,
 		SessionID:     sessionID,
+		scripts:       &newDocumentScripts{},
+		handles:       &handleRegistry{},
+		scopedHeaders: &scopedHeaders{},
+		polyfill:      &selectorPolyfillState{},
+		challenged:    &challengeLoaders{},
 	}
 }
 
@@ -292,10 +370,15 @@ func (b *Browser) PageFromTarget(targetID proto.TargetTargetID) (*Page, error) {
 		jsCtxLock:     &sync.Mutex{},
 		jsCtxID:       new(proto.RuntimeRemoteObjectID),
 		helpersLock:   &sync.Mutex{},
+		scripts:       &newDocumentScripts{},
+		handles:       &handleRegistry{},
+		scopedHeaders: &scopedHeaders{},
+		polyfill:      &selectorPolyfillState{},
+		challenged:    &challengeLoaders{},
 	}
 
 	page.root = page
-	page.newKeyboard().newMouse().newTouch()
+	page.newKeyboard().newMouse().newTouch().newPen()
 
 	if !b.defaultDevice.IsClear() {
 		err = page.Emulate(b.defaultDevice)
@@ -305,9 +388,15 @@ func (b *Browser) PageFromTarget(targetID proto.TargetTargetID) (*Page, error) {
 	}
 
 	b.cachePage(page)
+	b.metrics.pageOpened()
 
 	page.initEvents()
 
+	if b.artifacts != "" {
+		page.e = page.withArtifacts(b.e, b.artifacts)
+		page.collectConsole()
+	}
+
 	// If we don't enable it, it will cause a lot of unexpected browser behavior.
 	// Such as proto.PageAddScriptToEvaluateOnNewDocument won't work.
 	page.EnableDomain(&proto.PageEnable{})
@@ -372,7 +461,12 @@ func (b *Browser) eachEvent(sessionID proto.TargetSessionID, callbacks ...interf
 	}
 
 	b, cancel := b.WithCancel()
-	messages := b.Event()
+	methods := []string{}
+	for name := range cbMap {
+		methods = append(methods, name)
+	}
+	b.demand(b.ctx, &eventDemand{sessionID: sessionID, methods: methods})
+	messages := b.events()
 
 	return func() {
 		if messages == nil {
@@ -412,27 +506,14 @@ func (b *Browser) eachEvent(sessionID proto.TargetSessionID, callbacks ...interf
 
 // Event of the browser
 func (b *Browser) Event() <-chan *Message {
-	src := b.event.Subscribe(b.ctx)
-	dst := make(chan *Message)
-	go func() {
-		defer close(dst)
-		for {
-			select {
-			case <-b.ctx.Done():
-				return
-			case e, ok := <-src:
-				if !ok {
-					return
-				}
-				select {
-				case <-b.ctx.Done():
-					return
-				case dst <- e.(*Message):
-				}
-			}
-		}
-	}()
-	return dst
+	b.demand(b.ctx, &eventDemand{})
+	return b.events()
+}
+
+// events subscribes the browser events without telling what the subscriber needs, so it's only used internally
+// by the subscribers that have registered their demands or that don't rely on the filtered events
+func (b *Browser) events() <-chan *Message {
+	return b.forwardEvents(b.ctx, b.event.Subscribe(b.ctx), nil)
 }
 
 func (b *Browser) initEvents() {
@@ -443,12 +524,17 @@ func (b *Browser) initEvents() {
 	go func() {
 		defer cancel()
 		for e := range event {
-			b.event.Publish(&Message{
+			msg := &Message{
 				SessionID: proto.TargetSessionID(e.SessionID),
 				Method:    e.Method,
 				lock:      &sync.Mutex{},
 				data:      e.Params,
-			})
+			}
+			b.history.add(msg)
+			if msg.Method == (proto.TargetTargetCrashed{}).ProtoEvent() {
+				b.metrics.crashed()
+			}
+			b.event.Publish(msg)
 		}
 	}()
 }
@@ -462,13 +548,47 @@ func (b *Browser) pageInfo(id proto.TargetTargetID) (*proto.TargetTargetInfo, er
 }
 
 func (b *Browser) isHeadless() (enabled bool) {
-	res, _ := proto.BrowserGetBrowserCommandLine{}.Call(b)
-	for _, v := range res.Arguments {
-		if strings.Contains(v, "headless") {
-			return true
+	mode, _ := b.HeadlessMode()
+	return mode != launcher.HeadlessFalse
+}
+
+// HeadlessMode detects the headless mode of the running browser.
+// Some emulation features behave differently between the modes.
+func (b *Browser) HeadlessMode() (launcher.HeadlessType, error) {
+	res, err := proto.BrowserGetBrowserCommandLine{}.Call(b)
+	if err != nil {
+		return launcher.HeadlessFalse, err
+	}
+
+	if len(res.Arguments) > 0 {
+		name := strings.ToLower(filepath.Base(res.Arguments[0]))
+		if strings.Contains(name, "headless-shell") || strings.Contains(name, "headless_shell") {
+			return launcher.HeadlessShell, nil
+		}
+	}
+
+	plain := false
+	for _, arg := range res.Arguments {
+		if arg == "--headless" {
+			plain = true
+		} else if strings.HasPrefix(arg, "--headless=") {
+			return launcher.HeadlessType(strings.TrimPrefix(arg, "--headless=")), nil
 		}
 	}
-	return false
+
+	if !plain {
+		return launcher.HeadlessFalse, nil
+	}
+
+	// the flag without value means the new mode since Chrome 132
+	ver, err := b.Version()
+	if err != nil {
+		return launcher.HeadlessFalse, err
+	}
+	if majorVersion(ver.Product) >= 132 {
+		return launcher.HeadlessNew, nil
+	}
+	return launcher.HeadlessOld, nil
 }
 
 // IgnoreCertErrors switch. If enabled, all certificate errors will be ignored.
@@ -476,6 +596,103 @@ func (b *Browser) IgnoreCertErrors(enable bool) error {
 	return proto.SecuritySetIgnoreCertificateErrors{Ignore: enable}.Call(b)
 }
 
+// InjectOnEveryDocument evaluates the js in every new document of the browser before the document's own scripts,
+// including the documents of the existing pages, new pages, popups, out-of-process iframes, and workers.
+// New targets are paused until the js is injected, so even the initial about:blank document of a popup is covered.
+// Call remove to stop injecting.
+func (b *Browser) InjectOnEveryDocument(js string) (remove func() error, err error) {
+	pages, err := b.Pages()
+	if err != nil {
+		return
+	}
+
+	removes := []func() error{}
+	for _, p := range pages {
+		r, err := p.EvalOnNewDocument(js)
+		if err != nil {
+			return nil, err
+		}
+		removes = append(removes, r)
+	}
+
+	live := b
+	b, cancel := b.WithCancel()
+
+	autoAttach := proto.TargetSetAutoAttach{
+		AutoAttach:             true,
+		WaitForDebuggerOnStart: true,
+		Flatten:                true,
+	}
+
+	injected := &injectedScripts{list: map[proto.TargetSessionID]proto.PageScriptIdentifier{}}
+
+	wait := b.eachEvent("", func(e *proto.TargetAttachedToTarget) {
+		if e.WaitingForDebugger {
+			go b.injectTarget(e, js, autoAttach, injected)
+		}
+	})
+
+	err = autoAttach.Call(b)
+	if err != nil {
+		cancel()
+		return
+	}
+
+	go wait()
+
+	remove = func() error {
+		// the cleanup must be sent with the live context, the cancel only stops the injection of new targets
+		defer cancel()
+
+		injected.lock.Lock()
+		for sessionID, id := range injected.list {
+			_ = proto.PageRemoveScriptToEvaluateOnNewDocument{Identifier: id}.Call(live.PageFromSession(sessionID))
+		}
+		injected.list = map[proto.TargetSessionID]proto.PageScriptIdentifier{}
+		injected.lock.Unlock()
+
+		// auto-attached sessions will be detached after auto-attach is disabled
+		err := proto.TargetSetAutoAttach{}.Call(live)
+		for _, r := range removes {
+			if e := r(); err == nil {
+				err = e
+			}
+		}
+		return err
+	}
+
+	return
+}
+
+// injectedScripts of [Browser.InjectOnEveryDocument] for the auto-attached targets
+type injectedScripts struct {
+	lock sync.Mutex
+	list map[proto.TargetSessionID]proto.PageScriptIdentifier
+}
+
+func (b *Browser) injectTarget(e *proto.TargetAttachedToTarget, js string, autoAttach proto.TargetSetAutoAttach, injected *injectedScripts) {
+	p := b.PageFromSession(e.SessionID)
+	defer p.sessionCancel()
+
+	switch e.TargetInfo.Type {
+	case proto.TargetTargetInfoTypePage, "iframe":
+		res, err := proto.PageAddScriptToEvaluateOnNewDocument{Source: js}.Call(p)
+		if err == nil {
+			injected.lock.Lock()
+			injected.list[e.SessionID] = res.Identifier
+			injected.lock.Unlock()
+		}
+
+		// attach the out-of-process iframes and workers of the target
+		_ = autoAttach.Call(p)
+	}
+
+	// the target is paused before its initial document or worker script runs
+	_, _ = proto.RuntimeEvaluate{Expression: js}.Call(p)
+
+	_ = proto.RuntimeRunIfWaitingForDebugger{}.Call(p)
+}
+
 // GetCookies from the browser
 func (b *Browser) GetCookies() ([]*proto.NetworkCookie, error) {
 	res, err := proto.StorageGetCookies{BrowserContextID: b.BrowserContextID}.Call(b)
@@ -497,6 +714,83 @@ func (b *Browser) SetCookies(cookies []*proto.NetworkCookieParam) error {
 	}.Call(b)
 }
 
+// ClearData of the origin in the browser context, such as to reset the state of the app between the test cases
+// without recreating the incognito context. The origin is like "https://example.com". If no type is set,
+// all types of the data will be cleared, such as the cookies, local storage, indexeddb, and cache storage.
+func (b *Browser) ClearData(origin string, types ...proto.StorageStorageType) error {
+	list := []string{}
+	for _, t := range types {
+		list = append(list, string(t))
+	}
+	if len(list) == 0 {
+		list = append(list, string(proto.StorageStorageTypeAll))
+	}
+
+	return b.withStorage(func(c proto.Client) error {
+		return proto.StorageClearDataForOrigin{Origin: origin, StorageTypes: strings.Join(list, ",")}.Call(c)
+	})
+}
+
+// StorageUsage of the origin in the browser context, it includes the usage breakdown of each storage type
+func (b *Browser) StorageUsage(origin string) (res *proto.StorageGetUsageAndQuotaResult, err error) {
+	err = b.withStorage(func(c proto.Client) error {
+		res, err = proto.StorageGetUsageAndQuota{Origin: origin}.Call(c)
+		return err
+	})
+	return
+}
+
+// withStorage calls the Storage domain of the browser context, the Storage domain of the browser target
+// only works for the default context, so a page of the incognito context will be used.
+func (b *Browser) withStorage(fn func(c proto.Client) error) error {
+	if b.BrowserContextID == "" {
+		return fn(b)
+	}
+	return b.withPage(func(p *Page) error { return fn(p) })
+}
+
+// withPage calls fn with a page of the browser context, if there's none a blank page will be
+// created and closed after fn returns.
+func (b *Browser) withPage(fn func(p *Page) error) error {
+	list, err := proto.TargetGetTargets{}.Call(b)
+	if err != nil {
+		return err
+	}
+
+	// the targets of the default context may not have the context id
+	others := map[proto.BrowserBrowserContextID]bool{}
+	if b.BrowserContextID == "" {
+		res, err := proto.TargetGetBrowserContexts{}.Call(b)
+		if err != nil {
+			return err
+		}
+		for _, id := range res.BrowserContextIds {
+			others[id] = true
+		}
+	}
+
+	for _, t := range list.TargetInfos {
+		if t.Type != proto.TargetTargetInfoTypePage {
+			continue
+		}
+		if t.BrowserContextID == b.BrowserContextID || (b.BrowserContextID == "" && !others[t.BrowserContextID]) {
+			p, err := b.PageFromTarget(t.TargetID)
+			if err != nil {
+				return err
+			}
+			return fn(p)
+		}
+	}
+
+	p, err := b.Page(proto.TargetCreateTarget{})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = p.Close() }()
+
+	return fn(p)
+}
+
 // WaitDownload returns a helper to get the next download file.
 // The file path will be:
 //
@@ -1,17 +1,24 @@
 package rod_test
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"runtime"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/Fromsko/rodPro"
 	"github.com/Fromsko/rodPro/lib/cdp"
+	"github.com/Fromsko/rodPro/lib/defaults"
 	"github.com/Fromsko/rodPro/lib/devices"
 	"github.com/Fromsko/rodPro/lib/launcher"
 	"github.com/Fromsko/rodPro/lib/proto"
@@ -144,6 +151,63 @@ func TestBrowserEvent(t *testing.T) {
 	<-wait
 }
 
+func TestBrowserSubscribe(t *testing.T) {
+	g := setup(t)
+
+	g.browser.EventReplay(100)
+	defer g.browser.EventReplay(0)
+
+	p := g.newPage()
+	p.MustNavigate(g.blank()).MustWaitLoad()
+
+	ctx, cancel := context.WithCancel(g.Context())
+
+	// the load event fired before the subscription is replayed
+	pageEvents := rod.SubscribePage[*proto.PageLoadEventFired](ctx, p, 1)
+	g.Gt((<-pageEvents).Timestamp, 0)
+
+	events := rod.Subscribe[*proto.PageLoadEventFired](ctx, g.browser, 0)
+	values := rod.Subscribe[proto.PageLoadEventFired](ctx, g.browser, 0)
+
+	go p.MustReload()
+
+	g.Gt((<-pageEvents).Timestamp, 0)
+	g.Gt((<-events).Timestamp, 0)
+	g.Gt((<-values).Timestamp, 0)
+
+	cancel()
+	for range events {
+		utils.Noop()
+	}
+}
+
+func TestBrowserEventBuffer(t *testing.T) {
+	g := setup(t)
+
+	p := g.newPage()
+	p.MustNavigate(g.blank()).MustWaitLoad()
+	defer p.EnableDomain(&proto.RuntimeEnable{})()
+
+	dropped := make(chan string, 100)
+	b := g.browser.Context(g.Context()).EventBuffer(1, cdp.EventDropNewest, func(msg *rod.Message) {
+		select {
+		case dropped <- msg.Method:
+		default:
+		}
+	})
+
+	// the subscriber never reads, so the events after the first one overflow
+	_ = b.Event()
+
+	p.MustEval(`() => { for (let i = 0; i < 10; i++) console.log(i) }`)
+
+	for method := range dropped {
+		if method == "Runtime.consoleAPICalled" {
+			break
+		}
+	}
+}
+
 func TestBrowserWaitEvent(t *testing.T) {
 	g := setup(t)
 
@@ -181,6 +245,61 @@ func TestBrowserCrash(t *testing.T) {
 	g.Has(err.Error(), "use of closed network connection")
 }
 
+func TestBrowserLifecycleHooks(t *testing.T) {
+	g := setup(t)
+
+	b := g.browser.MustIncognito()
+	defer b.MustClose()
+
+	created := make(chan *rod.Page, 10)
+	destroyed := make(chan proto.TargetTargetID, 10)
+	crashed := make(chan *rod.Page, 10)
+
+	defer b.OnPageCreated(func(p *rod.Page) { created <- p })()
+	defer b.OnPageDestroyed(func(id proto.TargetTargetID, _ *rod.Page) { destroyed <- id })()
+	defer b.OnCrash(func(p *rod.Page, _ *proto.TargetTargetCrashed) { crashed <- p })()
+
+	p := b.MustPage(g.blank())
+
+	wait := p.MustWaitOpen()
+	p.MustEval(`() => window.open('about:blank')`)
+	popup := wait()
+
+	list := []proto.TargetTargetID{(<-created).TargetID, (<-created).TargetID}
+	g.Has(list, p.TargetID)
+	g.Has(list, popup.TargetID)
+
+	popup.MustClose()
+	g.Eq(<-destroyed, popup.TargetID)
+
+	// the crash of the page from another browser context is ignored
+	other := g.newPage()
+	otherCrashed := g.browser.EachEvent(func(e *proto.TargetTargetCrashed) bool {
+		return e.TargetID == other.TargetID
+	})
+	_ = other.Navigate("chrome://crash")
+	otherCrashed()
+
+	_ = p.Navigate("chrome://crash")
+	g.Eq((<-crashed).TargetID, p.TargetID)
+}
+
+func TestBrowserHeadlessMode(t *testing.T) {
+	g := setup(t)
+
+	mode, err := g.browser.HeadlessMode()
+	g.E(err)
+
+	if defaults.Show {
+		g.Eq(mode, launcher.HeadlessFalse)
+	} else {
+		g.Eq(mode, launcher.HeadlessNew)
+	}
+
+	g.mc.stubErr(1, proto.BrowserGetBrowserCommandLine{})
+	g.Err(g.browser.HeadlessMode())
+}
+
 func TestBrowserCall(t *testing.T) {
 	g := setup(t)
 
@@ -190,6 +309,62 @@ func TestBrowserCall(t *testing.T) {
 	g.Regex("1.3", v.ProtocolVersion)
 }
 
+func TestBrowserCallRaw(t *testing.T) {
+	g := setup(t)
+
+	var v struct {
+		ProtocolVersion string `json:"protocolVersion"`
+	}
+	g.browser.MustCallRaw("Browser.getVersion", nil, &v)
+	g.Regex("1.3", v.ProtocolVersion)
+
+	g.E(g.browser.CallRaw("Browser.getVersion", nil, nil))
+	err := g.browser.CallRaw("Browser.notExists", nil, nil)
+	g.Is(err, &rod.ErrUnsupportedCapability{})
+	g.Eq(err.Error(), "unsupported capability: Browser.notExists")
+	g.Is(err, &cdp.Error{})
+}
+
+func TestBrowserCapabilities(t *testing.T) {
+	g := setup(t)
+
+	c := g.browser.MustCapabilities()
+	g.Regex("1.3", c.ProtocolVersion)
+	g.Gt(c.Major, 0)
+	g.Has(c.UserAgent, "Mozilla")
+	g.True(c.HasDomain("Page"))
+	g.False(c.HasDomain("NotExists"))
+	g.E(c.Require("Page", "Runtime"))
+	g.Is(c.Require("Page", "NotExists"), &rod.ErrUnsupportedCapability{})
+	g.E(c.RequireMajor(c.Major))
+	g.Eq(c.RequireMajor(c.Major+1).Error(), "unsupported capability: "+c.Product+" < "+strconv.Itoa(c.Major+1))
+
+	g.False((&rod.Capabilities{}).HasDomain("Any"))
+	g.Is((&rod.Capabilities{}).Require("Page"), &rod.ErrUnsupportedCapability{})
+
+	// the typed calls report the unsupported methods too
+	g.mc.stub(1, proto.BrowserGetVersion{}, func(send StubSend) (gson.JSON, error) {
+		return gson.New(nil), &cdp.Error{Code: -32601, Message: "'Browser.getVersion' wasn't found"}
+	})
+	_, err := g.browser.Version()
+	g.Is(err, &rod.ErrUnsupportedCapability{})
+
+	g.mc.stubErr(1, proto.BrowserGetVersion{})
+	g.Err(g.browser.Capabilities())
+
+	g.mc.stub(1, proto.SchemaGetDomains{}, func(send StubSend) (gson.JSON, error) {
+		g.mc.stubErr(1, proto.TargetGetTargets{})
+		return gson.New(nil), errors.New("err")
+	})
+	g.Err(g.browser.Capabilities())
+
+	g.mc.stub(1, proto.SchemaGetDomains{}, func(send StubSend) (gson.JSON, error) {
+		g.mc.stubErr(1, proto.SchemaGetDomains{})
+		return gson.New(nil), errors.New("err")
+	})
+	g.Err(g.browser.Capabilities())
+}
+
 func TestBlockingNavigation(t *testing.T) {
 	g := setup(t)
 
@@ -316,6 +491,42 @@ func TestBrowserCookies(t *testing.T) {
 	g.Err(b.GetCookies())
 }
 
+func TestBrowserInjectOnEveryDocument(t *testing.T) {
+	g := setup(t)
+
+	s := g.Serve()
+	s.Route("/", ".html", `<html><head><script>window.seen = window.injected</script></head></html>`)
+
+	b := g.browser.MustIncognito()
+	defer b.MustClose()
+
+	p := b.MustPage()
+
+	remove := b.MustInjectOnEveryDocument(`window.injected = 'ok'`)
+
+	p.MustNavigate(s.URL()).MustWaitLoad()
+	g.Eq(p.MustEval(`() => window.seen`).String(), "ok")
+
+	p2 := b.MustPage(s.URL()).MustWaitLoad()
+	g.Eq(p2.MustEval(`() => window.seen`).String(), "ok")
+
+	wait := p2.MustWaitOpen()
+	p2.MustEval(`() => window.open('about:blank')`)
+	popup := wait()
+	defer popup.MustClose()
+	g.Eq(popup.MustEval(`() => window.injected`).String(), "ok")
+
+	remove()
+	p.MustReload().MustWaitLoad()
+	g.True(p.MustEval(`() => window.seen`).Nil())
+
+	g.mc.stubErr(1, proto.TargetGetTargets{})
+	g.Err(b.InjectOnEveryDocument(""))
+
+	g.mc.stubErr(1, proto.TargetSetAutoAttach{})
+	g.Err(b.InjectOnEveryDocument(""))
+}
+
 func TestWaitDownload(t *testing.T) {
 	g := setup(t)
 
@@ -428,6 +639,25 @@ func TestStreamReader(t *testing.T) {
 	g.Err(err)
 }
 
+func TestStreamReaderWriteTo(t *testing.T) {
+	g := setup(t)
+
+	r := rod.NewStreamReader(g.page, "")
+
+	g.mc.stub(1, proto.IORead{}, func(send StubSend) (gson.JSON, error) {
+		return gson.New(proto.IOReadResult{
+			Base64Encoded: true,
+			Data:          "dGVzdA==",
+			EOF:           true,
+		}), nil
+	})
+	buf := bytes.NewBuffer(nil)
+	n, err := io.Copy(buf, r)
+	g.E(err)
+	g.Eq(n, 4)
+	g.Eq(buf.String(), "test")
+}
+
 func TestBrowserConnectFailure(t *testing.T) {
 	g := setup(t)
 
@@ -482,3 +712,108 @@ func TestBrowserConnectConflict(t *testing.T) {
 		rod.New().Client(&cdp.Client{}).ControlURL("test").MustConnect()
 	})
 }
+
+func TestSession(t *testing.T) {
+	g := setup(t)
+
+	path := filepath.Join(t.TempDir(), "session.json")
+
+	b := rod.New().MustConnect()
+
+	srv := g.Serve().Route("/", ".html", `<html></html>`)
+
+	p := b.MustPage(srv.URL()).MustWaitLoad()
+	p.MustEval(`() => { localStorage.a = '1'; sessionStorage.b = '2' }`)
+
+	b.MustSaveSession(path)
+
+	s, err := rod.LoadSession(path)
+	g.E(err)
+	g.NotZero(s.ControlURL)
+	sp := s.Pages[0]
+	for _, item := range s.Pages {
+		if item.TargetID == p.TargetID {
+			sp = item
+		}
+	}
+	g.Eq(sp.URL, srv.URL("/"))
+	g.Eq(sp.LocalStorage, map[string]string{"a": "1"})
+	g.Eq(sp.SessionStorage, map[string]string{"b": "2"})
+
+	resumed, pages, err := rod.ResumeSession(path)
+	g.E(err)
+	defer resumed.MustClose() // the resumed browser is the same as b
+	g.Len(pages, len(s.Pages))
+	g.Eq(resumed.MustPageFromTargetID(p.TargetID).MustEval(`() => localStorage.a`).Str(), "1")
+
+	// the storages are restored to another browser that has never visited the page
+	other := rod.New().MustConnect()
+	defer other.MustClose()
+	restored := other.MustRestoreSession(s)
+	for i, item := range s.Pages {
+		if item == sp {
+			g.Eq(restored[i].MustEval(`() => localStorage.a`).Str(), "1")
+			g.Eq(restored[i].MustEval(`() => sessionStorage.b`).Str(), "2")
+		}
+	}
+
+	// the closed page is reopened with its storages
+	p.MustClose()
+	pages = b.MustRestoreSession(s)
+	reopened := pages[len(pages)-1]
+	for i, item := range s.Pages {
+		if item == sp {
+			reopened = pages[i]
+		}
+	}
+	g.Neq(reopened.TargetID, p.TargetID)
+	g.Eq(reopened.MustInfo().URL, srv.URL("/"))
+	g.Eq(reopened.MustEval(`() => sessionStorage.b`).Str(), "2")
+	g.Eq(reopened.MustEval(`() => localStorage.a`).Str(), "1")
+
+	_, err = rod.LoadSession(filepath.Join(t.TempDir(), "not-exists"))
+	g.Err(err)
+
+	_, _, err = rod.ResumeSession(filepath.Join(t.TempDir(), "not-exists"))
+	g.Err(err)
+
+	_, err = rod.New().Session()
+	g.Err(err)
+}
+
+func TestBrowserClearData(t *testing.T) {
+	g := setup(t)
+
+	s := g.Serve().Route("/", ".html", `<html></html>`)
+	origin := strings.TrimSuffix(s.URL(), "/")
+
+	b := g.browser.MustIncognito()
+	defer b.MustClose()
+
+	for _, b := range []*rod.Browser{g.browser, b} {
+		p := b.MustPage(s.URL())
+		p.MustEval(`() => { localStorage.setItem('a', '1'); document.cookie = 'b=2' }`)
+
+		g.Gt(b.MustStorageUsage(origin).Quota, 0.0)
+
+		b.MustClearData(origin, proto.StorageStorageTypeLocalStorage)
+		g.True(p.MustEval(`() => localStorage.getItem('a')`).Nil())
+		g.Eq(p.MustEval(`() => document.cookie`).Str(), "b=2")
+
+		b.MustClearData(origin)
+		g.Eq(p.MustEval(`() => document.cookie`).Str(), "")
+
+		p.MustClose()
+	}
+
+	b.MustClearData(origin)
+
+	g.mc.stubErr(1, proto.StorageClearDataForOrigin{})
+	g.Err(g.browser.ClearData(origin))
+
+	g.mc.stubErr(1, proto.TargetGetTargets{})
+	g.Err(b.StorageUsage(origin))
+
+	g.mc.stubErr(1, proto.TargetCreateTarget{})
+	g.Err(b.ClearData(origin))
+}
@@ -0,0 +1,155 @@
+// This file serves for the control of the HTTP cache.
+
+package rod
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/Fromsko/rodPro/lib/proto"
+)
+
+// SetCacheDisabled toggles ignoring the HTTP cache for each request of the page,
+// the Network domain of the page will be enabled.
+func (p *Page) SetCacheDisabled(disabled bool) error {
+	p.EnableDomain(&proto.NetworkEnable{})
+	return proto.NetworkSetCacheDisabled{CacheDisabled: disabled}.Call(p)
+}
+
+// ClearHTTPCache clears the HTTP cache of the browser context
+func (b *Browser) ClearHTTPCache() error {
+	return b.withPage(func(p *Page) error {
+		return proto.NetworkClearBrowserCache{}.Call(p)
+	})
+}
+
+// WarmCache pre-fetches the urls in the page so that the later requests to them can be served from the HTTP cache.
+// The HTTP cache is partitioned by the site of the page, so it only warms the cache for the current site of the page.
+// The responses that aren't cacheable, such as the ones with "Cache-Control: no-store", won't be cached.
+func (p *Page) WarmCache(urls ...string) error {
+	res, err := p.Evaluate(Eval(`urls => Promise.all(urls.map(u =>
+		fetch(u, { mode: 'no-cors', credentials: 'include' })
+			.then(r => r.arrayBuffer())
+			.then(() => '', e => u + ': ' + e.message)
+	))`, urls).ByPromise())
+	if err != nil {
+		return err
+	}
+
+	failed := []string{}
+	for _, e := range res.Value.Arr() {
+		if e.Str() != "" {
+			failed = append(failed, e.Str())
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to warm the cache: %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// CacheSource of a response
+type CacheSource string
+
+const (
+	// CacheSourceNetwork means the response is not from the cache
+	CacheSourceNetwork CacheSource = ""
+
+	// CacheSourceMemory is the memory cache
+	CacheSourceMemory CacheSource = "memory"
+
+	// CacheSourceDisk is the disk cache
+	CacheSourceDisk CacheSource = "disk"
+
+	// CacheSourcePrefetch is the prefetch cache
+	CacheSourcePrefetch CacheSource = "prefetch"
+
+	// CacheSourceServiceWorker is the service worker
+	CacheSourceServiceWorker CacheSource = "service-worker"
+)
+
+// CacheHit is the cache information of a response
+type CacheHit struct {
+	URL    string
+	Source CacheSource
+}
+
+// Hit returns true if the response is not from the network
+func (h *CacheHit) Hit() bool {
+	return h.Source != CacheSourceNetwork
+}
+
+// CacheWatcher records the cache information of the responses, check [Page.WatchCache]
+type CacheWatcher struct {
+	cancel  func()
+	restore func()
+
+	lock   sync.Mutex
+	cached map[proto.NetworkRequestID]bool
+	hits   []*CacheHit
+}
+
+// WatchCache records the cache information of each response of the page via the Network events
+func (p *Page) WatchCache() *CacheWatcher {
+	ctx, cancel := context.WithCancel(p.ctx)
+	w := &CacheWatcher{
+		cancel:  cancel,
+		restore: p.EnableDomain(&proto.NetworkEnable{}),
+		cached:  map[proto.NetworkRequestID]bool{},
+	}
+
+	wait := p.Context(ctx).EachEvent(func(e *proto.NetworkRequestServedFromCache) {
+		w.lock.Lock()
+		defer w.lock.Unlock()
+		w.cached[e.RequestID] = true
+	}, func(e *proto.NetworkResponseReceived) {
+		w.lock.Lock()
+		defer w.lock.Unlock()
+
+		h := &CacheHit{URL: e.Response.URL}
+		switch {
+		case e.Response.FromServiceWorker:
+			h.Source = CacheSourceServiceWorker
+		case e.Response.FromPrefetchCache:
+			h.Source = CacheSourcePrefetch
+		case e.Response.FromDiskCache:
+			h.Source = CacheSourceDisk
+		case w.cached[e.RequestID]:
+			h.Source = CacheSourceMemory
+		}
+		delete(w.cached, e.RequestID)
+
+		w.hits = append(w.hits, h)
+	})
+	go wait()
+
+	return w
+}
+
+// Hits returns the recorded cache information of the responses in order
+func (w *CacheWatcher) Hits() []*CacheHit {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return append([]*CacheHit{}, w.hits...)
+}
+
+// Find returns the last recorded cache information of the url, nil if not found
+func (w *CacheWatcher) Find(url string) *CacheHit {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	for i := len(w.hits) - 1; i >= 0; i-- {
+		if w.hits[i].URL == url {
+			return w.hits[i]
+		}
+	}
+	return nil
+}
+
+// Stop recording
+func (w *CacheWatcher) Stop() {
+	w.cancel()
+	w.restore()
+}
@@ -0,0 +1,148 @@
+// This file serves for the interaction and assertion of the canvas elements.
+
+package rod
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+
+	"github.com/Fromsko/rodPro/lib/proto"
+)
+
+// CanvasImage returns the bitmap of the canvas element. It reads the canvas via toDataURL, if the canvas
+// is tainted by the cross-origin images or the bitmap is fully transparent, such as a WebGL canvas without
+// preserveDrawingBuffer, it falls back to the screenshot of the element, which is what the user sees,
+// the size of it is the css size multiplied by the device pixel ratio rather than the size of the bitmap.
+func (el *Element) CanvasImage() (image.Image, error) {
+	res, err := el.Eval(`() => {
+		try {
+			return this.toDataURL('image/png')
+		} catch {
+			return ''
+		}
+	}`)
+	if err != nil {
+		return nil, err
+	}
+
+	if uri := res.Value.Str(); uri != "" {
+		_, bin := parseDataURI(uri)
+		img, err := png.Decode(bytes.NewReader(bin))
+		if err == nil && !blankImage(img) {
+			return img, nil
+		}
+	}
+
+	bin, err := el.Screenshot(proto.PageCaptureScreenshotFormatPng, 0)
+	if err != nil {
+		return nil, err
+	}
+	return png.Decode(bytes.NewReader(bin))
+}
+
+// CanvasClickAt clicks the point of the canvas, the x and y are the coordinates of the canvas bitmap
+// relative to its top-left corner, they are converted to the page coordinates even if the canvas
+// is scaled by css or has borders and paddings.
+func (el *Element) CanvasClickAt(x, y float64) error {
+	err := el.ScrollIntoView()
+	if err != nil {
+		return err
+	}
+
+	pt, err := el.canvasPoint(x, y)
+	if err != nil {
+		return err
+	}
+
+	defer el.tryTrace(TraceTypeInput, "canvas click")()
+
+	mouse := el.page.Context(el.ctx).Mouse
+
+	err = mouse.MoveTo(*pt)
+	if err != nil {
+		return err
+	}
+	return mouse.Click(proto.InputMouseButtonLeft, 1)
+}
+
+func (el *Element) canvasPoint(x, y float64) (*proto.Point, error) {
+	res, err := el.Eval(`(x, y) => {
+		const r = this.getBoundingClientRect()
+		const s = getComputedStyle(this)
+		const n = (v) => parseFloat(v) || 0
+		const left = n(s.borderLeftWidth) + n(s.paddingLeft)
+		const top = n(s.borderTopWidth) + n(s.paddingTop)
+		const w = r.width - left - n(s.borderRightWidth) - n(s.paddingRight)
+		const h = r.height - top - n(s.borderBottomWidth) - n(s.paddingBottom)
+		return {
+			x: r.left + left + x * w / (this.width || w),
+			y: r.top + top + y * h / (this.height || h),
+		}
+	}`, x, y)
+	if err != nil {
+		return nil, err
+	}
+	return &proto.Point{X: res.Value.Get("x").Num(), Y: res.Value.Get("y").Num()}, nil
+}
+
+// CanvasColorAt returns the color of the pixel at the coordinates of the canvas bitmap.
+// Check [Element.CanvasImage] for how the bitmap is read.
+func (el *Element) CanvasColorAt(x, y int) (color.NRGBA, error) {
+	img, err := el.CanvasImage()
+	if err != nil {
+		return color.NRGBA{}, err
+	}
+
+	// the screenshot fallback may have a different size from the bitmap
+	size, err := el.Eval(`() => ({ w: this.width, h: this.height })`)
+	if err != nil {
+		return color.NRGBA{}, err
+	}
+	b := img.Bounds()
+	if w, h := size.Value.Get("w").Int(), size.Value.Get("h").Int(); w > 0 && h > 0 && (w != b.Dx() || h != b.Dy()) {
+		x, y = x*b.Dx()/w, y*b.Dy()/h
+	}
+
+	return color.NRGBAModel.Convert(img.At(b.Min.X+x, b.Min.Y+y)).(color.NRGBA), nil
+}
+
+// CanvasExpectColorAt returns [ErrCanvasColor] if the color of the pixel at the coordinates of the canvas
+// bitmap doesn't match the expected one, each channel of the color can differ at most by the tolerance,
+// such as to tolerate the antialiasing.
+func (el *Element) CanvasExpectColorAt(x, y int, expected color.Color, tolerance uint8) error {
+	got, err := el.CanvasColorAt(x, y)
+	if err != nil {
+		return err
+	}
+
+	want := color.NRGBAModel.Convert(expected).(color.NRGBA)
+	if !colorNear(got, want, tolerance) {
+		return &ErrCanvasColor{X: x, Y: y, Expected: want, Actual: got}
+	}
+	return nil
+}
+
+func colorNear(a, b color.NRGBA, tolerance uint8) bool {
+	near := func(x, y uint8) bool {
+		if x > y {
+			return x-y <= tolerance
+		}
+		return y-x <= tolerance
+	}
+	return near(a.R, b.R) && near(a.G, b.G) && near(a.B, b.B) && near(a.A, b.A)
+}
+
+// blankImage returns true if all the pixels are fully transparent
+func blankImage(img image.Image) bool {
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if _, _, _, a := img.At(x, y).RGBA(); a != 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,79 @@
+package rod_test
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/Fromsko/rodPro"
+	"github.com/Fromsko/rodPro/lib/proto"
+)
+
+func TestCanvasImage(t *testing.T) {
+	g := setup(t)
+
+	p := g.page.MustNavigate(g.srcFile("fixtures/canvas.html"))
+	el := p.MustElement("#canvas")
+
+	img := el.MustCanvasImage()
+	g.Eq(img.Bounds().Dx(), 300)
+	g.Eq(color.NRGBAModel.Convert(img.At(50, 50)), color.NRGBA{0xFF, 0x00, 0x00, 0xFF})
+
+	g.Eq(el.MustCanvasColorAt(50, 50), color.NRGBA{0xFF, 0x00, 0x00, 0xFF})
+	el.MustCanvasExpectColorAt(50, 50, color.RGBA{0xFA, 0x00, 0x00, 0xFF}, 5)
+
+	err := el.CanvasExpectColorAt(5, 5, color.White, 0)
+	g.Is(err, &rod.ErrCanvasColor{})
+	g.Eq(err.Error(), "expect the canvas color at (5, 5) to be {255 255 255 255}, but got {0 0 0 0}")
+
+	// the blank canvas falls back to the screenshot
+	blank := p.MustElementByJS(`() => {
+		const c = document.createElement('canvas')
+		c.width = 20
+		c.height = 10
+		document.body.prepend(c)
+		return c
+	}`)
+	g.Eq(blank.MustCanvasColorAt(1, 1), color.NRGBA{0xFF, 0xFF, 0xFF, 0xFF})
+
+	g.mc.stubErr(1, proto.RuntimeCallFunctionOn{})
+	g.Err(el.CanvasImage())
+
+	g.mc.stubErr(1, proto.RuntimeCallFunctionOn{})
+	g.Err(el.CanvasColorAt(1, 1))
+
+	g.mc.stubErr(2, proto.RuntimeCallFunctionOn{})
+	g.Err(el.CanvasColorAt(1, 1))
+
+	g.mc.stubErr(1, proto.RuntimeCallFunctionOn{})
+	g.Err(el.CanvasExpectColorAt(1, 1, color.White, 0))
+
+	g.mc.stubErr(1, proto.PageCaptureScreenshot{})
+	g.Err(blank.CanvasImage())
+}
+
+func TestCanvasClickAt(t *testing.T) {
+	g := setup(t)
+
+	p := g.page.MustNavigate(g.srcFile("fixtures/canvas.html"))
+	el := p.MustElement("#canvas")
+	el.MustEval(`() => {
+		this.style = 'width: 600px; height: 300px; border: 5px solid; padding: 3px'
+		this.onclick = e => window.clicked = [
+			Math.round((e.clientX - this.getBoundingClientRect().left - 8) / 2),
+			Math.round((e.clientY - this.getBoundingClientRect().top - 8) / 2),
+		]
+	}`)
+
+	el.MustCanvasClickAt(20, 30)
+	g.Eq(p.MustEval(`() => clicked`).Arr()[0].Int(), 20)
+	g.Eq(p.MustEval(`() => clicked`).Arr()[1].Int(), 30)
+
+	g.mc.stubErr(1, proto.DOMScrollIntoViewIfNeeded{})
+	g.Err(el.CanvasClickAt(1, 1))
+
+	g.mc.stubErr(1, proto.RuntimeCallFunctionOn{})
+	g.Err(el.CanvasClickAt(1, 1))
+
+	g.mc.stubErr(1, proto.InputDispatchMouseEvent{})
+	g.Err(el.CanvasClickAt(1, 1))
+}
@@ -0,0 +1,114 @@
+// This file serves for querying what the browser supports.
+
+package rod
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/Fromsko/rodPro/lib/cdp"
+	"github.com/Fromsko/rodPro/lib/proto"
+)
+
+// Capabilities of the browser, check [Browser.Capabilities]
+type Capabilities struct {
+	// ProtocolVersion of the CDP, such as "1.3"
+	ProtocolVersion string
+
+	// Product is like "HeadlessChrome/120.0.6099.109"
+	Product string
+
+	// Major version of the product, such as 120
+	Major int
+
+	// Headless is true if the product is the headless shell or the old headless mode
+	Headless bool
+
+	Revision  string
+	UserAgent string
+	JSVersion string
+
+	// Domains supported by the browser, including the experimental ones, the values are the domain versions.
+	// It's nil if the browser doesn't report them, such as when the browser has no page to query.
+	Domains map[string]string
+}
+
+// Capabilities queries the protocol version, the product, and the supported domains of the browser,
+// so that the features that depend on the new or experimental commands can check them and degrade gracefully
+// via [Capabilities.Require] instead of failing with the opaque protocol errors.
+func (b *Browser) Capabilities() (*Capabilities, error) {
+	v, err := b.Version()
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Capabilities{
+		ProtocolVersion: v.ProtocolVersion,
+		Product:         v.Product,
+		Headless:        strings.Contains(v.Product, "Headless") || strings.Contains(v.UserAgent, "Headless"),
+		Revision:        v.Revision,
+		UserAgent:       v.UserAgent,
+		JSVersion:       v.JsVersion,
+		Major:           majorVersion(v.Product),
+	}
+
+	// the browser target may not have the Schema domain, try a page target then
+	res, err := proto.SchemaGetDomains{}.Call(b)
+	if err != nil {
+		var pages Pages
+		pages, err = b.Pages()
+		if err != nil {
+			return nil, err
+		}
+		if pages.Empty() {
+			return c, nil
+		}
+		res, err = proto.SchemaGetDomains{}.Call(pages.First())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	c.Domains = map[string]string{}
+	for _, d := range res.Domains {
+		c.Domains[d.Name] = d.Version
+	}
+
+	return c, nil
+}
+
+// HasDomain returns true if the domain is supported, such as "Storage".
+// It returns false if the browser doesn't report the domains, because the support is unknown.
+func (c *Capabilities) HasDomain(name string) bool {
+	_, has := c.Domains[name]
+	return has
+}
+
+// Require returns [ErrUnsupportedCapability] if any of the domains is not supported
+func (c *Capabilities) Require(domains ...string) error {
+	for _, d := range domains {
+		if !c.HasDomain(d) {
+			return &ErrUnsupportedCapability{Name: d}
+		}
+	}
+	return nil
+}
+
+// RequireMajor returns [ErrUnsupportedCapability] if the major version of the product is less than major
+func (c *Capabilities) RequireMajor(major int) error {
+	if c.Major < major {
+		return &ErrUnsupportedCapability{Name: c.Product + " < " + strconv.Itoa(major)}
+	}
+	return nil
+}
+
+// unsupported converts the "method not found" protocol error to [ErrUnsupportedCapability],
+// it's applied to all the calls of [Browser.Call], including the typed ones of lib/proto
+func unsupported(method string, err error) error {
+	var cdpErr *cdp.Error
+	if errors.As(err, &cdpErr) && cdpErr.Code == -32601 {
+		return &ErrUnsupportedCapability{Name: method, Err: err}
+	}
+	return err
+}
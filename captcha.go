@@ -0,0 +1,213 @@
+// This file serves for solving the captcha widgets.
+
+package rod
+
+import (
+	"context"
+	"time"
+
+	"github.com/Fromsko/rodPro/lib/captcha"
+)
+
+// CaptchaPollInterval of the token that is filled by a human for the [captcha.Manual] solver
+var CaptchaPollInterval = 500 * time.Millisecond
+
+// the token field names of each kind, the hCaptcha also fills the g-recaptcha-response for compatibility
+const jsCaptchaFields = `{
+	'recaptcha-v2': ['g-recaptcha-response'],
+	'recaptcha-v3': ['g-recaptcha-response'],
+	'hcaptcha': ['h-captcha-response', 'g-recaptcha-response'],
+	'turnstile': ['cf-turnstile-response'],
+}`
+
+// FindCaptcha locates the first captcha widget of the page, such as reCAPTCHA v2/v3, hCaptcha, and Turnstile,
+// and extracts its sitekey. The widget is located via its container like ".g-recaptcha[data-sitekey]", its iframe,
+// or the render param of the reCAPTCHA v3 script. Returns [ErrCaptchaNotFound] if there's none.
+func (p *Page) FindCaptcha() (*captcha.Task, error) {
+	res, err := p.Eval(`() => {
+		const param = (src, key) => {
+			try {
+				const u = new URL(src, location.href)
+				return u.searchParams.get(key) || new URLSearchParams(u.hash.slice(1)).get(key) || ''
+			} catch (e) {
+				return ''
+			}
+		}
+		const widget = (kind, el) => ({
+			kind,
+			siteKey: el.dataset.sitekey,
+			action: el.dataset.action || '',
+			data: el.dataset.cdata || '',
+			invisible: el.dataset.size === 'invisible',
+		})
+
+		let el = document.querySelector('.cf-turnstile[data-sitekey]')
+		if (el) return widget('turnstile', el)
+
+		el = document.querySelector('.h-captcha[data-sitekey]')
+		if (el) return widget('hcaptcha', el)
+
+		el = document.querySelector('.g-recaptcha[data-sitekey]')
+		if (el) return widget('recaptcha-v2', el)
+
+		for (const f of document.querySelectorAll('iframe[src]')) {
+			const src = f.src
+			if (/hcaptcha\.com/.test(src) && param(src, 'sitekey')) {
+				return { kind: 'hcaptcha', siteKey: param(src, 'sitekey') }
+			}
+			if (/\/recaptcha\/(api2|enterprise)\/anchor/.test(src) && param(src, 'k')) {
+				return {
+					kind: 'recaptcha-v2',
+					siteKey: param(src, 'k'),
+					invisible: param(src, 'size') === 'invisible',
+					enterprise: src.includes('/enterprise/'),
+				}
+			}
+			if (/challenges\.cloudflare\.com/.test(src)) {
+				const m = src.match(/\/(0x[\w-]+)\//)
+				if (m) return { kind: 'turnstile', siteKey: m[1] }
+			}
+		}
+
+		for (const s of document.querySelectorAll('script[src*="/recaptcha/"]')) {
+			const key = param(s.src, 'render')
+			if (key && key !== 'explicit') {
+				return { kind: 'recaptcha-v3', siteKey: key, enterprise: s.src.includes('enterprise') }
+			}
+		}
+
+		return null
+	}`)
+	if err != nil {
+		return nil, err
+	}
+	if res.Value.Nil() {
+		return nil, &ErrCaptchaNotFound{}
+	}
+
+	info, err := p.Info()
+	if err != nil {
+		return nil, err
+	}
+
+	v := res.Value
+	return &captcha.Task{
+		Kind:       captcha.Kind(v.Get("kind").Str()),
+		SiteKey:    v.Get("siteKey").Str(),
+		PageURL:    info.URL,
+		Action:     v.Get("action").Str(),
+		Data:       v.Get("data").Str(),
+		Invisible:  v.Get("invisible").Bool(),
+		Enterprise: v.Get("enterprise").Bool(),
+	}, nil
+}
+
+// SubmitCaptcha fills the token to the response fields of the widget, such as the "g-recaptcha-response",
+// then triggers the callback of the widget, which is its "data-callback" attribute or the callback registered via
+// the render API. For the reCAPTCHA v3 the execute API will resolve the token too.
+func (p *Page) SubmitCaptcha(t *captcha.Task, token string) error {
+	defer p.tryTrace(TraceTypeInput, "submit captcha "+string(t.Kind))()
+
+	_, err := p.Evaluate(Eval(`(kind, token) => {
+		const fields = (`+jsCaptchaFields+`)[kind] || []
+		const form = document.querySelector('.g-recaptcha, .h-captcha, .cf-turnstile')
+
+		for (const name of fields) {
+			let list = document.querySelectorAll('[name="' + name + '"], [id^="' + name + '"]')
+			if (list.length === 0 && form) {
+				const el = document.createElement(name === 'cf-turnstile-response' ? 'input' : 'textarea')
+				el.name = name
+				el.style.display = 'none'
+				form.appendChild(el)
+				list = [el]
+			}
+			for (const el of list) {
+				el.value = token
+				if (el.tagName === 'TEXTAREA') el.innerHTML = token
+				el.dispatchEvent(new Event('input', { bubbles: true }))
+				el.dispatchEvent(new Event('change', { bubbles: true }))
+			}
+		}
+
+		const g = kind === 'hcaptcha' ? window.hcaptcha : kind === 'turnstile' ? window.turnstile : window.grecaptcha
+		if (g) {
+			g.getResponse = () => token
+			if (kind === 'recaptcha-v3') {
+				g.execute = () => Promise.resolve(token)
+				if (g.enterprise) g.enterprise.execute = g.execute
+			}
+		}
+
+		const call = (fn) => {
+			if (typeof fn === 'string') fn = fn.split('.').reduce((o, k) => o && o[k], window)
+			if (typeof fn === 'function') {
+				fn(token)
+				return true
+			}
+			return false
+		}
+
+		if (form && call(form.dataset.callback)) return
+
+		// search the callback registered via the grecaptcha.render
+		const seen = new Set()
+		const walk = (o, depth) => {
+			if (!o || typeof o !== 'object' || depth > 4 || seen.has(o)) return false
+			seen.add(o)
+			for (const k of Object.keys(o)) {
+				if (k === 'callback' && call(o[k])) return true
+				if (walk(o[k], depth + 1)) return true
+			}
+			return false
+		}
+		if (kind.startsWith('recaptcha') && window.___grecaptcha_cfg) walk(window.___grecaptcha_cfg.clients, 0)
+	}`, string(t.Kind), token).ByUser())
+	return err
+}
+
+// SolveCaptcha locates the captcha widget via [Page.FindCaptcha], solves it via the solver,
+// then submits the token via [Page.SubmitCaptcha]. The returned task is the located widget.
+// The [captcha.Task.Wait] is bound to the page to wait for the token filled by a human, such as via the
+// interactive screencast of [Browser.ServeMonitor], so [captcha.Manual] can be used as the solver.
+func (p *Page) SolveCaptcha(solver captcha.Solver) (*captcha.Task, error) {
+	t, err := p.FindCaptcha()
+	if err != nil {
+		return nil, err
+	}
+
+	t.Wait = func(ctx context.Context) (string, error) {
+		return p.waitCaptchaToken(ctx, t.Kind)
+	}
+
+	token, err := solver.Solve(p.ctx, t)
+	if err != nil {
+		return t, err
+	}
+
+	return t, p.SubmitCaptcha(t, token)
+}
+
+func (p *Page) waitCaptchaToken(ctx context.Context, kind captcha.Kind) (string, error) {
+	p = p.Context(ctx)
+	for {
+		res, err := p.Eval(`kind => {
+			for (const name of (`+jsCaptchaFields+`)[kind] || []) {
+				for (const el of document.querySelectorAll('[name="' + name + '"]')) {
+					if (el.value) return el.value
+				}
+			}
+			return ''
+		}`, string(kind))
+		if err != nil {
+			return "", err
+		}
+		if token := res.Value.Str(); token != "" {
+			return token, nil
+		}
+
+		err = humanSleep(ctx, CaptchaPollInterval)
+		if err != nil {
+			return "", err
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package rod_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Fromsko/rodPro"
+	"github.com/Fromsko/rodPro/lib/captcha"
+	"github.com/Fromsko/rodPro/lib/proto"
+)
+
+func TestSolveCaptcha(t *testing.T) {
+	g := setup(t)
+
+	s := g.Serve()
+	s.Route("/v2", ".html", `<html><body><form>
+		<div class="g-recaptcha" data-sitekey="v2key" data-callback="done"></div>
+		<textarea id="g-recaptcha-response" name="g-recaptcha-response"></textarea>
+	</form><script>function done(t) { window.got = t }</script></body></html>`)
+	s.Route("/h", ".html", `<html><body>
+		<div class="h-captcha" data-sitekey="hkey"></div>
+	</body></html>`)
+	s.Route("/turnstile", ".html", `<html><body>
+		<div class="cf-turnstile" data-sitekey="tkey" data-action="login" data-cdata="data" data-callback="a.b"></div>
+		<script>window.a = { b: t => { window.got = t } }</script>
+	</body></html>`)
+	s.Route("/v3", ".html", `<html><body>
+		<script src="/recaptcha/api.js?render=v3key"></script>
+	</body></html>`)
+	s.Route("/none", ".html", `<html></html>`)
+
+	var task *captcha.Task
+	solver := captcha.SolverFunc(func(_ context.Context, t *captcha.Task) (string, error) {
+		task = t
+		return "token-" + t.SiteKey, nil
+	})
+
+	p := g.page.MustNavigate(s.URL("/v2"))
+	p.MustSolveCaptcha(solver)
+	g.Eq(task.Kind, captcha.RecaptchaV2)
+	g.Eq(task.PageURL, s.URL("/v2"))
+	g.Eq(p.MustElement("textarea").MustText(), "token-v2key")
+	g.Eq(p.MustEval(`() => window.got`).Str(), "token-v2key")
+
+	p.MustNavigate(s.URL("/h"))
+	p.MustSolveCaptcha(solver)
+	g.Eq(task.Kind, captcha.HCaptcha)
+	g.Eq(p.MustElement("[name=h-captcha-response]").MustText(), "token-hkey")
+
+	p.MustNavigate(s.URL("/turnstile"))
+	p.MustSolveCaptcha(solver)
+	g.Eq(task.Kind, captcha.Turnstile)
+	g.Eq(task.Action, "login")
+	g.Eq(task.Data, "data")
+	g.Eq(p.MustEval(`() => window.got`).Str(), "token-tkey")
+
+	p.MustNavigate(s.URL("/v3"))
+	g.Eq(p.MustFindCaptcha().Kind, captcha.RecaptchaV3)
+	g.Eq(p.MustFindCaptcha().SiteKey, "v3key")
+
+	p.MustNavigate(s.URL("/none"))
+	_, err := p.SolveCaptcha(solver)
+	g.Is(err, &rod.ErrCaptchaNotFound{})
+
+	g.mc.stubErr(1, proto.RuntimeCallFunctionOn{})
+	g.Err(p.FindCaptcha())
+}
+
+func TestSolveCaptchaManual(t *testing.T) {
+	g := setup(t)
+
+	s := g.Serve()
+	s.Route("/", ".html", `<html><body>
+		<div class="h-captcha" data-sitekey="hkey"></div>
+		<textarea name="h-captcha-response"></textarea>
+		<script>setTimeout(() => { document.querySelector('textarea').value = 'human' }, 300)</script>
+	</body></html>`)
+
+	p := g.page.MustNavigate(s.URL())
+
+	waiting := false
+	p.MustSolveCaptcha(&captcha.Manual{OnWaiting: func(*captcha.Task) { waiting = true }})
+	g.True(waiting)
+	g.Eq(p.MustElement("textarea").MustText(), "human")
+}
@@ -0,0 +1,203 @@
+// This file serves for the certificates of the pages, such as the certificate errors and the client certificates.
+
+package rod
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Fromsko/rodPro/lib/proto"
+)
+
+// CertError is a certificate error handled by [Page.IgnoreCertErrors]
+type CertError struct {
+	URL  string
+	Host string
+
+	// Type of the error, such as "net::ERR_CERT_AUTHORITY_INVALID"
+	Type string
+
+	// Ignored is true if the error is bypassed, false if the request is canceled
+	Ignored bool
+
+	Time time.Time
+}
+
+// CertErrorPolicy of a page, check [Page.IgnoreCertErrors]
+type CertErrorPolicy struct {
+	patterns []string
+	cancel   func()
+	page     *Page
+
+	lock   sync.Mutex
+	errors []*CertError
+	events chan *CertError
+}
+
+// IgnoreCertErrors ignores the certificate errors of the page whose hosts match the patterns, the errors of the
+// other hosts cancel the requests as usual. It's safer than the launcher flag "ignore-certificate-errors" which
+// weakens all the pages for all the hosts. The patterns are the glob patterns of [path.Match], if a pattern contains
+// a port it matches the "host:port", else it matches the hostname, such as "localhost", "*.test.com", or
+// "127.0.0.1:8443". If no pattern is set, no host is allowed, use "*" to allow all the hosts explicitly.
+func (p *Page) IgnoreCertErrors(patterns ...string) (*CertErrorPolicy, error) {
+	err := proto.SecurityEnable{}.Call(p)
+	if err != nil {
+		return nil, err
+	}
+
+	err = proto.SecuritySetOverrideCertificateErrors{Override: true}.Call(p)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(p.ctx)
+	c := &CertErrorPolicy{
+		patterns: patterns,
+		cancel:   cancel,
+		page:     p,
+		events:   make(chan *CertError, 100),
+	}
+
+	wait := p.Context(ctx).EachEvent(func(e *proto.SecurityCertificateError) {
+		c.handle(e)
+	})
+	go wait()
+
+	return c, nil
+}
+
+// Allowed returns true if the host of the url is allowed by the patterns
+func (c *CertErrorPolicy) Allowed(u string) bool {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return false
+	}
+
+	for _, pattern := range c.patterns {
+		host := parsed.Hostname()
+		if _, _, err := net.SplitHostPort(pattern); err == nil {
+			host = parsed.Host
+			if parsed.Port() == "" && parsed.Scheme == "https" {
+				host += ":443"
+			}
+		}
+
+		if ok, _ := path.Match(strings.ToLower(pattern), strings.ToLower(host)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Errors returns the handled certificate errors
+func (c *CertErrorPolicy) Errors() []*CertError {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return append([]*CertError{}, c.errors...)
+}
+
+// Events is the feed of the handled certificate errors, the events will be dropped if the feed is full
+func (c *CertErrorPolicy) Events() <-chan *CertError {
+	return c.events
+}
+
+// Stop ignoring the errors, the certificate errors will be handled by the browser as usual
+func (c *CertErrorPolicy) Stop() error {
+	c.cancel()
+	return proto.SecuritySetOverrideCertificateErrors{Override: false}.Call(c.page)
+}
+
+func (c *CertErrorPolicy) handle(e *proto.SecurityCertificateError) {
+	ce := &CertError{
+		URL:     e.RequestURL,
+		Type:    e.ErrorType,
+		Ignored: c.Allowed(e.RequestURL),
+		Time:    time.Now(),
+	}
+	if u, err := url.Parse(e.RequestURL); err == nil {
+		ce.Host = u.Host
+	}
+
+	action := proto.SecurityCertificateErrorActionCancel
+	if ce.Ignored {
+		action = proto.SecurityCertificateErrorActionContinue
+	}
+
+	err := proto.SecurityHandleCertificateError{EventID: e.EventID, Action: action}.Call(c.page)
+	if err != nil {
+		return
+	}
+
+	c.lock.Lock()
+	c.errors = append(c.errors, ce)
+	c.lock.Unlock()
+
+	select {
+	case c.events <- ce:
+	default:
+	}
+}
+
+// SetClientCertificate sends the requests of the page that match the pattern with the client certificate for the
+// mutual TLS, because the headless browser can't prompt to select a certificate. The requests are hijacked and sent
+// via a Go TLS client, the cookies of the page are attached, the redirects are followed by the browser.
+// If rootCAs is nil, the system roots are used to verify the server. The doc of the pattern is the same as
+// [proto.FetchRequestPattern.URLPattern], such as "https://api.example.com/*". It's implemented via the Fetch domain,
+// so it may conflict with the [Page.HijackRequests] of the same page. Call the returned function to remove it.
+func (p *Page) SetClientCertificate(pattern string, cert tls.Certificate, rootCAs *x509.CertPool) (func(), error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      rootCAs,
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	client := &http.Client{
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	router := p.HijackRequests()
+	err := router.Add(pattern, "", func(ctx *Hijack) {
+		if ctx.Request.header("Cookie") == "" {
+			res, err := proto.NetworkGetCookies{Urls: []string{ctx.Request.URL().String()}}.Call(p)
+			if err == nil && len(res.Cookies) > 0 {
+				list := []string{}
+				for _, c := range res.Cookies {
+					list = append(list, c.Name+"="+c.Value)
+				}
+				ctx.Request.setHeader("Cookie", strings.Join(list, "; "))
+			}
+		}
+
+		err := ctx.LoadResponse(client, true)
+		if err != nil {
+			ctx.OnError(err)
+			ctx.Response.Fail(proto.NetworkErrorReasonFailed)
+		}
+	})
+	if err != nil {
+		_ = router.Stop()
+		return nil, err
+	}
+
+	go router.Run()
+
+	once := sync.Once{}
+	return func() {
+		once.Do(func() {
+			_ = router.Stop()
+			transport.CloseIdleConnections()
+		})
+	}, nil
+}
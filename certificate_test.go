@@ -0,0 +1,119 @@
+package rod_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/Fromsko/rodPro/lib/proto"
+)
+
+func TestIgnoreCertErrors(t *testing.T) {
+	g := setup(t)
+
+	s := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte(`<html><body>secure</body></html>`))
+	}))
+	defer s.Close()
+
+	u, _ := url.Parse(s.URL)
+
+	p := g.newPage()
+
+	policy := p.MustIgnoreCertErrors("test.com", u.Host)
+	g.True(policy.Allowed(s.URL))
+	g.True(policy.Allowed("https://TEST.com/a"))
+	g.False(policy.Allowed("https://a.test.com/a"))
+	g.False(policy.Allowed("://"))
+
+	p.MustNavigate(s.URL).MustWaitLoad()
+	g.Eq(p.MustElement("body").MustText(), "secure")
+
+	e := <-policy.Events()
+	g.True(e.Ignored)
+	g.Eq(e.Host, u.Host)
+	g.Has(e.Type, "CERT")
+	g.Gte(len(policy.Errors()), 1)
+
+	g.E(policy.Stop())
+
+	p = g.newPage()
+	policy = p.MustIgnoreCertErrors("*.test.com")
+	defer func() { g.E(policy.Stop()) }()
+	g.True(policy.Allowed("https://a.test.com:8443"))
+
+	g.Err(p.Navigate(s.URL))
+	g.False((<-policy.Events()).Ignored)
+
+	// an empty allowlist allows nothing
+	none := p.MustIgnoreCertErrors()
+	g.False(none.Allowed(s.URL))
+	g.E(none.Stop())
+	all := p.MustIgnoreCertErrors("*")
+	g.True(all.Allowed("https://a.test.com:8443"))
+	g.E(all.Stop())
+
+	g.mc.stubErr(1, proto.SecurityEnable{})
+	g.Err(p.IgnoreCertErrors())
+
+	g.mc.stubErr(1, proto.SecuritySetOverrideCertificateErrors{})
+	g.Err(p.IgnoreCertErrors())
+}
+
+func TestSetClientCertificate(t *testing.T) {
+	g := setup(t)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	g.E(err)
+	tpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, tpl, &key.PublicKey, key)
+	g.E(err)
+	leaf, err := x509.ParseCertificate(der)
+	g.E(err)
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(leaf)
+
+	s := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, _ := r.Cookie("a")
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte(`<html><body>` + r.TLS.PeerCertificates[0].Subject.CommonName + " " + c.Value + `</body></html>`))
+	}))
+	s.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs, MinVersion: tls.VersionTLS12}
+	s.StartTLS()
+	defer s.Close()
+
+	rootCAs := x509.NewCertPool()
+	rootCAs.AddCert(s.Certificate())
+
+	p := g.newPage()
+	p.MustSetCookies(&proto.NetworkCookieParam{Name: "a", Value: "b", URL: s.URL})
+
+	cleanup := p.MustSetClientCertificate(s.URL+"/*", tls.Certificate{
+		Certificate: [][]byte{der},
+		PrivateKey:  key,
+	}, rootCAs)
+	defer cleanup()
+
+	p.MustNavigate(s.URL + "/page").MustWaitLoad()
+	g.Eq(p.MustElement("body").MustText(), "client b")
+
+	g.mc.stubErr(2, proto.FetchEnable{})
+	g.Err(p.SetClientCertificate("*", tls.Certificate{}, nil))
+}
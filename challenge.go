@@ -0,0 +1,259 @@
+// This file serves for the detection of the anti-bot challenge pages.
+
+package rod
+
+import (
+	"regexp"
+	"sync"
+
+	"github.com/Fromsko/rodPro/lib/proto"
+)
+
+// Challenge page detected by a [ChallengeDetector]
+type Challenge struct {
+	// Kind of the challenge, such as "cloudflare"
+	Kind string
+
+	// URL of the challenge page
+	URL string
+
+	// Status code of the document, 0 if unknown
+	Status int
+}
+
+// ChallengeSignals of the loaded document for the [ChallengeDetector]
+type ChallengeSignals struct {
+	URL    string
+	Title  string
+	Status int
+
+	// Page to query the details of the document
+	Page *Page
+}
+
+// ChallengeDetector inspects the loaded document for a known challenge page
+type ChallengeDetector interface {
+	// Detect returns the challenge if the document is a challenge page, nil if not
+	Detect(s *ChallengeSignals) (*Challenge, error)
+}
+
+// ChallengePattern is a [ChallengeDetector] that matches the document by its title, url, elements, and status code.
+// The document matches if any of the Title, URL, and Selectors matches, and the status code is one of the Statuses
+// if they are set.
+type ChallengePattern struct {
+	Kind      string
+	Title     *regexp.Regexp
+	URL       *regexp.Regexp
+	Selectors []string
+	Statuses  []int
+}
+
+// Detect interface
+func (c *ChallengePattern) Detect(s *ChallengeSignals) (*Challenge, error) {
+	if len(c.Statuses) > 0 {
+		in := false
+		for _, code := range c.Statuses {
+			in = in || code == s.Status
+		}
+		if !in {
+			return nil, nil
+		}
+	}
+
+	match := (c.Title != nil && c.Title.MatchString(s.Title)) || (c.URL != nil && c.URL.MatchString(s.URL))
+
+	if !match && len(c.Selectors) > 0 {
+		res, err := s.Page.Eval(`list => list.some(s => document.querySelector(s))`, c.Selectors)
+		if err != nil {
+			return nil, err
+		}
+		match = res.Value.Bool()
+	}
+
+	if !match {
+		return nil, nil
+	}
+	return &Challenge{Kind: c.Kind, URL: s.URL, Status: s.Status}, nil
+}
+
+// DefaultChallengeDetectors detect the interstitial challenge pages of Cloudflare, PerimeterX, DataDome, hCaptcha,
+// and the Google reCAPTCHA "unusual traffic" page.
+var DefaultChallengeDetectors = []ChallengeDetector{
+	&ChallengePattern{
+		Kind:  "cloudflare",
+		Title: regexp.MustCompile(`^(Just a moment\.\.\.|Attention Required! \| Cloudflare|Please Wait\.\.\. \| Cloudflare)$`),
+		Selectors: []string{
+			"#challenge-form", "#challenge-stage", "#cf-challenge-running",
+			"script[src*='/cdn-cgi/challenge-platform/']",
+		},
+	},
+	&ChallengePattern{
+		Kind:      "perimeterx",
+		Title:     regexp.MustCompile(`^Access to this page has been denied`),
+		Selectors: []string{"#px-captcha", "script[src*='captcha.px-cdn.net']", "script[src*='captcha.perimeterx.net']"},
+	},
+	&ChallengePattern{
+		Kind:      "datadome",
+		Selectors: []string{"iframe[src*='captcha-delivery.com']", "script[src*='ct.captcha-delivery.com']"},
+		Statuses:  []int{403, 405, 429},
+	},
+	&ChallengePattern{
+		Kind:      "hcaptcha",
+		Selectors: []string{"iframe[src*='hcaptcha.com']", ".h-captcha"},
+		Statuses:  []int{403, 429, 503},
+	},
+	&ChallengePattern{
+		Kind:      "recaptcha",
+		URL:       regexp.MustCompile(`^https://www\.google\.[a-z.]+/sorry/`),
+		Selectors: []string{"#recaptcha", ".g-recaptcha"},
+		Statuses:  []int{429},
+	},
+}
+
+type challengeHooks struct {
+	detectors []ChallengeDetector
+	handler   func(p *Page, c *Challenge) error
+}
+
+// OnChallenge calls the handler when [Page.WaitLoad] finds the loaded document is a challenge page, each document
+// is only inspected once. The error of the handler is returned by the [Page.WaitLoad], the handler can be used to
+// solve the challenge, rotate the proxy, or back off, such as:
+//
+//	browser.OnChallenge(func(p *rod.Page, c *rod.Challenge) error {
+//		time.Sleep(time.Minute)
+//		return p.Reload()
+//	})
+//
+// The detectors are [DefaultChallengeDetectors] unless set by [Browser.ChallengeDetectors].
+// Use nil as the handler to disable it.
+func (b *Browser) OnChallenge(handler func(p *Page, c *Challenge) error) *Browser {
+	hooks := &challengeHooks{detectors: DefaultChallengeDetectors}
+	if b.challenge != nil {
+		hooks.detectors = b.challenge.detectors
+	}
+	hooks.handler = handler
+	b.challenge = hooks
+	return b
+}
+
+// ChallengeDetectors sets the detectors of [Browser.OnChallenge] and [Page.DetectChallenge]
+func (b *Browser) ChallengeDetectors(list ...ChallengeDetector) *Browser {
+	hooks := &challengeHooks{detectors: list}
+	if b.challenge != nil {
+		hooks.handler = b.challenge.handler
+	}
+	b.challenge = hooks
+	return b
+}
+
+// DetectChallenge returns the challenge if the current document is a challenge page, nil if not.
+func (p *Page) DetectChallenge() (*Challenge, error) {
+	s, err := p.challengeSignals(false)
+	if err != nil {
+		return nil, err
+	}
+	return p.detectChallenge(s)
+}
+
+func (p *Page) detectChallenge(s *ChallengeSignals) (*Challenge, error) {
+	detectors := DefaultChallengeDetectors
+	if p.browser.challenge != nil {
+		detectors = p.browser.challenge.detectors
+	}
+
+	for _, d := range detectors {
+		c, err := d.Detect(s)
+		if err != nil || c != nil {
+			return c, err
+		}
+	}
+	return nil, nil
+}
+
+// challengeSignals returns nil if once is true and the document has been inspected
+func (p *Page) challengeSignals(once bool) (*ChallengeSignals, error) {
+	if once {
+		loader, err := p.loaderID()
+		if err != nil {
+			return nil, err
+		}
+		if !p.challenged.check(p.FrameID, loader) {
+			return nil, nil
+		}
+	}
+
+	res, err := p.Eval(`() => {
+		const nav = performance.getEntriesByType('navigation')[0]
+		return { url: location.href, title: document.title, status: (nav && nav.responseStatus) || 0 }
+	}`)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ChallengeSignals{
+		URL:    res.Value.Get("url").Str(),
+		Title:  res.Value.Get("title").Str(),
+		Status: res.Value.Get("status").Int(),
+		Page:   p,
+	}, nil
+}
+
+// loaderID returns the loader of the current document of the frame
+func (p *Page) loaderID() (proto.NetworkLoaderID, error) {
+	res, err := proto.PageGetFrameTree{}.Call(p)
+	if err != nil {
+		return "", err
+	}
+
+	list := []*proto.PageFrameTree{res.FrameTree}
+	for len(list) > 0 {
+		tree := list[0]
+		list = append(list[1:], tree.ChildFrames...)
+		if tree.Frame.ID == p.FrameID {
+			return tree.Frame.LoaderID, nil
+		}
+	}
+	return "", &ErrPageNotFound{}
+}
+
+// challengeLoaders remembers the inspected document of each frame, it's shared by the clones of a page,
+// so that the page js doesn't see any mark of the inspection.
+type challengeLoaders struct {
+	lock sync.Mutex
+	list map[proto.PageFrameID]proto.NetworkLoaderID
+}
+
+// check returns true if the document of the loader hasn't been inspected, and marks it as inspected
+func (c *challengeLoaders) check(frame proto.PageFrameID, loader proto.NetworkLoaderID) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.list == nil {
+		c.list = map[proto.PageFrameID]proto.NetworkLoaderID{}
+	}
+	if c.list[frame] == loader {
+		return false
+	}
+	c.list[frame] = loader
+	return true
+}
+
+// tryChallenge runs the [Browser.OnChallenge] handler if the document is a challenge page
+func (p *Page) tryChallenge() error {
+	hooks := p.browser.challenge
+	if hooks == nil || hooks.handler == nil {
+		return nil
+	}
+
+	s, err := p.challengeSignals(true)
+	if err != nil || s == nil {
+		return err
+	}
+
+	c, err := p.detectChallenge(s)
+	if err != nil || c == nil {
+		return err
+	}
+
+	return hooks.handler(p, c)
+}
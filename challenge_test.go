@@ -0,0 +1,80 @@
+package rod_test
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"testing"
+
+	"github.com/Fromsko/rodPro"
+	"github.com/Fromsko/rodPro/lib/proto"
+)
+
+func TestDetectChallenge(t *testing.T) {
+	g := setup(t)
+
+	s := g.Serve()
+	s.Route("/ok", ".html", `<html><title>ok</title><div class="h-captcha"></div></html>`)
+	s.Route("/cf", ".html", `<html><title>Just a moment...</title></html>`)
+	s.Route("/px", ".html", `<html><div id="px-captcha"></div></html>`)
+	s.Mux.HandleFunc("/hcaptcha", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`<html><iframe src="https://newassets.hcaptcha.com/captcha"></iframe></html>`))
+	})
+
+	p := g.page.MustNavigate(s.URL("/ok")).MustWaitLoad()
+	g.Nil(p.MustDetectChallenge())
+
+	c := p.MustNavigate(s.URL("/cf")).MustWaitLoad().MustDetectChallenge()
+	g.Eq(c, &rod.Challenge{Kind: "cloudflare", URL: s.URL("/cf"), Status: 200})
+
+	g.Eq(p.MustNavigate(s.URL("/px")).MustWaitLoad().MustDetectChallenge().Kind, "perimeterx")
+
+	c = p.MustNavigate(s.URL("/hcaptcha")).MustWaitLoad().MustDetectChallenge()
+	g.Eq(c.Kind, "hcaptcha")
+	g.Eq(c.Status, http.StatusForbidden)
+
+	g.mc.stubErr(1, proto.RuntimeCallFunctionOn{})
+	g.Err(p.DetectChallenge())
+}
+
+func TestOnChallenge(t *testing.T) {
+	g := setup(t)
+
+	s := g.Serve()
+	s.Route("/cf", ".html", `<html><title>Just a moment...</title></html>`)
+	s.Route("/custom", ".html", `<html><title>blocked</title></html>`)
+
+	b := g.browser
+	defer b.OnChallenge(nil).ChallengeDetectors(rod.DefaultChallengeDetectors...)
+
+	list := []*rod.Challenge{}
+	b.OnChallenge(func(p *rod.Page, c *rod.Challenge) error {
+		list = append(list, c)
+		return nil
+	})
+
+	// each document is only inspected once
+	p := g.page.MustNavigate(s.URL("/cf")).MustWaitLoad().MustWaitLoad()
+	g.Len(list, 1)
+	g.Eq(list[0].Kind, "cloudflare")
+	g.False(p.MustEval(`() => '__rodChallengeChecked' in window`).Bool())
+
+	// a reload is a new document
+	p.MustReload().MustWaitLoad()
+	g.Len(list, 2)
+	list = list[:1]
+
+	b.ChallengeDetectors(&rod.ChallengePattern{Kind: "custom", Title: regexp.MustCompile(`^blocked$`)})
+	p.MustNavigate(s.URL("/cf")).MustWaitLoad()
+	g.Len(list, 1)
+
+	p.MustNavigate(s.URL("/custom")).MustWaitLoad()
+	g.Len(list, 2)
+	g.Eq(list[1].Kind, "custom")
+
+	errBlocked := errors.New("blocked")
+	b.OnChallenge(func(p *rod.Page, c *rod.Challenge) error { return errBlocked })
+	g.Eq(p.MustNavigate(s.URL("/custom")).WaitLoad(), errBlocked)
+}
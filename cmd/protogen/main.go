@@ -1,23 +1,61 @@
-// Package main ...
+// Package main is the command to generate the lib/proto from the devtools protocol definition.
+//
+// By default it launches a browser and reads the definition from its "/json/protocol" endpoint:
+//
+//	go run ./cmd/protogen
+//
+// To generate against the exact build you use, such as a custom Chromium, Edge, or Electron,
+// pass the paths or URLs of the protocol json files, the domains of them will be merged:
+//
+//	go run ./cmd/protogen -protocol browser_protocol.json,js_protocol.json
+//
+// The definitions in "newer_protocol.json" are merged into the schema, so that the domains that are newer than
+// the browser are still generated, add the definitions there rather than editing the generated files.
+//
+// The experimental domains are generated too. The output dir must contain the hand-written
+// "a_" prefixed files of lib/proto, they are kept, other go files in it will be replaced.
 package main
 
 import (
+	"flag"
 	"fmt"
+	"go/format"
 	"io/ioutil"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"regexp"
 	"strings"
 
 	"github.com/Fromsko/rodPro/lib/utils"
+	"github.com/ysmood/gson"
+)
+
+var (
+	flagProtocol = flag.String("protocol", "",
+		"comma separated paths or URLs of the protocol json files, such as browser_protocol.json,js_protocol.json. "+
+			"If empty, the definition will be read from a launched browser")
+	flagBin = flag.String("bin", "", "the browser to read the definition from when -protocol is empty")
+	flagOut = flag.String("out", filepath.FromSlash("lib/proto"), "the dir to output the generated code")
 )
 
 func main() {
-	cleanup()
+	flag.Parse()
+
+	var schema gson.JSON
+	if *flagProtocol == "" {
+		schema = getSchema(*flagBin)
+	} else {
+		schema = loadSchema(strings.Split(*flagProtocol, ",")...)
+	}
+
+	generate(schema, *flagOut)
+}
 
-	comment := `// This file is generated by "./lib/proto/generate"`
+func generate(schema gson.JSON, out string) {
+	cleanup(out)
 
-	schema := getSchema()
+	comment := `// This file is generated by "./cmd/protogen"`
 
 	init := comment + utils.S(`
 
@@ -78,26 +116,36 @@ func main() {
 			}
 		}
 
-		utils.E(utils.OutputFile(
-			filepath.FromSlash(
-				fmt.Sprintf("lib/proto/%s.go", toSnakeCase(domain.name))),
-			code))
+		output(filepath.Join(out, toSnakeCase(domain.name)+".go"), code)
 	}
 
 	init += `
 		}
 	`
 
-	utils.E(utils.OutputFile(filepath.FromSlash("lib/proto/definitions.go"), init))
-	utils.E(utils.OutputFile(filepath.FromSlash("lib/proto/definitions_test.go"), testsCode))
+	output(filepath.Join(out, "definitions.go"), init)
+	output(filepath.Join(out, "definitions_test.go"), testsCode)
 
-	path := "./lib/proto"
-	utils.Exec("gofumpt -w", path)
-	utils.Exec("go run golang.org/x/tools/cmd/goimports@latest -w", path)
-	utils.Exec(
-		"go run github.com/ysmood/golangci-lint@latest -- run --fix",
-		path,
-	)
+	// optional, to keep the same style as the bundled lib/proto
+	if _, err := exec.LookPath("gofumpt"); err == nil {
+		utils.Exec("gofumpt -w", out)
+	}
+}
+
+var regGSONImport = regexp.MustCompile(`\n\s*"github.com/ysmood/gson"\n`)
+
+// output removes the unused import and formats the code before writing it to the path
+func output(path, code string) {
+	if strings.Count(code, "gson.") == 0 {
+		code = regGSONImport.ReplaceAllString(code, "\n")
+	}
+
+	src, err := format.Source([]byte(code))
+	if err != nil {
+		panic(fmt.Errorf("failed to format %s: %w", path, err))
+	}
+
+	utils.E(utils.OutputFile(path, src))
 }
 
 func (d *definition) comment() string {
@@ -195,6 +243,15 @@ func (d *definition) format() (code string) {
 			}
 		}
 
+		if field := d.base64Field(); field != "" {
+			code += utils.S(`
+				// Bytes returns the {{.field}} as binary, it's decoded only when the Base64Encoded is true
+				func (r {{.name}}) Bytes() ([]byte, error) {
+					return decodeBase64(r.{{.field}}, r.Base64Encoded)
+				}
+			`, "name", d.name, "field", field)
+		}
+
 		if d.cdpType == cdpTypeEvents {
 			code += utils.S(`
 				// ProtoEvent name
@@ -208,6 +265,26 @@ func (d *definition) format() (code string) {
 	return
 }
 
+// base64Field returns the name of the string field whose encoding is flagged by the base64Encoded field,
+// such as the "body" of Network.getResponseBody, it returns empty if there's no such field.
+func (d *definition) base64Field() string {
+	flagged := false
+	field := ""
+	for _, prop := range d.props {
+		switch {
+		case prop.originName == "base64Encoded" && prop.typeName == "bool":
+			flagged = true
+		case prop.typeName == "string" && (prop.originName == "body" || prop.originName == "content" ||
+			prop.originName == "data"):
+			field = prop.name
+		}
+	}
+	if !flagged {
+		return ""
+	}
+	return field
+}
+
 func (d *definition) formatTests() (code string) {
 	switch d.cdpType {
 	case cdpTypeCommands:
@@ -246,8 +323,7 @@ func (d *definition) formatTests() (code string) {
 }
 
 // The "a_" prefixed files won't removed, other go files will be removed before the generation
-func cleanup() {
-	d := filepath.Join("lib", "proto")
+func cleanup(d string) {
 	list, err := ioutil.ReadDir(d)
 	utils.E(err)
 
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ysmood/got"
+)
+
+func TestGenerate(t *testing.T) {
+	g := got.T(t)
+
+	out := t.TempDir()
+	g.E(os.WriteFile(filepath.Join(out, "a_interface.go"), []byte("package proto\n"), 0o644))
+	g.E(os.WriteFile(filepath.Join(out, "stale.go"), []byte("package proto\n"), 0o644))
+
+	generate(loadSchema(
+		filepath.FromSlash("fixtures/browser_protocol.json"),
+		filepath.FromSlash("fixtures/js_protocol.json"),
+	), out)
+
+	g.PathExists(filepath.Join(out, "a_interface.go"))
+	_, err := os.Stat(filepath.Join(out, "stale.go"))
+	g.True(os.IsNotExist(err))
+
+	fedCM := g.Read(filepath.Join(out, "fed_cm.go")).String()
+	g.Has(fedCM, `FedCmDialogTypeAccountChooser FedCmDialogType = "AccountChooser"`)
+	g.Has(fedCM, "Accounts []*FedCmAccount `json:\"accounts\"`")
+	g.Has(fedCM, "PictureURL string `json:\"pictureUrl,omitempty\"`")
+	g.Has(fedCM, `func (m FedCmSelectAccount) ProtoReq() string { return "FedCm.selectAccount" }`)
+	g.Has(fedCM, `return "FedCm.dialogShown"`)
+	g.Has(fedCM, "DisableRejectionDelay bool")
+
+	// the newer definitions are merged into the fixture
+	g.Has(fedCM, `func (m FedCmClickDialogButton) ProtoReq() string { return "FedCm.clickDialogButton" }`)
+	g.Has(fedCM, "DialogButton FedCmDialogButton `json:\"dialogButton\"`")
+
+	bluetooth := g.Read(filepath.Join(out, "bluetooth_emulation.go")).String()
+	g.Has(bluetooth, `BluetoothEmulationCentralStatePoweredOn BluetoothEmulationCentralState = "powered-on"`)
+	g.Has(bluetooth, "Data []byte `json:\"data\"`")
+	g.Has(bluetooth, `return "BluetoothEmulation.gattOperationReceived"`)
+
+	schema := g.Read(filepath.Join(out, "schema.go")).String()
+	g.Has(schema, "func (m SchemaGetDomains) Call(c Client) (*SchemaGetDomainsResult, error)")
+
+	io := g.Read(filepath.Join(out, "io.go")).String()
+	g.Has(io, "func (r IOReadResult) Bytes() ([]byte, error)")
+	g.Has(io, "return decodeBase64(r.Data, r.Base64Encoded)")
+
+	defs := g.Read(filepath.Join(out, "definitions.go")).String()
+	g.Has(defs, `"FedCm.dialogShown"`)
+	g.Has(defs, `const Version = "v1.3"`)
+}
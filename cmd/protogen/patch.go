@@ -0,0 +1,157 @@
+package main
+
+import (
+	_ "embed"
+	"fmt"
+
+	"github.com/ysmood/gson"
+)
+
+// The definitions that are newer than the browser we use to generate, such as the experimental domains
+// that are only available in the canary builds. Remove them once the browser has them.
+//
+//go:embed newer_protocol.json
+var newerProtocol []byte
+
+// patch the schema, the patches whose targets don't exist will be skipped,
+// because the protocol of other browser builds may not have them.
+func patch(json gson.JSON) {
+	addNewerDefinitions(json)
+
+	k := func(k, v string) gson.Query {
+		return func(obj interface{}) (val interface{}, has bool) {
+			list, ok := obj.([]interface{})
+			if !ok {
+				return nil, false
+			}
+			for _, el := range list {
+				if m, ok := el.(map[string]interface{}); ok && m[k] == v {
+					return el, true
+				}
+			}
+			return nil, false
+		}
+	}
+	index := func(obj interface{}, k, v string) (string, bool) {
+		list, _ := obj.([]interface{})
+		for i, el := range list {
+			if m, ok := el.(map[string]interface{}); ok && m[k] == v {
+				return fmt.Sprintf("%d", i), true
+			}
+		}
+		return "", false
+	}
+
+	getTypes := func(domain string) gson.JSON {
+		res, _ := json.Gets("domains", k("domain", domain), "types")
+		return res
+	}
+
+	// TargetTargetInfoType
+	if j, ok := getTypes("Target").Gets(k("id", "TargetInfo"), "properties", k("name", "type")); ok {
+		j.Set("enum", []string{
+			"page", "background_page", "service_worker", "shared_worker", "browser", "other",
+		})
+	}
+
+	// PageLifecycleEventName
+	if j, ok := json.Gets("domains", k("domain", "Page"), "events", k("name", "lifecycleEvent"), "parameters", k("name", "name")); ok {
+		j.Set("enum", []string{
+			"init", "firstPaint", "firstContentfulPaint", "firstImagePaint", "firstMeaningfulPaintCandidate",
+			"DOMContentLoaded", "load", "networkAlmostIdle", "firstMeaningfulPaint", "networkIdle",
+		})
+	}
+
+	// replace these with better type definition
+	if j, ok := getTypes("Input").Gets(k("id", "TimeSinceEpoch")); ok {
+		j.Set("skip", true)
+	}
+	if j, ok := getTypes("Network").Gets(k("id", "TimeSinceEpoch")); ok {
+		j.Set("skip", true)
+	}
+	if j, ok := getTypes("Network").Gets(k("id", "MonotonicTime")); ok {
+		j.Set("skip", true)
+	}
+
+	// fix Cookie.Expires
+	if j, ok := getTypes("Network").Gets(k("id", "Cookie"), "properties"); ok {
+		if i, ok := index(j.Val(), "name", "expires"); ok {
+			j.Set(i, map[string]interface{}{
+				"$ref":        "TimeSinceEpoch",
+				"description": "Cookie expiration date",
+				"name":        "expires",
+			})
+		}
+	}
+
+	// deltaX and deltaY are not optional for mouseWheel events
+	if j, ok := json.Gets("domains", k("domain", "Input"), "commands", k("name", "dispatchMouseEvent"), "parameters"); ok {
+		if jj, ok := j.Gets(k("name", "deltaX")); ok {
+			jj.Del("optional")
+		}
+		if jj, ok := j.Gets(k("name", "deltaY")); ok {
+			jj.Del("optional")
+		}
+	}
+
+	// removing the optional for the body as we need to distinguish between no body and empty body
+	// with that fix we can send an 'empty body' using `SetBody([]byte{})`
+	// and 'no body' by not calling using 'SetBody()' on the response
+	if j, ok := json.Gets("domains", k("domain", "Fetch"), "commands", k("name", "fulfillRequest"), "parameters"); ok {
+		if jj, ok := j.Gets(k("name", "body")); ok {
+			jj.Del("optional")
+		}
+	}
+}
+
+// addNewerDefinitions merges the definitions of the newer_protocol.json into the schema,
+// the definitions of the browser are kept, the missing ones are inserted after their predecessors.
+func addNewerDefinitions(schema gson.JSON) {
+	newer := gson.New(newerProtocol)
+	schema.Set("domains", mergeList(schema.Get("domains").Val(), newer.Get("domains").Val(), "domain"))
+}
+
+// the keys of the lists in a definition and the key to identify their items
+var mergeKeys = map[string]string{
+	"types":      "id",
+	"commands":   "name",
+	"events":     "name",
+	"parameters": "name",
+	"properties": "name",
+	"returns":    "name",
+}
+
+// mergeList inserts the items of the newer that the list doesn't have, the items are identified by the key
+func mergeList(list, newer interface{}, key string) []interface{} {
+	res, _ := list.([]interface{})
+	res = append([]interface{}{}, res...)
+
+	pos := 0
+	for _, item := range newer.([]interface{}) {
+		n := item.(map[string]interface{})
+
+		i := -1
+		for j, el := range res {
+			if m, ok := el.(map[string]interface{}); ok && m[key] == n[key] {
+				i = j
+				break
+			}
+		}
+
+		if i < 0 {
+			res = append(res[:pos], append([]interface{}{item}, res[pos:]...)...)
+			pos++
+			continue
+		}
+
+		old := res[i].(map[string]interface{})
+		for k, itemKey := range mergeKeys {
+			if v, has := n[k]; has {
+				old[k] = mergeList(old[k], v, itemKey)
+			}
+		}
+		pos = i + 1
+	}
+
+	return res
+}
@@ -13,8 +13,13 @@ import (
 	"github.com/ysmood/gson"
 )
 
-func getSchema() gson.JSON {
-	l := launcher.New().Bin(launcher.NewBrowser().MustGet())
+// getSchema from the "/json/protocol" endpoint of a launched browser
+func getSchema(bin string) gson.JSON {
+	if bin == "" {
+		bin = launcher.NewBrowser().MustGet()
+	}
+
+	l := launcher.New().Bin(bin)
 	defer l.Kill()
 
 	u := l.MustLaunch()
@@ -23,18 +28,52 @@ func getSchema() gson.JSON {
 	parsed.Scheme = "http"
 	parsed.Path = "/json/protocol"
 
-	res, err := http.Get(parsed.String())
+	obj := gson.New(read(parsed.String()))
+
+	utils.E(utils.OutputFile("tmp/proto.json", obj.JSON("", "  ")))
+
+	return obj
+}
+
+// loadSchema from the paths or urls, the domains of them are merged into one schema,
+// the version comes from the first one.
+func loadSchema(sources ...string) gson.JSON {
+	var schema gson.JSON
+	domains := []interface{}{}
+
+	for i, src := range sources {
+		obj := gson.New(read(strings.TrimSpace(src)))
+		if i == 0 {
+			schema = obj
+		}
+		for _, d := range obj.Get("domains").Arr() {
+			domains = append(domains, d.Val())
+		}
+	}
+
+	schema.Set("domains", domains)
+
+	return schema
+}
+
+func read(src string) []byte {
+	if !strings.HasPrefix(src, "http://") && !strings.HasPrefix(src, "https://") {
+		data, err := ioutil.ReadFile(src)
+		utils.E(err)
+		return data
+	}
+
+	res, err := http.Get(src)
 	utils.E(err)
 	defer func() { _ = res.Body.Close() }()
 
+	if res.StatusCode != http.StatusOK {
+		panic(fmt.Sprintf("failed to get %s: %s", src, res.Status))
+	}
+
 	data, err := ioutil.ReadAll(res.Body)
 	utils.E(err)
-
-	obj := gson.New(data)
-
-	utils.E(utils.OutputFile("tmp/proto.json", obj.JSON("", "  ")))
-
-	return obj
+	return data
 }
 
 func mapType(n string) string {
@@ -0,0 +1,83 @@
+// Package main is the command to record the manual browsing as a runnable rod program.
+//
+// It launches a headful browser, or attaches to a running one via -remote, records the clicks, typing,
+// and navigations of the page until the page or the browser is closed, or Ctrl+C is pressed, then
+// writes the generated Go code:
+//
+//	go run ./cmd/rod-record -url https://example.com -out main.go
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+
+	"github.com/Fromsko/rodPro"
+	"github.com/Fromsko/rodPro/lib/launcher"
+	"github.com/Fromsko/rodPro/lib/proto"
+	"github.com/Fromsko/rodPro/lib/recorder"
+	"github.com/Fromsko/rodPro/lib/utils"
+)
+
+var (
+	flagURL    = flag.String("url", "", "the url to start with")
+	flagOut    = flag.String("out", "", "the file to output the generated code, stdout if empty")
+	flagBin    = flag.String("bin", "", "the browser to launch")
+	flagRemote = flag.String("remote", "", "the websocket url of a running browser to attach to")
+)
+
+func main() {
+	flag.Parse()
+
+	u := *flagRemote
+	if u == "" {
+		l := launcher.New().Headless(false)
+		if *flagBin != "" {
+			l = l.Bin(*flagBin)
+		}
+		u = l.MustLaunch()
+	}
+
+	browser := rod.New().ControlURL(u).NoDefaultDevice().MustConnect()
+	page := browser.MustPage(*flagURL)
+	if *flagURL != "" {
+		page.MustWaitLoad()
+	}
+
+	r := recorder.New(page)
+	utils.E(r.Start())
+
+	fmt.Fprintln(os.Stderr, "recording, close the page or press Ctrl+C to stop...")
+
+	done := make(chan struct{})
+	var once sync.Once
+	stop := browser.OnPageDestroyed(func(id proto.TargetTargetID, _ *rod.Page) {
+		if id == page.TargetID {
+			once.Do(func() { close(done) })
+		}
+	})
+	defer stop()
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt)
+
+	select {
+	case <-sig:
+	case <-done:
+	}
+
+	code, err := r.Code()
+	utils.E(err)
+
+	if *flagOut == "" {
+		fmt.Print(string(code))
+	} else {
+		utils.E(utils.OutputFile(*flagOut, code))
+	}
+
+	if *flagRemote == "" {
+		_ = browser.Close()
+	}
+}
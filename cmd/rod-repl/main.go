@@ -0,0 +1,61 @@
+// Package main is the interactive shell to run the query, eval, and click commands against a live page,
+// it's useful to develop the scrapers iteratively. Press Tab to complete the command names and the selectors
+// found in the DOM, type "help" to list the commands:
+//
+//	go run ./cmd/rod-repl -url https://example.com
+//
+// Use -remote to attach to a running browser, the first page of it will be used.
+package main
+
+import (
+	"flag"
+	"os"
+
+	"github.com/Fromsko/rodPro"
+	"github.com/Fromsko/rodPro/lib/launcher"
+	"github.com/Fromsko/rodPro/lib/repl"
+	"github.com/Fromsko/rodPro/lib/utils"
+)
+
+var (
+	flagURL      = flag.String("url", "", "the url to open")
+	flagBin      = flag.String("bin", "", "the browser to launch")
+	flagRemote   = flag.String("remote", "", "the websocket url of a running browser to attach to")
+	flagHeadless = flag.Bool("headless", false, "launch the browser in headless mode")
+	flagTimeout  = flag.Duration("timeout", 0, "the timeout of each command, the default is 10s")
+)
+
+func main() {
+	flag.Parse()
+
+	u := *flagRemote
+	if u == "" {
+		l := launcher.New().Headless(*flagHeadless)
+		if *flagBin != "" {
+			l = l.Bin(*flagBin)
+		}
+		u = l.MustLaunch()
+	}
+
+	browser := rod.New().ControlURL(u).NoDefaultDevice().MustConnect()
+
+	var page *rod.Page
+	if pages := browser.MustPages(); *flagRemote != "" && !pages.Empty() {
+		page = pages.First()
+	} else {
+		page = browser.MustPage()
+	}
+	if *flagURL != "" {
+		page.MustNavigate(*flagURL).MustWaitLoad()
+	}
+
+	r := repl.New(browser, page, os.Stdout)
+	if *flagTimeout > 0 {
+		r.Timeout = *flagTimeout
+	}
+	utils.E(r.Run(os.Stdin))
+
+	if *flagRemote == "" {
+		_ = browser.Close()
+	}
+}
@@ -2,6 +2,8 @@ package rod
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/Fromsko/rodPro/lib/utils"
@@ -10,11 +12,46 @@ import (
 type (
 	timeoutContextKey struct{}
 	timeoutContextVal struct {
-		parent context.Context
-		cancel context.CancelFunc
+		parent  context.Context
+		cancel  context.CancelFunc
+		start   time.Time
+		timeout time.Duration
 	}
 )
 
+func withTimeoutVal(parent context.Context, deadline time.Time) context.Context {
+	ctx, cancel := context.WithDeadline(parent, deadline)
+	now := time.Now()
+	return context.WithValue(ctx, timeoutContextKey{}, &timeoutContextVal{parent, cancel, now, deadline.Sub(now)})
+}
+
+// timeoutErr annotates the deadline error with the operation and the time it took, such as
+// "timeout after 5s (5.001s elapsed) waiting Element(`#login`)". If the err is already annotated, the op will
+// replace the inner one, so the error describes the outermost operation the user called.
+func timeoutErr(ctx context.Context, err error, op string, args ...interface{}) error {
+	if err == nil || !errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+
+	if len(args) > 0 {
+		op = fmt.Sprintf(op, args...)
+	}
+
+	var e *ErrTimeout
+	if errors.As(err, &e) {
+		clone := *e
+		clone.Op = op
+		return &clone
+	}
+
+	e = &ErrTimeout{Op: op, Err: err}
+	if val, ok := ctx.Value(timeoutContextKey{}).(*timeoutContextVal); ok {
+		e.Timeout = val.timeout
+		e.Elapsed = time.Since(val.start)
+	}
+	return e
+}
+
 // Context returns a clone with the specified ctx for chained sub-operations
 func (b *Browser) Context(ctx context.Context) *Browser {
 	newObj := *b
@@ -27,10 +64,16 @@ func (b *Browser) GetContext() context.Context {
 	return b.ctx
 }
 
-// Timeout returns a clone with the specified total timeout of all chained sub-operations
+// Timeout returns a clone with the specified total timeout of all chained sub-operations.
+// The deadline errors of the operations will be annotated as [ErrTimeout].
 func (b *Browser) Timeout(d time.Duration) *Browser {
-	ctx, cancel := context.WithTimeout(b.ctx, d)
-	return b.Context(context.WithValue(ctx, timeoutContextKey{}, &timeoutContextVal{b.ctx, cancel}))
+	return b.Context(withTimeoutVal(b.ctx, time.Now().Add(d)))
+}
+
+// WithDeadline returns a clone with the specified deadline of all chained sub-operations,
+// use [Browser.CancelTimeout] to cancel it.
+func (b *Browser) WithDeadline(t time.Time) *Browser {
+	return b.Context(withTimeoutVal(b.ctx, t))
 }
 
 // CancelTimeout cancels the current timeout context and returns a clone with the parent context
@@ -53,13 +96,24 @@ func (b *Browser) Sleeper(sleeper func() utils.Sleeper) *Browser {
 	return &newObj
 }
 
+// SleeperPolicy returns a clone that uses the policy to create the sleepers for chained sub-operations,
+// such as:
+//
+//	rod.New().SleeperPolicy(utils.SleeperPolicy{
+//		Backoff:    utils.ExponentialBackoff(100*time.Millisecond, 2*time.Second, 2, 0.2),
+//		MaxElapsed: 30 * time.Second,
+//	}).MustConnect()
+//
+// When the trace is enabled, each attempt is logged as [TraceTypeRetry].
+func (b *Browser) SleeperPolicy(policy utils.SleeperPolicy) *Browser {
+	return b.Sleeper(traceSleeperPolicy(policy, func() bool { return b.trace }, b.logger))
+}
+
 // Context returns a clone with the specified ctx for chained sub-operations
 func (p *Page) Context(ctx context.Context) *Page {
-	p.helpersLock.Lock()
-	newObj := *p
-	p.helpersLock.Unlock()
+	newObj := p.Fork()
 	newObj.ctx = ctx
-	return &newObj
+	return newObj
 }
 
 // GetContext of current instance
@@ -69,8 +123,13 @@ func (p *Page) GetContext() context.Context {
 
 // Timeout returns a clone with the specified total timeout of all chained sub-operations
 func (p *Page) Timeout(d time.Duration) *Page {
-	ctx, cancel := context.WithTimeout(p.ctx, d)
-	return p.Context(context.WithValue(ctx, timeoutContextKey{}, &timeoutContextVal{p.ctx, cancel}))
+	return p.Context(withTimeoutVal(p.ctx, time.Now().Add(d)))
+}
+
+// WithDeadline returns a clone with the specified deadline of all chained sub-operations,
+// use [Page.CancelTimeout] to cancel it.
+func (p *Page) WithDeadline(t time.Time) *Page {
+	return p.Context(withTimeoutVal(p.ctx, t))
 }
 
 // CancelTimeout cancels the current timeout context and returns a clone with the parent context
@@ -88,11 +147,42 @@ func (p *Page) WithCancel() (*Page, func()) {
 
 // Sleeper returns a clone with the specified sleeper for chained sub-operations
 func (p *Page) Sleeper(sleeper func() utils.Sleeper) *Page {
-	newObj := *p
+	newObj := p.Fork()
 	newObj.sleeper = sleeper
+	return newObj
+}
+
+// Trace returns a clone that enables/disables the visual tracing of the page,
+// it overrides the [Browser.Trace] setting for the clone only.
+func (p *Page) Trace(enable bool) *Page {
+	newObj := p.Fork()
+	newObj.trace = &enable
+	return newObj
+}
+
+// Fork returns a clone of the page that shares the same target and js context, but has its own
+// context, sleeper, and trace settings, changing them on the fork won't affect the page and vice versa.
+// It's safe to use different forks of the same page concurrently, such as:
+//
+//	for _, selector := range selectors {
+//		go func(selector string) {
+//			page.Fork().Timeout(time.Second).MustElement(selector).MustText()
+//		}(selector)
+//	}
+//
+// The input devices, such as [Page.Mouse], are still shared, because they represent the state of the same target.
+func (p *Page) Fork() *Page {
+	p.helpersLock.Lock()
+	newObj := *p
+	p.helpersLock.Unlock()
 	return &newObj
 }
 
+// SleeperPolicy is similar to [Browser.SleeperPolicy], the trace setting of the page is respected, see [Page.Trace]
+func (p *Page) SleeperPolicy(policy utils.SleeperPolicy) *Page {
+	return p.Sleeper(traceSleeperPolicy(policy, p.traceEnabled, p.browser.logger))
+}
+
 // Context returns a clone with the specified ctx for chained sub-operations
 func (el *Element) Context(ctx context.Context) *Element {
 	newObj := *el
@@ -107,8 +197,13 @@ func (el *Element) GetContext() context.Context {
 
 // Timeout returns a clone with the specified total timeout of all chained sub-operations
 func (el *Element) Timeout(d time.Duration) *Element {
-	ctx, cancel := context.WithTimeout(el.ctx, d)
-	return el.Context(context.WithValue(ctx, timeoutContextKey{}, &timeoutContextVal{el.ctx, cancel}))
+	return el.Context(withTimeoutVal(el.ctx, time.Now().Add(d)))
+}
+
+// WithDeadline returns a clone with the specified deadline of all chained sub-operations,
+// use [Element.CancelTimeout] to cancel it.
+func (el *Element) WithDeadline(t time.Time) *Element {
+	return el.Context(withTimeoutVal(el.ctx, t))
 }
 
 // CancelTimeout cancels the current timeout context and returns a clone with the parent context
@@ -130,3 +225,8 @@ func (el *Element) Sleeper(sleeper func() utils.Sleeper) *Element {
 	newObj.sleeper = sleeper
 	return &newObj
 }
+
+// SleeperPolicy is similar to [Browser.SleeperPolicy]
+func (el *Element) SleeperPolicy(policy utils.SleeperPolicy) *Element {
+	return el.Sleeper(traceSleeperPolicy(policy, el.page.traceEnabled, el.page.browser.logger))
+}
@@ -42,10 +42,17 @@ const (
 
 	// TraceTypeInput type
 	TraceTypeInput TraceType = "input"
+
+	// TraceTypeRetry type, the message is a [utils.SleepAttempt]
+	TraceTypeRetry TraceType = "retry"
 )
 
 // ServeMonitor starts the monitor server.
 // The reason why not to use "chrome://inspect/#devices" is one target cannot be driven by multiple controllers.
+// Each page is played as a live MJPEG screencast at "/stream/{target-id}", the clicks, scrolls, and keystrokes
+// on the screen can be forwarded back into the page, check [MonitorInput] for details. Only the monitor page
+// itself can forward the input, the requests from other sites are rejected.
+// The page also links to the DevTools of the page via [Page.DevToolsURL].
 func (b *Browser) ServeMonitor(host string) string {
 	u, mux, closeSvr := serve(host)
 	go func() {
@@ -70,7 +77,9 @@ func (b *Browser) ServeMonitor(host string) string {
 		w.WriteHeader(http.StatusOK)
 		utils.E(w.Write(utils.MustToJSONBytes(list)))
 	})
+	token := utils.RandString(16)
 	mux.HandleFunc("/page/", func(w http.ResponseWriter, r *http.Request) {
+		setMonitorToken(w, token)
 		httHTML(w, assets.MonitorPage)
 	})
 	mux.HandleFunc("/api/page/", func(w http.ResponseWriter, r *http.Request) {
@@ -80,6 +89,22 @@ func (b *Browser) ServeMonitor(host string) string {
 		w.WriteHeader(http.StatusOK)
 		utils.E(w.Write(utils.MustToJSONBytes(info)))
 	})
+	casts := newScreencasts(b)
+	mux.HandleFunc("/stream/", func(w http.ResponseWriter, r *http.Request) {
+		id := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+		p, err := b.PageFromTarget(proto.TargetTargetID(id))
+		utils.E(err)
+		casts.serveStream(w, r, p)
+	})
+	mux.HandleFunc("/api/input/", func(w http.ResponseWriter, r *http.Request) {
+		b.serveMonitorInput(w, r, token)
+	})
+	mux.HandleFunc("/devtools/", func(w http.ResponseWriter, r *http.Request) {
+		id := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+		u, err := devToolsURL(b.wsURL, proto.TargetTargetID(id))
+		utils.E(err)
+		http.Redirect(w, r, u, http.StatusFound)
+	})
 	mux.HandleFunc("/screenshot/", func(w http.ResponseWriter, r *http.Request) {
 		id := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
 		target := proto.TargetTargetID(id)
@@ -93,12 +118,28 @@ func (b *Browser) ServeMonitor(host string) string {
 }
 
 // check method and sleep if needed
-func (b *Browser) trySlowMotion() {
-	if b.slowMotion == 0 {
+func (b *Browser) trySlowMotion(action string) {
+	d := b.slowMotionDelay(action)
+	if d <= 0 {
 		return
 	}
 
-	time.Sleep(b.slowMotion)
+	time.Sleep(d)
+}
+
+func (b *Browser) slowMotionDelay(action string) time.Duration {
+	if b.slowFunc != nil {
+		return b.slowFunc(action)
+	}
+
+	category, _, _ := strings.Cut(action, ".")
+	if d, has := b.slowFor[category]; has {
+		return d
+	}
+	if category == SlowMotionInput {
+		return b.slowMotion
+	}
+	return 0
 }
 
 // ExposeHelpers helper functions to page's js context so that we can use the Devtools' console to debug them.
@@ -130,9 +171,19 @@ func (p *Page) Overlay(left, top, width, height float64, msg string) (remove fun
 	return
 }
 
+// traceEnabled tells if the trace is enabled for the page, see [Page.Trace]
+func (p *Page) traceEnabled() bool {
+	if p.trace != nil {
+		return *p.trace
+	}
+	return p.browser.trace
+}
+
 func (p *Page) tryTrace(typ TraceType, msg ...interface{}) func() {
-	if !p.browser.trace {
-		return func() {}
+	record := p.browser.timeline.start(p.ctx, p, typ, traceMessage(msg), p.String())
+
+	if !p.traceEnabled() {
+		return record
 	}
 
 	msg = append([]interface{}{typ}, msg...)
@@ -140,11 +191,29 @@ func (p *Page) tryTrace(typ TraceType, msg ...interface{}) func() {
 
 	p.browser.logger.Println(msg...)
 
-	return p.Overlay(0, 0, 500, 0, fmt.Sprint(msg))
+	remove := p.Overlay(0, 0, 500, 0, fmt.Sprint(msg))
+	return func() {
+		remove()
+		record()
+	}
+}
+
+// traceSleeperPolicy logs each attempt of the policy when the trace is enabled
+func traceSleeperPolicy(policy utils.SleeperPolicy, enabled func() bool, logger utils.Logger) func() utils.Sleeper {
+	hook := policy.OnSleep
+	policy.OnSleep = func(a utils.SleepAttempt) {
+		if enabled() {
+			logger.Println(TraceTypeRetry, a)
+		}
+		if hook != nil {
+			hook(a)
+		}
+	}
+	return policy.Sleeper
 }
 
 func (p *Page) tryTraceQuery(opts *EvalOptions) func() {
-	if !p.browser.trace {
+	if !p.traceEnabled() {
 		return func() {}
 	}
 
@@ -155,7 +224,7 @@ func (p *Page) tryTraceQuery(opts *EvalOptions) func() {
 }
 
 func (p *Page) tryTraceReq(includes, excludes []string) func(map[proto.NetworkRequestID]string) {
-	if !p.browser.trace {
+	if !p.traceEnabled() {
 		return func(map[proto.NetworkRequestID]string) {}
 	}
 
@@ -211,8 +280,10 @@ func (el *Element) Overlay(msg string) (removeOverlay func()) {
 }
 
 func (el *Element) tryTrace(typ TraceType, msg ...interface{}) func() {
-	if !el.page.browser.trace {
-		return func() {}
+	record := el.page.browser.timeline.start(el.ctx, el.page, typ, traceMessage(msg), el.String())
+
+	if !el.page.traceEnabled() {
+		return record
 	}
 
 	msg = append([]interface{}{typ}, msg...)
@@ -220,7 +291,11 @@ func (el *Element) tryTrace(typ TraceType, msg ...interface{}) func() {
 
 	el.page.browser.logger.Println(msg...)
 
-	return el.Overlay(fmt.Sprint(msg))
+	remove := el.Overlay(fmt.Sprint(msg))
+	return func() {
+		remove()
+		record()
+	}
 }
 
 func (m *Mouse) initMouseTracer() {
@@ -1,6 +1,14 @@
 package rod_test
 
 import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/cookiejar"
+	"strconv"
 	"testing"
 	"time"
 
@@ -38,6 +46,81 @@ func TestMonitor(t *testing.T) {
 	g.Eq(-32602, gson.New(res.Body).Get("code").Int())
 }
 
+func TestMonitorScreencast(t *testing.T) {
+	g := setup(t)
+
+	b := rod.New().MustConnect()
+	defer b.MustClose()
+	p := b.MustPage(g.srcFile("fixtures/click.html")).MustWaitLoad()
+
+	b, cancel := b.WithCancel()
+	defer cancel()
+	host := b.Context(g.Context()).ServeMonitor("")
+
+	res, err := http.Get(host + "/stream/" + string(p.TargetID))
+	g.E(err)
+	g.Has(res.Header.Get("Content-Type"), "multipart/x-mixed-replace")
+	_, params, err := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	g.E(err)
+	part, err := multipart.NewReader(res.Body, params["boundary"]).NextPart()
+	g.E(err)
+	g.Eq(part.Header.Get("Content-Type"), "image/jpeg")
+	size, err := strconv.Atoi(part.Header.Get("Content-Length"))
+	g.E(err)
+	g.Gt(size, 10)
+	_, err = io.ReadFull(part, make([]byte, size))
+	g.E(err)
+	g.E(res.Body.Close())
+
+	box := p.MustElement("button").MustShape().Box()
+	vp := p.MustEval(`() => [innerWidth, innerHeight]`)
+	in, err := json.Marshal(rod.MonitorInput{
+		Type: "click",
+		X:    (box.X + box.Width/2) / vp.Get("0").Num(),
+		Y:    (box.Y + box.Height/2) / vp.Get("1").Num(),
+	})
+	g.E(err)
+	inputURL := host + "/api/input/" + string(p.TargetID)
+
+	// without the token of the monitor page
+	res, err = http.Post(inputURL, "application/json", bytes.NewReader(in))
+	g.E(err)
+	g.Eq(res.StatusCode, http.StatusForbidden)
+
+	jar, err := cookiejar.New(nil)
+	g.E(err)
+	client := &http.Client{Jar: jar}
+	res, err = client.Get(host + "/page/" + string(p.TargetID))
+	g.E(err)
+	g.E(res.Body.Close())
+
+	res, err = client.Post(inputURL, "text/plain", bytes.NewReader(in))
+	g.E(err)
+	g.Eq(res.StatusCode, http.StatusUnsupportedMediaType)
+
+	req, err := http.NewRequest(http.MethodPost, inputURL, bytes.NewReader(in))
+	g.E(err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Origin", "http://evil.example.com")
+	res, err = client.Do(req)
+	g.E(err)
+	g.Eq(res.StatusCode, http.StatusForbidden)
+
+	res, err = client.Post(inputURL, "application/json", bytes.NewReader(in))
+	g.E(err)
+	g.Eq(res.StatusCode, http.StatusNoContent)
+	g.True(p.MustHas("[a=ok]"))
+
+	g.Eq(g.Req("", inputURL).StatusCode, http.StatusMethodNotAllowed)
+
+	u, err := p.DevToolsURL()
+	g.E(err)
+	g.Has(u, "/devtools/inspector.html?ws=")
+	g.Has(u, "/devtools/page/"+string(p.TargetID))
+
+	g.Err((&rod.MonitorInput{Type: "unknown"}).Forward(p))
+}
+
 func TestMonitorErr(t *testing.T) {
 	g := setup(t)
 
@@ -106,3 +189,76 @@ func TestExposeHelpers(t *testing.T) {
 
 	g.Eq(p.MustElementByJS(`() => rod.elementR('button', 'click me')`).MustText(), "click me")
 }
+
+func TestTraceSleeperPolicy(t *testing.T) {
+	g := setup(t)
+
+	logs := []interface{}{}
+	g.browser.Logger(utils.Log(func(msg ...interface{}) {
+		if msg[0] == rod.TraceTypeRetry {
+			logs = append(logs, msg[1])
+		}
+	}))
+	g.browser.Trace(true)
+	defer func() {
+		g.browser.Logger(rod.DefaultLogger)
+		g.browser.Trace(defaults.Trace)
+	}()
+
+	attempts := 0
+	p := g.page.MustNavigate(g.blank()).SleeperPolicy(utils.SleeperPolicy{
+		Backoff:     utils.ConstantBackoff(time.Millisecond),
+		MaxAttempts: 2,
+		OnSleep:     func(utils.SleepAttempt) { attempts++ },
+	})
+
+	_, err := p.Element("not-exists")
+	g.Is(err, &utils.ErrMaxSleepCount{})
+	g.Eq(attempts, 2)
+	g.Len(logs, 2)
+	g.Eq(logs[1].(utils.SleepAttempt).N, 2)
+
+	_, err = p.Trace(false).SleeperPolicy(utils.SleeperPolicy{MaxAttempts: 1}).Element("not-exists")
+	g.Is(err, &utils.ErrMaxSleepCount{})
+	g.Len(logs, 2)
+
+	el := g.page.MustElement("body").SleeperPolicy(utils.SleeperPolicy{MaxAttempts: 1})
+	_, err = el.Element("not-exists")
+	g.Is(err, &utils.ErrMaxSleepCount{})
+
+	page := g.browser.SleeperPolicy(utils.SleeperPolicy{MaxAttempts: 1}).MustPage(g.blank())
+	defer page.MustClose()
+	_, err = page.Element("not-exists")
+	g.Is(err, &utils.ErrMaxSleepCount{})
+}
+
+func TestSlowMotionFor(t *testing.T) {
+	g := setup(t)
+
+	actions := []string{}
+	g.browser.SlowMotionFunc(func(action string) time.Duration {
+		actions = append(actions, action)
+		return 0
+	})
+	defer g.browser.SlowMotionFunc(nil)
+
+	p := g.page.MustNavigate(g.srcFile("fixtures/click.html")).MustWaitLoad()
+	p.MustElement("button").MustClick()
+	p.MustEval(`() => 1`)
+
+	g.Has(actions, "navigation.navigate")
+	g.Has(actions, "wait.load")
+	g.Has(actions, "input.click")
+	g.Has(actions, "eval.eval")
+
+	g.browser.SlowMotionFunc(nil).SlowMotionFor(rod.SlowMotionNavigation, 300*time.Millisecond)
+	defer g.browser.SlowMotionFor(rod.SlowMotionNavigation, 0)
+
+	start := time.Now()
+	p.MustNavigate(g.blank())
+	g.Gte(time.Since(start), 300*time.Millisecond)
+
+	start = time.Now()
+	p.MustEval(`() => 1`)
+	g.Lt(time.Since(start), 300*time.Millisecond)
+}
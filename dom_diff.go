@@ -0,0 +1,228 @@
+// This file serves for diffing the DOM snapshots.
+
+package rod
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Fromsko/rodPro/lib/proto"
+)
+
+// SnapshotDOM is a shortcut for [Page.DOMSnapshot] without the computed styles and rects,
+// it's the usual input of [DiffDOM].
+func (p *Page) SnapshotDOM() (*DOMSnapshot, error) {
+	return p.DOMSnapshot(nil)
+}
+
+// DOMDiff is the result of [DiffDOM]
+type DOMDiff struct {
+	// Added nodes, only the roots of the added subtrees are listed
+	Added []*DOMChange
+
+	// Removed nodes, only the roots of the removed subtrees are listed
+	Removed []*DOMChange
+
+	// Modified nodes, such as the changed attributes, text, or input value
+	Modified []*DOMChange
+}
+
+// DOMChange of a node in [DOMDiff]
+type DOMChange struct {
+	// Selector is the css path of the node, for a text node it's the css path of the parent element
+	Selector string
+
+	// Node in the new snapshot, for the removed node it's the node in the old snapshot
+	Node *SnapshotNode
+
+	// Old node in the old snapshot, nil for the added and removed nodes
+	Old *SnapshotNode
+
+	// Changes of the modified node, such as "attr:class", "text", "value", "checked", or "selected"
+	Changes []string
+}
+
+// String interface
+func (c *DOMChange) String() string {
+	if len(c.Changes) == 0 {
+		return fmt.Sprintf("%s %s", c.Selector, c.Node.Name)
+	}
+	return fmt.Sprintf("%s %s", c.Selector, strings.Join(c.Changes, ","))
+}
+
+// Empty returns true if there's no change
+func (d *DOMDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Modified) == 0
+}
+
+// DiffDOM compares two snapshots of the same page, such as the snapshots before and after clicking a button:
+//
+//	a := page.MustSnapshotDOM()
+//	page.MustElement("button").MustClick()
+//	b := page.MustSnapshotDOM()
+//	diff := rod.DiffDOM(a, b)
+//	fmt.Println(len(diff.Added)) // rows added by the click
+//
+// The nodes are matched by their [SnapshotNode.BackendNodeID], so the snapshots should be taken from the same
+// document, the comments and the whitespace-only text nodes are ignored.
+func DiffDOM(a, b *DOMSnapshot) *DOMDiff {
+	diff := &DOMDiff{Added: []*DOMChange{}, Removed: []*DOMChange{}, Modified: []*DOMChange{}}
+
+	oldNodes := diffableNodes(a)
+	newNodes := diffableNodes(b)
+
+	for _, n := range newNodes.list {
+		old, has := oldNodes.index[n.BackendNodeID]
+		if !has {
+			if !parentMissing(n, newNodes, oldNodes) {
+				diff.Added = append(diff.Added, &DOMChange{Selector: snapshotSelector(n), Node: n})
+			}
+			continue
+		}
+
+		if changes := diffNode(old, n); len(changes) > 0 {
+			diff.Modified = append(diff.Modified, &DOMChange{
+				Selector: snapshotSelector(n), Node: n, Old: old, Changes: changes,
+			})
+		}
+	}
+
+	for _, n := range oldNodes.list {
+		if _, has := newNodes.index[n.BackendNodeID]; has {
+			continue
+		}
+		if !parentMissing(n, oldNodes, newNodes) {
+			diff.Removed = append(diff.Removed, &DOMChange{Selector: snapshotSelector(n), Node: n})
+		}
+	}
+
+	return diff
+}
+
+type snapshotNodes struct {
+	list  []*SnapshotNode
+	index map[proto.DOMBackendNodeID]*SnapshotNode
+}
+
+func diffableNodes(s *DOMSnapshot) *snapshotNodes {
+	nodes := &snapshotNodes{index: map[proto.DOMBackendNodeID]*SnapshotNode{}}
+	s.Walk(func(n *SnapshotNode) bool {
+		if n.Type == 1 || (n.Type == 3 && strings.TrimSpace(n.Value) != "") {
+			nodes.list = append(nodes.list, n)
+			nodes.index[n.BackendNodeID] = n
+		}
+		return true
+	})
+	return nodes
+}
+
+// parentMissing checks if the parent of n is in the same set but missing in the other set,
+// which means n is a descendant of an added or removed subtree.
+func parentMissing(n *SnapshotNode, same, other *snapshotNodes) bool {
+	if n.Parent == nil {
+		return false
+	}
+	if _, has := same.index[n.Parent.BackendNodeID]; !has {
+		return false
+	}
+	_, has := other.index[n.Parent.BackendNodeID]
+	return !has
+}
+
+func diffNode(a, b *SnapshotNode) []string {
+	changes := []string{}
+
+	if b.Type == 3 {
+		if a.Value != b.Value {
+			changes = append(changes, "text")
+		}
+		return changes
+	}
+
+	names := map[string]struct{}{}
+	for k := range a.Attributes {
+		names[k] = struct{}{}
+	}
+	for k := range b.Attributes {
+		names[k] = struct{}{}
+	}
+	attrs := []string{}
+	for k := range names {
+		va, hasA := a.Attributes[k]
+		vb, hasB := b.Attributes[k]
+		if va != vb || hasA != hasB {
+			attrs = append(attrs, "attr:"+k)
+		}
+	}
+	sort.Strings(attrs)
+	changes = append(changes, attrs...)
+
+	if a.InputValue != b.InputValue {
+		changes = append(changes, "value")
+	}
+	if a.InputChecked != b.InputChecked {
+		changes = append(changes, "checked")
+	}
+	if a.OptionSelected != b.OptionSelected {
+		changes = append(changes, "selected")
+	}
+
+	return changes
+}
+
+// snapshotSelector returns the css path of the node, it stops at the nearest ancestor that has an id
+func snapshotSelector(n *SnapshotNode) string {
+	if n.Type != 1 {
+		n = n.Parent
+	}
+
+	parts := []string{}
+	for ; n != nil && n.Type == 1; n = n.Parent {
+		if id := n.Attributes["id"]; id != "" {
+			parts = append(parts, "#"+cssEscape(id))
+			break
+		}
+
+		part := strings.ToLower(n.Name)
+		if n.Parent != nil {
+			nth, count := 0, 0
+			for _, c := range n.Parent.Children {
+				if c.Type == 1 && c.Name == n.Name {
+					count++
+					if c == n {
+						nth = count
+					}
+				}
+			}
+			if count > 1 {
+				part += fmt.Sprintf(":nth-of-type(%d)", nth)
+			}
+		}
+		parts = append(parts, part)
+	}
+
+	for i, j := 0, len(parts)-1; i < j; i, j = i+1, j-1 {
+		parts[i], parts[j] = parts[j], parts[i]
+	}
+
+	return strings.Join(parts, " > ")
+}
+
+// cssEscape escapes the id for the css selector, it's a simplified version of the CSS.escape
+func cssEscape(s string) string {
+	b := strings.Builder{}
+	for i, r := range s {
+		switch {
+		case r == '-' || r == '_' || r >= 0x80 ||
+			(r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9' && i > 0):
+			b.WriteRune(r)
+		case r >= '0' && r <= '9':
+			fmt.Fprintf(&b, "\\%x ", r)
+		default:
+			b.WriteRune('\\')
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
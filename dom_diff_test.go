@@ -0,0 +1,51 @@
+package rod_test
+
+import (
+	"testing"
+
+	"github.com/Fromsko/rodPro"
+)
+
+func TestDiffDOM(t *testing.T) {
+	g := setup(t)
+
+	p := g.newPage(g.html(`<html><body>
+		<table id="list"><tbody><tr><td>a</td></tr></tbody></table>
+		<p class="x">old text</p>
+		<p id="gone"><b>bye</b></p>
+		<input id="in">
+		<div id="1st">digit id</div>
+	</body></html>`))
+
+	a := p.MustSnapshotDOM()
+	g.True(rod.DiffDOM(a, a).Empty())
+
+	p.MustEval(`() => {
+		document.querySelector('tbody').insertAdjacentHTML('beforeend', '<tr><td>b</td></tr>')
+		const x = document.querySelector('.x')
+		x.className = 'y'
+		x.firstChild.nodeValue = 'new text'
+		document.querySelector('#gone').remove()
+		document.querySelector('#in').value = 'typed'
+		document.getElementById('1st').title = 't'
+	}`)
+
+	b := p.MustSnapshotDOM()
+	diff := rod.DiffDOM(a, b)
+
+	g.Len(diff.Added, 1)
+	g.Eq(diff.Added[0].Selector, "#list > tbody > tr:nth-of-type(2)")
+	g.Eq(diff.Added[0].Node.Name, "TR")
+
+	g.Len(diff.Removed, 1)
+	g.Eq(diff.Removed[0].String(), "#gone P")
+
+	g.Len(diff.Modified, 4)
+	g.Eq(diff.Modified[0].String(), "body > p attr:class")
+	g.Eq(diff.Modified[1].String(), "body > p text")
+	g.Eq(diff.Modified[1].Old.Value, "old text")
+	g.Eq(diff.Modified[2].String(), "#in value")
+	g.Eq(diff.Modified[3].String(), `#\31  attr:title`)
+	g.Eq(p.MustElement(diff.Modified[3].Selector).MustText(), "digit id")
+	g.False(diff.Empty())
+}
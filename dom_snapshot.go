@@ -0,0 +1,341 @@
+// This file serves for the typed DOM snapshot of Page.
+
+package rod
+
+import (
+	"github.com/Fromsko/rodPro/lib/proto"
+)
+
+// DOMSnapshotOptions for [Page.DOMSnapshot]
+type DOMSnapshotOptions struct {
+	// Styles is the list of the computed style properties to capture for the rendered nodes, such as "display".
+	Styles []string
+
+	// PaintOrder of the rendered nodes will be captured
+	PaintOrder bool
+
+	// DOMRects will capture the offsetRect, clientRect, and scrollRect of the rendered nodes
+	DOMRects bool
+}
+
+// DOMSnapshot is the typed tree decoded from the flattened result of [proto.DOMSnapshotCaptureSnapshot].
+type DOMSnapshot struct {
+	// Documents of the page, the first one is the main document, the others are the iframe documents.
+	Documents []*SnapshotDocument
+}
+
+// SnapshotDocument is a document in the [DOMSnapshot].
+type SnapshotDocument struct {
+	URL     string
+	Title   string
+	FrameID proto.PageFrameID
+
+	// Root is the document node
+	Root *SnapshotNode
+
+	// Nodes of the document in the document order
+	Nodes []*SnapshotNode
+
+	ScrollX, ScrollY            float64
+	ContentWidth, ContentHeight float64
+}
+
+// SnapshotNode is a node in the [SnapshotDocument].
+type SnapshotNode struct {
+	Type          int
+	Name          string
+	Value         string
+	BackendNodeID proto.DOMBackendNodeID
+	Attributes    map[string]string
+
+	TextValue      string
+	InputValue     string
+	InputChecked   bool
+	OptionSelected bool
+	IsClickable    bool
+
+	Parent   *SnapshotNode `json:"-"`
+	Children []*SnapshotNode
+
+	// ContentDocument of the iframe node
+	ContentDocument *SnapshotDocument `json:"-"`
+
+	// Layout of the node, nil if the node is not rendered
+	Layout *SnapshotLayout
+}
+
+// SnapshotLayout is the layout info of a rendered [SnapshotNode].
+type SnapshotLayout struct {
+	Bounds *proto.DOMRect
+
+	// Text content of the layout object, only for the text nodes
+	Text string
+
+	// Styles is the computed styles listed in [DOMSnapshotOptions.Styles]
+	Styles map[string]string
+
+	StackingContext bool
+
+	// PaintOrder is only available when [DOMSnapshotOptions.PaintOrder] is true
+	PaintOrder int
+
+	// The rects are only available when [DOMSnapshotOptions.DOMRects] is true
+	OffsetRect, ClientRect, ScrollRect *proto.DOMRect
+
+	// TextBoxes are the inline text boxes of the text nodes
+	TextBoxes []*SnapshotTextBox
+}
+
+// SnapshotTextBox is a line of text of a text node
+type SnapshotTextBox struct {
+	Bounds *proto.DOMRect
+	Start  int
+	Length int
+}
+
+// DOMSnapshot captures the whole DOM tree with the layout, computed styles, and text in one CDP call,
+// it's much faster than querying the elements one by one. If opts is nil, no computed style will be captured.
+// Use [Page.CaptureDOMSnapshot] for the raw result.
+func (p *Page) DOMSnapshot(opts *DOMSnapshotOptions) (*DOMSnapshot, error) {
+	if opts == nil {
+		opts = &DOMSnapshotOptions{}
+	}
+
+	styles := opts.Styles
+	if styles == nil {
+		styles = []string{}
+	}
+
+	res, err := proto.DOMSnapshotCaptureSnapshot{
+		ComputedStyles:    styles,
+		IncludePaintOrder: opts.PaintOrder,
+		IncludeDOMRects:   opts.DOMRects,
+	}.Call(p)
+	if err != nil {
+		return nil, err
+	}
+
+	return newDOMSnapshot(res, styles), nil
+}
+
+// Walk the nodes of all the documents in the document order, return false to stop.
+func (s *DOMSnapshot) Walk(fn func(*SnapshotNode) bool) {
+	for _, doc := range s.Documents {
+		for _, n := range doc.Nodes {
+			if !fn(n) {
+				return
+			}
+		}
+	}
+}
+
+// Filter returns the nodes that fn returns true
+func (s *DOMSnapshot) Filter(fn func(*SnapshotNode) bool) []*SnapshotNode {
+	list := []*SnapshotNode{}
+	s.Walk(func(n *SnapshotNode) bool {
+		if fn(n) {
+			list = append(list, n)
+		}
+		return true
+	})
+	return list
+}
+
+// Text returns the rendered text of the node and its descendants
+func (n *SnapshotNode) Text() string {
+	str := ""
+	if n.Layout != nil && n.Type == 3 {
+		str += n.Layout.Text
+	}
+	for _, c := range n.Children {
+		str += c.Text()
+	}
+	return str
+}
+
+func newDOMSnapshot(res *proto.DOMSnapshotCaptureSnapshotResult, styles []string) *DOMSnapshot {
+	str := func(i proto.DOMSnapshotStringIndex) string {
+		if i < 0 || int(i) >= len(res.Strings) {
+			return ""
+		}
+		return res.Strings[i]
+	}
+
+	snapshot := &DOMSnapshot{}
+
+	// the node index to the content document index of the iframes
+	type link struct {
+		node *SnapshotNode
+		doc  int
+	}
+	links := []link{}
+
+	for _, d := range res.Documents {
+		doc := &SnapshotDocument{
+			URL:     str(d.DocumentURL),
+			Title:   str(d.Title),
+			FrameID: proto.PageFrameID(str(d.FrameID)),
+		}
+		if d.ScrollOffsetX != nil {
+			doc.ScrollX = *d.ScrollOffsetX
+		}
+		if d.ScrollOffsetY != nil {
+			doc.ScrollY = *d.ScrollOffsetY
+		}
+		if d.ContentWidth != nil {
+			doc.ContentWidth = *d.ContentWidth
+		}
+		if d.ContentHeight != nil {
+			doc.ContentHeight = *d.ContentHeight
+		}
+
+		tree := d.Nodes
+		for i := range tree.ParentIndex {
+			n := &SnapshotNode{Attributes: map[string]string{}}
+			if i < len(tree.NodeType) {
+				n.Type = tree.NodeType[i]
+			}
+			if i < len(tree.NodeName) {
+				n.Name = str(tree.NodeName[i])
+			}
+			if i < len(tree.NodeValue) {
+				n.Value = str(tree.NodeValue[i])
+			}
+			if i < len(tree.BackendNodeID) {
+				n.BackendNodeID = tree.BackendNodeID[i]
+			}
+			if i < len(tree.Attributes) {
+				attrs := tree.Attributes[i]
+				for j := 0; j+1 < len(attrs); j += 2 {
+					n.Attributes[str(attrs[j])] = str(attrs[j+1])
+				}
+			}
+
+			if parent := tree.ParentIndex[i]; parent >= 0 && parent < len(doc.Nodes) {
+				n.Parent = doc.Nodes[parent]
+				n.Parent.Children = append(n.Parent.Children, n)
+			}
+
+			doc.Nodes = append(doc.Nodes, n)
+		}
+
+		rareStr := func(data *proto.DOMSnapshotRareStringData, set func(*SnapshotNode, string)) {
+			if data == nil {
+				return
+			}
+			for j, i := range data.Index {
+				if i < len(doc.Nodes) && j < len(data.Value) {
+					set(doc.Nodes[i], str(data.Value[j]))
+				}
+			}
+		}
+		rareBool := func(data *proto.DOMSnapshotRareBooleanData, set func(*SnapshotNode)) {
+			if data == nil {
+				return
+			}
+			for _, i := range data.Index {
+				if i < len(doc.Nodes) {
+					set(doc.Nodes[i])
+				}
+			}
+		}
+
+		rareStr(tree.TextValue, func(n *SnapshotNode, v string) { n.TextValue = v })
+		rareStr(tree.InputValue, func(n *SnapshotNode, v string) { n.InputValue = v })
+		rareBool(tree.InputChecked, func(n *SnapshotNode) { n.InputChecked = true })
+		rareBool(tree.OptionSelected, func(n *SnapshotNode) { n.OptionSelected = true })
+		rareBool(tree.IsClickable, func(n *SnapshotNode) { n.IsClickable = true })
+
+		if data := tree.ContentDocumentIndex; data != nil {
+			for j, i := range data.Index {
+				if i < len(doc.Nodes) && j < len(data.Value) {
+					links = append(links, link{doc.Nodes[i], data.Value[j]})
+				}
+			}
+		}
+
+		layouts := []*SnapshotLayout{}
+		if l := d.Layout; l != nil {
+			for i, nodeIndex := range l.NodeIndex {
+				layout := &SnapshotLayout{Styles: map[string]string{}}
+				if i < len(l.Bounds) {
+					layout.Bounds = snapshotRect(l.Bounds[i])
+				}
+				if i < len(l.Text) {
+					layout.Text = str(l.Text[i])
+				}
+				if i < len(l.Styles) {
+					for j, v := range l.Styles[i] {
+						if j < len(styles) {
+							layout.Styles[styles[j]] = str(v)
+						}
+					}
+				}
+				if i < len(l.PaintOrders) {
+					layout.PaintOrder = l.PaintOrders[i]
+				}
+				if i < len(l.OffsetRects) {
+					layout.OffsetRect = snapshotRect(l.OffsetRects[i])
+				}
+				if i < len(l.ClientRects) {
+					layout.ClientRect = snapshotRect(l.ClientRects[i])
+				}
+				if i < len(l.ScrollRects) {
+					layout.ScrollRect = snapshotRect(l.ScrollRects[i])
+				}
+				if nodeIndex >= 0 && nodeIndex < len(doc.Nodes) {
+					doc.Nodes[nodeIndex].Layout = layout
+				}
+				layouts = append(layouts, layout)
+			}
+
+			if l.StackingContexts != nil {
+				for _, i := range l.StackingContexts.Index {
+					if i < len(layouts) {
+						layouts[i].StackingContext = true
+					}
+				}
+			}
+		}
+
+		if t := d.TextBoxes; t != nil {
+			for i, layoutIndex := range t.LayoutIndex {
+				if layoutIndex < 0 || layoutIndex >= len(layouts) {
+					continue
+				}
+				box := &SnapshotTextBox{}
+				if i < len(t.Bounds) {
+					box.Bounds = snapshotRect(t.Bounds[i])
+				}
+				if i < len(t.Start) {
+					box.Start = t.Start[i]
+				}
+				if i < len(t.Length) {
+					box.Length = t.Length[i]
+				}
+				layouts[layoutIndex].TextBoxes = append(layouts[layoutIndex].TextBoxes, box)
+			}
+		}
+
+		if len(doc.Nodes) > 0 {
+			doc.Root = doc.Nodes[0]
+		}
+
+		snapshot.Documents = append(snapshot.Documents, doc)
+	}
+
+	for _, l := range links {
+		if l.doc >= 0 && l.doc < len(snapshot.Documents) {
+			l.node.ContentDocument = snapshot.Documents[l.doc]
+		}
+	}
+
+	return snapshot
+}
+
+func snapshotRect(r proto.DOMSnapshotRectangle) *proto.DOMRect {
+	if len(r) < 4 {
+		return nil
+	}
+	return &proto.DOMRect{X: r[0], Y: r[1], Width: r[2], Height: r[3]}
+}
@@ -0,0 +1,62 @@
+package rod_test
+
+import (
+	"testing"
+
+	"github.com/Fromsko/rodPro"
+	"github.com/Fromsko/rodPro/lib/proto"
+)
+
+func TestDOMSnapshot(t *testing.T) {
+	g := setup(t)
+
+	p := g.newPage(g.html(`<html><body>
+		<div id="a" style="width: 100px; height: 50px; color: red">hello <b>world</b></div>
+		<input id="b" value="ok" type="checkbox" checked>
+		<div style="display: none">hidden</div>
+		<iframe srcdoc="<p>inner</p>"></iframe>
+	</body></html>`))
+	p.MustElement("iframe").MustFrame().MustElement("p")
+
+	s := p.MustDOMSnapshot("color", "display")
+	g.Gte(len(s.Documents), 2)
+
+	doc := s.Documents[0]
+	g.Eq(doc.Root.Type, 9)
+	g.Gt(doc.ContentWidth, 0)
+
+	a := s.Filter(func(n *rod.SnapshotNode) bool { return n.Attributes["id"] == "a" })[0]
+	g.Eq(a.Name, "DIV")
+	g.Eq(a.Layout.Bounds.Width, 100.0)
+	g.Eq(a.Layout.Styles["color"], "rgb(255, 0, 0)")
+	g.Eq(a.Layout.Styles["display"], "block")
+	g.Eq(a.Text(), "hello world")
+	g.Eq(a.Parent.Name, "BODY")
+	g.Gt(len(a.Children[0].Layout.TextBoxes), 0)
+
+	b := s.Filter(func(n *rod.SnapshotNode) bool { return n.Attributes["id"] == "b" })[0]
+	g.True(b.InputChecked)
+
+	hidden := s.Filter(func(n *rod.SnapshotNode) bool { return n.Value == "hidden" })[0]
+	g.Nil(hidden.Layout)
+
+	iframe := s.Filter(func(n *rod.SnapshotNode) bool { return n.Name == "IFRAME" })[0]
+	g.NotNil(iframe.ContentDocument)
+	g.Len(iframe.ContentDocument.Root.Children, 1)
+
+	count := 0
+	s.Walk(func(*rod.SnapshotNode) bool {
+		count++
+		return count < 3
+	})
+	g.Eq(count, 3)
+
+	full, err := p.DOMSnapshot(&rod.DOMSnapshotOptions{PaintOrder: true, DOMRects: true})
+	g.E(err)
+	a = full.Filter(func(n *rod.SnapshotNode) bool { return n.Attributes["id"] == "a" })[0]
+	g.Gt(a.Layout.PaintOrder, 0)
+	g.Eq(a.Layout.ClientRect.Width, 100.0)
+
+	g.mc.stubErr(1, proto.DOMSnapshotCaptureSnapshot{})
+	g.Err(p.DOMSnapshot(nil))
+}
@@ -64,18 +64,93 @@ func (el *Element) Focus() error {
 	return err
 }
 
+// the error of DOM.scrollIntoViewIfNeeded when the node can't be scrolled by the CDP
+const errMsgNoLayoutObject = "Node does not have a layout object"
+
 // ScrollIntoView scrolls the current element into the visible area of the browser
 // window if it's not already within the visible area.
 func (el *Element) ScrollIntoView() error {
 	defer el.tryTrace(TraceTypeInput, "scroll into view")()
-	el.page.browser.trySlowMotion()
+	el.page.browser.trySlowMotion("input.scroll")
+
+	err := el.WaitStableRAF()
+	if err != nil {
+		return err
+	}
+
+	err = proto.DOMScrollIntoViewIfNeeded{ObjectID: el.id()}.Call(el)
+
+	// such as the shadow DOM hosts that the CDP can't handle, fallback to the js
+	var cdpErr *cdp.Error
+	if errors.As(err, &cdpErr) && cdpErr.Message == errMsgNoLayoutObject {
+		return el.scrollIntoView(&ScrollIntoViewOptions{})
+	}
+
+	return err
+}
+
+// ScrollAlign of [ScrollIntoViewOptions], the values are the same as the js Element.scrollIntoView
+type ScrollAlign string
+
+const (
+	// ScrollAlignStart aligns the element to the start of the scroll container
+	ScrollAlignStart ScrollAlign = "start"
+	// ScrollAlignCenter aligns the element to the center of the scroll container
+	ScrollAlignCenter ScrollAlign = "center"
+	// ScrollAlignEnd aligns the element to the end of the scroll container
+	ScrollAlignEnd ScrollAlign = "end"
+	// ScrollAlignNearest aligns the element to the nearest edge of the scroll container
+	ScrollAlignNearest ScrollAlign = "nearest"
+)
+
+// ScrollIntoViewOptions for [Element.ScrollIntoViewIfNeeded]
+type ScrollIntoViewOptions struct {
+	// Block is the vertical alignment, default is [ScrollAlignCenter]
+	Block ScrollAlign
+
+	// Inline is the horizontal alignment, default is [ScrollAlignCenter]
+	Inline ScrollAlign
+
+	// OffsetTop is the height of the sticky header that may cover the element
+	OffsetTop float64
+
+	// OffsetLeft is the width of the sticky sidebar that may cover the element
+	OffsetLeft float64
+
+	// Always scroll even if the element is already in the visible area
+	Always bool
+}
+
+// ScrollIntoViewIfNeeded is similar to [Element.ScrollIntoView], but with the alignment options.
+// All the nested scroll containers, including the ones across the shadow roots, will be scrolled.
+// If opts is nil, it's the same as [Element.ScrollIntoView].
+func (el *Element) ScrollIntoViewIfNeeded(opts *ScrollIntoViewOptions) error {
+	if opts == nil {
+		return el.ScrollIntoView()
+	}
+
+	defer el.tryTrace(TraceTypeInput, "scroll into view")()
+	el.page.browser.trySlowMotion("input.scroll")
 
 	err := el.WaitStableRAF()
 	if err != nil {
 		return err
 	}
 
-	return proto.DOMScrollIntoViewIfNeeded{ObjectID: el.id()}.Call(el)
+	return el.scrollIntoView(opts)
+}
+
+func (el *Element) scrollIntoView(opts *ScrollIntoViewOptions) error {
+	block, inline := opts.Block, opts.Inline
+	if block == "" {
+		block = ScrollAlignCenter
+	}
+	if inline == "" {
+		inline = ScrollAlignCenter
+	}
+
+	_, err := el.Evaluate(evalHelper(js.ScrollIntoView, block, inline, opts.OffsetTop, opts.OffsetLeft, !opts.Always))
+	return err
 }
 
 // Hover the mouse over the center of the element.
@@ -99,18 +174,69 @@ func (el *Element) MoveMouseOut() error {
 	return el.page.Mouse.MoveTo(proto.NewPoint(box.X+box.Width, box.Y))
 }
 
+// HoverIntentInterval is the interval of the micro-moves of [Element.HoverAndWait]
+var HoverIntentInterval = 100 * time.Millisecond
+
+// HoverAndWait hovers the mouse over the element and waits for the element that matches the selector to be
+// visible, such as a flyout menu or a tooltip, then returns it. The selector is queried from the page, because
+// the flyouts are often appended to the body rather than the element. While waiting, the mouse keeps making
+// tiny moves within the element every [HoverIntentInterval], so the hover-intent libraries that ignore a still
+// or passing-by cursor will treat it as a real hover.
+func (el *Element) HoverAndWait(selector string) (*Element, error) {
+	err := el.Hover()
+	if err != nil {
+		return nil, err
+	}
+
+	defer el.tryTrace(TraceTypeWait, "hover and wait "+selector)()
+
+	p := el.page.Context(el.ctx)
+	center := p.Mouse.Position()
+
+	for i := 0; ; i++ {
+		has, found, err := p.Has(selector)
+		if err != nil {
+			return nil, err
+		}
+		if has {
+			visible, err := found.Visible()
+			if err != nil {
+				return nil, err
+			}
+			if visible {
+				return found, nil
+			}
+		}
+
+		err = humanSleep(el.ctx, HoverIntentInterval)
+		if err != nil {
+			return nil, timeoutErr(el.ctx, err, "HoverAndWait(%s) on %s", selector, el)
+		}
+
+		// wiggle around the center within 1px, so the cursor never leaves the element
+		offset := float64(i%2*2 - 1)
+		err = p.Mouse.MoveTo(proto.NewPoint(center.X+offset, center.Y+offset))
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
 // Click will press then release the button just like a human.
 // Before the action, it will try to scroll to the element, hover the mouse over it,
 // wait until the it's interactable and enabled.
-func (el *Element) Click(button proto.InputMouseButton, clickCount int) error {
-	err := el.Hover()
+func (el *Element) Click(button proto.InputMouseButton, clickCount int) (err error) {
+	el, end := el.startSpan("Click")
+	defer end(&err)
+
+	err = el.Hover()
 	if err != nil {
 		return err
 	}
 
 	err = el.WaitEnabled()
 	if err != nil {
-		return err
+		return el.blocked(err)
 	}
 
 	defer el.tryTrace(TraceTypeInput, string(button)+" click")()
@@ -128,7 +254,7 @@ func (el *Element) Tap() error {
 
 	err = el.WaitEnabled()
 	if err != nil {
-		return err
+		return el.blocked(err)
 	}
 
 	pt, err := el.WaitInteractable()
@@ -145,15 +271,23 @@ func (el *Element) Tap() error {
 // The cursor can be mouse, finger, stylus, etc.
 // If not interactable err will be ErrNotInteractable, such as when covered by a modal,
 func (el *Element) Interactable() (pt *proto.Point, err error) {
-	noPointerEvents, err := el.Eval(`() => getComputedStyle(this).pointerEvents === 'none'`)
+	style, err := el.Eval(`() => {
+		const s = getComputedStyle(this)
+		return { pointerEvents: s.pointerEvents, visibility: s.visibility }
+	}`)
 	if err != nil {
 		return nil, err
 	}
 
-	if noPointerEvents.Value.Bool() {
+	if style.Value.Get("pointerEvents").Str() == "none" {
 		return nil, &ErrNoPointerEvents{el}
 	}
 
+	// the element with visibility hidden still has a shape, but the events will go through it
+	if style.Value.Get("visibility").Str() == "hidden" {
+		return nil, &ErrInvisibleShape{el}
+	}
+
 	shape, err := el.Shape()
 	if err != nil {
 		return nil, err
@@ -213,6 +347,64 @@ func (el *Element) Type(keys ...input.Key) error {
 	return el.page.Context(el.ctx).Keyboard.Type(keys...)
 }
 
+// PressChord is similar with [Keyboard.PressChord].
+// Before the action, it will try to scroll to the element and focus on it.
+func (el *Element) PressChord(chords ...string) error {
+	err := el.Focus()
+	if err != nil {
+		return err
+	}
+	return el.page.Context(el.ctx).Keyboard.PressChord(chords...)
+}
+
+// TypeText is similar with [Keyboard.TypeText].
+// Before the action, it will try to scroll to the element and focus on it.
+func (el *Element) TypeText(text string) error {
+	err := el.Focus()
+	if err != nil {
+		return err
+	}
+	return el.page.Context(el.ctx).Keyboard.TypeText(text)
+}
+
+// ClearMode for [Element.Clear]
+type ClearMode int
+
+const (
+	// ClearModeSelectAll selects all the text via the keyboard shortcut then presses Backspace, just like a human
+	ClearModeSelectAll ClearMode = iota
+
+	// ClearModeValue sets the value, or the text of the contenteditable element, to empty,
+	// then dispatches the input and change events
+	ClearModeValue
+)
+
+// Clear the text of the input, textarea, or contenteditable element.
+// Before the action, it will try to scroll to the element and focus on it.
+func (el *Element) Clear(mode ClearMode) error {
+	err := el.Focus()
+	if err != nil {
+		return err
+	}
+
+	defer el.tryTrace(TraceTypeInput, "clear")()
+
+	if mode == ClearModeValue {
+		_, err = el.Evaluate(Eval(`() => {
+			if ('value' in this) this.value = ''
+			else if (this.isContentEditable) this.textContent = ''
+		}`).ByUser())
+		if err != nil {
+			return err
+		}
+
+		_, err = el.Evaluate(evalHelper(js.InputEvent).ByUser())
+		return err
+	}
+
+	return el.page.Context(el.ctx).Keyboard.PressChord("Mod+A", "Backspace")
+}
+
 // KeyActions is similar with Page.KeyActions.
 // Before the action, it will try to scroll to the element and focus on it.
 func (el *Element) KeyActions() (*KeyActions, error) {
@@ -233,7 +425,7 @@ func (el *Element) SelectText(regex string) error {
 	}
 
 	defer el.tryTrace(TraceTypeInput, "select text: "+regex)()
-	el.page.browser.trySlowMotion()
+	el.page.browser.trySlowMotion("input.select_text")
 
 	_, err = el.Evaluate(evalHelper(js.SelectText, regex).ByUser())
 	return err
@@ -248,7 +440,7 @@ func (el *Element) SelectAllText() error {
 	}
 
 	defer el.tryTrace(TraceTypeInput, "select all text")()
-	el.page.browser.trySlowMotion()
+	el.page.browser.trySlowMotion("input.select_text")
 
 	_, err = el.Evaluate(evalHelper(js.SelectAllText).ByUser())
 	return err
@@ -259,6 +451,8 @@ func (el *Element) SelectAllText() error {
 // To empty the input you can use something like
 //
 //	el.SelectAllText().MustInput("")
+//
+// Or use [Element.Clear].
 func (el *Element) Input(text string) error {
 	err := el.Focus()
 	if err != nil {
@@ -275,7 +469,34 @@ func (el *Element) Input(text string) error {
 		return err
 	}
 
-	err = el.page.Context(el.ctx).InsertText(text)
+	if h := el.page.Keyboard.getHuman(); h != nil {
+		err = el.page.Context(el.ctx).Keyboard.typeHuman(text, h)
+	} else {
+		err = el.page.Context(el.ctx).InsertText(text)
+	}
+	_, _ = el.Evaluate(evalHelper(js.InputEvent).ByUser())
+	return err
+}
+
+// InputHuman is similar to [Element.Input], but types the text like a human, see [Page.Humanize] for details.
+// If opts is nil, [DefaultHumanizeOptions] will be used.
+func (el *Element) InputHuman(text string, opts *HumanizeOptions) error {
+	err := el.Focus()
+	if err != nil {
+		return err
+	}
+
+	err = el.WaitEnabled()
+	if err != nil {
+		return err
+	}
+
+	err = el.WaitWritable()
+	if err != nil {
+		return err
+	}
+
+	err = el.page.Context(el.ctx).Keyboard.TypeHuman(text, opts)
 	_, _ = el.Evaluate(evalHelper(js.InputEvent).ByUser())
 	return err
 }
@@ -283,7 +504,19 @@ func (el *Element) Input(text string) error {
 // InputTime focuses on the element and input time to it.
 // Before the action, it will scroll to the element, wait until it's visible, enabled and writable.
 // It will wait until the element is visible, enabled and writable.
+// The supported input types are date, datetime-local, month, week, and time, the value is formatted in the
+// location of t. The seconds and milliseconds are only kept when the step attribute of the input allows them.
 func (el *Element) InputTime(t time.Time) error {
+	return el.inputTime(t, false)
+}
+
+// InputDate is similar to [Element.InputTime], but only inputs the date part of t,
+// the time part of the datetime-local input will be kept. It returns error for the time input.
+func (el *Element) InputDate(t time.Time) error {
+	return el.inputTime(t, true)
+}
+
+func (el *Element) inputTime(t time.Time, dateOnly bool) error {
 	err := el.Focus()
 	if err != nil {
 		return err
@@ -301,12 +534,26 @@ func (el *Element) InputTime(t time.Time) error {
 
 	defer el.tryTrace(TraceTypeInput, "input "+t.String())()
 
-	_, err = el.Evaluate(evalHelper(js.InputTime, t.UnixNano()/1e6).ByUser())
+	weekYear, week := t.ISOWeek()
+
+	_, err = el.Evaluate(evalHelper(js.InputTime, map[string]int{
+		"year":        t.Year(),
+		"month":       int(t.Month()),
+		"day":         t.Day(),
+		"hour":        t.Hour(),
+		"minute":      t.Minute(),
+		"second":      t.Second(),
+		"millisecond": t.Nanosecond() / 1e6,
+		"weekYear":    weekYear,
+		"week":        week,
+	}, dateOnly).ByUser())
 	return err
 }
 
 // InputColor focuses on the element and inputs a color string to it.
 // Before the action, it will scroll to the element, wait until it's visible, enabled and writable.
+// The color can be any CSS color without transparency, such as "red" or "rgb(255, 0, 0)",
+// it will be normalized to the "#rrggbb" format.
 func (el *Element) InputColor(color string) error {
 	err := el.Focus()
 	if err != nil {
@@ -329,6 +576,88 @@ func (el *Element) InputColor(color string) error {
 	return err
 }
 
+// SetChecked sets the checked state of the checkbox or radio, it does nothing if the state is already as expected.
+// It clicks the element if it's interactable, otherwise clicks its label, such as when the input is
+// visually hidden and covered by a styled label, then fallback to the js click. If the final state is
+// not as expected, such as unchecking a radio, [ErrCheckedState] will be returned.
+func (el *Element) SetChecked(checked bool) error {
+	isChecked := func() (bool, error) {
+		res, err := el.Eval(`() => {
+			if (this.type !== 'checkbox' && this.type !== 'radio') throw new Error('not a checkbox or radio')
+			return this.checked
+		}`)
+		if err != nil {
+			return false, err
+		}
+		return res.Value.Bool(), nil
+	}
+
+	state, err := isChecked()
+	if err != nil || state == checked {
+		return err
+	}
+
+	defer el.tryTrace(TraceTypeInput, fmt.Sprintf("set checked %v", checked))()
+
+	err = el.clickOrLabel()
+	if err != nil {
+		return err
+	}
+
+	state, err = isChecked()
+	if err != nil {
+		return err
+	}
+	if state != checked {
+		return &ErrCheckedState{el, checked}
+	}
+	return nil
+}
+
+func (el *Element) clickOrLabel() error {
+	// the scrolling waits for the element to be visible, so the hidden elements, such as the
+	// custom checkboxes whose inputs are display:none, must skip it and go to the fallbacks
+	clicked, err := el.scrollAndClick()
+	if err != nil || clicked {
+		return err
+	}
+
+	label, err := el.ElementByJS(Eval(`() => (this.labels && this.labels[0]) || this.closest('label')`))
+	if err != nil && !errors.Is(err, &ErrElementNotFound{}) {
+		return err
+	}
+	if label != nil {
+		clicked, err := label.scrollAndClick()
+		if err != nil || clicked {
+			return err
+		}
+	}
+
+	_, err = el.Evaluate(Eval(`() => this.click()`).ByUser())
+	return err
+}
+
+// scrollAndClick clicks the element if it's visible and interactable, it returns false if it's not clicked.
+func (el *Element) scrollAndClick() (bool, error) {
+	visible, err := el.Visible()
+	if err != nil || !visible {
+		return false, err
+	}
+
+	err = el.ScrollIntoView()
+	if err != nil {
+		return false, err
+	}
+
+	// such as the element is covered, the error can be ignored because there are fallbacks
+	pt, err := el.Interactable()
+	if err != nil {
+		return false, nil
+	}
+
+	return true, el.page.Context(el.ctx).Mouse.moveAndClick(*pt)
+}
+
 // Blur removes focus from the element.
 func (el *Element) Blur() error {
 	_, err := el.Evaluate(Eval("() => this.blur()").ByUser())
@@ -345,7 +674,7 @@ func (el *Element) Select(selectors []string, selected bool, t SelectorType) err
 	}
 
 	defer el.tryTrace(TraceTypeInput, fmt.Sprintf(`select "%s"`, strings.Join(selectors, "; ")))()
-	el.page.browser.trySlowMotion()
+	el.page.browser.trySlowMotion("input.select")
 
 	res, err := el.Evaluate(evalHelper(js.Select, selectors, selected, t).ByUser())
 	if err != nil {
@@ -359,7 +688,7 @@ func (el *Element) Select(selectors []string, selected bool, t SelectorType) err
 
 // Matches checks if the element can be selected by the css selector
 func (el *Element) Matches(selector string) (bool, error) {
-	res, err := el.Eval(`s => this.matches(s)`, selector)
+	res, err := el.Evaluate(Eval(`s => this.matches(s)`, selector).polyfillFor(selector))
 	if err != nil {
 		return false, err
 	}
@@ -407,7 +736,7 @@ func (el *Element) SetFiles(paths []string) error {
 	absPaths := utils.AbsolutePaths(paths)
 
 	defer el.tryTrace(TraceTypeInput, fmt.Sprintf("set files: %v", absPaths))()
-	el.page.browser.trySlowMotion()
+	el.page.browser.trySlowMotion("input.set_files")
 
 	err := proto.DOMSetFileInputFiles{
 		Files:    absPaths,
@@ -495,7 +824,9 @@ func (el *Element) HTML() (string, error) {
 	return res.OuterHTML, nil
 }
 
-// Visible returns true if the element is visible on the page
+// Visible returns true if the element is visible on the page.
+// The element is invisible if its display is none, visibility is hidden, or it has no size.
+// The transparent element is still visible, such as a custom checkbox, use [Element.VisibleOpaque] to exclude it.
 func (el *Element) Visible() (bool, error) {
 	res, err := el.Evaluate(evalHelper(js.Visible))
 	if err != nil {
@@ -504,6 +835,50 @@ func (el *Element) Visible() (bool, error) {
 	return res.Value.Bool(), nil
 }
 
+// VisibleOpaque is similar to [Element.Visible], but the element whose opacity is 0 is invisible.
+func (el *Element) VisibleOpaque() (bool, error) {
+	res, err := el.Evaluate(evalHelper(js.Visible, true))
+	if err != nil {
+		return false, err
+	}
+	return res.Value.Bool(), nil
+}
+
+// ComputedStyle returns the computed values of the CSS properties of the element via [proto.CSSGetComputedStyleForNode].
+// If props is empty all the properties will be returned.
+func (el *Element) ComputedStyle(props ...string) (map[string]string, error) {
+	defer el.page.EnableDomain(proto.DOMEnable{})()
+	defer el.page.EnableDomain(proto.CSSEnable{})()
+
+	_, err := proto.DOMGetDocument{}.Call(el)
+	if err != nil {
+		return nil, err
+	}
+
+	node, err := proto.DOMRequestNode{ObjectID: el.Object.ObjectID}.Call(el)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := proto.CSSGetComputedStyleForNode{NodeID: node.NodeID}.Call(el)
+	if err != nil {
+		return nil, err
+	}
+
+	want := map[string]bool{}
+	for _, p := range props {
+		want[p] = true
+	}
+
+	style := map[string]string{}
+	for _, p := range res.ComputedStyle {
+		if len(props) == 0 || want[p.Name] {
+			style[p.Name] = p.Value
+		}
+	}
+	return style, nil
+}
+
 // WaitLoad for element like <img>
 func (el *Element) WaitLoad() error {
 	defer el.tryTrace(TraceTypeWait, "load")()
@@ -534,7 +909,7 @@ func (el *Element) WaitStable(d time.Duration) error {
 		select {
 		case <-t.C:
 		case <-el.ctx.Done():
-			return el.ctx.Err()
+			return timeoutErr(el.ctx, el.ctx.Err(), "WaitStable(%v) on %s", d, el)
 		}
 		current, err := el.Shape()
 		if err != nil {
@@ -582,6 +957,7 @@ func (el *Element) WaitStableRAF() error {
 
 // WaitInteractable waits for the element to be interactable.
 // It will try to scroll to the element on each try.
+// If it times out, the err will be [ErrBlocked] that tells what blocks the element.
 func (el *Element) WaitInteractable() (pt *proto.Point, err error) {
 	defer el.tryTrace(TraceTypeWait, "interactable")()
 
@@ -599,6 +975,7 @@ func (el *Element) WaitInteractable() (pt *proto.Point, err error) {
 		}
 		return true, err
 	})
+	err = el.blocked(timeoutErr(el.ctx, err, "WaitInteractable() on %s", el))
 	return
 }
 
@@ -610,27 +987,38 @@ func (el *Element) Wait(opts *EvalOptions) error {
 // WaitVisible until the element is visible
 func (el *Element) WaitVisible() error {
 	defer el.tryTrace(TraceTypeWait, "visible")()
-	return el.Wait(evalHelper(js.Visible))
+	return timeoutErr(el.ctx, el.Wait(evalHelper(js.Visible)), "WaitVisible() on %s", el)
+}
+
+// WaitStyle until the computed value of the CSS property equals the value, such as:
+//
+//	el.WaitStyle("opacity", "1")
+//
+// The value must be in the computed form, for example colors are in the "rgb(255, 0, 0)" format.
+func (el *Element) WaitStyle(prop, value string) error {
+	defer el.tryTrace(TraceTypeWait, "style "+prop)()
+	err := el.Wait(Eval(`(p, v) => getComputedStyle(this).getPropertyValue(p) === v`, prop, value))
+	return timeoutErr(el.ctx, err, "WaitStyle(`%s`, `%s`) on %s", prop, value, el)
 }
 
 // WaitEnabled until the element is not disabled.
 // Doc for readonly: https://developer.mozilla.org/en-US/docs/Web/HTML/Attributes/readonly
 func (el *Element) WaitEnabled() error {
 	defer el.tryTrace(TraceTypeWait, "enabled")()
-	return el.Wait(Eval(`() => !this.disabled`))
+	return timeoutErr(el.ctx, el.Wait(Eval(`() => !this.disabled`)), "WaitEnabled() on %s", el)
 }
 
 // WaitWritable until the element is not readonly.
 // Doc for disabled: https://developer.mozilla.org/en-US/docs/Web/HTML/Attributes/disabled
 func (el *Element) WaitWritable() error {
 	defer el.tryTrace(TraceTypeWait, "writable")()
-	return el.Wait(Eval(`() => !this.readonly`))
+	return timeoutErr(el.ctx, el.Wait(Eval(`() => !this.readonly`)), "WaitWritable() on %s", el)
 }
 
 // WaitInvisible until the element invisible
 func (el *Element) WaitInvisible() error {
 	defer el.tryTrace(TraceTypeWait, "invisible")()
-	return el.Wait(evalHelper(js.Invisible))
+	return timeoutErr(el.ctx, el.Wait(evalHelper(js.Invisible)), "WaitInvisible() on %s", el)
 }
 
 // CanvasToImage get image data of a canvas.
@@ -723,7 +1111,12 @@ func (el *Element) Call(ctx context.Context, sessionID, methodName string, param
 }
 
 // Eval is a shortcut for [Element.Evaluate] with AwaitPromise, ByValue and AutoExp set to true.
-func (el *Element) Eval(js string, params ...interface{}) (*proto.RuntimeRemoteObject, error) {
+func (el *Element) Eval(js string, params ...interface{}) (res *proto.RuntimeRemoteObject, err error) {
+	el, end := el.startSpan("Eval")
+	defer end(&err)
+
+	el.page.browser.trySlowMotion("eval.eval")
+
 	return el.Evaluate(Eval(js, params...).ByPromise())
 }
 
@@ -159,6 +159,50 @@ func TestInteractableWithNoShape(t *testing.T) {
 	el = p.MustElement("#invisible")
 	_, err = el.Interactable()
 	g.Is(err, &rod.ErrInvisibleShape{})
+
+	el = p.MustElement("#hidden")
+	_, err = el.Interactable()
+	g.Is(err, &rod.ErrInvisibleShape{})
+	g.False(el.MustVisible())
+
+	g.True(p.MustElement("#transparent").MustVisible())
+	g.False(p.MustElement("#transparent").MustVisibleOpaque())
+	el = p.MustElement("#hidden")
+	el.MustEval(`() => this.style.visibility = 'visible'`)
+	g.True(el.MustVisibleOpaque())
+}
+
+func TestElementComputedStyle(t *testing.T) {
+	g := setup(t)
+
+	p := g.page.MustNavigate(g.srcFile("fixtures/interactable.html"))
+
+	style := p.MustElement("#transparent").MustComputedStyle("opacity", "display")
+	g.Eq(style, map[string]string{"opacity": "0", "display": "inline-block"})
+
+	g.Has(p.MustElement("#hidden").MustComputedStyle(), "visibility")
+
+	el := p.MustElement("#hidden")
+
+	g.mc.stubErr(1, proto.DOMGetDocument{})
+	g.Err(el.ComputedStyle())
+
+	g.mc.stubErr(1, proto.DOMRequestNode{})
+	g.Err(el.ComputedStyle())
+
+	g.mc.stubErr(1, proto.CSSGetComputedStyleForNode{})
+	g.Err(el.ComputedStyle())
+}
+
+func TestElementWaitStyle(t *testing.T) {
+	g := setup(t)
+
+	p := g.page.MustNavigate(g.srcFile("fixtures/interactable.html"))
+	el := p.MustElement("#transparent")
+
+	el.MustEval(`() => setTimeout(() => this.style.opacity = '1', 100)`)
+	el.MustWaitStyle("opacity", "1")
+	g.True(el.MustVisibleOpaque())
 }
 
 func TestNotInteractableWithNoPointerEvents(t *testing.T) {
@@ -195,6 +239,57 @@ func TestWaitInteractable(t *testing.T) {
 	g.Err(el.WaitInteractable())
 }
 
+func TestElementScrollIntoViewIfNeeded(t *testing.T) {
+	g := setup(t)
+
+	p := g.page.MustNavigate(g.srcFile("fixtures/scroll-container.html"))
+	el := p.MustElement("#target")
+
+	el.MustScrollIntoViewIfNeeded(&rod.ScrollIntoViewOptions{
+		Block:     rod.ScrollAlignStart,
+		OffsetTop: 50,
+	})
+
+	top := el.MustEval(`() => this.getBoundingClientRect().top`).Int()
+	g.Eq(top, 50)
+	g.Gt(p.MustElement("#container").MustEval(`() => this.scrollTop`).Int(), 0)
+
+	// already visible, nothing should change
+	p.MustEval(`() => window.scrollBy(0, 10)`)
+	el.MustScrollIntoViewIfNeeded(&rod.ScrollIntoViewOptions{Block: rod.ScrollAlignStart, OffsetTop: 50})
+	g.Eq(el.MustEval(`() => this.getBoundingClientRect().top`).Int(), 40)
+
+	el.MustScrollIntoViewIfNeeded(&rod.ScrollIntoViewOptions{Block: rod.ScrollAlignStart, OffsetTop: 50, Always: true})
+	g.Eq(el.MustEval(`() => this.getBoundingClientRect().top`).Int(), 50)
+
+	el.MustScrollIntoViewIfNeeded(nil)
+
+	g.mc.stubErr(1, proto.RuntimeCallFunctionOn{})
+	g.Err(el.ScrollIntoViewIfNeeded(&rod.ScrollIntoViewOptions{}))
+
+	g.mc.stubErr(2, proto.RuntimeCallFunctionOn{})
+	g.Err(el.ScrollIntoViewIfNeeded(&rod.ScrollIntoViewOptions{}))
+}
+
+func TestElementScrollIntoViewFallback(t *testing.T) {
+	g := setup(t)
+
+	p := g.page.MustNavigate(g.srcFile("fixtures/scroll.html"))
+	el := p.MustElement("button")
+
+	g.mc.stub(1, proto.DOMScrollIntoViewIfNeeded{}, func(send StubSend) (gson.JSON, error) {
+		return gson.New(nil), &cdp.Error{Code: -32000, Message: "Node does not have a layout object"}
+	})
+	el.MustScrollIntoView()
+
+	g.True(el.MustInteractable())
+
+	g.mc.stub(1, proto.DOMScrollIntoViewIfNeeded{}, func(send StubSend) (gson.JSON, error) {
+		return gson.New(nil), &cdp.Error{Code: -32000, Message: "Node is detached from document"}
+	})
+	g.Err(el.ScrollIntoView())
+}
+
 func TestHover(t *testing.T) {
 	g := setup(t)
 
@@ -229,6 +324,24 @@ func TestElementMoveMouseOut(t *testing.T) {
 	g.Err(btn.MoveMouseOut())
 }
 
+func TestElementHoverAndWait(t *testing.T) {
+	g := setup(t)
+
+	p := g.page.MustNavigate(g.srcFile("fixtures/hover-intent.html"))
+	menu := p.MustElement("#menu")
+
+	g.Eq(menu.MustHoverAndWait("#flyout").MustText(), "flyout")
+
+	_, err := menu.Timeout(300 * time.Millisecond).HoverAndWait("#none")
+	g.Is(err, &rod.ErrTimeout{})
+
+	g.mc.stubErr(1, proto.DOMGetContentQuads{})
+	g.Err(menu.HoverAndWait("#flyout"))
+
+	g.mc.stubErr(2, proto.InputDispatchMouseEvent{})
+	g.Err(menu.HoverAndWait("#none"))
+}
+
 func TestElementContext(t *testing.T) {
 	g := setup(t)
 
@@ -343,6 +456,22 @@ func TestInputTime(t *testing.T) {
 		g.True(p.MustHas("[event=input-month-change]"))
 	}
 
+	{
+		el = p.MustElement("[type=week]")
+		el.MustInputTime(now)
+
+		g.Eq(el.MustText(), "2006-W01")
+		g.True(p.MustHas("[event=input-week-change]"))
+	}
+
+	{
+		el = p.MustElement("#time-seconds")
+		el.MustInputTime(now.In(time.FixedZone("", 3600)))
+
+		g.Eq(el.MustText(), now.In(time.FixedZone("", 3600)).Format("15:04:05"))
+		g.True(p.MustHas("[event=input-time-seconds-change]"))
+	}
+
 	g.Panic(func() {
 		g.mc.stubErr(1, proto.RuntimeCallFunctionOn{})
 		el.MustInputTime(now)
@@ -389,6 +518,37 @@ func TestInputColor(t *testing.T) {
 	})
 }
 
+func TestInputDate(t *testing.T) {
+	g := setup(t)
+
+	p := g.page.MustNavigate(g.srcFile("fixtures/input.html"))
+	date := time.Date(2006, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	el := p.MustElement("[type=date]").MustInputDate(date)
+	g.Eq(el.MustText(), "2006-01-02")
+
+	el = p.MustElement("[type=datetime-local]").MustInputTime(time.Date(2000, 1, 1, 10, 11, 0, 0, time.UTC))
+	el.MustInputDate(date)
+	g.Eq(el.MustText(), "2006-01-02T10:11")
+
+	g.Err(p.MustElement("[type=time]").InputDate(date))
+	g.Err(p.MustElement("[type=text]").InputTime(date))
+}
+
+func TestInputColorNormalize(t *testing.T) {
+	g := setup(t)
+
+	p := g.page.MustNavigate(g.srcFile("fixtures/input.html"))
+	el := p.MustElement("[type=color]")
+
+	g.Eq(el.MustInputColor("red").MustText(), "#ff0000")
+	g.Eq(el.MustInputColor("rgb(0, 128, 255)").MustText(), "#0080ff")
+	g.Eq(el.MustInputColor("#ABC").MustText(), "#aabbcc")
+
+	g.Err(el.InputColor("not-a-color"))
+	g.Err(el.InputColor("rgba(0, 0, 0, 0.5)"))
+}
+
 func TestElementInputDate(t *testing.T) {
 	g := setup(t)
 
@@ -396,6 +556,50 @@ func TestElementInputDate(t *testing.T) {
 	p.MustElement("[type=date]").MustInput("12")
 }
 
+func TestSetChecked(t *testing.T) {
+	g := setup(t)
+
+	p := g.page.MustNavigate(g.srcFile("fixtures/checkbox.html"))
+
+	checked := func(selector string) bool {
+		return p.MustElement(selector).MustProperty("checked").Bool()
+	}
+
+	for _, selector := range []string{"#plain", "#wrapped", "#display-none", "#no-label"} {
+		el := p.MustElement(selector)
+		el.MustSetChecked(true)
+		g.True(checked(selector))
+		el.MustSetChecked(true)
+		g.True(checked(selector))
+		el.MustSetChecked(false)
+		g.False(checked(selector))
+	}
+
+	p.MustElement("#radio-a").MustSetChecked(true)
+	g.True(checked("#radio-a"))
+	p.MustElement("#radio-b").MustSetChecked(true)
+	g.False(checked("#radio-a"))
+
+	err := p.MustElement("#radio-b").SetChecked(false)
+	g.Is(err, &rod.ErrCheckedState{})
+	g.Eq(err.Error(), "failed to set checked state to false: <input#radio-b>")
+
+	g.Is(p.MustElement("#readonly").SetChecked(true), &rod.ErrCheckedState{})
+
+	g.Err(p.MustElement("#text").SetChecked(true))
+
+	el := p.MustElement("#plain")
+
+	g.mc.stubErr(1, proto.RuntimeCallFunctionOn{})
+	g.Err(el.SetChecked(true))
+
+	g.mc.stubErr(1, proto.DOMScrollIntoViewIfNeeded{})
+	g.Err(el.SetChecked(true))
+
+	g.mc.stubErr(1, proto.InputDispatchMouseEvent{})
+	g.Err(el.SetChecked(true))
+}
+
 func TestCheckbox(t *testing.T) {
 	g := setup(t)
 
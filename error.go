@@ -3,6 +3,9 @@ package rod
 import (
 	"context"
 	"fmt"
+	"image/color"
+	"strings"
+	"time"
 
 	"github.com/Fromsko/rodPro/lib/proto"
 	"github.com/Fromsko/rodPro/lib/utils"
@@ -191,3 +194,220 @@ func (e *ErrNoShadowRoot) Error() string {
 
 // Is interface
 func (e *ErrNoShadowRoot) Is(err error) bool { _, ok := err.(*ErrNoShadowRoot); return ok }
+
+// ErrCredentialNotFound error
+type ErrCredentialNotFound struct {
+	ID []byte
+}
+
+func (e *ErrCredentialNotFound) Error() string {
+	return fmt.Sprintf("cannot find webauthn credential: %x", e.ID)
+}
+
+// Is interface
+func (e *ErrCredentialNotFound) Is(err error) bool { _, ok := err.(*ErrCredentialNotFound); return ok }
+
+// ErrCheckedState error
+type ErrCheckedState struct {
+	*Element
+	Checked bool
+}
+
+// Error ...
+func (e *ErrCheckedState) Error() string {
+	return fmt.Sprintf("failed to set checked state to %v: %s", e.Checked, e.String())
+}
+
+// Is interface
+func (e *ErrCheckedState) Is(err error) bool { _, ok := err.(*ErrCheckedState); return ok }
+
+// ErrUnmatchedFields error
+type ErrUnmatchedFields struct {
+	Fields []string
+}
+
+func (e *ErrUnmatchedFields) Error() string {
+	return fmt.Sprintf("cannot find form fields: %s", strings.Join(e.Fields, ", "))
+}
+
+// Is interface
+func (e *ErrUnmatchedFields) Is(err error) bool { _, ok := err.(*ErrUnmatchedFields); return ok }
+
+// ErrHandleLeak error
+type ErrHandleLeak struct {
+	Page      *Page
+	Count     int
+	Threshold int
+}
+
+func (e *ErrHandleLeak) Error() string {
+	return fmt.Sprintf("possible handle leak on %s: %d outstanding handles exceed the threshold %d", e.Page, e.Count, e.Threshold)
+}
+
+// Is interface
+func (e *ErrHandleLeak) Is(err error) bool { _, ok := err.(*ErrHandleLeak); return ok }
+
+// ErrTimeout error, it wraps the [context.DeadlineExceeded] with the operation that times out
+type ErrTimeout struct {
+	// Op is the operation, such as "Element(`#login`)"
+	Op string
+
+	// Timeout of [Page.Timeout] or [Page.WithDeadline], zero if the deadline is from a custom context
+	Timeout time.Duration
+
+	// Elapsed since the timeout is set, zero if the deadline is from a custom context
+	Elapsed time.Duration
+
+	Err error
+}
+
+func (e *ErrTimeout) Error() string {
+	if e.Timeout > 0 {
+		return fmt.Sprintf("timeout after %v (%v elapsed) waiting %s", e.Timeout, e.Elapsed.Round(time.Millisecond), e.Op)
+	}
+	return fmt.Sprintf("timeout waiting %s", e.Op)
+}
+
+// Unwrap stdlib interface
+func (e *ErrTimeout) Unwrap() error {
+	return e.Err
+}
+
+// Is interface
+func (e *ErrTimeout) Is(err error) bool { _, ok := err.(*ErrTimeout); return ok }
+
+// ErrArtifacts error, it wraps the error that the artifacts are captured for
+type ErrArtifacts struct {
+	Err error
+
+	// Dir of the artifacts
+	Dir string
+}
+
+func (e *ErrArtifacts) Error() string {
+	return fmt.Sprintf("%v (artifacts: %s)", e.Err, e.Dir)
+}
+
+// Unwrap stdlib interface
+func (e *ErrArtifacts) Unwrap() error {
+	return e.Err
+}
+
+// Is interface
+func (e *ErrArtifacts) Is(err error) bool { _, ok := err.(*ErrArtifacts); return ok }
+
+// ErrRobotsDisallowed error, the url is disallowed by the robots.txt, check [Browser.RobotsPolicy]
+type ErrRobotsDisallowed struct {
+	URL string
+}
+
+func (e *ErrRobotsDisallowed) Error() string {
+	return "disallowed by robots.txt: " + e.URL
+}
+
+// Is interface
+func (e *ErrRobotsDisallowed) Is(err error) bool { _, ok := err.(*ErrRobotsDisallowed); return ok }
+
+// ErrSitemap error, the sitemap can't be fetched or parsed
+type ErrSitemap struct {
+	URL string
+	Err error
+}
+
+func (e *ErrSitemap) Error() string {
+	return fmt.Sprintf("failed to load sitemap %s: %v", e.URL, e.Err)
+}
+
+// Unwrap stdlib interface
+func (e *ErrSitemap) Unwrap() error {
+	return e.Err
+}
+
+// Is interface
+func (e *ErrSitemap) Is(err error) bool { _, ok := err.(*ErrSitemap); return ok }
+
+// ErrCaptchaNotFound error, the page has no known captcha widget, check [Page.FindCaptcha]
+type ErrCaptchaNotFound struct{}
+
+func (e *ErrCaptchaNotFound) Error() string {
+	return "no captcha widget found on the page"
+}
+
+// Is interface
+func (e *ErrCaptchaNotFound) Is(err error) bool { _, ok := err.(*ErrCaptchaNotFound); return ok }
+
+// ErrLoginFailed error, a failure predicate of the [LoginFlow] matched
+type ErrLoginFailed struct {
+	Reason string
+}
+
+func (e *ErrLoginFailed) Error() string {
+	return fmt.Sprintf("login failed: %s", e.Reason)
+}
+
+// Is interface
+func (e *ErrLoginFailed) Is(err error) bool { _, ok := err.(*ErrLoginFailed); return ok }
+
+// ErrBlocked error, the element isn't interactable before the timeout, check [Element.Diagnose]
+type ErrBlocked struct {
+	*Element
+	Diagnosis *InteractableDiagnosis
+	Err       error
+}
+
+func (e *ErrBlocked) Error() string {
+	return fmt.Sprintf("%v: %s is %s", e.Err, e.Element, e.Diagnosis)
+}
+
+// Unwrap stdlib interface
+func (e *ErrBlocked) Unwrap() error {
+	return e.Err
+}
+
+// Is interface
+func (e *ErrBlocked) Is(err error) bool {
+	switch err.(type) {
+	case *ErrBlocked, *ErrNotInteractable:
+		return true
+	}
+	return false
+}
+
+// ErrCanvasColor error, check [Element.CanvasExpectColorAt]
+type ErrCanvasColor struct {
+	X, Y     int
+	Expected color.NRGBA
+	Actual   color.NRGBA
+}
+
+func (e *ErrCanvasColor) Error() string {
+	return fmt.Sprintf("expect the canvas color at (%d, %d) to be %v, but got %v", e.X, e.Y, e.Expected, e.Actual)
+}
+
+// Is interface
+func (e *ErrCanvasColor) Is(err error) bool { _, ok := err.(*ErrCanvasColor); return ok }
+
+// ErrUnsupportedCapability error, the browser doesn't support the command, domain, or version,
+// check [Browser.Capabilities]
+type ErrUnsupportedCapability struct {
+	// Name of the capability, such as "Storage" or "Page.captureScreenshot"
+	Name string
+
+	// Err is the protocol error if the capability is detected by a failed call
+	Err error
+}
+
+func (e *ErrUnsupportedCapability) Error() string {
+	return "unsupported capability: " + e.Name
+}
+
+// Unwrap stdlib interface
+func (e *ErrUnsupportedCapability) Unwrap() error {
+	return e.Err
+}
+
+// Is interface
+func (e *ErrUnsupportedCapability) Is(err error) bool {
+	_, ok := err.(*ErrUnsupportedCapability)
+	return ok
+}
@@ -0,0 +1,192 @@
+// This file serves for extracting data from the page in a single round trip.
+
+package rod
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/Fromsko/rodPro/lib/js"
+	"github.com/Fromsko/rodPro/lib/proto"
+	"github.com/Fromsko/rodPro/lib/scrape"
+	"github.com/ysmood/gson"
+)
+
+// Extract the data of the fields in a single call, the keys of the fields are the keys of the result,
+// the values are the specs to extract the data, the format of a spec is:
+//
+//	selector        the trimmed innerText of the first element that matches the css selector
+//	selector@attr   the attribute of the element, such as "a@href"
+//	selector@html   the innerHTML of the element
+//	spec[]          the list of the data of all the matched elements, such as "li[]" or "a@href[]"
+//
+// If no element matches the selector, the value will be null, or an empty list for the "[]" spec.
+// Such as:
+//
+//	data, err := page.Extract(map[string]string{"title": "h1", "price": ".price", "links": "a@href[]"})
+//	fmt.Println(data["title"].Str(), data["links"].Arr())
+func (p *Page) Extract(fields map[string]string) (map[string]gson.JSON, error) {
+	specs := map[string]extractSpec{}
+	for k, v := range fields {
+		specs[k] = parseExtractSpec(v)
+	}
+
+	res, err := p.Evaluate(evalHelper(js.Extract, specs))
+	if err != nil {
+		return nil, err
+	}
+
+	return res.Value.Map(), nil
+}
+
+// ExtractStruct is similar to [Page.Extract], but the fields are defined by the "extract" tags of the struct,
+// the data is a pointer to struct. The fields without the tag are skipped. Such as:
+//
+//	type Product struct {
+//		Title string   `extract:"h1"`
+//		Price float64  `extract:".price"`      // the text will be decoded as json for non-string types
+//		Tags  []string `extract:".tag[]"`
+//		Link  *string  `extract:"a.more@href"` // nil if not found
+//	}
+//
+//	var product Product
+//	err := page.ExtractStruct(&product)
+func (p *Page) ExtractStruct(data interface{}) error {
+	v := reflect.ValueOf(data)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("expect a pointer to struct, got %T", data)
+	}
+	v = v.Elem()
+
+	fields := map[string]string{}
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Type().Field(i)
+		spec := f.Tag.Get("extract")
+		if f.PkgPath != "" || spec == "" || spec == "-" {
+			continue
+		}
+		fields[f.Name] = spec
+	}
+
+	res, err := p.Extract(fields)
+	if err != nil {
+		return err
+	}
+
+	for name, val := range res {
+		err = setExtracted(v.FieldByName(name), val)
+		if err != nil {
+			return fmt.Errorf("failed to set field %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// Scrape fills the data via the "rod" tags of the struct in a single call, the hooks post-process the
+// extracted strings of the fields that have the "hook" tag option, check the [scrape] package for the tag format.
+// Such as:
+//
+//	type Product struct {
+//		Name  string  `rod:"h2"`
+//		Price float64 `rod:".price,hook=currency"`
+//		Link  *string `rod:"a.more,attr=href"`
+//	}
+//
+//	var products struct {
+//		List []Product `rod:".product"`
+//	}
+//	err := page.Scrape(&products, scrape.Hooks{"currency": func(s string) (string, error) {
+//		return strings.TrimPrefix(s, "$"), nil
+//	}})
+func (p *Page) Scrape(data interface{}, hooks ...scrape.Hooks) error {
+	return p.scrape(nil, data, hooks)
+}
+
+// Scrape is similar to [Page.Scrape], but the selectors are relative to the element.
+func (el *Element) Scrape(data interface{}, hooks ...scrape.Hooks) error {
+	return el.page.Context(el.ctx).scrape(el.Object, data, hooks)
+}
+
+func (p *Page) scrape(this *proto.RuntimeRemoteObject, data interface{}, hooks []scrape.Hooks) error {
+	schema, err := scrape.Compile(data)
+	if err != nil {
+		return err
+	}
+
+	res, err := p.Evaluate(Eval(schema.JS()).This(this))
+	if err != nil {
+		return err
+	}
+
+	all := scrape.Hooks{}
+	for _, h := range hooks {
+		for k, fn := range h {
+			all[k] = fn
+		}
+	}
+
+	return schema.Decode(res.Value, data, all)
+}
+
+type extractSpec struct {
+	Selector string `json:"selector"`
+	Attr     string `json:"attr"`
+	All      bool   `json:"all"`
+}
+
+func parseExtractSpec(s string) extractSpec {
+	spec := extractSpec{}
+
+	s = strings.TrimSpace(s)
+	if strings.HasSuffix(s, "[]") {
+		spec.All = true
+		s = strings.TrimSuffix(s, "[]")
+	}
+
+	// the "@" may also appear in the css selector, such as "a[href*='@']"
+	spec.Selector = s
+	if i := strings.LastIndex(s, "@"); i >= 0 && !strings.ContainsAny(s[i+1:], `]'" `) {
+		spec.Selector, spec.Attr = strings.TrimSpace(s[:i]), s[i+1:]
+	}
+
+	return spec
+}
+
+func setExtracted(v reflect.Value, val gson.JSON) error {
+	if val.Nil() {
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		ptr := reflect.New(v.Type().Elem())
+		err := setExtracted(ptr.Elem(), val)
+		if err != nil {
+			return err
+		}
+		v.Set(ptr)
+
+	case reflect.String:
+		v.SetString(val.Str())
+
+	case reflect.Slice:
+		list := reflect.MakeSlice(v.Type(), 0, len(val.Arr()))
+		for _, item := range val.Arr() {
+			el := reflect.New(v.Type().Elem()).Elem()
+			err := setExtracted(el, item)
+			if err != nil {
+				return err
+			}
+			list = reflect.Append(list, el)
+		}
+		v.Set(list)
+
+	default:
+		return json.Unmarshal([]byte(val.Str()), v.Addr().Interface())
+	}
+
+	return nil
+}
@@ -0,0 +1,116 @@
+package rod_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Fromsko/rodPro/lib/proto"
+	"github.com/Fromsko/rodPro/lib/scrape"
+)
+
+func TestPageExtract(t *testing.T) {
+	g := setup(t)
+
+	p := g.page.MustNavigate(g.srcFile("fixtures/extract.html"))
+
+	data := p.MustExtract(map[string]string{
+		"title": "h1",
+		"first": ".product h2",
+		"link":  "a.more@href",
+		"desc":  ".desc @html",
+		"tags":  ".tag[]",
+		"none":  ".none",
+		"nones": ".none@href[]",
+		"at":    "a[href*='@']",
+	})
+
+	g.Eq(data["title"].Str(), "Shop")
+	g.Eq(data["first"].Str(), "Apple")
+	g.Eq(data["link"].Str(), "/apple")
+	g.Eq(data["desc"].Str(), "<b>fresh</b> food")
+	g.Eq(data["tags"].Arr()[2].Str(), "vegetable")
+	g.True(data["none"].Nil())
+	g.Len(data["nones"].Arr(), 0)
+	g.True(data["at"].Nil())
+
+	g.mc.stubErr(1, proto.RuntimeCallFunctionOn{})
+	g.Err(p.Extract(map[string]string{"title": "h1"}))
+}
+
+func TestPageExtractStruct(t *testing.T) {
+	g := setup(t)
+
+	p := g.page.MustNavigate(g.srcFile("fixtures/extract.html"))
+
+	var data struct {
+		Title   string    `extract:"h1"`
+		Price   float64   `extract:".price"`
+		Prices  []float64 `extract:".price[]"`
+		Tags    []string  `extract:".tag[]"`
+		Link    *string   `extract:"a.more@href"`
+		None    *string   `extract:".none"`
+		Skip    string    `extract:"-"`
+		NoTag   string
+		private string `extract:"h1"`
+	}
+	p.MustExtractStruct(&data)
+
+	g.Eq(data.Title, "Shop")
+	g.Eq(data.Price, 1.5)
+	g.Eq(data.Prices, []float64{1.5, 0.8})
+	g.Eq(data.Tags, []string{"fruit", "red", "vegetable"})
+	g.Eq(*data.Link, "/apple")
+	g.Nil(data.None)
+	g.Eq(data.private, "")
+
+	g.Has(p.ExtractStruct(data).Error(), "expect a pointer to struct")
+
+	var bad struct {
+		Title int `extract:"h1"`
+	}
+	g.Has(p.ExtractStruct(&bad).Error(), "failed to set field Title")
+
+	g.mc.stubErr(1, proto.RuntimeCallFunctionOn{})
+	g.Err(p.ExtractStruct(&data))
+}
+
+func TestPageScrape(t *testing.T) {
+	g := setup(t)
+
+	p := g.page.MustNavigate(g.srcFile("fixtures/extract.html"))
+
+	type product struct {
+		Name  string   `rod:"h2"`
+		Price float64  `rod:".price,hook=currency"`
+		Link  *string  `rod:"a.more,attr=href"`
+		Tags  []string `rod:".tag"`
+	}
+
+	var data struct {
+		Title    string    `rod:"h1"`
+		Desc     string    `rod:".desc,html"`
+		Products []product `rod:".product"`
+	}
+	p.MustScrape(&data, scrape.Hooks{"currency": func(s string) (string, error) {
+		return strings.TrimPrefix(s, "$"), nil
+	}})
+
+	g.Eq(data.Title, "Shop")
+	g.Eq(data.Desc, "<b>fresh</b> food")
+	g.Len(data.Products, 2)
+	g.Eq(data.Products[0].Price, 1.5)
+	g.Eq(*data.Products[0].Link, "/apple")
+	g.Eq(data.Products[1].Tags, []string{"vegetable"})
+	g.Nil(data.Products[1].Link)
+
+	var item product
+	p.MustElement(".product:last-child, .product + .product").MustScrape(&item, scrape.Hooks{
+		"currency": func(s string) (string, error) { return s, nil },
+	})
+	g.Eq(item.Name, "Carrot")
+
+	g.Err(p.Scrape(1))
+
+	g.mc.stubErr(1, proto.RuntimeCallFunctionOn{})
+	g.Err(p.Scrape(&data))
+}
@@ -0,0 +1,230 @@
+// This file serves for filling the forms.
+
+package rod
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Fromsko/rodPro/lib/js"
+)
+
+// FormField is a field to fill by [Page.FillFormFields]
+type FormField struct {
+	// Key to find the field, it can be the name, id, label text, placeholder, or aria-label of the field.
+	Key string
+
+	// Value to fill, how it's filled depends on the type of the field:
+	//
+	//	checkbox                       bool, or the strings "true", "on", "1"
+	//	radio                          the value or the label text of the option
+	//	select                         string or []string of the option values or texts
+	//	date, time, month, week, etc.  [time.Time] or the formatted string
+	//	file                           string or []string of the file paths
+	//	the others                     any value that will be formatted via fmt.Sprint
+	Value interface{}
+}
+
+// FillForm fills the form that matches the css selector with the data, the keys of the data are the same as
+// [FormField.Key]. The fields are filled in the order of the sorted keys, use [Page.FillFormFields] if the order
+// matters. The keys that don't match any field will be reported via [ErrUnmatchedFields] after the others are filled.
+func (p *Page) FillForm(selector string, data map[string]interface{}) error {
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	fields := make([]FormField, 0, len(keys))
+	for _, k := range keys {
+		fields = append(fields, FormField{k, data[k]})
+	}
+
+	return p.FillFormFields(selector, fields...)
+}
+
+// FillFormStruct is similar to [Page.FillForm], but the data is a struct or a pointer to struct.
+// The key of each exported field is its name, or the "form" tag:
+//
+//	type Login struct {
+//		User     string `form:"Username"`  // the field with the label "Username"
+//		Password string `form:"password"`  // the field with the name "password"
+//		Remember bool   `form:",omitempty"` // skip if it's zero value
+//		Token    string `form:"-"`          // always skip
+//	}
+//
+// The fields are filled in the order of the struct fields.
+func (p *Page) FillFormStruct(selector string, data interface{}) error {
+	v := reflect.Indirect(reflect.ValueOf(data))
+	if v.Kind() != reflect.Struct {
+		return fmt.Errorf("expect a struct, got %T", data)
+	}
+
+	fields := []FormField{}
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Type().Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+
+		key, opts, _ := strings.Cut(f.Tag.Get("form"), ",")
+		if key == "-" {
+			continue
+		}
+		if key == "" {
+			key = f.Name
+		}
+		if opts == "omitempty" && v.Field(i).IsZero() {
+			continue
+		}
+
+		fields = append(fields, FormField{key, v.Field(i).Interface()})
+	}
+
+	return p.FillFormFields(selector, fields...)
+}
+
+// FillFormFields fills the fields in order, check [Page.FillForm] for details.
+func (p *Page) FillFormFields(selector string, fields ...FormField) error {
+	form, err := p.Element(selector)
+	if err != nil {
+		return err
+	}
+
+	defer p.tryTrace(TraceTypeInput, "fill form "+selector)()
+
+	unmatched := []string{}
+	for _, f := range fields {
+		el, err := form.ElementByJS(evalHelper(js.FormField, f.Key, fmt.Sprint(f.Value)))
+		if err != nil {
+			if errors.Is(err, &ErrElementNotFound{}) {
+				unmatched = append(unmatched, f.Key)
+				continue
+			}
+			return err
+		}
+
+		err = el.fill(f.Value)
+		if err != nil {
+			return fmt.Errorf("failed to fill field %q: %w", f.Key, err)
+		}
+	}
+
+	if len(unmatched) > 0 {
+		return &ErrUnmatchedFields{unmatched}
+	}
+	return nil
+}
+
+func (el *Element) fill(value interface{}) error {
+	info, err := el.Eval(`() => ({
+		tag: this.tagName.toLowerCase(),
+		type: (this.type || '').toLowerCase(),
+		editable: this.isContentEditable,
+	})`)
+	if err != nil {
+		return err
+	}
+
+	tag, typ := info.Value.Get("tag").Str(), info.Value.Get("type").Str()
+
+	switch {
+	case typ == "checkbox":
+		return el.SetChecked(truthy(value))
+
+	case typ == "radio":
+		return el.SetChecked(true)
+
+	case tag == "select":
+		return el.fillSelect(toStrings(value))
+
+	case typ == "file":
+		return el.SetFiles(toStrings(value))
+
+	case typ == "color":
+		return el.InputColor(fmt.Sprint(value))
+
+	case typ == "date" || typ == "datetime-local" || typ == "month" || typ == "week" || typ == "time":
+		if t, ok := value.(time.Time); ok {
+			return el.InputTime(t)
+		}
+		_, err = el.Evaluate(Eval(`v => { this.value = v }`, fmt.Sprint(value)).ByUser())
+		if err != nil {
+			return err
+		}
+		_, err = el.Evaluate(evalHelper(js.InputEvent).ByUser())
+		return err
+
+	case tag != "input" && tag != "textarea" && info.Value.Get("editable").Bool():
+		err = el.Clear(ClearModeValue)
+		if err != nil {
+			return err
+		}
+		return el.InputRichText(fmt.Sprint(value), RichTextPlain)
+	}
+
+	err = el.Clear(ClearModeValue)
+	if err != nil {
+		return err
+	}
+	return el.Input(fmt.Sprint(value))
+}
+
+func (el *Element) fillSelect(values []string) error {
+	// unselect all first for the multiple select
+	err := el.Select([]string{"option"}, false, SelectorTypeCSSSector)
+	if err != nil && !errors.Is(err, &ErrElementNotFound{}) {
+		return err
+	}
+
+	for _, v := range values {
+		err = el.Select([]string{"option[value=" + strconv.Quote(v) + "]"}, true, SelectorTypeCSSSector)
+		if errors.Is(err, &ErrElementNotFound{}) {
+			err = el.Select([]string{v}, true, SelectorTypeText)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func truthy(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case string:
+		switch strings.ToLower(v) {
+		case "true", "on", "1", "yes":
+			return true
+		}
+		return false
+	}
+	return !reflect.ValueOf(v).IsZero()
+}
+
+func toStrings(v interface{}) []string {
+	switch v := v.(type) {
+	case []string:
+		return v
+	case string:
+		return []string{v}
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Slice {
+		list := []string{}
+		for i := 0; i < rv.Len(); i++ {
+			list = append(list, fmt.Sprint(rv.Index(i).Interface()))
+		}
+		return list
+	}
+	return []string{fmt.Sprint(v)}
+}
@@ -0,0 +1,88 @@
+package rod_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Fromsko/rodPro"
+	"github.com/Fromsko/rodPro/lib/proto"
+)
+
+func TestFillForm(t *testing.T) {
+	g := setup(t)
+
+	p := g.page.MustNavigate(g.srcFile("fixtures/form.html"))
+
+	p.MustFillForm("#login", map[string]interface{}{
+		"Username": "jack",
+		"password": "secret",
+		"Bio":      "hello",
+		"Remember": true,
+		"plan":     "Pro",
+		"country":  "China",
+		"tags":     []string{"a", "c"},
+		"birthday": time.Date(2000, 1, 2, 0, 0, 0, 0, time.UTC),
+		"color":    "red",
+		"age":      18,
+		"note":     "rich",
+	})
+
+	val := func(js string) interface{} {
+		return p.MustEval(js).Val()
+	}
+
+	g.Eq(val(`() => document.querySelector('[name=user]').value`), "jack")
+	g.Eq(val(`() => document.querySelector('[type=password]').value`), "secret")
+	g.Eq(val(`() => document.querySelector('textarea').value`), "hello")
+	g.Eq(val(`() => document.querySelector('#remember').checked`), true)
+	g.Eq(val(`() => document.querySelector('#pro').checked`), true)
+	g.Eq(val(`() => document.querySelector('[name=country]').value`), "cn")
+	g.Eq(val(`() => Array.from(document.querySelector('[name=tags]').selectedOptions).map(o => o.value).join()`), "a,c")
+	g.Eq(val(`() => document.querySelector('[name=birthday]').value`), "2000-01-02")
+	g.Eq(val(`() => document.querySelector('[name=color]').value`), "#ff0000")
+	g.Eq(val(`() => document.querySelector('[name=age]').value`), "18")
+	g.Eq(val(`() => document.querySelector('#note').innerText`), "rich")
+
+	// fill again should replace the old values
+	p.MustFillForm("#login", map[string]interface{}{"Username": "tom", "Remember": false})
+	g.Eq(val(`() => document.querySelector('[name=user]').value`), "tom")
+	g.Eq(val(`() => document.querySelector('#remember').checked`), false)
+
+	err := p.FillForm("#login", map[string]interface{}{"user": "a", "nope": 1, "missing": 2})
+	g.Is(err, &rod.ErrUnmatchedFields{})
+	g.Eq(err.Error(), "cannot find form fields: missing, nope")
+	g.Eq(val(`() => document.querySelector('[name=user]').value`), "a")
+
+	g.Err(p.FillForm("#login", map[string]interface{}{"plan": "none"}))
+
+	g.mc.stubErr(1, proto.RuntimeCallFunctionOn{})
+	g.Err(p.FillForm("#login", map[string]interface{}{"user": "a"}))
+
+	g.mc.stubErr(2, proto.RuntimeCallFunctionOn{})
+	g.Err(p.FillForm("#login", map[string]interface{}{"user": "a"}))
+
+	g.mc.stubErr(3, proto.RuntimeCallFunctionOn{})
+	g.Err(p.FillForm("#login", map[string]interface{}{"birthday": "2001-01-01"}))
+}
+
+func TestFillFormStruct(t *testing.T) {
+	g := setup(t)
+
+	p := g.page.MustNavigate(g.srcFile("fixtures/form.html"))
+
+	type login struct {
+		User     string `form:"Username"`
+		Password string
+		Remember bool   `form:"remember,omitempty"`
+		Token    string `form:"-"`
+		age      int
+	}
+
+	p.MustFillFormStruct("#login", &login{User: "jack", Password: "secret", Token: "x", age: 1})
+
+	g.Eq(p.MustEval(`() => document.querySelector('[name=user]').value`).Str(), "jack")
+	g.Eq(p.MustEval(`() => document.querySelector('[type=password]').value`).Str(), "secret")
+	g.False(p.MustEval(`() => document.querySelector('#remember').checked`).Bool())
+
+	g.Eq(p.FillFormStruct("#login", 1).Error(), "expect a struct, got int")
+}
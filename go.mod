@@ -1,6 +1,6 @@
 module github.com/Fromsko/rodPro
 
-go 1.16
+go 1.18
 
 require (
 	github.com/ysmood/fetchup v0.2.3
@@ -0,0 +1,183 @@
+// This file serves for tracking the lifecycle of the remote object handles.
+
+package rod
+
+import (
+	"container/list"
+	"errors"
+	"sync"
+
+	"github.com/Fromsko/rodPro/lib/cdp"
+	"github.com/Fromsko/rodPro/lib/proto"
+)
+
+// TrackHandlesOptions for [Page.TrackHandles]
+type TrackHandlesOptions struct {
+	// Max is the max number of the outstanding handles, when it's exceeded the oldest handles
+	// will be released automatically. Zero means no limit.
+	Max int
+
+	// LeakThreshold reports a possible leak via the logger of the browser when the number of the outstanding
+	// handles exceeds it, it's reported again only after the number drops below it. Zero means disabled.
+	LeakThreshold int
+
+	// PanicOnLeak panics with [ErrHandleLeak] instead of logging it, it's useful for tests.
+	PanicOnLeak bool
+}
+
+type handleRegistry struct {
+	lock   sync.Mutex
+	opts   *TrackHandlesOptions // nil means disabled
+	list   *list.List           // the oldest is the front
+	index  map[proto.RuntimeRemoteObjectID]*list.Element
+	leaked bool
+}
+
+// TrackHandles starts to track the remote objects of the elements created by the page and its frames, such as the
+// elements returned by [Page.Element] or [Element.Elements]. [Element.Release] untracks the element, and
+// [Page.ReleaseAll] releases all the tracked ones. It's useful for long crawls that reuse the same page, the renderer
+// keeps every object alive until it's released or the page navigates. If opts is nil, the handles are tracked
+// without limits. Use [Page.StopTrackHandles] to disable it.
+func (p *Page) TrackHandles(opts *TrackHandlesOptions) *Page {
+	if opts == nil {
+		opts = &TrackHandlesOptions{}
+	}
+
+	r := p.handles
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.opts = opts
+	if r.list == nil {
+		r.list = list.New()
+		r.index = map[proto.RuntimeRemoteObjectID]*list.Element{}
+	}
+	return p
+}
+
+// StopTrackHandles disables the [Page.TrackHandles], the tracked handles are forgotten without being released.
+func (p *Page) StopTrackHandles() *Page {
+	r := p.handles
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.opts = nil
+	r.list = nil
+	r.index = nil
+	r.leaked = false
+	return p
+}
+
+// HandleCount returns the number of the outstanding handles tracked by [Page.TrackHandles]
+func (p *Page) HandleCount() int {
+	r := p.handles
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.list == nil {
+		return 0
+	}
+	return r.list.Len()
+}
+
+// ReleaseAll releases all the handles tracked by [Page.TrackHandles], the elements that hold them
+// can no longer be used. The handles that are already gone, such as after a navigation, are ignored.
+func (p *Page) ReleaseAll() error {
+	r := p.handles
+	r.lock.Lock()
+	ids := []proto.RuntimeRemoteObjectID{}
+	if r.list != nil {
+		for e := r.list.Front(); e != nil; e = e.Next() {
+			ids = append(ids, e.Value.(proto.RuntimeRemoteObjectID))
+		}
+		r.list.Init()
+		r.index = map[proto.RuntimeRemoteObjectID]*list.Element{}
+		r.leaked = false
+	}
+	r.lock.Unlock()
+
+	for _, id := range ids {
+		err := p.releaseHandle(id)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// releaseHandle ignores the cdp errors, they mean the object is already gone
+func (p *Page) releaseHandle(id proto.RuntimeRemoteObjectID) error {
+	err := proto.RuntimeReleaseObject{ObjectID: id}.Call(p)
+	var cdpErr *cdp.Error
+	if errors.As(err, &cdpErr) {
+		return nil
+	}
+	return err
+}
+
+func (p *Page) trackHandle(id proto.RuntimeRemoteObjectID) {
+	r := p.handles
+	if r == nil {
+		return
+	}
+	r.lock.Lock()
+
+	if r.opts == nil || id == "" {
+		r.lock.Unlock()
+		return
+	}
+
+	if _, has := r.index[id]; !has {
+		r.index[id] = r.list.PushBack(id)
+	}
+
+	evicted := []proto.RuntimeRemoteObjectID{}
+	for r.opts.Max > 0 && r.list.Len() > r.opts.Max {
+		e := r.list.Front()
+		r.list.Remove(e)
+		delete(r.index, e.Value.(proto.RuntimeRemoteObjectID))
+		evicted = append(evicted, e.Value.(proto.RuntimeRemoteObjectID))
+	}
+
+	var leak *ErrHandleLeak
+	if t := r.opts.LeakThreshold; t > 0 && r.list.Len() > t && !r.leaked {
+		r.leaked = true
+		leak = &ErrHandleLeak{p, r.list.Len(), t}
+	}
+	panicOnLeak := r.opts.PanicOnLeak
+
+	r.lock.Unlock()
+
+	for _, id := range evicted {
+		_ = p.releaseHandle(id)
+	}
+
+	if leak != nil {
+		if panicOnLeak {
+			panic(leak)
+		}
+		p.browser.logger.Println(leak)
+	}
+}
+
+func (p *Page) untrackHandle(id proto.RuntimeRemoteObjectID) {
+	r := p.handles
+	if r == nil {
+		return
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.list == nil {
+		return
+	}
+
+	if e, has := r.index[id]; has {
+		r.list.Remove(e)
+		delete(r.index, id)
+	}
+
+	if r.leaked && r.list.Len() < r.opts.LeakThreshold {
+		r.leaked = false
+	}
+}
@@ -0,0 +1,75 @@
+package rod_test
+
+import (
+	"testing"
+
+	"github.com/Fromsko/rodPro"
+	"github.com/Fromsko/rodPro/lib/proto"
+)
+
+func TestTrackHandles(t *testing.T) {
+	g := setup(t)
+
+	p := g.page.MustNavigate(g.srcFile("fixtures/click.html"))
+	p.TrackHandles(nil)
+	defer p.StopTrackHandles()
+
+	g.Eq(p.HandleCount(), 0)
+
+	el := p.MustElement("button")
+	p.MustElement("body")
+	g.Eq(p.HandleCount(), 2)
+
+	el.MustRelease()
+	g.Eq(p.HandleCount(), 1)
+
+	p.MustElements("*")
+	g.Gt(p.HandleCount(), 3)
+
+	p.MustReleaseAll()
+	g.Eq(p.HandleCount(), 0)
+
+	p.MustElement("button")
+	p.MustReload().MustWaitLoad()
+	p.MustReleaseAll()
+
+	g.mc.stubErr(1, proto.RuntimeReleaseObject{})
+	p.MustElement("button")
+	g.Err(p.ReleaseAll())
+
+	p.StopTrackHandles()
+	p.MustElement("button")
+	g.Eq(p.HandleCount(), 0)
+}
+
+func TestTrackHandlesLimit(t *testing.T) {
+	g := setup(t)
+
+	p := g.page.MustNavigate(g.srcFile("fixtures/click.html"))
+	p.TrackHandles(&rod.TrackHandlesOptions{Max: 2})
+	defer p.StopTrackHandles()
+
+	first := p.MustElement("button")
+	p.MustElement("button")
+	p.MustElement("body")
+	g.Eq(p.HandleCount(), 2)
+
+	// the oldest one is released
+	g.Err(first.Describe(0, false))
+
+	p.TrackHandles(&rod.TrackHandlesOptions{LeakThreshold: 2, PanicOnLeak: true})
+	p.MustReleaseAll()
+
+	p.MustElement("button")
+	el := p.MustElement("button")
+	g.Panic(func() { p.MustElement("body") })
+	g.Eq(p.HandleCount(), 3)
+
+	el.MustRelease()
+	el = p.MustElement("button")
+
+	p.TrackHandles(&rod.TrackHandlesOptions{LeakThreshold: 1})
+	g.Eq(p.HandleCount(), 3)
+	el.MustRelease()
+	p.MustElement("button")
+}
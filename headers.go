@@ -0,0 +1,175 @@
+// This file serves for rewriting the headers of the requests, such as the scoped extra headers and the referer policy.
+
+package rod
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/Fromsko/rodPro/lib/proto"
+)
+
+type scopedHeaders struct {
+	lock   sync.Mutex
+	router *HijackRouter
+	rules  []*scopedHeaderRule
+}
+
+type scopedHeaderRule struct {
+	pattern string
+	regexp  *regexp.Regexp
+	dict    []string
+}
+
+// SetExtraHeadersFor is similar to [Page.SetExtraHeaders], but the headers are only sent with the requests whose
+// url matches the pattern, such as only add the Authorization to "https://api.example.com/*", so the headers won't
+// leak to the third-party hosts. The doc of the pattern is the same as [proto.FetchRequestPattern.URLPattern].
+// It's implemented via the Fetch domain, so it may conflict with the [Page.HijackRequests] of the same page.
+// The headers of the rules that match the same request are merged, the later ones override the earlier ones.
+// Call the returned function to remove the rule.
+func (p *Page) SetExtraHeadersFor(pattern string, dict []string) (func(), error) {
+	s := p.scopedHeaders
+	rule := &scopedHeaderRule{
+		pattern: pattern,
+		regexp:  regexp.MustCompile(proto.PatternToReg(pattern)),
+		dict:    dict,
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.router == nil {
+		s.router = p.HijackRequests()
+		go s.router.Run()
+	}
+
+	err := s.router.Add(pattern, "", s.handle)
+	if err != nil {
+		if len(s.rules) == 0 {
+			_ = s.router.Stop()
+			s.router = nil
+		}
+		return nil, err
+	}
+	s.rules = append(s.rules, rule)
+
+	once := sync.Once{}
+	return func() { once.Do(func() { s.remove(rule) }) }, nil
+}
+
+func (s *scopedHeaders) remove(rule *scopedHeaderRule) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	list := []*scopedHeaderRule{}
+	shared := false
+	for _, r := range s.rules {
+		if r != rule {
+			list = append(list, r)
+			shared = shared || r.pattern == rule.pattern
+		}
+	}
+	s.rules = list
+
+	if len(list) == 0 {
+		_ = s.router.Stop()
+		s.router = nil
+		return
+	}
+
+	if !shared {
+		_ = s.router.Remove(rule.pattern)
+	}
+}
+
+func (s *scopedHeaders) handle(ctx *Hijack) {
+	u := ctx.Request.URL().String()
+
+	s.lock.Lock()
+	for _, r := range s.rules {
+		if r.regexp.MatchString(u) {
+			for i := 0; i+1 < len(r.dict); i += 2 {
+				ctx.Request.setHeader(r.dict[i], r.dict[i+1])
+			}
+		}
+	}
+	s.lock.Unlock()
+
+	ctx.ContinueRequest(&proto.FetchContinueRequest{Headers: ctx.Request.headerEntries()})
+}
+
+// RefererPolicy rewrites the Referer and Origin headers of the navigations, XHRs, and fetches,
+// check [Browser.SetRefererPolicy]. For each function, return the original value to keep it,
+// return an empty string to remove the header.
+type RefererPolicy struct {
+	// Referer returns the new referer of the request, nil keeps the original one
+	Referer func(u *url.URL, referer string) string
+
+	// Origin returns the new origin of the request, nil keeps the original one.
+	// Browsers only send the Origin with the cross-origin and the non-GET requests.
+	Origin func(u *url.URL, origin string) string
+}
+
+// SameOriginRefererPolicy makes each request look like it comes from the home page of its own site,
+// the Referer is "scheme://host/" and the Origin is "scheme://host" of the request url.
+// The sites that validate the referrer chains will see the requests as the internal ones.
+func SameOriginRefererPolicy() *RefererPolicy {
+	return &RefererPolicy{
+		Referer: func(u *url.URL, _ string) string { return u.Scheme + "://" + u.Host + "/" },
+		Origin: func(u *url.URL, origin string) string {
+			if origin == "" {
+				return ""
+			}
+			return u.Scheme + "://" + u.Host
+		},
+	}
+}
+
+// SetRefererPolicy rewrites the Referer and Origin headers of the navigations, XHRs, and fetches of all the pages
+// via the Fetch domain, so it may conflict with the [Browser.HijackRequests].
+// Call the returned function to remove the policy.
+func (b *Browser) SetRefererPolicy(policy *RefererPolicy) (func(), error) {
+	router := b.HijackRequests()
+
+	handler := func(ctx *Hijack) {
+		u := ctx.Request.URL()
+		if policy.Referer != nil {
+			rewriteHeader(ctx.Request, "Referer", policy.Referer(u, ctx.Request.header("Referer")))
+		}
+		if policy.Origin != nil {
+			rewriteHeader(ctx.Request, "Origin", policy.Origin(u, ctx.Request.header("Origin")))
+		}
+		ctx.ContinueRequest(&proto.FetchContinueRequest{Headers: ctx.Request.headerEntries()})
+	}
+
+	for _, t := range []proto.NetworkResourceType{
+		proto.NetworkResourceTypeDocument,
+		proto.NetworkResourceTypeXHR,
+		proto.NetworkResourceTypeFetch,
+	} {
+		err := router.Add("*", t, handler)
+		if err != nil {
+			_ = router.Stop()
+			return nil, err
+		}
+	}
+
+	go router.Run()
+
+	once := sync.Once{}
+	return func() { once.Do(func() { _ = router.Stop() }) }, nil
+}
+
+func rewriteHeader(req *HijackRequest, key, value string) {
+	if value == "" {
+		for k := range req.req.Header {
+			if strings.EqualFold(k, key) {
+				delete(req.req.Header, k)
+			}
+		}
+		return
+	}
+	req.setHeader(key, value)
+}
@@ -0,0 +1,188 @@
+// This file serves for parsing and rewriting the bodies of the hijacked requests.
+
+package rod
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/Fromsko/rodPro/lib/proto"
+)
+
+// HijackFormPart is a part of the multipart form body, check [HijackRequest.MultipartForm]
+type HijackFormPart struct {
+	// Name of the form field
+	Name string
+
+	// FileName is empty if the part is not a file
+	FileName string
+
+	// ContentType of the part, such as "image/png"
+	ContentType string
+
+	Data []byte
+}
+
+// RawBody returns the bytes of the body that will be sent, the binary data is kept as it is,
+// such as the protobuf payload. Use [HijackRequest.SetBody] with a []byte to rewrite it.
+func (ctx *HijackRequest) RawBody() ([]byte, error) {
+	if ctx.req.Body == nil {
+		return nil, nil
+	}
+
+	b, err := ioutil.ReadAll(ctx.req.Body)
+	if err != nil {
+		return nil, err
+	}
+	ctx.req.Body = ioutil.NopCloser(bytes.NewReader(b))
+	return b, nil
+}
+
+// Form parses the url-encoded form body
+func (ctx *HijackRequest) Form() (url.Values, error) {
+	b, err := ctx.RawBody()
+	if err != nil {
+		return nil, err
+	}
+	return url.ParseQuery(string(b))
+}
+
+// SetForm sets the body to the url-encoded form, the Content-Type and Content-Length will be updated.
+func (ctx *HijackRequest) SetForm(form url.Values) *HijackRequest {
+	ctx.setHeader("Content-Type", "application/x-www-form-urlencoded")
+	ctx.setBody([]byte(form.Encode()))
+	return ctx
+}
+
+// MultipartForm parses the multipart form body, the parts keep their order
+func (ctx *HijackRequest) MultipartForm() ([]*HijackFormPart, error) {
+	_, params, err := mime.ParseMediaType(ctx.header("Content-Type"))
+	if err != nil {
+		return nil, err
+	}
+	if params["boundary"] == "" {
+		return nil, errors.New("the body is not a multipart form")
+	}
+
+	b, err := ctx.RawBody()
+	if err != nil {
+		return nil, err
+	}
+
+	parts := []*HijackFormPart{}
+	r := multipart.NewReader(bytes.NewReader(b), params["boundary"])
+	for {
+		p, err := r.NextRawPart()
+		if errors.Is(err, io.EOF) {
+			return parts, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := ioutil.ReadAll(p)
+		if err != nil {
+			return nil, err
+		}
+
+		parts = append(parts, &HijackFormPart{
+			Name:        p.FormName(),
+			FileName:    p.FileName(),
+			ContentType: p.Header.Get("Content-Type"),
+			Data:        data,
+		})
+	}
+}
+
+// SetMultipartForm sets the body to the multipart form with a new boundary,
+// the Content-Type and Content-Length will be updated.
+func (ctx *HijackRequest) SetMultipartForm(parts ...*HijackFormPart) *HijackRequest {
+	buf := bytes.NewBuffer(nil)
+	w := multipart.NewWriter(buf)
+
+	for _, p := range parts {
+		h := textproto.MIMEHeader{}
+		disposition := fmt.Sprintf(`form-data; name="%s"`, quoteEscaper.Replace(p.Name))
+		if p.FileName != "" {
+			disposition += fmt.Sprintf(`; filename="%s"`, quoteEscaper.Replace(p.FileName))
+		}
+		h.Set("Content-Disposition", disposition)
+		if p.ContentType != "" {
+			h.Set("Content-Type", p.ContentType)
+		} else if p.FileName != "" {
+			h.Set("Content-Type", "application/octet-stream")
+		}
+
+		pw, _ := w.CreatePart(h)
+		_, _ = pw.Write(p.Data)
+	}
+	_ = w.Close()
+
+	ctx.setHeader("Content-Type", w.FormDataContentType())
+	ctx.setBody(buf.Bytes())
+	return ctx
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// setBody sets the body and recomputes the content length
+func (ctx *HijackRequest) setBody(b []byte) {
+	ctx.req.Body = ioutil.NopCloser(bytes.NewReader(b))
+	ctx.req.ContentLength = int64(len(b))
+	if ctx.header("Content-Length") != "" {
+		ctx.setHeader("Content-Length", strconv.Itoa(len(b)))
+	}
+}
+
+// header of the request, the key is case-insensitive
+func (ctx *HijackRequest) header(key string) string {
+	for k, vs := range ctx.req.Header {
+		if strings.EqualFold(k, key) && len(vs) > 0 {
+			return vs[0]
+		}
+	}
+	return ""
+}
+
+// setHeader replaces the header of the request, the key is case-insensitive
+func (ctx *HijackRequest) setHeader(key, value string) {
+	for k := range ctx.req.Header {
+		if strings.EqualFold(k, key) {
+			delete(ctx.req.Header, k)
+		}
+	}
+	ctx.req.Header[key] = []string{value}
+}
+
+// headerEntries of the request for the Fetch domain
+func (ctx *HijackRequest) headerEntries() []*proto.FetchHeaderEntry {
+	list := []*proto.FetchHeaderEntry{}
+	for k, vs := range ctx.req.Header {
+		for _, v := range vs {
+			list = append(list, &proto.FetchHeaderEntry{Name: k, Value: v})
+		}
+	}
+	return list
+}
+
+// postData returns the raw bytes of the request body
+func postData(r *proto.NetworkRequest) []byte {
+	if len(r.PostDataEntries) == 0 {
+		return []byte(r.PostData)
+	}
+
+	b := []byte{}
+	for _, e := range r.PostDataEntries {
+		b = append(b, e.Bytes...)
+	}
+	return b
+}
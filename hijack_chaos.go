@@ -0,0 +1,86 @@
+// This file serves for the chaos testing of the frontend via the hijacked requests.
+
+package rod
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/Fromsko/rodPro/lib/proto"
+)
+
+// HijackDelay is a middleware to delay the response for d after the handler runs,
+// such as to test the loading spinners and the timeouts of the frontend.
+func HijackDelay(d time.Duration) HijackMiddleware {
+	return func(next func(*Hijack)) func(*Hijack) {
+		return func(ctx *Hijack) {
+			next(ctx)
+
+			err := humanSleep(ctx.Request.req.Context(), d)
+			if err != nil {
+				ctx.OnError(err)
+			}
+		}
+	}
+}
+
+// HijackFail is a middleware to fail the request with the reason without calling the handler,
+// such as to test the retries and the error states of the frontend.
+func HijackFail(reason proto.NetworkErrorReason) HijackMiddleware {
+	return func(func(*Hijack)) func(*Hijack) {
+		return func(ctx *Hijack) {
+			ctx.Response.Fail(reason)
+		}
+	}
+}
+
+// HijackCorruptBody is a middleware to corrupt the response body after the handler runs,
+// each byte of the body will be replaced by a random byte with the probability rate which is between 0 and 1.
+// The body must not be encoded, use [HijackGunzip] inside it if the response may be compressed.
+func HijackCorruptBody(rate float64) HijackMiddleware {
+	return func(next func(*Hijack)) func(*Hijack) {
+		return func(ctx *Hijack) {
+			next(ctx)
+
+			if !ctx.responding() {
+				return
+			}
+
+			body := append([]byte{}, ctx.Response.payload.Body...)
+			for i := range body {
+				if rand.Float64() < rate { //nolint: gosec
+					body[i] = byte(rand.Intn(256)) //nolint: gosec
+				}
+			}
+
+			ctx.Response.delHeader("Content-Length")
+			ctx.Response.SetBody(body)
+		}
+	}
+}
+
+// DelayResponse delays the responses of the requests that match the pattern for d,
+// the responses are loaded via [Hijack.LoadResponse]. Check [HijackDelay] for details.
+func (r *HijackRouter) DelayResponse(pattern string, d time.Duration) error {
+	return r.Add(pattern, "", HijackDelay(d)(loadHijackResponse))
+}
+
+// FailWith fails the requests that match the pattern with the reason. Check [HijackFail] for details.
+func (r *HijackRouter) FailWith(pattern string, reason proto.NetworkErrorReason) error {
+	return r.Add(pattern, "", HijackFail(reason)(loadHijackResponse))
+}
+
+// CorruptBody corrupts the response bodies of the requests that match the pattern,
+// the responses are loaded via [Hijack.LoadResponse]. Check [HijackCorruptBody] for details.
+func (r *HijackRouter) CorruptBody(pattern string, rate float64) error {
+	return r.Add(pattern, "", HijackCorruptBody(rate)(HijackGunzip()(loadHijackResponse)))
+}
+
+// loadHijackResponse is the handler that loads the response from the real destination
+func loadHijackResponse(ctx *Hijack) {
+	err := ctx.LoadResponse(nil, true)
+	if err != nil {
+		ctx.OnError(err)
+		ctx.Response.Fail(proto.NetworkErrorReasonFailed)
+	}
+}
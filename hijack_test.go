@@ -1,11 +1,15 @@
 package rod_test
 
 import (
+	"compress/gzip"
 	"context"
+	"crypto/tls"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"mime"
 	"net/http"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -186,6 +190,127 @@ func TestHijackMockWholeResponse(t *testing.T) {
 	g.Eq("ok", g.page.MustElement("body").MustText())
 }
 
+func TestHijackMiddleware(t *testing.T) {
+	g := setup(t)
+
+	s := g.Serve()
+	s.Mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		g.Eq(r.Header.Get("X-Test"), "ok")
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		_, _ = gz.Write([]byte("<html><head></head><body></body></html>"))
+		_ = gz.Close()
+	})
+
+	router := g.page.HijackRequests()
+	defer router.MustStop()
+
+	order := []string{}
+	trace := func(name string) rod.HijackMiddleware {
+		return func(next func(*rod.Hijack)) func(*rod.Hijack) {
+			return func(ctx *rod.Hijack) {
+				order = append(order, name+" in")
+				next(ctx)
+				order = append(order, name+" out")
+			}
+		}
+	}
+
+	router.Use(
+		trace("a"),
+		trace("b"),
+		rod.HijackCORS(""),
+		rod.HijackInjectHTML(`<script>window.injected = 1</script>`),
+		rod.HijackGunzip(),
+		rod.HijackRequestHeader("X-Test", "ok"),
+	)
+
+	router.MustAdd(s.URL("/"), func(ctx *rod.Hijack) {
+		ctx.MustLoadResponse()
+	})
+
+	go router.Run()
+
+	g.page.MustNavigate(s.URL())
+
+	g.Eq(g.page.MustEval(`() => window.injected`).Int(), 1)
+	g.Eq(order, []string{"a in", "b in", "b out", "a out"})
+
+	g.Panic(func() { rod.HijackRequestHeader("X-Test") })
+}
+
+func TestHijackInjectHTML(t *testing.T) {
+	g := setup(t)
+
+	router := g.page.HijackRequests()
+	defer router.MustStop()
+
+	router.Use(rod.HijackInjectHTML(`<p id="injected">injected</p>`))
+
+	router.MustAdd("*", func(ctx *rod.Hijack) {
+		ctx.Response.SetHeader("Content-Type", "Text/HTML; Charset=UTF-8")
+		ctx.Response.SetBody(`<html><BODY class="İİİ"><p>İİİ</p></BODY></html>`)
+	})
+
+	go router.Run()
+
+	g.page.MustNavigate("http://test.com")
+
+	g.Eq(g.page.MustEval(`() => document.body.firstElementChild.id`).Str(), "injected")
+	g.Eq(g.page.MustElement("body > p:nth-child(2)").MustText(), "İİİ")
+}
+
+func TestHijackGzip(t *testing.T) {
+	g := setup(t)
+
+	router := g.page.HijackRequests()
+	defer router.MustStop()
+
+	router.Use(rod.HijackGzip())
+
+	router.MustAdd("*", func(ctx *rod.Hijack) {
+		ctx.Response.SetHeader("Content-Type", mime.TypeByExtension(".html"))
+		ctx.Response.SetBody("<body>ok</body>")
+	})
+
+	go router.Run()
+
+	g.page.MustNavigate("http://test.com")
+
+	g.Eq("ok", g.page.MustElement("body").MustText())
+}
+
+func TestHijackChaos(t *testing.T) {
+	g := setup(t)
+
+	s := g.Serve()
+	s.Route("/", ".html", `<html></html>`)
+	s.Route("/slow", ".txt", "slow")
+	s.Route("/fail", ".txt", "fail")
+	s.Route("/corrupt", ".txt", "corrupt")
+
+	router := g.page.HijackRequests()
+	defer router.MustStop()
+
+	router.MustDelayResponse(s.URL("/slow"), 300*time.Millisecond)
+	router.MustFailWith(s.URL("/fail"), proto.NetworkErrorReasonConnectionReset)
+	router.MustCorruptBody(s.URL("/corrupt"), 1)
+
+	go router.Run()
+
+	p := g.page.MustNavigate(s.URL())
+
+	start := time.Now()
+	g.Eq(p.MustEval(`() => fetch('/slow').then(r => r.text())`).Str(), "slow")
+	g.Gt(time.Since(start), 300*time.Millisecond)
+
+	g.Eq(p.MustEval(`() => fetch('/fail').then(() => 'ok', () => 'failed')`).Str(), "failed")
+
+	g.Eq(p.MustEval(`() => fetch('/corrupt').then(r => r.arrayBuffer()).then(b => b.byteLength)`).Int(), 7)
+	g.Neq(p.MustEval(`() => fetch('/corrupt').then(r => r.text())`).Str(), "corrupt")
+}
+
 func TestHijackSkip(t *testing.T) {
 	g := setup(t)
 
@@ -313,6 +438,228 @@ func TestHijackLoadResponseErr(t *testing.T) {
 	wg.Wait()
 }
 
+func TestHijackViaBrowser(t *testing.T) {
+	g := setup(t)
+
+	s := g.Serve()
+	s.Route("/", slash("fixtures/fetch.html"))
+	s.Mux.HandleFunc("/a", func(w http.ResponseWriter, r *http.Request) {
+		b, err := ioutil.ReadAll(r.Body)
+		g.E(err)
+		g.Eq("changed", string(b))
+		g.Eq("header", r.Header.Get("Test"))
+		g.Has(r.UserAgent(), "Chrome")
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Encoding", "gzip")
+		gw := gzip.NewWriter(w)
+		_, _ = gw.Write([]byte(`{"text":"raw"}`))
+		g.E(gw.Close())
+	})
+	s.Route("/b", "", "b")
+
+	router := g.page.HijackRequests().ViaBrowser(true)
+	defer router.MustStop()
+
+	router.MustAdd(s.URL("/a"), func(ctx *rod.Hijack) {
+		ctx.Request.Req().Header.Set("Test", "header")
+		ctx.Request.SetBody("changed")
+
+		ctx.MustLoadResponse()
+
+		g.Eq(200, ctx.Response.Payload().ResponseCode)
+		g.Eq("", ctx.Response.Headers().Get("Content-Encoding"))
+		g.Eq(`{"text":"raw"}`, ctx.Response.Body())
+		ctx.Response.SetBody(map[string]string{"text": "test"})
+	})
+
+	router.MustAdd(s.URL("/b"), func(ctx *rod.Hijack) {
+		g.E(ctx.LoadResponseViaBrowser(true))
+	})
+
+	go router.Run()
+
+	g.page.MustNavigate(s.URL())
+
+	g.Eq("200 test ", g.page.MustElement("#a").MustText())
+	g.Eq("b", g.page.MustElement("#b").MustText())
+}
+
+func TestHijackViaBrowserRedirect(t *testing.T) {
+	g := setup(t)
+
+	s := g.Serve()
+	s.Mux.HandleFunc("/r", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/c", http.StatusFound)
+	})
+	s.Route("/c", ".html", "<body>c</body>")
+
+	p := g.newPage().Context(g.Context())
+	router := p.HijackRequests()
+	defer router.MustStop()
+
+	router.MustAdd(s.URL("/r"), func(ctx *rod.Hijack) {
+		g.E(ctx.LoadResponseViaBrowser(true))
+		g.Eq(200, ctx.Response.Payload().ResponseCode)
+	})
+
+	go router.Run()
+
+	p.MustNavigate(s.URL("/r"))
+	g.Eq("c", p.MustElement("body").MustText())
+}
+
+func TestHijackTransport(t *testing.T) {
+	g := setup(t)
+
+	p := g.newPage().Context(g.Context())
+	router := p.HijackRequests().Transport(&MockRoundTripper{res: &http.Response{
+		StatusCode: 200,
+		Header:     http.Header{"Content-Type": {"text/html"}},
+		Body:       ioutil.NopCloser(strings.NewReader("mock")),
+	}})
+	defer router.MustStop()
+
+	router.MustAdd("http://test.com/a", func(ctx *rod.Hijack) {
+		ctx.MustLoadResponse()
+	})
+
+	go router.Run()
+
+	p.MustNavigate("http://test.com/a")
+	g.Eq(p.MustElement("body").MustText(), "mock")
+}
+
+func TestHijackLoadResponseWith(t *testing.T) {
+	g := setup(t)
+
+	proxy := g.Serve()
+	proxy.Mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte("proxied " + r.URL.Host))
+	})
+
+	p := g.newPage().Context(g.Context())
+	router := p.HijackRequests().Client(func(h *rod.Hijack) *http.Client {
+		if h.Request.URL().Path == "/factory" {
+			return &http.Client{Transport: &MockRoundTripper{res: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader("factory")),
+			}}}
+		}
+		return nil
+	})
+	defer router.MustStop()
+
+	router.MustAdd("http://test.com/a", func(ctx *rod.Hijack) {
+		g.E(ctx.LoadResponseWith(nil, &rod.HijackLoadOptions{
+			Proxy:     proxy.URL(),
+			Timeout:   time.Minute,
+			TLSConfig: &tls.Config{},
+		}))
+	})
+
+	router.MustAdd("http://test.com/factory", func(ctx *rod.Hijack) {
+		g.E(ctx.LoadResponseWith(nil, nil))
+	})
+
+	router.MustAdd("http://test.com/err", func(ctx *rod.Hijack) {
+		g.Err(ctx.LoadResponseWith(nil, &rod.HijackLoadOptions{Proxy: "://"}))
+		g.Err(ctx.LoadResponseWith(&http.Client{Transport: &MockRoundTripper{}}, &rod.HijackLoadOptions{
+			Proxy: proxy.URL(),
+		}))
+		ctx.Response.SetBody("err")
+	})
+
+	go router.Run()
+
+	p.MustNavigate("http://test.com/a")
+	g.Eq(p.MustElement("body").MustText(), "proxied test.com")
+
+	p.MustNavigate("http://test.com/factory")
+	g.Eq(p.MustElement("body").MustText(), "factory")
+
+	p.MustNavigate("http://test.com/err")
+	g.Eq(p.MustElement("body").MustText(), "err")
+}
+
+func TestHijackRequestBody(t *testing.T) {
+	g := setup(t)
+
+	s := g.Serve()
+	s.Route("/", ".html", `<html></html>`)
+	s.Mux.HandleFunc("/form", func(w http.ResponseWriter, r *http.Request) {
+		g.E(r.ParseForm())
+		g.Eq(r.ContentLength, int64(len("a=changed&b=new")))
+		_, _ = w.Write([]byte(r.PostForm.Encode()))
+	})
+	s.Mux.HandleFunc("/multipart", func(w http.ResponseWriter, r *http.Request) {
+		g.E(r.ParseMultipartForm(1 << 20))
+		f, h, err := r.FormFile("file")
+		g.E(err)
+		b, _ := ioutil.ReadAll(f)
+		_, _ = w.Write([]byte(r.FormValue("a") + " " + h.Filename + " " + string(b)))
+	})
+	s.Mux.HandleFunc("/binary", func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		g.Eq(r.ContentLength, int64(len(b)))
+		_, _ = w.Write([]byte(fmt.Sprint(b)))
+	})
+
+	router := g.page.HijackRequests()
+	defer router.MustStop()
+
+	router.MustAdd(s.URL("/form"), func(ctx *rod.Hijack) {
+		form, err := ctx.Request.Form()
+		g.E(err)
+		g.Eq(form.Get("a"), "origin")
+		form.Set("a", "changed")
+		form.Set("b", "new")
+		ctx.Request.SetForm(form)
+		ctx.MustLoadResponse()
+	})
+
+	router.MustAdd(s.URL("/multipart"), func(ctx *rod.Hijack) {
+		parts, err := ctx.Request.MultipartForm()
+		g.E(err)
+		g.Len(parts, 2)
+		g.Eq(parts[1].FileName, "a.txt")
+		g.Eq(string(parts[1].Data), "file")
+
+		parts[0].Data = []byte("changed")
+		parts[1].FileName = "b.txt"
+		parts[1].Data = append(parts[1].Data, '!')
+		ctx.Request.SetMultipartForm(parts...)
+		ctx.MustLoadResponse()
+	})
+
+	router.MustAdd(s.URL("/binary"), func(ctx *rod.Hijack) {
+		b, err := ctx.Request.RawBody()
+		g.E(err)
+		g.Eq(b, []byte{0, 1, 255})
+		ctx.Request.SetBody(append(b, 0))
+		ctx.MustLoadResponse()
+
+		_, err = ctx.Request.MultipartForm()
+		g.Err(err)
+	})
+
+	go router.Run()
+
+	p := g.page.MustNavigate(s.URL())
+
+	g.Eq(p.MustEval(`() => fetch('/form', { method: 'POST', body: new URLSearchParams({ a: 'origin' }) }).then(r => r.text())`).Str(), "a=changed&b=new")
+
+	g.Eq(p.MustEval(`() => {
+		const data = new FormData()
+		data.append('a', 'origin')
+		data.append('file', new Blob(['file']), 'a.txt')
+		return fetch('/multipart', { method: 'POST', body: data }).then(r => r.text())
+	}`).Str(), "changed b.txt file!")
+
+	g.Eq(p.MustEval(`() => fetch('/binary', { method: 'POST', body: new Uint8Array([0, 1, 255]) }).then(r => r.text())`).Str(), "[0 1 255 0]")
+}
+
 func TestHijackResponseErr(t *testing.T) {
 	g := setup(t)
 
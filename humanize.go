@@ -0,0 +1,297 @@
+// This file serves for the human-like input simulation.
+
+package rod
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/Fromsko/rodPro/lib/input"
+	"github.com/Fromsko/rodPro/lib/proto"
+)
+
+// HumanizeOptions for [Page.Humanize]
+type HumanizeOptions struct {
+	// MouseSpeed is the average speed of the mouse in pixels per second
+	MouseSpeed float64
+
+	// Jitter is the max random offset in pixels of each mouse step
+	Jitter float64
+
+	// PressMin and PressMax are the range of the duration to hold a mouse button or a key
+	PressMin, PressMax time.Duration
+
+	// TypeDelay is the mean delay between two keystrokes, the delays are sampled from
+	// a normal distribution with the standard deviation TypeDeviation.
+	TypeDelay, TypeDeviation time.Duration
+
+	// Rand is the random source, set it to make the simulation reproducible
+	Rand *rand.Rand
+}
+
+// DefaultHumanizeOptions for [Page.Humanize]
+func DefaultHumanizeOptions() *HumanizeOptions {
+	return &HumanizeOptions{
+		MouseSpeed:    800,
+		Jitter:        1.5,
+		PressMin:      50 * time.Millisecond,
+		PressMax:      130 * time.Millisecond,
+		TypeDelay:     120 * time.Millisecond,
+		TypeDeviation: 40 * time.Millisecond,
+	}
+}
+
+type humanizer struct {
+	lock sync.Mutex
+	opts HumanizeOptions
+	rand *rand.Rand
+}
+
+func newHumanizer(opts *HumanizeOptions) *humanizer {
+	if opts == nil {
+		opts = DefaultHumanizeOptions()
+	}
+
+	h := &humanizer{opts: *opts, rand: opts.Rand}
+	if h.rand == nil {
+		h.rand = rand.New(rand.NewSource(time.Now().UnixNano())) //nolint: gosec
+	}
+	if h.opts.MouseSpeed <= 0 {
+		h.opts.MouseSpeed = DefaultHumanizeOptions().MouseSpeed
+	}
+	if h.opts.PressMax < h.opts.PressMin {
+		h.opts.PressMax = h.opts.PressMin
+	}
+	return h
+}
+
+func (h *humanizer) float() float64 {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return h.rand.Float64()
+}
+
+func (h *humanizer) norm() float64 {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return h.rand.NormFloat64()
+}
+
+// between returns a random duration in [a, b]
+func (h *humanizer) between(a, b time.Duration) time.Duration {
+	return a + time.Duration(h.float()*float64(b-a))
+}
+
+func (h *humanizer) press() time.Duration {
+	return h.between(h.opts.PressMin, h.opts.PressMax)
+}
+
+func (h *humanizer) typeDelay() time.Duration {
+	d := h.opts.TypeDelay + time.Duration(h.norm()*float64(h.opts.TypeDeviation))
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
+// path returns the points of a cubic Bezier curve from "from" to "to", the control points
+// are randomly placed beside the straight line, the steps are eased so the speed varies like a human hand.
+func (h *humanizer) path(from, to proto.Point) (points []proto.Point, interval time.Duration) {
+	dist := math.Hypot(to.X-from.X, to.Y-from.Y)
+	duration := time.Duration(dist / h.opts.MouseSpeed * float64(time.Second))
+
+	steps := int(duration / (16 * time.Millisecond))
+	if steps < 1 {
+		return []proto.Point{to}, 0
+	}
+
+	// the unit normal of the line
+	nx, ny := -(to.Y-from.Y)/dist, (to.X-from.X)/dist
+
+	control := func(t float64) proto.Point {
+		offset := (h.float() - 0.5) * dist * 0.4
+		return proto.Point{
+			X: from.X + (to.X-from.X)*t + nx*offset,
+			Y: from.Y + (to.Y-from.Y)*t + ny*offset,
+		}
+	}
+	c1, c2 := control(0.25+h.float()*0.2), control(0.55+h.float()*0.2)
+
+	for i := 1; i <= steps; i++ {
+		t := float64(i) / float64(steps)
+		t = t * t * (3 - 2*t) // ease in and out
+
+		u := 1 - t
+		pt := proto.Point{
+			X: u*u*u*from.X + 3*u*u*t*c1.X + 3*u*t*t*c2.X + t*t*t*to.X,
+			Y: u*u*u*from.Y + 3*u*u*t*c1.Y + 3*u*t*t*c2.Y + t*t*t*to.Y,
+		}
+
+		if i < steps {
+			pt.X += (h.float()*2 - 1) * h.opts.Jitter
+			pt.Y += (h.float()*2 - 1) * h.opts.Jitter
+		} else {
+			pt = to
+		}
+
+		points = append(points, pt)
+	}
+
+	return points, duration / time.Duration(steps)
+}
+
+func humanSleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// Humanize makes the input of the page human-like, it's useful for the sites that are sensitive to bots.
+// [Mouse.MoveTo] will follow a random Bezier curve with jitter and variable speed, [Mouse.Click] and
+// [Keyboard.Type] will hold the buttons for a random duration, [Keyboard.Type] and [Element.Input] will type
+// the characters one by one with random delays. If opts is nil, [DefaultHumanizeOptions] will be used.
+// Use [Page.StopHumanize] to disable it. To humanize a single action use the methods like [Mouse.MoveHuman].
+func (p *Page) Humanize(opts *HumanizeOptions) *Page {
+	h := newHumanizer(opts)
+	p.Mouse.setHuman(h)
+	p.Keyboard.setHuman(h)
+	return p
+}
+
+// StopHumanize disables the [Page.Humanize]
+func (p *Page) StopHumanize() *Page {
+	p.Mouse.setHuman(nil)
+	p.Keyboard.setHuman(nil)
+	return p
+}
+
+func (m *Mouse) setHuman(h *humanizer) {
+	m.Lock()
+	defer m.Unlock()
+	m.human = h
+}
+
+func (m *Mouse) getHuman() *humanizer {
+	m.Lock()
+	defer m.Unlock()
+	return m.human
+}
+
+func (k *Keyboard) setHuman(h *humanizer) {
+	k.Lock()
+	defer k.Unlock()
+	k.human = h
+}
+
+func (k *Keyboard) getHuman() *humanizer {
+	k.Lock()
+	defer k.Unlock()
+	return k.human
+}
+
+// MoveHuman moves the mouse to the absolute position like a human, see [Page.Humanize] for details.
+// If opts is nil, [DefaultHumanizeOptions] will be used.
+func (m *Mouse) MoveHuman(to proto.Point, opts *HumanizeOptions) error {
+	return m.moveHuman(to, newHumanizer(opts))
+}
+
+func (m *Mouse) moveHuman(to proto.Point, h *humanizer) error {
+	points, interval := h.path(m.Position(), to)
+
+	for i, pt := range points {
+		if i > 0 {
+			err := humanSleep(m.page.ctx, h.between(interval*4/5, interval*6/5))
+			if err != nil {
+				return err
+			}
+		}
+
+		err := m.move(pt)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ClickHuman clicks the button like a human, see [Page.Humanize] for details.
+// If opts is nil, [DefaultHumanizeOptions] will be used.
+func (m *Mouse) ClickHuman(button proto.InputMouseButton, clickCount int, opts *HumanizeOptions) error {
+	return m.clickHuman(button, clickCount, newHumanizer(opts))
+}
+
+func (m *Mouse) clickHuman(button proto.InputMouseButton, clickCount int, h *humanizer) error {
+	m.page.browser.trySlowMotion("input.click")
+
+	err := m.Down(button, clickCount)
+	if err != nil {
+		return err
+	}
+
+	err = humanSleep(m.page.ctx, h.press())
+	if err != nil {
+		return err
+	}
+
+	return m.Up(button, clickCount)
+}
+
+// TypeHuman types the text like a human, see [Page.Humanize] for details.
+// The characters that are not on the keyboard will be inserted via [Page.InsertText].
+// If opts is nil, [DefaultHumanizeOptions] will be used.
+func (k *Keyboard) TypeHuman(text string, opts *HumanizeOptions) error {
+	return k.typeHuman(text, newHumanizer(opts))
+}
+
+func (k *Keyboard) typeHuman(text string, h *humanizer) error {
+	for i, r := range []rune(text) {
+		if i > 0 {
+			err := humanSleep(k.page.ctx, h.typeDelay())
+			if err != nil {
+				return err
+			}
+		}
+
+		key := input.Key(r)
+
+		var err error
+		if key.Defined() && key.Printable() {
+			err = k.typeKeyHuman(key, h)
+		} else {
+			err = k.page.InsertText(string(r))
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (k *Keyboard) typeKeyHuman(key input.Key, h *humanizer) error {
+	err := k.Press(key)
+	if err != nil {
+		return err
+	}
+
+	err = humanSleep(k.page.ctx, h.press())
+	if err != nil {
+		return err
+	}
+
+	return k.Release(key)
+}
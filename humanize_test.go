@@ -0,0 +1,93 @@
+package rod_test
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/Fromsko/rodPro"
+	"github.com/Fromsko/rodPro/lib/input"
+	"github.com/Fromsko/rodPro/lib/proto"
+)
+
+func fastHuman() *rod.HumanizeOptions {
+	opts := rod.DefaultHumanizeOptions()
+	opts.MouseSpeed = 5000
+	opts.PressMin = time.Millisecond
+	opts.PressMax = 5 * time.Millisecond
+	opts.TypeDelay = 5 * time.Millisecond
+	opts.TypeDeviation = time.Millisecond
+	opts.Rand = rand.New(rand.NewSource(1))
+	return opts
+}
+
+func TestHumanize(t *testing.T) {
+	g := setup(t)
+
+	p := g.page.MustNavigate(g.srcFile("fixtures/click.html"))
+	p.MustEval(`() => {
+		window.moves = []
+		window.addEventListener('mousemove', e => window.moves.push([e.clientX, e.clientY]))
+	}`)
+
+	p.Humanize(fastHuman())
+	defer p.StopHumanize()
+
+	p.Mouse.MustMoveTo(0, 0)
+	p.MustElement("button").MustClick()
+	g.True(p.MustHas("[a=ok]"))
+
+	moves := p.MustEval(`() => window.moves`).Arr()
+	g.Gt(len(moves), 3)
+
+	p = g.page.MustNavigate(g.srcFile("fixtures/input.html"))
+	el := p.MustElement("[type=text]")
+
+	start := time.Now()
+	el.MustInput("ab 中文")
+	g.Eq(el.MustText(), "ab 中文")
+	g.Gt(time.Since(start), 20*time.Millisecond)
+
+	p.Keyboard.MustType(input.Backspace, input.Backspace)
+	g.Eq(el.MustText(), "ab ")
+
+	g.mc.stubErr(1, proto.InputDispatchMouseEvent{})
+	g.Err(p.Mouse.MoveTo(proto.NewPoint(300, 300)))
+
+	g.mc.stubErr(1, proto.InputDispatchKeyEvent{})
+	g.Err(p.Keyboard.Type('a'))
+}
+
+func TestHumanizeSingleAction(t *testing.T) {
+	g := setup(t)
+
+	p := g.page.MustNavigate(g.srcFile("fixtures/click.html"))
+	el := p.MustElement("button")
+
+	pt := el.MustShape().OnePointInside()
+	p.Mouse.MustMoveTo(0, 0).MustMoveHuman(pt.X, pt.Y, fastHuman()).MustClickHuman(proto.InputMouseButtonLeft, fastHuman())
+	g.True(p.MustHas("[a=ok]"))
+	g.Eq(p.Mouse.Position(), *pt)
+
+	p = g.page.MustNavigate(g.srcFile("fixtures/input.html"))
+	input := p.MustElement("[type=text]").MustInputHuman("Hi!", fastHuman())
+	g.Eq(input.MustText(), "Hi!")
+
+	p.Keyboard.MustTypeHuman("?", nil)
+	g.Eq(input.MustText(), "Hi!?")
+
+	g.mc.stubErr(1, proto.InputDispatchMouseEvent{})
+	g.Err(p.Mouse.ClickHuman(proto.InputMouseButtonLeft, 1, fastHuman()))
+
+	g.mc.stubErr(2, proto.InputDispatchMouseEvent{})
+	g.Err(p.Mouse.ClickHuman(proto.InputMouseButtonLeft, 1, fastHuman()))
+
+	g.mc.stubErr(1, proto.InputDispatchKeyEvent{})
+	g.Err(p.Keyboard.TypeHuman("a", fastHuman()))
+
+	g.mc.stubErr(2, proto.InputDispatchKeyEvent{})
+	g.Err(p.Keyboard.TypeHuman("a", fastHuman()))
+
+	g.mc.stubErr(1, proto.InputInsertText{})
+	g.Err(p.Keyboard.TypeHuman("中", fastHuman()))
+}
@@ -2,7 +2,9 @@ package rod
 
 import (
 	"fmt"
+	"math"
 	"sync"
+	"time"
 
 	"github.com/Fromsko/rodPro/lib/input"
 	"github.com/Fromsko/rodPro/lib/proto"
@@ -18,6 +20,8 @@ type Keyboard struct {
 
 	// pressed keys must be released before it can be pressed again
 	pressed map[input.Key]struct{}
+
+	human *humanizer
 }
 
 func (p *Page) newKeyboard() *Page {
@@ -44,7 +48,7 @@ func (k *Keyboard) modifiers() int {
 // use method like [Page.InsertText].
 func (k *Keyboard) Press(key input.Key) error {
 	defer k.page.tryTrace(TraceTypeInput, "press key: "+key.Info().Code)()
-	k.page.browser.trySlowMotion()
+	k.page.browser.trySlowMotion("input.key")
 
 	k.Lock()
 	defer k.Unlock()
@@ -70,8 +74,25 @@ func (k *Keyboard) Release(key input.Key) error {
 	return key.Encode(proto.InputDispatchKeyEventTypeKeyUp, k.modifiers()).Call(k.page)
 }
 
-// Type releases the key after the press
+// Type releases the key after the press.
+// If [Page.Humanize] is enabled, the keys will be held and typed with random delays.
 func (k *Keyboard) Type(keys ...input.Key) (err error) {
+	if h := k.getHuman(); h != nil {
+		for i, key := range keys {
+			if i > 0 {
+				err = humanSleep(k.page.ctx, h.typeDelay())
+				if err != nil {
+					return
+				}
+			}
+			err = k.typeKeyHuman(key, h)
+			if err != nil {
+				return
+			}
+		}
+		return
+	}
+
 	for _, key := range keys {
 		err = k.Press(key)
 		if err != nil {
@@ -85,6 +106,81 @@ func (k *Keyboard) Type(keys ...input.Key) (err error) {
 	return
 }
 
+// PressChord presses the chords one by one, such as:
+//
+//	k.PressChord("Ctrl+A", "Backspace", "Shift+Tab")
+//
+// The modifiers are released after each chord, check [input.ParseChord] for the syntax.
+func (k *Keyboard) PressChord(chords ...string) error {
+	for _, chord := range chords {
+		keys, err := input.ParseChord(chord)
+		if err != nil {
+			return err
+		}
+
+		err = (&KeyActions{keyboard: k}).Press(keys[:len(keys)-1]...).Type(keys[len(keys)-1]).Do()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TypeText types the text with the real key events, the chords can be mixed with the text inside
+// the curly braces, use "{{" for a literal "{". Such as:
+//
+//	k.TypeText("{Ctrl+A}{Backspace}Hello World!{Enter}")
+//
+// The Shift will be held for the shifted characters like "A" or "!", "\n" will be typed as Enter,
+// the characters that are not on the keyboard will be inserted via [Page.InsertText].
+func (k *Keyboard) TypeText(text string) error {
+	runes := []rune(text)
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+
+		if r == '{' && (i+1 >= len(runes) || runes[i+1] != '{') {
+			end := i + 1
+			for end < len(runes) && runes[end] != '}' {
+				end++
+			}
+			if end == len(runes) {
+				return fmt.Errorf("unclosed chord in text: %q", text)
+			}
+
+			err := k.PressChord(string(runes[i+1 : end]))
+			if err != nil {
+				return err
+			}
+
+			i = end
+			continue
+		} else if r == '{' {
+			i++
+		}
+
+		key := input.Key(r)
+		if r == '\n' {
+			key = input.Enter
+		}
+
+		var err error
+		switch {
+		case !key.Defined():
+			err = k.page.InsertText(string(r))
+		case key.NeedShift():
+			err = (&KeyActions{keyboard: k}).Press(input.ShiftLeft).Type(key).Do()
+		default:
+			err = k.Type(key)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // KeyActionType enum
 type KeyActionType int
 
@@ -184,7 +280,7 @@ func (ka *KeyActions) balance() []KeyAction {
 // InsertText is like pasting text into the page
 func (p *Page) InsertText(text string) error {
 	defer p.tryTrace(TraceTypeInput, "insert text "+text)()
-	p.browser.trySlowMotion()
+	p.browser.trySlowMotion("input.insert_text")
 
 	err := proto.InputInsertText{Text: text}.Call(p)
 	return err
@@ -202,6 +298,8 @@ type Mouse struct {
 
 	// the buttons is currently being pressed, reflects the press order
 	buttons []proto.InputMouseButton
+
+	human *humanizer
 }
 
 func (p *Page) newMouse() *Page {
@@ -216,14 +314,22 @@ func (m *Mouse) Position() proto.Point {
 	return m.pos
 }
 
-// MoveTo the absolute position
+// MoveTo the absolute position.
+// If [Page.Humanize] is enabled, the mouse will move along a human-like path.
 func (m *Mouse) MoveTo(p proto.Point) error {
+	if h := m.getHuman(); h != nil {
+		return m.moveHuman(p, h)
+	}
+	return m.move(p)
+}
+
+func (m *Mouse) move(p proto.Point) error {
 	m.Lock()
 	defer m.Unlock()
 
 	button, buttons := input.EncodeMouseButton(m.buttons)
 
-	m.page.browser.trySlowMotion()
+	m.page.browser.trySlowMotion("input.move")
 
 	err := proto.InputDispatchMouseEvent{
 		Type:      proto.InputDispatchMouseEventTypeMouseMoved,
@@ -240,7 +346,7 @@ func (m *Mouse) MoveTo(p proto.Point) error {
 	// to make sure set only when call is successful
 	m.pos = p
 
-	if m.page.browser.trace {
+	if m.page.traceEnabled() {
 		if !m.updateMouseTracer() {
 			m.initMouseTracer()
 			m.updateMouseTracer()
@@ -257,10 +363,10 @@ func (m *Mouse) MoveAlong(guide func() (proto.Point, bool)) error {
 	for {
 		p, stop := guide()
 		if stop {
-			return m.MoveTo(p)
+			return m.move(p)
 		}
 
-		err := m.MoveTo(p)
+		err := m.move(p)
 		if err != nil {
 			return err
 		}
@@ -291,7 +397,7 @@ func (m *Mouse) Scroll(offsetX, offsetY float64, steps int) error {
 	defer m.Unlock()
 
 	defer m.page.tryTrace(TraceTypeInput, fmt.Sprintf("scroll (%.2f, %.2f)", offsetX, offsetY))()
-	m.page.browser.trySlowMotion()
+	m.page.browser.trySlowMotion("input.scroll")
 
 	if steps < 1 {
 		steps = 1
@@ -321,6 +427,103 @@ func (m *Mouse) Scroll(offsetX, offsetY float64, steps int) error {
 	return nil
 }
 
+// SmoothScroll the relative offset within the duration. Unlike [Mouse.Scroll] it emits a wheel event
+// about every 16ms, and the deltas are eased in and out like a real touchpad, because many infinite-scroll
+// implementations ignore a single large wheel delta.
+func (m *Mouse) SmoothScroll(offsetX, offsetY float64, duration time.Duration) error {
+	defer m.page.tryTrace(TraceTypeInput, fmt.Sprintf("smooth scroll (%.2f, %.2f)", offsetX, offsetY))()
+	m.page.browser.trySlowMotion("input.scroll")
+
+	steps := int(duration / (16 * time.Millisecond))
+	if steps < 1 {
+		steps = 1
+	}
+	interval := duration / time.Duration(steps)
+
+	ease := func(t float64) float64 { return t * t * (3 - 2*t) }
+
+	prev := 0.0
+	for i := 1; i <= steps; i++ {
+		if i > 1 {
+			err := humanSleep(m.page.ctx, interval)
+			if err != nil {
+				return err
+			}
+		}
+
+		cur := ease(float64(i) / float64(steps))
+		err := m.wheel(offsetX*(cur-prev), offsetY*(cur-prev))
+		if err != nil {
+			return err
+		}
+		prev = cur
+	}
+
+	return nil
+}
+
+func (m *Mouse) wheel(deltaX, deltaY float64) error {
+	m.Lock()
+	defer m.Unlock()
+
+	button, buttons := input.EncodeMouseButton(m.buttons)
+
+	return proto.InputDispatchMouseEvent{
+		Type:      proto.InputDispatchMouseEventTypeMouseWheel,
+		Button:    button,
+		Buttons:   gson.Int(buttons),
+		Modifiers: m.page.Keyboard.getModifiers(),
+		DeltaX:    deltaX,
+		DeltaY:    deltaY,
+		X:         m.pos.X,
+		Y:         m.pos.Y,
+	}.Call(m.page)
+}
+
+// ScrollTo scrolls the page with the mouse wheel until the element is at the center of the viewport,
+// the wheel events are emitted via [Mouse.SmoothScroll]. Because the layout may change while scrolling,
+// such as an infinite-scroll list, it will retry a few times, then fallback to [Element.ScrollIntoView].
+func (p *Page) ScrollTo(el *Element) error {
+	defer p.tryTrace(TraceTypeInput, "scroll to element")()
+
+	for i := 0; i < 10; i++ {
+		res, err := el.Eval(`() => {
+			const r = this.getBoundingClientRect()
+			return {
+				x: r.left + r.width / 2 - innerWidth / 2,
+				y: r.top + r.height / 2 - innerHeight / 2,
+				visible: r.top >= 0 && r.left >= 0 && r.bottom <= innerHeight && r.right <= innerWidth,
+			}
+		}`)
+		if err != nil {
+			return err
+		}
+
+		x, y := res.Value.Get("x").Num(), res.Value.Get("y").Num()
+		if res.Value.Get("visible").Bool() || math.Abs(x) < 1 && math.Abs(y) < 1 {
+			return nil
+		}
+
+		// about 2000 pixels per second
+		d := time.Duration(math.Hypot(x, y)/2000*float64(time.Second)) + 100*time.Millisecond
+		if d > time.Second {
+			d = time.Second
+		}
+
+		err = p.Mouse.SmoothScroll(x, y, d)
+		if err != nil {
+			return err
+		}
+
+		err = p.WaitRepaint()
+		if err != nil {
+			return err
+		}
+	}
+
+	return el.ScrollIntoView()
+}
+
 // Down holds the button down
 func (m *Mouse) Down(button proto.InputMouseButton, clickCount int) error {
 	m.Lock()
@@ -377,9 +580,14 @@ func (m *Mouse) Up(button proto.InputMouseButton, clickCount int) error {
 	return nil
 }
 
-// Click the button. It's the combination of [Mouse.Down] and [Mouse.Up]
+// Click the button. It's the combination of [Mouse.Down] and [Mouse.Up].
+// If [Page.Humanize] is enabled, the button will be held for a random duration.
 func (m *Mouse) Click(button proto.InputMouseButton, clickCount int) error {
-	m.page.browser.trySlowMotion()
+	if h := m.getHuman(); h != nil {
+		return m.clickHuman(button, clickCount, h)
+	}
+
+	m.page.browser.trySlowMotion("input.click")
 
 	err := m.Down(button, clickCount)
 	if err != nil {
@@ -389,6 +597,14 @@ func (m *Mouse) Click(button proto.InputMouseButton, clickCount int) error {
 	return m.Up(button, clickCount)
 }
 
+func (m *Mouse) moveAndClick(p proto.Point) error {
+	err := m.MoveTo(p)
+	if err != nil {
+		return err
+	}
+	return m.Click(proto.InputMouseButtonLeft, 1)
+}
+
 // Touch presents a touch device, such as a hand with fingers, each finger is a [proto.InputTouchPoint].
 // Touch events is stateless, we use the struct here only as a namespace to make the API style unified.
 type Touch struct {
@@ -444,7 +660,7 @@ func (t *Touch) Cancel() error {
 // Tap dispatches a touchstart and touchend event.
 func (t *Touch) Tap(x, y float64) error {
 	defer t.page.tryTrace(TraceTypeInput, "touch")()
-	t.page.browser.trySlowMotion()
+	t.page.browser.trySlowMotion("input.tap")
 
 	p := &proto.InputTouchPoint{X: x, Y: y}
 
@@ -455,3 +671,144 @@ func (t *Touch) Tap(x, y float64) error {
 
 	return t.End()
 }
+
+// PenPoint is a point of the [Pen] with the stylus states
+type PenPoint struct {
+	X, Y float64
+
+	// Pressure is the normalized pressure in the range [0, 1], 0 means 0.5 when the pen touches the surface
+	Pressure float64
+
+	// TiltX and TiltY are the tilt angles of the stylus in the range [-90, 90] degrees
+	TiltX, TiltY int
+
+	// Twist is the clockwise rotation of the stylus around its own axis in the range [0, 359] degrees
+	Twist int
+}
+
+// Pen presents a stylus, the events are the pointer events with the pointerType "pen",
+// it's useful to test the drawing or signature canvases.
+type Pen struct {
+	sync.Mutex
+
+	page *Page
+
+	pos  PenPoint
+	down bool
+}
+
+func (p *Page) newPen() *Page {
+	p.Pen = &Pen{page: p}
+	return p
+}
+
+// Position of the pen
+func (p *Pen) Position() PenPoint {
+	p.Lock()
+	defer p.Unlock()
+	return p.pos
+}
+
+// MoveTo the point, it hovers if the pen is up, or draws if the pen is down
+func (p *Pen) MoveTo(pt PenPoint) error {
+	p.Lock()
+	defer p.Unlock()
+
+	p.page.browser.trySlowMotion("input.move")
+
+	return p.dispatch(proto.InputDispatchMouseEventTypeMouseMoved, pt, p.down)
+}
+
+// Down touches the surface at the point
+func (p *Pen) Down(pt PenPoint) error {
+	p.Lock()
+	defer p.Unlock()
+
+	err := p.dispatch(proto.InputDispatchMouseEventTypeMousePressed, pt, true)
+	if err != nil {
+		return err
+	}
+	p.down = true
+	return nil
+}
+
+// Up lifts the pen from the surface at the current position
+func (p *Pen) Up() error {
+	p.Lock()
+	defer p.Unlock()
+
+	pt := p.pos
+	pt.Pressure = 0
+
+	err := p.dispatch(proto.InputDispatchMouseEventTypeMouseReleased, pt, false)
+	if err != nil {
+		return err
+	}
+	p.down = false
+	return nil
+}
+
+// DrawPath draws a stroke along the points, the pen touches the surface at the first point
+// and lifts at the last point. Each point can have its own pressure and angles.
+func (p *Pen) DrawPath(points []PenPoint) error {
+	if len(points) == 0 {
+		return nil
+	}
+
+	defer p.page.tryTrace(TraceTypeInput, "pen draw")()
+
+	err := p.MoveTo(points[0])
+	if err != nil {
+		return err
+	}
+
+	err = p.Down(points[0])
+	if err != nil {
+		return err
+	}
+
+	for _, pt := range points[1:] {
+		err = p.MoveTo(pt)
+		if err != nil {
+			return err
+		}
+	}
+
+	return p.Up()
+}
+
+func (p *Pen) dispatch(typ proto.InputDispatchMouseEventType, pt PenPoint, down bool) error {
+	e := proto.InputDispatchMouseEvent{
+		Type:        typ,
+		X:           pt.X,
+		Y:           pt.Y,
+		Modifiers:   p.page.Keyboard.getModifiers(),
+		TiltX:       pt.TiltX,
+		TiltY:       pt.TiltY,
+		Twist:       pt.Twist,
+		PointerType: proto.InputDispatchMouseEventPointerTypePen,
+	}
+
+	if down {
+		e.Button = proto.InputMouseButtonLeft
+		e.Buttons = gson.Int(1)
+		e.Force = pt.Pressure
+		if e.Force == 0 {
+			e.Force = 0.5
+		}
+	} else {
+		e.Buttons = gson.Int(0)
+	}
+	if typ != proto.InputDispatchMouseEventTypeMouseMoved {
+		e.Button = proto.InputMouseButtonLeft
+		e.ClickCount = 1
+	}
+
+	err := e.Call(p.page)
+	if err != nil {
+		return err
+	}
+
+	p.pos = pt
+	return nil
+}
@@ -2,7 +2,9 @@ package rod_test
 
 import (
 	"testing"
+	"time"
 
+	"github.com/Fromsko/rodPro"
 	"github.com/Fromsko/rodPro/lib/devices"
 	"github.com/Fromsko/rodPro/lib/input"
 	"github.com/Fromsko/rodPro/lib/proto"
@@ -53,6 +55,72 @@ func TestKeyType(t *testing.T) {
 	g.Eq("1 A b test", el.MustText())
 }
 
+func TestKeyTypeText(t *testing.T) {
+	g := setup(t)
+
+	p := g.page.MustNavigate(g.srcFile("fixtures/input.html"))
+	el := p.MustElement("[type=text]")
+
+	el.MustTypeText("Hello {{World}! 中")
+	g.Eq(el.MustText(), "Hello {World}! 中")
+
+	el.MustPressChord("Mod+A", "Backspace")
+	g.Eq(el.MustText(), "")
+
+	el.MustTypeText("abc{ArrowLeft}{Backspace}")
+	g.Eq(el.MustText(), "ac")
+
+	el.MustClear(rod.ClearModeSelectAll)
+	g.Eq(el.MustText(), "")
+
+	el.MustInput("test").MustClear(rod.ClearModeValue)
+	g.Eq(el.MustText(), "")
+	g.Eq(el.MustAttribute("event"), "input-change")
+
+	g.Err(el.TypeText("{Ctrl+A"))
+	g.Err(el.TypeText("{Nope}"))
+	g.Err(el.PressChord("Nope"))
+
+	g.mc.stubErr(1, proto.InputDispatchKeyEvent{})
+	g.Err(el.TypeText("a"))
+
+	g.mc.stubErr(1, proto.InputDispatchKeyEvent{})
+	g.Err(el.TypeText("A"))
+
+	g.mc.stubErr(1, proto.InputInsertText{})
+	g.Err(el.TypeText("中"))
+
+	g.mc.stubErr(1, proto.RuntimeCallFunctionOn{})
+	g.Err(el.TypeText("a"))
+
+	g.mc.stubErr(1, proto.RuntimeCallFunctionOn{})
+	g.Err(el.PressChord("a"))
+
+	g.mc.stubErr(1, proto.RuntimeCallFunctionOn{})
+	g.Err(el.Clear(rod.ClearModeValue))
+
+	g.mc.stubErr(3, proto.RuntimeCallFunctionOn{})
+	g.Err(el.Clear(rod.ClearModeValue))
+}
+
+func TestKeyTypeTextModifiers(t *testing.T) {
+	g := setup(t)
+
+	p := g.page.MustNavigate(g.srcFile("fixtures/keys.html"))
+	body := p.MustElement("body")
+
+	p.Keyboard.MustTypeText("A{Ctrl+Enter}")
+	g.Eq(body.MustText(), `↓ "Shift" ShiftLeft 16 modifiers(shift)
+↓ "A" KeyA 65 modifiers(shift)
+↑ "A" KeyA 65 modifiers(shift)
+↑ "Shift" ShiftLeft 16 modifiers()
+↓ "Control" ControlLeft 17 modifiers(ctrl)
+↓ "Enter" Enter 13 modifiers(ctrl)
+↑ "Enter" Enter 13 modifiers(ctrl)
+↑ "Control" ControlLeft 17 modifiers()
+`)
+}
+
 func TestKeyTypeErr(t *testing.T) {
 	g := setup(t)
 
@@ -211,6 +279,48 @@ func TestMouseScroll(t *testing.T) {
 	p.MustWait(`() => pageXOffset > 200 && pageYOffset > 300`)
 }
 
+func TestMouseSmoothScroll(t *testing.T) {
+	g := setup(t)
+
+	p := g.page.MustNavigate(g.srcFile("fixtures/scroll.html")).MustWaitLoad()
+	p.MustEval(`() => {
+		window.wheels = 0
+		window.addEventListener('wheel', () => window.wheels++)
+	}`)
+
+	p.Mouse.MustMoveTo(30, 30)
+	p.Mouse.MustSmoothScroll(100, 300, 200*time.Millisecond)
+
+	p.MustWait(`() => pageXOffset > 90 && pageYOffset > 290`)
+	g.Gt(p.MustEval(`() => window.wheels`).Int(), 5)
+
+	g.mc.stubErr(1, proto.InputDispatchMouseEvent{})
+	g.Err(p.Mouse.SmoothScroll(0, 10, 0))
+}
+
+func TestPageScrollTo(t *testing.T) {
+	g := setup(t)
+
+	p := g.page.MustNavigate(g.srcFile("fixtures/scroll.html")).MustWaitLoad()
+	el := p.MustElement("button")
+
+	p.MustScrollTo(el)
+	g.True(el.MustEval(`() => {
+		const r = this.getBoundingClientRect()
+		return r.top >= 0 && r.bottom <= innerHeight
+	}`).Bool())
+
+	// already in the viewport
+	p.MustScrollTo(el)
+
+	g.mc.stubErr(1, proto.RuntimeCallFunctionOn{})
+	g.Err(p.ScrollTo(el))
+
+	p.MustEval(`() => window.scrollTo(0, 0)`)
+	g.mc.stubErr(1, proto.InputDispatchMouseEvent{})
+	g.Err(p.ScrollTo(el))
+}
+
 func TestMouseMoveLinear(t *testing.T) {
 	g := setup(t)
 
@@ -281,3 +391,53 @@ func TestTouch(t *testing.T) {
 		touch.MustTap(1, 2)
 	})
 }
+
+func TestPen(t *testing.T) {
+	g := setup(t)
+
+	page := g.page.MustNavigate(g.blank())
+	page.MustEval(`() => {
+		window.penTrack = []
+		for (const type of ['pointerdown', 'pointermove', 'pointerup']) {
+			window.addEventListener(type, e => {
+				if (e.pointerType !== 'pen') return
+				window.penTrack.push([e.type, e.clientX, e.clientY, e.pressure, e.tiltX, e.twist])
+			})
+		}
+	}`)
+
+	pen := page.Pen
+	pen.MustDrawPath([]rod.PenPoint{
+		{X: 10, Y: 10, Pressure: 0.2},
+		{X: 20, Y: 20, Pressure: 0.8, TiltX: 30, Twist: 90},
+		{X: 30, Y: 30},
+	})
+	g.Eq(pen.Position().X, 30.0)
+
+	track := page.MustEval(`() => penTrack`).Arr()
+	g.Eq(track[0].Arr()[0].Str(), "pointermove")
+	g.Eq(track[1].Arr()[0].Str(), "pointerdown")
+	g.InDelta(track[1].Arr()[3].Num(), 0.2, 0.01)
+	g.Eq(track[2].Arr()[0].Str(), "pointermove")
+	g.InDelta(track[2].Arr()[3].Num(), 0.8, 0.01)
+	g.Eq(track[2].Arr()[4].Int(), 30)
+	g.Eq(track[2].Arr()[5].Int(), 90)
+	g.InDelta(track[3].Arr()[3].Num(), 0.5, 0.01)
+	g.Eq(track[len(track)-1].Arr()[0].Str(), "pointerup")
+
+	pen.MustMoveTo(rod.PenPoint{X: 40, Y: 40}).MustDown(rod.PenPoint{X: 40, Y: 40}).MustUp()
+
+	g.E(pen.DrawPath(nil))
+
+	g.mc.stubErr(1, proto.InputDispatchMouseEvent{})
+	g.Err(pen.DrawPath([]rod.PenPoint{{X: 1, Y: 1}}))
+
+	g.mc.stubErr(2, proto.InputDispatchMouseEvent{})
+	g.Err(pen.DrawPath([]rod.PenPoint{{X: 1, Y: 1}}))
+
+	g.mc.stubErr(3, proto.InputDispatchMouseEvent{})
+	g.Err(pen.DrawPath([]rod.PenPoint{{X: 1, Y: 1}, {X: 2, Y: 2}}))
+
+	g.mc.stubErr(1, proto.InputDispatchMouseEvent{})
+	g.Err(pen.Up())
+}
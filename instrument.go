@@ -0,0 +1,127 @@
+// This file serves for the instrumentation of the actions and the cdp calls, such as the OpenTelemetry tracing.
+
+package rod
+
+import (
+	"context"
+)
+
+// Attribute of a [Span]
+type Attribute struct {
+	Key   string
+	Value string
+}
+
+// The keys of the attributes
+const (
+	AttrSelector  = "rod.selector"
+	AttrElement   = "rod.element"
+	AttrURL       = "url.full"
+	AttrTargetID  = "rod.target_id"
+	AttrSessionID = "rod.session_id"
+	AttrCDPMethod = "rod.cdp.method"
+)
+
+// Span of an action or a cdp call
+type Span interface {
+	// End the span, err is the result of the action
+	End(err error)
+}
+
+// Instrumentation starts the spans, the returned context will be used by the sub-operations of the action,
+// so the spans of them can be nested. It's designed to be easily adapted to OpenTelemetry, such as:
+//
+//	type otelInstrumentation struct{ tracer trace.Tracer }
+//
+//	func (o otelInstrumentation) Start(ctx context.Context, name string, attrs []rod.Attribute) (context.Context, rod.Span) {
+//		kv := []attribute.KeyValue{}
+//		for _, a := range attrs {
+//			kv = append(kv, attribute.String(a.Key, a.Value))
+//		}
+//		ctx, span := o.tracer.Start(ctx, name, trace.WithAttributes(kv...))
+//		return ctx, otelSpan{span}
+//	}
+//
+//	type otelSpan struct{ trace.Span }
+//
+//	func (s otelSpan) End(err error) {
+//		if err != nil {
+//			s.RecordError(err)
+//			s.SetStatus(codes.Error, err.Error())
+//		}
+//		s.Span.End()
+//	}
+type Instrumentation interface {
+	Start(ctx context.Context, name string, attrs []Attribute) (context.Context, Span)
+}
+
+type instrumentation struct {
+	Instrumentation
+	cdpCalls bool
+}
+
+// Instrument sets the instrumentation for the high-level actions, such as [Page.Navigate], [Page.Element],
+// [Element.Click], and [Page.Eval]. The span names are prefixed with "rod.", such as "rod.Navigate".
+// If cdpCalls is true, each cdp call will also start a span with the method name, such as "cdp.Page.navigate".
+// Set it to nil to disable.
+func (b *Browser) Instrument(i Instrumentation, cdpCalls bool) *Browser {
+	if i == nil {
+		b.instrument = nil
+	} else {
+		b.instrument = &instrumentation{i, cdpCalls}
+	}
+	return b
+}
+
+func (b *Browser) startCDPSpan(ctx context.Context, sessionID, method string) (context.Context, func(error)) {
+	if b.instrument == nil || !b.instrument.cdpCalls {
+		return ctx, func(error) {}
+	}
+
+	attrs := []Attribute{{AttrCDPMethod, method}}
+	if sessionID != "" {
+		attrs = append(attrs, Attribute{AttrSessionID, sessionID})
+	}
+
+	ctx, span := b.instrument.Start(ctx, "cdp."+method, attrs)
+	return ctx, span.End
+}
+
+// startSpan returns a clone of the page with the context of the span
+func (p *Page) startSpan(name string, attrs ...Attribute) (*Page, func(*error)) {
+	if p.browser.instrument == nil {
+		return p, func(*error) {}
+	}
+
+	attrs = append(attrs, Attribute{AttrTargetID, string(p.TargetID)})
+	ctx, span := p.browser.instrument.Start(p.ctx, "rod."+name, attrs)
+	return p.Context(ctx), func(err *error) { span.End(*err) }
+}
+
+// querySpan runs the query within a span, the returned element keeps the context of the page
+func (p *Page) querySpan(name, selector string, query func(*Page) (*Element, error)) (*Element, error) {
+	if p.browser.instrument == nil {
+		return query(p)
+	}
+
+	sp, end := p.startSpan(name, Attribute{AttrSelector, selector})
+	el, err := query(sp)
+	end(&err)
+
+	if el != nil {
+		el.page = el.page.Context(p.ctx)
+		el.ctx = p.ctx
+	}
+	return el, err
+}
+
+// startSpan returns a clone of the element with the context of the span
+func (el *Element) startSpan(name string, attrs ...Attribute) (*Element, func(*error)) {
+	if el.page.browser.instrument == nil {
+		return el, func(*error) {}
+	}
+
+	attrs = append(attrs, Attribute{AttrElement, el.String()}, Attribute{AttrTargetID, string(el.page.TargetID)})
+	ctx, span := el.page.browser.instrument.Start(el.ctx, "rod."+name, attrs)
+	return el.Context(ctx), func(err *error) { span.End(*err) }
+}
@@ -0,0 +1,97 @@
+package rod_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/Fromsko/rodPro"
+	"github.com/Fromsko/rodPro/lib/proto"
+)
+
+type spanKey struct{}
+
+type testSpan struct {
+	name   string
+	parent string
+	attrs  map[string]string
+	err    error
+	ended  bool
+}
+
+type testInstrumentation struct {
+	lock  sync.Mutex
+	spans []*testSpan
+}
+
+func (t *testInstrumentation) Start(ctx context.Context, name string, attrs []rod.Attribute) (context.Context, rod.Span) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	s := &testSpan{name: name, attrs: map[string]string{}}
+	if parent, ok := ctx.Value(spanKey{}).(*testSpan); ok {
+		s.parent = parent.name
+	}
+	for _, a := range attrs {
+		s.attrs[a.Key] = a.Value
+	}
+	t.spans = append(t.spans, s)
+
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+func (t *testInstrumentation) find(name string) *testSpan {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	for _, s := range t.spans {
+		if s.name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+func (s *testSpan) End(err error) {
+	s.err = err
+	s.ended = true
+}
+
+func TestInstrument(t *testing.T) {
+	g := setup(t)
+
+	ins := &testInstrumentation{}
+	g.browser.Instrument(ins, true)
+	defer g.browser.Instrument(nil, false)
+
+	u := g.srcFile("fixtures/click.html")
+	p := g.page.MustNavigate(u)
+
+	nav := ins.find("rod.Navigate")
+	g.Eq(nav.attrs[rod.AttrURL], u)
+	g.Eq(nav.attrs[rod.AttrTargetID], string(p.TargetID))
+	g.True(nav.ended)
+	g.Nil(nav.err)
+
+	cdpNav := ins.find("cdp.Page.navigate")
+	g.Eq(cdpNav.parent, "rod.Navigate")
+	g.Eq(cdpNav.attrs[rod.AttrCDPMethod], "Page.navigate")
+
+	el := p.MustElement("button")
+	g.Eq(ins.find("rod.Element").attrs[rod.AttrSelector], "button")
+	g.Eq(ins.find("cdp.Runtime.callFunctionOn").parent, "rod.Element")
+
+	el.MustClick()
+	g.Eq(ins.find("rod.Click").attrs[rod.AttrElement], el.String())
+
+	p.MustEval(`() => 1`)
+	g.True(ins.find("rod.Eval").ended)
+
+	// the element keeps the context of the page, so the later actions are not nested in the query
+	g.Nil(el.GetContext().Value(spanKey{}))
+
+	g.mc.stubErr(1, proto.RuntimeCallFunctionOn{})
+	_, err := p.ElementX("//button")
+	g.Err(err)
+	g.Eq(ins.find("rod.ElementX").err, err)
+}
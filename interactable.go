@@ -0,0 +1,152 @@
+// This file serves for diagnosing why an element isn't interactable.
+
+package rod
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Fromsko/rodPro/lib/proto"
+)
+
+// InteractableReason tells why an element isn't interactable, check [Element.Diagnose]
+type InteractableReason string
+
+const (
+	// InteractableHidden means the element is detached, display none, or visibility hidden
+	InteractableHidden InteractableReason = "hidden"
+
+	// InteractableZeroSize means the element has no width or height
+	InteractableZeroSize InteractableReason = "zero size"
+
+	// InteractableNoPointerEvents means the pointer-events of the element is none
+	InteractableNoPointerEvents InteractableReason = "pointer-events none"
+
+	// InteractableDisabled means the element is a disabled form control
+	InteractableDisabled InteractableReason = "disabled"
+
+	// InteractableOutsideViewport means the element can't be scrolled into the viewport
+	InteractableOutsideViewport InteractableReason = "outside viewport"
+
+	// InteractableCovered means the element is covered by another element, such as a modal
+	InteractableCovered InteractableReason = "covered"
+)
+
+// InteractableDiagnosis of [Element.Diagnose]
+type InteractableDiagnosis struct {
+	Reason InteractableReason
+
+	// CoveredBy is the element on top of it when the Reason is [InteractableCovered]
+	CoveredBy *Element
+
+	// Box of the element relative to the viewport
+	Box *proto.DOMRect
+}
+
+func (d *InteractableDiagnosis) String() string {
+	if d.Reason == InteractableCovered && d.CoveredBy != nil {
+		return fmt.Sprintf("covered by %s", d.CoveredBy)
+	}
+	return string(d.Reason)
+}
+
+// Diagnose tells what blocks the element from the cursor, such as when [Element.WaitInteractable] times out.
+// It returns nil if the element is interactable and enabled. Unlike [Element.Interactable], it doesn't
+// scroll the element into view.
+func (el *Element) Diagnose() (*InteractableDiagnosis, error) {
+	res, err := el.Eval(`() => {
+		if (!this.isConnected) return { hidden: true }
+		const s = getComputedStyle(this)
+		const r = this.getBoundingClientRect()
+		return {
+			hidden: s.display === 'none' || s.visibility === 'hidden' || s.visibility === 'collapse',
+			pointerEvents: s.pointerEvents,
+			disabled: this.matches(':disabled'),
+			x: r.x, y: r.y, width: r.width, height: r.height,
+			outside: r.right <= 0 || r.bottom <= 0 || r.left >= innerWidth || r.top >= innerHeight,
+		}
+	}`)
+	if err != nil {
+		return nil, err
+	}
+	v := res.Value
+
+	d := &InteractableDiagnosis{Box: &proto.DOMRect{
+		X:      v.Get("x").Num(),
+		Y:      v.Get("y").Num(),
+		Width:  v.Get("width").Num(),
+		Height: v.Get("height").Num(),
+	}}
+
+	switch {
+	case v.Get("hidden").Bool():
+		d.Reason = InteractableHidden
+	case d.Box.Width == 0 || d.Box.Height == 0:
+		d.Reason = InteractableZeroSize
+	case v.Get("pointerEvents").Str() == "none":
+		d.Reason = InteractableNoPointerEvents
+	case v.Get("disabled").Bool():
+		d.Reason = InteractableDisabled
+	case v.Get("outside").Bool():
+		d.Reason = InteractableOutsideViewport
+	}
+	if d.Reason != "" {
+		return d, nil
+	}
+
+	_, err = el.Interactable()
+	var covered *ErrCovered
+	switch {
+	case errors.As(err, &covered):
+		d.Reason, d.CoveredBy = InteractableCovered, covered.Element
+	case errors.Is(err, &ErrInvisibleShape{}):
+		d.Reason = InteractableOutsideViewport
+	case err != nil:
+		return nil, err
+	default:
+		return nil, nil
+	}
+	return d, nil
+}
+
+// blocked wraps the timeout error of the waiting for the element to be interactable
+// with the diagnosis of what blocks it.
+func (el *Element) blocked(err error) error {
+	if !errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+
+	// the ctx of the element has expired, so use a new one to diagnose
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	d, dErr := el.Context(ctx).Diagnose()
+	if dErr != nil || d == nil {
+		return err
+	}
+	return &ErrBlocked{Element: el, Diagnosis: d, Err: err}
+}
+
+// ForceClick dispatches the pointer and mouse events and the click event directly to the element via js,
+// it skips all the checks of [Element.Click], so it works even if the element is covered or outside the viewport.
+// Use it only when you accept the risk, the events are not trusted by the browser, and a real user can't do the same.
+func (el *Element) ForceClick() error {
+	defer el.tryTrace(TraceTypeInput, "force click")()
+
+	_, err := el.Evaluate(Eval(`() => {
+		const r = this.getBoundingClientRect()
+		const opts = {
+			bubbles: true, cancelable: true, composed: true, view: window, button: 0,
+			clientX: r.x + r.width / 2, clientY: r.y + r.height / 2,
+		}
+		this.dispatchEvent(new PointerEvent('pointerdown', { ...opts, buttons: 1, pointerType: 'mouse' }))
+		this.dispatchEvent(new MouseEvent('mousedown', { ...opts, buttons: 1, detail: 1 }))
+		if (this.focus) this.focus()
+		this.dispatchEvent(new PointerEvent('pointerup', { ...opts, pointerType: 'mouse' }))
+		this.dispatchEvent(new MouseEvent('mouseup', { ...opts, detail: 1 }))
+		this.dispatchEvent(new MouseEvent('click', { ...opts, detail: 1 }))
+	}`).ByUser())
+	return err
+}
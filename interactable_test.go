@@ -0,0 +1,65 @@
+package rod_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Fromsko/rodPro"
+	"github.com/Fromsko/rodPro/lib/proto"
+)
+
+func TestElementDiagnose(t *testing.T) {
+	g := setup(t)
+
+	p := g.page.MustNavigate(g.srcFile("fixtures/interactable.html"))
+
+	g.Nil(p.MustElement("#transparent").MustDiagnose())
+
+	g.Eq(p.MustElement("#invisible").MustDiagnose().Reason, rod.InteractableHidden)
+	g.Eq(p.MustElement("#hidden").MustDiagnose().Reason, rod.InteractableHidden)
+	g.Eq(p.MustElement("#no-shape").MustDiagnose().Reason, rod.InteractableZeroSize)
+	g.Eq(p.MustElement("#no-pointer-events").MustDiagnose().Reason, rod.InteractableNoPointerEvents)
+	g.Eq(p.MustElement("#disabled").MustDiagnose().Reason, rod.InteractableDisabled)
+	g.Eq(p.MustElement("#outside").MustDiagnose().String(), "outside viewport")
+
+	d := p.MustElement("#covered").MustDiagnose()
+	g.Eq(d.Reason, rod.InteractableCovered)
+	g.Eq(*d.CoveredBy.MustAttribute("id"), "cover")
+	g.Eq(d.String(), "covered by <div#cover>")
+	g.Gt(d.Box.Width, 0.0)
+
+	el := p.MustElement("#covered")
+	el.MustRemove()
+	g.Eq(el.MustDiagnose().Reason, rod.InteractableHidden)
+
+	g.mc.stubErr(1, proto.RuntimeCallFunctionOn{})
+	g.Err(p.MustElement("#transparent").Diagnose())
+}
+
+func TestElementBlocked(t *testing.T) {
+	g := setup(t)
+
+	p := g.page.MustNavigate(g.srcFile("fixtures/interactable.html"))
+
+	el := p.MustElement("#covered")
+	err := el.Timeout(300*time.Millisecond).Click(proto.InputMouseButtonLeft, 1)
+	g.Is(err, &rod.ErrBlocked{})
+	g.Is(err, &rod.ErrNotInteractable{})
+	g.Is(err, &rod.ErrTimeout{})
+
+	var e *rod.ErrBlocked
+	g.True(errors.As(err, &e))
+	g.Eq(e.Diagnosis.Reason, rod.InteractableCovered)
+	g.Has(err.Error(), "is covered by <div#cover>")
+
+	el.MustForceClick()
+	g.Eq(*el.MustAttribute("clicked"), "ok")
+
+	err = p.MustElement("#disabled").Timeout(300*time.Millisecond).Click(proto.InputMouseButtonLeft, 1)
+	g.True(errors.As(err, &e))
+	g.Eq(e.Diagnosis.Reason, rod.InteractableDisabled)
+
+	g.mc.stubErr(1, proto.RuntimeCallFunctionOn{})
+	g.Err(el.ForceClick())
+}
@@ -123,6 +123,20 @@ const MonitorPage = `<html>
       .rate {
         flex: 1;
       }
+      label,
+      a {
+        color: #c3c3c3;
+        font-size: 0.8em;
+        align-self: center;
+        margin: 0 5px;
+        white-space: nowrap;
+      }
+      .screen {
+        outline: none;
+      }
+      .screen.interactive {
+        cursor: crosshair;
+      }
     </style>
   </head>
   <body>
@@ -140,11 +154,15 @@ const MonitorPage = `<html>
         value="0.5"
         min="0"
         step="0.1"
-        title="refresh rate (second)"
+        title="refresh rate (second) of the screenshots when the live stream is unavailable"
       />
+      <label title="forward the clicks, scrolls, and keystrokes on the screen to the page">
+        <input type="checkbox" class="interactive" /> interactive
+      </label>
+      <a class="devtools" target="_blank" title="open the DevTools of the page">DevTools</a>
     </div>
     <pre class="error"></pre>
-    <img class="screen" />
+    <img class="screen" tabindex="0" />
   </body>
   <script>
     const id = location.pathname.split('/').slice(-1)[0]
@@ -156,16 +174,33 @@ const MonitorPage = `<html>
 
     document.title = ` + "`" + `Rod Monitor - ${id}` + "`" + `
 
-    async function update() {
+    const elInteractive = document.querySelector('.interactive')
+    const elDevTools = document.querySelector('.devtools')
+
+    elDevTools.href = ` + "`" + `/devtools/${id}` + "`" + `
+
+    let streaming = true
+
+    function showErr(err) {
+      if (err) {
+        elErr.style.display = 'block'
+        elErr.textContent = err + ''
+      } else {
+        elErr.attributeStyleMap.delete('display')
+      }
+    }
+
+    async function updateInfo() {
       const res = await fetch(` + "`" + `/api/page/${id}` + "`" + `)
       const info = await res.json()
       elTitle.value = info.title
       elUrl.value = info.url
+    }
 
+    async function updateScreenshot() {
       await new Promise((resolve, reject) => {
         const now = new Date()
         elImg.src = ` + "`" + `/screenshot/${id}?t=${now.getTime()}` + "`" + `
-        elImg.style.maxWidth = innerWidth + 'px'
         elImg.onload = resolve
         elImg.onerror = () => reject(new Error('error loading screenshots'))
       })
@@ -173,16 +208,94 @@ const MonitorPage = `<html>
 
     async function mainLoop() {
       try {
-        await update()
-        elErr.attributeStyleMap.delete('display')
+        await updateInfo()
+        if (!streaming) await updateScreenshot()
+        showErr()
       } catch (err) {
-        elErr.style.display = 'block'
-        elErr.textContent = err + ''
+        showErr(err)
       }
 
       setTimeout(mainLoop, parseFloat(elRate.value) * 1000)
     }
 
+    function stream() {
+      elImg.style.maxWidth = innerWidth + 'px'
+      elImg.onerror = () => {
+        // fallback to the screenshots
+        streaming = false
+      }
+      elImg.src = ` + "`" + `/stream/${id}` + "`" + `
+    }
+
+    async function send(input) {
+      if (!elInteractive.checked) return
+      try {
+        const res = await fetch(` + "`" + `/api/input/${id}` + "`" + `, {
+          method: 'POST',
+          headers: { 'Content-Type': 'application/json' },
+          body: JSON.stringify(input)
+        })
+        if (!res.ok) throw new Error(await res.text())
+        if (!streaming) await updateScreenshot()
+      } catch (err) {
+        showErr(err)
+      }
+    }
+
+    function position(e) {
+      const rect = elImg.getBoundingClientRect()
+      return {
+        x: (e.clientX - rect.left) / rect.width,
+        y: (e.clientY - rect.top) / rect.height
+      }
+    }
+
+    // the keys that can't be used as the last key of a chord as they are
+    const chordKeys = {
+      ' ': 'Space',
+      '}': 'Shift+]'
+    }
+
+    function keyText(e) {
+      const mods = []
+      if (e.ctrlKey) mods.push('Ctrl')
+      if (e.altKey) mods.push('Alt')
+      if (e.metaKey) mods.push('Meta')
+
+      if (mods.length === 0 && e.key.length === 1) {
+        return e.key === '{' ? '{{' : e.key
+      }
+      if (e.shiftKey && e.key.length > 1) mods.push('Shift')
+
+      const key = e.key.length === 1 ? chordKeys[e.key] || e.key : e.key
+      return ` + "`" + `{${[...mods, key].join('+')}}` + "`" + `
+    }
+
+    elInteractive.onchange = () => {
+      elImg.classList.toggle('interactive', elInteractive.checked)
+      if (elInteractive.checked) elImg.focus()
+    }
+
+    elImg.ondragstart = (e) => e.preventDefault()
+
+    elImg.onclick = (e) => {
+      elImg.focus()
+      send({ type: 'click', ...position(e) })
+    }
+
+    elImg.onwheel = (e) => {
+      if (!elInteractive.checked) return
+      e.preventDefault()
+      send({ type: 'scroll', ...position(e), deltaX: e.deltaX, deltaY: e.deltaY })
+    }
+
+    elImg.onkeydown = (e) => {
+      if (!elInteractive.checked || ['Control', 'Alt', 'Meta', 'Shift'].includes(e.key)) return
+      e.preventDefault()
+      send({ type: 'type', text: keyText(e) })
+    }
+
+    stream()
     mainLoop()
   </script>
 </html>
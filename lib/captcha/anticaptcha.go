@@ -0,0 +1,136 @@
+package captcha
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// AntiCaptcha adapter of https://anti-captcha.com
+type AntiCaptcha struct {
+	Key string
+
+	// BaseURL of the API, the default is "https://api.anti-captcha.com"
+	BaseURL string
+
+	// PollInterval of the result, the default is 5s
+	PollInterval time.Duration
+
+	// Client to send the requests, the default is [http.DefaultClient]
+	Client *http.Client
+}
+
+// ErrAntiCaptcha is the error returned by the anti-captcha API
+type ErrAntiCaptcha struct {
+	Code        string `json:"errorCode"`
+	Description string `json:"errorDescription"`
+}
+
+func (e *ErrAntiCaptcha) Error() string {
+	return "anti-captcha: " + e.Code + ": " + e.Description
+}
+
+// Is interface
+func (e *ErrAntiCaptcha) Is(err error) bool { _, ok := err.(*ErrAntiCaptcha); return ok }
+
+type antiCaptchaRes struct {
+	ErrAntiCaptcha
+	ErrorID  int    `json:"errorId"`
+	TaskID   int64  `json:"taskId"`
+	Status   string `json:"status"`
+	Solution struct {
+		GRecaptchaResponse string `json:"gRecaptchaResponse"`
+		Token              string `json:"token"`
+	} `json:"solution"`
+}
+
+// Solve interface
+func (c *AntiCaptcha) Solve(ctx context.Context, t *Task) (string, error) {
+	task := map[string]interface{}{
+		"websiteURL": t.PageURL,
+		"websiteKey": t.SiteKey,
+	}
+
+	switch t.Kind {
+	case RecaptchaV2:
+		task["type"] = "RecaptchaV2TaskProxyless"
+		if t.Enterprise {
+			task["type"] = "RecaptchaV2EnterpriseTaskProxyless"
+		}
+		task["isInvisible"] = t.Invisible
+	case RecaptchaV3:
+		task["type"] = "RecaptchaV3TaskProxyless"
+		task["pageAction"] = t.Action
+		task["minScore"] = 0.7
+		task["isEnterprise"] = t.Enterprise
+	case HCaptcha:
+		task["type"] = "HCaptchaTaskProxyless"
+	case Turnstile:
+		task["type"] = "TurnstileTaskProxyless"
+		if t.Action != "" {
+			task["action"] = t.Action
+		}
+		if t.Data != "" {
+			task["cData"] = t.Data
+		}
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnsupported, t.Kind)
+	}
+
+	res := &antiCaptchaRes{}
+	err := c.call(ctx, "/createTask", map[string]interface{}{"clientKey": c.Key, "task": task}, res)
+	if err != nil {
+		return "", err
+	}
+	id := res.TaskID
+
+	interval := c.PollInterval
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+
+	var token string
+	err = poll(ctx, interval, func() (bool, error) {
+		res := &antiCaptchaRes{}
+		err := c.call(ctx, "/getTaskResult", map[string]interface{}{"clientKey": c.Key, "taskId": id}, res)
+		if err != nil || res.Status != "ready" {
+			return false, err
+		}
+		token = res.Solution.GRecaptchaResponse
+		if token == "" {
+			token = res.Solution.Token
+		}
+		return true, nil
+	})
+	return token, err
+}
+
+func (c *AntiCaptcha) call(ctx context.Context, path string, body interface{}, res *antiCaptchaRes) error {
+	base := c.BaseURL
+	if base == "" {
+		base = "https://api.anti-captcha.com"
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, base+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	err = doJSON(c.Client, req, res)
+	if err != nil {
+		return err
+	}
+	if res.ErrorID != 0 {
+		e := res.ErrAntiCaptcha
+		return &e
+	}
+	return nil
+}
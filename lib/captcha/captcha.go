@@ -0,0 +1,114 @@
+// Package captcha defines the interface to solve the captcha widgets, such as reCAPTCHA, hCaptcha, and Turnstile,
+// with the adapters of the solving services and the manual solving. Use [rod.Page.SolveCaptcha] to locate the
+// widget of a page, solve it, and submit the token.
+package captcha
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Kind of the captcha
+type Kind string
+
+const (
+	// RecaptchaV2 is the checkbox or invisible reCAPTCHA v2
+	RecaptchaV2 Kind = "recaptcha-v2"
+
+	// RecaptchaV3 is the score based reCAPTCHA v3
+	RecaptchaV3 Kind = "recaptcha-v3"
+
+	// HCaptcha widget
+	HCaptcha Kind = "hcaptcha"
+
+	// Turnstile widget of Cloudflare
+	Turnstile Kind = "turnstile"
+)
+
+// Task to solve
+type Task struct {
+	Kind    Kind
+	SiteKey string
+
+	// PageURL where the widget is
+	PageURL string
+
+	// Action of the reCAPTCHA v3 or Turnstile
+	Action string
+
+	// Data is the cdata of the Turnstile
+	Data string
+
+	// Invisible is true for the invisible reCAPTCHA v2
+	Invisible bool
+
+	// Enterprise is true for the reCAPTCHA Enterprise
+	Enterprise bool
+
+	// Wait for the token that is filled by a human on the page, it's used by [Manual]
+	Wait func(ctx context.Context) (string, error) `json:"-"`
+}
+
+// Solver returns the token of the task
+type Solver interface {
+	Solve(ctx context.Context, t *Task) (token string, err error)
+}
+
+// SolverFunc is a function that implements [Solver]
+type SolverFunc func(ctx context.Context, t *Task) (string, error)
+
+// Solve interface
+func (fn SolverFunc) Solve(ctx context.Context, t *Task) (string, error) {
+	return fn(ctx, t)
+}
+
+// ErrUnsupported is returned when the solver doesn't support the kind of the task
+var ErrUnsupported = errors.New("captcha: unsupported kind")
+
+// Manual solver waits for a human to solve the widget on the page, such as via the interactive screencast of
+// [rod.Browser.ServeMonitor].
+type Manual struct {
+	// Timeout to wait for the human, the default is 5 minutes
+	Timeout time.Duration
+
+	// OnWaiting is called before the waiting, such as to notify the human
+	OnWaiting func(t *Task)
+}
+
+// Solve interface
+func (m *Manual) Solve(ctx context.Context, t *Task) (string, error) {
+	if t.Wait == nil {
+		return "", errors.New("captcha: the task is not bound to a page")
+	}
+
+	timeout := m.Timeout
+	if timeout <= 0 {
+		timeout = 5 * time.Minute
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if m.OnWaiting != nil {
+		m.OnWaiting(t)
+	}
+	return t.Wait(ctx)
+}
+
+// poll calls fn every interval until it returns done or an error
+func poll(ctx context.Context, interval time.Duration, fn func() (done bool, err error)) error {
+	for {
+		done, err := fn()
+		if done || err != nil {
+			return err
+		}
+
+		t := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package captcha_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Fromsko/rodPro/lib/captcha"
+	"github.com/ysmood/got"
+)
+
+func TestTwoCaptcha(t *testing.T) {
+	g := got.T(t)
+
+	polls := 0
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		g.E(r.ParseForm())
+		g.Eq(r.Form.Get("key"), "k")
+
+		switch r.URL.Path {
+		case "/in.php":
+			g.Eq(r.Method, http.MethodPost)
+			g.Eq(r.Form.Get("method"), "userrecaptcha")
+			g.Eq(r.Form.Get("googlekey"), "site")
+			g.Eq(r.Form.Get("version"), "v3")
+			g.Eq(r.Form.Get("action"), "login")
+			g.Eq(r.Form.Get("pageurl"), "http://a.com")
+			_, _ = w.Write([]byte(`{"status":1,"request":"42"}`))
+		case "/res.php":
+			g.Eq(r.Form.Get("id"), "42")
+			polls++
+			if polls < 2 {
+				_, _ = w.Write([]byte(`{"status":0,"request":"CAPCHA_NOT_READY"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"status":1,"request":"token"}`))
+		}
+	}))
+	defer s.Close()
+
+	c := &captcha.TwoCaptcha{Key: "k", BaseURL: s.URL, PollInterval: time.Millisecond}
+	token, err := c.Solve(context.Background(), &captcha.Task{
+		Kind: captcha.RecaptchaV3, SiteKey: "site", PageURL: "http://a.com", Action: "login",
+	})
+	g.E(err)
+	g.Eq(token, "token")
+	g.Eq(polls, 2)
+
+	_, err = c.Solve(context.Background(), &captcha.Task{Kind: "unknown"})
+	g.Is(err, captcha.ErrUnsupported)
+}
+
+func TestTwoCaptchaErr(t *testing.T) {
+	g := got.T(t)
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/in.php" {
+			_, _ = w.Write([]byte(`{"status":1,"request":"1"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"status":0,"request":"ERROR_CAPTCHA_UNSOLVABLE"}`))
+	}))
+	defer s.Close()
+
+	c := &captcha.TwoCaptcha{BaseURL: s.URL, PollInterval: time.Millisecond}
+	_, err := c.Solve(context.Background(), &captcha.Task{Kind: captcha.HCaptcha})
+	g.Is(err, &captcha.ErrTwoCaptcha{})
+	g.Eq(err.Error(), "2captcha: ERROR_CAPTCHA_UNSOLVABLE")
+
+	s.Close()
+	_, err = c.Solve(context.Background(), &captcha.Task{Kind: captcha.Turnstile})
+	g.Err(err)
+}
+
+func TestAntiCaptcha(t *testing.T) {
+	g := got.T(t)
+
+	polls := 0
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := map[string]interface{}{}
+		g.E(json.NewDecoder(r.Body).Decode(&body))
+		g.Eq(body["clientKey"], "k")
+
+		switch r.URL.Path {
+		case "/createTask":
+			task := body["task"].(map[string]interface{})
+			g.Eq(task["type"], "TurnstileTaskProxyless")
+			g.Eq(task["websiteKey"], "site")
+			g.Eq(task["cData"], "data")
+			_, _ = w.Write([]byte(`{"errorId":0,"taskId":7}`))
+		case "/getTaskResult":
+			g.Eq(body["taskId"], 7.0)
+			polls++
+			if polls < 2 {
+				_, _ = w.Write([]byte(`{"errorId":0,"status":"processing"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"errorId":0,"status":"ready","solution":{"token":"token"}}`))
+		}
+	}))
+	defer s.Close()
+
+	c := &captcha.AntiCaptcha{Key: "k", BaseURL: s.URL, PollInterval: time.Millisecond}
+	token, err := c.Solve(context.Background(), &captcha.Task{Kind: captcha.Turnstile, SiteKey: "site", Data: "data"})
+	g.E(err)
+	g.Eq(token, "token")
+
+	_, err = c.Solve(context.Background(), &captcha.Task{Kind: "unknown"})
+	g.Is(err, captcha.ErrUnsupported)
+}
+
+func TestAntiCaptchaErr(t *testing.T) {
+	g := got.T(t)
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"errorId":1,"errorCode":"ERROR_KEY_DOES_NOT_EXIST","errorDescription":"bad key"}`))
+	}))
+	defer s.Close()
+
+	c := &captcha.AntiCaptcha{BaseURL: s.URL}
+	_, err := c.Solve(context.Background(), &captcha.Task{Kind: captcha.RecaptchaV2})
+	g.Is(err, &captcha.ErrAntiCaptcha{})
+	g.Eq(err.Error(), "anti-captcha: ERROR_KEY_DOES_NOT_EXIST: bad key")
+
+	s.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+	_, err = c.Solve(context.Background(), &captcha.Task{Kind: captcha.RecaptchaV2})
+	g.Eq(err.Error(), "captcha: unexpected status 502 Bad Gateway")
+}
+
+func TestManual(t *testing.T) {
+	g := got.T(t)
+
+	waiting := false
+	m := &captcha.Manual{OnWaiting: func(*captcha.Task) { waiting = true }}
+
+	_, err := m.Solve(context.Background(), &captcha.Task{})
+	g.Err(err)
+
+	token, err := m.Solve(context.Background(), &captcha.Task{Wait: func(context.Context) (string, error) {
+		return "token", nil
+	}})
+	g.E(err)
+	g.Eq(token, "token")
+	g.True(waiting)
+
+	m.Timeout = time.Millisecond
+	_, err = m.Solve(context.Background(), &captcha.Task{Wait: func(ctx context.Context) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	}})
+	g.True(errors.Is(err, context.DeadlineExceeded))
+
+	var fn captcha.Solver = captcha.SolverFunc(func(context.Context, *captcha.Task) (string, error) { return "ok", nil })
+	token, _ = fn.Solve(context.Background(), nil)
+	g.Eq(token, "ok")
+}
@@ -0,0 +1,156 @@
+package captcha
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// TwoCaptcha adapter of https://2captcha.com
+type TwoCaptcha struct {
+	Key string
+
+	// BaseURL of the API, the default is "https://2captcha.com"
+	BaseURL string
+
+	// PollInterval of the result, the default is 5s
+	PollInterval time.Duration
+
+	// Client to send the requests, the default is [http.DefaultClient]
+	Client *http.Client
+}
+
+// ErrTwoCaptcha is the error returned by the 2captcha API
+type ErrTwoCaptcha struct {
+	Code string
+}
+
+func (e *ErrTwoCaptcha) Error() string {
+	return "2captcha: " + e.Code
+}
+
+// Is interface
+func (e *ErrTwoCaptcha) Is(err error) bool { _, ok := err.(*ErrTwoCaptcha); return ok }
+
+type twoCaptchaRes struct {
+	Status  int    `json:"status"`
+	Request string `json:"request"`
+}
+
+// Solve interface
+func (c *TwoCaptcha) Solve(ctx context.Context, t *Task) (string, error) {
+	q := url.Values{
+		"key":     {c.Key},
+		"json":    {"1"},
+		"sitekey": {t.SiteKey},
+		"pageurl": {t.PageURL},
+	}
+
+	switch t.Kind {
+	case RecaptchaV2:
+		q.Set("method", "userrecaptcha")
+		q.Set("googlekey", t.SiteKey)
+		if t.Invisible {
+			q.Set("invisible", "1")
+		}
+	case RecaptchaV3:
+		q.Set("method", "userrecaptcha")
+		q.Set("googlekey", t.SiteKey)
+		q.Set("version", "v3")
+		q.Set("action", t.Action)
+	case HCaptcha:
+		q.Set("method", "hcaptcha")
+	case Turnstile:
+		q.Set("method", "turnstile")
+		if t.Action != "" {
+			q.Set("action", t.Action)
+		}
+		if t.Data != "" {
+			q.Set("data", t.Data)
+		}
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnsupported, t.Kind)
+	}
+	if t.Enterprise {
+		q.Set("enterprise", "1")
+	}
+
+	res := &twoCaptchaRes{}
+	err := c.call(ctx, http.MethodPost, "/in.php", q, res)
+	if err != nil {
+		return "", err
+	}
+	if res.Status != 1 {
+		return "", &ErrTwoCaptcha{res.Request}
+	}
+	id := res.Request
+
+	interval := c.PollInterval
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+
+	var token string
+	err = poll(ctx, interval, func() (bool, error) {
+		res := &twoCaptchaRes{}
+		err := c.call(ctx, http.MethodGet, "/res.php", url.Values{
+			"key": {c.Key}, "action": {"get"}, "id": {id}, "json": {"1"},
+		}, res)
+		if err != nil {
+			return false, err
+		}
+		if res.Status == 1 {
+			token = res.Request
+			return true, nil
+		}
+		if res.Request == "CAPCHA_NOT_READY" {
+			return false, nil
+		}
+		return false, &ErrTwoCaptcha{res.Request}
+	})
+	return token, err
+}
+
+func (c *TwoCaptcha) call(ctx context.Context, method, path string, q url.Values, res interface{}) error {
+	base := c.BaseURL
+	if base == "" {
+		base = "https://2captcha.com"
+	}
+
+	var req *http.Request
+	var err error
+	if method == http.MethodPost {
+		req, err = http.NewRequestWithContext(ctx, method, base+path, strings.NewReader(q.Encode()))
+		if err == nil {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+	} else {
+		req, err = http.NewRequestWithContext(ctx, method, base+path+"?"+q.Encode(), nil)
+	}
+	if err != nil {
+		return err
+	}
+
+	return doJSON(c.Client, req, res)
+}
+
+func doJSON(client *http.Client, req *http.Request, res interface{}) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	r, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = r.Body.Close() }()
+
+	if r.StatusCode != http.StatusOK {
+		return fmt.Errorf("captcha: unexpected status %s", r.Status)
+	}
+	return json.NewDecoder(r.Body).Decode(res)
+}
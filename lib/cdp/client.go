@@ -4,8 +4,14 @@ package cdp
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/Fromsko/rodPro/lib/defaults"
 	"github.com/Fromsko/rodPro/lib/utils"
@@ -42,19 +48,42 @@ type WebSocketable interface {
 	Read() ([]byte, error)
 }
 
+// OwnedReader is an optional interface of [WebSocketable]. Implement it if the bytes returned by Read are never
+// reused by the implementation, so that the client can reference them rather than copy them, such as the large
+// results of the screenshots or the pdf chunks. Otherwise the client copies the results.
+// Both [WebSocket] and [Pipe] implement it.
+type OwnedReader interface {
+	// OwnedRead is only a marker, it's never called
+	OwnedRead()
+}
+
 // Client is a devtools protocol connection instance.
 type Client struct {
 	count uint64
 
-	ws WebSocketable
+	ws     WebSocketable
+	wsLock sync.Mutex
+	ready  chan struct{} // closed when the connection is ready to use
+	err    error         // the error that makes the connection unusable forever
 
 	pending sync.Map    // pending requests
 	event   chan *Event // events from browser
 
+	eventPolicy   EventDropPolicy
+	eventOverflow func(*Event)
+	eventFilters  sync.Map // session id -> map[string]struct{}
+
 	logger utils.Logger
+
+	reconnect        *reconnect
+	reconnectTimeout time.Duration // see [Client.ReconnectTimeout]
+
+	callTimeout time.Duration
+	callRetries int
 }
 
-// New creates a cdp connection, all messages from Client.Event must be received or they will block the client.
+// New creates a cdp connection, all messages from Client.Event must be received or they will block the client,
+// use [Client.EventBuffer] to change the behavior.
 func New() *Client {
 	return &Client{
 		event:  make(chan *Event),
@@ -69,11 +98,103 @@ func (cdp *Client) Logger(l utils.Logger) *Client {
 	return cdp
 }
 
+// CallTimeout sets the default timeout of each call, it's independent of the context passed to [Client.Call],
+// so a single stuck call can't freeze a job that has no deadline. When a read-only or idempotent call, such as
+// "DOM.getDocument" or "Page.enable", times out or fails because of a transient websocket error, it will be retried
+// up to retries times before [ErrCallTimeout] or the error is returned. The other calls are never retried,
+// because the browser may have executed them when only the response is lost.
+// The calls that await a promise, such as "Runtime.evaluate" with awaitPromise, can legitimately take long,
+// so they are exempt from the timeout. If timeout is zero, the calls only end with their contexts.
+func (cdp *Client) CallTimeout(timeout time.Duration, retries int) *Client {
+	cdp.callTimeout = timeout
+	cdp.callRetries = retries
+	return cdp
+}
+
+// EventDropPolicy decides what to do with the events when the buffer of [Client.Event] is full
+type EventDropPolicy int
+
+const (
+	// EventBlock blocks the reader of the websocket until the buffer has room, it's the default.
+	// No event will be lost, but a slow consumer will also delay the responses of the calls.
+	EventBlock EventDropPolicy = iota
+
+	// EventDropNewest drops the incoming event
+	EventDropNewest
+
+	// EventDropOldest drops the oldest event in the buffer to make room for the incoming event
+	EventDropOldest
+)
+
+// EventBuffer sets the size of the buffer of [Client.Event] and the policy when the buffer is full,
+// so that the high-frequency events, such as the Network events of heavy pages or the screencast frames,
+// won't cause unbounded memory growth or block the reader of the websocket silently.
+// The onOverflow is called with each dropped event, it's optional and it shouldn't block.
+// The [EventReconnected] event is never dropped. It should be called before [Client.Start].
+// The drop policies don't know the meaning of the events, the Target, Inspector and lifecycle events may be dropped too,
+// rod relies on them to track the pages and the navigations, so a client used by rod will break with the drop policies.
+// The rod.Browser drains [Client.Event] into its own subscribers, use rod's Browser.EventBuffer to bound them instead,
+// it never drops the events that rod relies on.
+func (cdp *Client) EventBuffer(size int, policy EventDropPolicy, onOverflow func(*Event)) *Client {
+	cdp.event = make(chan *Event, size)
+	cdp.eventPolicy = policy
+	cdp.eventOverflow = onOverflow
+	return cdp
+}
+
+// FilterEvents sets the event methods of the session that the consumer of [Client.Event] cares about,
+// the other events of the session will be dropped before their params are decoded, such as a page that only needs
+// the navigation events won't pay the decode cost of every Network event. A method can be a domain wildcard,
+// such as "Page.*". Call it without methods to remove the filter of the session. It's safe to call it anytime.
+// The devtools protocol has no generic way to filter the events on the browser side, to stop the browser from
+// sending the events at all, disable the domains you don't need, such as "Network.disable".
+// The filter applies to every consumer of [Client.Event], when the client is used by rod, use the FilterEvents of
+// rod's Page instead, it keeps the events that rod and the other subscribers of the page depend on.
+func (cdp *Client) FilterEvents(sessionID string, methods ...string) *Client {
+	if len(methods) == 0 {
+		cdp.eventFilters.Delete(sessionID)
+		return cdp
+	}
+
+	filter := map[string]struct{}{}
+	for _, m := range methods {
+		filter[m] = struct{}{}
+	}
+	cdp.eventFilters.Store(sessionID, filter)
+	return cdp
+}
+
+// wanted returns true if the event passes the filter of its session
+func (cdp *Client) wanted(sessionID, method string) bool {
+	if cdp.reconnect != nil {
+		// the reconnect mode needs them to track the sessions
+		if method == "Target.attachedToTarget" || method == "Target.detachedFromTarget" {
+			return true
+		}
+		sessionID = cdp.reconnect.caller(sessionID)
+	}
+
+	val, has := cdp.eventFilters.Load(sessionID)
+	if !has {
+		return true
+	}
+	filter := val.(map[string]struct{})
+
+	if _, has := filter[method]; has {
+		return true
+	}
+	domain, _, _ := strings.Cut(method, ".")
+	_, has = filter[domain+".*"]
+	return has
+}
+
 // Start to browser
 func (cdp *Client) Start(ws WebSocketable) *Client {
 	cdp.ws = ws
+	cdp.ready = make(chan struct{})
+	close(cdp.ready)
 
-	go cdp.consumeMessages()
+	go cdp.consumeMessages(ws, nil)
 
 	return cdp
 }
@@ -85,6 +206,97 @@ type result struct {
 
 // Call a method and wait for its response
 func (cdp *Client) Call(ctx context.Context, sessionID, method string, params interface{}) ([]byte, error) {
+	for attempt := 0; ; attempt++ {
+		ws, err := cdp.waitReady(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		res, err := cdp.callWithTimeout(ctx, ws, sessionID, method, params)
+		if err != nil && attempt < cdp.callRetries && ctx.Err() == nil && isTransient(err) && isRetryable(method) {
+			continue
+		}
+
+		if err == nil && cdp.reconnect != nil {
+			cdp.reconnect.track(sessionID, method, params, res)
+		}
+		return res, err
+	}
+}
+
+func (cdp *Client) callWithTimeout(ctx context.Context, ws WebSocketable, sessionID, method string, params interface{}) ([]byte, error) {
+	if cdp.callTimeout <= 0 || awaitsPromise(method, params) {
+		return cdp.call(ctx, ws, sessionID, method, params)
+	}
+
+	callCtx, cancel := context.WithTimeout(ctx, cdp.callTimeout)
+	defer cancel()
+
+	res, err := cdp.call(callCtx, ws, sessionID, method, params)
+	if err != nil && ctx.Err() == nil && errors.Is(callCtx.Err(), context.DeadlineExceeded) {
+		return nil, fmt.Errorf("%w: %s exceeded %v", ErrCallTimeout, method, cdp.callTimeout)
+	}
+	return res, err
+}
+
+// isTransient returns true if the call may succeed when it's retried
+func isTransient(err error) bool {
+	if errors.Is(err, ErrCallTimeout) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// retryableMethods are the methods that don't match the prefixes in [isRetryable] but are safe to send twice
+var retryableMethods = map[string]bool{
+	"DOM.resolveNode":                true,
+	"DOM.requestNode":                true,
+	"DOM.scrollIntoViewIfNeeded":     true,
+	"Page.setLifecycleEventsEnabled": true,
+	"Target.setDiscoverTargets":      true,
+	"Target.setAutoAttach":           true,
+	"Runtime.releaseObject":          true,
+}
+
+// isRetryable returns true if the method is read-only or idempotent, so that it's safe to retry
+func isRetryable(method string) bool {
+	if retryableMethods[method] || strings.HasSuffix(method, ".enable") || strings.HasSuffix(method, ".disable") {
+		return true
+	}
+
+	_, name, _ := strings.Cut(method, ".")
+	for _, prefix := range []string{"get", "describe", "query"} {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// awaitsPromise returns true if the call waits for a promise to settle, it may take as long as the page wants
+func awaitsPromise(method string, params interface{}) bool {
+	if method == "Runtime.awaitPromise" {
+		return true
+	}
+
+	switch v := params.(type) {
+	case map[string]interface{}:
+		await, _ := v["awaitPromise"].(bool)
+		return await
+	case nil:
+		return false
+	}
+
+	val := reflect.Indirect(reflect.ValueOf(params))
+	if val.Kind() != reflect.Struct {
+		return false
+	}
+	field := val.FieldByName("AwaitPromise")
+	return field.IsValid() && field.Kind() == reflect.Bool && field.Bool()
+}
+
+func (cdp *Client) call(ctx context.Context, ws WebSocketable, sessionID, method string, params interface{}) ([]byte, error) {
 	req := &Request{
 		ID:        int(atomic.AddUint64(&cdp.count, 1)),
 		SessionID: sessionID,
@@ -94,6 +306,10 @@ func (cdp *Client) Call(ctx context.Context, sessionID, method string, params in
 
 	cdp.logger.Println(req)
 
+	if cdp.reconnect != nil {
+		req.SessionID = cdp.reconnect.current(sessionID)
+	}
+
 	data, err := json.Marshal(req)
 	utils.E(err)
 
@@ -109,7 +325,7 @@ func (cdp *Client) Call(ctx context.Context, sessionID, method string, params in
 	})
 	defer cdp.pending.Delete(req.ID)
 
-	err = cdp.ws.Send(data)
+	err = ws.Send(data)
 	if err != nil {
 		return nil, err
 	}
@@ -122,43 +338,97 @@ func (cdp *Client) Call(ctx context.Context, sessionID, method string, params in
 	}
 }
 
+// waitReady waits until the connection is usable, it only blocks when the client is reconnecting.
+func (cdp *Client) waitReady(ctx context.Context) (WebSocketable, error) {
+	cdp.wsLock.Lock()
+	ready := cdp.ready
+	cdp.wsLock.Unlock()
+
+	// check the ready first, so that the select won't randomly pick the done ctx when the connection is ready
+	select {
+	case <-ready:
+	default:
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ready:
+		}
+	}
+
+	cdp.wsLock.Lock()
+	defer cdp.wsLock.Unlock()
+	return cdp.ws, cdp.err
+}
+
 // Event returns a channel that will emit browser devtools protocol events. Must be consumed or will block producer.
 func (cdp *Client) Event() <-chan *Event {
 	return cdp.event
 }
 
 // Consume messages coming from the browser via the websocket.
-func (cdp *Client) consumeMessages() {
-	defer close(cdp.event)
+// The prev is the done signal of the previous connection, it's only used for reconnection.
+func (cdp *Client) consumeMessages(ws WebSocketable, prev <-chan struct{}) {
+	err := cdp.readMessages(ws)
+
+	if prev != nil {
+		<-prev
+	}
+
+	if cdp.reconnect != nil && cdp.reconnectWS(err) {
+		return
+	}
+
+	cdp.pending.Range(func(_, val interface{}) bool {
+		val.(func(result))(result{err: err})
+		return true
+	})
+
+	close(cdp.event)
+}
 
+// readMessages until the connection is broken
+func (cdp *Client) readMessages(ws WebSocketable) error {
 	for {
-		data, err := cdp.ws.Read()
+		data, err := ws.Read()
 		if err != nil {
-			cdp.pending.Range(func(_, val interface{}) bool {
-				val.(func(result))(result{err: err})
-				return true
-			})
-			return
+			return err
 		}
 
 		var id struct {
-			ID int `json:"id"`
+			ID        int    `json:"id"`
+			SessionID string `json:"sessionId"`
+			Method    string `json:"method"`
 		}
 		err = json.Unmarshal(data, &id)
 		utils.E(err)
 
 		if id.ID == 0 {
+			if !cdp.wanted(id.SessionID, id.Method) {
+				continue
+			}
+
 			var evt Event
 			err := json.Unmarshal(data, &evt)
 			utils.E(err)
+			if cdp.reconnect != nil {
+				cdp.reconnect.trackEvent(&evt)
+			}
 			cdp.logger.Println(&evt)
-			cdp.event <- &evt
+			cdp.emit(&evt)
 			continue
 		}
 
-		var res Response
-		err = json.Unmarshal(data, &res)
+		var msg struct {
+			ID     int     `json:"id"`
+			Result rawJSON `json:"result,omitempty"`
+			Error  *Error  `json:"error,omitempty"`
+		}
+		err = json.Unmarshal(data, &msg)
 		utils.E(err)
+		if _, owned := ws.(OwnedReader); !owned {
+			msg.Result = append(rawJSON{}, msg.Result...)
+		}
+		res := Response{ID: msg.ID, Result: json.RawMessage(msg.Result), Error: msg.Error}
 
 		cdp.logger.Println(&res)
 
@@ -173,3 +443,54 @@ func (cdp *Client) consumeMessages() {
 		}
 	}
 }
+
+// emit the event to the consumer of [Client.Event] with the [EventDropPolicy]
+func (cdp *Client) emit(e *Event) {
+	switch cdp.eventPolicy {
+	case EventDropNewest:
+		select {
+		case cdp.event <- e:
+		default:
+			cdp.overflow(e)
+		}
+
+	case EventDropOldest:
+		for {
+			select {
+			case cdp.event <- e:
+				return
+			default:
+			}
+
+			// the consumer may take the oldest one at the same time, so retry until the event is sent
+			select {
+			case old := <-cdp.event:
+				cdp.overflow(old)
+			default:
+				if cap(cdp.event) == 0 {
+					cdp.overflow(e)
+					return
+				}
+			}
+		}
+
+	default:
+		cdp.event <- e
+	}
+}
+
+func (cdp *Client) overflow(e *Event) {
+	if cdp.eventOverflow != nil {
+		cdp.eventOverflow(e)
+	}
+}
+
+// rawJSON is like the [json.RawMessage], but it references the data of the message rather than copying it,
+// so that the large results, such as the screenshots or the pdf chunks, won't be copied, see [OwnedReader].
+type rawJSON []byte
+
+// UnmarshalJSON interface
+func (r *rawJSON) UnmarshalJSON(b []byte) error {
+	*r = b
+	return nil
+}
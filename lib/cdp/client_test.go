@@ -1,11 +1,13 @@
 package cdp_test
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"path/filepath"
+	"sync"
 	"testing"
 	"time"
 
@@ -241,6 +243,33 @@ func TestSlowSend(t *testing.T) {
 	g.E(err)
 }
 
+func TestReusedReadBuffer(t *testing.T) {
+	g := setup(t)
+
+	buf := []byte{}
+	sent := make(chan struct{})
+
+	ws := &MockWebSocket{
+		send: func([]byte) error {
+			sent <- struct{}{}
+			return nil
+		},
+		read: func() ([]byte, error) {
+			<-sent
+			// the transport reuses the same buffer for every message
+			buf = append(buf[:0], `{"id":1,"result":{"a":1}}`...)
+			return buf, nil
+		},
+	}
+
+	c := cdp.New().Start(ws)
+	res, err := c.Call(g.Context(), "", "method", nil)
+	g.E(err)
+
+	copy(buf, bytes.Repeat([]byte("x"), len(buf)))
+	g.Eq(string(res), `{"a":1}`)
+}
+
 func TestCancelCallLeak(t *testing.T) {
 	g := setup(t)
 
@@ -327,6 +356,124 @@ func TestConcurrentCall(t *testing.T) {
 	}
 }
 
+func TestCallTimeout(t *testing.T) {
+	g := setup(t)
+
+	// the browser gets stuck on the first n calls
+	stuck := func(n int) *MockWebSocket {
+		req := make(chan cdp.Request, 10)
+		t.Cleanup(func() { close(req) })
+
+		return &MockWebSocket{
+			send: func(data []byte) error {
+				var r cdp.Request
+				utils.E(json.Unmarshal(data, &r))
+				req <- r
+				return nil
+			},
+			read: func() ([]byte, error) {
+				for r := range req {
+					if r.ID <= n {
+						continue
+					}
+					return json.Marshal(cdp.Response{ID: r.ID, Result: json.RawMessage("1")})
+				}
+				return nil, io.EOF
+			},
+		}
+	}
+
+	c := cdp.New().CallTimeout(100*time.Millisecond, 2).Start(stuck(2))
+	res, err := c.Call(g.Context(), "", "DOM.getDocument", nil)
+	g.E(err)
+	g.Eq(string(res), "1")
+
+	c = cdp.New().CallTimeout(100*time.Millisecond, 1).Start(stuck(10))
+	_, err = c.Call(g.Context(), "", "DOM.getDocument", nil)
+	g.Is(err, cdp.ErrCallTimeout)
+
+	// the calls that are not idempotent are never retried
+	c = cdp.New().CallTimeout(100*time.Millisecond, 2).Start(stuck(1))
+	_, err = c.Call(g.Context(), "", "Input.dispatchMouseEvent", nil)
+	g.Is(err, cdp.ErrCallTimeout)
+
+	// the calls that await a promise are exempt from the timeout
+	slow := &MockWebSocket{
+		send: func([]byte) error { return nil },
+		read: func() ([]byte, error) {
+			time.Sleep(300 * time.Millisecond)
+			return json.Marshal(cdp.Response{ID: 1, Result: json.RawMessage("1")})
+		},
+	}
+	c = cdp.New().CallTimeout(100*time.Millisecond, 0).Start(slow)
+	res, err = c.Call(g.Context(), "", "Runtime.evaluate", map[string]interface{}{"awaitPromise": true})
+	g.E(err)
+	g.Eq(string(res), "1")
+}
+
+func TestEventBuffer(t *testing.T) {
+	g := setup(t)
+
+	run := func(policy cdp.EventDropPolicy) ([]string, []string) {
+		conn := newFakeConn("1")
+		overflow := make(chan string, 10)
+		c := cdp.New().EventBuffer(2, policy, func(e *cdp.Event) {
+			overflow <- string(e.Params)
+		}).Start(conn.ws())
+
+		for _, p := range []string{"1", "2", "3", "4"} {
+			conn.events <- cdp.Event{Method: "Network.dataReceived", Params: json.RawMessage(p)}
+		}
+		dropped := []string{<-overflow, <-overflow}
+		close(conn.closed)
+
+		list := []string{}
+		for e := range c.Event() {
+			list = append(list, string(e.Params))
+		}
+		return list, dropped
+	}
+
+	list, dropped := run(cdp.EventDropNewest)
+	g.Eq(list, []string{"1", "2"})
+	g.Eq(dropped, []string{"3", "4"})
+
+	list, dropped = run(cdp.EventDropOldest)
+	g.Eq(list, []string{"3", "4"})
+	g.Eq(dropped, []string{"1", "2"})
+}
+
+func TestFilterEvents(t *testing.T) {
+	g := setup(t)
+
+	conn := newFakeConn("1")
+	c := cdp.New().FilterEvents("s1", "Page.*", "Network.requestWillBeSent").Start(conn.ws())
+
+	for _, e := range []cdp.Event{
+		{SessionID: "s1", Method: "Network.dataReceived"},
+		{SessionID: "s1", Method: "Network.requestWillBeSent"},
+		{SessionID: "s1", Method: "Page.frameNavigated"},
+		{SessionID: "s2", Method: "Network.dataReceived"},
+	} {
+		conn.events <- e
+	}
+
+	list := []string{}
+	for e := range c.Event() {
+		list = append(list, e.SessionID+" "+e.Method)
+		if len(list) == 3 {
+			break
+		}
+	}
+	g.Eq(list, []string{"s1 Network.requestWillBeSent", "s1 Page.frameNavigated", "s2 Network.dataReceived"})
+
+	c.FilterEvents("s1")
+	conn.events <- cdp.Event{SessionID: "s1", Method: "Network.dataReceived"}
+	g.Eq((<-c.Event()).Method, "Network.dataReceived")
+
+	close(conn.closed)
+}
+
 func TestMassBrowserClose(t *testing.T) {
 	t.Skip()
 
@@ -343,6 +490,156 @@ func TestMassBrowserClose(t *testing.T) {
 	}
 }
 
+func TestReconnect(t *testing.T) {
+	g := setup(t)
+
+	conn1 := newFakeConn("1")
+	conn2 := newFakeConn("2")
+
+	c := cdp.New().Reconnect(func(ctx context.Context) (cdp.WebSocketable, error) {
+		return conn2.ws(), nil
+	}, 0).Start(conn1.ws())
+
+	events := make(chan *cdp.Event, 10)
+	go func() {
+		for e := range c.Event() {
+			events <- e
+		}
+	}()
+
+	res, err := c.Call(g.Context(), "", "Target.attachToTarget", map[string]interface{}{"targetId": "t"})
+	g.E(err)
+	g.Eq(gson.New(res).Get("sessionId").Str(), "s1")
+
+	_, err = c.Call(g.Context(), "s1", "Page.enable", nil)
+	g.E(err)
+
+	close(conn1.closed)
+
+	e := <-events
+	g.Eq(e.Method, cdp.EventReconnected)
+	g.Eq(gson.New([]byte(e.Params)).Get("sessions").JSON("", ""), `["s1"]`)
+
+	g.Eq(conn2.requests(), []string{"Target.attachToTarget ", "Page.enable s2"})
+
+	_, err = c.Call(g.Context(), "s1", "Runtime.evaluate", nil)
+	g.E(err)
+	g.Eq(conn2.requests()[2], "Runtime.evaluate s2")
+
+	conn2.events <- cdp.Event{SessionID: "s2", Method: "Page.loadEventFired"}
+	e = <-events
+	g.Eq(e.SessionID, "s1")
+}
+
+func TestReconnectGiveUp(t *testing.T) {
+	g := setup(t)
+
+	conn := newFakeConn("1")
+
+	c := cdp.New().ReconnectTimeout(time.Minute).Reconnect(func(ctx context.Context) (cdp.WebSocketable, error) {
+		deadline, ok := ctx.Deadline()
+		g.True(ok)
+		g.Lte(time.Until(deadline), time.Minute)
+		return nil, io.ErrClosedPipe
+	}, 2).Start(conn.ws())
+
+	close(conn.closed)
+
+	for range c.Event() {
+		utils.Noop()
+	}
+
+	_, err := c.Call(g.Context(), "", "Page.enable", nil)
+	g.Eq(err, io.EOF)
+}
+
+func TestPipe(t *testing.T) {
+	g := setup(t)
+
+	browserIn, w := io.Pipe()
+	r, browserOut := io.Pipe()
+
+	go func() {
+		p := cdp.NewPipe(browserOut, browserIn)
+		for {
+			data, err := p.Read()
+			if err != nil {
+				_ = browserOut.Close()
+				return
+			}
+			var req cdp.Request
+			utils.E(json.Unmarshal(data, &req))
+			res, _ := json.Marshal(cdp.Response{ID: req.ID, Result: json.RawMessage(`"` + req.Method + `"`)})
+			utils.E(p.Send(res))
+		}
+	}()
+
+	pipe := cdp.NewPipe(w, r)
+	c := cdp.New().Start(pipe)
+
+	res, err := c.Call(g.Context(), "", "Browser.getVersion", nil)
+	g.E(err)
+	g.Eq(string(res), `"Browser.getVersion"`)
+
+	g.E(pipe.Close())
+	for range c.Event() {
+		utils.Noop()
+	}
+}
+
+type fakeConn struct {
+	id     string
+	req    chan cdp.Request
+	events chan cdp.Event
+	closed chan struct{}
+
+	lock sync.Mutex
+	list []string
+}
+
+func newFakeConn(id string) *fakeConn {
+	return &fakeConn{
+		id:     id,
+		req:    make(chan cdp.Request, 10),
+		events: make(chan cdp.Event, 10),
+		closed: make(chan struct{}),
+	}
+}
+
+func (c *fakeConn) requests() []string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return append([]string{}, c.list...)
+}
+
+func (c *fakeConn) ws() *MockWebSocket {
+	return &MockWebSocket{
+		send: func(data []byte) error {
+			var req cdp.Request
+			utils.E(json.Unmarshal(data, &req))
+			c.lock.Lock()
+			c.list = append(c.list, req.Method+" "+req.SessionID)
+			c.lock.Unlock()
+			c.req <- req
+			return nil
+		},
+		read: func() ([]byte, error) {
+			select {
+			case <-c.closed:
+				return nil, io.EOF
+			case e := <-c.events:
+				return json.Marshal(e)
+			case req := <-c.req:
+				result := "{}"
+				if req.Method == "Target.attachToTarget" {
+					result = `{"sessionId":"s` + c.id + `"}`
+				}
+				return json.Marshal(cdp.Response{ID: req.ID, Result: json.RawMessage(result)})
+			}
+		},
+	}
+}
+
 type MockWebSocket struct {
 	send func(data []byte) error
 	read func() ([]byte, error)
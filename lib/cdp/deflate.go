@@ -0,0 +1,65 @@
+package cdp
+
+import (
+	"bytes"
+	"compress/flate"
+	"io"
+	"sync"
+)
+
+// Each message is compressed independently, so no context needs to be kept between messages.
+// Ref: https://tools.ietf.org/html/rfc7692
+const deflateExtension = "permessage-deflate; client_no_context_takeover; server_no_context_takeover"
+
+// the bit of the frame header to mark the message is compressed
+const rsv1 = 0b0100_0000
+
+var deflateTail = []byte{0x00, 0x00, 0xff, 0xff}
+
+// the flate writer allocates several hundred KB of state, reuse it across messages
+var flateWriters = sync.Pool{
+	New: func() interface{} {
+		w, _ := flate.NewWriter(nil, flate.BestSpeed)
+		return w
+	},
+}
+
+func compress(msg []byte) ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+	w := flateWriters.Get().(*flate.Writer)
+	defer flateWriters.Put(w)
+	w.Reset(buf)
+
+	_, err := w.Write(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	err = w.Flush()
+	if err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimSuffix(buf.Bytes(), deflateTail), nil
+}
+
+// decompress the msg, if the max is greater than zero, the size of the result will be limited.
+func decompress(msg []byte, max int) ([]byte, error) {
+	r := flate.NewReader(io.MultiReader(bytes.NewReader(msg), bytes.NewReader(deflateTail)))
+	defer func() { _ = r.Close() }()
+
+	var src io.Reader = r
+	if max > 0 {
+		src = io.LimitReader(r, int64(max)+1)
+	}
+
+	data, err := io.ReadAll(src)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+
+	if max > 0 && len(data) > max {
+		return nil, ErrPayloadTooLarge
+	}
+	return data, nil
+}
@@ -1,6 +1,7 @@
 package cdp
 
 import (
+	"errors"
 	"fmt"
 )
 
@@ -63,3 +64,6 @@ var ErrNotAttachedToActivePage = &Error{
 	Code:    -32000,
 	Message: "Not attached to an active page",
 }
+
+// ErrCallTimeout is returned when a call exceeds the timeout of [Client.CallTimeout]
+var ErrCallTimeout = errors.New("cdp call timeout")
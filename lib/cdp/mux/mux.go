@@ -0,0 +1,545 @@
+// Package mux implements a server that multiplexes many client connections to one browser,
+// so a central browser farm can be shared safely by multiple services.
+// Each connection talks CDP over TCP, the messages are terminated by a null byte, the same as [cdp.Pipe].
+// Use [Dial] to connect to the server.
+package mux
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Fromsko/rodPro/lib/cdp"
+	"github.com/Fromsko/rodPro/lib/utils"
+)
+
+// ErrMethodNotAllowed is the error code returned to the client when the method is not in [Server.Allow].
+const ErrMethodNotAllowed = -32601
+
+// ErrSessionNotOwned is the error code returned to the client when it uses a session of another client.
+const ErrSessionNotOwned = -32001
+
+// ErrTargetNotOwned is the error code returned to the client when it uses a target of another client.
+const ErrTargetNotOwned = -32002
+
+// Server multiplexes the connections to one browser.
+// Requests from each client are forwarded with new ids, the sessions attached by a client and the targets created or
+// attached by it can only be used by it, including via the sessionId and targetId params of the Target domain.
+// The events of a session and the Target events of a target are only routed to their owner, the Target events of
+// the targets that no client owns are dropped, the other browser level events are broadcast to all clients.
+// Each client has its own bounded queue, a client that can't keep up is disconnected rather than blocking others.
+type Server struct {
+	// Allow is the list of methods that clients can call, such as "Page.navigate" or "Runtime.*".
+	// Empty means all methods are allowed.
+	Allow []string
+
+	// Rate is the max requests per second for each client. Zero means no limit.
+	Rate float64
+
+	// Burst is the max requests a client can make at once when [Server.Rate] is set.
+	Burst int
+
+	// QueueSize is the max messages buffered for each client, the client will be disconnected when it's exceeded.
+	// Default is 1024.
+	QueueSize int
+
+	// Logger for the connection errors
+	Logger utils.Logger
+
+	browser *cdp.Client
+
+	lock       sync.Mutex
+	clients    map[*conn]struct{}
+	owners     map[string]*conn // session id -> owner
+	targets    map[string]*conn // target id -> owner
+	attaching  map[string]*conn // target id -> the client that is attaching it
+	autoAttach map[*conn]struct{}
+}
+
+// New server that forwards the requests to the browser.
+// The events of the browser client will be consumed by the server.
+func New(browser *cdp.Client) *Server {
+	s := &Server{
+		Logger:     utils.LoggerQuiet,
+		browser:    browser,
+		clients:    map[*conn]struct{}{},
+		owners:     map[string]*conn{},
+		targets:    map[string]*conn{},
+		attaching:  map[string]*conn{},
+		autoAttach: map[*conn]struct{}{},
+	}
+
+	go s.routeEvents()
+
+	return s
+}
+
+// Serve accepts the connections from the listener until it's closed.
+func (s *Server) Serve(l net.Listener) error {
+	for {
+		c, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go s.ServeConn(c)
+	}
+}
+
+// ServeConn serves a client connection until it's closed.
+func (s *Server) ServeConn(rwc io.ReadWriteCloser) {
+	size := s.QueueSize
+	if size <= 0 {
+		size = 1024
+	}
+
+	c := &conn{
+		rwc:     rwc,
+		pipe:    cdp.NewPipe(rwc, rwc),
+		limiter: newLimiter(s.Rate, s.Burst),
+		queue:   make(chan []byte, size),
+		closed:  make(chan struct{}),
+		logger:  s.Logger,
+	}
+	go c.write()
+
+	s.lock.Lock()
+	s.clients[c] = struct{}{}
+	s.lock.Unlock()
+
+	defer s.release(c)
+	defer c.close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	for {
+		data, err := c.pipe.Read()
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				s.Logger.Println("[mux]", err)
+			}
+			_ = rwc.Close()
+			return
+		}
+
+		var req struct {
+			ID        int             `json:"id"`
+			SessionID string          `json:"sessionId,omitempty"`
+			Method    string          `json:"method"`
+			Params    json.RawMessage `json:"params,omitempty"`
+		}
+		err = json.Unmarshal(data, &req)
+		if err != nil {
+			s.Logger.Println("[mux]", err)
+			continue
+		}
+
+		c.limiter.wait()
+
+		if !s.allowed(req.Method) {
+			c.reply(req.ID, nil, &cdp.Error{Code: ErrMethodNotAllowed, Message: "method not allowed: " + req.Method})
+			continue
+		}
+
+		if req.SessionID != "" && s.owner(req.SessionID) != c {
+			c.reply(req.ID, nil, &cdp.Error{Code: ErrSessionNotOwned, Message: "session not owned: " + req.SessionID})
+			continue
+		}
+
+		if cdpErr := s.checkTarget(c, req.Method, req.Params); cdpErr != nil {
+			c.reply(req.ID, nil, cdpErr)
+			continue
+		}
+
+		var params interface{}
+		if len(req.Params) > 0 {
+			params = req.Params
+		}
+
+		s.track(c, req.SessionID, req.Method, req.Params)
+
+		go func() {
+			res, err := s.browser.Call(ctx, req.SessionID, req.Method, params)
+			if err == nil {
+				s.claim(c, req.Method, req.Params, res)
+			}
+			c.reply(req.ID, res, err)
+		}()
+	}
+}
+
+func (s *Server) allowed(method string) bool {
+	if len(s.Allow) == 0 {
+		return true
+	}
+	for _, p := range s.Allow {
+		if p == method || (strings.HasSuffix(p, "*") && strings.HasPrefix(method, strings.TrimSuffix(p, "*"))) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) owner(sessionID string) *conn {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.owners[sessionID]
+}
+
+// targetParams are the params of the Target domain that refer to a session or target
+type targetParams struct {
+	SessionID string `json:"sessionId"`
+	TargetID  string `json:"targetId"`
+}
+
+// checkTarget returns an error if the Target method refers to a session or target of another client.
+// The targets that no client owns, such as the ones opened before the server starts, can be used by any client.
+func (s *Server) checkTarget(c *conn, method string, params json.RawMessage) *cdp.Error {
+	if !strings.HasPrefix(method, "Target.") {
+		return nil
+	}
+
+	var p targetParams
+	_ = json.Unmarshal(params, &p)
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if p.SessionID != "" && s.owners[p.SessionID] != c {
+		return &cdp.Error{Code: ErrSessionNotOwned, Message: "session not owned: " + p.SessionID}
+	}
+	if owner, has := s.targets[p.TargetID]; has && owner != c {
+		return &cdp.Error{Code: ErrTargetNotOwned, Message: "target not owned: " + p.TargetID}
+	}
+	return nil
+}
+
+// claim the session and target of the succeeded request for the client
+func (s *Server) claim(c *conn, method string, params json.RawMessage, res []byte) {
+	var req, result targetParams
+	_ = json.Unmarshal(params, &req)
+	_ = json.Unmarshal(res, &result)
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	switch method {
+	case "Target.attachToTarget":
+		if result.SessionID != "" {
+			s.owners[result.SessionID] = c
+		}
+		s.claimTarget(req.TargetID, c)
+	case "Target.createTarget":
+		s.claimTarget(result.TargetID, c)
+	}
+}
+
+// claimTarget for the client if no one owns it, the caller must hold the lock
+func (s *Server) claimTarget(targetID string, c *conn) {
+	if targetID == "" || c == nil {
+		return
+	}
+	if _, has := s.targets[targetID]; !has {
+		s.targets[targetID] = c
+	}
+}
+
+// targetEventOwner returns the client that should receive the browser level Target event, nil if no one owns the
+// target. The target opened by a page, such as a popup, belongs to the owner of the opener.
+func (s *Server) targetEventOwner(e *cdp.Event) *conn {
+	var params struct {
+		TargetID   string `json:"targetId"`
+		TargetInfo struct {
+			TargetID string `json:"targetId"`
+			OpenerID string `json:"openerId"`
+		} `json:"targetInfo"`
+	}
+	_ = json.Unmarshal(e.Params, &params)
+
+	id := params.TargetID
+	if id == "" {
+		id = params.TargetInfo.TargetID
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	owner := s.targets[id]
+	if owner == nil && params.TargetInfo.OpenerID != "" {
+		owner = s.targets[params.TargetInfo.OpenerID]
+		s.claimTarget(id, owner)
+	}
+
+	if e.Method == "Target.targetDestroyed" {
+		delete(s.targets, id)
+	}
+
+	return owner
+}
+
+// track the requests that decide where the Target.attachedToTarget events should go
+func (s *Server) track(c *conn, sessionID, method string, params json.RawMessage) {
+	var p struct {
+		TargetID   string `json:"targetId"`
+		AutoAttach bool   `json:"autoAttach"`
+	}
+	_ = json.Unmarshal(params, &p)
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	switch method {
+	case "Target.attachToTarget":
+		s.attaching[p.TargetID] = c
+	case "Target.setAutoAttach":
+		if sessionID != "" {
+			return
+		}
+		if p.AutoAttach {
+			s.autoAttach[c] = struct{}{}
+		} else {
+			delete(s.autoAttach, c)
+		}
+	}
+}
+
+// attached returns the clients that should receive the Target.attachedToTarget event
+func (s *Server) attached(e *cdp.Event) []*conn {
+	var params struct {
+		SessionID  string `json:"sessionId"`
+		TargetInfo struct {
+			TargetID string `json:"targetId"`
+		} `json:"targetInfo"`
+	}
+	_ = json.Unmarshal(e.Params, &params)
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	// the sessions auto attached to a session belong to the owner of the parent session
+	if e.SessionID != "" {
+		owner := s.owners[e.SessionID]
+		if owner == nil {
+			return nil
+		}
+		s.owners[params.SessionID] = owner
+		s.claimTarget(params.TargetInfo.TargetID, owner)
+		return []*conn{owner}
+	}
+
+	if c, has := s.attaching[params.TargetInfo.TargetID]; has {
+		delete(s.attaching, params.TargetInfo.TargetID)
+		s.owners[params.SessionID] = c
+		s.claimTarget(params.TargetInfo.TargetID, c)
+		return []*conn{c}
+	}
+
+	// attached by the browser level auto attach, only the clients that enabled it can see them
+	list := []*conn{}
+	for c := range s.autoAttach {
+		list = append(list, c)
+	}
+	return list
+}
+
+// release the client and detach its sessions
+func (s *Server) release(c *conn) {
+	s.lock.Lock()
+	delete(s.clients, c)
+	delete(s.autoAttach, c)
+	for id, owner := range s.attaching {
+		if owner == c {
+			delete(s.attaching, id)
+		}
+	}
+	for id, owner := range s.targets {
+		if owner == c {
+			delete(s.targets, id)
+		}
+	}
+	list := []string{}
+	for id, owner := range s.owners {
+		if owner == c {
+			list = append(list, id)
+			delete(s.owners, id)
+		}
+	}
+	s.lock.Unlock()
+
+	for _, id := range list {
+		_, _ = s.browser.Call(context.Background(), "", "Target.detachFromTarget", map[string]string{"sessionId": id})
+	}
+}
+
+func (s *Server) routeEvents() {
+	for e := range s.browser.Event() {
+		switch e.Method {
+		case "Target.attachedToTarget":
+			for _, c := range s.attached(e) {
+				c.send(e)
+			}
+			continue
+
+		case "Target.detachedFromTarget":
+			var params struct {
+				SessionID string `json:"sessionId"`
+			}
+			_ = json.Unmarshal(e.Params, &params)
+			if owner := s.owner(params.SessionID); owner != nil {
+				owner.send(e)
+				s.lock.Lock()
+				delete(s.owners, params.SessionID)
+				s.lock.Unlock()
+				continue
+			}
+		}
+
+		if e.SessionID != "" {
+			if owner := s.owner(e.SessionID); owner != nil {
+				owner.send(e)
+			}
+			continue
+		}
+
+		// the target events carry the urls of the targets, they are private to the owner
+		if strings.HasPrefix(e.Method, "Target.") {
+			if owner := s.targetEventOwner(e); owner != nil {
+				owner.send(e)
+			}
+			continue
+		}
+
+		s.lock.Lock()
+		list := []*conn{}
+		for c := range s.clients {
+			list = append(list, c)
+		}
+		s.lock.Unlock()
+
+		for _, c := range list {
+			c.send(e)
+		}
+	}
+}
+
+type conn struct {
+	rwc     io.ReadWriteCloser
+	pipe    *cdp.Pipe
+	limiter *limiter
+	logger  utils.Logger
+
+	queue     chan []byte
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// send queues the message without blocking, the client will be disconnected if its queue is full
+func (c *conn) send(v interface{}) {
+	b, err := json.Marshal(v)
+	utils.E(err)
+
+	select {
+	case <-c.closed:
+	case c.queue <- b:
+	default:
+		c.logger.Println("[mux] disconnect the slow client, its queue is full")
+		c.close()
+	}
+}
+
+// write the queued messages to the client
+func (c *conn) write() {
+	for {
+		select {
+		case <-c.closed:
+			return
+		case b := <-c.queue:
+			if c.pipe.Send(b) != nil {
+				c.close()
+				return
+			}
+		}
+	}
+}
+
+func (c *conn) close() {
+	c.closeOnce.Do(func() {
+		close(c.closed)
+		_ = c.rwc.Close()
+	})
+}
+
+func (c *conn) reply(id int, res []byte, err error) {
+	r := cdp.Response{ID: id, Result: res}
+	if err != nil {
+		var cdpErr *cdp.Error
+		if !errors.As(err, &cdpErr) {
+			cdpErr = &cdp.Error{Code: -32000, Message: err.Error()}
+		}
+		r.Result = nil
+		r.Error = cdpErr
+	}
+	c.send(r)
+}
+
+// limiter is a token bucket
+type limiter struct {
+	lock   sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newLimiter(rate float64, burst int) *limiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &limiter{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// wait until a token is available
+func (l *limiter) wait() {
+	if l.rate <= 0 {
+		return
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+
+	l.tokens--
+	if l.tokens < 0 {
+		d := time.Duration(-l.tokens / l.rate * float64(time.Second))
+		time.Sleep(d)
+	}
+}
+
+// Dial the server and returns a client, use it like:
+//
+//	rod.New().Client(mux.MustDial(ctx, "farm:9222")).MustConnect()
+func Dial(ctx context.Context, addr string) (*cdp.Client, error) {
+	c, err := (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return cdp.New().Start(cdp.NewPipe(c, c)), nil
+}
+
+// MustDial is similar to [Dial].
+func MustDial(ctx context.Context, addr string) *cdp.Client {
+	c, err := Dial(ctx, addr)
+	utils.E(err)
+	return c
+}
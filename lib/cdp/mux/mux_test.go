@@ -0,0 +1,255 @@
+package mux_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/Fromsko/rodPro/lib/cdp"
+	"github.com/Fromsko/rodPro/lib/cdp/mux"
+	"github.com/Fromsko/rodPro/lib/utils"
+	"github.com/ysmood/got"
+	"github.com/ysmood/gson"
+)
+
+var setup = got.Setup(nil)
+
+// fakeBrowser responds each request with its method, Target.attachToTarget returns "s-" + targetId as the session id,
+// Target.createTarget returns the url as the target id.
+func fakeBrowser(g got.G) (*cdp.Client, chan cdp.Event) {
+	browserIn, w := io.Pipe()
+	r, browserOut := io.Pipe()
+	events := make(chan cdp.Event)
+
+	p := cdp.NewPipe(browserOut, browserIn)
+	go func() {
+		for e := range events {
+			b, _ := json.Marshal(e)
+			_ = p.Send(b)
+		}
+	}()
+	go func() {
+		for {
+			data, err := p.Read()
+			if err != nil {
+				return
+			}
+			var req cdp.Request
+			utils.E(json.Unmarshal(data, &req))
+
+			result := gson.New(map[string]string{"method": req.Method, "sessionId": req.SessionID}).JSON("", "")
+			if req.Method == "Target.attachToTarget" {
+				result = `{"sessionId":"s-` + gson.New(req.Params).Get("targetId").Str() + `"}`
+			}
+			if req.Method == "Target.createTarget" {
+				result = `{"targetId":"` + gson.New(req.Params).Get("url").Str() + `"}`
+			}
+
+			res, _ := json.Marshal(cdp.Response{ID: req.ID, Result: json.RawMessage(result)})
+			_ = p.Send(res)
+		}
+	}()
+
+	g.Cleanup(func() {
+		close(events)
+		_ = w.Close()
+		_ = browserOut.Close()
+	})
+
+	return cdp.New().Start(cdp.NewPipe(w, r)), events
+}
+
+func connect(g got.G, s *mux.Server) (*cdp.Client, <-chan *cdp.Event) {
+	server, client := net.Pipe()
+	go s.ServeConn(server)
+	g.Cleanup(func() { _ = client.Close() })
+
+	c := cdp.New().Start(cdp.NewPipe(client, client))
+	events := make(chan *cdp.Event, 10)
+	go func() {
+		for e := range c.Event() {
+			events <- e
+		}
+	}()
+	return c, events
+}
+
+func TestSessionRouting(t *testing.T) {
+	g := setup(t)
+
+	browser, browserEvents := fakeBrowser(g)
+	s := mux.New(browser)
+
+	a, aEvents := connect(g, s)
+	b, bEvents := connect(g, s)
+
+	res, err := a.Call(g.Context(), "", "Target.attachToTarget", map[string]string{"targetId": "a"})
+	g.E(err)
+	g.Eq(gson.New(res).Get("sessionId").Str(), "s-a")
+
+	res, err = a.Call(g.Context(), "s-a", "Page.enable", nil)
+	g.E(err)
+	g.Eq(gson.New(res).Get("sessionId").Str(), "s-a")
+
+	_, err = b.Call(g.Context(), "s-a", "Page.enable", nil)
+	g.Eq(err.(*cdp.Error).Code, mux.ErrSessionNotOwned)
+
+	browserEvents <- cdp.Event{SessionID: "s-a", Method: "Page.loadEventFired"}
+	browserEvents <- cdp.Event{Method: "Browser.downloadProgress"}
+
+	g.Eq((<-aEvents).Method, "Page.loadEventFired")
+	g.Eq((<-aEvents).Method, "Browser.downloadProgress")
+	g.Eq((<-bEvents).Method, "Browser.downloadProgress")
+}
+
+func TestTargetOwnership(t *testing.T) {
+	g := setup(t)
+
+	browser, browserEvents := fakeBrowser(g)
+	s := mux.New(browser)
+
+	a, aEvents := connect(g, s)
+	b, bEvents := connect(g, s)
+
+	_, err := a.Call(g.Context(), "", "Target.createTarget", map[string]string{"url": "a"})
+	g.E(err)
+	_, err = a.Call(g.Context(), "", "Target.attachToTarget", map[string]string{"targetId": "a"})
+	g.E(err)
+
+	notOwned := func(method string, params map[string]string, code int) {
+		g.Helper()
+		_, err := b.Call(g.Context(), "", method, params)
+		g.Eq(err.(*cdp.Error).Code, code)
+	}
+	notOwned("Target.attachToTarget", map[string]string{"targetId": "a"}, mux.ErrTargetNotOwned)
+	notOwned("Target.closeTarget", map[string]string{"targetId": "a"}, mux.ErrTargetNotOwned)
+	notOwned("Target.detachFromTarget", map[string]string{"sessionId": "s-a"}, mux.ErrSessionNotOwned)
+	notOwned("Target.sendMessageToTarget", map[string]string{"sessionId": "s-a"}, mux.ErrSessionNotOwned)
+
+	_, err = a.Call(g.Context(), "", "Target.closeTarget", map[string]string{"targetId": "a"})
+	g.E(err)
+
+	// the targets that no one owns can be claimed by any client
+	_, err = b.Call(g.Context(), "", "Target.attachToTarget", map[string]string{"targetId": "other"})
+	g.E(err)
+
+	// the target events only go to the owner, the popup belongs to the owner of its opener
+	browserEvents <- cdp.Event{Method: "Target.targetInfoChanged", Params: json.RawMessage(
+		`{"targetInfo":{"targetId":"a","url":"secret"}}`)}
+	browserEvents <- cdp.Event{Method: "Target.targetCreated", Params: json.RawMessage(
+		`{"targetInfo":{"targetId":"popup","openerId":"other"}}`)}
+	browserEvents <- cdp.Event{Method: "Target.targetCreated", Params: json.RawMessage(
+		`{"targetInfo":{"targetId":"unknown"}}`)}
+	browserEvents <- cdp.Event{Method: "Browser.downloadProgress"}
+
+	g.Eq((<-aEvents).Method, "Target.targetInfoChanged")
+	g.Eq((<-aEvents).Method, "Browser.downloadProgress")
+	g.Eq((<-bEvents).Method, "Target.targetCreated")
+	g.Eq((<-bEvents).Method, "Browser.downloadProgress")
+
+	notOwned = func(method string, params map[string]string, code int) {
+		g.Helper()
+		_, err := a.Call(g.Context(), "", method, params)
+		g.Eq(err.(*cdp.Error).Code, code)
+	}
+	notOwned("Target.closeTarget", map[string]string{"targetId": "popup"}, mux.ErrTargetNotOwned)
+}
+
+func TestAllow(t *testing.T) {
+	g := setup(t)
+
+	browser, _ := fakeBrowser(g)
+	s := mux.New(browser)
+	s.Allow = []string{"Runtime.*", "Page.navigate"}
+
+	c, _ := connect(g, s)
+
+	_, err := c.Call(g.Context(), "", "Runtime.evaluate", nil)
+	g.E(err)
+	_, err = c.Call(g.Context(), "", "Page.navigate", nil)
+	g.E(err)
+
+	_, err = c.Call(g.Context(), "", "Browser.close", nil)
+	g.Eq(err.(*cdp.Error).Code, mux.ErrMethodNotAllowed)
+}
+
+func TestRateLimit(t *testing.T) {
+	g := setup(t)
+
+	browser, _ := fakeBrowser(g)
+	s := mux.New(browser)
+	s.Rate = 20
+	s.Burst = 1
+
+	c, _ := connect(g, s)
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		_, err := c.Call(g.Context(), "", "Runtime.evaluate", nil)
+		g.E(err)
+	}
+	g.Gt(time.Since(start), 150*time.Millisecond)
+}
+
+func TestDial(t *testing.T) {
+	g := setup(t)
+
+	browser, _ := fakeBrowser(g)
+	s := mux.New(browser)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	g.E(err)
+	g.Cleanup(func() { _ = l.Close() })
+	go func() { _ = s.Serve(l) }()
+
+	c := mux.MustDial(context.Background(), l.Addr().String())
+	res, err := c.Call(g.Context(), "", "Browser.getVersion", nil)
+	g.E(err)
+	g.Eq(gson.New(res).Get("method").Str(), "Browser.getVersion")
+}
+
+func TestAttachedRouting(t *testing.T) {
+	g := setup(t)
+
+	browser, browserEvents := fakeBrowser(g)
+	s := mux.New(browser)
+
+	a, aEvents := connect(g, s)
+	_, bEvents := connect(g, s)
+
+	_, err := a.Call(g.Context(), "", "Target.attachToTarget", map[string]string{"targetId": "a"})
+	g.E(err)
+
+	browserEvents <- cdp.Event{Method: "Target.attachedToTarget", Params: json.RawMessage(
+		`{"sessionId":"s-a","targetInfo":{"targetId":"a"}}`)}
+	browserEvents <- cdp.Event{Method: "Browser.downloadProgress"}
+
+	g.Eq((<-aEvents).Method, "Target.attachedToTarget")
+	g.Eq((<-aEvents).Method, "Browser.downloadProgress")
+	g.Eq((<-bEvents).Method, "Browser.downloadProgress")
+}
+
+func TestSlowClient(t *testing.T) {
+	g := setup(t)
+
+	browser, browserEvents := fakeBrowser(g)
+	s := mux.New(browser)
+	s.QueueSize = 2
+
+	// the slow client never reads
+	server, client := net.Pipe()
+	go s.ServeConn(server)
+	g.Cleanup(func() { _ = client.Close() })
+
+	_, events := connect(g, s)
+
+	for i := 0; i < 5; i++ {
+		browserEvents <- cdp.Event{Method: "Browser.downloadProgress"}
+	}
+	for i := 0; i < 5; i++ {
+		g.Eq((<-events).Method, "Browser.downloadProgress")
+	}
+}
@@ -0,0 +1,55 @@
+package cdp
+
+import (
+	"bufio"
+	"io"
+	"sync"
+)
+
+// Pipe is a transport that talks CDP over a pair of pipes instead of a websocket,
+// such as the stdio pipes (fd 3 and 4) of a browser launched with the "--remote-debugging-pipe" flag.
+// Each message is terminated by a null byte.
+type Pipe struct {
+	lock sync.Mutex
+	w    io.WriteCloser
+	r    *bufio.Reader
+}
+
+var _ WebSocketable = &Pipe{}
+
+var _ OwnedReader = &Pipe{}
+
+// NewPipe creates a transport that writes messages to w and reads messages from r.
+func NewPipe(w io.WriteCloser, r io.Reader) *Pipe {
+	return &Pipe{w: w, r: bufio.NewReader(r)}
+}
+
+// Send a message
+func (p *Pipe) Send(msg []byte) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	_, err := p.w.Write(msg)
+	if err != nil {
+		return err
+	}
+	_, err = p.w.Write([]byte{0})
+	return err
+}
+
+// OwnedRead implements [OwnedReader], each message read is a new buffer
+func (p *Pipe) OwnedRead() {}
+
+// Read a message
+func (p *Pipe) Read() ([]byte, error) {
+	msg, err := p.r.ReadBytes(0)
+	if err != nil {
+		return nil, err
+	}
+	return msg[:len(msg)-1], nil
+}
+
+// Close the writer, the browser will exit once its read end is closed
+func (p *Pipe) Close() error {
+	return p.w.Close()
+}
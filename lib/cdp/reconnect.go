@@ -0,0 +1,364 @@
+package cdp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Fromsko/rodPro/lib/utils"
+)
+
+// EventReconnected is the method name of the event emitted by [Client.Event] after the client is reconnected.
+// Its params is the [Reconnected].
+const EventReconnected = "CDP.reconnected"
+
+// Reconnected is the params of the [EventReconnected] event.
+type Reconnected struct {
+	// Attempts to dial before the connection is recovered
+	Attempts int `json:"attempts"`
+
+	// Sessions that have been re-attached
+	Sessions []string `json:"sessions"`
+
+	// Lost sessions that can't be re-attached, such as the target is closed during the reconnection
+	Lost []string `json:"lost"`
+}
+
+// Connect is a function to create a new connection to the browser.
+type Connect func(ctx context.Context) (WebSocketable, error)
+
+// ConnectURL returns a [Connect] that dials the u with the default websocket lib.
+func ConnectURL(u string, h http.Header) Connect {
+	return func(ctx context.Context) (WebSocketable, error) {
+		ws := &WebSocket{}
+		err := ws.Connect(ctx, u, h)
+		if err != nil {
+			return nil, err
+		}
+		return ws, nil
+	}
+}
+
+// Reconnect enables the reconnect mode. When the websocket drops, the client will use the connect to re-dial the browser,
+// re-attach the sessions, replay the domain enable states, then emit the [EventReconnected] event.
+// The session ids used by the caller are kept the same, they are transparently mapped to the new sessions.
+// The calls made during the reconnection will wait until it's done, the in-flight calls will fail with the error
+// that breaks the connection. If maxAttempts is zero the client will retry forever.
+// It should be called before [Client.Start].
+func (cdp *Client) Reconnect(connect Connect, maxAttempts int) *Client {
+	cdp.reconnect = &reconnect{
+		connect:     connect,
+		maxAttempts: maxAttempts,
+		sessions:    map[string]*trackedSession{"": {}},
+		alias:       map[string]string{},
+		reverse:     map[string]string{},
+	}
+	return cdp
+}
+
+// DefaultReconnectTimeout is the default of [Client.ReconnectTimeout]
+var DefaultReconnectTimeout = 30 * time.Second
+
+// ReconnectTimeout bounds each dial of the reconnection, and the re-attach and replay of the sessions after
+// the dial, so that an unresponsive browser won't hang the calls that are waiting for the reconnection.
+// Default is [DefaultReconnectTimeout].
+func (cdp *Client) ReconnectTimeout(timeout time.Duration) *Client {
+	cdp.reconnectTimeout = timeout
+	return cdp
+}
+
+func (cdp *Client) reconnectCtx() (context.Context, context.CancelFunc) {
+	timeout := cdp.reconnectTimeout
+	if timeout <= 0 {
+		timeout = DefaultReconnectTimeout
+	}
+	return context.WithTimeout(context.Background(), timeout)
+}
+
+type reconnect struct {
+	connect     Connect
+	maxAttempts int
+
+	lock     sync.Mutex
+	sessions map[string]*trackedSession
+	alias    map[string]string // session id of the caller -> session id of the current connection
+	reverse  map[string]string // session id of the current connection -> session id of the caller
+}
+
+type trackedSession struct {
+	targetID string
+	enabled  []trackedCall
+}
+
+type trackedCall struct {
+	method string
+	params interface{}
+}
+
+// current returns the session id of the current connection
+func (r *reconnect) current(sessionID string) string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if id, has := r.alias[sessionID]; has {
+		return id
+	}
+	return sessionID
+}
+
+// caller returns the session id known by the caller for the session id of the current connection
+func (r *reconnect) caller(sessionID string) string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if id, has := r.reverse[sessionID]; has {
+		return id
+	}
+	return sessionID
+}
+
+// isStateful returns true if the method changes the state of the session that should be replayed.
+func isStateful(method string) bool {
+	return strings.HasSuffix(method, ".enable") ||
+		method == "Target.setDiscoverTargets" ||
+		method == "Target.setAutoAttach"
+}
+
+func (r *reconnect) track(sessionID, method string, params interface{}, res []byte) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	switch {
+	case method == "Target.attachToTarget":
+		var req struct {
+			TargetID string `json:"targetId"`
+		}
+		var result struct {
+			SessionID string `json:"sessionId"`
+		}
+		b, _ := json.Marshal(params)
+		_ = json.Unmarshal(b, &req)
+		_ = json.Unmarshal(res, &result)
+		if result.SessionID != "" {
+			r.sessions[result.SessionID] = &trackedSession{targetID: req.TargetID}
+		}
+
+	case method == "Target.detachFromTarget":
+		var req struct {
+			SessionID string `json:"sessionId"`
+		}
+		b, _ := json.Marshal(params)
+		_ = json.Unmarshal(b, &req)
+		r.remove(req.SessionID)
+
+	case isStateful(method):
+		s, has := r.sessions[sessionID]
+		if !has {
+			return
+		}
+		for i, c := range s.enabled {
+			if c.method == method {
+				s.enabled[i].params = params
+				return
+			}
+		}
+		s.enabled = append(s.enabled, trackedCall{method, params})
+
+	case strings.HasSuffix(method, ".disable"):
+		s, has := r.sessions[sessionID]
+		if !has {
+			return
+		}
+		enable := strings.TrimSuffix(method, ".disable") + ".enable"
+		list := []trackedCall{}
+		for _, c := range s.enabled {
+			if c.method != enable {
+				list = append(list, c)
+			}
+		}
+		s.enabled = list
+	}
+}
+
+// trackEvent maps the session id of the event back to the one known by the caller
+// and tracks the sessions that are auto attached.
+func (r *reconnect) trackEvent(e *Event) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if id, has := r.reverse[e.SessionID]; has {
+		e.SessionID = id
+	}
+
+	switch e.Method {
+	case "Target.attachedToTarget":
+		var params struct {
+			SessionID  string `json:"sessionId"`
+			TargetInfo struct {
+				TargetID string `json:"targetId"`
+			} `json:"targetInfo"`
+		}
+		_ = json.Unmarshal(e.Params, &params)
+		if _, has := r.sessions[params.SessionID]; !has {
+			r.sessions[params.SessionID] = &trackedSession{targetID: params.TargetInfo.TargetID}
+		}
+
+	case "Target.detachedFromTarget":
+		var params struct {
+			SessionID string `json:"sessionId"`
+		}
+		_ = json.Unmarshal(e.Params, &params)
+		if id, has := r.reverse[params.SessionID]; has {
+			params.SessionID = id
+		}
+		r.remove(params.SessionID)
+	}
+}
+
+func (r *reconnect) remove(sessionID string) {
+	if sessionID == "" {
+		return
+	}
+	delete(r.sessions, sessionID)
+	if id, has := r.alias[sessionID]; has {
+		delete(r.reverse, id)
+		delete(r.alias, sessionID)
+	}
+}
+
+func (r *reconnect) snapshot() (ids []string, sessions []trackedSession) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	// the browser session goes first
+	ids = append(ids, "")
+	sessions = append(sessions, *r.sessions[""])
+	for id, s := range r.sessions {
+		if id != "" {
+			ids = append(ids, id)
+			sessions = append(sessions, *s)
+		}
+	}
+	return
+}
+
+func (r *reconnect) setAlias(sessionID, current string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if old, has := r.alias[sessionID]; has {
+		delete(r.reverse, old)
+	}
+	r.alias[sessionID] = current
+	r.reverse[current] = sessionID
+}
+
+// reconnectWS returns true if the connection is recovered.
+func (cdp *Client) reconnectWS(cause error) bool {
+	r := cdp.reconnect
+
+	cdp.wsLock.Lock()
+	cdp.ready = make(chan struct{})
+	cdp.wsLock.Unlock()
+
+	cdp.pending.Range(func(_, val interface{}) bool {
+		val.(func(result))(result{err: cause})
+		return true
+	})
+
+	sleeper := utils.BackoffSleeper(100*time.Millisecond, 5*time.Second, nil)
+
+	var ws WebSocketable
+	attempts := 0
+	for {
+		attempts++
+
+		var err error
+		ctx, cancel := cdp.reconnectCtx()
+		ws, err = r.connect(ctx)
+		cancel()
+		if err == nil {
+			break
+		}
+
+		cdp.logger.Println("reconnect failed:", err)
+
+		if r.maxAttempts > 0 && attempts >= r.maxAttempts {
+			cdp.wsLock.Lock()
+			cdp.err = cause
+			close(cdp.ready)
+			cdp.wsLock.Unlock()
+			return false
+		}
+
+		_ = sleeper(context.Background())
+	}
+
+	done := make(chan struct{})
+	go cdp.consumeMessages(ws, done)
+
+	ctx, cancel := cdp.reconnectCtx()
+	reconnected := cdp.resubscribe(ctx, ws)
+	cancel()
+	reconnected.Attempts = attempts
+
+	cdp.wsLock.Lock()
+	cdp.ws = ws
+	close(cdp.ready)
+	cdp.wsLock.Unlock()
+
+	params, _ := json.Marshal(reconnected)
+	e := &Event{Method: EventReconnected, Params: params}
+	cdp.logger.Println(e)
+	cdp.event <- e
+
+	close(done)
+
+	return true
+}
+
+// resubscribe re-attaches the sessions and replays their states on the new connection.
+func (cdp *Client) resubscribe(ctx context.Context, ws WebSocketable) *Reconnected {
+	r := cdp.reconnect
+	result := &Reconnected{Sessions: []string{}, Lost: []string{}}
+
+	ids, sessions := r.snapshot()
+	for i, id := range ids {
+		s := sessions[i]
+
+		if id != "" {
+			res, err := cdp.call(ctx, ws, "", "Target.attachToTarget", map[string]interface{}{
+				"targetId": s.targetID,
+				"flatten":  true,
+			})
+			var attached struct {
+				SessionID string `json:"sessionId"`
+			}
+			if err == nil {
+				err = json.Unmarshal(res, &attached)
+			}
+			if err != nil || attached.SessionID == "" {
+				r.lock.Lock()
+				r.remove(id)
+				r.lock.Unlock()
+				result.Lost = append(result.Lost, id)
+				continue
+			}
+
+			r.setAlias(id, attached.SessionID)
+			result.Sessions = append(result.Sessions, id)
+		}
+
+		for _, c := range s.enabled {
+			_, err := cdp.call(ctx, ws, id, c.method, c.params)
+			if err != nil {
+				cdp.logger.Println("failed to replay", c.method, err)
+			}
+		}
+	}
+
+	return result
+}
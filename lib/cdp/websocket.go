@@ -5,17 +5,22 @@ import (
 	"context"
 	"crypto/sha1"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net"
 	"net/http"
 	"net/url"
+	"strings"
 	"sync"
+	"sync/atomic"
 )
 
 var _ WebSocketable = &WebSocket{}
 
+var _ OwnedReader = &WebSocket{}
+
 // WebSocket client for chromium. It only implements a subset of WebSocket protocol.
 // Both the Read and Write are thread-safe.
 // Limitation: https://bugs.chromium.org/p/chromium/issues/detail?id=1069431
@@ -24,11 +29,32 @@ type WebSocket struct {
 	// Dialer is usually used for proxy
 	Dialer Dialer
 
+	// Compression enables the permessage-deflate extension if the server supports it.
+	// It reduces the bandwidth when driving a remote browser across WAN links.
+	Compression bool
+
+	// WriteBufferSize is the size of the write buffer, default is 4096.
+	// The messages sent concurrently are batched into as few writes as possible, so that the requests
+	// can be pipelined without extra round trips.
+	WriteBufferSize int
+
+	// MaxPayload is the max size of a message to read, zero means no limit.
+	MaxPayload int
+
 	lock sync.Mutex
 	conn net.Conn
 	r    *bufio.Reader
+
+	wLock   sync.Mutex
+	w       *bufio.Writer
+	pending int32 // the count of the messages waiting to be written
+
+	deflate bool
 }
 
+// ErrPayloadTooLarge is returned when the message size exceeds [WebSocket.MaxPayload].
+var ErrPayloadTooLarge = errors.New("websocket payload too large")
+
 // Connect to browser
 func (ws *WebSocket) Connect(ctx context.Context, wsURL string, header http.Header) error {
 	if ws.conn != nil {
@@ -86,6 +112,15 @@ func (ws *WebSocket) Send(msg []byte) error {
 func (ws *WebSocket) send(msg []byte) error {
 	// FIN is alway true, Opcode is always text frame.
 	header := [18]byte{0b1000_0001, 0b1000_0000}
+
+	if ws.deflate {
+		var err error
+		msg, err = compress(msg)
+		if err != nil {
+			return err
+		}
+		header[0] |= rsv1
+	}
 	mask := []byte{0, 1, 2, 3}
 
 	size := len(msg)
@@ -117,10 +152,35 @@ func (ws *WebSocket) send(msg []byte) error {
 	copy(data, header[:i+6])
 	copy(data[i+6:], msg)
 
-	_, err := ws.conn.Write(data)
-	return err
+	return ws.write(data)
+}
+
+// write the frame to the buffer, the last one of the concurrent writers flushes the buffer,
+// so the frames are batched.
+func (ws *WebSocket) write(data []byte) error {
+	atomic.AddInt32(&ws.pending, 1)
+
+	ws.wLock.Lock()
+	defer ws.wLock.Unlock()
+
+	if ws.w == nil {
+		size := ws.WriteBufferSize
+		if size <= 0 {
+			size = 4096
+		}
+		ws.w = bufio.NewWriterSize(ws.conn, size)
+	}
+
+	_, err := ws.w.Write(data)
+	if atomic.AddInt32(&ws.pending, -1) > 0 || err != nil {
+		return err
+	}
+	return ws.w.Flush()
 }
 
+// OwnedRead implements [OwnedReader], each message read is a new buffer
+func (ws *WebSocket) OwnedRead() {}
+
 // Read a message from browser
 func (ws *WebSocket) Read() ([]byte, error) {
 	b, err := ws.read()
@@ -135,7 +195,7 @@ func (ws *WebSocket) read() ([]byte, error) {
 	ws.lock.Lock()
 	defer ws.lock.Unlock()
 
-	_, err := ws.r.ReadByte()
+	first, err := ws.r.ReadByte()
 	if err != nil {
 		return nil, err
 	}
@@ -167,9 +227,20 @@ func (ws *WebSocket) read() ([]byte, error) {
 		size = size<<8 + int(b)
 	}
 
+	if ws.MaxPayload > 0 && size > ws.MaxPayload {
+		return nil, ErrPayloadTooLarge
+	}
+
 	data := make([]byte, size)
 	_, err = io.ReadFull(ws.r, data)
-	return data, err
+	if err != nil {
+		return nil, err
+	}
+
+	if first&rsv1 != 0 {
+		return decompress(data, ws.MaxPayload)
+	}
+	return data, nil
 }
 
 // ErrBadHandshake type
@@ -203,6 +274,10 @@ func (ws *WebSocket) handshake(ctx context.Context, u *url.URL, header http.Head
 		"Sec-WebSocket-Version": {"13"},
 	}}).WithContext(ctx)
 
+	if ws.Compression {
+		req.Header.Set("Sec-WebSocket-Extensions", deflateExtension)
+	}
+
 	secKey := defaultSecKey
 	for k, vs := range header {
 		if k == "Host" && len(vs) > 0 {
@@ -234,5 +309,7 @@ func (ws *WebSocket) handshake(ctx context.Context, u *url.URL, header http.Head
 		}
 	}
 
+	ws.deflate = strings.Contains(res.Header.Get("Sec-WebSocket-Extensions"), "permessage-deflate")
+
 	return nil
 }
@@ -6,7 +6,9 @@ import (
 	"errors"
 	"net"
 	"net/url"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -107,3 +109,47 @@ func (c *MockConn) SetReadDeadline(_ time.Time) error {
 func (c *MockConn) SetWriteDeadline(_ time.Time) error {
 	return nil
 }
+
+func TestWebSocketBatch(t *testing.T) {
+	g := setup(t)
+
+	mc := &MockConn{errOnCount: 100}
+	ws := &WebSocket{conn: mc}
+
+	ws.wLock.Lock()
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			g.E(ws.Send([]byte("test")))
+		}()
+	}
+	for atomic.LoadInt32(&ws.pending) < 10 {
+		time.Sleep(time.Millisecond)
+	}
+	ws.wLock.Unlock()
+	wg.Wait()
+
+	// all the frames are flushed in one write
+	g.Eq(mc.errOnCount, 99)
+}
+
+func TestWebSocketMaxPayload(t *testing.T) {
+	g := setup(t)
+
+	mc := &MockConn{errOnCount: 100, frame: []byte{0b1000_0001, 10}}
+	ws := &WebSocket{conn: mc, r: bufio.NewReader(mc), MaxPayload: 5}
+	_, err := ws.Read()
+	g.Is(err, ErrPayloadTooLarge)
+
+	data, err := compress([]byte(strings.Repeat("a", 100)))
+	g.E(err)
+
+	out, err := decompress(data, 0)
+	g.E(err)
+	g.Eq(string(out), strings.Repeat("a", 100))
+
+	_, err = decompress(data, 10)
+	g.Is(err, ErrPayloadTooLarge)
+}
@@ -2,7 +2,10 @@ package cdp_test
 
 import (
 	"context"
+	"crypto/sha1"
+	"encoding/base64"
 	"fmt"
+	"io"
 	"net/http"
 	"path/filepath"
 	"strings"
@@ -123,3 +126,48 @@ func TestDuplicatedConnectErr(t *testing.T) {
 		_ = ws.Connect(g.Context(), u, nil)
 	})
 }
+
+func TestWebSocketCompression(t *testing.T) {
+	g := setup(t)
+
+	s := g.Serve()
+
+	// a websocket server that echoes the messages, it only supports the compressed small frames
+	s.Mux.HandleFunc("/", func(rw http.ResponseWriter, r *http.Request) {
+		g.Has(r.Header.Get("Sec-WebSocket-Extensions"), "permessage-deflate")
+
+		h := sha1.Sum([]byte(r.Header.Get("Sec-WebSocket-Key") + "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"))
+
+		conn, buf, err := rw.(http.Hijacker).Hijack()
+		g.E(err)
+		defer func() { _ = conn.Close() }()
+
+		_, _ = buf.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n" +
+			"Sec-WebSocket-Extensions: permessage-deflate\r\n" +
+			"Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(h[:]) + "\r\n\r\n")
+		g.E(buf.Flush())
+
+		header := make([]byte, 6)
+		_, err = io.ReadFull(buf, header)
+		g.E(err)
+		g.Eq(header[0], byte(0b1100_0001))
+
+		payload := make([]byte, header[1]&0x7f)
+		_, err = io.ReadFull(buf, payload)
+		g.E(err)
+		for i := range payload {
+			payload[i] ^= header[2+i%4]
+		}
+
+		_, _ = conn.Write(append([]byte{0b1100_0001, byte(len(payload))}, payload...))
+	})
+
+	ws := &cdp.WebSocket{Compression: true}
+	g.E(ws.Connect(g.Context(), s.URL(), nil))
+
+	g.E(ws.Send([]byte(strings.Repeat("ok", 20))))
+
+	msg, err := ws.Read()
+	g.E(err)
+	g.Eq(string(msg), strings.Repeat("ok", 20))
+}
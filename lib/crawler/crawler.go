@@ -0,0 +1,274 @@
+// Package crawler is a minimal crawling framework on top of rod. It visits the seed urls, extracts the links
+// of each page via the injected js, deduplicates them by their normalized urls, and follows them breadth-first
+// within the depth limit, the pages are visited concurrently by a pool of pages.
+// Use [rod.Browser.RateLimit] and [rod.Browser.RobotsPolicy] to crawl politely, the links disallowed by the
+// robots.txt are skipped.
+package crawler
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"sync"
+
+	"github.com/Fromsko/rodPro"
+	"github.com/Fromsko/rodPro/lib/proto"
+)
+
+var errUnsupportedURL = errors.New("only the absolute http and https urls are supported")
+
+// ErrSkipLinks can be returned by a [Handler] to not follow the links of the page
+var ErrSkipLinks = errors.New("skip the links of the page")
+
+// Visit of a page
+type Visit struct {
+	Link
+
+	// Page that has loaded the url, don't use it after the handler returns, it will be reused for the next visit
+	Page *rod.Page
+}
+
+// Handler of each visited page
+type Handler func(v *Visit) error
+
+// Options of [New]
+type Options struct {
+	// MaxDepth of the links to follow, the seeds are at depth 0, so 0 only visits the seeds.
+	// Negative for unlimited.
+	MaxDepth int
+
+	// MaxPages to visit, 0 for unlimited
+	MaxPages int
+
+	// Concurrency is the number of the pages to visit at the same time, the default is 1
+	Concurrency int
+
+	// Filter the links to follow, the default only follows the links of the same hosts as the seeds
+	Filter func(l Link) bool
+
+	// Normalize the urls for the deduplication, the default is [Normalize]
+	Normalize func(string) (string, error)
+
+	// Links extracts the links of the page, the default is [ExtractLinks]
+	Links func(p *rod.Page) ([]string, error)
+
+	// Wait for the page to be ready after the navigation, the default is [rod.Page.WaitLoad]
+	Wait func(p *rod.Page) error
+
+	// OnError is called when visiting a link fails, if it's nil the crawling stops at the first error
+	OnError func(l Link, err error)
+}
+
+// Crawler of a browser
+type Crawler struct {
+	browser  *rod.Browser
+	opts     Options
+	handlers []Handler
+}
+
+// New crawler that opens its pages in the browser
+func New(b *rod.Browser, opts Options) *Crawler {
+	if opts.Concurrency < 1 {
+		opts.Concurrency = 1
+	}
+	if opts.Normalize == nil {
+		opts.Normalize = Normalize
+	}
+	if opts.Links == nil {
+		opts.Links = ExtractLinks
+	}
+	if opts.Wait == nil {
+		opts.Wait = func(p *rod.Page) error { return p.WaitLoad() }
+	}
+	return &Crawler{browser: b, opts: opts}
+}
+
+// OnPage adds a handler for each visited page, the handlers are called in order
+func (c *Crawler) OnPage(h Handler) *Crawler {
+	c.handlers = append(c.handlers, h)
+	return c
+}
+
+// Run the crawling from the seeds until no link is left, the [Options.MaxPages] is reached, or the ctx is done.
+func (c *Crawler) Run(ctx context.Context, seeds ...string) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	f := NewFrontier(c.opts.Normalize)
+
+	filter := c.opts.Filter
+	if filter == nil {
+		// compare the normalized hosts, so that such as "A.com:80" and "a.com" are the same
+		hostOf := func(raw string) string {
+			n, err := f.normalize(raw)
+			if err != nil {
+				return ""
+			}
+			u, err := url.Parse(n)
+			if err != nil {
+				return ""
+			}
+			return u.Host
+		}
+
+		hosts := map[string]bool{}
+		for _, s := range seeds {
+			if h := hostOf(s); h != "" {
+				hosts[h] = true
+			}
+		}
+		filter = func(l Link) bool {
+			return hosts[hostOf(l.URL)]
+		}
+	}
+
+	for _, s := range seeds {
+		f.Push(Link{URL: s})
+	}
+
+	go func() {
+		<-ctx.Done()
+		f.Close()
+	}()
+
+	var lock sync.Mutex
+	var firstErr error
+	visited := 0
+
+	fail := func(l Link, err error) {
+		if c.opts.OnError != nil {
+			c.opts.OnError(l, err)
+			return
+		}
+		lock.Lock()
+		defer lock.Unlock()
+		if firstErr == nil {
+			firstErr = err
+		}
+		cancel()
+	}
+
+	// reserve a visit, false if the max pages is reached
+	reserve := func() bool {
+		lock.Lock()
+		defer lock.Unlock()
+		if c.opts.MaxPages > 0 && visited >= c.opts.MaxPages {
+			return false
+		}
+		visited++
+		return true
+	}
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < c.opts.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			var page *rod.Page
+			defer func() {
+				if page != nil {
+					_ = page.Close()
+				}
+			}()
+
+			for {
+				l, ok := f.Pop()
+				if !ok {
+					return
+				}
+
+				if !reserve() {
+					f.Done()
+					f.Close()
+					return
+				}
+
+				if page == nil {
+					p, err := c.browser.Context(ctx).Page(proto.TargetCreateTarget{})
+					if err != nil {
+						fail(l, err)
+						f.Done()
+						return
+					}
+					page = p
+				}
+
+				links, err := c.visit(page.Context(ctx), l)
+				if err != nil && !errors.Is(err, &rod.ErrRobotsDisallowed{}) && ctx.Err() == nil {
+					fail(l, err)
+				}
+
+				if c.opts.MaxDepth < 0 || l.Depth < c.opts.MaxDepth {
+					for _, u := range links {
+						next := Link{URL: u, Depth: l.Depth + 1, Referrer: l.URL}
+						if filter(next) {
+							f.Push(next)
+						}
+					}
+				}
+
+				f.Done()
+			}
+		}()
+	}
+	wg.Wait()
+
+	lock.Lock()
+	defer lock.Unlock()
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+func (c *Crawler) visit(p *rod.Page, l Link) ([]string, error) {
+	err := p.Navigate(l.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.opts.Wait(p)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &Visit{Link: l, Page: p}
+	for _, h := range c.handlers {
+		err = h(v)
+		if errors.Is(err, ErrSkipLinks) {
+			return nil, nil
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	return c.opts.Links(p)
+}
+
+// ExtractLinks returns the absolute urls of the links and the frames of the page,
+// the links with rel="nofollow" are excluded.
+func ExtractLinks(p *rod.Page) ([]string, error) {
+	res, err := p.Eval(jsLinks)
+	if err != nil {
+		return nil, err
+	}
+
+	list := []string{}
+	for _, u := range res.Value.Arr() {
+		list = append(list, u.Str())
+	}
+	return list, nil
+}
+
+const jsLinks = `() => {
+	const list = []
+	for (const el of document.querySelectorAll('a[href], area[href]')) {
+		if (/\bnofollow\b/i.test(el.getAttribute('rel') || '')) continue
+		list.push(el.href)
+	}
+	for (const el of document.querySelectorAll('iframe[src], frame[src]')) {
+		list.push(el.src)
+	}
+	return list
+}`
@@ -0,0 +1,88 @@
+package crawler_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/Fromsko/rodPro/lib/crawler"
+	"github.com/ysmood/got"
+)
+
+func TestNormalize(t *testing.T) {
+	g := got.T(t)
+
+	for in, out := range map[string]string{
+		"HTTP://Example.COM":                  "http://example.com/",
+		"https://example.com:443/a#top":       "https://example.com/a",
+		"http://example.com:80/a?b=2&a=1&b=1": "http://example.com/a?a=1&b=1&b=2",
+		"http://example.com:8080/a/":          "http://example.com:8080/a/",
+		"http://[::1]:80/":                    "http://[::1]/",
+		" https://example.com/?q=a%20b ":      "https://example.com/?q=a+b",
+	} {
+		u, err := crawler.Normalize(in)
+		g.E(err)
+		g.Eq(u, out)
+	}
+
+	for _, in := range []string{"mailto:a@b.com", "javascript:void(0)", "/relative", "://"} {
+		_, err := crawler.Normalize(in)
+		g.Err(err)
+	}
+}
+
+func TestFrontier(t *testing.T) {
+	g := got.T(t)
+
+	f := crawler.NewFrontier(nil)
+
+	g.True(f.Push(crawler.Link{URL: "http://a.com"}))
+	g.False(f.Push(crawler.Link{URL: "http://A.com/#x"}))
+	g.False(f.Push(crawler.Link{URL: "mailto:a@b.com"}))
+	g.True(f.Push(crawler.Link{URL: "http://a.com/b"}))
+	g.Eq(f.Len(), 2)
+	g.Eq(f.Seen(), 2)
+
+	// the url is kept as it is
+	l, ok := f.Pop()
+	g.True(ok)
+	g.Eq(l.URL, "http://a.com")
+
+	// the links pushed while processing are popped in order
+	g.True(f.Push(crawler.Link{URL: "http://a.com/c", Depth: 1, Referrer: l.URL}))
+	f.Done()
+
+	l, ok = f.Pop()
+	g.True(ok)
+	g.Eq(l.URL, "http://a.com/b")
+	f.Done()
+
+	l, ok = f.Pop()
+	g.True(ok)
+	g.Eq(l, crawler.Link{URL: "http://a.com/c", Depth: 1, Referrer: "http://a.com"})
+
+	// Pop waits for the inflight link
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		l, ok := f.Pop()
+		g.True(ok)
+		g.Eq(l.URL, "http://a.com/d")
+		f.Done()
+
+		_, ok = f.Pop()
+		g.False(ok)
+	}()
+	f.Push(crawler.Link{URL: "http://a.com/d"})
+	f.Done()
+	wg.Wait()
+
+	g.False(f.Push(crawler.Link{URL: "http://a.com/e"}))
+
+	f = crawler.NewFrontier(nil)
+	f.Push(crawler.Link{URL: "http://a.com"})
+	f.Close()
+	_, ok = f.Pop()
+	g.False(ok)
+	g.Eq(f.Len(), 0)
+}
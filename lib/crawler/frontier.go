@@ -0,0 +1,158 @@
+package crawler
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Link in the [Frontier]
+type Link struct {
+	URL      string
+	Depth    int
+	Referrer string
+}
+
+// Frontier is the queue of the links to visit, the links are deduplicated by their normalized urls
+// and popped in the breadth-first order. It's safe for concurrent use.
+type Frontier struct {
+	lock     sync.Mutex
+	cond     *sync.Cond
+	queue    []Link
+	seen     map[string]bool
+	inflight int
+	closed   bool
+
+	normalize func(string) (string, error)
+}
+
+// NewFrontier with the normalize function, [Normalize] is used if it's nil
+func NewFrontier(normalize func(string) (string, error)) *Frontier {
+	if normalize == nil {
+		normalize = Normalize
+	}
+	f := &Frontier{seen: map[string]bool{}, normalize: normalize}
+	f.cond = sync.NewCond(&f.lock)
+	return f
+}
+
+// Push the link if it has not been seen, the normalized url is only used as the key of the deduplication,
+// the url of the link is kept as it is, because the server may not treat the normalized one the same.
+// Returns false if the link is a duplicate, invalid, or the frontier is closed.
+func (f *Frontier) Push(l Link) bool {
+	u, err := f.normalize(l.URL)
+	if err != nil {
+		return false
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.closed || f.seen[u] {
+		return false
+	}
+	f.seen[u] = true
+	f.queue = append(f.queue, l)
+	f.cond.Signal()
+	return true
+}
+
+// Pop the next link, it blocks until a link is available. The ok is false when the frontier is closed,
+// or it's empty and no popped link is still being processed. Call [Frontier.Done] after the link is processed.
+func (f *Frontier) Pop() (l Link, ok bool) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	for len(f.queue) == 0 && !f.closed {
+		if f.inflight == 0 {
+			f.closed = true
+			f.cond.Broadcast()
+			break
+		}
+		f.cond.Wait()
+	}
+	if f.closed {
+		return Link{}, false
+	}
+
+	l, f.queue = f.queue[0], f.queue[1:]
+	f.inflight++
+	return l, true
+}
+
+// Done marks a popped link as processed
+func (f *Frontier) Done() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.inflight--
+	f.cond.Broadcast()
+}
+
+// Close the frontier, the pending links are dropped
+func (f *Frontier) Close() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.closed = true
+	f.queue = nil
+	f.cond.Broadcast()
+}
+
+// Len of the pending links
+func (f *Frontier) Len() int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return len(f.queue)
+}
+
+// Seen returns the number of the unique links that have been pushed
+func (f *Frontier) Seen() int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return len(f.seen)
+}
+
+// Normalize the url for the deduplication: the scheme and host are lower-cased, the default port and the fragment
+// are removed, the empty path becomes "/", and the query parameters are sorted.
+// Only the absolute http and https urls are valid.
+func Normalize(raw string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return "", err
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return "", &url.Error{Op: "normalize", URL: raw, Err: errUnsupportedURL}
+	}
+
+	host, port := strings.ToLower(u.Hostname()), u.Port()
+	if (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+		port = ""
+	}
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if port != "" {
+		host += ":" + port
+	}
+	u.Host = host
+
+	u.Fragment = ""
+	u.RawFragment = ""
+	if u.Path == "" {
+		u.Path = "/"
+	}
+
+	if u.RawQuery != "" {
+		q := u.Query()
+		for _, v := range q {
+			sort.Strings(v)
+		}
+		u.RawQuery = q.Encode()
+	}
+
+	return u.String(), nil
+}
@@ -0,0 +1,421 @@
+// Package fleet manages browsers across multiple hosts and runs the tasks on them like a queue.
+// Each host is a [launcher.Manager] or the local machine, the browsers are launched lazily,
+// the tasks wait in order when all the slots are busy, the task is retried on another browser
+// if its page or browser crashes, and the fleet can be drained gracefully before the shutdown.
+package fleet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Fromsko/rodPro"
+	"github.com/Fromsko/rodPro/lib/launcher"
+	"github.com/Fromsko/rodPro/lib/proto"
+)
+
+// ErrDraining is returned when a task is submitted after [Fleet.Drain]
+var ErrDraining = errors.New("fleet is draining")
+
+// Task to run on a new page, the page will be closed after the task returns
+type Task func(*rod.Page) error
+
+// Host of the browsers
+type Host struct {
+	// URL of the [launcher.Manager], such as "ws://10.0.0.2:7317", empty to launch the browsers locally
+	URL string
+
+	// Browsers is the max number of the browsers on the host, the default is 1
+	Browsers int
+
+	// Pages is the max number of the concurrent tasks on each browser, the default is 1
+	Pages int
+}
+
+// Options of [New]
+type Options struct {
+	Hosts []Host
+
+	// Retries is the max number of the retries of a task when its page or browser crashes
+	Retries int
+
+	// Launch a browser on the host, the default launches a headless browser via the [launcher.Manager]
+	// of the host, or a local browser if the [Host.URL] is empty.
+	Launch func(Host) (*rod.Browser, error)
+}
+
+// ErrCrashed is returned when a task fails because its page or browser crashed and the retries run out
+type ErrCrashed struct {
+	Host     string
+	Attempts int
+	Err      error
+}
+
+func (e *ErrCrashed) Error() string {
+	host := e.Host
+	if host == "" {
+		host = "local"
+	}
+	return fmt.Sprintf("fleet: task crashed after %d attempts on %s: %s", e.Attempts, host, e.Err.Error())
+}
+
+// Unwrap stdlib interface
+func (e *ErrCrashed) Unwrap() error {
+	return e.Err
+}
+
+// Is interface
+func (e *ErrCrashed) Is(err error) bool {
+	_, ok := err.(*ErrCrashed)
+	return ok
+}
+
+func (f *Fleet) isDraining() bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.draining
+}
+
+// Stats of the fleet
+type Stats struct {
+	// Running tasks
+	Running int64
+	// Waiting tasks for the free slots
+	Waiting int64
+	// Done tasks, including the failed ones
+	Done int64
+	// Retried times of the tasks
+	Retried int64
+	// Launched browsers, including the relaunched ones
+	Launched int64
+}
+
+// Fleet of browsers
+type Fleet struct {
+	stats Stats // keep it first for the 64-bit alignment of the atomic operations
+
+	opts  Options
+	slots chan *slot
+
+	lock     sync.Mutex
+	draining bool
+	members  []*member
+	running  sync.WaitGroup
+
+	// the browser operations, they are replaced in the tests
+	drv driver
+}
+
+// New fleet, the browsers will be launched on demand
+func New(opts Options) *Fleet {
+	if len(opts.Hosts) == 0 {
+		opts.Hosts = []Host{{}}
+	}
+	if opts.Launch == nil {
+		opts.Launch = Launch
+	}
+
+	f := &Fleet{opts: opts}
+	f.drv = &rodDriver{launch: opts.Launch}
+
+	total := 0
+	for _, h := range opts.Hosts {
+		if h.Browsers < 1 {
+			h.Browsers = 1
+		}
+		if h.Pages < 1 {
+			h.Pages = 1
+		}
+		for i := 0; i < h.Browsers; i++ {
+			f.members = append(f.members, &member{fleet: f, host: h})
+		}
+		total += h.Browsers * h.Pages
+	}
+
+	// interleave the slots of the hosts and browsers, so the tasks are spread out evenly
+	f.slots = make(chan *slot, total)
+	for i := 0; len(f.slots) < total; i++ {
+		for _, m := range f.members {
+			if i < m.host.Pages {
+				f.slots <- &slot{m}
+			}
+		}
+	}
+
+	return f
+}
+
+// Launch is the default [Options.Launch]
+func Launch(h Host) (*rod.Browser, error) {
+	var u string
+	if h.URL == "" {
+		l := launcher.New()
+		var err error
+		u, err = l.Launch()
+		if err != nil {
+			return nil, err
+		}
+		b := rod.New().ControlURL(u)
+		if err = b.Connect(); err != nil {
+			l.Kill()
+			l.Cleanup()
+			return nil, err
+		}
+		// remove the user-data-dir after the browser is closed
+		go l.Cleanup()
+		return b, nil
+	}
+
+	l, err := launcher.NewManaged(h.URL)
+	if err != nil {
+		return nil, err
+	}
+	c, err := l.Client()
+	if err != nil {
+		return nil, err
+	}
+	b := rod.New().Client(c)
+	return b, b.Connect()
+}
+
+// Run the task on a new page and wait for it, check [Fleet.RunContext] for details.
+func (f *Fleet) Run(task Task) error {
+	return f.RunContext(context.Background(), task)
+}
+
+// Go runs the task in the background, the channel receives the result of [Fleet.Run].
+func (f *Fleet) Go(task Task) <-chan error {
+	res := make(chan error, 1)
+	if err := f.enter(); err != nil {
+		res <- err
+		return res
+	}
+
+	go func() {
+		defer f.running.Done()
+		res <- f.run(context.Background(), task)
+	}()
+	return res
+}
+
+// RunContext waits for a free slot, then runs the task on a new page of the slot's browser.
+// The page will be bound to the ctx. If the page or the browser crashes, the browser will be relaunched
+// and the task will be retried on the next free slot up to [Options.Retries] times, the other errors are
+// returned as they are.
+func (f *Fleet) RunContext(ctx context.Context, task Task) error {
+	if err := f.enter(); err != nil {
+		return err
+	}
+	defer f.running.Done()
+
+	return f.run(ctx, task)
+}
+
+func (f *Fleet) enter() error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.draining {
+		return ErrDraining
+	}
+	f.running.Add(1)
+	return nil
+}
+
+func (f *Fleet) run(ctx context.Context, task Task) error {
+	for attempt := 1; ; attempt++ {
+		atomic.AddInt64(&f.stats.Waiting, 1)
+		var s *slot
+		select {
+		case <-ctx.Done():
+			atomic.AddInt64(&f.stats.Waiting, -1)
+			return ctx.Err()
+		case s = <-f.slots:
+		}
+		atomic.AddInt64(&f.stats.Waiting, -1)
+
+		atomic.AddInt64(&f.stats.Running, 1)
+		crashed, err := s.member.run(ctx, task)
+		atomic.AddInt64(&f.stats.Running, -1)
+		f.slots <- s
+
+		if !crashed {
+			atomic.AddInt64(&f.stats.Done, 1)
+			return err
+		}
+
+		// don't relaunch the browsers that the draining is closing
+		if attempt > f.opts.Retries || ctx.Err() != nil || f.isDraining() {
+			atomic.AddInt64(&f.stats.Done, 1)
+			return &ErrCrashed{Host: s.member.host.URL, Attempts: attempt, Err: err}
+		}
+		atomic.AddInt64(&f.stats.Retried, 1)
+	}
+}
+
+// Drain stops accepting new tasks, waits for the submitted tasks to finish, then closes the browsers.
+// If the ctx is done before the tasks finish, the browsers will be closed anyway and the ctx error is returned.
+func (f *Fleet) Drain(ctx context.Context) error {
+	f.lock.Lock()
+	f.draining = true
+	f.lock.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		f.running.Wait()
+		close(done)
+	}()
+
+	var err error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	for _, m := range f.members {
+		m.reset()
+	}
+
+	return err
+}
+
+// Stats of the fleet
+func (f *Fleet) Stats() Stats {
+	return Stats{
+		Running:  atomic.LoadInt64(&f.stats.Running),
+		Waiting:  atomic.LoadInt64(&f.stats.Waiting),
+		Done:     atomic.LoadInt64(&f.stats.Done),
+		Retried:  atomic.LoadInt64(&f.stats.Retried),
+		Launched: atomic.LoadInt64(&f.stats.Launched),
+	}
+}
+
+type slot struct {
+	member *member
+}
+
+// member is a browser of a host, it's launched lazily and relaunched after it crashes
+type member struct {
+	fleet *Fleet
+	host  Host
+
+	lock    sync.Mutex
+	browser *rod.Browser
+}
+
+func (m *member) get() (*rod.Browser, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.browser != nil {
+		return m.browser, nil
+	}
+
+	b, err := m.fleet.drv.Launch(m.host)
+	if err != nil {
+		return nil, err
+	}
+	atomic.AddInt64(&m.fleet.stats.Launched, 1)
+	m.browser = b
+	return b, nil
+}
+
+// resetIf closes the browser if it's the same as b, so the next get will relaunch it.
+// If b is nil, the current browser is closed.
+func (m *member) resetIf(b *rod.Browser) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.browser == nil || (b != nil && m.browser != b) {
+		return
+	}
+	m.fleet.drv.Close(m.browser)
+	m.browser = nil
+}
+
+func (m *member) reset() {
+	m.resetIf(nil)
+}
+
+// run the task, crashed is true if the task should be retried
+func (m *member) run(ctx context.Context, task Task) (crashed bool, err error) {
+	b, err := m.get()
+	if err != nil {
+		return true, err
+	}
+
+	p, closePage, err := m.fleet.drv.Open(ctx, b)
+	if err != nil {
+		m.resetIf(b)
+		return true, err
+	}
+	defer closePage()
+
+	pageCrashed, stop := m.fleet.drv.WatchCrash(b, p)
+	defer stop()
+
+	if e := rod.Try(func() { err = task(p) }); e != nil {
+		err = e
+	}
+	if err == nil {
+		return false, nil
+	}
+
+	if pageCrashed() {
+		return true, err
+	}
+	if !m.fleet.drv.Alive(b) {
+		m.resetIf(b)
+		return true, err
+	}
+	return false, err
+}
+
+// driver of the browsers
+type driver interface {
+	Launch(Host) (*rod.Browser, error)
+	Close(*rod.Browser)
+	// Open a page bound to the ctx, the closePage should work even if the ctx is done
+	Open(context.Context, *rod.Browser) (p *rod.Page, closePage func(), err error)
+	Alive(*rod.Browser) bool
+	WatchCrash(*rod.Browser, *rod.Page) (crashed func() bool, stop func())
+}
+
+type rodDriver struct {
+	launch func(Host) (*rod.Browser, error)
+}
+
+func (d *rodDriver) Launch(h Host) (*rod.Browser, error) {
+	return d.launch(h)
+}
+
+func (d *rodDriver) Close(b *rod.Browser) {
+	_ = b.Close()
+}
+
+func (d *rodDriver) Open(ctx context.Context, b *rod.Browser) (*rod.Page, func(), error) {
+	p, err := b.Page(proto.TargetCreateTarget{})
+	if err != nil {
+		return nil, nil, err
+	}
+	return p.Context(ctx), func() { _ = p.Close() }, nil
+}
+
+func (d *rodDriver) Alive(b *rod.Browser) bool {
+	_, err := proto.BrowserGetVersion{}.Call(b.Timeout(5 * time.Second))
+	return err == nil
+}
+
+func (d *rodDriver) WatchCrash(b *rod.Browser, p *rod.Page) (func() bool, func()) {
+	var crashed int32
+	stop := b.OnCrash(func(cp *rod.Page, e *proto.TargetTargetCrashed) {
+		if e.TargetID == p.TargetID {
+			atomic.StoreInt32(&crashed, 1)
+		}
+	})
+	return func() bool { return atomic.LoadInt32(&crashed) == 1 }, stop
+}
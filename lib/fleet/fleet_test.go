@@ -0,0 +1,228 @@
+package fleet
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Fromsko/rodPro"
+	"github.com/Fromsko/rodPro/lib/proto"
+	"github.com/ysmood/got"
+)
+
+var setup = got.Setup(nil)
+
+type fakeDriver struct {
+	lock     sync.Mutex
+	launched []string
+	closed   int
+	pages    int32
+	dead     map[*rod.Browser]bool
+	crash    map[proto.TargetTargetID]bool
+	failOpen int32
+}
+
+func newFake() *fakeDriver {
+	return &fakeDriver{dead: map[*rod.Browser]bool{}, crash: map[proto.TargetTargetID]bool{}}
+}
+
+func (d *fakeDriver) Launch(h Host) (*rod.Browser, error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.launched = append(d.launched, h.URL)
+	return rod.New(), nil
+}
+
+func (d *fakeDriver) Close(*rod.Browser) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.closed++
+}
+
+func (d *fakeDriver) Open(_ context.Context, _ *rod.Browser) (*rod.Page, func(), error) {
+	if atomic.AddInt32(&d.failOpen, -1) >= 0 {
+		return nil, nil, errors.New("open failed")
+	}
+	n := atomic.AddInt32(&d.pages, 1)
+	return &rod.Page{TargetID: proto.TargetTargetID(string(rune('a' + n)))}, func() {}, nil
+}
+
+func (d *fakeDriver) Alive(b *rod.Browser) bool {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return !d.dead[b]
+}
+
+func (d *fakeDriver) WatchCrash(_ *rod.Browser, p *rod.Page) (func() bool, func()) {
+	return func() bool {
+		d.lock.Lock()
+		defer d.lock.Unlock()
+		return d.crash[p.TargetID]
+	}, func() {}
+}
+
+func newFleet(opts Options) (*Fleet, *fakeDriver) {
+	f := New(opts)
+	d := newFake()
+	f.drv = d
+	return f, d
+}
+
+func TestConcurrencyLimits(t *testing.T) {
+	g := setup(t)
+
+	f, d := newFleet(Options{Hosts: []Host{{URL: "a", Browsers: 2, Pages: 2}, {URL: "b"}}})
+	g.Eq(cap(f.slots), 5)
+
+	var running, max int32
+	wg := sync.WaitGroup{}
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			g.E(f.Run(func(p *rod.Page) error {
+				n := atomic.AddInt32(&running, 1)
+				for {
+					m := atomic.LoadInt32(&max)
+					if n <= m || atomic.CompareAndSwapInt32(&max, m, n) {
+						break
+					}
+				}
+				time.Sleep(10 * time.Millisecond)
+				atomic.AddInt32(&running, -1)
+				return nil
+			}))
+		}()
+	}
+	wg.Wait()
+
+	g.Lte(max, int32(5))
+	g.Gt(max, int32(1))
+	g.Len(d.launched, 3)
+	g.Eq(f.Stats(), Stats{Done: 20, Launched: 3})
+
+	g.E(f.Drain(context.Background()))
+	g.Eq(d.closed, 3)
+	g.Is(f.Run(func(*rod.Page) error { return nil }), ErrDraining)
+	g.Is(<-f.Go(func(*rod.Page) error { return nil }), ErrDraining)
+}
+
+func TestRetryOnCrash(t *testing.T) {
+	g := setup(t)
+
+	f, d := newFleet(Options{Retries: 2})
+
+	// the browser dies during the first attempt
+	count := 0
+	err := f.Run(func(p *rod.Page) error {
+		count++
+		if count == 1 {
+			d.lock.Lock()
+			for _, b := range []*rod.Browser{f.members[0].browser} {
+				d.dead[b] = true
+			}
+			d.lock.Unlock()
+			return errors.New("connection closed")
+		}
+		return nil
+	})
+	g.E(err)
+	g.Eq(count, 2)
+	g.Len(d.launched, 2)
+	g.Eq(f.Stats().Retried, int64(1))
+
+	// the page keeps crashing
+	err = f.Run(func(p *rod.Page) error {
+		d.lock.Lock()
+		d.crash[p.TargetID] = true
+		d.lock.Unlock()
+		return errors.New("crashed")
+	})
+	g.Is(err, &ErrCrashed{})
+	g.Eq(err.Error(), "fleet: task crashed after 3 attempts on local: crashed")
+	g.Eq(errors.Unwrap(err).Error(), "crashed")
+
+	// the normal error is not retried
+	count = 0
+	err = f.Run(func(p *rod.Page) error {
+		count++
+		return errors.New("normal")
+	})
+	g.Eq(err.Error(), "normal")
+	g.Eq(count, 1)
+
+	// the panic is returned as an error
+	err = f.Run(func(p *rod.Page) error { panic("oops") })
+	g.Is(err, &rod.ErrTry{})
+
+	// failing to open the page relaunches the browser
+	atomic.StoreInt32(&d.failOpen, 1)
+	g.E(<-f.Go(func(p *rod.Page) error { return nil }))
+	g.Len(d.launched, 3)
+}
+
+func TestDrain(t *testing.T) {
+	g := setup(t)
+
+	f, _ := newFleet(Options{})
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	res := f.Go(func(p *rod.Page) error {
+		close(started)
+		<-release
+		return nil
+	})
+	<-started
+
+	// the waiting task is canceled by its context
+	ctx, cancel := context.WithCancel(context.Background())
+	waiting := make(chan error)
+	go func() { waiting <- f.RunContext(ctx, func(*rod.Page) error { return nil }) }()
+	for f.Stats().Waiting == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	g.Is(<-waiting, context.Canceled)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	g.Is(f.Drain(ctx), context.DeadlineExceeded)
+
+	close(release)
+	g.E(<-res)
+	g.E(f.Drain(context.Background()))
+}
+
+func TestDrainNoRelaunch(t *testing.T) {
+	g := setup(t)
+
+	f, d := newFleet(Options{Retries: 2})
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	res := f.Go(func(p *rod.Page) error {
+		close(started)
+		<-release
+		d.lock.Lock()
+		d.crash[p.TargetID] = true
+		d.lock.Unlock()
+		return errors.New("crashed")
+	})
+	<-started
+
+	drained := make(chan error)
+	go func() { drained <- f.Drain(context.Background()) }()
+	for !f.isDraining() {
+		time.Sleep(time.Millisecond)
+	}
+
+	// the crashed task is not retried on a relaunched browser while draining
+	close(release)
+	g.Is(<-res, &ErrCrashed{})
+	g.E(<-drained)
+	g.Len(d.launched, 1)
+}
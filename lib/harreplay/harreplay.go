@@ -0,0 +1,285 @@
+// Package harreplay serves recorded responses from a HAR file via the hijack router,
+// so that pages can be tested fully offline and deterministically.
+// HAR spec: http://www.softwareishard.com/blog/har-12-spec
+package harreplay
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Fromsko/rodPro"
+	"github.com/Fromsko/rodPro/lib/proto"
+)
+
+// HAR file format, only the fields required for replaying are decoded.
+type HAR struct {
+	Log *Log `json:"log"`
+}
+
+// Log of the HAR
+type Log struct {
+	Entries []*Entry `json:"entries"`
+}
+
+// Entry is a recorded request and its response
+type Entry struct {
+	Request  *Request  `json:"request"`
+	Response *Response `json:"response"`
+}
+
+// Request of an entry
+type Request struct {
+	Method   string    `json:"method"`
+	URL      string    `json:"url"`
+	Headers  []*Header `json:"headers"`
+	PostData *PostData `json:"postData,omitempty"`
+}
+
+// Response of an entry
+type Response struct {
+	Status     int       `json:"status"`
+	StatusText string    `json:"statusText"`
+	Headers    []*Header `json:"headers"`
+	Content    *Content  `json:"content"`
+}
+
+// Header name-value pair
+type Header struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// PostData of a request
+type PostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+// Content of a response
+type Content struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// Body decodes the content text
+func (c *Content) Body() ([]byte, error) {
+	if c == nil {
+		return nil, nil
+	}
+	if c.Encoding == "base64" {
+		return base64.StdEncoding.DecodeString(c.Text)
+	}
+	return []byte(c.Text), nil
+}
+
+// Header value of the request, the name is case-insensitive
+func (r *Request) Header(name string) string {
+	for _, h := range r.Headers {
+		if strings.EqualFold(h.Name, name) {
+			return h.Value
+		}
+	}
+	return ""
+}
+
+// Load a HAR file
+func Load(path string) (*HAR, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(b)
+}
+
+// Parse HAR data
+func Parse(data []byte) (*HAR, error) {
+	har := &HAR{}
+	err := json.Unmarshal(data, har)
+	if err != nil {
+		return nil, err
+	}
+	if har.Log == nil {
+		har.Log = &Log{}
+	}
+	return har, nil
+}
+
+// Fallback policy when no recorded entry matches a request
+type Fallback int
+
+const (
+	// FallbackContinue sends the request to the real destination
+	FallbackContinue Fallback = iota
+
+	// FallbackFail fails the request with [proto.NetworkErrorReasonInternetDisconnected]
+	FallbackFail
+
+	// FallbackNotFound responds with an empty 404
+	FallbackNotFound
+)
+
+// Replayer serves the recorded entries of a HAR
+type Replayer struct {
+	// Fallback policy, default is [FallbackContinue]
+	Fallback Fallback
+
+	// IgnoreQuery ignores the url query when matching requests
+	IgnoreQuery bool
+
+	// MatchHeaders is the list of request header names that must be equal to match an entry
+	MatchHeaders []string
+
+	// MatchBody requires the request body to equal the recorded post data
+	MatchBody bool
+
+	// Match is an optional extra check, return false to reject an entry for the request
+	Match func(req *rod.HijackRequest, e *Entry) bool
+
+	lock    *sync.Mutex
+	entries []*Entry
+
+	// how many times each list of the matched entries has been served, so that repeated requests
+	// replay the recorded responses in order, the requests that match different entries, such as
+	// the ones with different headers or bodies, don't share the counter
+	served map[string]int
+}
+
+// New replayer for the har
+func New(har *HAR) *Replayer {
+	return &Replayer{
+		lock:    &sync.Mutex{},
+		entries: har.Log.Entries,
+		served:  map[string]int{},
+	}
+}
+
+// Register the replayer to the router to handle all the requests
+func (r *Replayer) Register(router *rod.HijackRouter) error {
+	return router.Add("*", "", r.Handle)
+}
+
+// Handle is a hijack handler
+func (r *Replayer) Handle(ctx *rod.Hijack) {
+	req := ctx.Request
+
+	e := r.Find(req.Method(), req.URL().String(), req.Header, req.Body(), func(e *Entry) bool {
+		return r.Match == nil || r.Match(req, e)
+	})
+
+	if e == nil {
+		switch r.Fallback {
+		case FallbackFail:
+			ctx.Response.Fail(proto.NetworkErrorReasonInternetDisconnected)
+		case FallbackNotFound:
+			ctx.Response.Payload().ResponseCode = http.StatusNotFound
+		default:
+			ctx.ContinueRequest(&proto.FetchContinueRequest{})
+		}
+		return
+	}
+
+	body, err := e.Response.Content.Body()
+	if err != nil {
+		ctx.OnError(err)
+		ctx.ContinueRequest(&proto.FetchContinueRequest{})
+		return
+	}
+
+	ctx.Response.Payload().ResponseCode = e.Response.Status
+	for _, h := range e.Response.Headers {
+		// the recorded body is already decoded and may have a different length
+		switch strings.ToLower(h.Name) {
+		case "content-encoding", "content-length", "transfer-encoding":
+			continue
+		}
+		ctx.Response.SetHeader(h.Name, h.Value)
+	}
+	ctx.Response.SetBody(body)
+}
+
+// Find the next recorded entry for the request, returns nil if not found.
+// The header is used to get the request header value by name.
+// The filter is optional, return false to reject an entry.
+func (r *Replayer) Find(method, u string, header func(string) string, body string, filter func(*Entry) bool) *Entry {
+	list := []*Entry{}
+	key := ""
+	for i, e := range r.entries {
+		if e.Request == nil || e.Response == nil {
+			continue
+		}
+		if !strings.EqualFold(e.Request.Method, method) || r.normalize(e.Request.URL) != r.normalize(u) {
+			continue
+		}
+		if !r.matchHeaders(e, header) {
+			continue
+		}
+		if r.MatchBody && !matchBody(e, body) {
+			continue
+		}
+		if filter != nil && !filter(e) {
+			continue
+		}
+		list = append(list, e)
+		key += strconv.Itoa(i) + ","
+	}
+
+	if len(list) == 0 {
+		return nil
+	}
+
+	// only the counters are guarded, the filter may be slow or call back into the replayer
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	// after all the recorded responses are served, keep serving the last one
+	i := r.served[key]
+	if i >= len(list) {
+		i = len(list) - 1
+	}
+	r.served[key]++
+
+	return list[i]
+}
+
+// Reset the served counters, so that the entries will be replayed from the beginning
+func (r *Replayer) Reset() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.served = map[string]int{}
+}
+
+func (r *Replayer) normalize(u string) string {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return u
+	}
+	parsed.Fragment = ""
+	if r.IgnoreQuery {
+		parsed.RawQuery = ""
+	}
+	return parsed.String()
+}
+
+func (r *Replayer) matchHeaders(e *Entry, header func(string) string) bool {
+	for _, name := range r.MatchHeaders {
+		if header == nil || e.Request.Header(name) != header(name) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchBody(e *Entry, body string) bool {
+	if e.Request.PostData == nil {
+		return body == ""
+	}
+	return e.Request.PostData.Text == body
+}
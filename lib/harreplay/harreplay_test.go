@@ -0,0 +1,99 @@
+package harreplay_test
+
+import (
+	"testing"
+
+	"github.com/Fromsko/rodPro/lib/harreplay"
+	"github.com/ysmood/got"
+)
+
+var setup = got.Setup(nil)
+
+func load(g got.G) *harreplay.Replayer {
+	har, err := harreplay.Load("fixtures/recorded.har")
+	g.E(err)
+	return harreplay.New(har)
+}
+
+func TestLoad(t *testing.T) {
+	g := setup(t)
+
+	har, err := harreplay.Load("fixtures/recorded.har")
+	g.E(err)
+	g.Len(har.Log.Entries, 4)
+
+	_, err = harreplay.Load("not-exists.har")
+	g.Err(err)
+
+	_, err = harreplay.Parse([]byte("{"))
+	g.Err(err)
+
+	har, err = harreplay.Parse([]byte("{}"))
+	g.E(err)
+	g.Len(har.Log.Entries, 0)
+}
+
+func TestFindInOrder(t *testing.T) {
+	g := setup(t)
+
+	r := load(g)
+
+	body := func(e *harreplay.Entry) string {
+		b, err := e.Response.Content.Body()
+		g.E(err)
+		return string(b)
+	}
+
+	g.Eq(body(r.Find("GET", "http://example.com/", nil, "", nil)), "<html>first</html>")
+	g.Eq(body(r.Find("GET", "http://example.com/#hash", nil, "", nil)), "<html>second</html>")
+	g.Eq(body(r.Find("GET", "http://example.com/", nil, "", nil)), "<html>second</html>")
+
+	r.Reset()
+	g.Eq(body(r.Find("get", "http://example.com/", nil, "", nil)), "<html>first</html>")
+
+	g.Nil(r.Find("GET", "http://example.com/not-exists", nil, "", nil))
+	g.Nil(r.Find("GET", "http://example.com/", nil, "", func(*harreplay.Entry) bool { return false }))
+
+	// the requests that match different entries don't share the counter
+	r.Reset()
+	second := func(e *harreplay.Entry) bool { return body(e) == "<html>second</html>" }
+	g.Eq(body(r.Find("GET", "http://example.com/", nil, "", second)), "<html>second</html>")
+	g.Eq(body(r.Find("GET", "http://example.com/", nil, "", nil)), "<html>first</html>")
+
+	// the filter isn't called with the lock held
+	g.NotNil(r.Find("GET", "http://example.com/", nil, "", func(*harreplay.Entry) bool {
+		r.Reset()
+		return true
+	}))
+}
+
+func TestFindIgnoreQuery(t *testing.T) {
+	g := setup(t)
+
+	r := load(g)
+
+	g.Nil(r.Find("GET", "http://example.com/img.png?v=2", nil, "", nil))
+
+	r.IgnoreQuery = true
+	e := r.Find("GET", "http://example.com/img.png?v=2", nil, "", nil)
+	b, err := e.Response.Content.Body()
+	g.E(err)
+	g.Eq(b, []byte{1, 2, 3})
+}
+
+func TestFindHeadersAndBody(t *testing.T) {
+	g := setup(t)
+
+	r := load(g)
+	r.MatchHeaders = []string{"x-token"}
+	r.MatchBody = true
+
+	header := func(v string) func(string) string {
+		return func(string) string { return v }
+	}
+
+	g.Nil(r.Find("POST", "http://example.com/api", header("b"), `{"id":1}`, nil))
+	g.Nil(r.Find("POST", "http://example.com/api", header("a"), `{"id":2}`, nil))
+	g.Nil(r.Find("POST", "http://example.com/api", nil, `{"id":1}`, nil))
+	g.Eq(r.Find("POST", "http://example.com/api", header("a"), `{"id":1}`, nil).Response.Status, 201)
+}
@@ -0,0 +1,118 @@
+package input
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+var keyAliases = map[string]Key{
+	"ctrl":    ControlLeft,
+	"control": ControlLeft,
+	"shift":   ShiftLeft,
+	"alt":     AltLeft,
+	"option":  AltLeft,
+	"meta":    MetaLeft,
+	"cmd":     MetaLeft,
+	"command": MetaLeft,
+	"enter":   Enter,
+	"return":  Enter,
+	"tab":     Tab,
+	"esc":     Escape,
+	"space":   Space,
+	"del":     Delete,
+	"up":      ArrowUp,
+	"down":    ArrowDown,
+	"left":    ArrowLeft,
+	"right":   ArrowRight,
+}
+
+// KeyByName returns the key by its name, the name is case-insensitive. It can be a character like "a",
+// the [KeyInfo.Key] like "Backspace", the [KeyInfo.Code] like "KeyA", or an alias like "Ctrl", "Cmd", "Esc".
+// The alias "Mod" is "Meta" on macOS, "Control" on the others.
+func KeyByName(name string) (Key, bool) {
+	if utf8.RuneCountInString(name) == 1 {
+		k := Key([]rune(name)[0])
+		return k, k.Defined()
+	}
+
+	lower := strings.ToLower(name)
+
+	if lower == "mod" {
+		if IsMac {
+			return MetaLeft, true
+		}
+		return ControlLeft, true
+	}
+
+	if k, has := keyAliases[lower]; has {
+		return k, true
+	}
+
+	// prefer the main keyboard, then the left side keys
+	var found Key
+	location := -1
+	for k, info := range keyMap {
+		if !strings.EqualFold(info.Key, name) && !strings.EqualFold(info.Code, name) {
+			continue
+		}
+		if location == -1 || info.Location < location {
+			found, location = k, info.Location
+		}
+	}
+
+	return found, location != -1
+}
+
+// ParseChord parses the chord like "Ctrl+A", "Shift+Tab", or "Ctrl++" to keys,
+// the modifiers come first and the main key is the last one. The names of the keys are the same as [KeyByName].
+// A letter will be lower case unless the Shift is in the chord, such as "Ctrl+A" is Control and "a".
+func ParseChord(chord string) ([]Key, error) {
+	var names []string
+	switch {
+	case chord == "+":
+		names = []string{"+"}
+	case strings.HasSuffix(chord, "++"):
+		names = append(strings.Split(strings.TrimSuffix(chord, "++"), "+"), "+")
+	default:
+		names = strings.Split(chord, "+")
+	}
+
+	keys := []Key{}
+	shift := false
+	for i, name := range names {
+		if strings.TrimSpace(name) != "" {
+			name = strings.TrimSpace(name)
+		}
+		k, ok := KeyByName(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown key %q in chord: %q", name, chord)
+		}
+
+		if i == len(names)-1 {
+			if r := rune(k); r >= 'A' && r <= 'Z' {
+				k = Key(r - 'A' + 'a')
+			}
+			if s, has := k.Shift(); has && shift {
+				k = s
+			}
+		} else if k.Modifier() == 0 {
+			return nil, fmt.Errorf("%q is not a modifier in chord: %q", name, chord)
+		} else if k.Modifier() == ModifierShift {
+			shift = true
+		}
+
+		keys = append(keys, k)
+	}
+
+	return keys, nil
+}
+
+// NeedShift returns true if the key is a shifted character, such as "A" or "!".
+func (k Key) NeedShift() bool {
+	if _, has := keyMap[k]; has {
+		return false
+	}
+	_, has := keyMapShifted[k]
+	return has
+}
@@ -0,0 +1,72 @@
+package input_test
+
+import (
+	"testing"
+
+	"github.com/Fromsko/rodPro/lib/input"
+	"github.com/ysmood/got"
+)
+
+func TestKeyByName(t *testing.T) {
+	g := got.T(t)
+
+	check := func(name string, key input.Key) {
+		g.Helper()
+		k, ok := input.KeyByName(name)
+		g.True(ok)
+		g.Eq(k, key)
+	}
+
+	check("a", 'a')
+	check("A", 'A')
+	check("Backspace", input.Backspace)
+	check("backspace", input.Backspace)
+	check("Tab", input.Tab)
+	check("Shift", input.ShiftLeft)
+	check("1", input.Digit1)
+	check("Numpad1", input.Numpad1)
+	check("Esc", input.Escape)
+	check("F5", input.F5)
+
+	old := input.IsMac
+	defer func() { input.IsMac = old }()
+	input.IsMac = true
+	check("Mod", input.MetaLeft)
+	input.IsMac = false
+	check("Mod", input.ControlLeft)
+
+	_, ok := input.KeyByName("NotAKey")
+	g.False(ok)
+
+	_, ok = input.KeyByName("中")
+	g.False(ok)
+}
+
+func TestParseChord(t *testing.T) {
+	g := got.T(t)
+
+	check := func(chord string, keys ...input.Key) {
+		g.Helper()
+		list, err := input.ParseChord(chord)
+		g.E(err)
+		g.Eq(list, keys)
+	}
+
+	check("Ctrl+A", input.ControlLeft, 'a')
+	check("ctrl + shift + a", input.ControlLeft, input.ShiftLeft, 'A')
+	check("Shift+1", input.ShiftLeft, '!')
+	check("Shift+Tab", input.ShiftLeft, input.Tab)
+	check("Enter", input.Enter)
+	check("Ctrl++", input.ControlLeft, '+')
+	check("+", '+')
+
+	_, err := input.ParseChord("Ctrl+Nope")
+	g.Eq(err.Error(), `unknown key "Nope" in chord: "Ctrl+Nope"`)
+
+	_, err = input.ParseChord("A+B")
+	g.Eq(err.Error(), `"A" is not a modifier in chord: "A+B"`)
+
+	g.True(input.Key('A').NeedShift())
+	g.False(input.Key('a').NeedShift())
+	g.False(input.Enter.NeedShift())
+}
@@ -43,6 +43,34 @@ var ElementR = &Function{
 	Dependencies: []*Function{Selectable, Text},
 }
 
+// ElementByLabel ...
+var ElementByLabel = &Function{
+	Name:         "elementByLabel",
+	Definition:   `function(e){const t=e=>(e||"").replace(/\s+/g," ").trim().toLowerCase(),r=t(e);e=functions.selectable(this);for(const l of e.querySelectorAll("label"))if(l.control&&t(l.innerText).includes(r))return l.control;for(const n of e.querySelectorAll("[aria-labelledby]")){var a=n.getAttribute("aria-labelledby").split(/\s+/).map(e=>document.getElementById(e)).filter(e=>e).map(e=>e.innerText).join(" ");if(t(a).includes(r))return n}for(const o of e.querySelectorAll("[aria-label]"))if(t(o.getAttribute("aria-label")).includes(r))return o;return null}`,
+	Dependencies: []*Function{Selectable},
+}
+
+// ElementByPlaceholder ...
+var ElementByPlaceholder = &Function{
+	Name:         "elementByPlaceholder",
+	Definition:   `function(e){const t=e=>(e||"").replace(/\s+/g," ").trim().toLowerCase(),r=t(e);e=functions.selectable(this).querySelectorAll("[placeholder]");return Array.from(e).find(e=>t(e.placeholder).includes(r))||null}`,
+	Dependencies: []*Function{Selectable},
+}
+
+// ElementByTestID ...
+var ElementByTestID = &Function{
+	Name:         "elementByTestID",
+	Definition:   `function(e,t){return functions.selectable(this).querySelector("["+e+'="'+CSS.escape(t)+'"]')}`,
+	Dependencies: []*Function{Selectable},
+}
+
+// ElementNth ...
+var ElementNth = &Function{
+	Name:         "elementNth",
+	Definition:   `function(e,t){e=functions.selectable(this).querySelectorAll(e);return e[t<0?e.length+t:t]||null}`,
+	Dependencies: []*Function{Selectable},
+}
+
 // Parents ...
 var Parents = &Function{
 	Name:         "parents",
@@ -131,14 +159,14 @@ var InputEvent = &Function{
 // InputTime ...
 var InputTime = &Function{
 	Name:         "inputTime",
-	Definition:   `function(e){var e=new Date(e),t=e=>e.toString().padStart(2,"0"),n=e.getFullYear(),r=t(e.getMonth()+1),i=t(e.getDate()),o=t(e.getHours()),s=t(e.getMinutes());switch(this.type){case"date":this.value=n+` + "`" + `-${r}-` + "`" + `+i;break;case"datetime-local":this.value=n+` + "`" + `-${r}-${i}T${o}:` + "`" + `+s;break;case"month":this.value=n+"-"+r;break;case"time":this.value=o+":"+s}functions.inputEvent.call(this)}`,
+	Definition:   `function(e,t){var n=(e,t=2)=>e.toString().padStart(t,"0"),i=n(e.year,4)+"-"+n(e.month)+"-"+n(e.day),r="any"===this.step?0:Number(this.step||60);let a=n(e.hour)+":"+n(e.minute);switch((e.second||e.millisecond)&&r<60&&(a+=":"+n(e.second),e.millisecond&&r<1&&(a+="."+n(e.millisecond,3))),this.type){case"date":this.value=i;break;case"datetime-local":this.value=i+"T"+(t?this.value.split("T")[1]||"00:00":a);break;case"month":this.value=n(e.year,4)+"-"+n(e.month);break;case"week":this.value=n(e.weekYear,4)+"-W"+n(e.week);break;case"time":if(t)throw new Error("cannot input a date to the time input");this.value=a;break;default:throw new Error("unsupported input type: "+this.type)}functions.inputEvent.call(this)}`,
 	Dependencies: []*Function{InputEvent},
 }
 
 // InputColor ...
 var InputColor = &Function{
 	Name:         "inputColor",
-	Definition:   `function(e){this.value=""+e,functions.inputEvent.call(this)}`,
+	Definition:   `function(e){if(!CSS.supports("color",e))throw new Error("invalid color: "+e);var t=document.createElement("canvas").getContext("2d");if(t.fillStyle=e,!t.fillStyle.startsWith("#"))throw new Error("the color input doesn't support transparency: "+e);this.value=t.fillStyle,functions.inputEvent.call(this)}`,
 	Dependencies: []*Function{InputEvent},
 }
 
@@ -149,6 +177,20 @@ var SelectText = &Function{
 	Dependencies: []*Function{},
 }
 
+// CaretToEnd ...
+var CaretToEnd = &Function{
+	Name:         "caretToEnd",
+	Definition:   `function(){this.focus();var e=window.getSelection();e.selectAllChildren(this),e.collapseToEnd()}`,
+	Dependencies: []*Function{},
+}
+
+// PasteRichText ...
+var PasteRichText = &Function{
+	Name:         "pasteRichText",
+	Definition:   `function(t,e){e||(e=(n=(new DOMParser).parseFromString(t,"text/html")).body.innerText||n.body.textContent);var n=new DataTransfer;n.setData("text/plain",e),n.setData("text/html",t);const a=this.innerHTML,i=e=>!this.dispatchEvent(e)||this.innerHTML!==a;i(new ClipboardEvent("paste",{clipboardData:n,bubbles:!0,cancelable:!0}))||i(new InputEvent("beforeinput",{inputType:"insertFromPaste",dataTransfer:n,bubbles:!0,cancelable:!0}))||document.execCommand("insertHTML",!1,t)}`,
+	Dependencies: []*Function{},
+}
+
 // SelectAllText ...
 var SelectAllText = &Function{
 	Name:         "selectAllText",
@@ -156,6 +198,13 @@ var SelectAllText = &Function{
 	Dependencies: []*Function{},
 }
 
+// FormField ...
+var FormField = &Function{
+	Name:         "formField",
+	Definition:   `function(t,r){const n=e=>(e||"").trim().toLowerCase(),l=(e,t)=>Array.from(e.labels||[]).some(e=>n(e.innerText)===n(t));var e,a=Array.from(this.querySelectorAll("input, select, textarea, [contenteditable]"));for(const i of[e=>e.name===t,e=>e.id===t,e=>l(e,t),e=>e.placeholder&&n(e.placeholder)===n(t),e=>n(e.getAttribute("aria-label"))===n(t)]){const o=a.filter(i);if(o.length)return"radio"!==o[0].type?o[0]:(e=String(r),o.find(t=>t.value===e||l(t,e))||null)}return null}`,
+	Dependencies: []*Function{},
+}
+
 // Select ...
 var Select = &Function{
 	Name:         "select",
@@ -163,10 +212,17 @@ var Select = &Function{
 	Dependencies: []*Function{},
 }
 
+// ScrollIntoView ...
+var ScrollIntoView = &Function{
+	Name:         "scrollIntoView",
+	Definition:   `function(t,o,n,l,e){const i=functions.tag(this),r=(e,t)=>e.top>=t.top&&e.bottom<=t.bottom&&e.left>=t.left&&e.right<=t.right;if(!e||!(()=>{var e=i.getBoundingClientRect();if(!r(e,{top:n,left:l,bottom:innerHeight,right:innerWidth}))return!1;for(let t=i.parentElement||i.getRootNode().host;t;){var o;if((t.scrollHeight>t.clientHeight||t.scrollWidth>t.clientWidth)&&("visible"!==(o=getComputedStyle(t)).overflowX||"visible"!==o.overflowY)&&!r(e,t.getBoundingClientRect()))return!1;t=t.parentElement||(t.getRootNode()!==document?t.getRootNode().host:null)}return!0})()){const{scrollMarginTop:s,scrollMarginLeft:c}=i.style;i.style.scrollMarginTop=n+"px",i.style.scrollMarginLeft=l+"px",i.scrollIntoView({block:t,inline:o,behavior:"instant"}),i.style.scrollMarginTop=s,i.style.scrollMarginLeft=c}}`,
+	Dependencies: []*Function{Tag},
+}
+
 // Visible ...
 var Visible = &Function{
 	Name:         "visible",
-	Definition:   `function(){var e=functions.tag(this),t=e.getBoundingClientRect(),e=window.getComputedStyle(e);return"none"!==e.display&&"hidden"!==e.visibility&&!!(t.top||t.bottom||t.width||t.height)}`,
+	Definition:   `function(t){var e=functions.tag(this),i=e.getBoundingClientRect(),e=window.getComputedStyle(e);return"none"!==e.display&&"hidden"!==e.visibility&&(!t||"0"!==e.opacity)&&!!(i.top||i.bottom||i.width||i.height)}`,
 	Dependencies: []*Function{Tag},
 }
 
@@ -205,6 +261,13 @@ var AddStyleTag = &Function{
 	Dependencies: []*Function{},
 }
 
+// InjectScriptTag ...
+var InjectScriptTag = &Function{
+	Name:         "injectScriptTag",
+	Definition:   `function(t,n,c){var e=()=>{var e;document.getElementById(t)||((e=document.createElement("script")).id=t,n?(e.src=n,e.async=!1):e.text=c,(document.head||document.documentElement).appendChild(e))};if(document.documentElement)return e();new MutationObserver((t,n)=>{document.documentElement&&(n.disconnect(),e())}).observe(document,{childList:!0})}`,
+	Dependencies: []*Function{},
+}
+
 // Selectable ...
 var Selectable = &Function{
 	Name:         "selectable",
@@ -232,3 +295,24 @@ var GetXPath = &Function{
 	Definition:   `function(e){class i{constructor(e,t){this.value=e,this.optimized=t||!1}toString(){return this.value}}function o(t){function n(e,t){return e===t||(e.nodeType===Node.ELEMENT_NODE&&t.nodeType===Node.ELEMENT_NODE?e.localName===t.localName:e.nodeType===t.nodeType||(e.nodeType===Node.CDATA_SECTION_NODE?Node.TEXT_NODE:e.nodeType)===(t.nodeType===Node.CDATA_SECTION_NODE?Node.TEXT_NODE:t.nodeType))}var e=t.parentNode,r=e?e.children:null;if(!r)return 0;let i;for(let e=0;e<r.length;++e)if(n(t,r[e])&&r[e]!==t){i=!0;break}if(!i)return 0;let o=1;for(let e=0;e<r.length;++e)if(n(t,r[e])){if(r[e]===t)return o;++o}return-1}if(this.nodeType===Node.DOCUMENT_NODE)return"/";var t=[];let n=this;for(;n;){var r=function(e,t){let n;var r=o(e);if(-1===r)return null;switch(e.nodeType){case Node.ELEMENT_NODE:if(t&&e.id)return new i(` + "`" + `//*[@id='${e.id}']` + "`" + `,!0);n=e.localName;break;case Node.ATTRIBUTE_NODE:n="@"+e.nodeName;break;case Node.TEXT_NODE:case Node.CDATA_SECTION_NODE:n="text()";break;case Node.PROCESSING_INSTRUCTION_NODE:n="processing-instruction()";break;case Node.COMMENT_NODE:n="comment()";break;default:Node.DOCUMENT_NODE;n=""}return 0<r&&(n+=` + "`" + `[${r}]` + "`" + `),new i(n,e.nodeType===Node.DOCUMENT_NODE)}(n,e);if(!r)break;if(t.push(r),r.optimized)break;n=n.parentNode}return t.reverse(),(t.length&&t[0].optimized?"":"/")+t.join("/")}`,
 	Dependencies: []*Function{},
 }
+
+// LoadLazy ...
+var LoadLazy = &Function{
+	Name:         "loadLazy",
+	Definition:   `async function(e){document.querySelectorAll('[loading="lazy"]').forEach(e=>{e.loading="eager"});var{scrollX:t,scrollY:o}=window,n=()=>document.documentElement.scrollHeight;for(let o=0;o<n();o+=window.innerHeight)window.scrollTo(t,o),await new Promise(t=>setTimeout(t,e));window.scrollTo(t,o)}`,
+	Dependencies: []*Function{},
+}
+
+// WaitAssets ...
+var WaitAssets = &Function{
+	Name:         "waitAssets",
+	Definition:   `async function(){await Promise.all(Array.from(document.images).map(t=>t.complete?null:new Promise(e=>{t.addEventListener("load",e),t.addEventListener("error",e)}))),await document.fonts.ready}`,
+	Dependencies: []*Function{},
+}
+
+// Extract ...
+var Extract = &Function{
+	Name:         "extract",
+	Definition:   `function(e){const r=this.querySelectorAll?this:document,l=(e,r)=>r?"html"===r?e.innerHTML:e.getAttribute(r):(e.innerText??e.textContent).trim();var t,n={};for([t,{selector:o,attr:c,all:u}]of Object.entries(e)){var o,c,u,a;u?n[t]=Array.from(r.querySelectorAll(o)).map(e=>l(e,c)):(a=r.querySelector(o),n[t]=a?l(a,c):null)}return n}`,
+	Dependencies: []*Function{},
+}
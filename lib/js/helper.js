@@ -101,6 +101,49 @@ const functions = {
     return el ? el : null
   },
 
+  elementByLabel(text) {
+    const norm = (s) => (s || '').replace(/\s+/g, ' ').trim().toLowerCase()
+    const t = norm(text)
+    const s = functions.selectable(this)
+
+    for (const label of s.querySelectorAll('label')) {
+      if (label.control && norm(label.innerText).includes(t)) return label.control
+    }
+
+    for (const el of s.querySelectorAll('[aria-labelledby]')) {
+      const label = el
+        .getAttribute('aria-labelledby')
+        .split(/\s+/)
+        .map((id) => document.getElementById(id))
+        .filter((e) => e)
+        .map((e) => e.innerText)
+        .join(' ')
+      if (norm(label).includes(t)) return el
+    }
+
+    for (const el of s.querySelectorAll('[aria-label]')) {
+      if (norm(el.getAttribute('aria-label')).includes(t)) return el
+    }
+
+    return null
+  },
+
+  elementByPlaceholder(text) {
+    const norm = (s) => (s || '').replace(/\s+/g, ' ').trim().toLowerCase()
+    const t = norm(text)
+    const list = functions.selectable(this).querySelectorAll('[placeholder]')
+    return Array.from(list).find((el) => norm(el.placeholder).includes(t)) || null
+  },
+
+  elementByTestID(attr, id) {
+    return functions.selectable(this).querySelector(`[${attr}="${CSS.escape(id)}"]`)
+  },
+
+  elementNth(selector, n) {
+    const list = functions.selectable(this).querySelectorAll(selector)
+    return list[n < 0 ? list.length + n : n] || null
+  },
+
   parents(selector) {
     let p = this.parentElement
     const list = []
@@ -265,40 +308,57 @@ const functions = {
     this.dispatchEvent(new Event('change', { bubbles: true }))
   },
 
-  inputTime(stamp) {
-    const time = new Date(stamp)
+  inputTime(t, dateOnly) {
+    const pad = (n, l = 2) => n.toString().padStart(l, '0')
 
-    const pad = (n) => n.toString().padStart(2, '0')
+    const date = `${pad(t.year, 4)}-${pad(t.month)}-${pad(t.day)}`
 
-    const y = time.getFullYear()
-    const mon = pad(time.getMonth() + 1)
-    const d = pad(time.getDate())
-    const h = pad(time.getHours())
-    const min = pad(time.getMinutes())
+    // only keep the seconds when the step of the input allows them
+    const step = this.step === 'any' ? 0 : Number(this.step || 60)
+    let time = `${pad(t.hour)}:${pad(t.minute)}`
+    if ((t.second || t.millisecond) && step < 60) {
+      time += `:${pad(t.second)}`
+      if (t.millisecond && step < 1) time += `.${pad(t.millisecond, 3)}`
+    }
 
     switch (this.type) {
       case 'date':
-        this.value = `${y}-${mon}-${d}`
+        this.value = date
         break
       case 'datetime-local':
-        this.value = `${y}-${mon}-${d}T${h}:${min}`
+        this.value = `${date}T${dateOnly ? this.value.split('T')[1] || '00:00' : time}`
         break
       case 'month':
-        this.value = `${y}-${mon}`
+        this.value = `${pad(t.year, 4)}-${pad(t.month)}`
+        break
+      case 'week':
+        this.value = `${pad(t.weekYear, 4)}-W${pad(t.week)}`
         break
       case 'time':
-        this.value = `${h}:${min}`
+        if (dateOnly) throw new Error('cannot input a date to the time input')
+        this.value = time
         break
+      default:
+        throw new Error(`unsupported input type: ${this.type}`)
     }
 
     functions.inputEvent.call(this)
   },
 
   inputColor(color) {
-    this.value = `${color}`
+    if (!CSS.supports('color', color)) throw new Error(`invalid color: ${color}`)
+
+    // normalize the color to the "#rrggbb" format that the color input requires
+    const ctx = document.createElement('canvas').getContext('2d')
+    ctx.fillStyle = color
+    if (!ctx.fillStyle.startsWith('#')) {
+      throw new Error(`the color input doesn't support transparency: ${color}`)
+    }
+    this.value = ctx.fillStyle
 
     functions.inputEvent.call(this)
   },
+
   selectText(pattern) {
     const m = this.value.match(new RegExp(pattern))
     if (m) {
@@ -306,10 +366,75 @@ const functions = {
     }
   },
 
+  caretToEnd() {
+    this.focus()
+    const sel = window.getSelection()
+    sel.selectAllChildren(this)
+    sel.collapseToEnd()
+  },
+
+  pasteRichText(html, text) {
+    if (!text) {
+      const doc = new DOMParser().parseFromString(html, 'text/html')
+      text = doc.body.innerText || doc.body.textContent
+    }
+
+    const data = new DataTransfer()
+    data.setData('text/plain', text)
+    data.setData('text/html', html)
+
+    const before = this.innerHTML
+    const handled = (e) => !this.dispatchEvent(e) || this.innerHTML !== before
+
+    // the editors like ProseMirror, Quill, and CKEditor handle the paste event by themselves
+    if (handled(new ClipboardEvent('paste', { clipboardData: data, bubbles: true, cancelable: true }))) {
+      return
+    }
+
+    const beforeInput = new InputEvent('beforeinput', {
+      inputType: 'insertFromPaste',
+      dataTransfer: data,
+      bubbles: true,
+      cancelable: true,
+    })
+    if (handled(beforeInput)) return
+
+    document.execCommand('insertHTML', false, html)
+  },
+
   selectAllText() {
     this.select()
   },
 
+  formField(key, value) {
+    const norm = (s) => (s || '').trim().toLowerCase()
+    const hasLabel = (el, text) => Array.from(el.labels || []).some((l) => norm(l.innerText) === norm(text))
+
+    const fields = Array.from(this.querySelectorAll('input, select, textarea, [contenteditable]'))
+    const matchers = [
+      (el) => el.name === key,
+      (el) => el.id === key,
+      (el) => hasLabel(el, key),
+      (el) => el.placeholder && norm(el.placeholder) === norm(key),
+      (el) => norm(el.getAttribute('aria-label')) === norm(key),
+    ]
+
+    for (const match of matchers) {
+      const list = fields.filter(match)
+      if (!list.length) continue
+
+      // for the radio group, find the one that matches the value or its label
+      if (list[0].type === 'radio') {
+        const v = String(value)
+        return list.find((el) => el.value === v || hasLabel(el, v)) || null
+      }
+
+      return list[0]
+    }
+
+    return null
+  },
+
   select(selectors, selected, type) {
     let matchers
     switch (type) {
@@ -344,13 +469,49 @@ const functions = {
     return has
   },
 
-  visible() {
+  scrollIntoView(block, inline, offsetTop, offsetLeft, ifNeeded) {
+    const el = functions.tag(this)
+
+    const inside = (r, box) =>
+      r.top >= box.top && r.bottom <= box.bottom && r.left >= box.left && r.right <= box.right
+
+    const visible = () => {
+      const r = el.getBoundingClientRect()
+      const viewport = { top: offsetTop, left: offsetLeft, bottom: innerHeight, right: innerWidth }
+      if (!inside(r, viewport)) return false
+
+      // check the scrollable ancestors, the shadow roots are crossed via their hosts
+      for (let p = el.parentElement || el.getRootNode().host; p; ) {
+        if (p.scrollHeight > p.clientHeight || p.scrollWidth > p.clientWidth) {
+          const style = getComputedStyle(p)
+          if (style.overflowX !== 'visible' || style.overflowY !== 'visible') {
+            if (!inside(r, p.getBoundingClientRect())) return false
+          }
+        }
+        p = p.parentElement || (p.getRootNode() !== document ? p.getRootNode().host : null)
+      }
+      return true
+    }
+
+    if (ifNeeded && visible()) return
+
+    // use scroll-margin so that the element won't be covered by the sticky headers
+    const { scrollMarginTop, scrollMarginLeft } = el.style
+    el.style.scrollMarginTop = offsetTop + 'px'
+    el.style.scrollMarginLeft = offsetLeft + 'px'
+    el.scrollIntoView({ block, inline, behavior: 'instant' })
+    el.style.scrollMarginTop = scrollMarginTop
+    el.style.scrollMarginLeft = scrollMarginLeft
+  },
+
+  visible(opaque) {
     const el = functions.tag(this)
     const box = el.getBoundingClientRect()
     const style = window.getComputedStyle(el)
     return (
       style.display !== 'none' &&
       style.visibility !== 'hidden' &&
+      (!opaque || style.opacity !== '0') &&
       !!(box.top || box.bottom || box.width || box.height)
     )
   },
@@ -430,6 +591,33 @@ const functions = {
     })
   },
 
+  injectScriptTag(id, url, content) {
+    var add = () => {
+      if (document.getElementById(id)) return
+
+      var s = document.createElement('script')
+      s.id = id
+
+      if (url) {
+        s.src = url
+        s.async = false
+      } else {
+        s.text = content
+      }
+
+      ;(document.head || document.documentElement).appendChild(s)
+    }
+
+    if (document.documentElement) return add()
+
+    // the observer runs before the first parser inserted script of the document
+    new MutationObserver((_, observer) => {
+      if (!document.documentElement) return
+      observer.disconnect()
+      add()
+    }).observe(document, { childList: true })
+  },
+
   selectable(s) {
     return s.querySelector ? s : document
   },
@@ -568,5 +756,54 @@ const functions = {
     }
     steps.reverse()
     return (steps.length && steps[0].optimized ? '' : '/') + steps.join('/')
+  },
+
+  async loadLazy(step) {
+    document.querySelectorAll('[loading="lazy"]').forEach((el) => {
+      el.loading = 'eager'
+    })
+
+    // scroll through the page to trigger the IntersectionObserver based lazy loaders
+    const { scrollX, scrollY } = window
+    const height = () => document.documentElement.scrollHeight
+    for (let y = 0; y < height(); y += window.innerHeight) {
+      window.scrollTo(scrollX, y)
+      await new Promise((r) => setTimeout(r, step))
+    }
+    window.scrollTo(scrollX, scrollY)
+  },
+
+  async waitAssets() {
+    await Promise.all(
+      Array.from(document.images).map((img) =>
+        img.complete
+          ? null
+          : new Promise((r) => {
+              img.addEventListener('load', r)
+              img.addEventListener('error', r)
+            })
+      )
+    )
+    await document.fonts.ready
+  },
+
+  extract(fields) {
+    const root = this.querySelectorAll ? this : document
+    const get = (el, attr) => {
+      if (!attr) return (el.innerText ?? el.textContent).trim()
+      if (attr === 'html') return el.innerHTML
+      return el.getAttribute(attr)
+    }
+
+    const res = {}
+    for (const [key, { selector, attr, all }] of Object.entries(fields)) {
+      if (all) {
+        res[key] = Array.from(root.querySelectorAll(selector)).map((el) => get(el, attr))
+      } else {
+        const el = root.querySelector(selector)
+        res[key] = el ? get(el, attr) : null
+      }
+    }
+    return res
   }
 }
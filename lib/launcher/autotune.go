@@ -0,0 +1,168 @@
+package launcher
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/Fromsko/rodPro/lib/launcher/flags"
+	"github.com/Fromsko/rodPro/lib/utils"
+)
+
+// Environment that affects how the browser should be launched
+type Environment struct {
+	// OS is the same as runtime.GOOS
+	OS string
+
+	// Container is true if inside a container, such as docker, podman, or kubernetes
+	Container bool
+
+	// Root is true if the process runs as root user
+	Root bool
+
+	// WSL is true if inside Windows Subsystem for Linux
+	WSL bool
+
+	// WSLg is true if the WSLg GUI support is available
+	WSLg bool
+
+	// ShmSize is the size of /dev/shm in bytes, 0 means unknown
+	ShmSize int64
+
+	// Display is the X11 DISPLAY or the WAYLAND_DISPLAY
+	Display string
+
+	// XVFB is true if the xvfb-run command is available
+	XVFB bool
+}
+
+// DetectEnvironment of current process
+func DetectEnvironment() *Environment {
+	env := &Environment{
+		OS:        runtime.GOOS,
+		Container: utils.InContainer,
+		Root:      os.Geteuid() == 0,
+		ShmSize:   shmSize(),
+		Display:   os.Getenv("DISPLAY"),
+	}
+
+	if env.Display == "" {
+		env.Display = os.Getenv("WAYLAND_DISPLAY")
+	}
+
+	if env.OS == "linux" {
+		version, _ := ioutil.ReadFile("/proc/version")
+		env.WSL = os.Getenv("WSL_DISTRO_NAME") != "" || strings.Contains(strings.ToLower(string(version)), "microsoft")
+		info, err := os.Stat("/mnt/wslg")
+		env.WSLg = env.WSL && err == nil && info.IsDir()
+	}
+
+	_, err := exec.LookPath("xvfb-run")
+	env.XVFB = err == nil
+
+	return env
+}
+
+// the size of /dev/shm from the mount options, such as "size=65536k"
+func shmSize() int64 {
+	b, err := ioutil.ReadFile("/proc/mounts")
+	if err != nil {
+		return 0
+	}
+
+	for _, line := range strings.Split(string(b), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 || fields[1] != "/dev/shm" {
+			continue
+		}
+		for _, opt := range strings.Split(fields[3], ",") {
+			if strings.HasPrefix(opt, "size=") {
+				return parseSize(strings.TrimPrefix(opt, "size="))
+			}
+		}
+	}
+	return 0
+}
+
+func parseSize(s string) int64 {
+	if s == "" {
+		return 0
+	}
+
+	unit := int64(1)
+	switch strings.ToLower(s[len(s)-1:]) {
+	case "k":
+		unit = 1 << 10
+	case "m":
+		unit = 1 << 20
+	case "g":
+		unit = 1 << 30
+	}
+	n, _ := strconv.ParseInt(strings.TrimRight(s, "kKmMgG"), 10, 64)
+	return n * unit
+}
+
+// the default /dev/shm of docker is 64MB, which is too small for the browser
+const minShmSize = 512 << 20
+
+// Apply the safe defaults for the environment to the launcher, it returns the report of what is applied.
+func (env *Environment) Apply(l *Launcher) (report []string) {
+	if env.OS == "linux" && (env.Container || env.Root) && !l.Has(flags.NoSandbox) {
+		l.Set(flags.NoSandbox)
+		report = append(report, "no-sandbox: the sandbox doesn't work in unprivileged containers or as root")
+	}
+
+	if env.ShmSize > 0 && env.ShmSize < minShmSize && !l.Has(flags.DisableDevShmUsage) {
+		l.Set(flags.DisableDevShmUsage)
+		report = append(report, "disable-dev-shm-usage: /dev/shm is only "+strconv.FormatInt(env.ShmSize>>20, 10)+"MB")
+	}
+
+	if env.Container && !l.Has(flags.DisableGPU) && !l.Has(flags.UseGL) {
+		l.Set(flags.DisableGPU)
+		report = append(report, "disable-gpu: containers usually have no gpu")
+	}
+
+	if env.OS != "linux" || l.Has(flags.Headless) || l.Has(flags.XVFB) || env.Display != "" {
+		return
+	}
+
+	// headful mode without display
+	switch {
+	case env.WSLg:
+		l.setEnv("DISPLAY=:0", "WAYLAND_DISPLAY=wayland-0")
+		report = append(report, "env DISPLAY=:0 WAYLAND_DISPLAY=wayland-0: use the display of WSLg")
+	case env.XVFB:
+		l.XVFB()
+		report = append(report, "xvfb: no display for headful mode, use xvfb-run")
+	default:
+		l.HeadlessMode(HeadlessNew)
+		report = append(report, "headless=new: no display for headful mode")
+	}
+
+	return
+}
+
+// the Env flag replaces the whole environment of the browser process, so we inherit the current one
+func (l *Launcher) setEnv(pairs ...string) {
+	env, has := l.GetFlags(flags.Env)
+	if !has {
+		env = os.Environ()
+	}
+	l.Set(flags.Env, append(env, pairs...)...)
+}
+
+// AutoTune switch. If enabled, the launcher will detect the environment before launch and apply safe defaults,
+// such as no-sandbox inside containers, the workaround for small /dev/shm, and the display handling under WSLg.
+// Use [Launcher.AutoTuneReport] to get what is applied.
+func (l *Launcher) AutoTune(enable bool) *Launcher {
+	l.autoTune = enable
+	return l
+}
+
+// AutoTuneReport returns what [Launcher.AutoTune] applied, it's available after launch.
+func (l *Launcher) AutoTuneReport() []string {
+	return l.autoTuneReport
+}
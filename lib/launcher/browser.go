@@ -93,8 +93,16 @@ type Browser struct {
 	// LockPort a tcp port to prevent race downloading. Default is 2968 .
 	LockPort int
 
-	// HTTPClient to download the browser
+	// HTTPClient to download the browser. By default the proxy is read from the
+	// HTTP_PROXY, HTTPS_PROXY, or ALL_PROXY env vars, socks5 proxies are supported too.
+	// Check [ProxyFromEnvironment] for details.
 	HTTPClient *http.Client
+
+	// Checksum returns the expected hex encoded sha256 of the archive for the revision,
+	// the u is the url of the archive, because the hosts may serve different archives for the same revision.
+	// The archive will be verified before extraction. If it returns empty string,
+	// the md5 reported by the host will be used when available.
+	Checksum func(revision int, u string) string
 }
 
 // NewBrowser with default values
@@ -126,6 +134,7 @@ func (lc *Browser) BinPath() string {
 }
 
 // Download browser from the fastest host. It will race downloading a TCP packet from each host and use the fastest host.
+// If the download fails the other hosts will be tried one by one. The unfinished download will be resumed next time.
 func (lc *Browser) Download() error {
 	us := []string{}
 	for _, host := range lc.Hosts {
@@ -137,16 +146,21 @@ func (lc *Browser) Download() error {
 	fu := fetchup.New(dir, us...)
 	fu.Ctx = lc.Context
 	fu.Logger = lc.Logger
-	if lc.HTTPClient != nil {
-		fu.HttpClient = lc.HTTPClient
-	}
+	fu.HttpClient = lc.httpClient()
 
-	err := fu.Fetch()
-	if err != nil {
-		return fmt.Errorf("Can't find a browser binary for your OS, the doc might help https://go-rod.github.io/#/compatibility?id=os : %w", err)
+	errs := []error{}
+	for _, u := range lc.mirrors(fu) {
+		err := lc.downloadFrom(fu, u)
+		if err == nil {
+			return fetchup.StripFirstDir(dir)
+		}
+		lc.Logger.Println("failed to download from", u, err)
+		errs = append(errs, err)
+
+		_ = os.RemoveAll(dir)
 	}
 
-	return fetchup.StripFirstDir(dir)
+	return fmt.Errorf("Can't find a browser binary for your OS, the doc might help https://go-rod.github.io/#/compatibility?id=os : %w", errDownload(us, errs))
 }
 
 // Get is a smart helper to get the browser executable path.
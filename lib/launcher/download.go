@@ -0,0 +1,339 @@
+package launcher
+
+import (
+	"compress/gzip"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ysmood/fetchup"
+)
+
+// ErrChecksum is returned when the downloaded archive doesn't match the expected checksum.
+type ErrChecksum struct {
+	URL      string
+	Expected string
+	Actual   string
+}
+
+func (e *ErrChecksum) Error() string {
+	return fmt.Sprintf("checksum mismatch for %s, expected %s but got %s", e.URL, e.Expected, e.Actual)
+}
+
+// Is interface
+func (e *ErrChecksum) Is(err error) bool {
+	_, ok := err.(*ErrChecksum)
+	return ok
+}
+
+// ProxyFromEnvironment is like [http.ProxyFromEnvironment], but it also falls back to
+// the ALL_PROXY env var. The proxy URL can use the http, https, or socks5 scheme,
+// such as "socks5://127.0.0.1:1080". The NO_PROXY env var is respected by the fallback too.
+func ProxyFromEnvironment(req *http.Request) (*url.URL, error) {
+	u, err := http.ProxyFromEnvironment(req)
+	if u != nil || err != nil {
+		return u, err
+	}
+
+	all := getEnv("ALL_PROXY")
+	if all == "" || !useProxy(req.URL) {
+		return nil, nil
+	}
+
+	return url.Parse(all)
+}
+
+func getEnv(name string) string {
+	if val := os.Getenv(name); val != "" {
+		return val
+	}
+	return os.Getenv(strings.ToLower(name))
+}
+
+// useProxy returns false if the url matches the NO_PROXY env var, or the host is localhost or a loopback address,
+// the rules are the same as [http.ProxyFromEnvironment].
+func useProxy(u *url.URL) bool {
+	host := strings.ToLower(u.Hostname())
+	ip := net.ParseIP(host)
+	if host == "localhost" || (ip != nil && ip.IsLoopback()) {
+		return false
+	}
+
+	port := u.Port()
+	if port == "" {
+		port = map[string]string{"http": "80", "https": "443"}[u.Scheme]
+	}
+
+	for _, p := range strings.Split(getEnv("NO_PROXY"), ",") {
+		p = strings.ToLower(strings.TrimSpace(p))
+		if p == "" {
+			continue
+		}
+		if p == "*" {
+			return false
+		}
+
+		if _, cidr, err := net.ParseCIDR(p); err == nil {
+			if ip != nil && cidr.Contains(ip) {
+				return false
+			}
+			continue
+		}
+
+		pHost, pPort := p, ""
+		if h, pt, err := net.SplitHostPort(p); err == nil {
+			pHost, pPort = h, pt
+		}
+		if pPort != "" && pPort != port {
+			continue
+		}
+
+		if pIP := net.ParseIP(pHost); pIP != nil {
+			if ip != nil && pIP.Equal(ip) {
+				return false
+			}
+			continue
+		}
+
+		// "example.com" matches the domain and its subdomains, ".example.com" only matches the subdomains
+		pHost = strings.TrimPrefix(pHost, "*")
+		if host == pHost || strings.HasSuffix(host, "."+strings.TrimPrefix(pHost, ".")) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (lc *Browser) httpClient() *http.Client {
+	if lc.HTTPClient != nil {
+		return lc.HTTPClient
+	}
+
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.Proxy = ProxyFromEnvironment
+
+	return &http.Client{Transport: &uaTransport{t}}
+}
+
+type uaTransport struct {
+	t http.RoundTripper
+}
+
+func (t *uaTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/111.0.0.0 Safari/537.36")
+	return t.t.RoundTrip(req)
+}
+
+// mirrors returns the urls of the hosts, the fastest one comes first.
+func (lc *Browser) mirrors(fu *fetchup.Fetchup) []string {
+	fastest := fu.FastestURL()
+
+	list := []string{}
+	if fastest != "" {
+		list = append(list, fastest)
+	}
+	for _, u := range fu.URLs {
+		if u != fastest {
+			list = append(list, u)
+		}
+	}
+	return list
+}
+
+// partPath is the path to save the unfinished archive of the url, so that it can be resumed.
+func (lc *Browser) partPath(u string) string {
+	return fmt.Sprintf("%s-%x.part", lc.Dir(), md5.Sum([]byte(u)))
+}
+
+// downloadFrom downloads the archive of u into a part file, verifies and extracts it.
+func (lc *Browser) downloadFrom(fu *fetchup.Fetchup, u string) error {
+	part := lc.partPath(u)
+
+	header, err := lc.fetchPart(fu, u, part)
+	if err != nil {
+		return err
+	}
+
+	err = lc.verify(u, part, header)
+	if err != nil {
+		// the part is corrupted, no need to resume it next time
+		_ = os.Remove(part)
+		return err
+	}
+
+	err = lc.extract(fu, u, part)
+	if err != nil {
+		return err
+	}
+
+	return os.Remove(part)
+}
+
+// fetchPart downloads u to the part file, if the part file already exists the download will be resumed.
+func (lc *Browser) fetchPart(fu *fetchup.Fetchup, u, part string) (http.Header, error) {
+	err := os.MkdirAll(filepath.Dir(part), 0o755)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(part, os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	offset := info.Size()
+
+	req, err := http.NewRequestWithContext(lc.Context, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	res, err := fu.HttpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	switch res.StatusCode {
+	case http.StatusPartialContent:
+		lc.Logger.Println(fetchup.EventDownload, u, "resume from", offset)
+		_, err = f.Seek(offset, io.SeekStart)
+	case http.StatusOK:
+		lc.Logger.Println(fetchup.EventDownload, u)
+		err = f.Truncate(0)
+	case http.StatusRequestedRangeNotSatisfiable:
+		// the part is already complete
+		return res.Header, nil
+	default:
+		b, _ := io.ReadAll(res.Body)
+		return nil, fmt.Errorf("failed to download the browser from %s: %d %s", u, res.StatusCode, string(b))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var body io.Reader = res.Body
+	if res.Header.Get("Content-Encoding") == "gzip" {
+		body, err = gzip.NewReader(res.Body)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	_, err = io.Copy(f, body)
+	if err != nil {
+		return nil, err
+	}
+
+	lc.Logger.Println(fetchup.EventDownloaded, part)
+
+	return res.Header, nil
+}
+
+// verify the part file with [Browser.Checksum], if it's empty the md5 in the
+// "x-goog-hash" header of the response will be used when available.
+func (lc *Browser) verify(u, part string, header http.Header) error {
+	var h hash.Hash
+	var expected string
+	var encode func([]byte) string
+
+	if lc.Checksum != nil {
+		expected = strings.ToLower(lc.Checksum(lc.Revision, u))
+	}
+
+	if expected != "" {
+		h, encode = sha256.New(), hex.EncodeToString
+	} else if md5sum := googHash(header, "md5"); md5sum != "" {
+		expected, h, encode = md5sum, md5.New(), base64.StdEncoding.EncodeToString
+	} else {
+		return nil
+	}
+
+	f, err := os.Open(part)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	_, err = io.Copy(h, f)
+	if err != nil {
+		return err
+	}
+
+	actual := encode(h.Sum(nil))
+	if actual != expected {
+		return &ErrChecksum{u, expected, actual}
+	}
+	return nil
+}
+
+// googHash parses the header like "x-goog-hash: crc32c=xxx, md5=xxx".
+func googHash(header http.Header, typ string) string {
+	for _, v := range header.Values("X-Goog-Hash") {
+		for _, s := range strings.Split(v, ",") {
+			if k, val, ok := strings.Cut(strings.TrimSpace(s), "="); ok && k == typ {
+				return val
+			}
+		}
+	}
+	return ""
+}
+
+func (lc *Browser) extract(fu *fetchup.Fetchup, u, part string) error {
+	f, err := os.Open(part)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	p := strings.ToLower(u)
+	if i := strings.IndexAny(p, "?#"); i > -1 {
+		p = p[:i]
+	}
+
+	switch {
+	case strings.HasSuffix(p, ".tar.gz"), strings.HasSuffix(p, ".tgz"):
+		gr, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		return fu.UnTar(gr)
+	case strings.HasSuffix(p, ".tar"):
+		return fu.UnTar(f)
+	default:
+		return fu.UnZip(f)
+	}
+}
+
+// errDownload wraps the error of the first tried mirror, which is the fastest one,
+// the errors of the other mirrors are appended to the message.
+func errDownload(us []string, errs []error) error {
+	if len(errs) == 0 {
+		return &fetchup.ErrNoURLs{URLs: us}
+	}
+
+	msgs := []string{}
+	for _, err := range errs {
+		msgs = append(msgs, err.Error())
+	}
+	return fmt.Errorf("%w (all errors: %s)", errs[0], strings.Join(msgs, "; "))
+}
@@ -4,3 +4,9 @@ import "errors"
 
 // ErrAlreadyLaunched is an error that indicates the launcher has already been launched.
 var ErrAlreadyLaunched = errors.New("already launched")
+
+// ErrNoActivePort is an error that indicates no running browser is found via the DevToolsActivePort file.
+var ErrNoActivePort = errors.New("no running browser found via the DevToolsActivePort file")
+
+// ErrUserDataDirNotFound is an error that indicates the user data dir of the browser is not found.
+var ErrUserDataDirNotFound = errors.New("user data dir not found")
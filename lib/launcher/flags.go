@@ -0,0 +1,198 @@
+package launcher
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/Fromsko/rodPro/lib/launcher/flags"
+)
+
+// Flags of the command line arguments, the key is the flag name, the values will be joined with comma.
+// Use the typed methods to set the common switches, such as [Flags.DisableGPU].
+type Flags map[flags.Flag][]string
+
+func (f Flags) toggle(name flags.Flag, enable bool, values ...string) Flags {
+	if enable {
+		f[name] = values
+	} else {
+		delete(f, name)
+	}
+	return f
+}
+
+func (f Flags) appendUniq(name flags.Flag, values ...string) Flags {
+	list := f[name]
+	for _, v := range values {
+		has := false
+		for _, item := range list {
+			if item == v {
+				has = true
+				break
+			}
+		}
+		if !has {
+			list = append(list, v)
+		}
+	}
+	f[name] = list
+	return f
+}
+
+// DisableGPU switch
+func (f Flags) DisableGPU(enable bool) Flags {
+	return f.toggle(flags.DisableGPU, enable)
+}
+
+// NoSandbox switch
+func (f Flags) NoSandbox(enable bool) Flags {
+	return f.toggle(flags.NoSandbox, enable)
+}
+
+// WindowSize of the browser window
+func (f Flags) WindowSize(width, height int) Flags {
+	return f.toggle(flags.WindowSize, true, strconv.Itoa(width), strconv.Itoa(height))
+}
+
+// WindowPosition of the browser window
+func (f Flags) WindowPosition(x, y int) Flags {
+	return f.toggle(flags.WindowPosition, true, strconv.Itoa(x), strconv.Itoa(y))
+}
+
+// UserAgent of the browser, empty string to remove the flag
+func (f Flags) UserAgent(ua string) Flags {
+	return f.toggle(flags.UserAgent, ua != "", ua)
+}
+
+// Lang of the browser UI and the Accept-Language header, such as "en-US", empty string to remove the flag
+func (f Flags) Lang(lang string) Flags {
+	return f.toggle(flags.Lang, lang != "", lang)
+}
+
+// ProxyServer of the browser, empty string to remove the flag
+func (f Flags) ProxyServer(host string) Flags {
+	return f.toggle(flags.ProxyServer, host != "", host)
+}
+
+// ProxyBypassList appends the hosts that bypass the proxy
+func (f Flags) ProxyBypassList(hosts ...string) Flags {
+	return f.appendUniq(flags.ProxyBypassList, hosts...)
+}
+
+// DisableExtensions switch
+func (f Flags) DisableExtensions(enable bool) Flags {
+	return f.toggle(flags.DisableExtensions, enable)
+}
+
+// MuteAudio switch
+func (f Flags) MuteAudio(enable bool) Flags {
+	return f.toggle(flags.MuteAudio, enable)
+}
+
+// StartMaximized switch
+func (f Flags) StartMaximized(enable bool) Flags {
+	return f.toggle(flags.StartMaximized, enable)
+}
+
+// StartFullscreen switch
+func (f Flags) StartFullscreen(enable bool) Flags {
+	return f.toggle(flags.StartFullscreen, enable)
+}
+
+// Kiosk switch
+func (f Flags) Kiosk(enable bool) Flags {
+	return f.toggle(flags.Kiosk, enable)
+}
+
+// HideScrollbars switch
+func (f Flags) HideScrollbars(enable bool) Flags {
+	return f.toggle(flags.HideScrollbars, enable)
+}
+
+// IgnoreCertificateErrors switch
+func (f Flags) IgnoreCertificateErrors(enable bool) Flags {
+	return f.toggle(flags.IgnoreCertificateErrors, enable)
+}
+
+// DisableWebSecurity switch, such as to disable the same-origin policy
+func (f Flags) DisableWebSecurity(enable bool) Flags {
+	return f.toggle(flags.DisableWebSecurity, enable)
+}
+
+// AutoOpenDevTools switch
+func (f Flags) AutoOpenDevTools(enable bool) Flags {
+	return f.toggle(flags.AutoOpenDevTools, enable)
+}
+
+// Incognito switch
+func (f Flags) Incognito(enable bool) Flags {
+	return f.toggle(flags.Incognito, enable)
+}
+
+// EnableFeatures appends the features to enable
+func (f Flags) EnableFeatures(features ...string) Flags {
+	return f.appendUniq(flags.EnableFeatures, features...)
+}
+
+// DisableFeatures appends the features to disable
+func (f Flags) DisableFeatures(features ...string) Flags {
+	return f.appendUniq(flags.DisableFeatures, features...)
+}
+
+// DisableBlinkFeatures appends the blink features to disable, such as "AutomationControlled"
+func (f Flags) DisableBlinkFeatures(features ...string) Flags {
+	return f.appendUniq(flags.DisableBlinkFeatures, features...)
+}
+
+// Validate returns the warnings of the conflicting flags.
+// The unknown flags are not warned, because the list of known flags is only a part of the browser switches,
+// use [flags.Flag.Known] to check a flag.
+func (f Flags) Validate() []string {
+	warnings := []string{}
+
+	for _, pair := range flags.Conflicts {
+		_, a := f[pair[0]]
+		_, b := f[pair[1]]
+		if a && b {
+			warnings = append(warnings, fmt.Sprintf("conflicting flags: --%s and --%s", pair[0], pair[1]))
+		}
+	}
+
+	for _, feature := range f[flags.EnableFeatures] {
+		for _, disabled := range f[flags.DisableFeatures] {
+			if feature == disabled {
+				warnings = append(warnings, fmt.Sprintf("feature both enabled and disabled: %s", feature))
+			}
+		}
+	}
+
+	return warnings
+}
+
+// Diff the command line arguments of the launcher with the base, such as the [New] launcher.
+// The added are the arguments that only the launcher has, the removed are the ones that only the base has.
+func (l *Launcher) Diff(base *Launcher) (added, removed []string) {
+	a := l.FormatArgs()
+	b := base.FormatArgs()
+
+	in := func(list []string, s string) bool {
+		for _, item := range list {
+			if item == s {
+				return true
+			}
+		}
+		return false
+	}
+
+	added, removed = []string{}, []string{}
+	for _, arg := range a {
+		if !in(b, arg) {
+			added = append(added, arg)
+		}
+	}
+	for _, arg := range b {
+		if !in(a, arg) {
+			removed = append(removed, arg)
+		}
+	}
+	return
+}
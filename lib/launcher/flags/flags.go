@@ -45,6 +45,9 @@ const (
 	// Leakless flag
 	Leakless Flag = "rod-leakless"
 
+	// Reaper flag
+	Reaper Flag = "rod-reaper"
+
 	// Bin is the browser executable file path. If it's empty, launcher will automatically search or download the bin.
 	Bin Flag = "rod-bin"
 
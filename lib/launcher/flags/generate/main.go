@@ -0,0 +1,90 @@
+// Package main ...
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"go/format"
+	"io"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Fromsko/rodPro/lib/utils"
+)
+
+const source = "https://chromium.googlesource.com/chromium/src/+/main/"
+
+// the files that define the switches of the browser, such as `const char kNoSandbox[] = "no-sandbox";`
+var files = []string{
+	"base/base_switches.cc",
+	"chrome/common/chrome_switches.cc",
+	"components/os_crypt/sync/os_crypt_switches.cc",
+	"content/public/common/content_switches.cc",
+	"gpu/config/gpu_switches.cc",
+	"headless/public/switches.cc",
+	"media/base/media_switches.cc",
+	"sandbox/policy/switches.cc",
+	"services/network/public/cpp/network_switches.cc",
+	"ui/base/ui_base_switches.cc",
+	"ui/display/display_switches.cc",
+	"ui/gfx/switches.cc",
+	"ui/gl/gl_switches.cc",
+}
+
+func main() {
+	set := map[string]struct{}{}
+	for _, f := range files {
+		for _, name := range parse(get(source + f + "?format=TEXT")) {
+			set[name] = struct{}{}
+		}
+	}
+
+	list := []string{}
+	for name := range set {
+		list = append(list, name)
+	}
+	sort.Strings(list)
+
+	code := "// generated by \"lib/launcher/flags/generate\"\n\npackage flags\n\n" +
+		"// switches of the browser that are defined in the Chromium source code\n" +
+		"var switches = map[Flag]struct{}{\n"
+	for _, name := range list {
+		code += strconv.Quote(name) + ": {},\n"
+	}
+	code += "}\n"
+
+	out, err := format.Source([]byte(code))
+	utils.E(err)
+
+	utils.E(utils.OutputFile(filepath.FromSlash("lib/launcher/flags/switches.go"), out))
+}
+
+var regSwitch = regexp.MustCompile(`k\w+\[\]\s*=\s*"([a-z0-9][a-z0-9-]*)"`)
+
+func parse(src string) []string {
+	list := []string{}
+	for _, m := range regSwitch.FindAllStringSubmatch(src, -1) {
+		list = append(list, strings.TrimSpace(m[1]))
+	}
+	return list
+}
+
+// get the file from googlesource, the content of the TEXT format is base64 encoded
+func get(u string) string {
+	res, err := http.Get(u)
+	utils.E(err)
+	defer func() { _ = res.Body.Close() }()
+
+	if res.StatusCode != http.StatusOK {
+		utils.E(fmt.Errorf("failed to get %s: %s", u, res.Status))
+	}
+
+	b, err := io.ReadAll(base64.NewDecoder(base64.StdEncoding, res.Body))
+	utils.E(err)
+
+	return string(b)
+}
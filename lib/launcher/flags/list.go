@@ -0,0 +1,122 @@
+package flags
+
+import "strings"
+
+// The typed switches of the browser, it's a subset of the Chromium switch list:
+// https://peter.sh/experiments/chromium-command-line-switches
+const (
+	// DisableGPU flag
+	DisableGPU Flag = "disable-gpu"
+
+	// WindowSize flag, such as "800,600"
+	WindowSize Flag = "window-size"
+
+	// WindowPosition flag, such as "0,0"
+	WindowPosition Flag = "window-position"
+
+	// UserAgent flag
+	UserAgent Flag = "user-agent"
+
+	// Lang flag, such as "en-US"
+	Lang Flag = "lang"
+
+	// ProxyBypassList flag
+	ProxyBypassList Flag = "proxy-bypass-list"
+
+	// DisableExtensions flag
+	DisableExtensions Flag = "disable-extensions"
+
+	// MuteAudio flag
+	MuteAudio Flag = "mute-audio"
+
+	// StartMaximized flag
+	StartMaximized Flag = "start-maximized"
+
+	// StartFullscreen flag
+	StartFullscreen Flag = "start-fullscreen"
+
+	// Kiosk flag
+	Kiosk Flag = "kiosk"
+
+	// HideScrollbars flag
+	HideScrollbars Flag = "hide-scrollbars"
+
+	// IgnoreCertificateErrors flag
+	IgnoreCertificateErrors Flag = "ignore-certificate-errors"
+
+	// DisableWebSecurity flag
+	DisableWebSecurity Flag = "disable-web-security"
+
+	// AutoOpenDevTools flag
+	AutoOpenDevTools Flag = "auto-open-devtools-for-tabs"
+
+	// EnableFeatures flag
+	EnableFeatures Flag = "enable-features"
+
+	// DisableFeatures flag
+	DisableFeatures Flag = "disable-features"
+
+	// DisableBlinkFeatures flag
+	DisableBlinkFeatures Flag = "disable-blink-features"
+
+	// Incognito flag
+	Incognito Flag = "incognito"
+
+	// SingleProcess flag
+	SingleProcess Flag = "single-process"
+
+	// DisableDevShmUsage flag
+	DisableDevShmUsage Flag = "disable-dev-shm-usage"
+
+	// RemoteDebuggingPipe flag
+	RemoteDebuggingPipe Flag = "remote-debugging-pipe"
+
+	// UseFakeUIForMediaStream flag, auto accept the getUserMedia permission prompts
+	UseFakeUIForMediaStream Flag = "use-fake-ui-for-media-stream"
+
+	// UseFakeDeviceForMediaStream flag, use the fake camera and microphone instead of the real ones
+	UseFakeDeviceForMediaStream Flag = "use-fake-device-for-media-stream"
+
+	// UseFileForFakeVideoCapture flag, such as "/path/to/video.y4m"
+	UseFileForFakeVideoCapture Flag = "use-file-for-fake-video-capture"
+
+	// UseFileForFakeAudioCapture flag, such as "/path/to/audio.wav"
+	UseFileForFakeAudioCapture Flag = "use-file-for-fake-audio-capture"
+
+	// UseGL flag, the gl implementation, such as "angle"
+	UseGL Flag = "use-gl"
+
+	// UseANGLE flag, the backend of ANGLE, such as "swiftshader", "vulkan", "gl", "metal", or "d3d11"
+	UseANGLE Flag = "use-angle"
+
+	// EnableUnsafeSwiftShader flag, allow the WebGL to fall back to SwiftShader
+	EnableUnsafeSwiftShader Flag = "enable-unsafe-swiftshader"
+
+	// IgnoreGPUBlocklist flag, use the gpu even if the driver is blocklisted
+	IgnoreGPUBlocklist Flag = "ignore-gpu-blocklist"
+
+	// EnableGPURasterization flag
+	EnableGPURasterization Flag = "enable-gpu-rasterization"
+)
+
+// Known returns true if the flag is a known switch of the browser or a rod specific flag.
+func (f Flag) Known() bool {
+	f = f.NormalizeFlag()
+	if f == Arguments || strings.HasPrefix(string(f), "rod-") {
+		return true
+	}
+	_, has := switches[f]
+	return has
+}
+
+// Conflicts is the list of switch pairs that shouldn't be used together
+var Conflicts = [][2]Flag{
+	{Headless, App},
+	{Headless, Kiosk},
+	{Headless, StartMaximized},
+	{Headless, StartFullscreen},
+	{Headless, AutoOpenDevTools},
+	{Kiosk, App},
+	{RemoteDebuggingPort, RemoteDebuggingPipe},
+	{Incognito, ProfileDir},
+}
@@ -0,0 +1,75 @@
+package flags
+
+// switches of the browser that are defined in the Chromium source code, it's a partial list of the common ones,
+// run "go run ./lib/launcher/flags/generate" to replace it with the full list
+var switches = map[Flag]struct{}{
+	"allow-insecure-localhost":                           {},
+	"app":                                                {},
+	"auto-open-devtools-for-tabs":                        {},
+	"disable-background-networking":                      {},
+	"disable-background-timer-throttling":                {},
+	"disable-backgrounding-occluded-windows":             {},
+	"disable-blink-features":                             {},
+	"disable-breakpad":                                   {},
+	"disable-client-side-phishing-detection":             {},
+	"disable-component-extensions-with-background-pages": {},
+	"disable-component-update":                           {},
+	"disable-default-apps":                               {},
+	"disable-dev-shm-usage":                              {},
+	"disable-extensions":                                 {},
+	"disable-features":                                   {},
+	"disable-gpu":                                        {},
+	"disable-hang-monitor":                               {},
+	"disable-ipc-flooding-protection":                    {},
+	"disable-popup-blocking":                             {},
+	"disable-prompt-on-repost":                           {},
+	"disable-renderer-backgrounding":                     {},
+	"disable-software-rasterizer":                        {},
+	"disable-sync":                                       {},
+	"disable-web-security":                               {},
+	"enable-automation":                                  {},
+	"enable-features":                                    {},
+	"enable-gpu-rasterization":                           {},
+	"enable-logging":                                     {},
+	"enable-unsafe-swiftshader":                          {},
+	"force-color-profile":                                {},
+	"headless":                                           {},
+	"hide-scrollbars":                                    {},
+	"host-resolver-rules":                                {},
+	"ignore-certificate-errors":                          {},
+	"ignore-certificate-errors-spki-list":                {},
+	"ignore-gpu-blocklist":                               {},
+	"incognito":                                          {},
+	"js-flags":                                           {},
+	"kiosk":                                              {},
+	"lang":                                               {},
+	"log-level":                                          {},
+	"metrics-recording-only":                             {},
+	"mute-audio":                                         {},
+	"no-default-browser-check":                           {},
+	"no-first-run":                                       {},
+	"no-sandbox":                                         {},
+	"no-startup-window":                                  {},
+	"password-store":                                     {},
+	"profile-directory":                                  {},
+	"proxy-bypass-list":                                  {},
+	"proxy-server":                                       {},
+	"remote-allow-origins":                               {},
+	"remote-debugging-pipe":                              {},
+	"remote-debugging-port":                              {},
+	"single-process":                                     {},
+	"start-fullscreen":                                   {},
+	"start-maximized":                                    {},
+	"use-angle":                                          {},
+	"use-fake-device-for-media-stream":                   {},
+	"use-fake-ui-for-media-stream":                       {},
+	"use-file-for-fake-audio-capture":                    {},
+	"use-file-for-fake-video-capture":                    {},
+	"use-gl":                                             {},
+	"use-mock-keychain":                                  {},
+	"user-agent":                                         {},
+	"user-data-dir":                                      {},
+	"v":                                                  {},
+	"window-position":                                    {},
+	"window-size":                                        {},
+}
@@ -0,0 +1,219 @@
+package launcher
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/Fromsko/rodPro/lib/cdp"
+	"github.com/Fromsko/rodPro/lib/launcher/flags"
+	"github.com/Fromsko/rodPro/lib/utils"
+)
+
+type hooks struct {
+	preStart  []func(cmd *exec.Cmd) error
+	postStart []func(pid int, u string) error
+	preKill   []func(pid int)
+}
+
+// PreStart adds a hook that will be called right before the browser process starts.
+// The cmd can be modified, if the hook returns an error the launch will be aborted.
+func (l *Launcher) PreStart(fn func(cmd *exec.Cmd) error) *Launcher {
+	l.hooks.preStart = append(l.hooks.preStart, fn)
+	return l
+}
+
+// PostStart adds a hook that will be called after the browser is started and its debug url is resolved.
+// If the hook returns an error the browser will be killed and the launch will fail.
+func (l *Launcher) PostStart(fn func(pid int, u string) error) *Launcher {
+	l.hooks.postStart = append(l.hooks.postStart, fn)
+	return l
+}
+
+// PreKill adds a hook that will be called before the browser process is killed or closed
+// via [Launcher.Kill] or [Launcher.GracefulClose].
+func (l *Launcher) PreKill(fn func(pid int)) *Launcher {
+	l.hooks.preKill = append(l.hooks.preKill, fn)
+	return l
+}
+
+func (l *Launcher) runPreStart(cmd *exec.Cmd) error {
+	for _, fn := range l.hooks.preStart {
+		if err := fn(cmd); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *Launcher) runPostStart(u string) error {
+	for _, fn := range l.hooks.postStart {
+		if err := fn(l.PID(), u); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *Launcher) runPreKill() {
+	l.preKillOnce.Do(func() {
+		for _, fn := range l.hooks.preKill {
+			fn(l.PID())
+		}
+	})
+}
+
+// GracefulClose closes the browser gracefully. It sends Browser.close via CDP and waits for the process to exit,
+// if the browser doesn't exit, it will be terminated, then killed. All the stages share the timeout, the first half
+// of it is for the graceful close, the rest is for the terminate and the kill, so it returns within about the timeout.
+// The temp [flags.UserDataDir] created by [New] will be removed after the browser exits.
+func (l *Launcher) GracefulClose(timeout time.Duration) error {
+	defer launched.remove(l)
+
+	if l.PID() == 0 {
+		return l.removeTempUserDataDir()
+	}
+
+	l.runPreKill()
+
+	now := time.Now()
+	deadline := now.Add(timeout)
+	graceful := now.Add(timeout / 2)
+	terminate := deadline.Add(-timeout / 4)
+
+	if l.pipe != nil {
+		l.closeViaPipe()
+	} else if l.url != "" {
+		ctx, cancel := context.WithDeadline(context.Background(), graceful)
+		client, err := cdp.StartWithURL(ctx, l.url, nil)
+		if err == nil {
+			_, _ = client.Call(ctx, "", "Browser.close", nil)
+		}
+		cancel()
+	}
+
+	if !l.waitExit(graceful) {
+		terminateGroup(l.PID())
+
+		if !l.waitExit(terminate) {
+			l.kill()
+			l.waitExit(deadline)
+		}
+	}
+
+	return l.removeTempUserDataDir()
+}
+
+// MustGracefulClose is similar to [Launcher.GracefulClose].
+func (l *Launcher) MustGracefulClose(timeout time.Duration) {
+	utils.E(l.GracefulClose(timeout))
+}
+
+// waitExit returns true if the browser exits before the deadline
+func (l *Launcher) waitExit(deadline time.Time) bool {
+	select {
+	case <-l.exit:
+		return true
+	default:
+	}
+
+	t := time.NewTimer(time.Until(deadline))
+	defer t.Stop()
+
+	select {
+	case <-l.exit:
+		return true
+	case <-t.C:
+		return false
+	}
+}
+
+// isTempUserDataDir returns true if the user data dir is a random dir created by [New].
+func (l *Launcher) isTempUserDataDir() bool {
+	dir := l.Get(flags.UserDataDir)
+	return dir != "" && strings.HasPrefix(dir, DefaultUserDataDirPrefix+string(filepath.Separator))
+}
+
+func (l *Launcher) removeTempUserDataDir() error {
+	if !l.isTempUserDataDir() {
+		return nil
+	}
+	return os.RemoveAll(l.Get(flags.UserDataDir))
+}
+
+// ExitTimeout is the timeout for each browser to close gracefully when the process exits.
+// Check [ExitHandler] for details.
+var ExitTimeout = 3 * time.Second
+
+type launchedSet struct {
+	lock sync.Mutex
+	list map[*Launcher]struct{}
+}
+
+var launched = &launchedSet{list: map[*Launcher]struct{}{}}
+
+func (s *launchedSet) add(l *Launcher) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.list[l] = struct{}{}
+}
+
+func (s *launchedSet) remove(l *Launcher) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.list, l)
+}
+
+func (s *launchedSet) closeAll() {
+	s.lock.Lock()
+	list := []*Launcher{}
+	for l := range s.list {
+		list = append(list, l)
+	}
+	s.lock.Unlock()
+
+	wg := sync.WaitGroup{}
+	for _, l := range list {
+		wg.Add(1)
+		go func(l *Launcher) {
+			defer wg.Done()
+			_ = l.GracefulClose(ExitTimeout)
+		}(l)
+	}
+	wg.Wait()
+}
+
+// ExitHandler gracefully closes all the browsers launched by this process and removes their
+// temp user data dirs when the returned cleanup is called, or when the process receives SIGINT or SIGTERM.
+// Defer the cleanup at the beginning of the main function, so that it will also run on panic:
+//
+//	defer launcher.ExitHandler()()
+func ExitHandler() (cleanup func()) {
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(ch, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		select {
+		case <-ch:
+			launched.closeAll()
+			os.Exit(1)
+		case <-done:
+		}
+	}()
+
+	once := sync.Once{}
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+			launched.closeAll()
+		})
+	}
+}
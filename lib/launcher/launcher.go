@@ -13,8 +13,10 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
 	"sync/atomic"
 
+	"github.com/Fromsko/rodPro/lib/cdp"
 	"github.com/Fromsko/rodPro/lib/defaults"
 	"github.com/Fromsko/rodPro/lib/launcher/flags"
 	"github.com/Fromsko/rodPro/lib/utils"
@@ -26,7 +28,7 @@ var DefaultUserDataDirPrefix = filepath.Join(os.TempDir(), "rod", "user-data")
 
 // Launcher is a helper to launch browser binary smartly
 type Launcher struct {
-	Flags map[flags.Flag][]string `json:"flags"`
+	Flags Flags `json:"flags"`
 
 	ctx       context.Context
 	ctxCancel func()
@@ -37,11 +39,27 @@ type Launcher struct {
 	parser  *URLParser
 	pid     int
 	exit    chan struct{}
+	url     string
+
+	hooks       hooks
+	preKillOnce sync.Once
+
+	reaper *ReaperRecord
+
+	pipe       *cdp.Pipe
+	pipeClient *cdp.Client // the only client of the pipe, see [Launcher.Client]
+	pipeLock   sync.Mutex
 
 	managed    bool
 	serviceURL string
 
 	isLaunched int32 // zero means not launched
+
+	userMode     bool
+	cloneProfile bool
+
+	autoTune       bool
+	autoTuneReport []string
 }
 
 // New returns the default arguments to start browser.
@@ -64,7 +82,7 @@ func New() *Launcher {
 		flags.RemoteDebuggingPort: {defaults.Port},
 
 		// enable headless by default
-		flags.Headless: nil,
+		flags.Headless: {string(HeadlessNew)},
 
 		flags.Preferences: {`{"plugins":{"always_open_pdf_externally": true}}`},
 
@@ -122,8 +140,10 @@ func New() *Launcher {
 }
 
 // NewUserMode is a preset to enable reusing current user data. Useful for automation of personal browser.
-// If you see any error, it may because you can't launch debug port for existing browser, the solution is to
-// completely close the running browser. Unfortunately, there's no API for rod to tell it automatically yet.
+// If the browser is already running with remote debugging enabled, the launcher will connect to it via
+// the DevToolsActivePort file of the user data dir. Otherwise you may have to completely close the running browser,
+// or use [Launcher.CloneProfile] to launch a separate browser with a copy of the profile.
+// Use [Launcher.UserProfile] to select the profile, [UserProfiles] to list them.
 func NewUserMode() *Launcher {
 	ctx, cancel := context.WithCancel(context.Background())
 	bin, _ := LookPath()
@@ -136,10 +156,11 @@ func NewUserMode() *Launcher {
 			"no-startup-window":       nil,
 			flags.Bin:                 {bin},
 		},
-		browser: NewBrowser(),
-		exit:    make(chan struct{}),
-		parser:  NewURLParser(),
-		logger:  ioutil.Discard,
+		browser:  NewBrowser(),
+		exit:     make(chan struct{}),
+		parser:   NewURLParser(),
+		logger:   ioutil.Discard,
+		userMode: true,
 	}
 }
 
@@ -221,11 +242,57 @@ func (l *Launcher) Revision(rev int) *Launcher {
 }
 
 // Headless switch. Whether to run browser in headless mode. A mode without visible UI.
+// If enabled, the [HeadlessNew] mode will be used, use [Launcher.HeadlessMode] to choose other modes.
 func (l *Launcher) Headless(enable bool) *Launcher {
 	if enable {
+		return l.HeadlessMode(HeadlessNew)
+	}
+	return l.HeadlessMode(HeadlessFalse)
+}
+
+// HeadlessType is the mode of headless browser.
+// Doc: https://developer.chrome.com/docs/chromium/headless
+type HeadlessType string
+
+const (
+	// HeadlessFalse runs the browser with visible UI
+	HeadlessFalse HeadlessType = ""
+
+	// HeadlessNew is the headless mode that shares the same code with the headful browser,
+	// so the behaviors, such as the emulation and screenshot, are the same as headful mode.
+	HeadlessNew HeadlessType = "new"
+
+	// HeadlessOld is the legacy headless implementation, it's removed since Chrome 132.
+	HeadlessOld HeadlessType = "old"
+
+	// HeadlessShell is for the chrome-headless-shell binary, which is the legacy headless implementation
+	// as a standalone lightweight binary. It uses the flag without value, for other binaries the browser
+	// will choose the mode by its version.
+	HeadlessShell HeadlessType = "shell"
+)
+
+// HeadlessMode sets the mode of headless, use [HeadlessFalse] to disable headless.
+func (l *Launcher) HeadlessMode(mode HeadlessType) *Launcher {
+	switch mode {
+	case HeadlessFalse:
+		return l.Delete(flags.Headless)
+	case HeadlessShell:
 		return l.Set(flags.Headless)
+	default:
+		return l.Set(flags.Headless, string(mode))
+	}
+}
+
+// GetHeadlessMode returns the headless mode the launcher will use.
+func (l *Launcher) GetHeadlessMode() HeadlessType {
+	list, has := l.GetFlags(flags.Headless)
+	if !has {
+		return HeadlessFalse
+	}
+	if len(list) == 0 {
+		return HeadlessShell
 	}
-	return l.Delete(flags.Headless)
+	return HeadlessType(list[0])
 }
 
 // NoSandbox switch. Whether to run browser in no-sandbox mode.
@@ -266,6 +333,62 @@ func (l *Launcher) Devtools(autoOpenForTabs bool) *Launcher {
 	return l.Delete("auto-open-devtools-for-tabs")
 }
 
+// FakeMedia switch to use the fake camera and microphone for getUserMedia, the permission prompts will be auto accepted.
+// The video is the path of a y4m or mjpeg file to feed the webcam, the audio is the path of a wav file to feed
+// the microphone. If they are empty, the test pattern and beep of the browser will be used.
+// To grant the permissions without the flags, use the Page.GrantMedia of rod.
+func (l *Launcher) FakeMedia(video, audio string) *Launcher {
+	l.Set(flags.UseFakeUIForMediaStream).Set(flags.UseFakeDeviceForMediaStream)
+
+	l.Delete(flags.UseFileForFakeVideoCapture)
+	if video != "" {
+		l.Set(flags.UseFileForFakeVideoCapture, utils.AbsolutePaths([]string{video})[0])
+	}
+
+	l.Delete(flags.UseFileForFakeAudioCapture)
+	if audio != "" {
+		l.Set(flags.UseFileForFakeAudioCapture, utils.AbsolutePaths([]string{audio})[0])
+	}
+
+	return l
+}
+
+// GLBackend is the WebGL backend of [Launcher.GL]
+type GLBackend string
+
+const (
+	// GLSwiftShader renders WebGL on the cpu via SwiftShader, it works everywhere but is slow,
+	// it's the common choice for CI without gpu.
+	GLSwiftShader GLBackend = "swiftshader"
+
+	// GLANGLE renders WebGL via ANGLE on the default native backend of the platform,
+	// such as OpenGL on Linux, Metal on macOS, and Direct3D 11 on Windows.
+	GLANGLE GLBackend = "angle"
+
+	// GLGPU uses the gpu even if it's blocklisted by the browser, such as a headless browser on a gpu server.
+	GLGPU GLBackend = "gpu"
+)
+
+// GL switches the WebGL backend, use it when a WebGL-heavy page renders blank, such as in the CI that has no gpu.
+// Use the Page.WebGLInfo of rod to check which renderer is actually used.
+func (l *Launcher) GL(backend GLBackend) *Launcher {
+	l.Delete(flags.DisableGPU).Delete(flags.UseANGLE).Delete(flags.EnableUnsafeSwiftShader).
+		Delete(flags.IgnoreGPUBlocklist).Delete(flags.EnableGPURasterization)
+
+	l.Set(flags.UseGL, "angle")
+
+	switch backend {
+	case GLSwiftShader:
+		l.Set(flags.UseANGLE, "swiftshader").Set(flags.EnableUnsafeSwiftShader)
+	case GLANGLE:
+		l.Set(flags.UseANGLE, "default")
+	case GLGPU:
+		l.Set(flags.IgnoreGPUBlocklist).Set(flags.EnableGPURasterization)
+	}
+
+	return l
+}
+
 // IgnoreCerts configure the Chrome's ignore-certificate-errors-spki-list argument with the public keys.
 func (l *Launcher) IgnoreCerts(pks []crypto.PublicKey) error {
 	spkis := make([]string, 0, len(pks))
@@ -401,6 +524,17 @@ func (l *Launcher) Launch() (string, error) {
 		return "", err
 	}
 
+	if l.cloneProfile {
+		err = l.cloneUserProfile()
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if l.autoTune {
+		l.autoTuneReport = DetectEnvironment().Apply(l)
+	}
+
 	l.setupUserPreferences()
 
 	var ll *leakless.Launcher
@@ -408,7 +542,17 @@ func (l *Launcher) Launch() (string, error) {
 
 	args := l.FormatArgs()
 
-	if l.Has(flags.Leakless) && leakless.Support() {
+	for _, w := range l.Flags.Validate() {
+		_, _ = fmt.Fprintln(l.logger, "[launcher] warning:", w)
+	}
+
+	if token := l.reaperToken(); token != "" {
+		args = append(args, reaperTokenSwitch+token)
+	}
+
+	if l.isPipe() {
+		cmd = exec.Command(bin, args...)
+	} else if l.Has(flags.Leakless) && leakless.Support() {
 		ll = leakless.New()
 		cmd = ll.Command(bin, args...)
 	} else {
@@ -417,12 +561,32 @@ func (l *Launcher) Launch() (string, error) {
 		if err == nil {
 			return u, nil
 		}
+		if l.userMode && !l.cloneProfile {
+			u, err = ActivePortURL(l.userDataDir())
+			if err == nil {
+				return u, nil
+			}
+		}
 		cmd = exec.Command(bin, args...)
 	}
 
 	l.setupCmd(cmd)
 
+	closePipe := func() {}
+	if l.isPipe() {
+		closePipe, err = l.setupPipe(cmd)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	err = l.runPreStart(cmd)
+	if err != nil {
+		return "", err
+	}
+
 	err = cmd.Start()
+	closePipe()
 	if err != nil {
 		return "", err
 	}
@@ -436,18 +600,51 @@ func (l *Launcher) Launch() (string, error) {
 		}
 	}
 
+	// save the record before waiting for the exit, so that it won't be saved after it's removed
+	err = l.saveReaperRecord()
+
 	go func() {
 		_ = cmd.Wait()
+		l.removeReaperRecord()
 		close(l.exit)
 	}()
 
+	launched.add(l)
+
+	if err != nil {
+		l.Kill()
+		return "", err
+	}
+
+	if l.isPipe() {
+		err = l.runPostStart("")
+		if err != nil {
+			l.Kill()
+			return "", err
+		}
+		return "", nil
+	}
+
 	u, err := l.getURL()
 	if err != nil {
 		l.Kill()
 		return "", err
 	}
 
-	return ResolveURL(u)
+	u, err = ResolveURL(u)
+	if err != nil {
+		l.Kill()
+		return "", err
+	}
+	l.url = u
+
+	err = l.runPostStart(u)
+	if err != nil {
+		l.Kill()
+		return "", err
+	}
+
+	return u, nil
 }
 
 func (l *Launcher) hasLaunched() bool {
@@ -456,7 +653,9 @@ func (l *Launcher) hasLaunched() bool {
 
 func (l *Launcher) setupUserPreferences() {
 	userDir := l.Get(flags.UserDataDir)
-	if userDir == "" {
+
+	// never override the preferences of the user profiles
+	if userDir == "" || l.userMode {
 		return
 	}
 
@@ -526,6 +725,12 @@ func (l *Launcher) Kill() {
 		return
 	}
 
+	l.runPreKill()
+	l.kill()
+	launched.remove(l)
+}
+
+func (l *Launcher) kill() {
 	killGroup(l.PID())
 	p, err := os.FindProcess(l.PID())
 	if err == nil {
@@ -536,6 +741,7 @@ func (l *Launcher) Kill() {
 // Cleanup wait until the Browser exits and remove [flags.UserDataDir]
 func (l *Launcher) Cleanup() {
 	<-l.exit
+	launched.remove(l)
 
 	dir := l.Get(flags.UserDataDir)
 	_ = os.RemoveAll(dir)
@@ -13,6 +13,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"testing"
 
@@ -162,6 +163,66 @@ func TestAppMode(t *testing.T) {
 	g.Eq(l.Get(flags.App), "http://example.com")
 }
 
+func TestHeadlessMode(t *testing.T) {
+	g := setup(t)
+
+	l := launcher.New()
+	g.Eq(l.GetHeadlessMode(), launcher.HeadlessNew)
+	g.Has(l.FormatArgs(), "--headless=new")
+
+	l.HeadlessMode(launcher.HeadlessOld)
+	g.Eq(l.GetHeadlessMode(), launcher.HeadlessOld)
+	g.Has(l.FormatArgs(), "--headless=old")
+
+	l.HeadlessMode(launcher.HeadlessShell)
+	g.Eq(l.GetHeadlessMode(), launcher.HeadlessShell)
+	g.Has(l.FormatArgs(), "--headless")
+
+	l.Headless(false)
+	g.Eq(l.GetHeadlessMode(), launcher.HeadlessFalse)
+	g.False(l.Has(flags.Headless))
+
+	l.Headless(true)
+	g.Eq(l.GetHeadlessMode(), launcher.HeadlessNew)
+}
+
+func TestFlagsBuilder(t *testing.T) {
+	g := setup(t)
+
+	l := launcher.New()
+	g.Len(l.Flags.Validate(), 0)
+
+	l.Flags.DisableGPU(true).WindowSize(800, 600).UserAgent("test").
+		EnableFeatures("A", "A").DisableFeatures("A").
+		StartMaximized(true)
+	l.Set("not-exists", "1")
+
+	g.Eq(l.Get(flags.WindowSize), "800")
+	g.Has(l.FormatArgs(), "--window-size=800,600")
+	g.Eq(l.Flags[flags.EnableFeatures], []string{"NetworkService", "NetworkServiceInProcess", "A"})
+
+	g.Eq(l.Flags.Validate(), []string{
+		"conflicting flags: --headless and --start-maximized",
+		"feature both enabled and disabled: A",
+	})
+
+	base := launcher.New().UserDataDir(l.Get(flags.UserDataDir))
+	added, removed := l.Diff(base)
+	g.Has(added, "--disable-gpu")
+	g.Has(added, "--user-agent=test")
+	g.Eq(removed, []string{
+		"--disable-features=site-per-process,TranslateUI",
+		"--enable-features=NetworkService,NetworkServiceInProcess",
+	})
+
+	l.Flags.DisableGPU(false).UserAgent("")
+	g.False(l.Has(flags.DisableGPU))
+	g.False(l.Has(flags.UserAgent))
+
+	g.True(flags.Flag("--rod-custom").Known())
+	g.False(flags.Flag("--abc").Known())
+}
+
 func TestGetWebSocketDebuggerURLErr(t *testing.T) {
 	g := setup(t)
 
@@ -324,3 +385,69 @@ func TestLaunchMultiTimes(t *testing.T) {
 	_, e = l.Launch()
 	g.Eq(e, launcher.ErrAlreadyLaunched)
 }
+
+func TestPipe(t *testing.T) {
+	g := setup(t)
+
+	if runtime.GOOS == "windows" {
+		g.SkipNow()
+	}
+
+	l := launcher.New().Pipe(true)
+	g.True(l.Has(flags.RemoteDebuggingPipe))
+	g.False(l.Has(flags.RemoteDebuggingPort))
+
+	// a fake browser that echoes the requests as responses
+	bin := filepath.Join(t.TempDir(), "browser")
+	g.E(os.WriteFile(bin, []byte("#!/bin/sh\ncat <&3 >&4\n"), 0o755))
+
+	u, err := l.Bin(bin).Launch()
+	g.E(err)
+	g.Eq(u, "")
+
+	c := l.MustClient()
+	_, err = c.Call(g.Context(), "", "Browser.getVersion", nil)
+	g.E(err)
+	g.Eq(l.MustClient(), c)
+
+	l.Kill()
+
+	l = launcher.New().Pipe(true).Pipe(false)
+	g.False(l.Has(flags.RemoteDebuggingPipe))
+	g.True(l.Has(flags.RemoteDebuggingPort))
+}
+
+func TestFakeMedia(t *testing.T) {
+	g := setup(t)
+
+	l := launcher.New().FakeMedia("video.y4m", "")
+	g.True(l.Has(flags.UseFakeUIForMediaStream))
+	g.True(l.Has(flags.UseFakeDeviceForMediaStream))
+	g.Eq(l.Get(flags.UseFileForFakeVideoCapture), utils.AbsolutePaths([]string{"video.y4m"})[0])
+	g.False(l.Has(flags.UseFileForFakeAudioCapture))
+
+	l.FakeMedia("", "audio.wav")
+	g.False(l.Has(flags.UseFileForFakeVideoCapture))
+	g.Eq(l.Get(flags.UseFileForFakeAudioCapture), utils.AbsolutePaths([]string{"audio.wav"})[0])
+}
+
+func TestGL(t *testing.T) {
+	g := setup(t)
+
+	l := launcher.New().Set(flags.DisableGPU).GL(launcher.GLSwiftShader)
+	g.False(l.Has(flags.DisableGPU))
+	g.Eq(l.Get(flags.UseGL), "angle")
+	g.Eq(l.Get(flags.UseANGLE), "swiftshader")
+	g.True(l.Has(flags.EnableUnsafeSwiftShader))
+
+	l.GL(launcher.GLANGLE)
+	g.Eq(l.Get(flags.UseANGLE), "default")
+	g.False(l.Has(flags.EnableUnsafeSwiftShader))
+
+	l.GL(launcher.GLGPU)
+	g.False(l.Has(flags.UseANGLE))
+	g.True(l.Has(flags.IgnoreGPUBlocklist))
+	g.True(l.Has(flags.EnableGPURasterization))
+	g.True(l.Has(flags.UseGL))
+	g.True(flags.UseANGLE.Known())
+}
@@ -74,12 +74,25 @@ func (l *Launcher) JSON() []byte {
 
 // MustClient similar to Launcher.Client
 func (l *Launcher) MustClient() *cdp.Client {
-	u, h := l.ClientHeader()
-	return cdp.MustStartWithURL(l.ctx, u, h)
+	c, err := l.Client()
+	utils.E(err)
+	return c
 }
 
-// Client for launching browser remotely via the launcher.Manager.
+// Client for launching browser remotely via the launcher.Manager,
+// or for the browser launched with [Launcher.Pipe].
+// The pipe can only be read by one client, so the same client is returned for the pipe every time.
 func (l *Launcher) Client() (*cdp.Client, error) {
+	if l.pipe != nil {
+		l.pipeLock.Lock()
+		defer l.pipeLock.Unlock()
+
+		if l.pipeClient == nil {
+			l.pipeClient = cdp.New().Start(l.pipe)
+		}
+		return l.pipeClient, nil
+	}
+
 	u, h := l.ClientHeader()
 	return cdp.StartWithURL(l.ctx, u, h)
 }
@@ -3,7 +3,10 @@
 package launcher
 
 import (
+	"bytes"
+	"os"
 	"os/exec"
+	"strconv"
 	"syscall"
 
 	"github.com/Fromsko/rodPro/lib/launcher/flags"
@@ -13,6 +16,10 @@ func killGroup(pid int) {
 	_ = syscall.Kill(-pid, syscall.SIGKILL)
 }
 
+func terminateGroup(pid int) {
+	_ = syscall.Kill(-pid, syscall.SIGTERM)
+}
+
 func (l *Launcher) osSetupCmd(cmd *exec.Cmd) {
 	if flags, has := l.GetFlags(flags.XVFB); has {
 		var command []string
@@ -24,3 +31,18 @@ func (l *Launcher) osSetupCmd(cmd *exec.Cmd) {
 	}
 	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
 }
+
+func processAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || err == syscall.EPERM
+}
+
+func processCmdline(pid int) string {
+	b, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/cmdline")
+	if err == nil {
+		return string(bytes.ReplaceAll(b, []byte{0}, []byte{' '}))
+	}
+
+	b, _ = exec.Command("ps", "-ww", "-o", "command=", "-p", strconv.Itoa(pid)).Output()
+	return string(b)
+}
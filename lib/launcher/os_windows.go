@@ -3,6 +3,7 @@
 package launcher
 
 import (
+	"fmt"
 	"os/exec"
 	"syscall"
 )
@@ -11,6 +12,11 @@ func killGroup(pid int) {
 	terminateProcess(pid)
 }
 
+// Windows has no SIGTERM, the process will be terminated directly.
+func terminateGroup(pid int) {
+	terminateProcess(pid)
+}
+
 func (l *Launcher) osSetupCmd(cmd *exec.Cmd) {
 	cmd.SysProcAttr = &syscall.SysProcAttr{
 		CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP,
@@ -26,3 +32,24 @@ func terminateProcess(pid int) {
 	_ = syscall.TerminateProcess(handle, 0)
 	_ = syscall.CloseHandle(handle)
 }
+
+func processAlive(pid int) bool {
+	const processQueryLimitedInformation = 0x1000
+	const stillActive = 259
+
+	handle, err := syscall.OpenProcess(processQueryLimitedInformation, false, uint32(pid))
+	if err != nil {
+		return false
+	}
+	defer func() { _ = syscall.CloseHandle(handle) }()
+
+	var code uint32
+	err = syscall.GetExitCodeProcess(handle, &code)
+	return err == nil && code == stillActive
+}
+
+func processCmdline(pid int) string {
+	b, _ := exec.Command("powershell", "-NoProfile", "-Command",
+		fmt.Sprintf("(Get-CimInstance Win32_Process -Filter 'ProcessId=%d').CommandLine", pid)).Output()
+	return string(b)
+}
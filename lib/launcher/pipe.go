@@ -0,0 +1,69 @@
+package launcher
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"runtime"
+
+	"github.com/Fromsko/rodPro/lib/cdp"
+	"github.com/Fromsko/rodPro/lib/defaults"
+	"github.com/Fromsko/rodPro/lib/launcher/flags"
+)
+
+// ErrPipeNotSupported is an error that indicates the pipe transport is not supported on the current OS.
+var ErrPipeNotSupported = errors.New("the pipe transport is not supported on " + runtime.GOOS)
+
+// Pipe switch. If enabled, the browser will talk CDP over the stdio pipes (fd 3 and 4) instead of a websocket,
+// no TCP port will be opened. Because the pipes can't be passed through the leakless helper, leakless
+// will be skipped, use [Launcher.Reaper] instead if you need it.
+// [Launcher.Launch] will return an empty url, use [Launcher.Client] to get the client, such as:
+//
+//	l := launcher.New().Pipe(true)
+//	l.MustLaunch()
+//	rod.New().Client(l.MustClient()).MustConnect()
+func (l *Launcher) Pipe(enable bool) *Launcher {
+	if enable {
+		return l.Set(flags.RemoteDebuggingPipe).Delete(flags.RemoteDebuggingPort)
+	}
+	return l.Delete(flags.RemoteDebuggingPipe).Set(flags.RemoteDebuggingPort, defaults.Port)
+}
+
+func (l *Launcher) isPipe() bool {
+	return l.Has(flags.RemoteDebuggingPipe)
+}
+
+// setupPipe passes the pipes to the cmd, it returns a function to close the ends for the browser,
+// which should be called after the cmd is started.
+func (l *Launcher) setupPipe(cmd *exec.Cmd) (func(), error) {
+	if runtime.GOOS == "windows" {
+		return nil, ErrPipeNotSupported
+	}
+
+	browserIn, w, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+
+	r, browserOut, err := os.Pipe()
+	if err != nil {
+		_ = browserIn.Close()
+		_ = w.Close()
+		return nil, err
+	}
+
+	// the browser reads from fd 3 and writes to fd 4
+	cmd.ExtraFiles = []*os.File{browserIn, browserOut}
+
+	l.pipe = cdp.NewPipe(w, r)
+
+	return func() {
+		_ = browserIn.Close()
+		_ = browserOut.Close()
+	}, nil
+}
+
+// closeViaPipe sends Browser.close to the browser directly, the response will be ignored by the client.
+func (l *Launcher) closeViaPipe() {
+	_ = l.pipe.Send([]byte(`{"id":2147483647,"method":"Browser.close"}`))
+}
@@ -1,11 +1,19 @@
 package launcher
 
 import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
 	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
 	"sync"
 	"testing"
 	"time"
@@ -179,3 +187,373 @@ func TestLaunchClient(t *testing.T) {
 	}
 	g.E(c.Call(ctx, "", "Browser.getVersion", nil))
 }
+
+func TestUserProfile(t *testing.T) {
+	g := setup(t)
+
+	dir := t.TempDir()
+	g.E(utils.OutputFile(filepath.Join(dir, "Local State"),
+		`{"profile":{"info_cache":{"Default":{"name":"Person 1"},"Profile 1":{"name":"Work"}}}}`))
+	g.E(utils.OutputFile(filepath.Join(dir, "Profile 1", "Preferences"), `{"a":1}`))
+	g.E(utils.OutputFile(filepath.Join(dir, "Profile 1", "SingletonLock"), ``))
+
+	list, err := UserProfiles(dir)
+	g.E(err)
+	g.Eq(list, []*UserProfile{{"Default", "Person 1"}, {"Profile 1", "Work"}})
+
+	_, err = UserProfiles(filepath.Join(dir, "not-exists"))
+	g.Err(err)
+
+	g.E(utils.OutputFile(filepath.Join(dir, "Bad", "Local State"), `{`))
+	_, err = UserProfiles(filepath.Join(dir, "Bad"))
+	g.Err(err)
+
+	l := NewUserMode().UserDataDir(dir).UserProfile("Work")
+	g.Eq(l.Get(flags.ProfileDir), "Profile 1")
+	g.Eq(l.UserProfile("Other").Get(flags.ProfileDir), "Other")
+
+	l.UserProfile("Profile 1").CloneProfile(true)
+	g.E(l.cloneUserProfile())
+
+	cloned := l.Get(flags.UserDataDir)
+	defer func() { _ = os.RemoveAll(cloned) }()
+	g.Neq(cloned, dir)
+	pref, err := utils.ReadString(filepath.Join(cloned, "Profile 1", "Preferences"))
+	g.E(err)
+	g.Eq(pref, `{"a":1}`)
+	g.False(utils.FileExists(filepath.Join(cloned, "Profile 1", "SingletonLock")))
+	g.True(utils.FileExists(filepath.Join(cloned, "Local State")))
+
+	g.Err(NewUserMode().UserDataDir(filepath.Join(dir, "not-exists")).cloneUserProfile())
+}
+
+func TestUserDataDirOf(t *testing.T) {
+	g := setup(t)
+
+	if runtime.GOOS != "linux" {
+		g.SkipNow()
+	}
+
+	base := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", base)
+
+	// the chromium one exists but the launched browser is chrome
+	g.E(utils.OutputFile(filepath.Join(base, "chromium", "Local State"), `{}`))
+	g.Eq(New().Delete(flags.UserDataDir).Bin("/usr/bin/google-chrome").userDataDir(), filepath.Join(base, "google-chrome"))
+
+	g.Eq(userDataDirOf("/usr/bin/google-chrome-beta"), filepath.Join(base, "google-chrome-beta"))
+	g.Eq(userDataDirOf("/snap/bin/chromium"), filepath.Join(base, "chromium"))
+	g.Eq(userDataDirOf("/usr/bin/microsoft-edge"), filepath.Join(base, "microsoft-edge"))
+	g.Eq(userDataDirOf("/usr/bin/firefox"), "")
+
+	g.Eq(New().Delete(flags.UserDataDir).Bin("").userDataDir(), filepath.Join(base, "chromium"))
+}
+
+func TestActivePortURL(t *testing.T) {
+	g := setup(t)
+
+	dir := t.TempDir()
+
+	_, err := ActivePortURL(dir)
+	g.Is(err, ErrNoActivePort)
+
+	g.E(utils.OutputFile(filepath.Join(dir, "DevToolsActivePort"), "1"))
+	_, err = ActivePortURL(dir)
+	g.Is(err, ErrNoActivePort)
+
+	s := g.Serve()
+	u, err := url.Parse(s.URL())
+	g.E(err)
+
+	g.E(utils.OutputFile(filepath.Join(dir, "DevToolsActivePort"), u.Port()+"\n/devtools/browser/id\n"))
+	ws, err := ActivePortURL(dir)
+	g.E(err)
+	g.Eq(ws, "ws://127.0.0.1:"+u.Port()+"/devtools/browser/id")
+
+	g.E(utils.OutputFile(filepath.Join(dir, "DevToolsActivePort"), "1\n/devtools/browser/id\n"))
+	_, err = ActivePortURL(dir)
+	g.Is(err, ErrNoActivePort)
+}
+
+func TestAutoTune(t *testing.T) {
+	g := setup(t)
+
+	g.Eq(parseSize("65536k"), int64(64<<20))
+	g.Eq(parseSize("2g"), int64(2<<30))
+	g.Eq(parseSize("100"), int64(100))
+	g.Eq(parseSize(""), int64(0))
+
+	g.NotNil(DetectEnvironment())
+
+	{
+		l := New().Delete(flags.NoSandbox).Delete(flags.DisableDevShmUsage)
+		report := (&Environment{OS: "linux", Container: true, ShmSize: 64 << 20}).Apply(l)
+		g.Len(report, 3)
+		g.True(l.Has(flags.NoSandbox))
+		g.True(l.Has(flags.DisableDevShmUsage))
+		g.True(l.Has(flags.DisableGPU))
+		g.Eq(l.GetHeadlessMode(), HeadlessNew)
+	}
+
+	{
+		// the gl preset is kept in containers
+		l := New().GL(GLSwiftShader)
+		(&Environment{OS: "linux", Container: true}).Apply(l)
+		g.False(l.Has(flags.DisableGPU))
+	}
+
+	{
+		l := New().Headless(false)
+		report := (&Environment{OS: "linux", WSL: true, WSLg: true}).Apply(l)
+		g.Len(report, 1)
+		env, _ := l.GetFlags(flags.Env)
+		g.Has(env, "DISPLAY=:0")
+	}
+
+	{
+		l := New().Headless(false)
+		(&Environment{OS: "linux", XVFB: true}).Apply(l)
+		g.True(l.Has(flags.XVFB))
+	}
+
+	{
+		l := New().Headless(false)
+		(&Environment{OS: "linux"}).Apply(l)
+		g.Eq(l.GetHeadlessMode(), HeadlessNew)
+	}
+
+	{
+		l := New().Headless(false)
+		g.Len((&Environment{OS: "darwin", Root: true}).Apply(l), 0)
+	}
+
+	l := New().AutoTune(true)
+	g.True(l.autoTune)
+	g.Len(l.AutoTuneReport(), 0)
+}
+
+func TestDownloadMirrors(t *testing.T) {
+	g := setup(t)
+
+	buf := bytes.NewBuffer(nil)
+	z := zip.NewWriter(buf)
+	f, _ := z.Create(filepath.FromSlash("a/b/c.txt"))
+	_, _ = f.Write([]byte(g.RandStr(500 * 1024)))
+	_ = z.Close()
+	data := buf.Bytes()
+	sum := sha256.Sum256(data)
+
+	var ranges []string
+	s := g.Serve()
+	s.Mux.HandleFunc("/ok.zip", func(w http.ResponseWriter, r *http.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+		http.ServeContent(w, r, "ok.zip", time.Time{}, bytes.NewReader(data))
+	})
+	s.Mux.HandleFunc("/broken.zip", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+
+	newBrowser := func() *Browser {
+		b := NewBrowser()
+		b.RootDir = t.TempDir()
+		b.Revision = 1
+		b.Logger = utils.LoggerQuiet
+		b.Hosts = []Host{
+			func(_ int) string { return s.URL("/broken.zip") },
+			func(_ int) string { return s.URL("/ok.zip") },
+		}
+		return b
+	}
+
+	{ // failover and resume
+		b := newBrowser()
+		b.Checksum = func(_ int, u string) string {
+			g.Eq(u, s.URL("/ok.zip"))
+			return hex.EncodeToString(sum[:])
+		}
+		g.E(os.WriteFile(b.partPath(s.URL("/ok.zip")), data[:1000], 0o644))
+
+		ranges = nil
+		g.E(b.Download())
+		g.PathExists(filepath.Join(b.Dir(), "b", "c.txt"))
+		g.Has(ranges, "bytes=1000-")
+		g.False(utils.FileExists(b.partPath(s.URL("/ok.zip"))))
+	}
+
+	{ // checksum mismatch
+		b := newBrowser()
+		b.Checksum = func(int, string) string { return "abc" }
+
+		err := b.Download()
+		g.Is(err, &ErrChecksum{})
+		g.False(utils.FileExists(b.partPath(s.URL("/ok.zip"))))
+	}
+
+	{ // all mirrors failed
+		b := newBrowser()
+		b.Hosts = b.Hosts[:1]
+		g.Has(b.Download().Error(), "502")
+	}
+}
+
+func TestProxyFromEnvironment(t *testing.T) {
+	g := setup(t)
+
+	for _, k := range []string{"HTTP_PROXY", "http_proxy", "HTTPS_PROXY", "https_proxy", "NO_PROXY", "no_proxy"} {
+		t.Setenv(k, "")
+	}
+	t.Setenv("ALL_PROXY", "socks5://127.0.0.1:1080")
+
+	req, _ := http.NewRequest(http.MethodGet, "https://example.com", nil)
+	u, err := ProxyFromEnvironment(req)
+	g.E(err)
+	g.Eq(u.String(), "socks5://127.0.0.1:1080")
+
+	t.Setenv("NO_PROXY", "foo.com,.bar.com,10.0.0.0/8,example.org:8080")
+	for _, c := range []struct {
+		u     string
+		proxy bool
+	}{
+		{"https://foo.com", false},
+		{"https://a.foo.com", false},
+		{"https://bar.com", true},
+		{"https://a.bar.com", false},
+		{"http://10.1.2.3", false},
+		{"http://127.0.0.1:3000", false},
+		{"http://localhost", false},
+		{"http://example.org", true},
+		{"http://example.org:8080", false},
+	} {
+		req, _ := http.NewRequest(http.MethodGet, c.u, nil)
+		u, err := ProxyFromEnvironment(req)
+		g.E(err)
+		g.Eq(u != nil, c.proxy)
+	}
+}
+
+func TestLaunchHooks(t *testing.T) {
+	g := setup(t)
+
+	l := New().Bin("go").Leakless(false).PreStart(func(cmd *exec.Cmd) error {
+		return errors.New("abort")
+	})
+	_, err := l.Launch()
+	g.Eq(err.Error(), "abort")
+}
+
+func TestGracefulClose(t *testing.T) {
+	g := setup(t)
+
+	if runtime.GOOS == "windows" {
+		g.SkipNow()
+	}
+
+	l := New()
+	g.E(os.MkdirAll(l.Get(flags.UserDataDir), 0o755))
+	g.True(l.isTempUserDataDir())
+
+	killed := 0
+	l.PreKill(func(pid int) {
+		g.Eq(pid, l.PID())
+		killed++
+	})
+
+	// a browser that ignores the terminate signal, so all the stages will run
+	cmd := exec.Command("sh", "-c", `trap "" TERM; sleep 100`)
+	l.osSetupCmd(cmd)
+	g.E(cmd.Start())
+	l.pid = cmd.Process.Pid
+	launched.add(l)
+	go func() {
+		_ = cmd.Wait()
+		close(l.exit)
+	}()
+
+	start := time.Now()
+	g.E(l.GracefulClose(time.Second))
+	g.Lt(time.Since(start), 1500*time.Millisecond)
+	g.Eq(killed, 1)
+	g.False(utils.FileExists(l.Get(flags.UserDataDir)))
+	g.Len(launched.list, 0)
+
+	l.Kill()
+	g.Eq(killed, 1)
+}
+
+func TestKillRemovesLaunched(t *testing.T) {
+	g := setup(t)
+
+	if runtime.GOOS == "windows" {
+		g.SkipNow()
+	}
+
+	l := New()
+
+	cmd := exec.Command("sleep", "100")
+	l.osSetupCmd(cmd)
+	g.E(cmd.Start())
+	go func() { _ = cmd.Wait() }()
+	l.pid = cmd.Process.Pid
+	launched.add(l)
+
+	l.Kill()
+	g.Len(launched.list, 0)
+}
+
+func TestCleanupOrphans(t *testing.T) {
+	g := setup(t)
+
+	if runtime.GOOS == "windows" {
+		g.SkipNow()
+	}
+
+	old := DefaultReaperDir
+	DefaultReaperDir = t.TempDir()
+	g.Cleanup(func() { DefaultReaperDir = old })
+
+	dead := exec.Command("go", "version")
+	g.E(dead.Run())
+	deadOwner := dead.Process.Pid
+
+	orphan := exec.Command("sh", "-c", "sleep 100", reaperTokenSwitch+"abc")
+	g.E(orphan.Start())
+	go func() { _ = orphan.Wait() }()
+
+	other := exec.Command("sleep", "100")
+	g.E(other.Start())
+	g.Cleanup(func() { _ = other.Process.Kill() })
+
+	dir := filepath.Join(DefaultUserDataDirPrefix, utils.RandString(8))
+	g.E(os.MkdirAll(dir, 0o755))
+
+	save := func(r *ReaperRecord) {
+		b, _ := json.Marshal(r)
+		g.E(utils.OutputFile(r.path(), b))
+	}
+	save(&ReaperRecord{PID: orphan.Process.Pid, Owner: deadOwner, Token: "abc", UserDataDir: dir})
+	save(&ReaperRecord{PID: other.Process.Pid, Owner: deadOwner, Token: "xyz"})
+	save(&ReaperRecord{PID: 1, Owner: os.Getpid(), Token: "abc"})
+
+	killed, err := CleanupOrphans()
+	g.E(err)
+	g.Eq(killed, []int{orphan.Process.Pid})
+	g.False(utils.FileExists(dir))
+	g.True(processAlive(other.Process.Pid))
+
+	records, err := ReaperRecords()
+	g.E(err)
+	g.Len(records, 1)
+	g.Eq(records[0].Owner, os.Getpid())
+}
+
+func TestReaperToken(t *testing.T) {
+	g := setup(t)
+
+	l := New()
+	g.Eq(l.reaperToken(), "")
+
+	l.Reaper(true)
+	token := l.reaperToken()
+	g.Len(token, 16)
+	g.Eq(l.reaperToken(), token)
+}
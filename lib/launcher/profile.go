@@ -0,0 +1,258 @@
+package launcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Fromsko/rodPro/lib/launcher/flags"
+	"github.com/Fromsko/rodPro/lib/utils"
+)
+
+// UserProfile of the browser
+type UserProfile struct {
+	// Dir is the folder name of the profile in the user data dir, such as "Default" or "Profile 1"
+	Dir string
+
+	// Name is the display name of the profile, such as "Person 1"
+	Name string
+}
+
+// UserDataDirs returns the often used user data dirs of the browsers of current user on current operating system.
+// Doc: https://chromium.googlesource.com/chromium/src/+/master/docs/user_data_dir.md
+func UserDataDirs() []string {
+	list := []string{}
+	for _, d := range userDataDirs() {
+		list = append(list, d.dir)
+	}
+	return list
+}
+
+type userDataDirOfBrowser struct {
+	keyword string // the keyword in the lower case path of the browser executable
+	dir     string
+}
+
+func userDataDirs() []userDataDirOfBrowser {
+	home, _ := os.UserHomeDir()
+
+	switch runtime.GOOS {
+	case "darwin":
+		base := filepath.Join(home, "Library", "Application Support")
+		return []userDataDirOfBrowser{
+			{"chrome", filepath.Join(base, "Google", "Chrome")},
+			{"chromium", filepath.Join(base, "Chromium")},
+			{"edge", filepath.Join(base, "Microsoft Edge")},
+			{"chrome canary", filepath.Join(base, "Google", "Chrome Canary")},
+		}
+	case "windows":
+		base := os.Getenv("LOCALAPPDATA")
+		return []userDataDirOfBrowser{
+			{"chrome", filepath.Join(base, "Google", "Chrome", "User Data")},
+			{"chromium", filepath.Join(base, "Chromium", "User Data")},
+			{"edge", filepath.Join(base, "Microsoft", "Edge", "User Data")},
+		}
+	default:
+		base := os.Getenv("XDG_CONFIG_HOME")
+		if base == "" {
+			base = filepath.Join(home, ".config")
+		}
+		return []userDataDirOfBrowser{
+			{"chrome", filepath.Join(base, "google-chrome")},
+			{"chromium", filepath.Join(base, "chromium")},
+			{"edge", filepath.Join(base, "microsoft-edge")},
+			{"chrome-beta", filepath.Join(base, "google-chrome-beta")},
+		}
+	}
+}
+
+// userDataDirOf returns the default user data dir of the browser executable, empty string if the browser is unknown.
+// The longest keyword wins, such as the "chrome canary" over the "chrome".
+func userDataDirOf(bin string) string {
+	bin = strings.ToLower(bin)
+
+	found := userDataDirOfBrowser{}
+	for _, d := range userDataDirs() {
+		if strings.Contains(bin, d.keyword) && len(d.keyword) > len(found.keyword) {
+			found = d
+		}
+	}
+	return found.dir
+}
+
+// DefaultUserDataDir returns the first existing dir of [UserDataDirs], empty string if not found.
+func DefaultUserDataDir() string {
+	for _, dir := range UserDataDirs() {
+		if utils.FileExists(filepath.Join(dir, "Local State")) {
+			return dir
+		}
+	}
+	return ""
+}
+
+// UserProfiles lists the profiles in the user data dir, they are sorted by the Dir.
+func UserProfiles(userDataDir string) ([]*UserProfile, error) {
+	b, err := ioutil.ReadFile(filepath.Join(userDataDir, "Local State"))
+	if err != nil {
+		return nil, err
+	}
+
+	var state struct {
+		Profile struct {
+			InfoCache map[string]struct {
+				Name string `json:"name"`
+			} `json:"info_cache"`
+		} `json:"profile"`
+	}
+	err = json.Unmarshal(b, &state)
+	if err != nil {
+		return nil, err
+	}
+
+	list := []*UserProfile{}
+	for dir, info := range state.Profile.InfoCache {
+		list = append(list, &UserProfile{Dir: dir, Name: info.Name})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Dir < list[j].Dir })
+
+	return list, nil
+}
+
+// UserProfile selects the profile to use via its display name or dir name, such as "Person 1" or "Profile 1".
+// If not found, the name will be used as the dir name, the browser will create the profile if it doesn't exist.
+func (l *Launcher) UserProfile(name string) *Launcher {
+	list, _ := UserProfiles(l.userDataDir())
+	for _, p := range list {
+		if p.Name == name || p.Dir == name {
+			return l.ProfileDir(p.Dir)
+		}
+	}
+	return l.ProfileDir(name)
+}
+
+// CloneProfile switch. If enabled, before launch the selected profile will be copied to a new temp user data dir,
+// so that a separate browser can use the profile while the browser that locks the original user data dir is running.
+func (l *Launcher) CloneProfile(enable bool) *Launcher {
+	l.cloneProfile = enable
+	return l
+}
+
+// the user data dir that the browser will use, it's the default one of the browser executable if not set
+func (l *Launcher) userDataDir() string {
+	if dir := l.Get(flags.UserDataDir); dir != "" {
+		return dir
+	}
+	if dir := userDataDirOf(l.Get(flags.Bin)); dir != "" {
+		return dir
+	}
+	return DefaultUserDataDir()
+}
+
+// ActivePortURL returns the websocket url of the running browser that uses the user data dir.
+// The browser writes the port to the DevToolsActivePort file when its remote debugging is enabled.
+func ActivePortURL(userDataDir string) (string, error) {
+	b, err := ioutil.ReadFile(filepath.Join(userDataDir, "DevToolsActivePort"))
+	if err != nil {
+		return "", ErrNoActivePort
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	if len(lines) < 2 {
+		return "", ErrNoActivePort
+	}
+
+	host := net.JoinHostPort("127.0.0.1", strings.TrimSpace(lines[0]))
+
+	// the file may be left by a crashed browser
+	conn, err := net.DialTimeout("tcp", host, time.Second)
+	if err != nil {
+		return "", ErrNoActivePort
+	}
+	_ = conn.Close()
+
+	return fmt.Sprintf("ws://%s%s", host, strings.TrimSpace(lines[1])), nil
+}
+
+// copy the selected profile and the "Local State" to a new temp user data dir
+func (l *Launcher) cloneUserProfile() error {
+	src := l.userDataDir()
+	if src == "" {
+		return ErrUserDataDirNotFound
+	}
+
+	profile := l.Get(flags.ProfileDir)
+	if profile == "" {
+		profile = "Default"
+	}
+
+	dst := filepath.Join(DefaultUserDataDirPrefix, utils.RandString(8))
+
+	err := copyPath(filepath.Join(src, "Local State"), filepath.Join(dst, "Local State"))
+	if err != nil {
+		return err
+	}
+
+	err = copyPath(filepath.Join(src, profile), filepath.Join(dst, profile))
+	if err != nil {
+		return err
+	}
+
+	l.UserDataDir(dst)
+	return nil
+}
+
+// copyPath recursively, the lock files of the browser will be skipped
+func copyPath(src, dst string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		switch {
+		case strings.HasPrefix(info.Name(), "Singleton"), info.Name() == "LOCK", info.Name() == "lockfile":
+			return nil
+		case info.IsDir():
+			return utils.Mkdir(target)
+		case !info.Mode().IsRegular():
+			return nil
+		}
+
+		return copyFile(path, target)
+	})
+}
+
+func copyFile(src, dst string) error {
+	r, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = r.Close() }()
+
+	err = utils.Mkdir(filepath.Dir(dst))
+	if err != nil {
+		return err
+	}
+
+	w, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = w.Close() }()
+
+	_, err = io.Copy(w, r)
+	return err
+}
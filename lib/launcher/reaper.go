@@ -0,0 +1,141 @@
+package launcher
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Fromsko/rodPro/lib/launcher/flags"
+	"github.com/Fromsko/rodPro/lib/utils"
+)
+
+// DefaultReaperDir is the dir to store the records of the browsers launched with [Launcher.Reaper].
+var DefaultReaperDir = filepath.Join(os.TempDir(), "rod", "reaper")
+
+// reaperTokenSwitch is passed to the browser to identify the process, the browser ignores unknown switches.
+const reaperTokenSwitch = "--rod-reaper-token="
+
+// Reaper is a pure Go alternative to [Launcher.Leakless] for environments that block the leakless helper binary.
+// If enabled, the pid of the launched browser will be recorded in [DefaultReaperDir], when the Go process
+// exits without closing the browser, call [CleanupOrphans] on the next run to kill the orphan browser.
+func (l *Launcher) Reaper(enable bool) *Launcher {
+	if enable {
+		return l.Set(flags.Reaper)
+	}
+	return l.Delete(flags.Reaper)
+}
+
+// ReaperRecord of a browser launched with [Launcher.Reaper].
+type ReaperRecord struct {
+	// PID of the browser process
+	PID int `json:"pid"`
+
+	// Owner is the pid of the Go process that launched the browser
+	Owner int `json:"owner"`
+
+	// Token is passed to the browser via command line to make sure the PID is not reused by other processes
+	Token string `json:"token"`
+
+	// UserDataDir of the browser, it will be removed if it's a temp dir
+	UserDataDir string `json:"userDataDir"`
+
+	// Started time of the browser
+	Started time.Time `json:"started"`
+}
+
+func (r *ReaperRecord) path() string {
+	return filepath.Join(DefaultReaperDir, strconv.Itoa(r.PID)+".json")
+}
+
+func (l *Launcher) reaperToken() string {
+	if !l.Has(flags.Reaper) {
+		return ""
+	}
+	if l.reaper == nil {
+		l.reaper = &ReaperRecord{Token: utils.RandString(8)}
+	}
+	return l.reaper.Token
+}
+
+func (l *Launcher) saveReaperRecord() error {
+	if l.reaper == nil {
+		return nil
+	}
+
+	l.reaper.PID = l.PID()
+	l.reaper.Owner = os.Getpid()
+	l.reaper.UserDataDir = l.Get(flags.UserDataDir)
+	l.reaper.Started = time.Now()
+
+	b, err := json.Marshal(l.reaper)
+	if err != nil {
+		return err
+	}
+	return utils.OutputFile(l.reaper.path(), b)
+}
+
+func (l *Launcher) removeReaperRecord() {
+	if l.reaper == nil {
+		return
+	}
+	_ = os.Remove(l.reaper.path())
+}
+
+// ReaperRecords returns all the records in [DefaultReaperDir].
+func ReaperRecords() ([]*ReaperRecord, error) {
+	list, err := filepath.Glob(filepath.Join(DefaultReaperDir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	records := []*ReaperRecord{}
+	for _, p := range list {
+		b, err := os.ReadFile(p)
+		if err != nil {
+			continue
+		}
+
+		var r ReaperRecord
+		if json.Unmarshal(b, &r) != nil {
+			_ = os.Remove(p)
+			continue
+		}
+		records = append(records, &r)
+	}
+	return records, nil
+}
+
+// CleanupOrphans kills the browsers launched with [Launcher.Reaper] whose owner process no longer exists,
+// and removes their temp user data dirs. It returns the pids of the killed browsers.
+// The command line of each process is checked against the record token, so a reused pid won't be killed.
+func CleanupOrphans() (killed []int, err error) {
+	records, err := ReaperRecords()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range records {
+		if r.Owner == os.Getpid() || processAlive(r.Owner) {
+			continue
+		}
+
+		if processAlive(r.PID) && strings.Contains(processCmdline(r.PID), reaperTokenSwitch+r.Token) {
+			killGroup(r.PID)
+			if p, err := os.FindProcess(r.PID); err == nil {
+				_ = p.Kill()
+			}
+			killed = append(killed, r.PID)
+		}
+
+		if strings.HasPrefix(r.UserDataDir, DefaultUserDataDirPrefix+string(filepath.Separator)) {
+			_ = os.RemoveAll(r.UserDataDir)
+		}
+
+		_ = os.Remove(r.path())
+	}
+
+	return killed, nil
+}
@@ -0,0 +1,469 @@
+// Package mock is a lightweight API mocking layer for the e2e tests on top of the hijack router.
+// Define the REST or GraphQL endpoints with their sequential responses, register the server to a
+// [rod.HijackRouter], then assert the recorded calls of each endpoint:
+//
+//	s := mock.New("https://api.test.com")
+//	user := s.GET("/users/:id").Reply(200, map[string]string{"name": "joy"})
+//	create := s.POST("/users").Schema(`{"type": "object", "required": ["name"]}`).Reply(201, nil)
+//	s.GraphQL("/graphql", "GetPosts").Reply(200, map[string]interface{}{"data": posts})
+//
+//	router := page.HijackRequests()
+//	s.MustRegister(router)
+//	go router.Run()
+//
+//	// ...
+//
+//	user.AssertCalled(t, 1)
+//	create.AssertCalled(t, 0)
+package mock
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Fromsko/rodPro"
+	"github.com/Fromsko/rodPro/lib/proto"
+	"github.com/ysmood/gson"
+)
+
+// Fallback policy when no endpoint matches a request
+type Fallback int
+
+const (
+	// FallbackNotFound responds with a 404 json error
+	FallbackNotFound Fallback = iota
+
+	// FallbackContinue sends the request to the real destination
+	FallbackContinue
+
+	// FallbackFail fails the request with [proto.NetworkErrorReasonInternetDisconnected]
+	FallbackFail
+)
+
+// Server of the mocked endpoints
+type Server struct {
+	// Base url of the endpoints, such as "https://api.test.com"
+	Base string
+
+	// Fallback policy, default is [FallbackNotFound]
+	Fallback Fallback
+
+	lock      sync.Mutex
+	endpoints []*Endpoint
+}
+
+// New server for the base url
+func New(base string) *Server {
+	return &Server{Base: strings.TrimSuffix(base, "/")}
+}
+
+// Endpoint defines a mocked endpoint of the method and path. The segments of the path that start with ":" are
+// params, such as "/users/:id", a "*" at the end matches the rest of the path. If method is empty, any method matches.
+// The earlier defined endpoints take precedence.
+func (s *Server) Endpoint(method, path string) *Endpoint {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	e := &Endpoint{Method: strings.ToUpper(method), Path: path}
+	s.endpoints = append(s.endpoints, e)
+	return e
+}
+
+// GET endpoint
+func (s *Server) GET(path string) *Endpoint { return s.Endpoint(http.MethodGet, path) }
+
+// POST endpoint
+func (s *Server) POST(path string) *Endpoint { return s.Endpoint(http.MethodPost, path) }
+
+// PUT endpoint
+func (s *Server) PUT(path string) *Endpoint { return s.Endpoint(http.MethodPut, path) }
+
+// PATCH endpoint
+func (s *Server) PATCH(path string) *Endpoint { return s.Endpoint(http.MethodPatch, path) }
+
+// DELETE endpoint
+func (s *Server) DELETE(path string) *Endpoint { return s.Endpoint(http.MethodDelete, path) }
+
+// GraphQL endpoint of the operation, it matches the POST requests to the path whose operationName is the operation,
+// or whose query defines the operation. The [Endpoint.Schema] validates the variables of the operation.
+func (s *Server) GraphQL(path, operation string) *Endpoint {
+	e := s.Endpoint(http.MethodPost, path)
+	e.Operation = operation
+	return e
+}
+
+// Register the server to the router to handle the requests of the base url
+func (s *Server) Register(router *rod.HijackRouter) error {
+	return router.Add(s.Base+"/*", "", s.Handle)
+}
+
+// MustRegister is similar to [Server.Register]
+func (s *Server) MustRegister(router *rod.HijackRouter) *Server {
+	err := s.Register(router)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// Handle is a hijack handler
+func (s *Server) Handle(ctx *rod.Hijack) {
+	body, err := ctx.Request.RawBody()
+	if err != nil {
+		ctx.OnError(err)
+		ctx.Response.Fail(proto.NetworkErrorReasonFailed)
+		return
+	}
+
+	req := ctx.Request.Req().Clone(ctx.Request.Req().Context())
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	res := s.Serve(req)
+	if res == nil && req.Method == http.MethodOptions {
+		// the cors preflight
+		res = &Response{Status: http.StatusNoContent, Header: http.Header{
+			"Access-Control-Allow-Methods": {"*"},
+			"Access-Control-Allow-Headers": {"*"},
+		}}
+	}
+	if res == nil {
+		switch s.Fallback {
+		case FallbackContinue:
+			ctx.ContinueRequest(&proto.FetchContinueRequest{})
+		case FallbackFail:
+			ctx.Response.Fail(proto.NetworkErrorReasonInternetDisconnected)
+		default:
+			res = jsonError(http.StatusNotFound, "no mock for "+req.Method+" "+req.URL.Path)
+		}
+		if res == nil {
+			return
+		}
+	}
+
+	if res.Delay > 0 {
+		t := time.NewTimer(res.Delay)
+		select {
+		case <-req.Context().Done():
+		case <-t.C:
+		}
+		t.Stop()
+	}
+
+	ctx.Response.Payload().ResponseCode = res.Status
+	for k, vs := range res.Header {
+		for _, v := range vs {
+			ctx.Response.SetHeader(k, v)
+		}
+	}
+	if res.Header.Get("Access-Control-Allow-Origin") == "" {
+		ctx.Response.SetHeader("Access-Control-Allow-Origin", "*")
+	}
+	ctx.Response.SetBody(res.body())
+}
+
+// Serve the request, it records the call of the matched endpoint and returns its next response,
+// returns nil if no endpoint matches.
+func (s *Server) Serve(req *http.Request) *Response {
+	var body []byte
+	if req.Body != nil {
+		body, _ = ioutil.ReadAll(req.Body)
+	}
+
+	s.lock.Lock()
+	endpoints := append([]*Endpoint{}, s.endpoints...)
+	s.lock.Unlock()
+
+	path := strings.TrimPrefix(req.URL.Path, s.basePath())
+
+	for _, e := range endpoints {
+		if e.Method != "" && e.Method != req.Method {
+			continue
+		}
+
+		params, ok := matchPath(e.Path, path)
+		if !ok {
+			continue
+		}
+
+		call := &Call{
+			Method: req.Method,
+			URL:    req.URL,
+			Params: params,
+			Header: req.Header,
+			Body:   body,
+			Time:   time.Now(),
+		}
+
+		data := gson.New(nil)
+		if e.Operation != "" || e.schema != nil {
+			data = gson.New(body)
+		}
+
+		if e.Operation != "" {
+			if !matchOperation(data, e.Operation) {
+				continue
+			}
+			vars, has := data.Gets("variables")
+			if !has || vars.Nil() {
+				vars = gson.New(map[string]interface{}{})
+			}
+			data = vars
+		}
+
+		if e.schema != nil {
+			call.Errors = e.schema.Validate(data)
+		}
+
+		return e.serve(call)
+	}
+
+	return nil
+}
+
+func (s *Server) basePath() string {
+	u, err := url.Parse(s.Base)
+	if err != nil {
+		return ""
+	}
+	return u.Path
+}
+
+// Endpoint of the [Server]
+type Endpoint struct {
+	Method string
+	Path   string
+
+	// Operation name of the GraphQL endpoint
+	Operation string
+
+	lock      sync.Mutex
+	schema    *Schema
+	responses []*Response
+	calls     []*Call
+}
+
+// Response of an [Endpoint]
+type Response struct {
+	Status int
+	Header http.Header
+
+	// Body is the raw body if it's []byte or string, else it will be encoded as json
+	Body interface{}
+
+	// Delay before the response is sent
+	Delay time.Duration
+}
+
+func (r *Response) body() []byte {
+	switch b := r.Body.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return b
+	case string:
+		return []byte(b)
+	}
+	b, _ := json.Marshal(r.Body)
+	return b
+}
+
+// Call of an [Endpoint]
+type Call struct {
+	Method string
+	URL    *url.URL
+
+	// Params of the path, such as the "id" of "/users/:id"
+	Params map[string]string
+
+	Header http.Header
+	Body   []byte
+	Time   time.Time
+
+	// Errors of the schema validation, the endpoint responds with 400 if it's not empty
+	Errors []string
+}
+
+// JSON body of the call
+func (c *Call) JSON() gson.JSON {
+	return gson.New(c.Body)
+}
+
+// Reply appends a response to the sequential responses, the calls get the responses in order,
+// the last one is repeated once the others are used. If body isn't []byte or string, it will be encoded as json.
+func (e *Endpoint) Reply(status int, body interface{}) *Endpoint {
+	return e.ReplyWith(&Response{Status: status, Body: body})
+}
+
+// ReplyWith is similar to [Endpoint.Reply], but the whole response can be customized
+func (e *Endpoint) ReplyWith(res *Response) *Endpoint {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if res.Status == 0 {
+		res.Status = http.StatusOK
+	}
+	e.responses = append(e.responses, res)
+	return e
+}
+
+// Schema validates the json body of the requests, the schema is a json string or a value that can be encoded as
+// json. If the body is invalid, the endpoint responds with 400 and the errors, check [Schema] for the keywords.
+func (e *Endpoint) Schema(schema interface{}) *Endpoint {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if s, ok := schema.(string); ok {
+		e.schema = &Schema{gson.NewFrom(s)}
+	} else {
+		b, _ := json.Marshal(schema)
+		e.schema = &Schema{gson.New(b)}
+	}
+	return e
+}
+
+// Calls returns the recorded calls
+func (e *Endpoint) Calls() []*Call {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return append([]*Call{}, e.calls...)
+}
+
+// Reset the recorded calls and the position of the sequential responses
+func (e *Endpoint) Reset() {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.calls = nil
+}
+
+// TestingT is the interface of [testing.TB] that [Endpoint.AssertCalled] uses
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// AssertCalled reports an error via t if the endpoint isn't called n times
+func (e *Endpoint) AssertCalled(t TestingT, n int) bool {
+	t.Helper()
+
+	if got := len(e.Calls()); got != n {
+		t.Errorf("expect %s %s to be called %d times, but got %d", e.name(), e.Path, n, got)
+		return false
+	}
+	return true
+}
+
+// WaitCalled waits until the endpoint is called at least n times
+func (e *Endpoint) WaitCalled(ctx context.Context, n int) error {
+	for len(e.Calls()) < n {
+		t := time.NewTimer(50 * time.Millisecond)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+	return nil
+}
+
+func (e *Endpoint) name() string {
+	if e.Operation != "" {
+		return "GraphQL " + e.Operation
+	}
+	if e.Method == "" {
+		return "*"
+	}
+	return e.Method
+}
+
+func (e *Endpoint) serve(call *Call) *Response {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	e.calls = append(e.calls, call)
+
+	if len(call.Errors) > 0 {
+		return jsonError(http.StatusBadRequest, call.Errors...)
+	}
+
+	if len(e.responses) == 0 {
+		return &Response{Status: http.StatusNoContent}
+	}
+
+	i := len(e.calls) - 1
+	if i >= len(e.responses) {
+		i = len(e.responses) - 1
+	}
+	return e.responses[i]
+}
+
+func jsonError(status int, errs ...string) *Response {
+	return &Response{
+		Status: status,
+		Header: http.Header{"Content-Type": {"application/json"}},
+		Body:   map[string]interface{}{"errors": errs},
+	}
+}
+
+// matchPath matches the path with the pattern, returns the params of the pattern
+func matchPath(pattern, path string) (map[string]string, bool) {
+	ps := strings.Split(strings.Trim(pattern, "/"), "/")
+	ss := strings.Split(strings.Trim(path, "/"), "/")
+	params := map[string]string{}
+
+	for i, p := range ps {
+		if p == "*" && i == len(ps)-1 {
+			params["*"] = strings.Join(ss[i:], "/")
+			return params, true
+		}
+		if i >= len(ss) {
+			return nil, false
+		}
+		if strings.HasPrefix(p, ":") {
+			v, err := url.PathUnescape(ss[i])
+			if err != nil {
+				return nil, false
+			}
+			params[p[1:]] = v
+			continue
+		}
+		if p != ss[i] {
+			return nil, false
+		}
+	}
+
+	return params, len(ps) == len(ss)
+}
+
+// matchOperation returns true if the GraphQL request is the operation
+func matchOperation(data gson.JSON, operation string) bool {
+	if data.Get("operationName").Str() == operation {
+		return true
+	}
+
+	query := data.Get("query").Str()
+	for _, kind := range []string{"query", "mutation", "subscription"} {
+		i := strings.Index(query, kind+" "+operation)
+		if i < 0 {
+			continue
+		}
+		rest := query[i+len(kind)+1+len(operation):]
+		if rest == "" || strings.ContainsAny(rest[:1], " ({") {
+			return true
+		}
+	}
+	return false
+}
+
+// String of the call for debugging
+func (c *Call) String() string {
+	return fmt.Sprintf("%s %s %s", c.Method, c.URL, string(c.Body))
+}
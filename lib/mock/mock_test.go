@@ -0,0 +1,145 @@
+package mock_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Fromsko/rodPro/lib/mock"
+	"github.com/ysmood/got"
+	"github.com/ysmood/gson"
+)
+
+func req(method, u, body string) *http.Request {
+	return httptest.NewRequest(method, u, strings.NewReader(body))
+}
+
+func TestServe(t *testing.T) {
+	g := got.T(t)
+
+	s := mock.New("https://api.test.com/v1/")
+	user := s.GET("/users/:id").Reply(200, map[string]string{"name": "joy"}).Reply(500, "down")
+	files := s.Endpoint("", "/files/*")
+	empty := s.DELETE("/users/:id")
+
+	res := s.Serve(req(http.MethodGet, "https://api.test.com/v1/users/a%20b?x=1", ""))
+	g.Eq(res.Status, 200)
+	g.Eq(res.Body, map[string]string{"name": "joy"})
+
+	g.Eq(s.Serve(req(http.MethodGet, "https://api.test.com/v1/users/2", "")).Status, 500)
+	g.Eq(s.Serve(req(http.MethodGet, "https://api.test.com/v1/users/3", "")).Status, 500)
+
+	calls := user.Calls()
+	g.Len(calls, 3)
+	g.Eq(calls[0].Params["id"], "a b")
+	g.Eq(calls[0].URL.Query().Get("x"), "1")
+
+	g.Nil(s.Serve(req(http.MethodPost, "https://api.test.com/v1/users/1", "")))
+	g.Nil(s.Serve(req(http.MethodGet, "https://api.test.com/v1/users", "")))
+	g.Nil(s.Serve(req(http.MethodGet, "https://api.test.com/v1/users/1/posts", "")))
+
+	s.Serve(req(http.MethodPut, "https://api.test.com/v1/files/a/b.txt", "data"))
+	g.Eq(files.Calls()[0].Params["*"], "a/b.txt")
+	g.Eq(string(files.Calls()[0].Body), "data")
+
+	g.Eq(s.Serve(req(http.MethodDelete, "https://api.test.com/v1/users/1", "")).Status, http.StatusNoContent)
+	empty.AssertCalled(t, 1)
+
+	user.Reset()
+	g.Eq(s.Serve(req(http.MethodGet, "https://api.test.com/v1/users/1", "")).Status, 200)
+}
+
+func TestSchema(t *testing.T) {
+	g := got.T(t)
+
+	s := mock.New("http://test.com")
+	create := s.POST("/users").Schema(`{
+		"type": "object",
+		"required": ["name", "age"],
+		"additionalProperties": false,
+		"properties": {
+			"name": { "type": "string", "minLength": 2, "pattern": "^[a-z]+$" },
+			"age": { "type": "integer", "minimum": 0 },
+			"role": { "enum": ["admin", "user"] },
+			"tags": { "type": "array", "maxItems": 2, "items": { "type": ["string", "null"] } }
+		}
+	}`).Reply(201, nil)
+
+	g.Eq(s.Serve(req(http.MethodPost, "http://test.com/users", `{"name": "joy", "age": 10, "tags": ["a", null]}`)).Status, 201)
+
+	res := s.Serve(req(http.MethodPost, "http://test.com/users",
+		`{"name": "J", "age": 1.5, "role": "x", "tags": ["a", 1, "b"], "x": 1}`))
+	g.Eq(res.Status, 400)
+	g.Eq(gson.New(res.Body).JSON("", ""), `{"errors":[`+
+		`"$.age: expect \"integer\", got number",`+
+		`"$.name: expect at least 2 characters, got 1",`+
+		`"$.name: expect to match \"^[a-z]+$\", got \"J\"",`+
+		`"$.role: expect one of [\"admin\",\"user\"], got \"x\"",`+
+		`"$.tags: expect at most 2 items, got 3",`+
+		`"$.tags[1]: expect [\"string\",\"null\"], got number",`+
+		`"$: unexpected property \"x\""]}`)
+
+	g.Eq(s.Serve(req(http.MethodPost, "http://test.com/users", `[]`)).Status, 400)
+	g.Len(create.Calls()[2].Errors, 1)
+
+	res = s.Serve(req(http.MethodPost, "http://test.com/users", `{}`))
+	g.Len(create.Calls()[3].Errors, 2)
+	g.Eq(res.Status, 400)
+
+	typed := s.PUT("/n").Schema(map[string]interface{}{"type": "number", "maximum": 1, "const": 1})
+	g.Len(s.Serve(req(http.MethodPut, "http://test.com/n", `2`)).Body, 1)
+	g.Len(typed.Calls()[0].Errors, 1)
+}
+
+func TestGraphQL(t *testing.T) {
+	g := got.T(t)
+
+	s := mock.New("http://test.com")
+	posts := s.GraphQL("/graphql", "GetPosts").Schema(`{"required": ["id"]}`).Reply(200, `{"data":{"posts":[]}}`)
+	user := s.GraphQL("/graphql", "GetUser").Reply(200, "user")
+
+	g.Eq(s.Serve(req(http.MethodPost, "http://test.com/graphql",
+		`{"query": "query GetPosts($id: ID) { posts }", "variables": {"id": 1}}`)).Body, `{"data":{"posts":[]}}`)
+
+	g.Eq(s.Serve(req(http.MethodPost, "http://test.com/graphql",
+		`{"operationName": "GetUser", "query": "{ user }"}`)).Body, "user")
+
+	g.Eq(s.Serve(req(http.MethodPost, "http://test.com/graphql", `{"query": "query GetPosts { posts }"}`)).Status, 400)
+
+	g.Nil(s.Serve(req(http.MethodPost, "http://test.com/graphql", `{"query": "query GetPostsAll { posts }"}`)))
+
+	posts.AssertCalled(t, 2)
+	user.AssertCalled(t, 1)
+	g.Eq(posts.Calls()[0].JSON().Get("variables.id").Int(), 1)
+	g.Has(posts.Calls()[0].String(), "POST http://test.com/graphql")
+}
+
+type mockT struct {
+	errs []string
+}
+
+func (t *mockT) Helper() {}
+
+func (t *mockT) Errorf(format string, args ...interface{}) {
+	t.errs = append(t.errs, fmt.Sprintf(format, args...))
+}
+
+func TestAssertCalled(t *testing.T) {
+	g := got.T(t)
+
+	s := mock.New("http://test.com")
+	e := s.GET("/a")
+
+	mt := &mockT{}
+	g.False(e.AssertCalled(mt, 1))
+	g.Eq(mt.errs, []string{"expect GET /a to be called 1 times, but got 0"})
+
+	s.Serve(req(http.MethodGet, "http://test.com/a", ""))
+	g.True(e.AssertCalled(mt, 1))
+	g.E(e.WaitCalled(g.Context(), 1))
+
+	ctx := g.Timeout(100 * 1e6)
+	g.Err(e.WaitCalled(ctx, 2))
+}
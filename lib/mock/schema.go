@@ -0,0 +1,163 @@
+package mock
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/ysmood/gson"
+)
+
+// Schema is a subset of the JSON Schema, the supported keywords are:
+// type, enum, const, properties, required, additionalProperties, items,
+// minItems, maxItems, minLength, maxLength, pattern, minimum, and maximum.
+type Schema struct {
+	gson.JSON
+}
+
+// Validate the value, returns the errors, each error is prefixed with the path of the invalid value, such as "$.a[0]"
+func (s *Schema) Validate(v gson.JSON) []string {
+	return validate(s.JSON, v, "$")
+}
+
+func validate(schema, v gson.JSON, path string) []string {
+	if schema.Nil() {
+		return nil
+	}
+
+	errs := []string{}
+	fail := func(format string, args ...interface{}) []string {
+		return append(errs, path+": "+fmt.Sprintf(format, args...))
+	}
+
+	if types := schema.Get("type"); !types.Nil() {
+		list := []gson.JSON{types}
+		if _, ok := types.Val().([]interface{}); ok {
+			list = types.Arr()
+		}
+		ok := false
+		for _, t := range list {
+			ok = ok || isType(v, t.Str())
+		}
+		if !ok {
+			return fail("expect %s, got %s", types.JSON("", ""), typeOf(v))
+		}
+	}
+
+	if enum, has := schema.Gets("enum"); has {
+		ok := false
+		for _, e := range enum.Arr() {
+			ok = ok || reflect.DeepEqual(e.Val(), v.Val())
+		}
+		if !ok {
+			return fail("expect one of %s, got %s", enum.JSON("", ""), v.JSON("", ""))
+		}
+	}
+
+	if c, has := schema.Gets("const"); has && !reflect.DeepEqual(c.Val(), v.Val()) {
+		return fail("expect %s, got %s", c.JSON("", ""), v.JSON("", ""))
+	}
+
+	switch val := v.Val().(type) {
+	case map[string]interface{}:
+		for _, k := range schema.Get("required").Arr() {
+			if _, has := val[k.Str()]; !has {
+				errs = fail("missing required property %q", k.Str())
+			}
+		}
+
+		props := schema.Get("properties").Map()
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			if p, has := props[k]; has {
+				errs = append(errs, validate(p, v.Get(k), path+"."+k)...)
+				continue
+			}
+
+			if add, has := schema.Gets("additionalProperties"); has {
+				if b, ok := add.Val().(bool); ok {
+					if !b {
+						errs = fail("unexpected property %q", k)
+					}
+				} else {
+					errs = append(errs, validate(add, v.Get(k), path+"."+k)...)
+				}
+			}
+		}
+
+	case []interface{}:
+		if min, has := schema.Gets("minItems"); has && len(val) < min.Int() {
+			errs = fail("expect at least %d items, got %d", min.Int(), len(val))
+		}
+		if max, has := schema.Gets("maxItems"); has && len(val) > max.Int() {
+			errs = fail("expect at most %d items, got %d", max.Int(), len(val))
+		}
+		if items, has := schema.Gets("items"); has {
+			for i := range val {
+				errs = append(errs, validate(items, v.Get(fmt.Sprint(i)), fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+
+	case string:
+		n := utf8.RuneCountInString(val)
+		if min, has := schema.Gets("minLength"); has && n < min.Int() {
+			errs = fail("expect at least %d characters, got %d", min.Int(), n)
+		}
+		if max, has := schema.Gets("maxLength"); has && n > max.Int() {
+			errs = fail("expect at most %d characters, got %d", max.Int(), n)
+		}
+		if p, has := schema.Gets("pattern"); has {
+			reg, err := regexp.Compile(p.Str())
+			if err != nil || !reg.MatchString(val) {
+				errs = fail("expect to match %q, got %q", p.Str(), val)
+			}
+		}
+
+	case float64:
+		if min, has := schema.Gets("minimum"); has && val < min.Num() {
+			errs = fail("expect >= %v, got %v", min.Num(), val)
+		}
+		if max, has := schema.Gets("maximum"); has && val > max.Num() {
+			errs = fail("expect <= %v, got %v", max.Num(), val)
+		}
+	}
+
+	return errs
+}
+
+func isType(v gson.JSON, t string) bool {
+	switch t {
+	case "integer":
+		n, ok := v.Val().(float64)
+		return ok && n == float64(int64(n))
+	case "number":
+		_, ok := v.Val().(float64)
+		return ok
+	}
+	return typeOf(v) == t
+}
+
+func typeOf(v gson.JSON) string {
+	switch v.Val().(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return "unknown"
+}
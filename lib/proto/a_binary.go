@@ -0,0 +1,285 @@
+package proto
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrFieldNotFound is returned by [RawField] when the json object doesn't have the field
+var ErrFieldNotFound = errors.New("json field not found")
+
+// ErrInvalidJSON is returned by [RawField] when the data is not a valid json object
+var ErrInvalidJSON = errors.New("invalid json object")
+
+// RawField returns the raw json value of the field of the top-level json object, the nested values are skipped
+// without being decoded. The returned value references the memory of the raw, it's not a copy.
+// It's useful to extract a large field, such as the base64 data of a screenshot, from the raw result of a call.
+func RawField(raw []byte, field string) ([]byte, error) {
+	s := &jsonScanner{data: raw}
+
+	s.space()
+	if !s.consume('{') {
+		return nil, ErrInvalidJSON
+	}
+
+	for {
+		s.space()
+		if s.consume('}') {
+			return nil, fmt.Errorf("%w: %s", ErrFieldNotFound, field)
+		}
+
+		key, ok := s.str()
+		if !ok {
+			return nil, ErrInvalidJSON
+		}
+
+		s.space()
+		if !s.consume(':') {
+			return nil, ErrInvalidJSON
+		}
+		s.space()
+
+		start := s.i
+		if !s.value() {
+			return nil, ErrInvalidJSON
+		}
+		if string(key[1:len(key)-1]) == field {
+			return raw[start:s.i], nil
+		}
+
+		s.space()
+		s.consume(',')
+	}
+}
+
+// DecodeBase64Field decodes the base64 string field of the top-level json object into w, such as the "data" field
+// of the result of Page.captureScreenshot. The base64 is decoded in a streaming way, neither the whole json nor the
+// whole binary is materialized in memory, which cuts the memory spike of the large payloads.
+func DecodeBase64Field(raw []byte, field string, w io.Writer) (int64, error) {
+	val, err := RawField(raw, field)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(val) < 2 || val[0] != '"' {
+		return 0, fmt.Errorf("%w: %s is not a string", ErrInvalidJSON, field)
+	}
+	val = val[1 : len(val)-1]
+
+	// the only escape that may appear in a base64 string is the escaped slash
+	if bytes.IndexByte(val, '\\') >= 0 {
+		val = bytes.ReplaceAll(val, []byte(`\/`), []byte(`/`))
+	}
+
+	return io.Copy(w, base64.NewDecoder(base64.StdEncoding, bytes.NewReader(val)))
+}
+
+// Bytes returns the PayloadData as binary, the payload of the non-text message is base64 encoded
+func (f NetworkWebSocketFrame) Bytes() ([]byte, error) {
+	return decodeBase64(f.PayloadData, f.Opcode != 1)
+}
+
+func decodeBase64(s string, encoded bool) ([]byte, error) {
+	if !encoded {
+		return []byte(s), nil
+	}
+	return base64.StdEncoding.DecodeString(s)
+}
+
+// Reader returns a reader of the stream, such as the stream of [PagePrintToPDFResult] or [TracingTracingComplete]
+func (h IOStreamHandle) Reader(c Client) *IOStreamReader {
+	return &IOStreamReader{c: c, handle: h, buf: &bytes.Buffer{}}
+}
+
+var _ io.ReadCloser = &IOStreamReader{}
+
+var _ io.WriterTo = &IOStreamReader{}
+
+// IOStreamReader reads the stream via IO.read, the base64 chunks are decoded transparently.
+// Use [IOStreamHandle.Reader] to create it.
+type IOStreamReader struct {
+	// Offset to seek before each read, it's optional. Some types of streams only support sequential reads.
+	Offset *int
+
+	c      Client
+	handle IOStreamHandle
+	buf    *bytes.Buffer
+}
+
+// Read interface
+func (r *IOStreamReader) Read(p []byte) (n int, err error) {
+	if r.buf.Len() > 0 {
+		return r.buf.Read(p)
+	}
+
+	_, err = r.readChunk(r.buf)
+	if err != nil {
+		return 0, err
+	}
+
+	return r.buf.Read(p)
+}
+
+// WriteTo writes the rest of the stream to w, each chunk is decoded into w directly without being buffered,
+// so that io.Copy can save a large pdf or trace to a file with a small memory footprint.
+func (r *IOStreamReader) WriteTo(w io.Writer) (n int64, err error) {
+	n, err = r.buf.WriteTo(w)
+	if err != nil {
+		return
+	}
+
+	cw := &countWriter{w: w}
+	defer func() { n += cw.n }()
+
+	for {
+		eof, err := r.readChunk(cw)
+		if err != nil || eof {
+			return n, err
+		}
+	}
+}
+
+// Close the stream, discard any temporary backing storage.
+func (r *IOStreamReader) Close() error {
+	return IOClose{Handle: r.handle}.Call(r.c)
+}
+
+// readChunk reads the next chunk of the stream into w, the base64 data is decoded from the raw
+// response in a streaming way to avoid copying the large chunks.
+func (r *IOStreamReader) readChunk(w io.Writer) (eof bool, err error) {
+	req := IORead{Handle: r.handle, Offset: r.Offset}
+	raw, err := CallRaw(r.c, req)
+	if err != nil {
+		return false, err
+	}
+
+	var res struct {
+		Base64Encoded bool `json:"base64Encoded"`
+		EOF           bool `json:"eof"`
+	}
+	err = json.Unmarshal(raw, &res)
+	if err != nil {
+		return false, err
+	}
+
+	if res.Base64Encoded {
+		_, err = DecodeBase64Field(raw, "data", w)
+		return res.EOF, err
+	}
+
+	data, err := RawField(raw, "data")
+	if err != nil {
+		return false, err
+	}
+	var str string
+	err = json.Unmarshal(data, &str)
+	if err != nil {
+		return false, err
+	}
+	_, err = io.WriteString(w, str)
+	return res.EOF, err
+}
+
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (w *countWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.n += int64(n)
+	return n, err
+}
+
+type jsonScanner struct {
+	data []byte
+	i    int
+}
+
+func (s *jsonScanner) space() {
+	for s.i < len(s.data) {
+		switch s.data[s.i] {
+		case ' ', '\t', '\r', '\n':
+			s.i++
+		default:
+			return
+		}
+	}
+}
+
+func (s *jsonScanner) consume(c byte) bool {
+	if s.i < len(s.data) && s.data[s.i] == c {
+		s.i++
+		return true
+	}
+	return false
+}
+
+// str skips a json string and returns it with the quotes
+func (s *jsonScanner) str() ([]byte, bool) {
+	start := s.i
+	if !s.consume('"') {
+		return nil, false
+	}
+	for s.i < len(s.data) {
+		switch s.data[s.i] {
+		case '\\':
+			s.i += 2
+		case '"':
+			s.i++
+			return s.data[start:s.i], true
+		default:
+			s.i++
+		}
+	}
+	return nil, false
+}
+
+// value skips a json value
+func (s *jsonScanner) value() bool {
+	if s.i >= len(s.data) {
+		return false
+	}
+
+	switch s.data[s.i] {
+	case '"':
+		_, ok := s.str()
+		return ok
+
+	case '{', '[':
+		depth := 0
+		for s.i < len(s.data) {
+			switch s.data[s.i] {
+			case '"':
+				if _, ok := s.str(); !ok {
+					return false
+				}
+				continue
+			case '{', '[':
+				depth++
+			case '}', ']':
+				depth--
+			}
+			s.i++
+			if depth == 0 {
+				return true
+			}
+		}
+		return false
+
+	default:
+		start := s.i
+		for s.i < len(s.data) {
+			switch s.data[s.i] {
+			case ',', '}', ']', ' ', '\t', '\r', '\n':
+				return s.i > start
+			}
+			s.i++
+		}
+		return s.i > start
+	}
+}
@@ -50,6 +50,24 @@ func ParseMethodName(method string) (domain, name string) {
 
 // call method with request and response containers.
 func call(method string, req, res interface{}, c Client) error {
+	bin, err := callRaw(method, req, c)
+	if err != nil {
+		return err
+	}
+	if res == nil {
+		return nil
+	}
+	return json.Unmarshal(bin, res)
+}
+
+// CallRaw is similar to the Call of the req, but it returns the raw result without decoding it.
+// It's useful to extract a large field from the result via [RawField] or [DecodeBase64Field].
+func CallRaw(c Client, req Request) ([]byte, error) {
+	return callRaw(req.ProtoReq(), req, c)
+}
+
+// callRaw calls the method and returns the raw response without decoding it
+func callRaw(method string, req interface{}, c Client) ([]byte, error) {
 	ctx := context.Background()
 	if cta, ok := c.(Contextable); ok {
 		ctx = cta.GetContext()
@@ -60,12 +78,5 @@ func call(method string, req, res interface{}, c Client) error {
 		sessionID = string(tsa.GetSessionID())
 	}
 
-	bin, err := c.Call(ctx, sessionID, method, req)
-	if err != nil {
-		return err
-	}
-	if res == nil {
-		return nil
-	}
-	return json.Unmarshal(bin, res)
+	return c.Call(ctx, sessionID, method, req)
 }
@@ -40,6 +40,14 @@ func (t T) CallErr() {
 	t.Eq(proto.PageEnable{}.Call(client).Error(), "err")
 }
 
+func (t T) CallRaw() {
+	client := &Client{ret: map[string]string{"data": "abc"}}
+	raw, err := proto.CallRaw(client, proto.PageCaptureScreenshot{})
+	t.E(err)
+	t.Eq(client.methodName, "Page.captureScreenshot")
+	t.Eq(string(raw), `{"data":"abc"}`)
+}
+
 func (t T) ParseMethodName() {
 	d, n := proto.ParseMethodName("Page.enable")
 	t.Eq("Page", d)
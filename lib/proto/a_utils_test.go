@@ -1,6 +1,9 @@
 package proto_test
 
 import (
+	"bytes"
+	"errors"
+	"io"
 	"testing"
 
 	"github.com/Fromsko/rodPro/lib/proto"
@@ -24,3 +27,84 @@ func (t T) PatternToReg() {
 	t.Eq(`\A\?\*\z`, proto.PatternToReg(`\?\*`))
 	t.Eq(`\Aa.com\?a=10&b=\*\z`, proto.PatternToReg(`a.com\?a=10&b=\*`))
 }
+
+func (t T) RawField() {
+	raw := []byte(` {"a": {"b": "}\"", "c": [1, {"d": 2}]}, "n" : -1.5e3, "data":"aGVsbG8\/", "e": true}`)
+
+	v, err := proto.RawField(raw, "a")
+	t.E(err)
+	t.Eq(string(v), `{"b": "}\"", "c": [1, {"d": 2}]}`)
+
+	v, err = proto.RawField(raw, "n")
+	t.E(err)
+	t.Eq(string(v), `-1.5e3`)
+
+	v, err = proto.RawField(raw, "e")
+	t.E(err)
+	t.Eq(string(v), `true`)
+
+	_, err = proto.RawField(raw, "b")
+	t.Is(err, proto.ErrFieldNotFound)
+
+	_, err = proto.RawField([]byte(`[]`), "a")
+	t.Is(err, proto.ErrInvalidJSON)
+
+	_, err = proto.RawField([]byte(`{"a": "`), "a")
+	t.Is(err, proto.ErrInvalidJSON)
+
+	buf := bytes.NewBuffer(nil)
+	n, err := proto.DecodeBase64Field(raw, "data", buf)
+	t.E(err)
+	t.Eq(n, 6)
+	t.Eq(buf.String(), "hello?")
+
+	_, err = proto.DecodeBase64Field(raw, "n", buf)
+	t.Is(err, proto.ErrInvalidJSON)
+}
+
+func (t T) Base64Bytes() {
+	b, err := proto.NetworkGetResponseBodyResult{Body: "aGk=", Base64Encoded: true}.Bytes()
+	t.E(err)
+	t.Eq(string(b), "hi")
+
+	b, err = proto.PageGetResourceContentResult{Content: "aGk="}.Bytes()
+	t.E(err)
+	t.Eq(string(b), "aGk=")
+
+	b, err = proto.NetworkWebSocketFrame{Opcode: 2, PayloadData: "aGk="}.Bytes()
+	t.E(err)
+	t.Eq(string(b), "hi")
+
+	_, err = proto.IOReadResult{Data: "@", Base64Encoded: true}.Bytes()
+	t.Err(err)
+}
+
+func (t T) IOStreamReader() {
+	c := &Client{ret: proto.IOReadResult{Data: "aGk=", Base64Encoded: true, EOF: true}}
+	r := proto.IOStreamHandle("h").Reader(c)
+
+	buf := bytes.NewBuffer(nil)
+	n, err := io.Copy(buf, r)
+	t.E(err)
+	t.Eq(n, 2)
+	t.Eq(buf.String(), "hi")
+	t.Eq(c.methodName, "IO.read")
+
+	c.ret = proto.IOReadResult{Data: `a"b`}
+	b := make([]byte, 2)
+	n2, err := r.Read(b)
+	t.E(err)
+	t.Eq(string(b[:n2]), `a"`)
+	n2, err = r.Read(b)
+	t.E(err)
+	t.Eq(string(b[:n2]), `b`)
+
+	t.E(r.Close())
+	t.Eq(c.methodName, "IO.close")
+
+	c.err = errors.New("err")
+	_, err = r.Read(b)
+	t.Err(err)
+	_, err = r.WriteTo(buf)
+	t.Err(err)
+}
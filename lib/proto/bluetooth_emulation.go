@@ -0,0 +1,205 @@
+// This file is generated by "./cmd/protogen"
+
+package proto
+
+/*
+
+BluetoothEmulation
+
+This domain allows configuring virtual Bluetooth devices to test
+the web-bluetooth API.
+
+*/
+
+// BluetoothEmulationCentralState Indicates the various states of Central.
+type BluetoothEmulationCentralState string
+
+const (
+	// BluetoothEmulationCentralStateAbsent enum const
+	BluetoothEmulationCentralStateAbsent BluetoothEmulationCentralState = "absent"
+
+	// BluetoothEmulationCentralStatePoweredOff enum const
+	BluetoothEmulationCentralStatePoweredOff BluetoothEmulationCentralState = "powered-off"
+
+	// BluetoothEmulationCentralStatePoweredOn enum const
+	BluetoothEmulationCentralStatePoweredOn BluetoothEmulationCentralState = "powered-on"
+)
+
+// BluetoothEmulationGATTOperationType Indicates the various types of GATT event.
+type BluetoothEmulationGATTOperationType string
+
+const (
+	// BluetoothEmulationGATTOperationTypeConnection enum const
+	BluetoothEmulationGATTOperationTypeConnection BluetoothEmulationGATTOperationType = "connection"
+
+	// BluetoothEmulationGATTOperationTypeDiscovery enum const
+	BluetoothEmulationGATTOperationTypeDiscovery BluetoothEmulationGATTOperationType = "discovery"
+)
+
+// BluetoothEmulationManufacturerData Stores the manufacturer data.
+type BluetoothEmulationManufacturerData struct {
+	// Key Company identifier
+	// https://bitbucket.org/bluetooth-SIG/public/src/main/assigned_numbers/company_identifiers/company_identifiers.yaml
+	// https://usb.org/developers
+	Key int `json:"key"`
+
+	// Data Manufacturer-specific data
+	Data []byte `json:"data"`
+}
+
+// BluetoothEmulationScanRecord Stores the byte data of the advertisement packet sent by a Bluetooth device.
+type BluetoothEmulationScanRecord struct {
+	// Name (optional) ...
+	Name string `json:"name,omitempty"`
+
+	// Uuids (optional) ...
+	Uuids []string `json:"uuids,omitempty"`
+
+	// Appearance (optional) Stores the external appearance description of the device.
+	Appearance *int `json:"appearance,omitempty"`
+
+	// TxPower (optional) Stores the transmission power of a broadcasting device.
+	TxPower *int `json:"txPower,omitempty"`
+
+	// ManufacturerData (optional) Key is the company identifier and the value is an array of bytes of
+	// manufacturer specific data.
+	ManufacturerData []*BluetoothEmulationManufacturerData `json:"manufacturerData,omitempty"`
+}
+
+// BluetoothEmulationScanEntry Stores the advertisement packet information that is sent by a Bluetooth device.
+type BluetoothEmulationScanEntry struct {
+	// DeviceAddress ...
+	DeviceAddress string `json:"deviceAddress"`
+
+	// Rssi ...
+	Rssi int `json:"rssi"`
+
+	// ScanRecord ...
+	ScanRecord *BluetoothEmulationScanRecord `json:"scanRecord"`
+}
+
+// BluetoothEmulationEnable Enable the BluetoothEmulation domain.
+type BluetoothEmulationEnable struct {
+	// State State of the simulated central.
+	State BluetoothEmulationCentralState `json:"state"`
+
+	// LeSupported If the simulated central supports low-energy.
+	LeSupported bool `json:"leSupported"`
+}
+
+// ProtoReq name
+func (m BluetoothEmulationEnable) ProtoReq() string { return "BluetoothEmulation.enable" }
+
+// Call sends the request
+func (m BluetoothEmulationEnable) Call(c Client) error {
+	return call(m.ProtoReq(), m, nil, c)
+}
+
+// BluetoothEmulationSetSimulatedCentralState Set the state of the simulated central.
+type BluetoothEmulationSetSimulatedCentralState struct {
+	// State State of the simulated central.
+	State BluetoothEmulationCentralState `json:"state"`
+}
+
+// ProtoReq name
+func (m BluetoothEmulationSetSimulatedCentralState) ProtoReq() string {
+	return "BluetoothEmulation.setSimulatedCentralState"
+}
+
+// Call sends the request
+func (m BluetoothEmulationSetSimulatedCentralState) Call(c Client) error {
+	return call(m.ProtoReq(), m, nil, c)
+}
+
+// BluetoothEmulationDisable Disable the BluetoothEmulation domain.
+type BluetoothEmulationDisable struct{}
+
+// ProtoReq name
+func (m BluetoothEmulationDisable) ProtoReq() string { return "BluetoothEmulation.disable" }
+
+// Call sends the request
+func (m BluetoothEmulationDisable) Call(c Client) error {
+	return call(m.ProtoReq(), m, nil, c)
+}
+
+// BluetoothEmulationSimulatePreconnectedPeripheral Simulates a peripheral with |address|, |name| and |knownServiceUuids|
+// that has already been connected to the system.
+type BluetoothEmulationSimulatePreconnectedPeripheral struct {
+	// Address ...
+	Address string `json:"address"`
+
+	// Name ...
+	Name string `json:"name"`
+
+	// ManufacturerData ...
+	ManufacturerData []*BluetoothEmulationManufacturerData `json:"manufacturerData"`
+
+	// KnownServiceUuids ...
+	KnownServiceUuids []string `json:"knownServiceUuids"`
+}
+
+// ProtoReq name
+func (m BluetoothEmulationSimulatePreconnectedPeripheral) ProtoReq() string {
+	return "BluetoothEmulation.simulatePreconnectedPeripheral"
+}
+
+// Call sends the request
+func (m BluetoothEmulationSimulatePreconnectedPeripheral) Call(c Client) error {
+	return call(m.ProtoReq(), m, nil, c)
+}
+
+// BluetoothEmulationSimulateAdvertisement Simulates an advertisement packet described in |entry| being received by
+// the central.
+type BluetoothEmulationSimulateAdvertisement struct {
+	// Entry ...
+	Entry *BluetoothEmulationScanEntry `json:"entry"`
+}
+
+// ProtoReq name
+func (m BluetoothEmulationSimulateAdvertisement) ProtoReq() string {
+	return "BluetoothEmulation.simulateAdvertisement"
+}
+
+// Call sends the request
+func (m BluetoothEmulationSimulateAdvertisement) Call(c Client) error {
+	return call(m.ProtoReq(), m, nil, c)
+}
+
+// BluetoothEmulationSimulateGATTOperationResponse Simulates the response code from the peripheral with |address| for a
+// GATT operation of |type|. The |code| value follows the HCI Error Codes from
+// Bluetooth Core Specification Vol 2 Part D 1.3 List Of Error Codes.
+type BluetoothEmulationSimulateGATTOperationResponse struct {
+	// Address ...
+	Address string `json:"address"`
+
+	// Type ...
+	Type BluetoothEmulationGATTOperationType `json:"type"`
+
+	// Code ...
+	Code int `json:"code"`
+}
+
+// ProtoReq name
+func (m BluetoothEmulationSimulateGATTOperationResponse) ProtoReq() string {
+	return "BluetoothEmulation.simulateGATTOperationResponse"
+}
+
+// Call sends the request
+func (m BluetoothEmulationSimulateGATTOperationResponse) Call(c Client) error {
+	return call(m.ProtoReq(), m, nil, c)
+}
+
+// BluetoothEmulationGattOperationReceived Event for when a GATT operation of |type| to the peripheral with |address|
+// happened.
+type BluetoothEmulationGattOperationReceived struct {
+	// Address ...
+	Address string `json:"address"`
+
+	// Type ...
+	Type BluetoothEmulationGATTOperationType `json:"type"`
+}
+
+// ProtoEvent name
+func (evt BluetoothEmulationGattOperationReceived) ProtoEvent() string {
+	return "BluetoothEmulation.gattOperationReceived"
+}
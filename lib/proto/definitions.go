@@ -1,4 +1,4 @@
-// This file is generated by "./lib/proto/generate"
+// This file is generated by "./cmd/protogen"
 
 package proto
 
@@ -1059,9 +1059,22 @@ var types = map[string]reflect.Type{
 	"FedCm.enable":                                          reflect.TypeOf(FedCmEnable{}),
 	"FedCm.disable":                                         reflect.TypeOf(FedCmDisable{}),
 	"FedCm.selectAccount":                                   reflect.TypeOf(FedCmSelectAccount{}),
+	"FedCm.clickDialogButton":                               reflect.TypeOf(FedCmClickDialogButton{}),
+	"FedCm.openUrl":                                         reflect.TypeOf(FedCmOpenURL{}),
 	"FedCm.dismissDialog":                                   reflect.TypeOf(FedCmDismissDialog{}),
 	"FedCm.resetCooldown":                                   reflect.TypeOf(FedCmResetCooldown{}),
 	"FedCm.dialogShown":                                     reflect.TypeOf(FedCmDialogShown{}),
+	"FedCm.dialogClosed":                                    reflect.TypeOf(FedCmDialogClosed{}),
+	"BluetoothEmulation.ManufacturerData":                   reflect.TypeOf(BluetoothEmulationManufacturerData{}),
+	"BluetoothEmulation.ScanRecord":                         reflect.TypeOf(BluetoothEmulationScanRecord{}),
+	"BluetoothEmulation.ScanEntry":                          reflect.TypeOf(BluetoothEmulationScanEntry{}),
+	"BluetoothEmulation.enable":                             reflect.TypeOf(BluetoothEmulationEnable{}),
+	"BluetoothEmulation.setSimulatedCentralState":           reflect.TypeOf(BluetoothEmulationSetSimulatedCentralState{}),
+	"BluetoothEmulation.disable":                            reflect.TypeOf(BluetoothEmulationDisable{}),
+	"BluetoothEmulation.simulatePreconnectedPeripheral":     reflect.TypeOf(BluetoothEmulationSimulatePreconnectedPeripheral{}),
+	"BluetoothEmulation.simulateAdvertisement":              reflect.TypeOf(BluetoothEmulationSimulateAdvertisement{}),
+	"BluetoothEmulation.simulateGATTOperationResponse":      reflect.TypeOf(BluetoothEmulationSimulateGATTOperationResponse{}),
+	"BluetoothEmulation.gattOperationReceived":              reflect.TypeOf(BluetoothEmulationGattOperationReceived{}),
 	"Console.ConsoleMessage":                                reflect.TypeOf(ConsoleConsoleMessage{}),
 	"Console.clearMessages":                                 reflect.TypeOf(ConsoleClearMessages{}),
 	"Console.disable":                                       reflect.TypeOf(ConsoleDisable{}),
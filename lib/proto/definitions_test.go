@@ -1,4 +1,4 @@
-// This file is generated by "./lib/proto/generate"
+// This file is generated by "./cmd/protogen"
 
 package proto_test
 
@@ -3673,6 +3673,18 @@ func (t T) FedCmSelectAccount() {
 	t.Nil(err)
 }
 
+func (t T) FedCmClickDialogButton() {
+	c := &Client{}
+	err := proto.FedCmClickDialogButton{}.Call(c)
+	t.Nil(err)
+}
+
+func (t T) FedCmOpenURL() {
+	c := &Client{}
+	err := proto.FedCmOpenURL{}.Call(c)
+	t.Nil(err)
+}
+
 func (t T) FedCmDismissDialog() {
 	c := &Client{}
 	err := proto.FedCmDismissDialog{}.Call(c)
@@ -3690,6 +3702,52 @@ func (t T) FedCmDialogShown() {
 	t.Regex("", e.ProtoEvent())
 }
 
+func (t T) FedCmDialogClosed() {
+	e := proto.FedCmDialogClosed{}
+	t.Regex("", e.ProtoEvent())
+}
+
+func (t T) BluetoothEmulationEnable() {
+	c := &Client{}
+	err := proto.BluetoothEmulationEnable{}.Call(c)
+	t.Nil(err)
+}
+
+func (t T) BluetoothEmulationSetSimulatedCentralState() {
+	c := &Client{}
+	err := proto.BluetoothEmulationSetSimulatedCentralState{}.Call(c)
+	t.Nil(err)
+}
+
+func (t T) BluetoothEmulationDisable() {
+	c := &Client{}
+	err := proto.BluetoothEmulationDisable{}.Call(c)
+	t.Nil(err)
+}
+
+func (t T) BluetoothEmulationSimulatePreconnectedPeripheral() {
+	c := &Client{}
+	err := proto.BluetoothEmulationSimulatePreconnectedPeripheral{}.Call(c)
+	t.Nil(err)
+}
+
+func (t T) BluetoothEmulationSimulateAdvertisement() {
+	c := &Client{}
+	err := proto.BluetoothEmulationSimulateAdvertisement{}.Call(c)
+	t.Nil(err)
+}
+
+func (t T) BluetoothEmulationSimulateGATTOperationResponse() {
+	c := &Client{}
+	err := proto.BluetoothEmulationSimulateGATTOperationResponse{}.Call(c)
+	t.Nil(err)
+}
+
+func (t T) BluetoothEmulationGattOperationReceived() {
+	e := proto.BluetoothEmulationGattOperationReceived{}
+	t.Regex("", e.ProtoEvent())
+}
+
 func (t T) ConsoleClearMessages() {
 	c := &Client{}
 	err := proto.ConsoleClearMessages{}.Call(c)
@@ -1,4 +1,4 @@
-// This file is generated by "./lib/proto/generate"
+// This file is generated by "./cmd/protogen"
 
 package proto
 
@@ -297,6 +297,11 @@ type FetchGetResponseBodyResult struct {
 	Base64Encoded bool `json:"base64Encoded"`
 }
 
+// Bytes returns the Body as binary, it's decoded only when the Base64Encoded is true
+func (r FetchGetResponseBodyResult) Bytes() ([]byte, error) {
+	return decodeBase64(r.Body, r.Base64Encoded)
+}
+
 // FetchTakeResponseBodyAsStream Returns a handle to the stream representing the response body.
 // The request must be paused in the HeadersReceived stage.
 // Note that after this command the request can't be continued
@@ -1,4 +1,4 @@
-// This file is generated by "./lib/proto/generate"
+// This file is generated by "./cmd/protogen"
 
 package proto
 
@@ -62,6 +62,11 @@ type IOReadResult struct {
 	EOF bool `json:"eof"`
 }
 
+// Bytes returns the Data as binary, it's decoded only when the Base64Encoded is true
+func (r IOReadResult) Bytes() ([]byte, error) {
+	return decodeBase64(r.Data, r.Base64Encoded)
+}
+
 // IOResolveBlob Return UUID of Blob object specified by a remote object id.
 type IOResolveBlob struct {
 	// ObjectID Object id of a Blob object wrapper.
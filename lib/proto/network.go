@@ -1,4 +1,4 @@
-// This file is generated by "./lib/proto/generate"
+// This file is generated by "./cmd/protogen"
 
 package proto
 
@@ -1849,6 +1849,11 @@ type NetworkGetResponseBodyResult struct {
 	Base64Encoded bool `json:"base64Encoded"`
 }
 
+// Bytes returns the Body as binary, it's decoded only when the Base64Encoded is true
+func (r NetworkGetResponseBodyResult) Bytes() ([]byte, error) {
+	return decodeBase64(r.Body, r.Base64Encoded)
+}
+
 // NetworkGetRequestPostData Returns post data sent with the request. Returns an error when no data was sent with the request.
 type NetworkGetRequestPostData struct {
 	// RequestID Identifier of the network request to get content for.
@@ -1896,6 +1901,11 @@ type NetworkGetResponseBodyForInterceptionResult struct {
 	Base64Encoded bool `json:"base64Encoded"`
 }
 
+// Bytes returns the Body as binary, it's decoded only when the Base64Encoded is true
+func (r NetworkGetResponseBodyForInterceptionResult) Bytes() ([]byte, error) {
+	return decodeBase64(r.Body, r.Base64Encoded)
+}
+
 // NetworkTakeResponseBodyForInterceptionAsStream (experimental) Returns a handle to the stream representing the response body. Note that after this command,
 // the intercepted request can't be continued as is -- you either need to cancel it or to provide
 // the response body. The stream only supports sequential read, IO.read will fail if the position
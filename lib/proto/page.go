@@ -1,4 +1,4 @@
-// This file is generated by "./lib/proto/generate"
+// This file is generated by "./cmd/protogen"
 
 package proto
 
@@ -1856,6 +1856,11 @@ type PageGetResourceContentResult struct {
 	Base64Encoded bool `json:"base64Encoded"`
 }
 
+// Bytes returns the Content as binary, it's decoded only when the Base64Encoded is true
+func (r PageGetResourceContentResult) Bytes() ([]byte, error) {
+	return decodeBase64(r.Content, r.Base64Encoded)
+}
+
 // PageGetResourceTree (experimental) Returns present frame / resource tree structure.
 type PageGetResourceTree struct{}
 
@@ -2019,6 +2024,12 @@ type PagePrintToPDF struct {
 
 	// TransferMode (experimental) (optional) return as stream
 	TransferMode PagePrintToPDFTransferMode `json:"transferMode,omitempty"`
+
+	// GenerateTaggedPDF (experimental) (optional) Whether or not to generate tagged (accessible) PDF. Defaults to embedder choice.
+	GenerateTaggedPDF bool `json:"generateTaggedPDF,omitempty"`
+
+	// GenerateDocumentOutline (experimental) (optional) Whether or not to embed the document outline into the PDF.
+	GenerateDocumentOutline bool `json:"generateDocumentOutline,omitempty"`
 }
 
 // ProtoReq name
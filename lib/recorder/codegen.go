@@ -0,0 +1,99 @@
+package recorder
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strconv"
+	"strings"
+)
+
+var pressKeys = map[string]bool{"Enter": true, "Escape": true, "Tab": true}
+
+// Generate a runnable Go program of the actions, the first navigation opens the page.
+// The output is formatted by gofmt.
+func Generate(actions []Action) ([]byte, error) {
+	body := &bytes.Buffer{}
+	usesInput := false
+	opened := false
+	used := false
+
+	line := func(format string, args ...interface{}) {
+		fmt.Fprintf(body, "\t"+format+"\n", args...)
+	}
+
+	for _, a := range actions {
+		if !opened {
+			if a.Type == ActionNavigate {
+				line("page := browser.MustPage(%s).MustWaitLoad()", strconv.Quote(a.Value))
+				opened = true
+				continue
+			}
+			line("page := browser.MustPage()")
+			opened = true
+		}
+
+		used = true
+		el := fmt.Sprintf("page.MustElement(%s)", strconv.Quote(a.Selector))
+
+		switch a.Type {
+		case ActionNavigate:
+			line("page.MustNavigate(%s).MustWaitLoad()", strconv.Quote(a.Value))
+		case ActionWaitLoad:
+			line("page.MustWaitLoad() // %s", a.Value)
+		case ActionClick:
+			line("%s.MustClick()", el)
+		case ActionInput:
+			line("%s.MustSelectAllText().MustInput(%s)", el, strconv.Quote(a.Value))
+		case ActionEdit:
+			// the contenteditable element has no value to select, clear its text before the input
+			line("%s.MustEval(`() => this.textContent = ''`)", el)
+			line("%s.MustInput(%s)", el, strconv.Quote(a.Value))
+		case ActionSelect:
+			values := []string{}
+			for _, v := range a.Values {
+				values = append(values, strconv.Quote(v))
+			}
+			line("%s.MustSelect(%s)", el, strings.Join(values, ", "))
+		case ActionCheck:
+			line("%s.MustSetChecked(%v)", el, a.Checked)
+		case ActionPress:
+			if !pressKeys[a.Value] {
+				return nil, fmt.Errorf("unsupported key to press: %s", a.Value)
+			}
+			usesInput = true
+			line("%s.MustType(input.%s)", el, a.Value)
+		default:
+			return nil, fmt.Errorf("unknown action type: %s", a.Type)
+		}
+	}
+
+	if !opened {
+		line("page := browser.MustPage()")
+	}
+	if !used {
+		line("_ = page")
+	}
+
+	imports := `"github.com/Fromsko/rodPro"`
+	if usesInput {
+		imports += "\n" + `"github.com/Fromsko/rodPro/lib/input"`
+	}
+
+	code := fmt.Sprintf(`// Code generated by rod-record.
+
+package main
+
+import (
+%s
+)
+
+func main() {
+	browser := rod.New().MustConnect()
+	defer browser.MustClose()
+
+%s}
+`, imports, body.String())
+
+	return format.Source([]byte(code))
+}
@@ -0,0 +1,273 @@
+// Package recorder records the manual browsing of a page as actions, then generates a runnable Go program
+// that replays them with rod. The selectors prefer the test ids, ids, names, and labels over the CSS paths,
+// so the program survives the minor changes of the page.
+package recorder
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/Fromsko/rodPro"
+	"github.com/Fromsko/rodPro/lib/proto"
+	"github.com/ysmood/gson"
+)
+
+// ActionType of [Action]
+type ActionType string
+
+const (
+	// ActionNavigate to the [Action.Value] url
+	ActionNavigate ActionType = "navigate"
+
+	// ActionWaitLoad waits for the page that is loaded by the previous action
+	ActionWaitLoad ActionType = "waitLoad"
+
+	// ActionClick on the element
+	ActionClick ActionType = "click"
+
+	// ActionInput the [Action.Value] to the element, the old value will be replaced
+	ActionInput ActionType = "input"
+
+	// ActionEdit replaces the text of the contenteditable element with the [Action.Value]
+	ActionEdit ActionType = "edit"
+
+	// ActionSelect the [Action.Values] options of the element by their texts
+	ActionSelect ActionType = "select"
+
+	// ActionCheck sets the checked state of the element to [Action.Checked]
+	ActionCheck ActionType = "check"
+
+	// ActionPress the [Action.Value] key on the element, such as "Enter"
+	ActionPress ActionType = "press"
+)
+
+// Action recorded
+type Action struct {
+	Type     ActionType `json:"type"`
+	Selector string     `json:"selector,omitempty"`
+	Value    string     `json:"value,omitempty"`
+	Values   []string   `json:"values,omitempty"`
+	Checked  bool       `json:"checked,omitempty"`
+	Time     time.Time  `json:"time"`
+}
+
+// NavigationGap is the max duration between a user action and the navigation it triggers,
+// such navigations are recorded as [ActionWaitLoad] instead of [ActionNavigate].
+var NavigationGap = 2 * time.Second
+
+// Recorder of a page
+type Recorder struct {
+	page *rod.Page
+
+	lock    sync.Mutex
+	actions []Action
+	stop    []func() error
+}
+
+// New recorder for the page
+func New(page *rod.Page) *Recorder {
+	return &Recorder{page: page}
+}
+
+// Start to record the actions of the page, the current url is recorded as the first navigation
+func (r *Recorder) Start() error {
+	info, err := r.page.Info()
+	if err != nil {
+		return err
+	}
+	if info.URL != "" && info.URL != "about:blank" {
+		r.add(Action{Type: ActionNavigate, Value: info.URL})
+	}
+
+	stopExpose, err := r.page.Expose("__rodRecord", func(payload gson.JSON) (interface{}, error) {
+		var a Action
+		err := json.Unmarshal([]byte(payload.JSON("", "")), &a)
+		if err == nil {
+			r.add(a)
+		}
+		return nil, err
+	})
+	if err != nil {
+		return err
+	}
+	r.stop = append(r.stop, stopExpose)
+
+	remove, err := r.page.EvalOnNewDocument("(" + script + ")()")
+	if err != nil {
+		return err
+	}
+	r.stop = append(r.stop, remove)
+
+	_, err = r.page.Eval(script)
+	if err != nil {
+		return err
+	}
+
+	p, cancel := r.page.WithCancel()
+	r.stop = append(r.stop, func() error { cancel(); return nil })
+
+	go p.EachEvent(func(e *proto.PageFrameNavigated) {
+		if e.Frame.ParentID == "" {
+			r.navigated(e.Frame.URL)
+		}
+	})()
+
+	return nil
+}
+
+// Stop recording
+func (r *Recorder) Stop() error {
+	r.lock.Lock()
+	list := r.stop
+	r.stop = nil
+	r.lock.Unlock()
+
+	for _, fn := range list {
+		err := fn()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Actions recorded so far, the consecutive inputs of the same element are merged into one
+func (r *Recorder) Actions() []Action {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return append([]Action{}, r.actions...)
+}
+
+// Code generates the Go program of the recorded actions, check [Generate] for details
+func (r *Recorder) Code() ([]byte, error) {
+	return Generate(r.Actions())
+}
+
+func (r *Recorder) navigated(url string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if len(r.actions) == 0 {
+		r.append(Action{Type: ActionNavigate, Value: url})
+		return
+	}
+
+	last := r.actions[len(r.actions)-1]
+	switch {
+	case last.Type == ActionNavigate && last.Value == url:
+	case last.Type != ActionNavigate && last.Type != ActionWaitLoad && time.Since(last.Time) < NavigationGap:
+		r.append(Action{Type: ActionWaitLoad, Value: url})
+	default:
+		r.append(Action{Type: ActionNavigate, Value: url})
+	}
+}
+
+func (r *Recorder) add(a Action) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.append(a)
+}
+
+// append the action, the caller must hold the lock
+func (r *Recorder) append(a Action) {
+	a.Time = time.Now()
+
+	if n := len(r.actions); n > 0 && (a.Type == ActionInput || a.Type == ActionEdit) {
+		last := &r.actions[n-1]
+		if last.Type == a.Type && last.Selector == a.Selector {
+			*last = a
+			return
+		}
+	}
+
+	r.actions = append(r.actions, a)
+}
+
+const script = `() => {
+	if (window.__rodRecorder) return
+	window.__rodRecorder = true
+
+	const send = (a) => {
+		try { window.__rodRecord(a) } catch (e) {}
+	}
+
+	const unique = (s) => {
+		try { return document.querySelectorAll(s).length === 1 } catch (e) { return false }
+	}
+
+	const quote = (v) => '"' + v.replace(/\\/g, '\\\\').replace(/"/g, '\\"') + '"'
+
+	const selector = (el) => {
+		for (const attr of ['data-testid', 'data-test', 'data-qa']) {
+			const v = el.getAttribute(attr)
+			if (v && unique('[' + attr + '=' + quote(v) + ']')) return '[' + attr + '=' + quote(v) + ']'
+		}
+
+		if (el.id && unique('#' + CSS.escape(el.id))) return '#' + CSS.escape(el.id)
+
+		const tag = el.tagName.toLowerCase()
+		for (const attr of ['name', 'aria-label', 'placeholder', 'title', 'alt']) {
+			const v = el.getAttribute(attr)
+			if (v && unique(tag + '[' + attr + '=' + quote(v) + ']')) return tag + '[' + attr + '=' + quote(v) + ']'
+		}
+
+		const parts = []
+		for (let e = el; e && e.nodeType === 1 && e !== document.documentElement; e = e.parentElement) {
+			if (e !== el && e.id && unique('#' + CSS.escape(e.id))) {
+				parts.unshift('#' + CSS.escape(e.id))
+				break
+			}
+			let part = e.tagName.toLowerCase()
+			const same = e.parentElement ? Array.from(e.parentElement.children).filter(c => c.tagName === e.tagName) : []
+			if (same.length > 1) part += ':nth-of-type(' + (same.indexOf(e) + 1) + ')'
+			parts.unshift(part)
+			if (unique(parts.join(' > '))) break
+		}
+		return parts.join(' > ')
+	}
+
+	const typeOf = (el) => (el.type || '').toLowerCase()
+
+	const isToggle = (el) => el.tagName === 'INPUT' && ['checkbox', 'radio'].includes(typeOf(el))
+
+	const isText = (el) => el.tagName === 'TEXTAREA' || el.isContentEditable ||
+		(el.tagName === 'INPUT' && !['checkbox', 'radio', 'button', 'submit', 'reset', 'image', 'file'].includes(typeOf(el)))
+
+	const target = (e) => {
+		let el = e.composedPath()[0]
+		if (el.nodeType !== 1) el = el.parentElement
+		return el.closest('a, button, input, select, textarea, label, [role=button], [onclick]') || el
+	}
+
+	document.addEventListener('click', (e) => {
+		const el = target(e)
+		if (el.tagName === 'SELECT' || isText(el) || isToggle(el)) return
+		if (el.tagName === 'LABEL' && el.control && isToggle(el.control)) return
+		send({ type: 'click', selector: selector(el) })
+	}, true)
+
+	const onChange = (e) => {
+		const el = e.target
+		if (el.tagName === 'SELECT') {
+			if (e.type === 'change') send({ type: 'select', selector: selector(el), values: Array.from(el.selectedOptions).map(o => o.text) })
+		} else if (isToggle(el)) {
+			if (e.type === 'change') send({ type: 'check', selector: selector(el), checked: el.checked })
+		} else if (isText(el)) {
+			if (el.isContentEditable) send({ type: 'edit', selector: selector(el), value: el.innerText })
+			else send({ type: 'input', selector: selector(el), value: el.value })
+		}
+	}
+	document.addEventListener('input', onChange, true)
+	document.addEventListener('change', onChange, true)
+
+	document.addEventListener('keydown', (e) => {
+		const el = target(e)
+		if (e.key === 'Enter' && (el.tagName === 'TEXTAREA' || el.isContentEditable)) return
+		if (['Enter', 'Escape', 'Tab'].includes(e.key)) {
+			send({ type: 'press', selector: selector(el), value: e.key })
+		}
+	}, true)
+}`
@@ -0,0 +1,98 @@
+package recorder
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ysmood/got"
+)
+
+func TestGenerate(t *testing.T) {
+	g := got.T(t)
+
+	code, err := Generate([]Action{
+		{Type: ActionNavigate, Value: "https://example.com/login"},
+		{Type: ActionInput, Selector: "#user", Value: `a"b`},
+		{Type: ActionEdit, Selector: "#bio", Value: "hi"},
+		{Type: ActionCheck, Selector: "[name=remember]", Checked: true},
+		{Type: ActionSelect, Selector: "select", Values: []string{"A", "B"}},
+		{Type: ActionPress, Selector: "#user", Value: "Enter"},
+		{Type: ActionClick, Selector: "button:nth-of-type(2)"},
+		{Type: ActionWaitLoad, Value: "https://example.com/home"},
+		{Type: ActionNavigate, Value: "https://example.com/about"},
+	})
+	g.E(err)
+
+	g.Eq(string(code), `// Code generated by rod-record.
+
+package main
+
+import (
+	"github.com/Fromsko/rodPro"
+	"github.com/Fromsko/rodPro/lib/input"
+)
+
+func main() {
+	browser := rod.New().MustConnect()
+	defer browser.MustClose()
+
+	page := browser.MustPage("https://example.com/login").MustWaitLoad()
+	page.MustElement("#user").MustSelectAllText().MustInput("a\"b")
+	page.MustElement("#bio").MustEval(`+"`() => this.textContent = ''`"+`)
+	page.MustElement("#bio").MustInput("hi")
+	page.MustElement("[name=remember]").MustSetChecked(true)
+	page.MustElement("select").MustSelect("A", "B")
+	page.MustElement("#user").MustType(input.Enter)
+	page.MustElement("button:nth-of-type(2)").MustClick()
+	page.MustWaitLoad() // https://example.com/home
+	page.MustNavigate("https://example.com/about").MustWaitLoad()
+}
+`)
+
+	code, err = Generate(nil)
+	g.E(err)
+	g.Has(string(code), "page := browser.MustPage()\n\t_ = page\n")
+	g.False(strings.Contains(string(code), "lib/input"))
+
+	code, err = Generate([]Action{{Type: ActionClick, Selector: "a"}})
+	g.E(err)
+	g.Has(string(code), "page := browser.MustPage()\n\tpage.MustElement(\"a\").MustClick()\n")
+
+	_, err = Generate([]Action{{Type: "x"}})
+	g.Eq(err.Error(), "unknown action type: x")
+
+	_, err = Generate([]Action{{Type: ActionPress, Value: "A"}})
+	g.Eq(err.Error(), "unsupported key to press: A")
+}
+
+func TestRecorderActions(t *testing.T) {
+	g := got.T(t)
+
+	r := New(nil)
+	r.navigated("https://a.com")
+	r.navigated("https://a.com")
+	r.add(Action{Type: ActionInput, Selector: "#a", Value: "x"})
+	r.add(Action{Type: ActionInput, Selector: "#a", Value: "xy"})
+	r.add(Action{Type: ActionInput, Selector: "#b", Value: "z"})
+	r.add(Action{Type: ActionEdit, Selector: "#b", Value: "w"})
+	r.add(Action{Type: ActionEdit, Selector: "#b", Value: "wv"})
+	r.add(Action{Type: ActionClick, Selector: "button"})
+	r.navigated("https://a.com/next")
+
+	list := r.Actions()
+	g.Len(list, 6)
+	g.Eq(list[0].Type, ActionNavigate)
+	g.Eq(list[1].Value, "xy")
+	g.Eq(list[2].Value, "z")
+	g.Eq(list[3].Value, "wv")
+	g.Eq(list[5].Type, ActionWaitLoad)
+
+	r.actions[len(r.actions)-1].Time = time.Now().Add(-NavigationGap)
+	r.add(Action{Type: ActionClick, Selector: "a"})
+	r.actions[len(r.actions)-1].Time = time.Now().Add(-NavigationGap)
+	r.navigated("https://b.com")
+	g.Eq(r.Actions()[7].Type, ActionNavigate)
+
+	g.E(r.Stop())
+}
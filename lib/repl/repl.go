@@ -0,0 +1,598 @@
+// Package repl is an interactive shell to query, eval, and click against a live page,
+// it's used by the rod-repl command to develop the scrapers iteratively.
+package repl
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Fromsko/rodPro"
+	"github.com/Fromsko/rodPro/lib/proto"
+	"github.com/Fromsko/rodPro/lib/utils"
+)
+
+// ErrExit is returned by [REPL.Exec] when the user wants to exit
+var ErrExit = errors.New("exit")
+
+// Command of the [REPL]
+type Command struct {
+	Name  string
+	Usage string
+	Help  string
+
+	// Selector is true if the first argument is a css selector, it's used for the tab-completion
+	Selector bool
+
+	// Raw is true if the rest of the line is passed as the only argument without splitting
+	Raw bool
+
+	Run func(r *REPL, args []string) error
+}
+
+// REPL to run commands against the current page
+type REPL struct {
+	Browser *rod.Browser
+	Page    *rod.Page
+
+	// Out is where the results are printed
+	Out io.Writer
+
+	// Timeout of each command
+	Timeout time.Duration
+
+	// Prompt of each line
+	Prompt string
+
+	commands map[string]*Command
+}
+
+// New REPL of the page, the output is written to out
+func New(b *rod.Browser, p *rod.Page, out io.Writer) *REPL {
+	r := &REPL{
+		Browser:  b,
+		Page:     p,
+		Out:      out,
+		Timeout:  10 * time.Second,
+		Prompt:   "rod> ",
+		commands: map[string]*Command{},
+	}
+	for _, c := range defaultCommands {
+		r.Add(c)
+	}
+	return r
+}
+
+// Add or replace a command
+func (r *REPL) Add(c *Command) {
+	r.commands[c.Name] = c
+}
+
+// Commands sorted by name
+func (r *REPL) Commands() []*Command {
+	list := make([]*Command, 0, len(r.commands))
+	for _, c := range r.commands {
+		list = append(list, c)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// Exec a line, the empty line is ignored
+func (r *REPL) Exec(line string) error {
+	name, rest := cutWord(line)
+	if name == "" {
+		return nil
+	}
+
+	c, has := r.commands[name]
+	if !has {
+		return fmt.Errorf("unknown command %q, type \"help\" to list the commands", name)
+	}
+
+	var args []string
+	if c.Raw {
+		if rest != "" {
+			args = []string{strings.TrimRight(rest, " \t")}
+		}
+	} else {
+		var err error
+		args, err = splitArgs(rest)
+		if err != nil {
+			return err
+		}
+	}
+
+	return c.Run(r, args)
+}
+
+// Run reads the lines from in and executes them until the in is closed or [ErrExit].
+// If in is [os.Stdin] and it's a terminal, the line editor with the tab-completion will be used.
+func (r *REPL) Run(in io.Reader) error {
+	if f, ok := in.(*os.File); ok && f == os.Stdin {
+		if t, err := newTerminal(f, r.Out); err == nil {
+			defer t.restore()
+			return r.loop(func() (string, error) { return t.readLine(r.Prompt, r.Complete) })
+		}
+	}
+
+	s := bufio.NewScanner(in)
+	return r.loop(func() (string, error) {
+		fmt.Fprint(r.Out, r.Prompt)
+		if !s.Scan() {
+			fmt.Fprintln(r.Out)
+			if s.Err() != nil {
+				return "", s.Err()
+			}
+			return "", io.EOF
+		}
+		return s.Text(), nil
+	})
+}
+
+func (r *REPL) loop(read func() (string, error)) error {
+	for {
+		line, err := read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		err = r.Exec(line)
+		if errors.Is(err, ErrExit) {
+			return nil
+		} else if err != nil {
+			fmt.Fprintln(r.Out, "error:", err)
+		}
+	}
+}
+
+// Complete returns the candidates to replace the last word of the line.
+// The first word completes the command names, the selector argument completes the selectors found in the DOM.
+func (r *REPL) Complete(line string) []string {
+	name, rest := cutWord(line)
+	if rest == "" && !strings.HasSuffix(line, " ") {
+		names := []string{}
+		for _, c := range r.Commands() {
+			names = append(names, c.Name)
+		}
+		return filterPrefix(names, name)
+	}
+
+	c, has := r.commands[name]
+	if !has || !c.Selector {
+		return nil
+	}
+
+	args, err := splitArgs(rest)
+	for _, q := range []string{`"`, "'"} {
+		if err != nil {
+			// the quote of the word being typed is not closed yet
+			args, err = splitArgs(rest + q)
+		}
+	}
+	if err != nil || len(args) > 1 || (len(args) == 1 && strings.HasSuffix(line, " ")) {
+		return nil
+	}
+
+	prefix := ""
+	if len(args) == 1 {
+		prefix = args[0]
+	}
+
+	// complete the last part of a compound selector, such as "ul > li"
+	head := ""
+	if i := strings.LastIndexAny(prefix, " >+~"); i >= 0 {
+		head, prefix = prefix[:i+1], prefix[i+1:]
+	}
+
+	list := []string{}
+	for _, s := range filterPrefix(r.selectors(), prefix) {
+		list = append(list, head+s)
+	}
+	return list
+}
+
+func (r *REPL) page() *rod.Page {
+	return r.Page.Timeout(r.Timeout)
+}
+
+func (r *REPL) selectors() []string {
+	res, err := r.page().Eval(jsSelectors)
+	if err != nil {
+		return nil
+	}
+
+	list := []string{}
+	for _, s := range res.Value.Arr() {
+		list = append(list, s.Str())
+	}
+	return list
+}
+
+func (r *REPL) printf(format string, args ...interface{}) {
+	fmt.Fprintf(r.Out, format, args...)
+}
+
+const jsSelectors = `() => {
+	const set = new Set()
+	for (const el of document.querySelectorAll('*')) {
+		const tag = el.tagName.toLowerCase()
+		set.add(tag)
+		if (el.id) set.add('#' + CSS.escape(el.id))
+		for (const c of el.classList) set.add('.' + CSS.escape(c))
+		for (const a of ['name', 'data-testid', 'aria-label', 'placeholder']) {
+			const v = el.getAttribute(a)
+			if (v) set.add(tag + '[' + a + '=' + JSON.stringify(v) + ']')
+		}
+		if (set.size > 5000) break
+	}
+	return [...set]
+}`
+
+var defaultCommands = []*Command{
+	{Name: "help", Usage: "help [command]", Help: "list the commands or show the usage of a command", Run: cmdHelp},
+	{Name: "exit", Usage: "exit", Help: "exit the shell", Run: func(*REPL, []string) error { return ErrExit }},
+	{Name: "open", Usage: "open <url>", Help: "navigate the current page to the url and wait for it to load", Run: cmdOpen},
+	{Name: "reload", Usage: "reload", Help: "reload the current page", Run: cmdReload},
+	{Name: "back", Usage: "back", Help: "navigate back in the history", Run: cmdBack},
+	{Name: "info", Usage: "info", Help: "print the url and title of the current page", Run: cmdInfo},
+	{Name: "pages", Usage: "pages", Help: "list the pages, the current one is marked with *", Run: cmdPages},
+	{Name: "page", Usage: "page <index>", Help: "switch to the page of the index in the \"pages\"", Run: cmdPage},
+	{Name: "query", Usage: "query <selector>", Help: "list the elements that match the selector", Selector: true, Run: cmdQuery},
+	{Name: "text", Usage: "text <selector>", Help: "print the text of the first matched element", Selector: true, Run: cmdText},
+	{Name: "html", Usage: "html <selector>", Help: "print the html of the first matched element", Selector: true, Run: cmdHTML},
+	{Name: "attr", Usage: "attr <selector> <name>", Help: "print the attribute of the first matched element", Selector: true, Run: cmdAttr},
+	{Name: "click", Usage: "click <selector>", Help: "click the first matched element", Selector: true, Run: cmdClick},
+	{Name: "input", Usage: "input <selector> <text>", Help: "replace the value of the first matched element with the text", Selector: true, Run: cmdInput},
+	{Name: "wait", Usage: "wait <selector>", Help: "wait until the selector matches a visible element", Selector: true, Run: cmdWait},
+	{Name: "eval", Usage: "eval <js>", Help: "eval the js expression or function on the page and print the result as JSON", Raw: true, Run: cmdEval},
+	{Name: "screenshot", Usage: "screenshot [file]", Help: "take a screenshot of the current page, the default file is screenshot.png", Run: cmdScreenshot},
+}
+
+func needArgs(args []string, n int, usage string) error {
+	if len(args) != n {
+		return fmt.Errorf("usage: %s", usage)
+	}
+	return nil
+}
+
+func cmdHelp(r *REPL, args []string) error {
+	if len(args) > 0 {
+		c, has := r.commands[args[0]]
+		if !has {
+			return fmt.Errorf("unknown command %q", args[0])
+		}
+		r.printf("%s\n\t%s\n", c.Usage, c.Help)
+		return nil
+	}
+
+	for _, c := range r.Commands() {
+		r.printf("%-26s %s\n", c.Usage, c.Help)
+	}
+	r.printf("\nPress Tab to complete the command names and selectors.\n")
+	return nil
+}
+
+func cmdOpen(r *REPL, args []string) error {
+	if err := needArgs(args, 1, "open <url>"); err != nil {
+		return err
+	}
+	p := r.page()
+	if err := p.Navigate(args[0]); err != nil {
+		return err
+	}
+	return p.WaitLoad()
+}
+
+func cmdReload(r *REPL, _ []string) error {
+	return r.page().Reload()
+}
+
+func cmdBack(r *REPL, _ []string) error {
+	return r.page().NavigateBack()
+}
+
+func cmdInfo(r *REPL, _ []string) error {
+	info, err := r.page().Info()
+	if err != nil {
+		return err
+	}
+	r.printf("%s\n%s\n", info.URL, info.Title)
+	return nil
+}
+
+func (r *REPL) pages() (rod.Pages, error) {
+	pages, err := r.Browser.Pages()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(pages, func(i, j int) bool { return pages[i].TargetID < pages[j].TargetID })
+	return pages, nil
+}
+
+func cmdPages(r *REPL, _ []string) error {
+	pages, err := r.pages()
+	if err != nil {
+		return err
+	}
+	for i, p := range pages {
+		mark := " "
+		if p.TargetID == r.Page.TargetID {
+			mark = "*"
+		}
+		info, err := p.Info()
+		if err != nil {
+			return err
+		}
+		r.printf("%s %d %s\n", mark, i, info.URL)
+	}
+	return nil
+}
+
+func cmdPage(r *REPL, args []string) error {
+	if err := needArgs(args, 1, "page <index>"); err != nil {
+		return err
+	}
+	i, err := strconv.Atoi(args[0])
+	if err != nil {
+		return err
+	}
+	pages, err := r.pages()
+	if err != nil {
+		return err
+	}
+	if i < 0 || i >= len(pages) {
+		return fmt.Errorf("index out of range, there are %d pages", len(pages))
+	}
+	p, err := pages[i].Activate()
+	if err != nil {
+		return err
+	}
+	r.Page = p
+	return nil
+}
+
+func cmdQuery(r *REPL, args []string) error {
+	if err := needArgs(args, 1, "query <selector>"); err != nil {
+		return err
+	}
+	list, err := r.page().Elements(args[0])
+	if err != nil {
+		return err
+	}
+	for i, el := range list {
+		res, err := el.Eval(`() => {
+			let s = this.tagName.toLowerCase()
+			if (this.id) s += '#' + this.id
+			for (const c of this.classList) s += '.' + c
+			return [s, (this.innerText || this.value || '').trim()]
+		}`)
+		if err != nil {
+			return err
+		}
+		r.printf("%d %s %s\n", i, res.Value.Get("0").Str(), strconv.Quote(ellipsis(res.Value.Get("1").Str(), 60)))
+	}
+	r.printf("%d matched\n", len(list))
+	return nil
+}
+
+func cmdText(r *REPL, args []string) error {
+	if err := needArgs(args, 1, "text <selector>"); err != nil {
+		return err
+	}
+	el, err := r.page().Element(args[0])
+	if err != nil {
+		return err
+	}
+	s, err := el.Text()
+	if err != nil {
+		return err
+	}
+	r.printf("%s\n", s)
+	return nil
+}
+
+func cmdHTML(r *REPL, args []string) error {
+	if err := needArgs(args, 1, "html <selector>"); err != nil {
+		return err
+	}
+	el, err := r.page().Element(args[0])
+	if err != nil {
+		return err
+	}
+	s, err := el.HTML()
+	if err != nil {
+		return err
+	}
+	r.printf("%s\n", s)
+	return nil
+}
+
+func cmdAttr(r *REPL, args []string) error {
+	if err := needArgs(args, 2, "attr <selector> <name>"); err != nil {
+		return err
+	}
+	el, err := r.page().Element(args[0])
+	if err != nil {
+		return err
+	}
+	s, err := el.Attribute(args[1])
+	if err != nil {
+		return err
+	}
+	if s == nil {
+		r.printf("null\n")
+		return nil
+	}
+	r.printf("%s\n", *s)
+	return nil
+}
+
+func cmdClick(r *REPL, args []string) error {
+	if err := needArgs(args, 1, "click <selector>"); err != nil {
+		return err
+	}
+	el, err := r.page().Element(args[0])
+	if err != nil {
+		return err
+	}
+	return el.Click(proto.InputMouseButtonLeft, 1)
+}
+
+func cmdInput(r *REPL, args []string) error {
+	if err := needArgs(args, 2, "input <selector> <text>"); err != nil {
+		return err
+	}
+	el, err := r.page().Element(args[0])
+	if err != nil {
+		return err
+	}
+	if err := el.SelectAllText(); err != nil {
+		return err
+	}
+	return el.Input(args[1])
+}
+
+func cmdWait(r *REPL, args []string) error {
+	if err := needArgs(args, 1, "wait <selector>"); err != nil {
+		return err
+	}
+	el, err := r.page().Element(args[0])
+	if err != nil {
+		return err
+	}
+	return el.WaitVisible()
+}
+
+func cmdEval(r *REPL, args []string) error {
+	if err := needArgs(args, 1, "eval <js>"); err != nil {
+		return err
+	}
+	res, err := r.page().Eval(toJSFunc(args[0]))
+	if err != nil {
+		return err
+	}
+	r.printf("%s\n", res.Value.JSON("", "  "))
+	return nil
+}
+
+func cmdScreenshot(r *REPL, args []string) error {
+	file := "screenshot.png"
+	if len(args) > 0 {
+		file = args[0]
+	}
+	bin, err := r.page().Screenshot(false, nil)
+	if err != nil {
+		return err
+	}
+	if err := utils.OutputFile(file, bin); err != nil {
+		return err
+	}
+	r.printf("saved to %s\n", file)
+	return nil
+}
+
+var regJSFunc = regexp.MustCompile(`^(async\s+)?(function\b|\([^)]*\)\s*=>|[\w$]+\s*=>)`)
+
+// toJSFunc wraps the expression as a function, the function definition is returned as it is
+func toJSFunc(js string) string {
+	js = strings.TrimSpace(js)
+	if regJSFunc.MatchString(js) {
+		return js
+	}
+	return "() => (" + strings.TrimRight(js, "; \t") + ")"
+}
+
+func ellipsis(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if r := []rune(s); len(r) > n {
+		return string(r[:n]) + "..."
+	}
+	return s
+}
+
+func cutWord(line string) (word, rest string) {
+	line = strings.TrimLeft(line, " \t")
+	i := strings.IndexAny(line, " \t")
+	if i < 0 {
+		return line, ""
+	}
+	return line[:i], strings.TrimLeft(line[i:], " \t")
+}
+
+// splitArgs splits the line by spaces, the single or double quotes can be used to include spaces,
+// the double-quoted argument supports the Go escapes such as "\n".
+func splitArgs(line string) ([]string, error) {
+	args := []string{}
+	for {
+		line = strings.TrimLeft(line, " \t")
+		if line == "" {
+			return args, nil
+		}
+
+		switch line[0] {
+		case '"':
+			end := 1
+			for ; end < len(line) && line[end] != '"'; end++ {
+				if line[end] == '\\' {
+					end++
+				}
+			}
+			if end >= len(line) {
+				return nil, errors.New("unclosed double quote")
+			}
+			s, err := strconv.Unquote(line[:end+1])
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, s)
+			line = line[end+1:]
+		case '\'':
+			end := strings.IndexByte(line[1:], '\'')
+			if end < 0 {
+				return nil, errors.New("unclosed single quote")
+			}
+			args = append(args, line[1:end+1])
+			line = line[end+2:]
+		default:
+			word, rest := cutWord(line)
+			args = append(args, word)
+			line = rest
+		}
+	}
+}
+
+func filterPrefix(list []string, prefix string) []string {
+	seen := map[string]bool{}
+	out := []string{}
+	for _, s := range list {
+		if strings.HasPrefix(s, prefix) && !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func commonPrefix(list []string) string {
+	if len(list) == 0 {
+		return ""
+	}
+	p := list[0]
+	for _, s := range list[1:] {
+		for !strings.HasPrefix(s, p) {
+			p = p[:len(p)-1]
+		}
+	}
+	return p
+}
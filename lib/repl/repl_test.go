@@ -0,0 +1,90 @@
+package repl
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/ysmood/got"
+)
+
+func TestSplitArgs(t *testing.T) {
+	g := got.T(t)
+
+	args, err := splitArgs(` input[name="q"]  "hello world\n" 'a b' c `)
+	g.E(err)
+	g.Eq(args, []string{`input[name="q"]`, "hello world\n", "a b", "c"})
+
+	args, err = splitArgs("")
+	g.E(err)
+	g.Len(args, 0)
+
+	_, err = splitArgs(`"a`)
+	g.Eq(err.Error(), "unclosed double quote")
+
+	_, err = splitArgs(`'a`)
+	g.Eq(err.Error(), "unclosed single quote")
+
+	_, err = splitArgs(`"\z"`)
+	g.Err(err)
+}
+
+func TestToJSFunc(t *testing.T) {
+	g := got.T(t)
+
+	g.Eq(toJSFunc("document.title;"), "() => (document.title)")
+	g.Eq(toJSFunc("() => 1"), "() => 1")
+	g.Eq(toJSFunc("async () => 1"), "async () => 1")
+	g.Eq(toJSFunc("function () { return 1 }"), "function () { return 1 }")
+	g.Eq(toJSFunc("a => a"), "a => a")
+	g.Eq(toJSFunc("(1 + 2) * 3"), "() => ((1 + 2) * 3)")
+}
+
+func TestCompletionHelpers(t *testing.T) {
+	g := got.T(t)
+
+	g.Eq(filterPrefix([]string{".b", "#a", ".a", ".b"}, "."), []string{".a", ".b"})
+	g.Eq(commonPrefix([]string{"#item-1", "#item-2", "#items"}), "#item")
+	g.Eq(commonPrefix(nil), "")
+
+	g.Eq(lastWordStart("click #a"), 6)
+	g.Eq(lastWordStart(`click "ul > l`), 6)
+	g.Eq(lastWordStart(`input "a b" c`), 12)
+	g.Eq(lastWordStart("click "), 6)
+
+	r := New(nil, nil, &bytes.Buffer{})
+	g.Eq(r.Complete("c"), []string{"click"})
+	g.Eq(r.Complete("p"), []string{"page", "pages"})
+	g.Nil(r.Complete("info "))
+	g.Nil(r.Complete("unknown #a"))
+	g.Nil(r.Complete("click #a "))
+}
+
+func TestExec(t *testing.T) {
+	g := got.T(t)
+
+	out := &bytes.Buffer{}
+	r := New(nil, nil, out)
+
+	g.E(r.Exec("   "))
+	g.Eq(r.Exec("exit"), ErrExit)
+	g.Eq(r.Exec("foo").Error(), `unknown command "foo", type "help" to list the commands`)
+	g.Eq(r.Exec("click").Error(), "usage: click <selector>")
+	g.Eq(r.Exec(`click "a`).Error(), "unclosed double quote")
+
+	g.E(r.Exec("help click"))
+	g.Eq(out.String(), "click <selector>\n\tclick the first matched element\n")
+	g.Err(r.Exec("help foo"))
+
+	r.Add(&Command{Name: "echo", Usage: "echo <text>", Raw: true, Run: func(r *REPL, args []string) error {
+		r.printf("%q\n", args)
+		return nil
+	}})
+
+	out.Reset()
+	g.E(r.Run(strings.NewReader("echo  a  'b' \nfoo\nhelp\nexit\necho c\n")))
+	g.Has(out.String(), "rod> [\"a  'b'\"]\n")
+	g.Has(out.String(), `error: unknown command "foo"`)
+	g.Has(out.String(), "echo <text>")
+	g.False(strings.Contains(out.String(), `["c"]`))
+}
@@ -0,0 +1,191 @@
+package repl
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// terminal is a minimal line editor, it switches the terminal to the non-canonical mode via stty,
+// so it works on the unix-like systems without extra dependencies.
+type terminal struct {
+	in    *bufio.Reader
+	out   io.Writer
+	state string
+}
+
+func newTerminal(f *os.File, out io.Writer) (*terminal, error) {
+	state, err := stty(f, "-g")
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = stty(f, "-icanon", "-echo", "-isig", "min", "1")
+	if err != nil {
+		return nil, err
+	}
+
+	return &terminal{in: bufio.NewReader(f), out: out, state: strings.TrimSpace(state)}, nil
+}
+
+func stty(f *os.File, args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = f
+	out, err := cmd.Output()
+	return string(out), err
+}
+
+func (t *terminal) restore() {
+	_, _ = stty(os.Stdin, t.state)
+}
+
+const (
+	keyCtrlC     = 3
+	keyCtrlD     = 4
+	keyCtrlU     = 21
+	keyTab       = '\t'
+	keyEscape    = 27
+	keyBackspace = 127
+	keyCtrlH     = 8
+)
+
+func (t *terminal) readLine(prompt string, complete func(string) []string) (string, error) {
+	line := []rune{}
+
+	redraw := func() {
+		fmt.Fprintf(t.out, "\r\x1b[K%s%s", prompt, string(line))
+	}
+	redraw()
+
+	for {
+		r, _, err := t.in.ReadRune()
+		if err != nil {
+			return "", err
+		}
+
+		switch r {
+		case '\r', '\n':
+			fmt.Fprintln(t.out)
+			return string(line), nil
+
+		case keyCtrlC:
+			fmt.Fprintln(t.out, "^C")
+			if len(line) == 0 {
+				return "", io.EOF
+			}
+			line = line[:0]
+			redraw()
+
+		case keyCtrlD:
+			if len(line) == 0 {
+				fmt.Fprintln(t.out)
+				return "", io.EOF
+			}
+
+		case keyCtrlU:
+			line = line[:0]
+			redraw()
+
+		case keyBackspace, keyCtrlH:
+			if len(line) > 0 {
+				line = line[:len(line)-1]
+				redraw()
+			}
+
+		case keyEscape:
+			// skip the escape sequences such as the arrow keys
+			if next, _ := t.in.Peek(1); len(next) == 1 && next[0] == '[' {
+				_, _ = t.in.ReadByte()
+				for {
+					b, err := t.in.ReadByte()
+					if err != nil || (b >= 0x40 && b <= 0x7e) {
+						break
+					}
+				}
+			}
+
+		case keyTab:
+			line = t.complete(line, complete)
+			redraw()
+
+		default:
+			if r >= ' ' && r != utf8.RuneError {
+				line = append(line, r)
+				redraw()
+			}
+		}
+	}
+}
+
+func (t *terminal) complete(line []rune, complete func(string) []string) []rune {
+	s := string(line)
+	list := complete(s)
+	if len(list) == 0 {
+		return line
+	}
+
+	start := lastWordStart(s)
+	word := s[start:]
+
+	// keep the quote of the word
+	closing := ""
+	if word != "" && (word[0] == '"' || word[0] == '\'') {
+		q := word[0]
+		for i, c := range list {
+			if q == '"' {
+				c = strings.TrimSuffix(strconv.Quote(c), `"`)
+			} else {
+				c = "'" + c
+			}
+			list[i] = c
+		}
+		closing = string(q)
+	}
+
+	if len(list) == 1 {
+		return []rune(s[:start] + list[0] + closing + " ")
+	}
+
+	if p := commonPrefix(list); len(p) > len(word) {
+		return []rune(s[:start] + p)
+	}
+
+	fmt.Fprintln(t.out)
+	const max = 100
+	if len(list) > max {
+		fmt.Fprintf(t.out, "%s\n... %d more\n", strings.Join(list[:max], "  "), len(list)-max)
+	} else {
+		fmt.Fprintln(t.out, strings.Join(list, "  "))
+	}
+	return line
+}
+
+// lastWordStart returns the byte index where the last word of the line starts, the word is what
+// [REPL.Complete] replaces, a quoted argument is treated as one word.
+func lastWordStart(line string) int {
+	start := 0
+	quote := byte(0)
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			if i == start {
+				quote = c
+			}
+		case c == ' ' || c == '\t':
+			start = i + 1
+		}
+	}
+	return start
+}
@@ -0,0 +1,165 @@
+// Package robots parses the robots.txt and matches the paths against its rules.
+// Spec: https://www.rfc-editor.org/rfc/rfc9309
+package robots
+
+import (
+	"bufio"
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Robots is a parsed robots.txt
+type Robots struct {
+	groups []*group
+}
+
+type group struct {
+	agents     []string
+	rules      []*rule
+	crawlDelay time.Duration
+}
+
+type rule struct {
+	allow   bool
+	pattern string
+	reg     *regexp.Regexp
+}
+
+// Parse the content of a robots.txt, the invalid lines are ignored
+func Parse(data []byte) *Robots {
+	r := &Robots{}
+
+	var g *group
+	inAgents := false
+
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for s.Scan() {
+		line := s.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+
+		key, val, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		val = strings.TrimSpace(val)
+
+		if key == "user-agent" {
+			if !inAgents {
+				g = &group{}
+				r.groups = append(r.groups, g)
+				inAgents = true
+			}
+			g.agents = append(g.agents, strings.ToLower(val))
+			continue
+		}
+
+		if g == nil {
+			continue
+		}
+		inAgents = false
+
+		switch key {
+		case "allow", "disallow":
+			if val == "" {
+				// an empty disallow allows everything
+				continue
+			}
+			g.rules = append(g.rules, &rule{allow: key == "allow", pattern: val, reg: compile(val)})
+		case "crawl-delay":
+			if f, err := strconv.ParseFloat(val, 64); err == nil && f >= 0 {
+				g.crawlDelay = time.Duration(f * float64(time.Second))
+			}
+		}
+	}
+
+	return r
+}
+
+// compile the pattern, the "*" matches any sequence, the "$" at the end anchors the end of the path
+func compile(pattern string) *regexp.Regexp {
+	end := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+
+	parts := strings.Split(pattern, "*")
+	for i, p := range parts {
+		parts[i] = regexp.QuoteMeta(p)
+	}
+
+	expr := "^" + strings.Join(parts, ".*")
+	if end {
+		expr += "$"
+	}
+	return regexp.MustCompile(expr)
+}
+
+// group for the user agent, the longest matched product token wins, "*" is the fallback
+func (r *Robots) group(agent string) []*group {
+	agent = strings.ToLower(agent)
+
+	best := 0
+	list := []*group{}
+	fallback := []*group{}
+	for _, g := range r.groups {
+		for _, a := range g.agents {
+			if a == "*" {
+				fallback = append(fallback, g)
+				continue
+			}
+			if a != "" && strings.Contains(agent, a) {
+				if len(a) > best {
+					best, list = len(a), []*group{g}
+				} else if len(a) == best {
+					list = append(list, g)
+				}
+			}
+		}
+	}
+
+	if len(list) > 0 {
+		return list
+	}
+	return fallback
+}
+
+// Allowed returns true if the agent is allowed to crawl the path, the path can include the query.
+// The longest matched rule wins, the allow wins if the allow and disallow rules are of the same length.
+func (r *Robots) Allowed(agent, path string) bool {
+	if path == "" {
+		path = "/"
+	}
+	if path == "/robots.txt" {
+		return true
+	}
+
+	allowed := true
+	longest := -1
+	for _, g := range r.group(agent) {
+		for _, ru := range g.rules {
+			if !ru.reg.MatchString(path) {
+				continue
+			}
+			l := len(ru.pattern)
+			if l > longest || (l == longest && ru.allow) {
+				longest, allowed = l, ru.allow
+			}
+		}
+	}
+	return allowed
+}
+
+// CrawlDelay of the agent, 0 if not set
+func (r *Robots) CrawlDelay(agent string) time.Duration {
+	var d time.Duration
+	for _, g := range r.group(agent) {
+		if g.crawlDelay > d {
+			d = g.crawlDelay
+		}
+	}
+	return d
+}
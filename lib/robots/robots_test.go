@@ -0,0 +1,68 @@
+package robots_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Fromsko/rodPro/lib/robots"
+	"github.com/ysmood/got"
+)
+
+const sample = `
+# comments are ignored
+User-agent: *
+Disallow: /private/
+Allow: /private/public.html
+Disallow: /*.pdf$
+Disallow: /search?
+Crawl-delay: 1.5
+
+User-agent: RodBot
+User-agent: other
+Disallow: /
+Allow: /$
+Allow: /docs
+Crawl-delay: 2
+
+User-agent: rodbot-news
+Disallow:
+
+invalid line
+`
+
+func TestAllowed(t *testing.T) {
+	g := got.T(t)
+
+	r := robots.Parse([]byte(sample))
+
+	g.True(r.Allowed("Mozilla", "/"))
+	g.True(r.Allowed("Mozilla", ""))
+	g.False(r.Allowed("Mozilla", "/private/a.html"))
+	g.True(r.Allowed("Mozilla", "/private/public.html"))
+	g.False(r.Allowed("Mozilla", "/files/a.pdf"))
+	g.True(r.Allowed("Mozilla", "/files/a.pdf?download=1"))
+	g.False(r.Allowed("Mozilla", "/search?q=rod"))
+	g.True(r.Allowed("Mozilla", "/search"))
+
+	g.True(r.Allowed("Mozilla/5.0 (compatible; RodBot/1.0)", "/"))
+	g.False(r.Allowed("Mozilla/5.0 (compatible; RodBot/1.0)", "/private/public.html"))
+	g.True(r.Allowed("Mozilla/5.0 (compatible; RodBot/1.0)", "/docs/api"))
+	g.False(r.Allowed("other", "/a"))
+	g.True(r.Allowed("other", "/robots.txt"))
+
+	// the longer product token wins
+	g.True(r.Allowed("rodbot-news/1.0", "/private/a.html"))
+
+	g.True(robots.Parse(nil).Allowed("Mozilla", "/a"))
+}
+
+func TestCrawlDelay(t *testing.T) {
+	g := got.T(t)
+
+	r := robots.Parse([]byte(sample))
+
+	g.Eq(r.CrawlDelay("Mozilla"), 1500*time.Millisecond)
+	g.Eq(r.CrawlDelay("RodBot"), 2*time.Second)
+	g.Eq(r.CrawlDelay("rodbot-news"), time.Duration(0))
+	g.Eq(robots.Parse([]byte("User-agent: *\nCrawl-delay: x")).CrawlDelay("a"), time.Duration(0))
+}
@@ -0,0 +1,49 @@
+package rodtest
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/Fromsko/rodPro/lib/proto"
+	"github.com/ysmood/got"
+	"github.com/ysmood/gson"
+)
+
+func TestTestDirName(t *testing.T) {
+	g := got.T(t)
+
+	g.Eq(testDirName("TestA"), "TestA")
+	g.Eq(testDirName("TestA/sub case#01"), filepath.Join("TestA", "sub_case_01"))
+	g.Eq(testDirName("TestA/../b"), filepath.Join("TestA", "_", "b"))
+}
+
+func TestGoroutineID(t *testing.T) {
+	g := got.T(t)
+
+	id := goroutineID()
+	g.Gt(id, uint64(0))
+	g.Eq(goroutineID(), id)
+
+	other := make(chan uint64)
+	go func() { other <- goroutineID() }()
+	g.Neq(<-other, id)
+}
+
+func TestConsoleMessage(t *testing.T) {
+	g := got.T(t)
+
+	g.Eq(consoleMessage([]*proto.RuntimeRemoteObject{
+		{Value: gson.New("err")},
+		{Value: gson.New(1)},
+		{ObjectID: "1", Description: "Error: boom"},
+	}), "err 1 Error: boom")
+
+	g.Eq(exceptionDescription(&proto.RuntimeExceptionDetails{}), "")
+	g.Eq(exceptionDescription(&proto.RuntimeExceptionDetails{
+		Exception: &proto.RuntimeRemoteObject{Description: "Error: boom"},
+	}), "Error: boom")
+
+	p := &Page{}
+	p.addConsoleError("a")
+	g.Eq(p.ConsoleErrors(), []string{"a"})
+}
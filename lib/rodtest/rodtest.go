@@ -0,0 +1,222 @@
+// Package rodtest lends an isolated page to each test, it packages the common patterns of testing with rod:
+// a shared browser, an incognito context per test, the test deadline, the console errors, the artifacts
+// on failure, and the cleanup.
+//
+//	func TestLogin(t *testing.T) {
+//		p := rodtest.New(t)
+//		p.MustNavigate("http://localhost:8080/login")
+//		p.MustElement("#user").MustInput("alice")
+//	}
+package rodtest
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Fromsko/rodPro"
+	"github.com/Fromsko/rodPro/lib/launcher"
+	"github.com/Fromsko/rodPro/lib/proto"
+)
+
+// Options for [NewWith]
+type Options struct {
+	// Browser to lend the pages from, if it's nil a shared browser will be launched once per process.
+	Browser *rod.Browser
+
+	// Artifacts is the dir to save the artifacts when a test fails, the artifacts of each test are saved under
+	// the sub dir of the test name, check [rod.Browser.Artifacts] for details. Set it to empty to disable.
+	Artifacts string
+
+	// DeadlineGrace is the duration reserved before the deadline of the test, so that the page times out
+	// before the test is killed and the cleanup can still run.
+	DeadlineGrace time.Duration
+
+	// FailOnConsoleError fails the test if the page has any console error or uncaught exception
+	FailOnConsoleError bool
+}
+
+// DefaultOptions for [New]
+func DefaultOptions() *Options {
+	return &Options{
+		Artifacts:     filepath.Join("tmp", "rodtest"),
+		DeadlineGrace: 5 * time.Second,
+	}
+}
+
+// Page lent to a test
+type Page struct {
+	*rod.Page
+
+	lock          sync.Mutex
+	consoleErrors []string
+}
+
+// ConsoleErrors returns the console errors and uncaught exceptions of the page so far
+func (p *Page) ConsoleErrors() []string {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return append([]string{}, p.consoleErrors...)
+}
+
+func (p *Page) addConsoleError(msg string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.consoleErrors = append(p.consoleErrors, msg)
+}
+
+var (
+	shared     *rod.Browser
+	sharedOnce sync.Once
+)
+
+// SharedBrowser returns the browser that is launched once per process for the tests
+func SharedBrowser() *rod.Browser {
+	sharedOnce.Do(func() {
+		shared = rod.New().ControlURL(launcher.New().MustLaunch()).MustConnect()
+	})
+	return shared
+}
+
+// New is similar to [NewWith] with the [DefaultOptions]
+func New(t testing.TB) *Page {
+	t.Helper()
+	return NewWith(t, DefaultOptions())
+}
+
+// NewWith lends a blank page of a new incognito browser context to the test. The Must methods of the page fail
+// the test via t.Fatal instead of panic, if they are called from other goroutines than the test's, the test is
+// marked as failed via t.Error and only the calling goroutine is stopped. The page is closed and the incognito context is disposed when the test
+// is done, if the test failed, the artifacts and the console errors are reported.
+func NewWith(t testing.TB, opts *Options) *Page {
+	t.Helper()
+
+	b := opts.Browser
+	if b == nil {
+		b = SharedBrowser()
+	}
+
+	incognito, err := b.Incognito()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := ""
+	if opts.Artifacts != "" {
+		dir = filepath.Join(opts.Artifacts, testDirName(t.Name()))
+	}
+	incognito = incognito.Artifacts(dir)
+
+	page, err := incognito.Page(proto.TargetCreateTarget{})
+	if err != nil {
+		_ = incognito.Close()
+		t.Fatal(err)
+	}
+
+	p := &Page{Page: page}
+
+	cancel := func() {}
+	if d, ok := t.(interface{ Deadline() (time.Time, bool) }); ok {
+		if deadline, has := d.Deadline(); has {
+			p.Page = p.Page.WithDeadline(deadline.Add(-opts.DeadlineGrace))
+			cancel = func() { p.Page.CancelTimeout() }
+		}
+	}
+
+	// t.FailNow must only be called from the goroutine running the test
+	testGoroutine := goroutineID()
+	p.Page = p.Page.WithPanic(func(v interface{}) {
+		t.Helper()
+		if goroutineID() == testGoroutine {
+			t.Fatal(v)
+		}
+		t.Error(v)
+		runtime.Goexit()
+	})
+
+	go page.EachEvent(func(e *proto.RuntimeConsoleAPICalled) {
+		if e.Type == proto.RuntimeConsoleAPICalledTypeError {
+			p.addConsoleError(consoleMessage(e.Args))
+		}
+	}, func(e *proto.RuntimeExceptionThrown) {
+		p.addConsoleError(e.ExceptionDetails.Text + " " + exceptionDescription(e.ExceptionDetails))
+	})()
+
+	t.Cleanup(func() {
+		errs := p.ConsoleErrors()
+		if opts.FailOnConsoleError && len(errs) > 0 {
+			t.Errorf("console errors:\n%s", strings.Join(errs, "\n"))
+		}
+
+		if t.Failed() {
+			if len(errs) > 0 && !opts.FailOnConsoleError {
+				t.Logf("console errors:\n%s", strings.Join(errs, "\n"))
+			}
+			if dir != "" {
+				if d, err := page.CaptureArtifacts(fmt.Errorf("%s failed", t.Name())); err == nil {
+					t.Logf("artifacts: %s", d)
+				}
+			}
+		}
+
+		cancel()
+		_ = page.Close()
+		_ = incognito.Close()
+	})
+
+	return p
+}
+
+func consoleMessage(args []*proto.RuntimeRemoteObject) string {
+	list := []string{}
+	for _, arg := range args {
+		if arg.ObjectID == "" {
+			list = append(list, arg.Value.String())
+		} else {
+			list = append(list, arg.Description)
+		}
+	}
+	return strings.Join(list, " ")
+}
+
+func exceptionDescription(d *proto.RuntimeExceptionDetails) string {
+	if d.Exception == nil {
+		return ""
+	}
+	return d.Exception.Description
+}
+
+// goroutineID parses the id of the current goroutine from the header of its stack, such as "goroutine 7 [running]:"
+func goroutineID() uint64 {
+	buf := make([]byte, 64)
+	buf = buf[:runtime.Stack(buf, false)]
+	fields := bytes.Fields(buf)
+	if len(fields) < 2 {
+		return 0
+	}
+	id, _ := strconv.ParseUint(string(fields[1]), 10, 64)
+	return id
+}
+
+var regUnsafeName = regexp.MustCompile(`[^\w.-]+`)
+
+// testDirName converts the test name to a safe dir name, such as "TestA/sub case" to "TestA/sub_case"
+func testDirName(name string) string {
+	parts := strings.Split(name, "/")
+	for i, p := range parts {
+		parts[i] = regUnsafeName.ReplaceAllString(p, "_")
+		if parts[i] == "." || parts[i] == ".." {
+			parts[i] = "_"
+		}
+	}
+	return filepath.Join(parts...)
+}
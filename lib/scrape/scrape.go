@@ -0,0 +1,263 @@
+// Package scrape compiles a Go struct with the "rod" tags into a js function that extracts all the fields
+// from the page in a single call, then decodes the result back into the struct.
+// Usually, you don't need to use it directly, use the Page.Scrape instead.
+//
+// The format of the tag is:
+//
+//	rod:"selector,option,option..."
+//
+// The selector is a css selector relative to the parent, empty selector means the parent itself.
+// The options are:
+//
+//	attr=NAME   extract the attribute instead of the trimmed innerText
+//	html        extract the innerHTML instead of the trimmed innerText
+//	hook=NAME   post-process the extracted string with the [Hook] of the name before decoding
+//
+// The type of the field decides how the matched elements are decoded:
+//
+//	string         the extracted string of the first matched element
+//	other kinds    the extracted string is decoded as json, such as int, float64, bool
+//	pointer        nil if no element matches the selector
+//	struct         the fields of the struct are relative to the first matched element
+//	slice          each matched element is decoded as the element type of the slice
+//
+// Such as:
+//
+//	type Product struct {
+//		Name  string   `rod:"h2"`
+//		Price float64  `rod:".price,hook=trimCurrency"`
+//		Link  string   `rod:"a,attr=href"`
+//		Tags  []string `rod:".tag"`
+//	}
+//
+//	type Shop struct {
+//		Title    string    `rod:"h1"`
+//		Products []Product `rod:".product"`
+//	}
+package scrape
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/Fromsko/rodPro/lib/utils"
+	"github.com/ysmood/gson"
+)
+
+// Hook post-processes the extracted string of a field, such as trimming the currency symbol of a price
+type Hook func(value string) (string, error)
+
+// Hooks maps the names in the "hook" tag option to the hook functions
+type Hooks map[string]Hook
+
+// Field of the [Schema]
+type Field struct {
+	// Name of the struct field
+	Name string `json:"name"`
+
+	Selector string `json:"selector"`
+
+	// Attr to extract, empty means the trimmed innerText
+	Attr string `json:"attr"`
+
+	// HTML is true if the innerHTML should be extracted
+	HTML bool `json:"html"`
+
+	// All is true if the field is a slice, then all the matched elements will be extracted
+	All bool `json:"all"`
+
+	// Fields of the nested struct, nil if the field isn't a struct
+	Fields []*Field `json:"fields"`
+
+	Hook string `json:"-"`
+}
+
+// Schema of a struct type, create it via [Compile]
+type Schema struct {
+	Type   reflect.Type
+	Fields []*Field
+}
+
+// Compile the struct type of the data into a [Schema], data is a struct or a pointer to struct.
+func Compile(data interface{}) (*Schema, error) {
+	t := reflect.TypeOf(data)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("expect a struct, got %T", data)
+	}
+
+	fields, err := compileStruct(t)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Schema{Type: t, Fields: fields}, nil
+}
+
+func compileStruct(t reflect.Type) ([]*Field, error) {
+	fields := []*Field{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag, ok := f.Tag.Lookup("rod")
+		if f.PkgPath != "" || !ok || tag == "-" {
+			continue
+		}
+
+		field, err := compileField(f.Name, f.Type, tag)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", f.Name, err)
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+func compileField(name string, t reflect.Type, tag string) (*Field, error) {
+	opts := strings.Split(tag, ",")
+	field := &Field{Name: name, Selector: strings.TrimSpace(opts[0])}
+
+	for _, opt := range opts[1:] {
+		k, v, _ := strings.Cut(strings.TrimSpace(opt), "=")
+		switch k {
+		case "attr":
+			field.Attr = v
+		case "html":
+			field.HTML = true
+		case "hook":
+			field.Hook = v
+		default:
+			return nil, fmt.Errorf("unknown tag option %q", opt)
+		}
+	}
+
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8 {
+		field.All = true
+		t = t.Elem()
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+	}
+
+	if t.Kind() == reflect.Struct {
+		fields, err := compileStruct(t)
+		if err != nil {
+			return nil, err
+		}
+		field.Fields = fields
+	}
+
+	return field, nil
+}
+
+// JS returns the js function that extracts the data of the schema, the "this" of the function is the root,
+// it's the document if "this" isn't an element.
+func (s *Schema) JS() string {
+	return fmt.Sprintf(`function() {
+		const schema = %s
+		const get = (el, f) => {
+			if (f.html) return el.innerHTML
+			if (f.attr) return el.getAttribute(f.attr)
+			return (el.innerText ?? el.textContent).trim()
+		}
+		const run = (root, fields) => {
+			const res = {}
+			for (const f of fields) {
+				const val = f.fields ? (el) => run(el, f.fields) : (el) => get(el, f)
+				if (f.all) {
+					const list = f.selector ? Array.from(root.querySelectorAll(f.selector)) : [root]
+					res[f.name] = list.map(val)
+				} else {
+					const el = f.selector ? root.querySelector(f.selector) : root
+					res[f.name] = el ? val(el) : null
+				}
+			}
+			return res
+		}
+		return run(this instanceof Element ? this : document.documentElement, schema)
+	}`, utils.MustToJSON(s.Fields))
+}
+
+// Decode the result of the [Schema.JS] into data, data must be a pointer to the type of the schema.
+// The hooks are applied to the extracted strings before decoding.
+func (s *Schema) Decode(res gson.JSON, data interface{}, hooks Hooks) error {
+	v := reflect.ValueOf(data)
+	if v.Kind() != reflect.Ptr || v.Elem().Type() != s.Type {
+		return fmt.Errorf("expect *%s, got %T", s.Type, data)
+	}
+
+	return decodeStruct(v.Elem(), s.Fields, res, hooks)
+}
+
+func decodeStruct(v reflect.Value, fields []*Field, res gson.JSON, hooks Hooks) error {
+	for _, f := range fields {
+		err := decode(v.FieldByName(f.Name), f, res.Get(f.Name), hooks)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", f.Name, err)
+		}
+	}
+	return nil
+}
+
+func decode(v reflect.Value, f *Field, val gson.JSON, hooks Hooks) error {
+	if val.Nil() {
+		return nil
+	}
+
+	switch {
+	case v.Kind() == reflect.Ptr:
+		ptr := reflect.New(v.Type().Elem())
+		err := decode(ptr.Elem(), f, val, hooks)
+		if err != nil {
+			return err
+		}
+		v.Set(ptr)
+		return nil
+
+	case f.All && v.Kind() == reflect.Slice:
+		list := reflect.MakeSlice(v.Type(), 0, len(val.Arr()))
+		item := *f
+		item.All = false
+		for _, j := range val.Arr() {
+			el := reflect.New(v.Type().Elem()).Elem()
+			err := decode(el, &item, j, hooks)
+			if err != nil {
+				return err
+			}
+			list = reflect.Append(list, el)
+		}
+		v.Set(list)
+		return nil
+
+	case v.Kind() == reflect.Struct:
+		return decodeStruct(v, f.Fields, val, hooks)
+	}
+
+	str := val.Str()
+	if f.Hook != "" {
+		hook, has := hooks[f.Hook]
+		if !has {
+			return fmt.Errorf("hook %q not found", f.Hook)
+		}
+
+		var err error
+		str, err = hook(str)
+		if err != nil {
+			return err
+		}
+	}
+
+	if v.Kind() == reflect.String {
+		v.SetString(str)
+		return nil
+	}
+
+	return json.Unmarshal([]byte(str), v.Addr().Interface())
+}
@@ -0,0 +1,102 @@
+package scrape_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Fromsko/rodPro/lib/scrape"
+	"github.com/ysmood/got"
+	"github.com/ysmood/gson"
+)
+
+var setup = got.Setup(nil)
+
+type product struct {
+	Name  string   `rod:"h2"`
+	Price float64  `rod:".price,hook=currency"`
+	Link  *string  `rod:"a,attr=href"`
+	Tags  []string `rod:".tag"`
+	Desc  string   `rod:"p,html"`
+	Skip  string   `rod:"-"`
+	NoTag string
+}
+
+type shop struct {
+	Title    string     `rod:"h1"`
+	Products []*product `rod:".product"`
+	First    product    `rod:".product"`
+}
+
+func TestCompile(t *testing.T) {
+	g := setup(t)
+
+	s, err := scrape.Compile(&shop{})
+	g.E(err)
+
+	g.Len(s.Fields, 3)
+	g.Eq(s.Fields[1].Name, "Products")
+	g.True(s.Fields[1].All)
+	g.Len(s.Fields[1].Fields, 5)
+
+	p := s.Fields[1].Fields
+	g.Eq(p[1].Hook, "currency")
+	g.Eq(p[2].Attr, "href")
+	g.True(p[3].All)
+	g.True(p[4].HTML)
+
+	g.Has(s.JS(), `"selector":".product"`)
+
+	_, err = scrape.Compile(1)
+	g.Eq(err.Error(), "expect a struct, got int")
+
+	_, err = scrape.Compile(struct {
+		A struct {
+			B string `rod:"b,unknown"`
+		} `rod:"a"`
+	}{})
+	g.Eq(err.Error(), `field A: field B: unknown tag option "unknown"`)
+}
+
+func TestDecode(t *testing.T) {
+	g := setup(t)
+
+	s, err := scrape.Compile(shop{})
+	g.E(err)
+
+	res := gson.NewFrom(`{
+		"Title": "Shop",
+		"Products": [
+			{"Name": "Apple", "Price": "$1.5", "Link": "/apple", "Tags": ["fruit", "red"], "Desc": "<b>a</b>"},
+			{"Name": "Carrot", "Price": "$0.8", "Link": null, "Tags": []}
+		],
+		"First": {"Name": "Apple", "Price": "$1.5"}
+	}`)
+
+	hooks := scrape.Hooks{"currency": func(s string) (string, error) {
+		return strings.TrimPrefix(s, "$"), nil
+	}}
+
+	var data shop
+	g.E(s.Decode(res, &data, hooks))
+
+	g.Eq(data.Title, "Shop")
+	g.Len(data.Products, 2)
+	g.Eq(data.Products[0].Price, 1.5)
+	g.Eq(*data.Products[0].Link, "/apple")
+	g.Eq(data.Products[0].Tags, []string{"fruit", "red"})
+	g.Eq(data.Products[0].Desc, "<b>a</b>")
+	g.Nil(data.Products[1].Link)
+	g.Eq(data.Products[1].Tags, []string{})
+	g.Eq(data.First.Name, "Apple")
+
+	g.Eq(s.Decode(res, data, hooks).Error(), "expect *scrape_test.shop, got scrape_test.shop")
+
+	g.Eq(s.Decode(res, &data, nil).Error(), `field Products: field Price: hook "currency" not found`)
+
+	hooks["currency"] = func(s string) (string, error) { return "", errors.New("err") }
+	g.Eq(s.Decode(res, &data, hooks).Error(), "field Products: field Price: err")
+
+	hooks["currency"] = func(s string) (string, error) { return s, nil }
+	g.Has(s.Decode(res, &data, hooks).Error(), "field Products: field Price: invalid character")
+}
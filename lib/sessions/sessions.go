@@ -0,0 +1,218 @@
+// Package sessions stores named login sessions, such as the cookies, the storages, the user agent, and the
+// fingerprint of an account, so that a scraping fleet can rotate the accounts by stamping any new page
+// or browser context with a chosen session. Use [Vault] to keep them encrypted on disk.
+package sessions
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/Fromsko/rodPro"
+	"github.com/Fromsko/rodPro/lib/proto"
+	"github.com/ysmood/gson"
+)
+
+// Session of an account
+type Session struct {
+	Name    string                 `json:"name"`
+	Cookies []*proto.NetworkCookie `json:"cookies,omitempty"`
+
+	// Storages of the origins, such as the tokens in the localStorage
+	Storages []*Storage `json:"storages,omitempty"`
+
+	// UserAgent override, empty to keep the browser's
+	UserAgent *proto.NetworkSetUserAgentOverride `json:"userAgent,omitempty"`
+
+	// Fingerprint to emulate, nil to keep the browser's
+	Fingerprint *Fingerprint `json:"fingerprint,omitempty"`
+
+	SavedAt time.Time `json:"savedAt"`
+}
+
+// Storage of an origin
+type Storage struct {
+	// Origin is like "https://example.com"
+	Origin         string            `json:"origin"`
+	LocalStorage   map[string]string `json:"localStorage,omitempty"`
+	SessionStorage map[string]string `json:"sessionStorage,omitempty"`
+}
+
+// Fingerprint of a session, the zero value of a field means no override.
+type Fingerprint struct {
+	// TimezoneID is like "America/New_York"
+	TimezoneID string `json:"timezoneID,omitempty"`
+
+	// Locale is like "en_US"
+	Locale string `json:"locale,omitempty"`
+
+	// Viewport of the pages
+	Viewport *proto.EmulationSetDeviceMetricsOverride `json:"viewport,omitempty"`
+}
+
+// Capture the session of the account that is logged in on the page. The cookies are from the browser context
+// of the page, the storages are from the origin of the page, the user agent and the fingerprint are what the
+// page reports, so that the session looks the same when it's applied to another browser.
+func Capture(name string, p *rod.Page) (*Session, error) {
+	cookies, err := p.Browser().GetCookies()
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := p.Eval(`() => {
+		const storage = (s) => { try { return { ...s() } } catch { return {} } }
+		const opts = Intl.DateTimeFormat().resolvedOptions()
+		return {
+			origin: location.origin,
+			local: storage(() => localStorage),
+			session: storage(() => sessionStorage),
+			ua: navigator.userAgent,
+			lang: navigator.languages.join(','),
+			platform: navigator.platform,
+			timezone: opts.timeZone,
+			locale: opts.locale,
+			width: innerWidth,
+			height: innerHeight,
+			scale: devicePixelRatio,
+		}
+	}`)
+	if err != nil {
+		return nil, err
+	}
+	v := res.Value
+
+	s := &Session{
+		Name:    name,
+		Cookies: cookies,
+		UserAgent: &proto.NetworkSetUserAgentOverride{
+			UserAgent:      v.Get("ua").Str(),
+			AcceptLanguage: v.Get("lang").Str(),
+			Platform:       v.Get("platform").Str(),
+		},
+		Fingerprint: &Fingerprint{
+			TimezoneID: v.Get("timezone").Str(),
+			Locale:     strings.ReplaceAll(v.Get("locale").Str(), "-", "_"),
+			Viewport: &proto.EmulationSetDeviceMetricsOverride{
+				Width:             v.Get("width").Int(),
+				Height:            v.Get("height").Int(),
+				DeviceScaleFactor: v.Get("scale").Num(),
+			},
+		},
+		SavedAt: time.Now(),
+	}
+
+	if origin := v.Get("origin").Str(); origin != "null" {
+		s.Storages = []*Storage{{
+			Origin:         origin,
+			LocalStorage:   storageMap(v.Get("local").Map()),
+			SessionStorage: storageMap(v.Get("session").Map()),
+		}}
+	}
+
+	return s, nil
+}
+
+// Apply the session to the page before it navigates to the site. The cookies are set to the browser context of
+// the page, the storages are seeded into each new document of the matched origins without overriding the
+// existing keys, the user agent and the fingerprint are emulated on the page.
+func (s *Session) Apply(p *rod.Page) error {
+	if len(s.Cookies) > 0 {
+		err := p.Browser().SetCookies(proto.CookiesToParams(s.Cookies))
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(s.Storages) > 0 {
+		js, err := json.Marshal(s.Storages)
+		if err != nil {
+			return err
+		}
+
+		_, err = p.EvalOnNewDocument(`(list => {
+			for (const s of list) {
+				if (location.origin !== s.origin) continue
+				const seed = (storage, dict) => {
+					for (const k in dict || {}) if (storage.getItem(k) === null) storage.setItem(k, dict[k])
+				}
+				try {
+					seed(localStorage, s.localStorage)
+					seed(sessionStorage, s.sessionStorage)
+				} catch {}
+			}
+		})(` + string(js) + `)`)
+		if err != nil {
+			return err
+		}
+	}
+
+	if s.UserAgent != nil && s.UserAgent.UserAgent != "" {
+		err := p.SetUserAgent(s.UserAgent)
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.Fingerprint.apply(p)
+}
+
+// NewPage creates a new incognito browser context of b and a page in it that is stamped with the session,
+// so that the sessions of different accounts won't share the cookies. Close the context via
+// p.Browser().Close() after use.
+func (s *Session) NewPage(b *rod.Browser) (*rod.Page, error) {
+	incognito, err := b.Incognito()
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := incognito.Page(proto.TargetCreateTarget{})
+	if err != nil {
+		_ = incognito.Close()
+		return nil, err
+	}
+
+	err = s.Apply(p)
+	if err != nil {
+		_ = incognito.Close()
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (f *Fingerprint) apply(p *rod.Page) error {
+	if f == nil {
+		return nil
+	}
+
+	if f.TimezoneID != "" {
+		err := proto.EmulationSetTimezoneOverride{TimezoneID: f.TimezoneID}.Call(p)
+		if err != nil {
+			return err
+		}
+	}
+
+	if f.Locale != "" {
+		err := proto.EmulationSetLocaleOverride{Locale: f.Locale}.Call(p)
+		if err != nil {
+			return err
+		}
+	}
+
+	if f.Viewport != nil && f.Viewport.Width > 0 && f.Viewport.Height > 0 {
+		return p.SetViewport(f.Viewport)
+	}
+
+	return nil
+}
+
+func storageMap(m map[string]gson.JSON) map[string]string {
+	if len(m) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		out[k] = v.Str()
+	}
+	return out
+}
@@ -0,0 +1,137 @@
+package sessions
+
+import (
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Fromsko/rodPro/lib/proto"
+	"github.com/ysmood/got"
+)
+
+func TestVault(t *testing.T) {
+	g := got.T(t)
+
+	dir := filepath.Join(t.TempDir(), "vault")
+
+	_, err := Open(dir, "")
+	g.Err(err)
+
+	v, err := Open(dir, "secret")
+	g.E(err)
+
+	s := &Session{
+		Name:     "alice",
+		Cookies:  []*proto.NetworkCookie{{Name: "sid", Value: "123", Domain: "example.com"}},
+		Storages: []*Storage{{Origin: "https://example.com", LocalStorage: map[string]string{"token": "abc"}}},
+		UserAgent: &proto.NetworkSetUserAgentOverride{
+			UserAgent: "ua",
+		},
+		Fingerprint: &Fingerprint{TimezoneID: "Asia/Tokyo"},
+	}
+	g.E(v.Save(s))
+	g.E(v.Save(&Session{Name: "bob"}))
+
+	names, err := v.List()
+	g.E(err)
+	g.Eq(names, []string{"alice", "bob"})
+
+	bin, err := os.ReadFile(filepath.Join(dir, "alice.session"))
+	g.E(err)
+	g.False(strings.Contains(string(bin), "token"))
+
+	loaded, err := v.Load("alice")
+	g.E(err)
+	g.Eq(loaded.Cookies[0].Value, "123")
+	g.Eq(loaded.Storages[0].LocalStorage["token"], "abc")
+	g.Eq(loaded.UserAgent.UserAgent, "ua")
+	g.Eq(loaded.Fingerprint.TimezoneID, "Asia/Tokyo")
+
+	// the salt is reused, so the same passphrase opens the vault again
+	v2, err := Open(dir, "secret")
+	g.E(err)
+	_, err = v2.Load("alice")
+	g.E(err)
+
+	_, err = Open(dir, "wrong")
+	g.Is(err, ErrDecrypt)
+
+	// renamed files can't be decrypted as another session
+	g.E(os.Rename(filepath.Join(dir, "bob.session"), filepath.Join(dir, "eve.session")))
+	_, err = v.Load("eve")
+	g.Is(err, ErrDecrypt)
+
+	g.E(os.WriteFile(filepath.Join(dir, "short.session"), []byte{1}, 0o600))
+	_, err = v.Load("short")
+	g.Is(err, ErrDecrypt)
+
+	_, err = v.Load("none")
+	g.Is(err, ErrNotFound)
+
+	g.E(v.Delete("alice"))
+	g.E(v.Delete("alice"))
+	_, err = v.Load("alice")
+	g.Is(err, ErrNotFound)
+
+	g.Err(v.Save(&Session{Name: "../x"}))
+	_, err = v.Load("")
+	g.Err(err)
+	g.Err(v.Delete(".."))
+}
+
+func TestPBKDF2(t *testing.T) {
+	g := got.T(t)
+
+	// test vector from RFC 7914
+	key := pbkdf2([]byte("passwd"), []byte("salt"), 1, 64)
+	g.Eq(hex.EncodeToString(key[:16]), "55ac046e56e3089fec1691c22544b605")
+	g.Len(key, 64)
+}
+
+func TestVaultCheck(t *testing.T) {
+	g := got.T(t)
+
+	dir := filepath.Join(t.TempDir(), "vault")
+
+	v, err := Open(dir, "secret")
+	g.E(err)
+	g.E(v.Save(&Session{Name: "alice"}))
+
+	salt, err := os.ReadFile(filepath.Join(dir, ".salt"))
+	g.E(err)
+	g.Len(salt, 16)
+
+	// the vault created before the check file exists is verified via its sessions
+	g.E(os.Remove(filepath.Join(dir, ".check")))
+	_, err = Open(dir, "wrong")
+	g.Is(err, ErrDecrypt)
+	_, err = os.Stat(filepath.Join(dir, ".check"))
+	g.True(os.IsNotExist(err))
+
+	_, err = Open(dir, "secret")
+	g.E(err)
+	_, err = Open(dir, "wrong")
+	g.Is(err, ErrDecrypt)
+
+	// the concurrent openers share the same salt
+	dir = filepath.Join(t.TempDir(), "vault")
+	g.E(os.MkdirAll(dir, 0o700))
+	wg := sync.WaitGroup{}
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := Open(dir, "secret")
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		g.E(err)
+	}
+}
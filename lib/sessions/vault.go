@@ -0,0 +1,309 @@
+package sessions
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNotFound is returned when the session doesn't exist in the vault
+var ErrNotFound = errors.New("session not found")
+
+// ErrDecrypt is returned when the session can't be decrypted, usually the passphrase is wrong
+var ErrDecrypt = errors.New("failed to decrypt the session, the passphrase may be wrong")
+
+const (
+	saltFile   = ".salt"
+	checkFile  = ".check"
+	fileExt    = ".session"
+	iterations = 100000
+)
+
+// checkValue is sealed into the check file to verify the passphrase when the vault is opened
+var checkValue = []byte("rod sessions vault")
+
+// Vault of the sessions, each session is a file encrypted via AES-GCM in the dir,
+// the key is derived from the passphrase via PBKDF2-SHA256 with a random salt of the vault.
+// It's safe for concurrent use.
+type Vault struct {
+	dir  string
+	aead cipher.AEAD
+	lock sync.Mutex
+}
+
+// Open the vault in the dir, the dir will be created if it doesn't exist.
+// All the sessions of the vault must use the same passphrase, [ErrDecrypt] is returned if the passphrase is wrong.
+func Open(dir, passphrase string) (*Vault, error) {
+	if passphrase == "" {
+		return nil, errors.New("the passphrase of the vault is empty")
+	}
+
+	err := os.MkdirAll(dir, 0o700)
+	if err != nil {
+		return nil, err
+	}
+
+	salt, err := readSalt(filepath.Join(dir, saltFile))
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(pbkdf2([]byte(passphrase), salt, iterations, 32))
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &Vault{dir: dir, aead: aead}
+
+	err = v.verify()
+	if err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// verify the passphrase via the check file. For the vault created before the check file exists, the passphrase
+// is verified via a session of it before the check file is created.
+func (v *Vault) verify() error {
+	check, err := createOrRead(filepath.Join(v.dir, checkFile), func() ([]byte, error) {
+		names, err := v.List()
+		if err != nil {
+			return nil, err
+		}
+		if len(names) > 0 {
+			_, err = v.Load(names[0])
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return v.seal(checkValue, []byte(checkFile))
+	})
+	if err != nil {
+		return err
+	}
+
+	data, err := v.open(check, []byte(checkFile))
+	if err != nil || !hmac.Equal(data, checkValue) {
+		return ErrDecrypt
+	}
+	return nil
+}
+
+func (v *Vault) seal(data, ad []byte) ([]byte, error) {
+	nonce := make([]byte, v.aead.NonceSize())
+	_, err := rand.Read(nonce)
+	if err != nil {
+		return nil, err
+	}
+	return v.aead.Seal(nonce, nonce, data, ad), nil
+}
+
+func (v *Vault) open(bin, ad []byte) ([]byte, error) {
+	size := v.aead.NonceSize()
+	if len(bin) < size {
+		return nil, ErrDecrypt
+	}
+
+	data, err := v.aead.Open(nil, bin[:size], bin[size:], ad)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return data, nil
+}
+
+// Save the session with its name, the existing one with the same name will be overwritten.
+func (v *Vault) Save(s *Session) error {
+	path, err := v.path(s.Name)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	bin, err := v.seal(data, []byte(s.Name))
+	if err != nil {
+		return err
+	}
+
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	tmp := path + ".tmp"
+	err = os.WriteFile(tmp, bin, 0o600)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Load the session by its name
+func (v *Vault) Load(name string) (*Session, error) {
+	path, err := v.path(name)
+	if err != nil {
+		return nil, err
+	}
+
+	v.lock.Lock()
+	bin, err := os.ReadFile(path)
+	v.lock.Unlock()
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+	} else if err != nil {
+		return nil, err
+	}
+
+	data, err := v.open(bin, []byte(name))
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Session{}
+	err = json.Unmarshal(data, s)
+	return s, err
+}
+
+// Delete the session by its name, it's not an error if the session doesn't exist.
+func (v *Vault) Delete(name string) error {
+	path, err := v.path(name)
+	if err != nil {
+		return err
+	}
+
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	err = os.Remove(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// List the names of the sessions in the vault, sorted
+func (v *Vault) List() ([]string, error) {
+	v.lock.Lock()
+	entries, err := os.ReadDir(v.dir)
+	v.lock.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), fileExt) {
+			continue
+		}
+		names = append(names, strings.TrimSuffix(e.Name(), fileExt))
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (v *Vault) path(name string) (string, error) {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf("invalid session name: %q", name)
+	}
+	return filepath.Join(v.dir, name+fileExt), nil
+}
+
+func readSalt(path string) ([]byte, error) {
+	return createOrRead(path, func() ([]byte, error) {
+		salt := make([]byte, 16)
+		_, err := rand.Read(salt)
+		return salt, err
+	})
+}
+
+// createOrRead creates the file with the content of gen via O_EXCL, so that the concurrent openers of the vault
+// won't overwrite each other. If the file exists, its content is returned.
+func createOrRead(path string, gen func() ([]byte, error)) ([]byte, error) {
+	for i := 0; ; i++ {
+		data, err := os.ReadFile(path)
+		if err == nil && len(data) > 0 {
+			return data, nil
+		}
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+
+		if err == nil {
+			// another opener has created the file but not written it yet
+			if i >= 100 {
+				return nil, fmt.Errorf("the file of the vault is empty: %s", path)
+			}
+			time.Sleep(10 * time.Millisecond)
+			continue
+		}
+
+		data, err = gen()
+		if err != nil {
+			return nil, err
+		}
+
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if errors.Is(err, os.ErrExist) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		_, err = f.Write(data)
+		if err != nil {
+			_ = f.Close()
+			return nil, err
+		}
+		return data, f.Close()
+	}
+}
+
+// pbkdf2 derives the key via PBKDF2 with HMAC-SHA256, RFC 8018
+func pbkdf2(password, salt []byte, iter, keyLen int) []byte {
+	prf := hmac.New(sha256.New, password)
+	size := prf.Size()
+	blocks := (keyLen + size - 1) / size
+
+	key := make([]byte, 0, blocks*size)
+	buf := make([]byte, 4)
+	for block := 1; block <= blocks; block++ {
+		prf.Reset()
+		prf.Write(salt)
+		binary.BigEndian.PutUint32(buf, uint32(block))
+		prf.Write(buf)
+		u := prf.Sum(nil)
+
+		t := make([]byte, len(u))
+		copy(t, u)
+		for n := 1; n < iter; n++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for i := range t {
+				t[i] ^= u[i]
+			}
+		}
+		key = append(key, t...)
+	}
+	return key[:keyLen]
+}
@@ -0,0 +1,160 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"math"
+	mr "math/rand"
+	"sync"
+	"time"
+)
+
+// Backoff returns the interval to sleep before the nth retry, n starts from 1
+type Backoff func(n int) time.Duration
+
+// ConstantBackoff always returns the interval
+func ConstantBackoff(interval time.Duration) Backoff {
+	return func(int) time.Duration {
+		return interval
+	}
+}
+
+// ExponentialBackoff algorithm: A(1) = init, A(n) = A(n-1) * factor, A(n) <= max.
+// Each interval is then scaled by random[1-jitter, 1+jitter), such as 0.1 means ±10%.
+// If factor is not greater than 1, 2 will be used.
+func ExponentialBackoff(init, max time.Duration, factor, jitter float64) Backoff {
+	if factor <= 1 {
+		factor = 2
+	}
+
+	return func(n int) time.Duration {
+		d := float64(init) * math.Pow(factor, float64(n-1))
+		if d > float64(max) {
+			d = float64(max)
+		}
+		return withJitter(time.Duration(d), jitter)
+	}
+}
+
+// FibonacciBackoff algorithm: A(1) = A(2) = init, A(n) = A(n-1) + A(n-2), A(n) <= max
+func FibonacciBackoff(init, max time.Duration) Backoff {
+	return func(n int) time.Duration {
+		a, b := init, init
+		for i := 2; i < n && b < max; i++ {
+			a, b = b, a+b
+		}
+		if b > max {
+			return max
+		}
+		return b
+	}
+}
+
+func withJitter(d time.Duration, jitter float64) time.Duration {
+	if jitter <= 0 {
+		return d
+	}
+	return time.Duration(float64(d) * (1 + (mr.Float64()*2-1)*jitter))
+}
+
+// SleepAttempt is the metadata of a sleep of the sleeper created by [SleeperPolicy.Sleeper]
+type SleepAttempt struct {
+	// N is the number of the sleep, it starts from 1
+	N int
+
+	// Interval is the duration of the sleep
+	Interval time.Duration
+
+	// Elapsed is the duration since the sleeper is created, the interval is not included
+	Elapsed time.Duration
+}
+
+// String interface
+func (a SleepAttempt) String() string {
+	return fmt.Sprintf("attempt %d, sleep %v, elapsed %v", a.N, a.Interval, a.Elapsed)
+}
+
+// ErrMaxSleepElapsed type
+type ErrMaxSleepElapsed struct {
+	// Max elapsed time
+	Max time.Duration
+}
+
+// Error interface
+func (e *ErrMaxSleepElapsed) Error() string {
+	return fmt.Sprintf("max sleep elapsed time %v exceeded", e.Max)
+}
+
+// Is interface
+func (e *ErrMaxSleepElapsed) Is(err error) bool { _, ok := err.(*ErrMaxSleepElapsed); return ok }
+
+// SleeperPolicy describes how a retry sleeps, use [SleeperPolicy.Sleeper] to create the sleepers.
+type SleeperPolicy struct {
+	// Backoff of the intervals, if it's nil the sleeper will wake immediately
+	Backoff Backoff
+
+	// MaxAttempts is the max number of the sleeps, when it's exceeded [ErrMaxSleepCount] will be returned.
+	// Zero means no limit.
+	MaxAttempts int
+
+	// MaxElapsed is the max total duration of the sleeps, when it's exceeded [ErrMaxSleepElapsed] will be returned.
+	// The last interval will be shortened to fit it. Zero means no limit.
+	MaxElapsed time.Duration
+
+	// OnSleep is called before each sleep
+	OnSleep func(SleepAttempt)
+}
+
+// Sleeper creates a new sleeper of the policy, the sleeper is stateful, so create a new one for each retry.
+func (p SleeperPolicy) Sleeper() Sleeper {
+	l := sync.Mutex{}
+	start := time.Now()
+	n := 0
+
+	return func(ctx context.Context) error {
+		l.Lock()
+		defer l.Unlock()
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if p.MaxAttempts > 0 && n >= p.MaxAttempts {
+			return &ErrMaxSleepCount{p.MaxAttempts}
+		}
+
+		elapsed := time.Since(start)
+		if p.MaxElapsed > 0 && elapsed >= p.MaxElapsed {
+			return &ErrMaxSleepElapsed{p.MaxElapsed}
+		}
+
+		n++
+
+		var interval time.Duration
+		if p.Backoff != nil {
+			interval = p.Backoff(n)
+		}
+		if p.MaxElapsed > 0 && elapsed+interval > p.MaxElapsed {
+			interval = p.MaxElapsed - elapsed
+		}
+
+		if p.OnSleep != nil {
+			p.OnSleep(SleepAttempt{n, interval, elapsed})
+		}
+
+		if interval <= 0 {
+			return nil
+		}
+
+		t := time.NewTimer(interval)
+		defer t.Stop()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+
+		return nil
+	}
+}
@@ -93,3 +93,69 @@ func TestRaceSleepers(t *testing.T) {
 	g.Is(err, &utils.ErrMaxSleepCount{})
 	g.Eq(err.Error(), "max sleep count 5 exceeded")
 }
+
+func TestBackoffs(t *testing.T) {
+	g := setup(t)
+
+	c := utils.ConstantBackoff(time.Second)
+	g.Eq(c(1), time.Second)
+	g.Eq(c(10), time.Second)
+
+	e := utils.ExponentialBackoff(time.Second, 5*time.Second, 0, 0)
+	g.Eq(e(1), time.Second)
+	g.Eq(e(2), 2*time.Second)
+	g.Eq(e(3), 4*time.Second)
+	g.Eq(e(4), 5*time.Second)
+
+	j := utils.ExponentialBackoff(time.Second, time.Second, 2, 0.1)(1)
+	g.Gte(j, 900*time.Millisecond)
+	g.Lt(j, 1100*time.Millisecond)
+
+	f := utils.FibonacciBackoff(time.Second, 6*time.Second)
+	g.Eq(f(1), time.Second)
+	g.Eq(f(2), time.Second)
+	g.Eq(f(3), 2*time.Second)
+	g.Eq(f(4), 3*time.Second)
+	g.Eq(f(5), 5*time.Second)
+	g.Eq(f(6), 6*time.Second)
+	g.Eq(f(100), 6*time.Second)
+}
+
+func TestSleeperPolicy(t *testing.T) {
+	g := setup(t)
+
+	attempts := []utils.SleepAttempt{}
+	s := utils.SleeperPolicy{
+		Backoff:     utils.ConstantBackoff(time.Millisecond),
+		MaxAttempts: 3,
+		OnSleep:     func(a utils.SleepAttempt) { attempts = append(attempts, a) },
+	}.Sleeper()
+
+	g.E(s(g.Context()))
+	g.E(s(g.Context()))
+	g.E(s(g.Context()))
+	g.Is(s(g.Context()), &utils.ErrMaxSleepCount{})
+	g.Len(attempts, 3)
+	g.Eq(attempts[2].N, 3)
+	g.Eq(attempts[2].Interval, time.Millisecond)
+	g.Has(attempts[2].String(), "attempt 3, sleep 1ms")
+
+	s = utils.SleeperPolicy{
+		Backoff:    utils.ConstantBackoff(time.Hour),
+		MaxElapsed: 10 * time.Millisecond,
+	}.Sleeper()
+	g.E(s(g.Context()))
+	err := s(g.Context())
+	g.Is(err, &utils.ErrMaxSleepElapsed{})
+	g.Eq(err.Error(), "max sleep elapsed time 10ms exceeded")
+
+	g.E(utils.SleeperPolicy{}.Sleeper()(g.Context()))
+
+	ctx := g.Context()
+	ctx.Cancel()
+	g.Eq(utils.SleeperPolicy{}.Sleeper()(ctx), context.Canceled)
+
+	ctx = g.Context()
+	go ctx.Cancel()
+	g.Eq(utils.SleeperPolicy{Backoff: utils.ConstantBackoff(time.Hour)}.Sleeper()(ctx), context.Canceled)
+}
@@ -0,0 +1,263 @@
+// Package visual compares images pixel by pixel for the visual regression testing.
+// The algorithm is based on https://github.com/mapbox/pixelmatch, it measures the color difference
+// in the YIQ color space and detects the anti-aliased pixels, so that the minor rendering differences
+// of the fonts and edges won't be reported as mismatches.
+package visual
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+)
+
+// Options for [Compare]
+type Options struct {
+	// Threshold of the color difference of two pixels, from 0 to 1. Smaller value makes the comparison more sensitive.
+	Threshold float64
+
+	// IncludeAA counts the anti-aliased pixels as mismatches
+	IncludeAA bool
+
+	// Ignore the pixels in these regions, such as the areas of the timestamps or ads.
+	Ignore []image.Rectangle
+
+	// DiffColor of the mismatched pixels in the diff image
+	DiffColor color.NRGBA
+
+	// AAColor of the anti-aliased pixels in the diff image
+	AAColor color.NRGBA
+}
+
+// DefaultOptions for [Compare]
+func DefaultOptions() *Options {
+	return &Options{
+		Threshold: 0.1,
+		DiffColor: color.NRGBA{255, 0, 0, 255},
+		AAColor:   color.NRGBA{255, 255, 0, 255},
+	}
+}
+
+// Result of [Compare]
+type Result struct {
+	// Diff image, the matched pixels are drawn as faded gray, the mismatched ones are drawn with [Options.DiffColor].
+	Diff *image.NRGBA
+
+	// Mismatched pixel count
+	Mismatched int
+
+	// Percent of the mismatched pixels, from 0 to 100
+	Percent float64
+}
+
+// ErrSizeMismatch error
+type ErrSizeMismatch struct {
+	A, B image.Point
+}
+
+func (e *ErrSizeMismatch) Error() string {
+	return fmt.Sprintf("image sizes are different: %dx%d and %dx%d", e.A.X, e.A.Y, e.B.X, e.B.Y)
+}
+
+// Is interface
+func (e *ErrSizeMismatch) Is(err error) bool { _, ok := err.(*ErrSizeMismatch); return ok }
+
+// Compare image a and b, they must have the same size. If opts is nil, [DefaultOptions] will be used.
+func Compare(a, b image.Image, opts *Options) (*Result, error) {
+	if opts == nil {
+		opts = DefaultOptions()
+	}
+
+	if a.Bounds().Size() != b.Bounds().Size() {
+		return nil, &ErrSizeMismatch{a.Bounds().Size(), b.Bounds().Size()}
+	}
+
+	img1, img2 := toNRGBA(a), toNRGBA(b)
+	w, h := img1.Rect.Dx(), img1.Rect.Dy()
+	diff := image.NewNRGBA(image.Rect(0, 0, w, h))
+
+	// the max acceptable square distance between two colors, 35215 is the max value of the YIQ difference
+	maxDelta := 35215 * opts.Threshold * opts.Threshold
+
+	mismatched := 0
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if ignored(opts.Ignore, x, y) {
+				diff.SetNRGBA(x, y, gray(img1, x, y, 0.1))
+				continue
+			}
+
+			delta := colorDelta(img1, img2, x, y, x, y, false)
+
+			if math.Abs(delta) <= maxDelta {
+				diff.SetNRGBA(x, y, gray(img1, x, y, 0.1))
+				continue
+			}
+
+			if !opts.IncludeAA && (antialiased(img1, x, y, img2) || antialiased(img2, x, y, img1)) {
+				diff.SetNRGBA(x, y, opts.AAColor)
+				continue
+			}
+
+			diff.SetNRGBA(x, y, opts.DiffColor)
+			mismatched++
+		}
+	}
+
+	percent := 0.0
+	if w*h > 0 {
+		percent = float64(mismatched) / float64(w*h) * 100
+	}
+
+	return &Result{Diff: diff, Mismatched: mismatched, Percent: percent}, nil
+}
+
+func toNRGBA(img image.Image) *image.NRGBA {
+	b := img.Bounds()
+	out := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(out, out.Rect, img, b.Min, draw.Src)
+	return out
+}
+
+func ignored(list []image.Rectangle, x, y int) bool {
+	p := image.Pt(x, y)
+	for _, r := range list {
+		if p.In(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// antialiased checks if the pixel is likely a part of anti-aliasing,
+// it has both darker and brighter neighbors, and the extreme ones are in the flat areas of both images.
+func antialiased(img *image.NRGBA, x1, y1 int, img2 *image.NRGBA) bool {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	x0, y0 := max(x1-1, 0), max(y1-1, 0)
+	x2, y2 := min(x1+1, w-1), min(y1+1, h-1)
+
+	zeroes := 0
+	if x1 == x0 || x1 == x2 || y1 == y0 || y1 == y2 {
+		zeroes = 1
+	}
+
+	minDelta, maxDelta := 0.0, 0.0
+	var minX, minY, maxX, maxY int
+
+	for x := x0; x <= x2; x++ {
+		for y := y0; y <= y2; y++ {
+			if x == x1 && y == y1 {
+				continue
+			}
+
+			delta := colorDelta(img, img, x1, y1, x, y, true)
+
+			switch {
+			case delta == 0:
+				zeroes++
+				if zeroes > 2 {
+					return false
+				}
+			case delta < minDelta:
+				minDelta, minX, minY = delta, x, y
+			case delta > maxDelta:
+				maxDelta, maxX, maxY = delta, x, y
+			}
+		}
+	}
+
+	if minDelta == 0 || maxDelta == 0 {
+		return false
+	}
+
+	return (manySiblings(img, minX, minY) && manySiblings(img2, minX, minY)) ||
+		(manySiblings(img, maxX, maxY) && manySiblings(img2, maxX, maxY))
+}
+
+// manySiblings checks if the pixel has more than 2 identical neighbors
+func manySiblings(img *image.NRGBA, x1, y1 int) bool {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	x0, y0 := max(x1-1, 0), max(y1-1, 0)
+	x2, y2 := min(x1+1, w-1), min(y1+1, h-1)
+
+	zeroes := 0
+	if x1 == x0 || x1 == x2 || y1 == y0 || y1 == y2 {
+		zeroes = 1
+	}
+
+	c := img.NRGBAAt(x1, y1)
+	for x := x0; x <= x2; x++ {
+		for y := y0; y <= y2; y++ {
+			if x == x1 && y == y1 {
+				continue
+			}
+			if img.NRGBAAt(x, y) == c {
+				zeroes++
+			}
+			if zeroes > 2 {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// colorDelta returns the square distance of two colors in the YIQ color space,
+// the sign tells which one is brighter. If yOnly is true only the brightness difference is returned.
+func colorDelta(img1, img2 *image.NRGBA, x1, y1, x2, y2 int, yOnly bool) float64 {
+	c1, c2 := img1.NRGBAAt(x1, y1), img2.NRGBAAt(x2, y2)
+	if c1 == c2 {
+		return 0
+	}
+
+	r1, g1, b1 := blend(c1)
+	r2, g2, b2 := blend(c2)
+
+	y := rgb2y(r1, g1, b1) - rgb2y(r2, g2, b2)
+	if yOnly {
+		return y
+	}
+
+	i := rgb2i(r1, g1, b1) - rgb2i(r2, g2, b2)
+	q := rgb2q(r1, g1, b1) - rgb2q(r2, g2, b2)
+
+	delta := 0.5053*y*y + 0.299*i*i + 0.1957*q*q
+	if rgb2y(r1, g1, b1) > rgb2y(r2, g2, b2) {
+		return -delta
+	}
+	return delta
+}
+
+// blend the color with white background
+func blend(c color.NRGBA) (r, g, b float64) {
+	a := float64(c.A) / 255
+	return 255 + (float64(c.R)-255)*a, 255 + (float64(c.G)-255)*a, 255 + (float64(c.B)-255)*a
+}
+
+func rgb2y(r, g, b float64) float64 { return r*0.29889531 + g*0.58662247 + b*0.11448223 }
+func rgb2i(r, g, b float64) float64 { return r*0.59597799 - g*0.27417610 - b*0.32180189 }
+func rgb2q(r, g, b float64) float64 { return r*0.21147017 - g*0.52261711 + b*0.31114694 }
+
+// gray returns the faded gray color of the pixel for the diff image
+func gray(img *image.NRGBA, x, y int, alpha float64) color.NRGBA {
+	r, g, b := blend(img.NRGBAAt(x, y))
+	v := 255 + (rgb2y(r, g, b)-255)*alpha
+	return color.NRGBA{uint8(v), uint8(v), uint8(v), 255}
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
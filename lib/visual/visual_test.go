@@ -0,0 +1,90 @@
+package visual_test
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"github.com/Fromsko/rodPro/lib/visual"
+	"github.com/ysmood/got"
+)
+
+var setup = got.Setup(nil)
+
+func canvas(w, h int, c color.Color) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(img, img.Rect, image.NewUniform(c), image.Point{}, draw.Src)
+	return img
+}
+
+func TestCompare(t *testing.T) {
+	g := setup(t)
+
+	white := color.NRGBA{255, 255, 255, 255}
+	black := color.NRGBA{0, 0, 0, 255}
+
+	a := canvas(10, 10, white)
+	b := canvas(10, 10, white)
+
+	res, err := visual.Compare(a, b, nil)
+	g.E(err)
+	g.Eq(res.Mismatched, 0)
+	g.Eq(res.Percent, 0.0)
+	g.Eq(res.Diff.Rect.Dx(), 10)
+
+	draw.Draw(b, image.Rect(2, 2, 7, 7), image.NewUniform(black), image.Point{}, draw.Src)
+
+	res, err = visual.Compare(a, b, nil)
+	g.E(err)
+	g.Eq(res.Mismatched, 25)
+	g.Eq(res.Percent, 25.0)
+	g.Eq(res.Diff.NRGBAAt(3, 3), color.NRGBA{255, 0, 0, 255})
+
+	opts := visual.DefaultOptions()
+	opts.Ignore = []image.Rectangle{image.Rect(0, 0, 5, 10)}
+	res, err = visual.Compare(a, b, opts)
+	g.E(err)
+	g.Eq(res.Mismatched, 10)
+
+	// a slightly different color is within the threshold
+	c := canvas(10, 10, color.NRGBA{250, 250, 250, 255})
+	res, err = visual.Compare(a, c, nil)
+	g.E(err)
+	g.Eq(res.Mismatched, 0)
+
+	opts = visual.DefaultOptions()
+	opts.Threshold = 0
+	res, err = visual.Compare(a, c, opts)
+	g.E(err)
+	g.Eq(res.Mismatched, 100)
+
+	_, err = visual.Compare(a, canvas(5, 5, white), nil)
+	g.Is(err, &visual.ErrSizeMismatch{})
+	g.Eq(err.Error(), "image sizes are different: 10x10 and 5x5")
+}
+
+func TestAntiAliasing(t *testing.T) {
+	g := setup(t)
+
+	white := color.NRGBA{255, 255, 255, 255}
+	black := color.NRGBA{0, 0, 0, 255}
+
+	// a black square on white, the edge pixel between them is rendered as gray in one image
+	a := canvas(10, 10, white)
+	draw.Draw(a, image.Rect(0, 0, 5, 10), image.NewUniform(black), image.Point{}, draw.Src)
+	b := canvas(10, 10, white)
+	draw.Draw(b, image.Rect(0, 0, 5, 10), image.NewUniform(black), image.Point{}, draw.Src)
+	b.SetNRGBA(5, 5, color.NRGBA{128, 128, 128, 255})
+
+	res, err := visual.Compare(a, b, nil)
+	g.E(err)
+	g.Eq(res.Mismatched, 0)
+	g.Eq(res.Diff.NRGBAAt(5, 5), color.NRGBA{255, 255, 0, 255})
+
+	opts := visual.DefaultOptions()
+	opts.IncludeAA = true
+	res, err = visual.Compare(a, b, opts)
+	g.E(err)
+	g.Eq(res.Mismatched, 1)
+}
@@ -0,0 +1,203 @@
+// This file serves for the target lifecycle hooks of Browser and Page.
+
+package rod
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/Fromsko/rodPro/lib/proto"
+)
+
+// OnPageCreated calls fn with the attached page when a new page is created in the browser,
+// such as the popups opened via window.open or links with target=_blank.
+// For an incognito browser only the pages of its own browser context will be reported.
+// Call stop to remove the hook.
+func (b *Browser) OnPageCreated(fn func(*Page)) (stop func()) {
+	// the pages should outlive the hook
+	live := b
+	b, cancel := b.WithCancel()
+
+	go b.eachEvent("", func(e *proto.TargetTargetCreated) {
+		info := e.TargetInfo
+		if info.Type != proto.TargetTargetInfoTypePage {
+			return
+		}
+		if b.BrowserContextID != "" && info.BrowserContextID != b.BrowserContextID {
+			return
+		}
+
+		go func() {
+			p, err := live.PageFromTarget(info.TargetID)
+			if err != nil {
+				return
+			}
+			fn(p)
+		}()
+	})()
+
+	return cancel
+}
+
+// OnPageDestroyed calls fn when a page is closed or destroyed.
+// The p is the page instance if it has been used via rod, or nil if not.
+// Call stop to remove the hook.
+func (b *Browser) OnPageDestroyed(fn func(id proto.TargetTargetID, p *Page)) (stop func()) {
+	b, cancel := b.WithCancel()
+
+	go b.eachEvent("", func(e *proto.TargetTargetDestroyed) {
+		p := b.loadCachedPage(e.TargetID)
+		if p == nil && b.BrowserContextID != "" {
+			return
+		}
+		go fn(e.TargetID, p)
+	})()
+
+	return cancel
+}
+
+// OnCrash calls fn with the page when the render process of a page crashes.
+// The crashed page can be recovered via [Page.Reload] or [Page.Navigate].
+// Call stop to remove the hook.
+func (b *Browser) OnCrash(fn func(p *Page, e *proto.TargetTargetCrashed)) (stop func()) {
+	live := b
+	b, cancel := b.WithCancel()
+
+	go b.eachEvent("", func(e *proto.TargetTargetCrashed) {
+		go func() {
+			// the event doesn't have the browser context id, so get it from the target info
+			if b.BrowserContextID != "" {
+				info, err := live.pageInfo(e.TargetID)
+				if err != nil || info.BrowserContextID != b.BrowserContextID {
+					return
+				}
+			}
+
+			p, err := live.PageFromTarget(e.TargetID)
+			if err != nil {
+				return
+			}
+			fn(p, e)
+		}()
+	})()
+
+	return cancel
+}
+
+// OnCrash calls fn when the render process of the page crashes, all the calls to the page will fail or hang
+// until the page is recovered via [Page.Reload], [Page.Navigate], or [Page.AutoRecover].
+// Call stop to remove the hook.
+func (p *Page) OnCrash(fn func(e *proto.TargetTargetCrashed)) (stop func()) {
+	p, cancel := p.WithCancel()
+
+	go p.browser.Context(p.ctx).eachEvent("", func(e *proto.TargetTargetCrashed) {
+		if e.TargetID == p.TargetID {
+			go fn(e)
+		}
+	})()
+
+	return cancel
+}
+
+// AutoRecover makes the page recover itself when its render process crashes, rather than leaving the
+// subsequent calls hanging. When the page crashes, the scripts of [Page.AddNewDocumentScript], the bindings of
+// [Page.Expose], and the viewport emulation of [Page.SetViewport] will be replayed, then the page will navigate to
+// the last url it committed before the crash. A failed recovery is retried up to maxAttempts times with the backoff
+// of the page's sleeper, the crashes during a recovery are handled by the ongoing one.
+// The fn is called with the result of each recovery, it's optional. Call stop to disable the mode.
+func (p *Page) AutoRecover(maxAttempts int, fn func(err error)) (stop func()) {
+	p, cancel := p.WithCancel()
+
+	last := ""
+	if info, err := p.Info(); err == nil {
+		last = info.URL
+	}
+	lock := sync.Mutex{}
+
+	go p.EachEvent(func(e *proto.PageFrameNavigated) {
+		if e.Frame.ParentID == "" {
+			lock.Lock()
+			last = e.Frame.URL
+			lock.Unlock()
+		}
+	})()
+
+	var recovering int32
+	stopCrash := p.OnCrash(func(_ *proto.TargetTargetCrashed) {
+		if !atomic.CompareAndSwapInt32(&recovering, 0, 1) {
+			return
+		}
+		defer atomic.StoreInt32(&recovering, 0)
+
+		lock.Lock()
+		u := last
+		lock.Unlock()
+
+		err := p.recoverWithRetry(maxAttempts, u)
+		if fn != nil {
+			fn(err)
+		}
+	})
+
+	return func() {
+		stopCrash()
+		cancel()
+	}
+}
+
+func (p *Page) recoverWithRetry(maxAttempts int, u string) error {
+	sleeper := p.sleeper()
+	for i := 1; ; i++ {
+		err := p.recoverFromCrash(u)
+		if err == nil || i >= maxAttempts || p.ctx.Err() != nil {
+			return err
+		}
+		if sleeper(p.ctx) != nil {
+			return err
+		}
+	}
+}
+
+func (p *Page) recoverFromCrash(u string) error {
+	for _, s := range p.NewDocumentScripts() {
+		p.scripts.lock.Lock()
+		id := s.ID
+		p.scripts.lock.Unlock()
+
+		_ = proto.PageRemoveScriptToEvaluateOnNewDocument{Identifier: id}.Call(p)
+
+		res, err := proto.PageAddScriptToEvaluateOnNewDocument{Source: s.Source, WorldName: s.world}.Call(p)
+		if err != nil {
+			return err
+		}
+
+		p.scripts.lock.Lock()
+		removed := !p.scripts.has(s)
+		if !removed {
+			s.ID = res.Identifier
+		}
+		p.scripts.lock.Unlock()
+
+		// the script is removed during the recovery
+		if removed {
+			_ = proto.PageRemoveScriptToEvaluateOnNewDocument{Identifier: res.Identifier}.Call(p)
+		}
+	}
+
+	for _, name := range p.scripts.getBindings() {
+		err := proto.RuntimeAddBinding{Name: name}.Call(p)
+		if err != nil {
+			return err
+		}
+	}
+
+	viewport := proto.EmulationSetDeviceMetricsOverride{}
+	if p.LoadState(&viewport) {
+		err := viewport.Call(p)
+		if err != nil {
+			return err
+		}
+	}
+
+	return p.Navigate(u)
+}
@@ -0,0 +1,380 @@
+// This file serves for the login flows.
+
+package rod
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1" //nolint: gosec
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/Fromsko/rodPro/lib/input"
+	"github.com/Fromsko/rodPro/lib/proto"
+	"github.com/Fromsko/rodPro/lib/utils"
+)
+
+// LoginFlow is a builder to automate the login of a site, create it via [Page.LoginFlow].
+// A typical flow:
+//
+//	err := page.LoginFlow().
+//		URL("https://example.com/login").
+//		Username("#user", "alice").
+//		Password("#pass", "secret").
+//		Submit("button[type=submit]").
+//		TOTP("#otp", "JBSWY3DPEHPK3PXP").
+//		SuccessElement(".dashboard").
+//		FailureElement(".error").
+//		Export(func(p *rod.Page) error {
+//			s, err := sessions.Capture("alice", p)
+//			if err != nil {
+//				return err
+//			}
+//			return vault.Save(s)
+//		}).
+//		Run()
+type LoginFlow struct {
+	page    *Page
+	url     string
+	timeout time.Duration
+
+	username, password loginField
+	submit             string
+
+	code       string
+	codeFn     func() (string, error)
+	codeSubmit string
+
+	success []func(*Page) (bool, error)
+	failure []func(*Page) (string, error)
+	export  func(*Page) error
+}
+
+type loginField struct {
+	selector, value string
+}
+
+// LoginFlow creates a login flow on the page, the default timeout is 1 minute.
+func (p *Page) LoginFlow() *LoginFlow {
+	return &LoginFlow{page: p, timeout: time.Minute}
+}
+
+// URL to navigate to before the login, if it's not set, the current page is used.
+func (f *LoginFlow) URL(u string) *LoginFlow {
+	f.url = u
+	return f
+}
+
+// Timeout of the whole flow, 0 means no timeout.
+func (f *LoginFlow) Timeout(d time.Duration) *LoginFlow {
+	f.timeout = d
+	return f
+}
+
+// Username sets the selector of the username field and the value to input.
+func (f *LoginFlow) Username(selector, value string) *LoginFlow {
+	f.username = loginField{selector, value}
+	return f
+}
+
+// Password sets the selector of the password field and the value to input.
+func (f *LoginFlow) Password(selector, value string) *LoginFlow {
+	f.password = loginField{selector, value}
+	return f
+}
+
+// Submit sets the selector of the button to submit the credentials,
+// if it's not set, the Enter key will be pressed on the last filled field.
+func (f *LoginFlow) Submit(selector string) *LoginFlow {
+	f.submit = selector
+	return f
+}
+
+// TOTP sets the selector of the 2FA code field, the code is generated from the base32 secret via [TOTPCode]
+// when the field appears after the credentials are submitted.
+func (f *LoginFlow) TOTP(selector, secret string) *LoginFlow {
+	return f.Code(selector, func() (string, error) {
+		return TOTPCode(secret, time.Now())
+	})
+}
+
+// Code sets the selector of the 2FA code field, the fn is called to get the code when the field appears
+// after the credentials are submitted, such as to wait for the SMS code from a user or a gateway.
+func (f *LoginFlow) Code(selector string, fn func() (string, error)) *LoginFlow {
+	f.code = selector
+	f.codeFn = fn
+	return f
+}
+
+// CodeSubmit sets the selector of the button to submit the 2FA code,
+// if it's not set, the Enter key will be pressed on the code field.
+func (f *LoginFlow) CodeSubmit(selector string) *LoginFlow {
+	f.codeSubmit = selector
+	return f
+}
+
+// Success adds a predicate of the success, the login succeeds when any of them returns true.
+func (f *LoginFlow) Success(fn func(*Page) (bool, error)) *LoginFlow {
+	f.success = append(f.success, fn)
+	return f
+}
+
+// SuccessElement is a shortcut of [LoginFlow.Success] that succeeds when the selector matches an element.
+func (f *LoginFlow) SuccessElement(selector string) *LoginFlow {
+	return f.Success(func(p *Page) (bool, error) {
+		has, _, err := p.Has(selector)
+		return has, err
+	})
+}
+
+// SuccessURL is a shortcut of [LoginFlow.Success] that succeeds when the url of the page contains the substr.
+func (f *LoginFlow) SuccessURL(substr string) *LoginFlow {
+	return f.Success(func(p *Page) (bool, error) {
+		info, err := p.Info()
+		if err != nil {
+			return false, err
+		}
+		return strings.Contains(info.URL, substr), nil
+	})
+}
+
+// Failure adds a predicate of the failure, the login fails with [ErrLoginFailed] when any of them
+// returns a non-empty reason.
+func (f *LoginFlow) Failure(fn func(*Page) (reason string, err error)) *LoginFlow {
+	f.failure = append(f.failure, fn)
+	return f
+}
+
+// FailureElement is a shortcut of [LoginFlow.Failure] that fails when the selector matches an element,
+// the text of the element is the reason.
+func (f *LoginFlow) FailureElement(selector string) *LoginFlow {
+	return f.Failure(func(p *Page) (string, error) {
+		has, el, err := p.Has(selector)
+		if err != nil || !has {
+			return "", err
+		}
+		text, err := el.Text()
+		if err != nil {
+			return "", err
+		}
+		if text = strings.TrimSpace(text); text == "" {
+			text = selector
+		}
+		return text, nil
+	})
+}
+
+// Export sets the fn to call after the login succeeds, such as to save the session via the sessions package.
+func (f *LoginFlow) Export(fn func(*Page) error) *LoginFlow {
+	f.export = fn
+	return f
+}
+
+// Run the flow. It fills the credentials, submits them, waits for a navigation or DOM change caused by the submit,
+// then waits until a success or failure predicate matches, the 2FA code is filled once when its field appears
+// in the meantime. At least one success or failure predicate is required.
+// If no success predicate is set, the login succeeds when the page changes after the submit without failure.
+func (f *LoginFlow) Run() error {
+	if len(f.success) == 0 && len(f.failure) == 0 {
+		return errors.New("login flow requires at least one success or failure predicate")
+	}
+
+	ctx, cancel := context.WithCancel(f.page.ctx)
+	defer cancel()
+	if f.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, f.timeout)
+		defer cancel()
+	}
+	p := f.page.Context(ctx)
+
+	defer p.tryTrace(TraceTypeInput, "login flow")()
+
+	if f.url != "" {
+		err := p.Navigate(f.url)
+		if err != nil {
+			return err
+		}
+		err = p.WaitLoad()
+		if err != nil {
+			return err
+		}
+	}
+
+	last, err := f.fill(p, f.username)
+	if err != nil {
+		return err
+	}
+	if el, err := f.fill(p, f.password); err != nil {
+		return err
+	} else if el != nil {
+		last = el
+	}
+
+	before, err := p.HTML()
+	if err != nil {
+		return err
+	}
+	navigated := f.watchNavigation(p)
+
+	err = f.submitWith(p, f.submit, last)
+	if err != nil {
+		return err
+	}
+
+	changed := false
+	codeDone := f.code == ""
+	err = utils.Retry(ctx, p.sleeper(), func() (bool, error) {
+		// the predicates may match the stale page before the submit takes effect
+		if !changed {
+			html, err := p.HTML()
+			if err != nil {
+				return true, err
+			}
+			changed = navigated() || html != before
+			if !changed {
+				return false, nil
+			}
+		}
+
+		reason, err := f.failed(p)
+		if err != nil || reason != "" {
+			if reason != "" {
+				err = &ErrLoginFailed{reason}
+			}
+			return true, err
+		}
+
+		if !codeDone {
+			has, el, err := p.Has(f.code)
+			if err != nil {
+				return true, err
+			}
+			if has {
+				codeDone = true
+				err = f.fillCode(p, el)
+				return err != nil, err
+			}
+		}
+
+		ok, err := f.succeeded(p)
+		return ok || err != nil, err
+	})
+	if err != nil {
+		return timeoutErr(ctx, err, "LoginFlow.Run")
+	}
+
+	if f.export != nil {
+		return f.export(p)
+	}
+	return nil
+}
+
+// watchNavigation returns a func to check if the main frame has navigated since the call
+func (f *LoginFlow) watchNavigation(p *Page) func() bool {
+	var navigated int32
+	wait := p.EachEvent(func(e *proto.PageFrameNavigated) bool {
+		return e.Frame.ParentID == ""
+	}, func(e *proto.PageNavigatedWithinDocument) bool {
+		return e.FrameID == p.FrameID
+	})
+	go func() {
+		wait()
+		atomic.StoreInt32(&navigated, 1)
+	}()
+
+	return func() bool {
+		return atomic.LoadInt32(&navigated) == 1 && p.ctx.Err() == nil
+	}
+}
+
+func (f *LoginFlow) fill(p *Page, field loginField) (*Element, error) {
+	if field.selector == "" {
+		return nil, nil
+	}
+
+	el, err := p.Element(field.selector)
+	if err != nil {
+		return nil, err
+	}
+	return el, el.fill(field.value)
+}
+
+func (f *LoginFlow) fillCode(p *Page, el *Element) error {
+	code, err := f.codeFn()
+	if err != nil {
+		return err
+	}
+
+	err = el.fill(code)
+	if err != nil {
+		return err
+	}
+
+	return f.submitWith(p, f.codeSubmit, el)
+}
+
+func (f *LoginFlow) submitWith(p *Page, selector string, last *Element) error {
+	if selector != "" {
+		el, err := p.Element(selector)
+		if err != nil {
+			return err
+		}
+		return el.Click(proto.InputMouseButtonLeft, 1)
+	}
+
+	if last == nil {
+		return nil
+	}
+	return last.Type(input.Enter)
+}
+
+func (f *LoginFlow) succeeded(p *Page) (bool, error) {
+	if len(f.success) == 0 {
+		// the page has changed without failure
+		return true, nil
+	}
+	for _, fn := range f.success {
+		ok, err := fn(p)
+		if err != nil || ok {
+			return ok, err
+		}
+	}
+	return false, nil
+}
+
+func (f *LoginFlow) failed(p *Page) (string, error) {
+	for _, fn := range f.failure {
+		reason, err := fn(p)
+		if err != nil || reason != "" {
+			return reason, err
+		}
+	}
+	return "", nil
+}
+
+// TOTPCode generates the 6-digit time-based one-time password of the base32 secret at the time t,
+// as most authenticator apps do, RFC 6238 with SHA1 and 30 seconds step.
+func TOTPCode(secret string, t time.Time) (string, error) {
+	secret = strings.ToUpper(strings.ReplaceAll(secret, " ", ""))
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(strings.TrimRight(secret, "="))
+	if err != nil {
+		return "", fmt.Errorf("invalid totp secret: %w", err)
+	}
+
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, uint64(t.Unix()/30))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0xf
+	code := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%06d", code%1000000), nil
+}
@@ -0,0 +1,88 @@
+package rod_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Fromsko/rodPro"
+	"github.com/Fromsko/rodPro/lib/proto"
+)
+
+func TestLoginFlow(t *testing.T) {
+	g := setup(t)
+
+	p := g.newPage()
+
+	exported := false
+	p.LoginFlow().
+		URL(g.srcFile("fixtures/login.html")).
+		Username("#user", "alice").
+		Password("#pass", "secret").
+		Submit("button").
+		SuccessElement(".dashboard").
+		FailureElement(".error").
+		Export(func(p *rod.Page) error {
+			exported = true
+			return nil
+		}).
+		MustRun()
+	g.True(exported)
+	g.Eq(p.MustElement(".dashboard").MustText(), "Hi alice")
+
+	err := p.LoginFlow().
+		URL(g.srcFile("fixtures/login.html")).
+		Username("#user", "alice").
+		Password("#pass", "wrong").
+		SuccessElement(".dashboard").
+		FailureElement(".error").
+		Run()
+	g.Is(err, &rod.ErrLoginFailed{})
+	g.Eq(err.Error(), "login failed: Wrong password")
+
+	p.LoginFlow().
+		URL(g.srcFile("fixtures/login.html")).
+		Username("#user", "totp").
+		Password("#pass", "secret").
+		TOTP("#otp", "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ").
+		SuccessURL("#done").
+		MustRun()
+	g.Has(p.MustElement(".dashboard").MustText(), "Code ")
+
+	codeErr := errors.New("no sms")
+	err = p.LoginFlow().
+		URL(g.srcFile("fixtures/login.html")).
+		Username("#user", "totp").
+		Password("#pass", "secret").
+		Code("#otp", func() (string, error) { return "", codeErr }).
+		SuccessElement(".dashboard").
+		Run()
+	g.Is(err, codeErr)
+
+	err = p.LoginFlow().
+		URL(g.srcFile("fixtures/login.html")).
+		Username("#user", "totp").
+		Password("#pass", "secret").
+		SuccessElement(".dashboard").
+		Timeout(300 * time.Millisecond).
+		Run()
+	g.Is(err, &rod.ErrTimeout{})
+
+	g.Err(p.LoginFlow().Username("#user", "alice").Run())
+
+	g.mc.stubErr(1, proto.RuntimeCallFunctionOn{})
+	g.Err(p.LoginFlow().Username("#user", "alice").SuccessElement(".dashboard").Run())
+}
+
+func TestTOTPCode(t *testing.T) {
+	g := setup(t)
+
+	// test vectors from RFC 6238
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	g.Eq(rod.MustTOTPCode(secret, time.Unix(59, 0)), "287082")
+	g.Eq(rod.MustTOTPCode(secret, time.Unix(1111111109, 0)), "081804")
+	g.Eq(rod.MustTOTPCode("gezd gnbv gy3t qojq gezd gnbv gy3t qojq", time.Unix(1234567890, 0)), "005924")
+
+	_, err := rod.TOTPCode("!!", time.Now())
+	g.Err(err)
+}
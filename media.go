@@ -0,0 +1,302 @@
+// This file serves for controlling the media elements and detecting the media errors.
+
+package rod
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/Fromsko/rodPro/lib/proto"
+)
+
+// Media controls a <video> or <audio> element, create it via [Element.Media]
+type Media struct {
+	el *Element
+}
+
+// Media returns the controller of the <video> or <audio> element
+func (el *Element) Media() *Media {
+	return &Media{el: el}
+}
+
+// MediaState of [Media.State]
+type MediaState struct {
+	Src string
+
+	Paused, Ended, Muted bool
+
+	// Playing is true if the media is advancing, not paused, ended, or stalled for data
+	Playing bool
+
+	// Audible is true if the media is playing with the sound on and the audio has been decoded
+	Audible bool
+
+	CurrentTime, Duration time.Duration
+
+	Volume, PlaybackRate float64
+
+	// ReadyState and NetworkState are the same as the properties of the HTMLMediaElement
+	ReadyState, NetworkState int
+
+	VideoWidth, VideoHeight int
+
+	// Error of the element, nil if there's no error.
+	// Doc: https://developer.mozilla.org/en-US/docs/Web/API/MediaError
+	Error *MediaElementError
+}
+
+// MediaElementError is the error property of the HTMLMediaElement
+type MediaElementError struct {
+	// Code is like 3 for MEDIA_ERR_DECODE or 4 for MEDIA_ERR_SRC_NOT_SUPPORTED
+	Code    int
+	Message string
+}
+
+// Play the media, it returns the error if the browser rejects it, such as the autoplay policy or
+// the source is not supported. The call is treated as a user gesture.
+func (m *Media) Play() error {
+	defer m.el.tryTrace(TraceTypeInput, "media play")()
+	_, err := m.el.Evaluate(Eval(`() => this.play()`).ByPromise().ByUser())
+	return err
+}
+
+// Pause the media
+func (m *Media) Pause() error {
+	defer m.el.tryTrace(TraceTypeInput, "media pause")()
+	_, err := m.el.Evaluate(Eval(`() => this.pause()`).ByUser())
+	return err
+}
+
+// Seek to the time and wait for the seeked event
+func (m *Media) Seek(t time.Duration) error {
+	defer m.el.tryTrace(TraceTypeInput, "media seek")()
+	_, err := m.el.Evaluate(Eval(mediaPromise(`(done, t) => {
+		this.addEventListener('seeked', done)
+		this.currentTime = t
+		return () => this.removeEventListener('seeked', done)
+	}`), t.Seconds()).ByPromise().ByUser())
+	return timeoutErr(m.el.ctx, err, "Seek() on %s", m.el)
+}
+
+// mediaPromise returns a js function that waits for the media, the body is like "(done, ...args) => cleanup",
+// it calls done when the wait is over and returns the cleanup of its listeners. The promise is rejected
+// as soon as the media fails to load or decode, instead of waiting until the context is done.
+func mediaPromise(body string) string {
+	return fmt.Sprintf(`function (...args) {
+		return new Promise((resolve, reject) => {
+			const fail = () => finish(new Error('media error' + (this.error ? ' ' + this.error.code + ': ' + this.error.message : '')))
+			let cleanup
+			const finish = (err) => {
+				this.removeEventListener('error', fail)
+				if (cleanup) cleanup()
+				err ? reject(err) : resolve()
+			}
+			if (this.error) return fail()
+			this.addEventListener('error', fail)
+			cleanup = (%s).apply(this, [() => finish(), ...args])
+		})
+	}`, body)
+}
+
+// SetMuted mutes or unmutes the media
+func (m *Media) SetMuted(muted bool) error {
+	_, err := m.el.Evaluate(Eval(`m => { this.muted = m }`, muted).ByUser())
+	return err
+}
+
+// State of the media
+func (m *Media) State() (*MediaState, error) {
+	res, err := m.el.Eval(`() => {
+		const e = this.error
+		return {
+			src: this.currentSrc,
+			paused: this.paused,
+			ended: this.ended,
+			muted: this.muted,
+			currentTime: this.currentTime,
+			duration: isFinite(this.duration) ? this.duration : 0,
+			volume: this.volume,
+			playbackRate: this.playbackRate,
+			readyState: this.readyState,
+			networkState: this.networkState,
+			videoWidth: this.videoWidth || 0,
+			videoHeight: this.videoHeight || 0,
+			audioDecoded: this.webkitAudioDecodedByteCount || 0,
+			error: e && { code: e.code, message: e.message },
+		}
+	}`)
+	if err != nil {
+		return nil, err
+	}
+	v := res.Value
+
+	s := &MediaState{
+		Src:          v.Get("src").Str(),
+		Paused:       v.Get("paused").Bool(),
+		Ended:        v.Get("ended").Bool(),
+		Muted:        v.Get("muted").Bool(),
+		CurrentTime:  time.Duration(v.Get("currentTime").Num() * float64(time.Second)),
+		Duration:     time.Duration(v.Get("duration").Num() * float64(time.Second)),
+		Volume:       v.Get("volume").Num(),
+		PlaybackRate: v.Get("playbackRate").Num(),
+		ReadyState:   v.Get("readyState").Int(),
+		NetworkState: v.Get("networkState").Int(),
+		VideoWidth:   v.Get("videoWidth").Int(),
+		VideoHeight:  v.Get("videoHeight").Int(),
+	}
+
+	// HAVE_FUTURE_DATA means the playback can advance
+	s.Playing = !s.Paused && !s.Ended && s.ReadyState >= 3
+	s.Audible = s.Playing && !s.Muted && s.Volume > 0 && v.Get("audioDecoded").Int() > 0
+
+	if e, has := v.Gets("error"); has && !e.Nil() {
+		s.Error = &MediaElementError{Code: e.Get("code").Int(), Message: e.Get("message").Str()}
+	}
+
+	return s, nil
+}
+
+// WaitPlaying waits until the current time of the media advances
+func (m *Media) WaitPlaying() error {
+	defer m.el.tryTrace(TraceTypeWait, "media playing")()
+
+	_, err := m.el.Evaluate(Eval(mediaPromise(`(done) => {
+		const start = this.currentTime
+		const check = () => {
+			if (!this.paused && this.currentTime > start) done()
+		}
+		this.addEventListener('timeupdate', check)
+		return () => this.removeEventListener('timeupdate', check)
+	}`)).ByPromise())
+	return timeoutErr(m.el.ctx, err, "WaitPlaying() on %s", m.el)
+}
+
+// WaitEnded waits until the media ends
+func (m *Media) WaitEnded() error {
+	defer m.el.tryTrace(TraceTypeWait, "media ended")()
+
+	_, err := m.el.Evaluate(Eval(mediaPromise(`(done) => {
+		if (this.ended) return done()
+		this.addEventListener('ended', done)
+		return () => this.removeEventListener('ended', done)
+	}`)).ByPromise())
+	return timeoutErr(m.el.ctx, err, "WaitEnded() on %s", m.el)
+}
+
+// Frame captures the current frame of the video as png. It draws the video to a canvas in its natural size,
+// if the video is protected by DRM or from another origin without CORS, it falls back to the screenshot
+// of the element.
+func (m *Media) Frame() ([]byte, error) {
+	res, err := m.el.Eval(`() => {
+		const c = document.createElement('canvas')
+		c.width = this.videoWidth
+		c.height = this.videoHeight
+		if (!c.width || !c.height) return ''
+		try {
+			c.getContext('2d').drawImage(this, 0, 0)
+			return c.toDataURL('image/png')
+		} catch {
+			return ''
+		}
+	}`)
+	if err != nil {
+		return nil, err
+	}
+
+	if uri := res.Value.Str(); uri != "" {
+		_, bin := parseDataURI(uri)
+		return bin, nil
+	}
+
+	return m.el.Screenshot(proto.PageCaptureScreenshotFormatPng, 0)
+}
+
+// MediaIssue is an error or an error message of a media player of the page, check [Page.WatchMedia]
+type MediaIssue struct {
+	PlayerID proto.MediaPlayerID
+	Type     string
+	Code     int
+	Message  string
+
+	// DRM is true if the issue is about the Encrypted Media Extensions, such as the CDM failed
+	// to load or the license request is rejected.
+	DRM bool
+
+	Time time.Time
+}
+
+// MediaWatcher records the issues of the media players, check [Page.WatchMedia]
+type MediaWatcher struct {
+	cancel  func()
+	restore func()
+
+	lock   sync.Mutex
+	issues []*MediaIssue
+}
+
+var regDRM = regexp.MustCompile(`(?i)\b(cdm|eme|drm|widevine|playready|fairplay|license|key ?system|encrypted|decrypt)`)
+
+// WatchMedia records the errors and the error messages of the media players of the page via the Media domain,
+// such as the decoding errors or the DRM errors of the streaming players, which are not exposed to the page.
+func (p *Page) WatchMedia() *MediaWatcher {
+	ctx, cancel := context.WithCancel(p.ctx)
+	w := &MediaWatcher{
+		cancel:  cancel,
+		restore: p.EnableDomain(&proto.MediaEnable{}),
+	}
+
+	wait := p.Context(ctx).EachEvent(func(e *proto.MediaPlayerErrorsRaised) {
+		for _, err := range e.Errors {
+			msg := ""
+			if m, has := err.Data["message"]; has {
+				msg = m.Str()
+			}
+			w.add(&MediaIssue{PlayerID: e.PlayerID, Type: err.ErrorType, Code: err.Code, Message: msg})
+		}
+	}, func(e *proto.MediaPlayerMessagesLogged) {
+		for _, m := range e.Messages {
+			if m.Level == proto.MediaPlayerMessageLevelError {
+				w.add(&MediaIssue{PlayerID: e.PlayerID, Type: string(m.Level), Message: m.Message})
+			}
+		}
+	})
+	go wait()
+
+	return w
+}
+
+func (w *MediaWatcher) add(issue *MediaIssue) {
+	issue.DRM = regDRM.MatchString(issue.Type + " " + issue.Message)
+	issue.Time = time.Now()
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.issues = append(w.issues, issue)
+}
+
+// Issues returns the recorded issues in order
+func (w *MediaWatcher) Issues() []*MediaIssue {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return append([]*MediaIssue{}, w.issues...)
+}
+
+// DRMIssues returns the recorded issues that are about DRM
+func (w *MediaWatcher) DRMIssues() []*MediaIssue {
+	list := []*MediaIssue{}
+	for _, issue := range w.Issues() {
+		if issue.DRM {
+			list = append(list, issue)
+		}
+	}
+	return list
+}
+
+// Stop recording
+func (w *MediaWatcher) Stop() {
+	w.cancel()
+	w.restore()
+}
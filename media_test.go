@@ -0,0 +1,139 @@
+package rod_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image/png"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Fromsko/rodPro"
+	"github.com/Fromsko/rodPro/lib/proto"
+	"github.com/Fromsko/rodPro/lib/utils"
+)
+
+// wav generates a silent mono 8-bit wav of the duration
+func wav(d time.Duration) []byte {
+	const rate = 8000
+	n := int(d.Seconds() * rate)
+
+	buf := bytes.NewBuffer(nil)
+	w := func(v interface{}) { _ = binary.Write(buf, binary.LittleEndian, v) }
+	buf.WriteString("RIFF")
+	w(uint32(36 + n))
+	buf.WriteString("WAVEfmt ")
+	w(uint32(16))
+	w(uint16(1))
+	w(uint16(1))
+	w(uint32(rate))
+	w(uint32(rate))
+	w(uint16(1))
+	w(uint16(8))
+	buf.WriteString("data")
+	w(uint32(n))
+	buf.Write(bytes.Repeat([]byte{128}, n))
+	return buf.Bytes()
+}
+
+func TestMedia(t *testing.T) {
+	g := setup(t)
+
+	s := g.Serve()
+	s.Route("/", ".html", `<html><body><audio src="/a.wav"></audio></body></html>`)
+	s.Mux.HandleFunc("/a.wav", func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "a.wav", time.Time{}, bytes.NewReader(wav(time.Second)))
+	})
+
+	p := g.newPage().MustNavigate(s.URL()).MustWaitLoad()
+	m := p.MustElement("audio").Media()
+
+	state := m.MustState()
+	g.True(state.Paused)
+	g.False(state.Playing)
+	g.Nil(state.Error)
+
+	m.MustPlay().MustWaitPlaying()
+	state = m.MustState()
+	g.False(state.Paused)
+	g.InDelta(state.Duration.Seconds(), 1, 0.01)
+	g.Has(state.Src, "/a.wav")
+
+	m.MustPause().MustSeek(900 * time.Millisecond)
+	g.InDelta(m.MustState().CurrentTime.Seconds(), 0.9, 0.01)
+
+	m.MustSetMuted(true)
+	g.True(m.MustState().Muted)
+	g.False(m.MustState().Audible)
+
+	m.MustPlay().MustWaitEnded()
+	g.True(m.MustState().Ended)
+
+	g.mc.stubErr(1, proto.RuntimeCallFunctionOn{})
+	g.Err(m.State())
+}
+
+func TestMediaFrame(t *testing.T) {
+	g := setup(t)
+
+	p := g.page.MustNavigate(g.blank())
+	el := p.MustElementByJS(`() => {
+		const canvas = document.createElement('canvas')
+		canvas.width = 40
+		canvas.height = 30
+		const ctx = canvas.getContext('2d')
+		setInterval(() => {
+			ctx.fillStyle = 'red'
+			ctx.fillRect(0, 0, 40, 30)
+		}, 10)
+
+		const video = document.createElement('video')
+		video.muted = true
+		video.srcObject = canvas.captureStream()
+		document.body.append(video)
+		return video
+	}`)
+
+	m := el.Media()
+	m.MustPlay().MustWaitPlaying()
+
+	img, err := png.Decode(bytes.NewReader(m.MustFrame()))
+	g.E(err)
+	g.Eq(img.Bounds().Dx(), 40)
+	r, _, _, _ := img.At(20, 15).RGBA()
+	g.Gt(r, uint32(0xf000))
+
+	g.mc.stubErr(1, proto.RuntimeCallFunctionOn{})
+	g.Err(m.Frame())
+}
+
+func TestWatchMedia(t *testing.T) {
+	g := setup(t)
+
+	s := g.Serve()
+	s.Route("/", ".html", `<html><body><video></video></body></html>`)
+	s.Route("/bad.mp4", ".mp4", "not a video")
+
+	p := g.newPage().MustNavigate(s.URL()).MustWaitLoad()
+
+	w := p.WatchMedia()
+	defer w.Stop()
+
+	m := p.MustElement("video").Media()
+	p.MustElement("video").MustEval(`src => this.src = src`, s.URL("/bad.mp4"))
+	g.Err(m.Play())
+
+	for i := 0; i < 30 && len(w.Issues()) == 0; i++ {
+		utils.Sleep(0.1)
+	}
+	g.Gt(len(w.Issues()), 0)
+	g.NotNil(m.MustState().Error)
+	g.Len(w.DRMIssues(), 0)
+
+	// the waits fail fast on the media error instead of hanging until the context is done
+	err := m.WaitPlaying()
+	g.Is(err, &rod.ErrEval{})
+	g.Has(err.Error(), "media error")
+	g.Err(m.Seek(time.Second))
+	g.Err(m.WaitEnded())
+}
@@ -0,0 +1,235 @@
+// This file serves for the metrics of the browser activities.
+
+package rod
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultLatencyBuckets of [Metrics.CDPLatency] in seconds
+var DefaultLatencyBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Metrics collects the counters and histograms of the browser activities, such as the pages opened,
+// navigations, and the latency of the cdp calls. It implements the [http.Handler] that serves the
+// Prometheus text format, and the expvar.Var that returns the JSON of [Metrics.Snapshot], such as:
+//
+//	m := rod.NewMetrics()
+//	browser := rod.New().Metrics(m).MustConnect()
+//
+//	http.Handle("/metrics", m)
+//	expvar.Publish("rod", m)
+//
+// The metrics of a browser is shared with its pages and the clones, use different [Metrics] to
+// monitor them separately.
+type Metrics struct {
+	pagesOpened      int64
+	navigations      int64
+	cdpCalls         int64
+	cdpErrors        int64
+	hijackedRequests int64
+	crashes          int64
+
+	latency *histogram
+}
+
+// NewMetrics creates a [Metrics] with the [DefaultLatencyBuckets]
+func NewMetrics() *Metrics {
+	return &Metrics{latency: newHistogram(DefaultLatencyBuckets)}
+}
+
+// Metrics sets the metrics to collect the activities of the browser, set it to nil to disable.
+func (b *Browser) Metrics(m *Metrics) *Browser {
+	b.metrics = m
+	return b
+}
+
+// MetricsSnapshot is the values of the [Metrics] at a moment
+type MetricsSnapshot struct {
+	PagesOpened      int64             `json:"pagesOpened"`
+	Navigations      int64             `json:"navigations"`
+	CDPCalls         int64             `json:"cdpCalls"`
+	CDPErrors        int64             `json:"cdpErrors"`
+	HijackedRequests int64             `json:"hijackedRequests"`
+	Crashes          int64             `json:"crashes"`
+	CDPLatency       HistogramSnapshot `json:"cdpLatency"`
+}
+
+// HistogramSnapshot is the values of a histogram at a moment
+type HistogramSnapshot struct {
+	// Buckets are the upper bounds in seconds
+	Buckets []float64 `json:"buckets"`
+
+	// Counts are the cumulative counts of each bucket
+	Counts []uint64 `json:"counts"`
+
+	// Sum of the observed values in seconds
+	Sum float64 `json:"sum"`
+
+	// Count of the observed values
+	Count uint64 `json:"count"`
+}
+
+// Snapshot of the current values
+func (m *Metrics) Snapshot() MetricsSnapshot {
+	return MetricsSnapshot{
+		PagesOpened:      atomic.LoadInt64(&m.pagesOpened),
+		Navigations:      atomic.LoadInt64(&m.navigations),
+		CDPCalls:         atomic.LoadInt64(&m.cdpCalls),
+		CDPErrors:        atomic.LoadInt64(&m.cdpErrors),
+		HijackedRequests: atomic.LoadInt64(&m.hijackedRequests),
+		Crashes:          atomic.LoadInt64(&m.crashes),
+		CDPLatency:       m.latency.snapshot(),
+	}
+}
+
+// String implements the expvar.Var
+func (m *Metrics) String() string {
+	b, _ := json.Marshal(m.Snapshot())
+	return string(b)
+}
+
+// ServeHTTP serves the metrics in the Prometheus text format
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	_ = m.WritePrometheus(w)
+}
+
+// WritePrometheus writes the metrics in the Prometheus text format, the names are prefixed with "rod_".
+func (m *Metrics) WritePrometheus(w io.Writer) error {
+	s := m.Snapshot()
+
+	counters := []struct {
+		name, help string
+		val        int64
+	}{
+		{"rod_pages_opened_total", "Number of the pages opened.", s.PagesOpened},
+		{"rod_navigations_total", "Number of the navigations.", s.Navigations},
+		{"rod_cdp_calls_total", "Number of the cdp calls.", s.CDPCalls},
+		{"rod_cdp_errors_total", "Number of the failed cdp calls.", s.CDPErrors},
+		{"rod_hijacked_requests_total", "Number of the hijacked requests.", s.HijackedRequests},
+		{"rod_crashes_total", "Number of the crashed targets.", s.Crashes},
+	}
+
+	for _, c := range counters {
+		_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n%s %d\n", c.name, c.help, c.name, c.name, c.val)
+		if err != nil {
+			return err
+		}
+	}
+
+	const name = "rod_cdp_call_duration_seconds"
+	_, err := fmt.Fprintf(w, "# HELP %s Latency of the cdp calls.\n# TYPE %s histogram\n", name, name)
+	if err != nil {
+		return err
+	}
+	for i, le := range s.CDPLatency.Buckets {
+		_, err = fmt.Fprintf(w, "%s_bucket{le=\"%g\"} %d\n", name, le, s.CDPLatency.Counts[i])
+		if err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "%s_bucket{le=\"+Inf\"} %d\n%s_sum %g\n%s_count %d\n",
+		name, s.CDPLatency.Count, name, s.CDPLatency.Sum, name, s.CDPLatency.Count)
+	return err
+}
+
+func (m *Metrics) inc(counter *int64) {
+	atomic.AddInt64(counter, 1)
+}
+
+func (m *Metrics) pageOpened() {
+	if m != nil {
+		m.inc(&m.pagesOpened)
+	}
+}
+
+func (m *Metrics) navigated() {
+	if m != nil {
+		m.inc(&m.navigations)
+	}
+}
+
+func (m *Metrics) hijacked() {
+	if m != nil {
+		m.inc(&m.hijackedRequests)
+	}
+}
+
+func (m *Metrics) crashed() {
+	if m != nil {
+		m.inc(&m.crashes)
+	}
+}
+
+func (m *Metrics) cdpCall(d time.Duration, err error) {
+	if m == nil {
+		return
+	}
+
+	m.inc(&m.cdpCalls)
+	if err != nil {
+		m.inc(&m.cdpErrors)
+	}
+	m.latency.observe(d.Seconds())
+}
+
+type histogram struct {
+	lock    sync.Mutex
+	buckets []float64
+	counts  []uint64
+	sum     float64
+	count   uint64
+}
+
+func newHistogram(buckets []float64) *histogram {
+	list := append([]float64{}, buckets...)
+	sort.Float64s(list)
+	return &histogram{buckets: list, counts: make([]uint64, len(list))}
+}
+
+func (h *histogram) observe(v float64) {
+	if h == nil {
+		return
+	}
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	i := sort.SearchFloat64s(h.buckets, v)
+	if i < len(h.counts) {
+		h.counts[i]++
+	}
+	h.sum += v
+	h.count++
+}
+
+// snapshot returns the cumulative counts
+func (h *histogram) snapshot() HistogramSnapshot {
+	if h == nil {
+		return HistogramSnapshot{}
+	}
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	s := HistogramSnapshot{
+		Buckets: append([]float64{}, h.buckets...),
+		Counts:  make([]uint64, len(h.counts)),
+		Sum:     h.sum,
+		Count:   h.count,
+	}
+
+	var total uint64
+	for i, c := range h.counts {
+		total += c
+		s.Counts[i] = total
+	}
+	return s
+}
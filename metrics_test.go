@@ -0,0 +1,53 @@
+package rod_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Fromsko/rodPro"
+	"github.com/Fromsko/rodPro/lib/proto"
+)
+
+func TestMetrics(t *testing.T) {
+	g := setup(t)
+
+	m := rod.NewMetrics()
+	g.browser.Metrics(m)
+	defer g.browser.Metrics(nil)
+
+	p := g.newPage(g.blank())
+	p.MustNavigate(g.blank())
+
+	router := p.HijackRequests()
+	router.MustAdd("*", func(ctx *rod.Hijack) { ctx.ContinueRequest(&proto.FetchContinueRequest{}) })
+	go router.Run()
+	p.MustNavigate(g.srcFile("fixtures/click.html"))
+	router.MustStop()
+
+	g.mc.stubErr(1, proto.RuntimeCallFunctionOn{})
+	g.Err(p.Eval(`() => 1`))
+
+	s := m.Snapshot()
+	g.Eq(s.PagesOpened, int64(1))
+	g.Gte(s.Navigations, int64(2))
+	g.Gt(s.CDPCalls, int64(0))
+	g.Gte(s.CDPErrors, int64(1))
+	g.Gte(s.HijackedRequests, int64(1))
+	g.Gt(s.CDPLatency.Count, uint64(0))
+	g.Eq(s.CDPLatency.Counts[len(s.CDPLatency.Counts)-1], s.CDPLatency.Count)
+
+	var j rod.MetricsSnapshot
+	g.E(json.Unmarshal([]byte(m.String()), &j))
+	g.Eq(j.PagesOpened, int64(1))
+
+	res := httptest.NewRecorder()
+	m.ServeHTTP(res, httptest.NewRequest("GET", "/metrics", nil))
+	g.Has(res.Body.String(), "# TYPE rod_pages_opened_total counter\nrod_pages_opened_total 1\n")
+	g.Has(res.Body.String(), `rod_cdp_call_duration_seconds_bucket{le="+Inf"}`)
+
+	buf := bytes.NewBuffer(nil)
+	g.E((&rod.Metrics{}).WritePrometheus(buf))
+	g.Has(buf.String(), "rod_crashes_total 0\n# HELP rod_cdp_call_duration_seconds")
+}
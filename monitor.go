@@ -0,0 +1,286 @@
+// This file serves for the live screencast and the remote input of the monitor.
+
+package rod
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Fromsko/rodPro/lib/proto"
+	"github.com/Fromsko/rodPro/lib/utils"
+	"github.com/ysmood/gson"
+)
+
+// MonitorScreencastQuality is the jpeg quality of the live screencast of [Browser.ServeMonitor]
+var MonitorScreencastQuality = 70
+
+// screencasts shares one screencast of a page among all its viewers,
+// because a page can only have one screencast at a time.
+type screencasts struct {
+	browser *Browser
+	lock    sync.Mutex
+	list    map[proto.TargetTargetID]*screencast
+}
+
+type screencast struct {
+	subs  map[chan []byte]struct{}
+	last  []byte
+	close func()
+}
+
+func newScreencasts(b *Browser) *screencasts {
+	return &screencasts{browser: b, list: map[proto.TargetTargetID]*screencast{}}
+}
+
+// subscribe to the jpeg frames of the page, the slow subscriber only gets the latest frame
+func (s *screencasts) subscribe(p *Page) (<-chan []byte, func(), error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	sc, has := s.list[p.TargetID]
+	if !has {
+		var err error
+		sc, err = s.start(p)
+		if err != nil {
+			return nil, nil, err
+		}
+		s.list[p.TargetID] = sc
+	}
+
+	ch := make(chan []byte, 1)
+	sc.subs[ch] = struct{}{}
+	if sc.last != nil {
+		ch <- sc.last
+	}
+
+	unsubscribe := func() {
+		s.lock.Lock()
+		defer s.lock.Unlock()
+
+		delete(sc.subs, ch)
+		if len(sc.subs) == 0 && s.list[p.TargetID] == sc {
+			delete(s.list, p.TargetID)
+			sc.close()
+		}
+	}
+
+	return ch, unsubscribe, nil
+}
+
+// start must be called with the lock held
+func (s *screencasts) start(p *Page) (*screencast, error) {
+	ctx, cancel := context.WithCancel(s.browser.ctx)
+	p = p.Context(ctx)
+
+	sc := &screencast{subs: map[chan []byte]struct{}{}}
+
+	wait := p.EachEvent(func(e *proto.PageScreencastFrame) {
+		_ = proto.PageScreencastFrameAck{SessionID: e.SessionID}.Call(p)
+		s.broadcast(sc, e.Data)
+	})
+
+	err := proto.PageStartScreencast{
+		Format:  proto.PageStartScreencastFormatJpeg,
+		Quality: gson.Int(MonitorScreencastQuality),
+	}.Call(p)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	go wait()
+
+	sc.close = func() {
+		cancel()
+		_ = proto.PageStopScreencast{}.Call(p.Context(s.browser.ctx))
+	}
+
+	return sc, nil
+}
+
+func (s *screencasts) broadcast(sc *screencast, frame []byte) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	sc.last = frame
+	for ch := range sc.subs {
+		select {
+		case <-ch: // drop the stale frame
+		default:
+		}
+		ch <- frame
+	}
+}
+
+// serveStream writes the screencast of the page as MJPEG, it can be used as the src of an img tag
+func (s *screencasts) serveStream(w http.ResponseWriter, r *http.Request, p *Page) {
+	frames, unsubscribe, err := s.subscribe(p)
+	utils.E(err)
+	defer unsubscribe()
+
+	mw := multipart.NewWriter(w)
+	w.Header().Set("Content-Type", "multipart/x-mixed-replace; boundary="+mw.Boundary())
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	flusher, _ := w.(http.Flusher)
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-s.browser.ctx.Done():
+			return
+		case frame := <-frames:
+			part, err := mw.CreatePart(textproto.MIMEHeader{
+				"Content-Type":   {"image/jpeg"},
+				"Content-Length": {strconv.Itoa(len(frame))},
+			})
+			if err != nil {
+				return
+			}
+			if _, err = part.Write(frame); err != nil {
+				return
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+	}
+}
+
+// MonitorInput is the input forwarded from the monitor to the page
+type MonitorInput struct {
+	// Type is "click", "move", "scroll", or "type"
+	Type string `json:"type"`
+
+	// X and Y are the position relative to the viewport, from 0 to 1
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+
+	// DeltaX and DeltaY are the css pixels to scroll
+	DeltaX float64 `json:"deltaX"`
+	DeltaY float64 `json:"deltaY"`
+
+	// Text to type, the format is the same as [Keyboard.TypeText]
+	Text string `json:"text"`
+}
+
+// Forward the input to the page
+func (in *MonitorInput) Forward(p *Page) error {
+	if in.Type == "type" {
+		return p.Keyboard.TypeText(in.Text)
+	}
+
+	metrics, err := proto.PageGetLayoutMetrics{}.Call(p)
+	if err != nil {
+		return err
+	}
+	vp := metrics.CSSLayoutViewport
+	pt := proto.NewPoint(in.X*float64(vp.ClientWidth), in.Y*float64(vp.ClientHeight))
+
+	err = p.Mouse.MoveTo(pt)
+	if err != nil {
+		return err
+	}
+
+	switch in.Type {
+	case "move":
+		return nil
+	case "click":
+		return p.Mouse.Click(proto.InputMouseButtonLeft, 1)
+	case "scroll":
+		return p.Mouse.Scroll(in.DeltaX, in.DeltaY, 1)
+	}
+	return fmt.Errorf("unknown monitor input type: %s", in.Type)
+}
+
+// monitorTokenCookie authorizes the input of the monitor, it's set when the monitor page is served
+const monitorTokenCookie = "rod-monitor-token"
+
+func setMonitorToken(w http.ResponseWriter, token string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     monitorTokenCookie,
+		Value:    token,
+		Path:     "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+// checkMonitorInput guards the input endpoint against the cross-site requests. The request must carry the
+// token cookie of the monitor page, come from the same origin, and be json, which a plain html form can't send.
+func checkMonitorInput(r *http.Request, token string) int {
+	if r.Method != http.MethodPost {
+		return http.StatusMethodNotAllowed
+	}
+
+	if t, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); t != "application/json" {
+		return http.StatusUnsupportedMediaType
+	}
+
+	if origin := r.Header.Get("Origin"); origin != "" {
+		u, err := url.Parse(origin)
+		if err != nil || u.Host != r.Host {
+			return http.StatusForbidden
+		}
+	}
+
+	c, err := r.Cookie(monitorTokenCookie)
+	if err != nil || subtle.ConstantTimeCompare([]byte(c.Value), []byte(token)) != 1 {
+		return http.StatusForbidden
+	}
+
+	return 0
+}
+
+func (b *Browser) serveMonitorInput(w http.ResponseWriter, r *http.Request, token string) {
+	if code := checkMonitorInput(r, token); code != 0 {
+		w.WriteHeader(code)
+		return
+	}
+
+	id := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+	p, err := b.PageFromTarget(proto.TargetTargetID(id))
+	utils.E(err)
+
+	in := &MonitorInput{}
+	utils.E(json.NewDecoder(r.Body).Decode(in))
+	utils.E(in.Forward(p.Context(r.Context())))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DevToolsURL returns the url of the DevTools frontend for the page, it's served by the browser itself,
+// so it's only reachable if the remote debugging port of the browser is reachable.
+// The browser must be connected via the websocket url, such as the one from the launcher.
+func (p *Page) DevToolsURL() (string, error) {
+	return devToolsURL(p.browser.wsURL, p.TargetID)
+}
+
+func devToolsURL(wsURL string, id proto.TargetTargetID) (string, error) {
+	u, err := url.Parse(wsURL)
+	if err != nil {
+		return "", err
+	}
+	if u.Host == "" || !strings.HasPrefix(u.Scheme, "ws") {
+		return "", fmt.Errorf("unknown websocket url of the browser: %q", wsURL)
+	}
+
+	scheme := "http"
+	if u.Scheme == "wss" {
+		scheme = "https"
+	}
+
+	return fmt.Sprintf("%s://%s/devtools/inspector.html?%s=%s/devtools/page/%s",
+		scheme, u.Host, u.Scheme, u.Host, id), nil
+}
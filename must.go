@@ -9,9 +9,13 @@
 package rod
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
+	"image"
+	"image/color"
+	"io"
 	"io/ioutil"
-	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
@@ -19,10 +23,13 @@ import (
 
 	"github.com/ysmood/gson"
 
+	"github.com/Fromsko/rodPro/lib/captcha"
 	"github.com/Fromsko/rodPro/lib/devices"
 	"github.com/Fromsko/rodPro/lib/input"
 	"github.com/Fromsko/rodPro/lib/proto"
+	"github.com/Fromsko/rodPro/lib/scrape"
 	"github.com/Fromsko/rodPro/lib/utils"
+	"github.com/Fromsko/rodPro/lib/visual"
 )
 
 // It must be generated by genE.
@@ -93,12 +100,39 @@ func (b *Browser) MustHandleAuth(username, password string) (wait func()) {
 	return func() { b.e(w()) }
 }
 
+// MustCallRaw is similar to [Browser.CallRaw].
+func (b *Browser) MustCallRaw(method string, params, result interface{}) *Browser {
+	b.e(b.CallRaw(method, params, result))
+	return b
+}
+
 // MustIgnoreCertErrors is similar to [Browser.IgnoreCertErrors].
 func (b *Browser) MustIgnoreCertErrors(enable bool) *Browser {
 	b.e(b.IgnoreCertErrors(enable))
 	return b
 }
 
+// MustInjectOnEveryDocument is similar to [Browser.InjectOnEveryDocument].
+func (b *Browser) MustInjectOnEveryDocument(js string) (remove func()) {
+	r, err := b.InjectOnEveryDocument(js)
+	b.e(err)
+	return func() { b.e(r()) }
+}
+
+// MustWindows is similar to [Browser.Windows].
+func (b *Browser) MustWindows() []*Window {
+	list, err := b.Windows()
+	b.e(err)
+	return list
+}
+
+// MustNewWindow is similar to [Browser.NewWindow].
+func (b *Browser) MustNewWindow(url string) (*Window, *Page) {
+	w, p, err := b.NewWindow(url)
+	b.e(err)
+	return w, p
+}
+
 // MustGetCookies is similar to [Browser.GetCookies].
 func (b *Browser) MustGetCookies() []*proto.NetworkCookie {
 	nc, err := b.GetCookies()
@@ -106,6 +140,19 @@ func (b *Browser) MustGetCookies() []*proto.NetworkCookie {
 	return nc
 }
 
+// MustSaveSession is similar to [Browser.SaveSession].
+func (b *Browser) MustSaveSession(path string) *Browser {
+	b.e(b.SaveSession(path))
+	return b
+}
+
+// MustRestoreSession is similar to [Browser.RestoreSession].
+func (b *Browser) MustRestoreSession(s *Session) Pages {
+	pages, err := b.RestoreSession(s)
+	b.e(err)
+	return pages
+}
+
 // MustSetCookies is similar to [Browser.SetCookies].
 // If the len(cookies) is 0 it will clear all the cookies.
 func (b *Browser) MustSetCookies(cookies ...*proto.NetworkCookie) *Browser {
@@ -133,6 +180,13 @@ func (b *Browser) MustWaitDownload() func() []byte {
 	}
 }
 
+// MustCapabilities is similar to [Browser.Capabilities].
+func (b *Browser) MustCapabilities() *Capabilities {
+	c, err := b.Capabilities()
+	b.e(err)
+	return c
+}
+
 // MustVersion is similar to [Browser.Version].
 func (b *Browser) MustVersion() *proto.BrowserGetVersionResult {
 	v, err := b.Version()
@@ -216,6 +270,13 @@ func (p *Page) MustSetExtraHeaders(dict ...string) (cleanup func()) {
 	return
 }
 
+// MustSetExtraHeadersFor is similar to [Page.SetExtraHeadersFor].
+func (p *Page) MustSetExtraHeadersFor(pattern string, dict ...string) (cleanup func()) {
+	cleanup, err := p.SetExtraHeadersFor(pattern, dict)
+	p.e(err)
+	return
+}
+
 // MustSetUserAgent is similar to [Page.SetUserAgent].
 func (p *Page) MustSetUserAgent(req *proto.NetworkSetUserAgentOverride) *Page {
 	p.e(p.SetUserAgent(req))
@@ -228,12 +289,24 @@ func (p *Page) MustSetBlockedURLs(urls ...string) *Page {
 	return p
 }
 
+// MustCallRaw is similar to [Page.CallRaw].
+func (p *Page) MustCallRaw(method string, params, result interface{}) *Page {
+	p.e(p.CallRaw(method, params, result))
+	return p
+}
+
 // MustNavigate is similar to [Page.Navigate].
 func (p *Page) MustNavigate(url string) *Page {
 	p.e(p.Navigate(url))
 	return p
 }
 
+// MustNavigateWithReferer is similar to [Page.NavigateWithReferer].
+func (p *Page) MustNavigateWithReferer(url, referer string) *Page {
+	p.e(p.NavigateWithReferer(url, referer))
+	return p
+}
+
 // MustReload is similar to [Page.Reload].
 func (p *Page) MustReload() *Page {
 	p.e(p.Reload())
@@ -357,6 +430,227 @@ func (p *Page) MustHandleFileDialog() func(...string) {
 	}
 }
 
+// MustHandleFedCM is similar to [Page.HandleFedCM].
+func (p *Page) MustHandleFedCM() func() *FedCMDialog {
+	return p.HandleFedCM()
+}
+
+// MustSelectAccount is similar to [FedCMDialog.SelectAccount].
+func (d *FedCMDialog) MustSelectAccount(index int) {
+	d.page.e(d.SelectAccount(index))
+}
+
+// MustClickButton is similar to [FedCMDialog.ClickButton].
+func (d *FedCMDialog) MustClickButton(button proto.FedCmDialogButton) {
+	d.page.e(d.ClickButton(button))
+}
+
+// MustDismiss is similar to [FedCMDialog.Dismiss].
+func (d *FedCMDialog) MustDismiss() {
+	d.page.e(d.Dismiss())
+}
+
+// MustEmulateBluetooth is similar to [Page.EmulateBluetooth].
+func (p *Page) MustEmulateBluetooth(state proto.BluetoothEmulationCentralState) *Page {
+	p.e(p.EmulateBluetooth(state))
+	return p
+}
+
+// MustAddBluetoothDevice is similar to [Page.AddBluetoothDevice].
+func (p *Page) MustAddBluetoothDevice(address, name string, serviceUUIDs ...string) *Page {
+	p.e(p.AddBluetoothDevice(address, name, serviceUUIDs...))
+	return p
+}
+
+// MustAdvertiseBluetoothDevice is similar to [Page.AdvertiseBluetoothDevice].
+func (p *Page) MustAdvertiseBluetoothDevice(entry *proto.BluetoothEmulationScanEntry) *Page {
+	p.e(p.AdvertiseBluetoothDevice(entry))
+	return p
+}
+
+// MustHandleDevicePrompt is similar to [Page.HandleDevicePrompt].
+func (p *Page) MustHandleDevicePrompt() (
+	wait func() *proto.DeviceAccessDeviceRequestPrompted, handle func(proto.DeviceAccessDeviceID),
+) {
+	w, h := p.HandleDevicePrompt()
+	return w, func(id proto.DeviceAccessDeviceID) {
+		p.e(h(id))
+	}
+}
+
+// MustAddAuthenticator is similar to [WebAuthn.AddAuthenticator].
+func (w *WebAuthn) MustAddAuthenticator(opts *proto.WebAuthnVirtualAuthenticatorOptions) *VirtualAuthenticator {
+	a, err := w.AddAuthenticator(opts)
+	w.page.e(err)
+	return a
+}
+
+// MustDisable is similar to [WebAuthn.Disable].
+func (w *WebAuthn) MustDisable() {
+	w.page.e(w.Disable())
+}
+
+// MustAddCredential is similar to [VirtualAuthenticator.AddCredential].
+func (a *VirtualAuthenticator) MustAddCredential(c *proto.WebAuthnCredential) *proto.WebAuthnCredential {
+	cred, err := a.AddCredential(c)
+	a.page.e(err)
+	return cred
+}
+
+// MustCredentials is similar to [VirtualAuthenticator.Credentials].
+func (a *VirtualAuthenticator) MustCredentials() []*proto.WebAuthnCredential {
+	list, err := a.Credentials()
+	a.page.e(err)
+	return list
+}
+
+// MustCredential is similar to [VirtualAuthenticator.Credential].
+func (a *VirtualAuthenticator) MustCredential(id []byte) *proto.WebAuthnCredential {
+	c, err := a.Credential(id)
+	a.page.e(err)
+	return c
+}
+
+// MustSignCount is similar to [VirtualAuthenticator.SignCount].
+func (a *VirtualAuthenticator) MustSignCount(id []byte) int {
+	n, err := a.SignCount(id)
+	a.page.e(err)
+	return n
+}
+
+// MustRemoveCredential is similar to [VirtualAuthenticator.RemoveCredential].
+func (a *VirtualAuthenticator) MustRemoveCredential(id []byte) *VirtualAuthenticator {
+	a.page.e(a.RemoveCredential(id))
+	return a
+}
+
+// MustClearCredentials is similar to [VirtualAuthenticator.ClearCredentials].
+func (a *VirtualAuthenticator) MustClearCredentials() *VirtualAuthenticator {
+	a.page.e(a.ClearCredentials())
+	return a
+}
+
+// MustSetUserVerified is similar to [VirtualAuthenticator.SetUserVerified].
+func (a *VirtualAuthenticator) MustSetUserVerified(verified bool) *VirtualAuthenticator {
+	a.page.e(a.SetUserVerified(verified))
+	return a
+}
+
+// MustRemove is similar to [VirtualAuthenticator.Remove].
+func (a *VirtualAuthenticator) MustRemove() {
+	a.page.e(a.Remove())
+}
+
+// MustGrantMedia is similar to [Page.GrantMedia].
+func (p *Page) MustGrantMedia() *Page {
+	p.e(p.GrantMedia())
+	return p
+}
+
+// MustRecordMediaRequests is similar to [Page.RecordMediaRequests].
+func (p *Page) MustRecordMediaRequests() (list func() []gson.JSON, stop func()) {
+	l, s, err := p.RecordMediaRequests()
+	p.e(err)
+	return l, func() { p.e(s()) }
+}
+
+// MustWaitPrintReady is similar to [Page.WaitPrintReady].
+func (p *Page) MustWaitPrintReady() *Page {
+	p.e(p.WaitPrintReady())
+	return p
+}
+
+// MustStream is similar to [PDFBuilder.Stream].
+func (b *PDFBuilder) MustStream() *StreamReader {
+	r, err := b.Stream()
+	b.page.e(err)
+	return r
+}
+
+// MustWriteTo is similar to [PDFBuilder.WriteTo].
+func (b *PDFBuilder) MustWriteTo(w io.Writer) int64 {
+	n, err := b.WriteTo(w)
+	b.page.e(err)
+	return n
+}
+
+// MustSave is similar to [PDFBuilder.Save].
+func (b *PDFBuilder) MustSave(path string) {
+	b.page.e(b.Save(path))
+}
+
+// MustScrollTo is similar to [Page.ScrollTo].
+func (p *Page) MustScrollTo(el *Element) *Page {
+	p.e(p.ScrollTo(el))
+	return p
+}
+
+// MustFillForm is similar to [Page.FillForm].
+func (p *Page) MustFillForm(selector string, data map[string]interface{}) *Page {
+	p.e(p.FillForm(selector, data))
+	return p
+}
+
+// MustFillFormStruct is similar to [Page.FillFormStruct].
+func (p *Page) MustFillFormStruct(selector string, data interface{}) *Page {
+	p.e(p.FillFormStruct(selector, data))
+	return p
+}
+
+// MustExtract is similar to [Page.Extract].
+func (p *Page) MustExtract(fields map[string]string) map[string]gson.JSON {
+	data, err := p.Extract(fields)
+	p.e(err)
+	return data
+}
+
+// MustExtractStruct is similar to [Page.ExtractStruct].
+func (p *Page) MustExtractStruct(data interface{}) *Page {
+	p.e(p.ExtractStruct(data))
+	return p
+}
+
+// MustElementAny is similar to [Page.ElementAny].
+func (p *Page) MustElementAny(selectors ...string) *Element {
+	el, err := p.ElementAny(selectors...)
+	p.e(err)
+	return el
+}
+
+// MustBestSelectorFor is similar to [Page.BestSelectorFor].
+func (p *Page) MustBestSelectorFor(el *Element) *SelectorCandidate {
+	c, err := p.BestSelectorFor(el)
+	p.e(err)
+	return c
+}
+
+// MustWatch is similar to [Page.Watch].
+func (p *Page) MustWatch(spec string, interval time.Duration, fn func(old, new gson.JSON)) (stop func()) {
+	stop, err := p.Watch(spec, interval, fn)
+	p.e(err)
+	return stop
+}
+
+// MustBotScore is similar to [Page.BotScore].
+func (p *Page) MustBotScore() *BotReport {
+	r, err := p.BotScore()
+	p.e(err)
+	return r
+}
+
+// MustSnapshotDOM is similar to [Page.SnapshotDOM].
+func (p *Page) MustSnapshotDOM() *DOMSnapshot {
+	s, err := p.SnapshotDOM()
+	p.e(err)
+	return s
+}
+
+// MustScrape is similar to [Page.Scrape].
+func (p *Page) MustScrape(data interface{}, hooks ...scrape.Hooks) *Page {
+	p.e(p.Scrape(data, hooks...))
+	return p
+}
+
 // MustScreenshot is similar to [Page.Screenshot].
 // If the toFile is "", it Page.will save output to "tmp/screenshots" folder, time as the file name.
 func (p *Page) MustScreenshot(toFile ...string) []byte {
@@ -388,6 +682,35 @@ func (p *Page) MustScreenshotFullPage(toFile ...string) []byte {
 	return bin
 }
 
+// MustScreenshotElement is similar to [Page.ScreenshotElement].
+// If the toFile is "", it will save output to "tmp/screenshots" folder, time as the file name.
+func (p *Page) MustScreenshotElement(selector string, toFile ...string) []byte {
+	bin, err := p.ScreenshotElement(selector, nil)
+	p.e(err)
+	p.e(saveFile(saveFileTypeScreenshot, bin, toFile))
+	return bin
+}
+
+// MustScreenshotAndCompare is similar to [Page.ScreenshotAndCompare].
+func (p *Page) MustScreenshotAndCompare(baselinePath string) *visual.Result {
+	res, err := p.ScreenshotAndCompare(baselinePath, nil)
+	p.e(err)
+	return res
+}
+
+// MustSaveScreenshotBaseline is similar to [Page.SaveScreenshotBaseline].
+func (p *Page) MustSaveScreenshotBaseline(baselinePath string) *Page {
+	p.e(p.SaveScreenshotBaseline(baselinePath))
+	return p
+}
+
+// MustDOMSnapshot is similar to [Page.DOMSnapshot].
+func (p *Page) MustDOMSnapshot(styles ...string) *DOMSnapshot {
+	s, err := p.DOMSnapshot(&DOMSnapshotOptions{Styles: styles})
+	p.e(err)
+	return s
+}
+
 // MustPDF is similar to [Page.PDF].
 // If the toFile is "", it Page.will save output to "tmp/pdf" folder, time as the file name.
 func (p *Page) MustPDF(toFile ...string) []byte {
@@ -400,6 +723,20 @@ func (p *Page) MustPDF(toFile ...string) []byte {
 	return bin
 }
 
+// MustWindow is similar to [Page.Window].
+func (p *Page) MustWindow() *Window {
+	w, err := p.Window()
+	p.e(err)
+	return w
+}
+
+// MustExpectPopup is similar to [Page.ExpectPopup].
+func (p *Page) MustExpectPopup(action func()) *Page {
+	popup, err := p.ExpectPopup(action)
+	p.e(err)
+	return popup
+}
+
 // MustWaitOpen is similar to [Page.WaitOpen].
 func (p *Page) MustWaitOpen() (wait func() (newPage *Page)) {
 	w := p.WaitOpen()
@@ -426,6 +763,12 @@ func (p *Page) MustWaitIdle() *Page {
 	return p
 }
 
+// MustWaitIdleCPU is similar to [Page.WaitIdleCPU].
+func (p *Page) MustWaitIdleCPU() *Page {
+	p.e(p.WaitIdleCPU(300 * time.Millisecond))
+	return p
+}
+
 // MustWaitDOMStable is similar to [Page.WaitDOMStable].
 func (p *Page) MustWaitDOMStable() *Page {
 	p.e(p.WaitDOMStable(time.Second, 0))
@@ -462,6 +805,26 @@ func (p *Page) MustEvalOnNewDocument(js string) {
 	p.e(err)
 }
 
+// MustAddNewDocumentScript is similar to [Page.AddNewDocumentScript].
+func (p *Page) MustAddNewDocumentScript(name, js string) *NewDocumentScript {
+	s, err := p.AddNewDocumentScript(name, js)
+	p.e(err)
+	return s
+}
+
+// MustRemoveNewDocumentScript is similar to [Page.RemoveNewDocumentScript].
+func (p *Page) MustRemoveNewDocumentScript(name string) *Page {
+	p.e(p.RemoveNewDocumentScript(name))
+	return p
+}
+
+// MustInjectScriptTag is similar to [Page.InjectScriptTag].
+func (p *Page) MustInjectScriptTag(url, content string) (remove func()) {
+	r, err := p.InjectScriptTag(url, content)
+	p.e(err)
+	return func() { p.e(r()) }
+}
+
 // MustExpose is similar to [Page.Expose].
 func (p *Page) MustExpose(name string, fn func(gson.JSON) (interface{}, error)) (stop func()) {
 	s, err := p.Expose(name, fn)
@@ -527,6 +890,12 @@ func (p *Page) MustElementFromPoint(left, top int) *Element {
 	return el
 }
 
+// MustReleaseAll is similar to [Page.ReleaseAll].
+func (p *Page) MustReleaseAll() *Page {
+	p.e(p.ReleaseAll())
+	return p
+}
+
 // MustRelease is similar to [Page.Release].
 func (p *Page) MustRelease(obj *proto.RuntimeRemoteObject) *Page {
 	p.e(p.Release(obj))
@@ -584,6 +953,47 @@ func (p *Page) MustElementX(xPath string) *Element {
 	return el
 }
 
+// MustElementByLabel is similar to [Page.ElementByLabel].
+func (p *Page) MustElementByLabel(text string) *Element {
+	el, err := p.ElementByLabel(text)
+	p.e(err)
+	return el
+}
+
+// MustElementByPlaceholder is similar to [Page.ElementByPlaceholder].
+func (p *Page) MustElementByPlaceholder(text string) *Element {
+	el, err := p.ElementByPlaceholder(text)
+	p.e(err)
+	return el
+}
+
+// MustElementByTestID is similar to [Page.ElementByTestID].
+func (p *Page) MustElementByTestID(id string) *Element {
+	el, err := p.ElementByTestID(id)
+	p.e(err)
+	return el
+}
+
+// MustElementNth is similar to [Page.ElementNth].
+func (p *Page) MustElementNth(selector string, n int) *Element {
+	el, err := p.ElementNth(selector, n)
+	p.e(err)
+	return el
+}
+
+// MustElementsFilter is similar to [Page.ElementsFilter].
+func (p *Page) MustElementsFilter(selector, jsPredicate string) Elements {
+	list, err := p.ElementsFilter(selector, jsPredicate)
+	p.e(err)
+	return list
+}
+
+// MustEachElement is similar to [Page.EachElement].
+func (p *Page) MustEachElement(selector string, fn func(*Element) (stop bool)) *Page {
+	p.e(p.EachElement(selector, fn))
+	return p
+}
+
 // MustElementByJS is similar to [Page.ElementByJS].
 func (p *Page) MustElementByJS(js string, params ...interface{}) *Element {
 	el, err := p.ElementByJS(Eval(js, params...))
@@ -638,12 +1048,24 @@ func (m *Mouse) MustMoveTo(x, y float64) *Mouse {
 	return m
 }
 
+// MustMoveHuman is similar to [Mouse.MoveHuman].
+func (m *Mouse) MustMoveHuman(x, y float64, opts *HumanizeOptions) *Mouse {
+	m.page.e(m.MoveHuman(proto.NewPoint(x, y), opts))
+	return m
+}
+
 // MustScroll is similar to [Mouse.Scroll].
 func (m *Mouse) MustScroll(x, y float64) *Mouse {
 	m.page.e(m.Scroll(x, y, 0))
 	return m
 }
 
+// MustSmoothScroll is similar to [Mouse.SmoothScroll].
+func (m *Mouse) MustSmoothScroll(x, y float64, duration time.Duration) *Mouse {
+	m.page.e(m.SmoothScroll(x, y, duration))
+	return m
+}
+
 // MustDown is similar to [Mouse.Down].
 func (m *Mouse) MustDown(button proto.InputMouseButton) *Mouse {
 	m.page.e(m.Down(button, 1))
@@ -662,12 +1084,36 @@ func (m *Mouse) MustClick(button proto.InputMouseButton) *Mouse {
 	return m
 }
 
+// MustClickHuman is similar to [Mouse.ClickHuman].
+func (m *Mouse) MustClickHuman(button proto.InputMouseButton, opts *HumanizeOptions) *Mouse {
+	m.page.e(m.ClickHuman(button, 1, opts))
+	return m
+}
+
 // MustType is similar to [Keyboard.Type].
 func (k *Keyboard) MustType(key ...input.Key) *Keyboard {
 	k.page.e(k.Type(key...))
 	return k
 }
 
+// MustTypeHuman is similar to [Keyboard.TypeHuman].
+func (k *Keyboard) MustTypeHuman(text string, opts *HumanizeOptions) *Keyboard {
+	k.page.e(k.TypeHuman(text, opts))
+	return k
+}
+
+// MustPressChord is similar to [Keyboard.PressChord].
+func (k *Keyboard) MustPressChord(chords ...string) *Keyboard {
+	k.page.e(k.PressChord(chords...))
+	return k
+}
+
+// MustTypeText is similar to [Keyboard.TypeText].
+func (k *Keyboard) MustTypeText(text string) *Keyboard {
+	k.page.e(k.TypeText(text))
+	return k
+}
+
 // MustDo is similar to [KeyActions.Do].
 func (ka *KeyActions) MustDo() {
 	ka.keyboard.page.e(ka.Do())
@@ -709,6 +1155,30 @@ func (t *Touch) MustTap(x, y float64) *Touch {
 	return t
 }
 
+// MustMoveTo is similar to [Pen.MoveTo].
+func (p *Pen) MustMoveTo(pt PenPoint) *Pen {
+	p.page.e(p.MoveTo(pt))
+	return p
+}
+
+// MustDown is similar to [Pen.Down].
+func (p *Pen) MustDown(pt PenPoint) *Pen {
+	p.page.e(p.Down(pt))
+	return p
+}
+
+// MustUp is similar to [Pen.Up].
+func (p *Pen) MustUp() *Pen {
+	p.page.e(p.Up())
+	return p
+}
+
+// MustDrawPath is similar to [Pen.DrawPath].
+func (p *Pen) MustDrawPath(points []PenPoint) *Pen {
+	p.page.e(p.DrawPath(points))
+	return p
+}
+
 // WithPanic returns an element clone with the specified panic function.
 // The fail must stop the current goroutine's execution immediately, such as use [runtime.Goexit] or panic inside it.
 func (el *Element) WithPanic(fail func(interface{})) *Element {
@@ -750,12 +1220,25 @@ func (el *Element) MustScrollIntoView() *Element {
 	return el
 }
 
+// MustScrollIntoViewIfNeeded is similar to [Element.ScrollIntoViewIfNeeded].
+func (el *Element) MustScrollIntoViewIfNeeded(opts *ScrollIntoViewOptions) *Element {
+	el.e(el.ScrollIntoViewIfNeeded(opts))
+	return el
+}
+
 // MustHover is similar to [Element.Hover].
 func (el *Element) MustHover() *Element {
 	el.e(el.Hover())
 	return el
 }
 
+// MustHoverAndWait is similar to [Element.HoverAndWait].
+func (el *Element) MustHoverAndWait(selector string) *Element {
+	found, err := el.HoverAndWait(selector)
+	el.e(err)
+	return found
+}
+
 // MustClick is similar to [Element.Click].
 func (el *Element) MustClick() *Element {
 	el.e(el.Click(proto.InputMouseButtonLeft, 1))
@@ -815,18 +1298,60 @@ func (el *Element) MustSelectAllText() *Element {
 	return el
 }
 
+// MustPressChord is similar to [Element.PressChord].
+func (el *Element) MustPressChord(chords ...string) *Element {
+	el.e(el.PressChord(chords...))
+	return el
+}
+
+// MustTypeText is similar to [Element.TypeText].
+func (el *Element) MustTypeText(text string) *Element {
+	el.e(el.TypeText(text))
+	return el
+}
+
+// MustClear is similar to [Element.Clear].
+func (el *Element) MustClear(mode ClearMode) *Element {
+	el.e(el.Clear(mode))
+	return el
+}
+
 // MustInput is similar to [Element.Input].
 func (el *Element) MustInput(text string) *Element {
 	el.e(el.Input(text))
 	return el
 }
 
+// MustInputHuman is similar to [Element.InputHuman].
+func (el *Element) MustInputHuman(text string, opts *HumanizeOptions) *Element {
+	el.e(el.InputHuman(text, opts))
+	return el
+}
+
+// MustInputRichText is similar to [Element.InputRichText].
+func (el *Element) MustInputRichText(content string, format RichTextFormat) *Element {
+	el.e(el.InputRichText(content, format))
+	return el
+}
+
 // MustInputTime is similar to [Element.Input].
 func (el *Element) MustInputTime(t time.Time) *Element {
 	el.e(el.InputTime(t))
 	return el
 }
 
+// MustInputDate is similar to [Element.InputDate].
+func (el *Element) MustInputDate(t time.Time) *Element {
+	el.e(el.InputDate(t))
+	return el
+}
+
+// MustSetChecked is similar to [Element.SetChecked].
+func (el *Element) MustSetChecked(checked bool) *Element {
+	el.e(el.SetChecked(checked))
+	return el
+}
+
 // MustInputColor is similar to [Element.InputColor].
 func (el *Element) MustInputColor(color string) *Element {
 	el.e(el.InputColor(color))
@@ -899,6 +1424,19 @@ func (el *Element) MustText() string {
 	return s
 }
 
+// MustSelectors is similar to [Element.Selectors].
+func (el *Element) MustSelectors() []SelectorCandidate {
+	list, err := el.Selectors()
+	el.e(err)
+	return list
+}
+
+// MustScrape is similar to [Element.Scrape].
+func (el *Element) MustScrape(data interface{}, hooks ...scrape.Hooks) *Element {
+	el.e(el.Scrape(data, hooks...))
+	return el
+}
+
 // MustHTML is similar to [Element.HTML].
 func (el *Element) MustHTML() string {
 	s, err := el.HTML()
@@ -913,6 +1451,13 @@ func (el *Element) MustVisible() bool {
 	return v
 }
 
+// MustVisibleOpaque is similar to [Element.VisibleOpaque].
+func (el *Element) MustVisibleOpaque() bool {
+	v, err := el.VisibleOpaque()
+	el.e(err)
+	return v
+}
+
 // MustWaitLoad is similar to [Element.WaitLoad].
 func (el *Element) MustWaitLoad() *Element {
 	el.e(el.WaitLoad())
@@ -931,6 +1476,19 @@ func (el *Element) MustWait(js string, params ...interface{}) *Element {
 	return el
 }
 
+// MustComputedStyle is similar to [Element.ComputedStyle].
+func (el *Element) MustComputedStyle(props ...string) map[string]string {
+	style, err := el.ComputedStyle(props...)
+	el.e(err)
+	return style
+}
+
+// MustWaitStyle is similar to [Element.WaitStyle].
+func (el *Element) MustWaitStyle(prop, value string) *Element {
+	el.e(el.WaitStyle(prop, value))
+	return el
+}
+
 // MustWaitVisible is similar to [Element.WaitVisible].
 func (el *Element) MustWaitVisible() *Element {
 	el.e(el.WaitVisible())
@@ -962,6 +1520,32 @@ func (el *Element) MustShape() *proto.DOMGetContentQuadsResult {
 	return shape
 }
 
+// MustCanvasImage is similar to [Element.CanvasImage].
+func (el *Element) MustCanvasImage() image.Image {
+	img, err := el.CanvasImage()
+	el.e(err)
+	return img
+}
+
+// MustCanvasClickAt is similar to [Element.CanvasClickAt].
+func (el *Element) MustCanvasClickAt(x, y float64) *Element {
+	el.e(el.CanvasClickAt(x, y))
+	return el
+}
+
+// MustCanvasColorAt is similar to [Element.CanvasColorAt].
+func (el *Element) MustCanvasColorAt(x, y int) color.NRGBA {
+	c, err := el.CanvasColorAt(x, y)
+	el.e(err)
+	return c
+}
+
+// MustCanvasExpectColorAt is similar to [Element.CanvasExpectColorAt].
+func (el *Element) MustCanvasExpectColorAt(x, y int, expected color.Color, tolerance uint8) *Element {
+	el.e(el.CanvasExpectColorAt(x, y, expected, tolerance))
+	return el
+}
+
 // MustCanvasToImage is similar to [Element.CanvasToImage].
 func (el *Element) MustCanvasToImage() []byte {
 	bin, err := el.CanvasToImage("", -1)
@@ -1043,6 +1627,47 @@ func (el *Element) MustElementX(xpath string) *Element {
 	return el
 }
 
+// MustElementByLabel is similar to [Element.ElementByLabel].
+func (el *Element) MustElementByLabel(text string) *Element {
+	sub, err := el.ElementByLabel(text)
+	el.e(err)
+	return sub
+}
+
+// MustElementByPlaceholder is similar to [Element.ElementByPlaceholder].
+func (el *Element) MustElementByPlaceholder(text string) *Element {
+	sub, err := el.ElementByPlaceholder(text)
+	el.e(err)
+	return sub
+}
+
+// MustElementByTestID is similar to [Element.ElementByTestID].
+func (el *Element) MustElementByTestID(id string) *Element {
+	sub, err := el.ElementByTestID(id)
+	el.e(err)
+	return sub
+}
+
+// MustElementNth is similar to [Element.ElementNth].
+func (el *Element) MustElementNth(selector string, n int) *Element {
+	sub, err := el.ElementNth(selector, n)
+	el.e(err)
+	return sub
+}
+
+// MustElementsFilter is similar to [Element.ElementsFilter].
+func (el *Element) MustElementsFilter(selector, jsPredicate string) Elements {
+	list, err := el.ElementsFilter(selector, jsPredicate)
+	el.e(err)
+	return list
+}
+
+// MustEachElement is similar to [Element.EachElement].
+func (el *Element) MustEachElement(selector string, fn func(*Element) (stop bool)) *Element {
+	el.e(el.EachElement(selector, fn))
+	return el
+}
+
 // MustElementByJS is similar to [Element.ElementByJS].
 func (el *Element) MustElementByJS(js string, params ...interface{}) *Element {
 	el, err := el.ElementByJS(Eval(js, params...))
@@ -1125,7 +1750,7 @@ func (r *HijackRouter) MustStop() {
 
 // MustLoadResponse is similar to [Hijack.LoadResponse].
 func (h *Hijack) MustLoadResponse() {
-	h.browser.e(h.LoadResponse(http.DefaultClient, true))
+	h.browser.e(h.LoadResponse(nil, true))
 }
 
 // MustEqual is similar to [Element.Equal].
@@ -1147,3 +1772,241 @@ func (el *Element) MustGetXPath(optimized bool) string {
 	el.e(err)
 	return xpath
 }
+
+// MustPages is similar to [Window.Pages].
+func (w *Window) MustPages() Pages {
+	list, err := w.Pages()
+	w.browser.e(err)
+	return list
+}
+
+// MustBounds is similar to [Window.Bounds].
+func (w *Window) MustBounds() *proto.BrowserBounds {
+	bounds, err := w.Bounds()
+	w.browser.e(err)
+	return bounds
+}
+
+// MustSetBounds is similar to [Window.SetBounds].
+func (w *Window) MustSetBounds(bounds *proto.BrowserBounds) *Window {
+	w.browser.e(w.SetBounds(bounds))
+	return w
+}
+
+// MustSetState is similar to [Window.SetState].
+func (w *Window) MustSetState(state proto.BrowserWindowState) *Window {
+	w.browser.e(w.SetState(state))
+	return w
+}
+
+// MustMovePage is similar to [Window.MovePage].
+func (w *Window) MustMovePage(p *Page) *Page {
+	np, err := w.MovePage(p)
+	w.browser.e(err)
+	return np
+}
+
+// MustFollowPagination is similar to [Page.FollowPagination].
+func (p *Page) MustFollowPagination(nextSelector string, handler func(p *Page, n int), maxPages int) *Page {
+	p.e(p.FollowPagination(nextSelector, func(p *Page, n int) error {
+		handler(p, n)
+		return nil
+	}, maxPages))
+	return p
+}
+
+// MustCrawlSitemap is similar to [Browser.CrawlSitemap].
+func (b *Browser) MustCrawlSitemap(sitemapURL string, handler func(p *Page, u SitemapURL)) *Browser {
+	b.e(b.CrawlSitemap(sitemapURL, func(p *Page, u SitemapURL) error {
+		handler(p, u)
+		return nil
+	}))
+	return b
+}
+
+// MustScrollUntil is similar to [Page.ScrollUntil].
+func (p *Page) MustScrollUntil(opts *ScrollUntilOptions) *ScrollResult {
+	res, err := p.ScrollUntil(opts)
+	p.e(err)
+	return res
+}
+
+// MustDetectChallenge is similar to [Page.DetectChallenge].
+func (p *Page) MustDetectChallenge() *Challenge {
+	c, err := p.DetectChallenge()
+	p.e(err)
+	return c
+}
+
+// MustFindCaptcha is similar to [Page.FindCaptcha].
+func (p *Page) MustFindCaptcha() *captcha.Task {
+	t, err := p.FindCaptcha()
+	p.e(err)
+	return t
+}
+
+// MustSubmitCaptcha is similar to [Page.SubmitCaptcha].
+func (p *Page) MustSubmitCaptcha(t *captcha.Task, token string) *Page {
+	p.e(p.SubmitCaptcha(t, token))
+	return p
+}
+
+// MustSolveCaptcha is similar to [Page.SolveCaptcha].
+func (p *Page) MustSolveCaptcha(solver captcha.Solver) *captcha.Task {
+	t, err := p.SolveCaptcha(solver)
+	p.e(err)
+	return t
+}
+
+// MustDelayResponse is similar to [HijackRouter.DelayResponse].
+func (r *HijackRouter) MustDelayResponse(pattern string, d time.Duration) *HijackRouter {
+	r.browser.e(r.DelayResponse(pattern, d))
+	return r
+}
+
+// MustFailWith is similar to [HijackRouter.FailWith].
+func (r *HijackRouter) MustFailWith(pattern string, reason proto.NetworkErrorReason) *HijackRouter {
+	r.browser.e(r.FailWith(pattern, reason))
+	return r
+}
+
+// MustCorruptBody is similar to [HijackRouter.CorruptBody].
+func (r *HijackRouter) MustCorruptBody(pattern string, rate float64) *HijackRouter {
+	r.browser.e(r.CorruptBody(pattern, rate))
+	return r
+}
+
+// MustSetRefererPolicy is similar to [Browser.SetRefererPolicy].
+func (b *Browser) MustSetRefererPolicy(policy *RefererPolicy) (cleanup func()) {
+	cleanup, err := b.SetRefererPolicy(policy)
+	b.e(err)
+	return
+}
+
+// MustIgnoreCertErrors is similar to [Page.IgnoreCertErrors].
+func (p *Page) MustIgnoreCertErrors(patterns ...string) *CertErrorPolicy {
+	c, err := p.IgnoreCertErrors(patterns...)
+	p.e(err)
+	return c
+}
+
+// MustSetClientCertificate is similar to [Page.SetClientCertificate].
+func (p *Page) MustSetClientCertificate(pattern string, cert tls.Certificate, rootCAs *x509.CertPool) (cleanup func()) {
+	cleanup, err := p.SetClientCertificate(pattern, cert, rootCAs)
+	p.e(err)
+	return
+}
+
+// MustClearData is similar to [Browser.ClearData].
+func (b *Browser) MustClearData(origin string, types ...proto.StorageStorageType) *Browser {
+	b.e(b.ClearData(origin, types...))
+	return b
+}
+
+// MustStorageUsage is similar to [Browser.StorageUsage].
+func (b *Browser) MustStorageUsage(origin string) *proto.StorageGetUsageAndQuotaResult {
+	res, err := b.StorageUsage(origin)
+	b.e(err)
+	return res
+}
+
+// MustSetCacheDisabled is similar to [Page.SetCacheDisabled].
+func (p *Page) MustSetCacheDisabled(disabled bool) *Page {
+	p.e(p.SetCacheDisabled(disabled))
+	return p
+}
+
+// MustClearHTTPCache is similar to [Browser.ClearHTTPCache].
+func (b *Browser) MustClearHTTPCache() *Browser {
+	b.e(b.ClearHTTPCache())
+	return b
+}
+
+// MustWarmCache is similar to [Page.WarmCache].
+func (p *Page) MustWarmCache(urls ...string) *Page {
+	p.e(p.WarmCache(urls...))
+	return p
+}
+
+// MustRun is similar to [LoginFlow.Run].
+func (f *LoginFlow) MustRun() *Page {
+	f.page.e(f.Run())
+	return f.page
+}
+
+// MustTOTPCode is similar to [TOTPCode].
+func MustTOTPCode(secret string, t time.Time) string {
+	code, err := TOTPCode(secret, t)
+	utils.E(err)
+	return code
+}
+
+// MustDiagnose is similar to [Element.Diagnose].
+func (el *Element) MustDiagnose() *InteractableDiagnosis {
+	d, err := el.Diagnose()
+	el.e(err)
+	return d
+}
+
+// MustForceClick is similar to [Element.ForceClick].
+func (el *Element) MustForceClick() *Element {
+	el.e(el.ForceClick())
+	return el
+}
+
+// MustWebGLInfo is similar to [Page.WebGLInfo].
+func (p *Page) MustWebGLInfo() *WebGLInfo {
+	info, err := p.WebGLInfo()
+	p.e(err)
+	return info
+}
+
+// MustPlay is similar to [Media.Play].
+func (m *Media) MustPlay() *Media {
+	m.el.e(m.Play())
+	return m
+}
+
+// MustPause is similar to [Media.Pause].
+func (m *Media) MustPause() *Media {
+	m.el.e(m.Pause())
+	return m
+}
+
+// MustSeek is similar to [Media.Seek].
+func (m *Media) MustSeek(t time.Duration) *Media {
+	m.el.e(m.Seek(t))
+	return m
+}
+
+// MustSetMuted is similar to [Media.SetMuted].
+func (m *Media) MustSetMuted(muted bool) *Media {
+	m.el.e(m.SetMuted(muted))
+	return m
+}
+
+// MustState is similar to [Media.State].
+func (m *Media) MustState() *MediaState {
+	s, err := m.State()
+	m.el.e(err)
+	return s
+}
+
+// MustWaitPlaying is similar to [Media.WaitPlaying].
+func (m *Media) MustWaitPlaying() *Media {
+	m.el.e(m.WaitPlaying())
+	return m
+}
+
+// MustWaitEnded is similar to [Media.WaitEnded].
+func (m *Media) MustWaitEnded() *Media {
+	m.el.e(m.WaitEnded())
+	return m
+}
+
+// MustFrame is similar to [Media.Frame].
+func (m *Media) MustFrame() []byte {
+	bin, err := m.Frame()
+	m.el.e(err)
+	return bin
+}
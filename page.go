@@ -1,12 +1,18 @@
 package rod
 
 import (
+	"bytes"
 	"context"
 	"crypto/md5"
-	"encoding/base64"
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
@@ -15,6 +21,7 @@ import (
 	"github.com/Fromsko/rodPro/lib/js"
 	"github.com/Fromsko/rodPro/lib/proto"
 	"github.com/Fromsko/rodPro/lib/utils"
+	"github.com/Fromsko/rodPro/lib/visual"
 	"github.com/ysmood/goob"
 	"github.com/ysmood/got/lib/lcs"
 	"github.com/ysmood/gson"
@@ -56,13 +63,19 @@ type Page struct {
 
 	sleeper func() utils.Sleeper
 
-	browser *Browser
-	event   *goob.Observable
+	trace *bool // overrides the trace setting of the browser when not nil
+
+	browser     *Browser
+	event       *goob.Observable
+	eventFilter []string // see [Page.FilterEvents]
+	polyfill    *selectorPolyfillState
+	challenged  *challengeLoaders
 
 	// devices
 	Mouse    *Mouse
 	Keyboard *Keyboard
 	Touch    *Touch
+	Pen      *Pen
 
 	element *Element // iframe only
 
@@ -70,6 +83,14 @@ type Page struct {
 	jsCtxID     *proto.RuntimeRemoteObjectID // use pointer so that page clones can share the change
 	helpersLock *sync.Mutex
 	helpers     map[proto.RuntimeRemoteObjectID]map[string]proto.RuntimeRemoteObjectID
+
+	scripts *newDocumentScripts
+
+	handles *handleRegistry
+
+	console *consoleLog
+
+	scopedHeaders *scopedHeaders
 }
 
 // String interface
@@ -168,17 +189,45 @@ func (p *Page) SetBlockedURLs(urls []string) error {
 
 // Navigate to the url. If the url is empty, "about:blank" will be used.
 // It will return immediately after the server responds the http header.
-func (p *Page) Navigate(url string) error {
+func (p *Page) Navigate(url string) (err error) {
+	return p.navigate(url, "")
+}
+
+// NavigateWithReferer is similar to [Page.Navigate], but the navigation is sent with the referer,
+// the full referer is sent even if the url is cross-origin.
+func (p *Page) NavigateWithReferer(url, referer string) (err error) {
+	return p.navigate(url, referer)
+}
+
+func (p *Page) navigate(url, referer string) (err error) {
 	if url == "" {
 		url = "about:blank"
 	}
 
+	p, end := p.startSpan("Navigate", Attribute{AttrURL, url})
+	defer end(&err)
+
+	err = p.browser.polite.wait(p.ctx, url)
+	if err != nil {
+		return err
+	}
+
+	p.browser.trySlowMotion("navigation.navigate")
+
 	// try to stop loading
 	_ = p.StopLoading()
 
-	res, err := proto.PageNavigate{URL: url}.Call(p)
+	p.browser.metrics.navigated()
+
+	req := proto.PageNavigate{URL: url}
+	if referer != "" {
+		req.Referrer = referer
+		req.ReferrerPolicy = proto.PageReferrerPolicyUnsafeURL
+	}
+
+	res, err := req.Call(p)
 	if err != nil {
-		return err
+		return timeoutErr(p.ctx, err, "Navigate(`%s`)", url)
 	}
 	if res.ErrorText != "" {
 		return &ErrNavigation{res.ErrorText}
@@ -191,6 +240,8 @@ func (p *Page) Navigate(url string) error {
 
 // NavigateBack history.
 func (p *Page) NavigateBack() error {
+	p.browser.trySlowMotion("navigation.back")
+
 	// Not using cdp API because it doesn't work for iframe
 	_, err := p.Evaluate(Eval(`() => history.back()`).ByUser())
 	return err
@@ -198,6 +249,8 @@ func (p *Page) NavigateBack() error {
 
 // NavigateForward history.
 func (p *Page) NavigateForward() error {
+	p.browser.trySlowMotion("navigation.forward")
+
 	// Not using cdp API because it doesn't work for iframe
 	_, err := p.Evaluate(Eval(`() => history.forward()`).ByUser())
 	return err
@@ -205,6 +258,8 @@ func (p *Page) NavigateForward() error {
 
 // Reload page.
 func (p *Page) Reload() error {
+	p.browser.trySlowMotion("navigation.reload")
+
 	p, cancel := p.WithCancel()
 	defer cancel()
 
@@ -309,7 +364,10 @@ func (p *Page) Close() error {
 	success := true
 	ctx, cancel := context.WithCancel(p.ctx)
 	defer cancel()
-	messages := p.browser.Context(ctx).Event()
+	p.browser.demand(ctx, &eventDemand{sessionID: p.SessionID, methods: []string{
+		proto.PageJavascriptDialogClosed{}.ProtoEvent(),
+	}})
+	messages := p.browser.Context(ctx).events()
 
 	for {
 		err := proto.PageClose{}.Call(p)
@@ -422,18 +480,32 @@ func (p *Page) HandleFileDialog() (func([]string) error, error) {
 }
 
 // Screenshot captures the screenshot of current page.
+// The formats supported by the browser are png, jpeg, and webp, AVIF is not available in the protocol yet,
+// use the Quality and OptimizeForSpeed of the req to balance the size and speed.
 func (p *Page) Screenshot(fullPage bool, req *proto.PageCaptureScreenshot) ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+	_, err := p.ScreenshotTo(buf, fullPage, req)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ScreenshotTo is similar to [Page.Screenshot], but it decodes the image into w in a streaming way,
+// the base64 data of the response is never copied or materialized as a whole, which cuts the memory spike
+// of the huge full page screenshots. It returns the number of bytes written.
+func (p *Page) ScreenshotTo(w io.Writer, fullPage bool, req *proto.PageCaptureScreenshot) (int64, error) {
 	if req == nil {
 		req = &proto.PageCaptureScreenshot{}
 	}
 	if fullPage {
 		metrics, err := proto.PageGetLayoutMetrics{}.Call(p)
 		if err != nil {
-			return nil, err
+			return 0, err
 		}
 
 		if metrics.CSSContentSize == nil {
-			return nil, errors.New("failed to get css content size")
+			return 0, errors.New("failed to get css content size")
 		}
 
 		oldView := proto.EmulationSetDeviceMetricsOverride{}
@@ -444,7 +516,7 @@ func (p *Page) Screenshot(fullPage bool, req *proto.PageCaptureScreenshot) ([]by
 
 		err = p.SetViewport(&view)
 		if err != nil {
-			return nil, err
+			return 0, err
 		}
 
 		defer func() { // try to recover the viewport
@@ -457,11 +529,114 @@ func (p *Page) Screenshot(fullPage bool, req *proto.PageCaptureScreenshot) ([]by
 		}()
 	}
 
-	shot, err := req.Call(p)
+	raw, err := proto.CallRaw(p, req)
+	if err != nil {
+		return 0, err
+	}
+	return proto.DecodeBase64Field(raw, "data", w)
+}
+
+// ScreenshotElement captures the screenshot of the element that matches the css selector.
+// Unlike [Element.Screenshot], the clipping is done by the browser, so any format of [proto.PageCaptureScreenshotFormat],
+// such as webp, can be used, and the image will be in the device pixels of the current device scale factor.
+// The req is for the options like the format, quality, and optimizeForSpeed, its clip will be overridden.
+func (p *Page) ScreenshotElement(selector string, req *proto.PageCaptureScreenshot) ([]byte, error) {
+	el, err := p.Element(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	err = el.ScrollIntoView()
+	if err != nil {
+		return nil, err
+	}
+
+	shape, err := el.Shape()
+	if err != nil {
+		return nil, err
+	}
+
+	metrics, err := proto.PageGetLayoutMetrics{}.Call(p)
+	if err != nil {
+		return nil, err
+	}
+
+	clip := proto.PageCaptureScreenshot{}
+	if req != nil {
+		clip = *req
+	}
+
+	// the box is relative to the viewport, the clip is relative to the document
+	box := shape.Box()
+	clip.Clip = &proto.PageViewport{
+		X:      box.X + metrics.CSSVisualViewport.PageX,
+		Y:      box.Y + metrics.CSSVisualViewport.PageY,
+		Width:  box.Width,
+		Height: box.Height,
+		Scale:  1,
+	}
+	clip.CaptureBeyondViewport = true
+
+	return p.Screenshot(false, &clip)
+}
+
+// ScreenshotAndCompare captures the screenshot of the page and compares it with the baseline png file for
+// the visual regression testing. If the baseline doesn't exist, an error that wraps [os.ErrNotExist] is returned,
+// use [Page.SaveScreenshotBaseline] to create or update the baseline explicitly. If there are mismatched pixels,
+// the diff image will be saved next to the baseline with the ".diff.png" suffix.
+// If opts is nil, [visual.DefaultOptions] will be used.
+func (p *Page) ScreenshotAndCompare(baselinePath string, opts *visual.Options) (*visual.Result, error) {
+	baseline, err := os.ReadFile(baselinePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("the baseline doesn't exist, create it via Page.SaveScreenshotBaseline: %w", err)
+	} else if err != nil {
+		return nil, err
+	}
+
+	expected, _, err := image.Decode(bytes.NewReader(baseline))
+	if err != nil {
+		return nil, err
+	}
+
+	bin, err := p.Screenshot(false, &proto.PageCaptureScreenshot{Format: proto.PageCaptureScreenshotFormatPng})
+	if err != nil {
+		return nil, err
+	}
+
+	actual, err := png.Decode(bytes.NewReader(bin))
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := visual.Compare(expected, actual, opts)
 	if err != nil {
 		return nil, err
 	}
-	return shot.Data, nil
+
+	if res.Mismatched > 0 {
+		buf := bytes.NewBuffer(nil)
+		err = png.Encode(buf, res.Diff)
+		if err != nil {
+			return nil, err
+		}
+		diffPath := strings.TrimSuffix(baselinePath, filepath.Ext(baselinePath)) + ".diff.png"
+		err = utils.OutputFile(diffPath, buf.Bytes())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return res, nil
+}
+
+// SaveScreenshotBaseline captures the screenshot of the page as the baseline png file of
+// [Page.ScreenshotAndCompare], the existing baseline will be overwritten.
+func (p *Page) SaveScreenshotBaseline(baselinePath string) error {
+	bin, err := p.Screenshot(false, &proto.PageCaptureScreenshot{Format: proto.PageCaptureScreenshotFormatPng})
+	if err != nil {
+		return err
+	}
+	return utils.OutputFile(baselinePath, bin)
 }
 
 // CaptureDOMSnapshot Returns a document snapshot, including the full DOM tree of the root node
@@ -487,7 +662,9 @@ func (p *Page) CaptureDOMSnapshot() (domSnapshot *proto.DOMSnapshotCaptureSnapsh
 	return snapshot, nil
 }
 
-// PDF prints page as PDF
+// PDF prints page as PDF.
+// Use [Page.WaitPrintReady] before it to make sure the fonts and lazy-loaded images are ready,
+// or use [Page.PDFBuilder] for more options.
 func (p *Page) PDF(req *proto.PagePrintToPDF) (*StreamReader, error) {
 	req.TransferMode = proto.PagePrintToPDFTransferModeReturnAsStream
 	res, err := req.Call(p)
@@ -509,17 +686,7 @@ func (p *Page) GetResource(url string) ([]byte, error) {
 		return nil, err
 	}
 
-	data := res.Content
-
-	var bin []byte
-	if res.Base64Encoded {
-		bin, err = base64.StdEncoding.DecodeString(data)
-		utils.E(err)
-	} else {
-		bin = []byte(data)
-	}
-
-	return bin, nil
+	return res.Bytes()
 }
 
 // WaitOpen waits for the next new page opened by the current one
@@ -539,6 +706,50 @@ func (p *Page) WaitOpen() func() (*Page, error) {
 	}
 }
 
+// ExpectPopup runs the action and returns the page it opens, such as via window.open or a link with target=_blank.
+// The popup is returned after its initial navigation is loaded, so it's ready to use.
+func (p *Page) ExpectPopup(action func()) (*Page, error) {
+	var info *proto.TargetTargetInfo
+
+	b := p.browser.Context(p.ctx)
+	wait := b.EachEvent(func(e *proto.TargetTargetCreated) bool {
+		if e.TargetInfo.OpenerID != p.TargetID {
+			return false
+		}
+		info = e.TargetInfo
+		return true
+	})
+
+	action()
+
+	defer p.tryTrace(TraceTypeWait, "popup")()
+	wait()
+
+	// the wait also returns when the context is done
+	if info == nil {
+		err := p.ctx.Err()
+		if err == nil {
+			err = context.Canceled
+		}
+		return nil, timeoutErr(p.ctx, err, "ExpectPopup")
+	}
+
+	popup, err := b.PageFromTarget(info.TargetID)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.URL != "" && info.URL != "about:blank" {
+		// the initial about:blank document exists before the navigation to the url commits
+		err = popup.Wait(Eval(`() => location.href !== 'about:blank'`))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return popup, popup.WaitLoad()
+}
+
 // EachEvent of the specified event types, if any callback returns true the wait function will resolve,
 // The type of each callback is (? means optional):
 //
@@ -665,7 +876,7 @@ func (p *Page) WaitDOMStable(d time.Duration, diff float64) error {
 		select {
 		case <-t.C:
 		case <-p.ctx.Done():
-			return p.ctx.Err()
+			return timeoutErr(p.ctx, p.ctx.Err(), "WaitDOMStable(%v, %v)", d, diff)
 		}
 
 		currentDomSnapshot, err := p.CaptureDOMSnapshot()
@@ -717,6 +928,40 @@ func (p *Page) WaitIdle(timeout time.Duration) (err error) {
 	return err
 }
 
+// WaitIdleCPU waits until the main thread of the page has been quiet for d duration, it's useful for the apps
+// that keep hydrating or computing after the network is idle. The main thread is busy when there's a long task
+// reported by the PerformanceObserver, or a heartbeat timer of the page is delayed by more than 50ms.
+// Be careful, d is not the max wait timeout, use the [Page.Timeout] to set it.
+func (p *Page) WaitIdleCPU(d time.Duration) error {
+	defer p.tryTrace(TraceTypeWait, "idle-cpu")()
+
+	_, err := p.Evaluate(Eval(`d => new Promise(resolve => {
+		let last = performance.now()
+		let observer
+		try {
+			observer = new PerformanceObserver(list => {
+				for (const e of list.getEntries()) last = Math.max(last, e.startTime + e.duration)
+			})
+			observer.observe({ type: 'longtask', buffered: true })
+		} catch {}
+
+		const interval = 10
+		let expected = performance.now() + interval
+		const tick = () => {
+			const now = performance.now()
+			if (now - expected > 50) last = now
+			if (now - last >= d) {
+				if (observer) observer.disconnect()
+				return resolve()
+			}
+			expected = now + interval
+			setTimeout(tick, interval)
+		}
+		setTimeout(tick, interval)
+	})`, d.Milliseconds()).ByPromise())
+	return timeoutErr(p.ctx, err, "WaitIdleCPU(%v)", d)
+}
+
 // WaitRepaint waits until the next repaint.
 // Doc: https://developer.mozilla.org/en-US/docs/Web/API/window/requestAnimationFrame
 func (p *Page) WaitRepaint() error {
@@ -726,10 +971,15 @@ func (p *Page) WaitRepaint() error {
 }
 
 // WaitLoad waits for the `window.onload` event, it returns immediately if the event is already fired.
+// If [Browser.OnChallenge] is set, the loaded document will be inspected for the challenge pages.
 func (p *Page) WaitLoad() error {
 	defer p.tryTrace(TraceTypeWait, "load")()
+	p.browser.trySlowMotion("wait.load")
 	_, err := p.Evaluate(evalHelper(js.WaitLoad).ByPromise())
-	return err
+	if err != nil {
+		return timeoutErr(p.ctx, err, "WaitLoad()")
+	}
+	return p.tryChallenge()
 }
 
 // AddScriptTag to page. If url is empty, content will be used.
@@ -748,25 +998,33 @@ func (p *Page) AddStyleTag(url, content string) error {
 	return err
 }
 
+// InjectScriptTag to every document of the page, including iframes, the current document is not affected.
+// If url is empty, content will be used. The content is guaranteed to run before the scripts of the document,
+// the script of the url is loaded in parallel and runs before the other dynamically inserted scripts.
+// Call remove to stop injecting.
+func (p *Page) InjectScriptTag(url, content string) (remove func() error, err error) {
+	hash := md5.Sum([]byte(url + content))
+	id := hex.EncodeToString(hash[:])
+	code := fmt.Sprintf(`(%s)(%s, %s, %s)`, js.InjectScriptTag.Definition,
+		utils.MustToJSON(id), utils.MustToJSON(url), utils.MustToJSON(content))
+	return p.EvalOnNewDocument(code)
+}
+
 // EvalOnNewDocument Evaluates given script in every frame upon creation (before loading frame's scripts).
+// Use [Page.AddNewDocumentScript] if you want to manage the script via a name.
 func (p *Page) EvalOnNewDocument(js string) (remove func() error, err error) {
-	res, err := proto.PageAddScriptToEvaluateOnNewDocument{Source: js}.Call(p)
+	s, err := p.AddNewDocumentScript("", js)
 	if err != nil {
 		return
 	}
-
-	remove = func() error {
-		return proto.PageRemoveScriptToEvaluateOnNewDocument{
-			Identifier: res.Identifier,
-		}.Call(p)
-	}
-
-	return
+	return s.Remove, nil
 }
 
 // Wait until the js returns true
 func (p *Page) Wait(opts *EvalOptions) error {
-	return utils.Retry(p.ctx, p.sleeper(), func() (bool, error) {
+	p.browser.trySlowMotion("wait.wait")
+
+	err := utils.Retry(p.ctx, p.sleeper(), func() (bool, error) {
 		res, err := p.Evaluate(opts)
 		if err != nil {
 			return true, err
@@ -774,6 +1032,7 @@ func (p *Page) Wait(opts *EvalOptions) error {
 
 		return res.Value.Bool(), nil
 	})
+	return timeoutErr(p.ctx, err, "Wait(%s)", strings.TrimSpace(opts.String()))
 }
 
 // WaitElementsMoreThan waits until there are more than num elements that match the selector.
@@ -817,6 +1076,8 @@ func (p *Page) ElementFromObject(obj *proto.RuntimeRemoteObject) (*Element, erro
 		p = &clone
 	}
 
+	p.trackHandle(obj.ObjectID)
+
 	return &Element{
 		e:       p.e,
 		ctx:     p.ctx,
@@ -873,6 +1134,7 @@ func (p *Page) ElementFromPoint(x, y int) (*Element, error) {
 // When a page is closed or reloaded, all remote objects will be released automatically.
 // It's useful if the page never closes or reloads.
 func (p *Page) Release(obj *proto.RuntimeRemoteObject) error {
+	p.untrackHandle(obj.ObjectID)
 	err := proto.RuntimeReleaseObject{ObjectID: obj.ObjectID}.Call(p)
 	return err
 }
@@ -882,36 +1144,41 @@ func (p *Page) Call(ctx context.Context, sessionID, methodName string, params in
 	return p.browser.Call(ctx, sessionID, methodName, params)
 }
 
-// Event of the page
-func (p *Page) Event() <-chan *Message {
-	dst := make(chan *Message)
-	s := p.event.Subscribe(p.ctx)
+// CallRaw calls the cdp method with the session and context of the page, so the timeout and cancellation
+// of the page are honored. The response will be decoded into the result if it's not nil.
+// It's useful to use the protocol methods that are not in the lib/proto yet.
+// If the browser doesn't have the method, [ErrUnsupportedCapability] will be returned.
+func (p *Page) CallRaw(method string, params, result interface{}) error {
+	return callRaw(p.ctx, p, p.SessionID, method, params, result)
+}
 
-	go func() {
-		defer close(dst)
-		for {
-			select {
-			case <-p.ctx.Done():
-				return
-			case msg, ok := <-s:
-				if !ok {
-					return
-				}
-				select {
-				case <-p.ctx.Done():
-					return
-				case dst <- msg.(*Message):
-				}
-			}
-		}
-	}()
+// FilterEvents returns a clone of the page whose [Page.Event] subscriptions only receive the events of the methods,
+// the other subscribers of the page aren't affected, such as a consumer that only needs the navigation events
+// won't pay the cost of every Network event. A method can be a domain wildcard, such as "Network.*".
+// Call it without methods to get a clone without the filter.
+// The Target, lifecycle and navigation events always pass the filter, because rod relies on them.
+// When all the subscribers of the page have told what they need, the other events of the page are dropped by the
+// cdp client before they are decoded, the [Browser.Event] and [Browser.EventReplay] disable it, because they
+// need all the events.
+// To stop the browser from sending the events at all, disable the domains you don't need.
+func (p *Page) FilterEvents(methods ...string) *Page {
+	newObj := p.Fork()
+	newObj.eventFilter = nil
+	if len(methods) > 0 {
+		newObj.eventFilter = append([]string{}, methods...)
+	}
+	return newObj
+}
 
-	return dst
+// Event of the page
+func (p *Page) Event() <-chan *Message {
+	p.browser.demand(p.ctx, &eventDemand{sessionID: p.SessionID, methods: p.eventFilter, filter: p.eventFilter != nil})
+	return p.browser.forwardEvents(p.ctx, p.event.Subscribe(p.ctx), p.eventFilter)
 }
 
 func (p *Page) initEvents() {
 	p.event = goob.New(p.ctx)
-	event := p.browser.Context(p.ctx).Event()
+	event := p.browser.Context(p.ctx).events()
 
 	go func() {
 		for msg := range event {
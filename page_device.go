@@ -0,0 +1,216 @@
+// This file serves for the FedCM dialogs, the device emulations, and the media devices of Page.
+
+package rod
+
+import (
+	"fmt"
+	"net/url"
+	"sync"
+
+	"github.com/Fromsko/rodPro/lib/proto"
+	"github.com/Fromsko/rodPro/lib/utils"
+	"github.com/ysmood/gson"
+)
+
+// FedCMDialog is a FedCM (Federated Credential Management) dialog shown by the browser,
+// such as the account chooser of navigator.credentials.get({ identity }).
+type FedCMDialog struct {
+	*proto.FedCmDialogShown
+
+	page    *Page
+	restore func()
+}
+
+// HandleFedCM returns a function that waits for the next FedCM dialog.
+// Because the dialog will block the credentials request, usually you have to trigger it in another goroutine.
+// The FedCm domain enabled for the wait is restored when the dialog is handled or closed.
+// For example:
+//
+//	wait := page.MustHandleFedCM()
+//	go page.MustElement("#sign-in").MustClick()
+//	wait().MustSelectAccount(0)
+func (p *Page) HandleFedCM() (wait func() *FedCMDialog) {
+	disable := p.EnableDomain(&proto.FedCmEnable{DisableRejectionDelay: true})
+	once := sync.Once{}
+	restore := func() { once.Do(disable) }
+
+	var e proto.FedCmDialogShown
+	w := p.WaitEvent(&e)
+
+	return func() *FedCMDialog {
+		w()
+
+		if e.DialogID == "" {
+			// the page is done before the dialog shows
+			restore()
+		} else {
+			// the dialog may be closed without being handled, such as by an aborted request
+			closed := p.EachEvent(func(c *proto.FedCmDialogClosed) bool {
+				return c.DialogID == e.DialogID
+			})
+			go func() {
+				closed()
+				restore()
+			}()
+		}
+
+		return &FedCMDialog{FedCmDialogShown: &e, page: p, restore: restore}
+	}
+}
+
+// SelectAccount selects the account at the index of [FedCMDialog.Accounts].
+func (d *FedCMDialog) SelectAccount(index int) error {
+	defer d.restore()
+	return proto.FedCmSelectAccount{DialogID: d.DialogID, AccountIndex: index}.Call(d.page)
+}
+
+// ClickButton clicks the button of the dialog, such as the "Continue" button of the
+// [proto.FedCmDialogTypeConfirmIdpLogin] dialog.
+func (d *FedCMDialog) ClickButton(button proto.FedCmDialogButton) error {
+	defer d.restore()
+	return proto.FedCmClickDialogButton{DialogID: d.DialogID, DialogButton: button}.Call(d.page)
+}
+
+// Dismiss the dialog, the credentials request will be rejected.
+func (d *FedCMDialog) Dismiss() error {
+	defer d.restore()
+	return proto.FedCmDismissDialog{DialogID: d.DialogID}.Call(d.page)
+}
+
+// EmulateBluetooth enables the Web Bluetooth emulation with the state of the simulated adapter,
+// so that navigator.bluetooth can be tested without real devices.
+// Use empty state to disable the emulation.
+func (p *Page) EmulateBluetooth(state proto.BluetoothEmulationCentralState) error {
+	if state == "" {
+		return proto.BluetoothEmulationDisable{}.Call(p)
+	}
+	return proto.BluetoothEmulationEnable{State: state, LeSupported: true}.Call(p)
+}
+
+// AddBluetoothDevice simulates a peripheral that has already been connected to the system,
+// call [Page.EmulateBluetooth] before it.
+func (p *Page) AddBluetoothDevice(address, name string, serviceUUIDs ...string) error {
+	if serviceUUIDs == nil {
+		serviceUUIDs = []string{}
+	}
+	return proto.BluetoothEmulationSimulatePreconnectedPeripheral{
+		Address:           address,
+		Name:              name,
+		ManufacturerData:  []*proto.BluetoothEmulationManufacturerData{},
+		KnownServiceUuids: serviceUUIDs,
+	}.Call(p)
+}
+
+// AdvertiseBluetoothDevice simulates an advertisement packet received by the adapter,
+// call [Page.EmulateBluetooth] before it.
+func (p *Page) AdvertiseBluetoothDevice(entry *proto.BluetoothEmulationScanEntry) error {
+	return proto.BluetoothEmulationSimulateAdvertisement{Entry: entry}.Call(p)
+}
+
+// HandleDevicePrompt returns functions to wait for the next device chooser prompt, such as the one
+// of navigator.bluetooth.requestDevice, and to handle it. Use empty id to cancel the prompt.
+// For example:
+//
+//	wait, handle := page.MustHandleDevicePrompt()
+//	go page.MustElement("#connect").MustClick()
+//	e := wait()
+//	handle(e.Devices[0].ID)
+func (p *Page) HandleDevicePrompt() (
+	wait func() *proto.DeviceAccessDeviceRequestPrompted,
+	handle func(proto.DeviceAccessDeviceID) error,
+) {
+	restore := p.EnableDomain(&proto.DeviceAccessEnable{})
+
+	var e proto.DeviceAccessDeviceRequestPrompted
+	w := p.WaitEvent(&e)
+
+	return func() *proto.DeviceAccessDeviceRequestPrompted {
+			w()
+			return &e
+		}, func(id proto.DeviceAccessDeviceID) error {
+			defer restore()
+			if id == "" {
+				return proto.DeviceAccessCancelPrompt{ID: e.ID}.Call(p)
+			}
+			return proto.DeviceAccessSelectPrompt{ID: e.ID, DeviceID: id}.Call(p)
+		}
+}
+
+// GrantMedia grants the camera and microphone permissions to the origin of the page,
+// so that getUserMedia won't be blocked by the permission prompt.
+// To feed fake video and audio, launch the browser with the FakeMedia of the launcher.
+func (p *Page) GrantMedia() error {
+	info, err := p.Info()
+	if err != nil {
+		return err
+	}
+
+	origin := ""
+	if u, err := url.Parse(info.URL); err == nil && u.Host != "" {
+		origin = u.Scheme + "://" + u.Host
+	}
+
+	return proto.BrowserGrantPermissions{
+		Permissions: []proto.BrowserPermissionType{
+			proto.BrowserPermissionTypeVideoCapture,
+			proto.BrowserPermissionTypeAudioCapture,
+		},
+		Origin:           origin,
+		BrowserContextID: p.browser.BrowserContextID,
+	}.Call(p.browser)
+}
+
+// RecordMediaRequests records the constraints of the getUserMedia calls of the page, the recording survives reloads.
+// Call list to get the constraints recorded so far, such as {"video":{"width":1280},"audio":true}.
+// Call stop to stop the recording.
+func (p *Page) RecordMediaRequests() (list func() []gson.JSON, stop func() error, err error) {
+	lock := sync.Mutex{}
+	records := []gson.JSON{}
+
+	name := "_" + utils.RandString(8)
+
+	stopExpose, err := p.Expose(name, func(constraints gson.JSON) (interface{}, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		records = append(records, constraints)
+		return nil, nil
+	})
+	if err != nil {
+		return
+	}
+
+	wrap := `(name) => {
+		const md = navigator.mediaDevices
+		if (!md || md[name]) return
+		const get = md.getUserMedia.bind(md)
+		md[name] = true
+		md.getUserMedia = (constraints) => {
+			window[name](constraints)
+			return get(constraints)
+		}
+	}`
+
+	_, err = p.Evaluate(Eval(wrap, name))
+	if err != nil {
+		_ = stopExpose()
+		return
+	}
+
+	remove, err := p.EvalOnNewDocument(fmt.Sprintf(`(%s)("%s")`, wrap, name))
+	if err != nil {
+		_ = stopExpose()
+		return
+	}
+
+	return func() []gson.JSON {
+			lock.Lock()
+			defer lock.Unlock()
+			return append([]gson.JSON{}, records...)
+		}, func() error {
+			err := remove()
+			if err != nil {
+				return err
+			}
+			return stopExpose()
+		}, nil
+}
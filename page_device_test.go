@@ -0,0 +1,97 @@
+package rod_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/Fromsko/rodPro/lib/proto"
+	"github.com/Fromsko/rodPro/lib/utils"
+	"github.com/ysmood/gson"
+)
+
+func TestEmulateBluetooth(t *testing.T) {
+	g := setup(t)
+
+	p := g.newPage()
+
+	calls := map[string]gson.JSON{}
+	g.mc.setCall(func(_ context.Context, _, method string, params interface{}) ([]byte, error) {
+		b, err := json.Marshal(params)
+		g.E(err)
+		calls[method] = gson.New(b)
+		return []byte("{}"), nil
+	})
+	defer g.mc.resetCall()
+
+	p.MustEmulateBluetooth(proto.BluetoothEmulationCentralStatePoweredOn).
+		MustAddBluetoothDevice("09:09:09:09:09:09", "heart", "heart_rate").
+		MustAdvertiseBluetoothDevice(&proto.BluetoothEmulationScanEntry{
+			DeviceAddress: "08:08:08:08:08:08",
+			Rssi:          -10,
+			ScanRecord:    &proto.BluetoothEmulationScanRecord{Name: "beacon"},
+		}).
+		MustEmulateBluetooth("")
+
+	g.Eq(calls["BluetoothEmulation.enable"].Get("state").Str(), "powered-on")
+	g.True(calls["BluetoothEmulation.enable"].Get("leSupported").Bool())
+	g.Eq(calls["BluetoothEmulation.simulatePreconnectedPeripheral"].Get("knownServiceUuids.0").Str(), "heart_rate")
+	g.Eq(calls["BluetoothEmulation.simulateAdvertisement"].Get("entry.scanRecord.name").Str(), "beacon")
+	g.Has(calls, "BluetoothEmulation.disable")
+}
+
+func TestHandleDevicePrompt(t *testing.T) {
+	g := setup(t)
+
+	p := g.newPage()
+
+	_, handle := p.MustHandleDevicePrompt()
+
+	calls := []string{}
+	g.mc.setCall(func(_ context.Context, _, method string, _ interface{}) ([]byte, error) {
+		calls = append(calls, method)
+		return []byte("{}"), nil
+	})
+	defer g.mc.resetCall()
+
+	handle("device")
+	g.Eq(calls[0], "DeviceAccess.selectPrompt")
+
+	_, h := p.HandleDevicePrompt()
+	g.E(h(""))
+	g.Has(calls, "DeviceAccess.cancelPrompt")
+}
+
+func TestMediaRequests(t *testing.T) {
+	g := setup(t)
+
+	s := g.Serve()
+	s.Route("/", ".html", `<html></html>`)
+
+	p := g.newPage(s.URL()).MustGrantMedia()
+
+	list, stop := p.MustRecordMediaRequests()
+	defer stop()
+
+	p.MustEval(`() => navigator.mediaDevices.getUserMedia({ video: { width: 640 }, audio: true }).catch(() => {})`)
+
+	for len(list()) == 0 {
+		utils.Sleep(0.01)
+	}
+
+	g.Eq(list()[0].Get("video.width").Int(), 640)
+	g.True(list()[0].Get("audio").Bool())
+
+	p.MustReload().MustWaitLoad()
+	p.MustEval(`() => navigator.mediaDevices.getUserMedia({ audio: true }).catch(() => {})`)
+	for len(list()) < 2 {
+		utils.Sleep(0.01)
+	}
+
+	g.mc.stubErr(1, proto.TargetGetTargetInfo{})
+	g.Err(p.GrantMedia())
+
+	g.mc.stubErr(2, proto.PageAddScriptToEvaluateOnNewDocument{})
+	_, _, err := p.RecordMediaRequests()
+	g.Err(err)
+}
@@ -3,9 +3,11 @@
 package rod
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/Fromsko/rodPro/lib/cdp"
@@ -39,6 +41,14 @@ type EvalOptions struct {
 
 	// Whether execution should be treated as initiated by user in the UI.
 	UserGesture bool
+
+	// If enabled, when the context is canceled or its deadline is exceeded before the eval returns,
+	// the running js of the page will be terminated via Runtime.terminateExecution,
+	// instead of leaving it running in the renderer.
+	TerminateOnCancel bool
+
+	// run in the world of [Page.PolyfillSelectors] if it's installed
+	polyfill bool
 }
 
 // Eval creates a [EvalOptions] with ByValue set to true.
@@ -50,6 +60,8 @@ func Eval(js string, args ...interface{}) *EvalOptions {
 		JS:           js,
 		JSArgs:       args,
 		UserGesture:  false,
+
+		TerminateOnCancel: false,
 	}
 }
 
@@ -108,13 +120,24 @@ func (e *EvalOptions) ByPromise() *EvalOptions {
 	return e
 }
 
+// Interruptible enables TerminateOnCancel.
+func (e *EvalOptions) Interruptible() *EvalOptions {
+	e.TerminateOnCancel = true
+	return e
+}
+
 func (e *EvalOptions) formatToJSFunc() string {
 	js := strings.Trim(e.JS, "\t\n\v\f\r ;")
 	return fmt.Sprintf(`function() { return (%s).apply(this, arguments) }`, js)
 }
 
 // Eval is a shortcut for [Page.Evaluate] with AwaitPromise, ByValue set to true.
-func (p *Page) Eval(js string, args ...interface{}) (*proto.RuntimeRemoteObject, error) {
+func (p *Page) Eval(js string, args ...interface{}) (res *proto.RuntimeRemoteObject, err error) {
+	p, end := p.startSpan("Eval")
+	defer end(&err)
+
+	p.browser.trySlowMotion("eval.eval")
+
 	return p.Evaluate(Eval(js, args...).ByPromise())
 }
 
@@ -146,6 +169,10 @@ func (p *Page) Evaluate(opts *EvalOptions) (res *proto.RuntimeRemoteObject, err
 }
 
 func (p *Page) evaluate(opts *EvalOptions) (*proto.RuntimeRemoteObject, error) {
+	if opts.polyfill && p.polyfill.isEnabled() {
+		return p.evaluateInWorld(opts)
+	}
+
 	args, err := p.formatArgs(opts)
 	if err != nil {
 		return nil, err
@@ -170,6 +197,9 @@ func (p *Page) evaluate(opts *EvalOptions) (*proto.RuntimeRemoteObject, error) {
 
 	res, err := req.Call(p)
 	if err != nil {
+		if opts.TerminateOnCancel && p.ctx.Err() != nil {
+			p.terminateExecution()
+		}
 		return nil, err
 	}
 
@@ -180,6 +210,15 @@ func (p *Page) evaluate(opts *EvalOptions) (*proto.RuntimeRemoteObject, error) {
 	return res.Result, nil
 }
 
+// terminateExecution stops the js that is currently running on the page. It doesn't use the context of the page,
+// because the context is already done when we need to call it.
+func (p *Page) terminateExecution() {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_ = proto.RuntimeTerminateExecution{}.Call(p.Context(ctx))
+}
+
 // Expose fn to the page's window object with the name. The exposure survives reloads.
 // Call stop to unbind the fn.
 func (p *Page) Expose(name string, fn func(gson.JSON) (interface{}, error)) (stop func() error, err error) {
@@ -202,9 +241,11 @@ func (p *Page) Expose(name string, fn func(gson.JSON) (interface{}, error)) (sto
 	}
 
 	p, cancel := p.WithCancel()
+	p.scripts.addBinding(bind)
 
 	stop = func() error {
 		defer cancel()
+		p.scripts.removeBinding(bind)
 		err := remove()
 		if err != nil {
 			return err
@@ -250,6 +291,11 @@ func (p *Page) ensureJSHelper(fn *js.Function) (proto.RuntimeRemoteObjectID, err
 		return "", err
 	}
 
+	return p.ensureJSHelperIn(jsCtxID, fn)
+}
+
+// ensureJSHelperIn injects the helper into the js context of the window object jsCtxID
+func (p *Page) ensureJSHelperIn(jsCtxID proto.RuntimeRemoteObjectID, fn *js.Function) (proto.RuntimeRemoteObjectID, error) {
 	fnID, has := p.getHelper(jsCtxID, js.Functions.Name)
 	if !has {
 		res, err := proto.RuntimeCallFunctionOn{
@@ -266,7 +312,7 @@ func (p *Page) ensureJSHelper(fn *js.Function) (proto.RuntimeRemoteObjectID, err
 	id, has := p.getHelper(jsCtxID, fn.Name)
 	if !has {
 		for _, dep := range fn.Dependencies {
-			_, err := p.ensureJSHelper(dep)
+			_, err := p.ensureJSHelperIn(jsCtxID, dep)
 			if err != nil {
 				return "", err
 			}
@@ -377,3 +423,162 @@ func (p *Page) jsCtxIDByObjectID(id proto.RuntimeRemoteObjectID) (proto.RuntimeR
 
 	return res.Result.ObjectID, nil
 }
+
+// NewDocumentScript is the handle of a script that will be evaluated on every new document of the page.
+type NewDocumentScript struct {
+	// ID of the script
+	ID proto.PageScriptIdentifier
+
+	// Name to tag the script, it's empty for the unnamed scripts
+	Name string
+
+	// Source of the script
+	Source string
+
+	world string // the isolated world to evaluate the script in, empty for the main world
+	page  *Page
+}
+
+type newDocumentScripts struct {
+	lock sync.Mutex
+	list []*NewDocumentScript
+
+	bindings map[string]struct{} // the bindings of [Page.Expose], they are replayed with the scripts
+}
+
+func (l *newDocumentScripts) addBinding(name string) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.bindings == nil {
+		l.bindings = map[string]struct{}{}
+	}
+	l.bindings[name] = struct{}{}
+}
+
+func (l *newDocumentScripts) removeBinding(name string) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	delete(l.bindings, name)
+}
+
+func (l *newDocumentScripts) getBindings() []string {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	list := []string{}
+	for name := range l.bindings {
+		list = append(list, name)
+	}
+	return list
+}
+
+func (l *newDocumentScripts) has(s *NewDocumentScript) bool {
+	for _, item := range l.list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// AddNewDocumentScript evaluates the js in every frame upon creation (before loading frame's scripts).
+// If the name is not empty and a script with the same name exists, the old one will be replaced by the new one,
+// so that you can swap the scripts of a long-lived page without reloading the browser.
+// If the new one is added but the old one fails to be removed, both the new one and the error are returned.
+func (p *Page) AddNewDocumentScript(name, js string) (*NewDocumentScript, error) {
+	return p.addNewDocumentScript(name, js, "")
+}
+
+func (p *Page) addNewDocumentScript(name, js, world string) (*NewDocumentScript, error) {
+	res, err := proto.PageAddScriptToEvaluateOnNewDocument{Source: js, WorldName: world}.Call(p)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &NewDocumentScript{ID: res.Identifier, Name: name, Source: js, world: world, page: p}
+
+	// swap the old one with the new one in the list atomically, so that the concurrent calls won't lose a script
+	var old *NewDocumentScript
+	var oldID proto.PageScriptIdentifier
+
+	p.scripts.lock.Lock()
+	for i, item := range p.scripts.list {
+		if name != "" && item.Name == name {
+			old, oldID = item, item.ID
+			p.scripts.list = append(p.scripts.list[:i], p.scripts.list[i+1:]...)
+			break
+		}
+	}
+	p.scripts.list = append(p.scripts.list, s)
+	p.scripts.lock.Unlock()
+
+	// remove the old one after the new one is added, so that no document will miss the script
+	if old != nil {
+		err = proto.PageRemoveScriptToEvaluateOnNewDocument{Identifier: oldID}.Call(p)
+		if err != nil {
+			// the old one is still evaluated by the browser, keep it in the list so that it can be removed later
+			p.scripts.lock.Lock()
+			p.scripts.list = append(p.scripts.list, old)
+			p.scripts.lock.Unlock()
+			return s, err
+		}
+	}
+
+	return s, nil
+}
+
+// NewDocumentScripts returns the scripts added to the page in order.
+func (p *Page) NewDocumentScripts() []*NewDocumentScript {
+	p.scripts.lock.Lock()
+	defer p.scripts.lock.Unlock()
+
+	return append([]*NewDocumentScript{}, p.scripts.list...)
+}
+
+// GetNewDocumentScript returns the script with the name, nil if not found.
+func (p *Page) GetNewDocumentScript(name string) *NewDocumentScript {
+	for _, s := range p.NewDocumentScripts() {
+		if s.Name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+// RemoveNewDocumentScript removes the script with the name, it's a no-op if not found.
+func (p *Page) RemoveNewDocumentScript(name string) error {
+	s := p.GetNewDocumentScript(name)
+	if s == nil {
+		return nil
+	}
+	return s.Remove()
+}
+
+// Remove the script, documents created afterwards won't evaluate it.
+func (s *NewDocumentScript) Remove() error {
+	l := s.page.scripts
+
+	// the ID may be changed by the crash recovery of [Page.AutoRecover]
+	l.lock.Lock()
+	id := s.ID
+	l.lock.Unlock()
+
+	err := proto.PageRemoveScriptToEvaluateOnNewDocument{Identifier: id}.Call(s.page)
+	if err != nil {
+		return err
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	for i, item := range l.list {
+		if item == s {
+			l.list = append(l.list[:i], l.list[i+1:]...)
+			break
+		}
+	}
+
+	return nil
+}
@@ -1,6 +1,7 @@
 package rod_test
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -29,6 +30,48 @@ func TestPageEvalOnNewDocument(t *testing.T) {
 	})
 }
 
+func TestPageNewDocumentScripts(t *testing.T) {
+	g := setup(t)
+
+	p := g.newPage()
+
+	p.MustEvalOnNewDocument(`window.a = 1`)
+	p.MustAddNewDocumentScript("stealth", `window.b = 1`)
+	g.Len(p.NewDocumentScripts(), 2)
+
+	// replace the script with the same name
+	s := p.MustAddNewDocumentScript("stealth", `window.b = 2`)
+	list := p.NewDocumentScripts()
+	g.Len(list, 2)
+	g.Eq(list[1], s)
+	g.Eq(p.GetNewDocumentScript("stealth").Source, `window.b = 2`)
+	g.Nil(p.GetNewDocumentScript("not-exists"))
+
+	p.MustNavigate(g.blank())
+	g.Eq(p.MustEval(`() => window.a + window.b`).Int(), 3)
+
+	p.MustRemoveNewDocumentScript("stealth").MustRemoveNewDocumentScript("not-exists")
+	g.Len(p.NewDocumentScripts(), 1)
+
+	p.MustReload()
+	g.True(p.MustEval(`() => window.b`).Nil())
+
+	g.E(p.NewDocumentScripts()[0].Remove())
+	g.Len(p.NewDocumentScripts(), 0)
+
+	s = p.MustAddNewDocumentScript("a", `1`)
+	g.mc.stubErr(1, proto.PageRemoveScriptToEvaluateOnNewDocument{})
+	s2, err := p.AddNewDocumentScript("a", `2`)
+	g.Err(err)
+	g.NotNil(s2)
+	// both the new one and the old one that failed to be removed are kept
+	g.Len(p.NewDocumentScripts(), 2)
+	g.E(s2.Remove())
+
+	g.mc.stubErr(1, proto.PageRemoveScriptToEvaluateOnNewDocument{})
+	g.Err(s.Remove())
+}
+
 func TestPageEval(t *testing.T) {
 	g := setup(t)
 
@@ -72,6 +115,17 @@ func TestPageEvaluateRetry(t *testing.T) {
 	g.Eq(1, page.MustEval(`() => 1`).Int())
 }
 
+func TestPageEvalInterruptible(t *testing.T) {
+	g := setup(t)
+
+	page := g.page.MustNavigate(g.blank())
+
+	_, err := page.Timeout(300 * time.Millisecond).Evaluate(rod.Eval(`() => { while (true) {} }`).Interruptible())
+	g.Is(err, context.DeadlineExceeded)
+
+	g.Eq(2, page.Timeout(5*time.Second).MustEval(`() => 1 + 1`).Int())
+}
+
 func TestPageUpdateJSCtxIDErr(t *testing.T) {
 	g := setup(t)
 
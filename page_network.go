@@ -0,0 +1,192 @@
+// This file serves for the network related helpers of Page.
+
+package rod
+
+import (
+	"net/url"
+	"sync"
+
+	"github.com/Fromsko/rodPro/lib/proto"
+)
+
+// NetworkStats aggregates the network activity of a page, such as bytes transferred,
+// request counts and cache hits. It's useful to monitor the cost of crawling.
+// Use [Page.NetworkStats] to create one.
+type NetworkStats struct {
+	lock *sync.Mutex
+	data *NetworkStatsData
+
+	// the domain of each request, used to attribute the received bytes
+	domains map[proto.NetworkRequestID]string
+	cached  map[proto.NetworkRequestID]struct{}
+
+	stop func()
+}
+
+// NetworkStatsData is a snapshot of [NetworkStats]. It can be encoded to JSON for exporting.
+type NetworkStatsData struct {
+	// BytesSent is the estimated size of the request lines, headers, and post data.
+	BytesSent int64 `json:"bytesSent"`
+
+	// BytesReceived is the encoded size of the responses, including the headers.
+	BytesReceived int64 `json:"bytesReceived"`
+
+	// Requests is the count of requests, a redirect counts as a new request.
+	Requests int `json:"requests"`
+
+	// Failed is the count of requests that failed to load.
+	Failed int `json:"failed"`
+
+	// CacheHits is the count of requests served from the memory, disk, or prefetch cache.
+	CacheHits int `json:"cacheHits"`
+
+	// ByType is the request count of each resource type.
+	ByType map[proto.NetworkResourceType]int `json:"byType"`
+
+	// ByDomain is the request count of each host.
+	ByDomain map[string]int `json:"byDomain"`
+
+	// BytesByDomain is the received bytes of each host.
+	BytesByDomain map[string]int64 `json:"bytesByDomain"`
+}
+
+// CacheHitRatio returns the ratio of requests served from cache, 0 if there's no request.
+func (d NetworkStatsData) CacheHitRatio() float64 {
+	if d.Requests == 0 {
+		return 0
+	}
+	return float64(d.CacheHits) / float64(d.Requests)
+}
+
+func newNetworkStatsData() *NetworkStatsData {
+	return &NetworkStatsData{
+		ByType:        map[proto.NetworkResourceType]int{},
+		ByDomain:      map[string]int{},
+		BytesByDomain: map[string]int64{},
+	}
+}
+
+// NetworkStats starts to aggregate the network activity of the page until [NetworkStats.Stop] is called.
+// The stats can be queried at any time via [NetworkStats.Data].
+func (p *Page) NetworkStats() *NetworkStats {
+	s := &NetworkStats{
+		lock:    &sync.Mutex{},
+		data:    newNetworkStatsData(),
+		domains: map[proto.NetworkRequestID]string{},
+		cached:  map[proto.NetworkRequestID]struct{}{},
+	}
+
+	p, cancel := p.WithCancel()
+	s.stop = cancel
+
+	go p.EachEvent(
+		s.onRequest,
+		s.onCache,
+		s.onResponse,
+		s.onFinished,
+		s.onFailed,
+	)()
+
+	return s
+}
+
+// Data returns a snapshot of the current stats.
+func (s *NetworkStats) Data() NetworkStatsData {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	d := *s.data
+	d.ByType = map[proto.NetworkResourceType]int{}
+	d.ByDomain = map[string]int{}
+	d.BytesByDomain = map[string]int64{}
+
+	for k, v := range s.data.ByType {
+		d.ByType[k] = v
+	}
+	for k, v := range s.data.ByDomain {
+		d.ByDomain[k] = v
+	}
+	for k, v := range s.data.BytesByDomain {
+		d.BytesByDomain[k] = v
+	}
+
+	return d
+}
+
+// Reset all the counters to zero.
+func (s *NetworkStats) Reset() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.data = newNetworkStatsData()
+}
+
+// Stop aggregating.
+func (s *NetworkStats) Stop() {
+	s.stop()
+}
+
+func (s *NetworkStats) onRequest(e *proto.NetworkRequestWillBeSent) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	domain := ""
+	if u, err := url.Parse(e.Request.URL); err == nil {
+		domain = u.Host
+	}
+
+	size := len(e.Request.Method) + len(e.Request.URL) + len(e.Request.PostData)
+	for k, v := range e.Request.Headers {
+		size += len(k) + len(v.String())
+	}
+
+	s.domains[e.RequestID] = domain
+	s.data.Requests++
+	s.data.BytesSent += int64(size)
+	s.data.ByType[e.Type]++
+	s.data.ByDomain[domain]++
+}
+
+func (s *NetworkStats) onCache(e *proto.NetworkRequestServedFromCache) {
+	s.hit(e.RequestID)
+}
+
+func (s *NetworkStats) onResponse(e *proto.NetworkResponseReceived) {
+	if e.Response.FromDiskCache || e.Response.FromPrefetchCache {
+		s.hit(e.RequestID)
+	}
+}
+
+func (s *NetworkStats) hit(id proto.NetworkRequestID) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, has := s.cached[id]; has {
+		return
+	}
+	s.cached[id] = struct{}{}
+	s.data.CacheHits++
+}
+
+func (s *NetworkStats) onFinished(e *proto.NetworkLoadingFinished) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	size := int64(e.EncodedDataLength)
+	s.data.BytesReceived += size
+	s.data.BytesByDomain[s.domains[e.RequestID]] += size
+	s.done(e.RequestID)
+}
+
+func (s *NetworkStats) onFailed(e *proto.NetworkLoadingFailed) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.data.Failed++
+	s.done(e.RequestID)
+}
+
+func (s *NetworkStats) done(id proto.NetworkRequestID) {
+	delete(s.domains, id)
+	delete(s.cached, id)
+}
@@ -0,0 +1,94 @@
+package rod_test
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/Fromsko/rodPro"
+	"github.com/Fromsko/rodPro/lib/proto"
+	"github.com/Fromsko/rodPro/lib/utils"
+)
+
+func TestPageNetworkStats(t *testing.T) {
+	g := setup(t)
+
+	s := g.Serve()
+	s.Route("/a.js", ".js", `window.a = 1`)
+	s.Route("/", ".html", `<html><script src="/a.js"></script></html>`)
+
+	p := g.newPage()
+	stats := p.NetworkStats()
+	defer stats.Stop()
+
+	wait := p.WaitNavigation(proto.PageLifecycleEventNameNetworkAlmostIdle)
+	p.MustNavigate(s.URL())
+	wait()
+
+	u, err := url.Parse(s.URL())
+	g.E(err)
+
+	data := stats.Data()
+	g.Gte(data.Requests, 2)
+	g.Gt(data.BytesSent, int64(0))
+	g.Gt(data.BytesReceived, int64(0))
+	g.Gte(data.ByType[proto.NetworkResourceTypeScript], 1)
+	g.Gte(data.ByDomain[u.Host], 2)
+	g.Gt(data.BytesByDomain[u.Host], int64(0))
+	g.Has(utils.MustToJSON(data), `"bytesReceived"`)
+
+	stats.Reset()
+	g.Eq(stats.Data().Requests, 0)
+	g.Eq(stats.Data().CacheHitRatio(), 0.0)
+
+	stats.Stop()
+	p.MustNavigate(s.URL()).MustWaitLoad()
+	utils.Sleep(0.1)
+	g.Eq(stats.Data().Requests, 0)
+}
+
+func TestCacheControl(t *testing.T) {
+	g := setup(t)
+
+	s := g.Serve()
+	s.Route("/", ".html", `<html></html>`)
+
+	count := 0
+	s.Mux.HandleFunc("/a.js", func(w http.ResponseWriter, r *http.Request) {
+		count++
+		w.Header().Set("Cache-Control", "max-age=3600")
+		w.Header().Set("Content-Type", "text/javascript")
+		_, _ = w.Write([]byte(`window.a = 1`))
+	})
+
+	p := g.newPage().MustNavigate(s.URL())
+	g.browser.MustClearHTTPCache()
+
+	watcher := p.WatchCache()
+	defer watcher.Stop()
+
+	p.MustWarmCache(s.URL("/a.js"))
+	g.Eq(count, 1)
+
+	p.MustEval(`() => fetch('/a.js').then(r => r.text())`)
+	g.Eq(count, 1)
+	g.True(watcher.Find(s.URL("/a.js")).Hit())
+	g.False(watcher.Hits()[0].Hit())
+
+	p.MustSetCacheDisabled(true)
+	p.MustEval(`() => fetch('/a.js').then(r => r.text())`)
+	g.Eq(count, 2)
+	g.Eq(watcher.Find(s.URL("/a.js")).Source, rod.CacheSourceNetwork)
+
+	p.MustSetCacheDisabled(false)
+	g.browser.MustClearHTTPCache()
+	p.MustEval(`() => fetch('/a.js').then(r => r.text())`)
+	g.Eq(count, 3)
+
+	g.Nil(watcher.Find("none"))
+
+	g.Err(p.WarmCache("http://not-exists.invalid/"))
+
+	g.mc.stubErr(1, proto.RuntimeCallFunctionOn{})
+	g.Err(p.WarmCache(s.URL()))
+}
@@ -4,13 +4,16 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"image"
 	"image/png"
 	"math"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"runtime"
 	"sort"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -21,6 +24,7 @@ import (
 	"github.com/Fromsko/rodPro/lib/devices"
 	"github.com/Fromsko/rodPro/lib/proto"
 	"github.com/Fromsko/rodPro/lib/utils"
+	"github.com/Fromsko/rodPro/lib/visual"
 	"github.com/ysmood/gson"
 )
 
@@ -127,6 +131,95 @@ func TestSetExtraHeaders(t *testing.T) {
 	}
 }
 
+func TestSetExtraHeadersFor(t *testing.T) {
+	g := setup(t)
+
+	s := g.Serve()
+	s.Route("/", ".html", `<html></html>`)
+
+	headers := make(chan http.Header, 10)
+	s.Mux.HandleFunc("/api/", func(rw http.ResponseWriter, r *http.Request) {
+		headers <- r.Header
+	})
+	s.Mux.HandleFunc("/other", func(rw http.ResponseWriter, r *http.Request) {
+		headers <- r.Header
+	})
+
+	p := g.newPage().MustNavigate(s.URL())
+	cleanupA := p.MustSetExtraHeadersFor(s.URL("/api/*"), "Authorization", "token", "a", "1")
+	cleanupB := p.MustSetExtraHeadersFor(s.URL("/api/*"), "a", "2")
+
+	p.MustEval(`() => fetch('/api/x')`)
+	h := <-headers
+	g.Eq(h.Get("Authorization"), "token")
+	g.Eq(h.Get("a"), "2")
+
+	p.MustEval(`() => fetch('/other')`)
+	g.Eq((<-headers).Get("Authorization"), "")
+
+	cleanupA()
+	cleanupA()
+
+	p.MustEval(`() => fetch('/api/x')`)
+	h = <-headers
+	g.Eq(h.Get("Authorization"), "")
+	g.Eq(h.Get("a"), "2")
+
+	cleanupB()
+
+	p.MustEval(`() => fetch('/api/x')`)
+	g.Eq((<-headers).Get("a"), "")
+
+	g.mc.stubErr(2, proto.FetchEnable{})
+	g.Err(p.SetExtraHeadersFor("*", nil))
+}
+
+func TestNavigateWithReferer(t *testing.T) {
+	g := setup(t)
+
+	s := g.Serve()
+	referer := make(chan string, 1)
+	s.Mux.HandleFunc("/", func(rw http.ResponseWriter, r *http.Request) {
+		referer <- r.Referer()
+	})
+
+	p := g.newPage()
+	p.MustNavigateWithReferer(s.URL(), "https://a.com/b?c=d")
+	g.Eq(<-referer, "https://a.com/b?c=d")
+}
+
+func TestSetRefererPolicy(t *testing.T) {
+	g := setup(t)
+
+	s := g.Serve()
+	s.Route("/", ".html", `<html></html>`)
+
+	headers := make(chan http.Header, 10)
+	s.Mux.HandleFunc("/api", func(rw http.ResponseWriter, r *http.Request) {
+		headers <- r.Header
+	})
+
+	cleanup := g.browser.MustSetRefererPolicy(&rod.RefererPolicy{
+		Referer: func(u *url.URL, referer string) string { return "https://a.com/" },
+		Origin:  func(u *url.URL, origin string) string { return "" },
+	})
+
+	p := g.newPage().MustNavigate(s.URL())
+	p.MustEval(`() => fetch('/api', { method: 'POST' })`)
+	h := <-headers
+	g.Eq(h.Get("Referer"), "https://a.com/")
+	g.Eq(h.Get("Origin"), "")
+
+	cleanup()
+	cleanup = g.browser.MustSetRefererPolicy(rod.SameOriginRefererPolicy())
+	defer cleanup()
+
+	p.MustEval(`() => fetch('/api', { method: 'POST' })`)
+	h = <-headers
+	g.Eq(h.Get("Referer"), s.URL("/"))
+	g.Eq(h.Get("Origin"), s.URL())
+}
+
 func TestSetUserAgent(t *testing.T) {
 	g := setup(t)
 
@@ -205,6 +298,34 @@ func TestPageContext(t *testing.T) {
 	_, _ = g.page.Timeout(time.Second).Timeout(time.Hour).CancelTimeout().Element("not-exist")
 }
 
+func TestPageFork(t *testing.T) {
+	g := setup(t)
+
+	p := g.page.MustNavigate(g.srcFile("fixtures/click.html"))
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			f := p.Fork().Timeout(time.Minute).Trace(i%2 == 0)
+			g.Eq(f.MustElement("button").MustText(), "click me")
+		}(i)
+	}
+	wg.Wait()
+
+	var msg []interface{}
+	g.browser.Logger(utils.Log(func(list ...interface{}) { msg = list }))
+	defer g.browser.Logger(rod.DefaultLogger)
+
+	p.Trace(true).MustWaitLoad()
+	g.Eq(rod.TraceTypeWait, msg[0])
+
+	msg = nil
+	p.MustWaitLoad()
+	g.Nil(msg)
+}
+
 func TestPageActivate(t *testing.T) {
 	g := setup(t)
 
@@ -387,6 +508,32 @@ func TestPageAddScriptTag(t *testing.T) {
 	g.Eq("yes", res.String())
 }
 
+func TestPageInjectScriptTag(t *testing.T) {
+	g := setup(t)
+
+	s := g.Serve()
+	s.Route("/", ".html", `<html><head><script>window.seen = window.injected</script></head></html>`)
+	s.Route("/a.js", ".js", `window.loaded = true`)
+
+	p := g.newPage()
+
+	remove := p.MustInjectScriptTag("", `window.injected = 'ok'`)
+	p.MustInjectScriptTag(s.URL("/a.js"), "")
+
+	p.MustNavigate(s.URL()).MustWaitLoad()
+	g.Eq(p.MustEval(`() => window.seen`).String(), "ok")
+	g.True(p.MustEval(`() => window.loaded`).Bool())
+
+	remove()
+	p.MustReload().MustWaitLoad()
+	g.True(p.MustEval(`() => window.seen`).Nil())
+
+	g.Panic(func() {
+		g.mc.stubErr(1, proto.PageAddScriptToEvaluateOnNewDocument{})
+		p.MustInjectScriptTag("", `1`)
+	})
+}
+
 func TestPageAddStyleTag(t *testing.T) {
 	g := setup(t)
 
@@ -419,6 +566,39 @@ func TestPageWaitOpen(t *testing.T) {
 	g.Eq("new page", newPage.MustEval("() => window.a").String())
 }
 
+func TestPageExpectPopup(t *testing.T) {
+	g := setup(t)
+
+	page := g.page.MustNavigate(g.srcFile("fixtures/open-page.html"))
+
+	popup := page.MustExpectPopup(func() {
+		page.MustElement("a").MustClick()
+	})
+	defer popup.MustClose()
+
+	g.Eq("new page", popup.MustEval("() => window.a").String())
+
+	blank := page.MustExpectPopup(func() {
+		page.MustEval(`() => window.open()`)
+	})
+	defer blank.MustClose()
+
+	g.Eq("about:blank", blank.MustInfo().URL)
+
+	g.Panic(func() {
+		g.mc.stubErr(1, proto.TargetAttachToTarget{})
+		page.MustExpectPopup(func() {
+			page.MustEval(`() => window.open()`)
+		})
+	})
+
+	// the pages not opened by the page are ignored
+	_, err := page.Timeout(time.Second).ExpectPopup(func() {
+		g.newPage()
+	})
+	g.Is(err, &rod.ErrTimeout{})
+}
+
 func TestPageWait(t *testing.T) {
 	g := setup(t)
 
@@ -437,6 +617,29 @@ func TestPageNavigateBlank(t *testing.T) {
 	g.page.MustNavigate("")
 }
 
+func TestPageAutoRecover(t *testing.T) {
+	g := setup(t)
+
+	p := g.newPage(g.srcFile("fixtures/click.html")).MustSetViewport(317, 419, 0, false)
+	p.MustEvalOnNewDocument(`window.recovered = true`)
+	p.MustExpose("exposed", func(gson.JSON) (interface{}, error) { return "ok", nil })
+
+	crashed := make(chan struct{}, 1)
+	defer p.OnCrash(func(*proto.TargetTargetCrashed) { crashed <- struct{}{} })()
+
+	recovered := make(chan error, 1)
+	defer p.AutoRecover(3, func(err error) { recovered <- err })()
+
+	_ = p.Navigate("chrome://crash")
+	<-crashed
+	g.E(<-recovered)
+
+	g.Has(p.MustInfo().URL, "click.html")
+	g.True(p.MustEval(`() => window.recovered`).Bool())
+	g.Eq(p.MustEval(`() => innerWidth`).Int(), 317)
+	g.Eq(p.MustEval(`() => exposed()`).Str(), "ok")
+}
+
 func TestPageWaitNavigation(t *testing.T) {
 	g := setup(t)
 
@@ -572,6 +775,48 @@ func TestPageWaitIdle(t *testing.T) {
 	g.True(p.MustHas("[a=ok]"))
 }
 
+func TestPageWaitIdleCPU(t *testing.T) {
+	g := setup(t)
+
+	p := g.page.MustNavigate(g.blank())
+	p.MustEval(`() => {
+		window.busyDone = false
+		let n = 0
+		const busy = () => {
+			const end = performance.now() + 80
+			while (performance.now() < end) {}
+			if (++n < 5) setTimeout(busy, 0)
+			else window.busyDone = true
+		}
+		setTimeout(busy, 0)
+	}`)
+	p.MustWaitIdleCPU()
+
+	g.True(p.MustEval(`() => window.busyDone`).Bool())
+
+	p.MustEval(`() => setInterval(() => {
+		const end = performance.now() + 80
+		while (performance.now() < end) {}
+	}, 0)`)
+	err := p.Timeout(500 * time.Millisecond).WaitIdleCPU(time.Second)
+	g.Is(err, &rod.ErrTimeout{})
+}
+
+func TestPageCallRaw(t *testing.T) {
+	g := setup(t)
+
+	var res proto.RuntimeEvaluateResult
+	g.page.MustCallRaw("Runtime.evaluate", map[string]interface{}{
+		"expression":    "1 + 1",
+		"returnByValue": true,
+	}, &res)
+	g.Eq(res.Result.Value.Int(), 2)
+
+	ctx, cancel := context.WithCancel(g.Context())
+	cancel()
+	g.Is(g.page.Context(ctx).CallRaw("Runtime.evaluate", map[string]interface{}{"expression": "1"}, nil), context.Canceled)
+}
+
 func TestPageEventSession(t *testing.T) {
 	g := setup(t)
 
@@ -627,6 +872,32 @@ func TestPageEvent(t *testing.T) {
 	p.MustClose()
 }
 
+func TestPageFilterEvents(t *testing.T) {
+	g := setup(t)
+
+	p := g.newPage().Context(g.Context())
+	defer p.EnableDomain(&proto.RuntimeEnable{})()
+
+	filtered := p.FilterEvents("Page.*").Event()
+	events := p.Event()
+	p.MustEval(`() => console.log("ok")`)
+	p.MustNavigate(g.blank())
+
+	for msg := range filtered {
+		g.False(msg.Method == "Runtime.consoleAPICalled")
+		if msg.Load(&proto.PageLoadEventFired{}) {
+			break
+		}
+	}
+
+	// the other subscribers of the page aren't affected by the filter
+	for msg := range events {
+		if msg.Method == "Runtime.consoleAPICalled" {
+			break
+		}
+	}
+}
+
 func TestPageStopEventAfterDetach(t *testing.T) {
 	g := setup(t)
 
@@ -703,6 +974,92 @@ func TestPageScreenshot(t *testing.T) {
 	})
 }
 
+func TestPageScreenshotTo(t *testing.T) {
+	g := setup(t)
+
+	p := g.page.MustNavigate(g.srcFile("fixtures/click.html"))
+
+	buf := bytes.NewBuffer(nil)
+	n, err := p.ScreenshotTo(buf, true, nil)
+	g.E(err)
+	g.Eq(n, int64(buf.Len()))
+	_, err = png.Decode(buf)
+	g.E(err)
+
+	g.mc.stubErr(1, proto.PageCaptureScreenshot{})
+	_, err = p.ScreenshotTo(buf, false, nil)
+	g.Err(err)
+}
+
+func TestPageScreenshotElement(t *testing.T) {
+	g := setup(t)
+
+	p := g.newPage().MustSetViewport(800, 600, 2, false)
+	p.MustNavigate(g.html(`<div style="height: 1000px"></div>
+		<div id="box" style="width: 30px; height: 20px; background: red"></div>`))
+
+	data := p.MustScreenshotElement("#box")
+	img, err := png.Decode(bytes.NewBuffer(data))
+	g.E(err)
+	g.Eq(60, img.Bounds().Dx())
+	g.Eq(40, img.Bounds().Dy())
+
+	data, err = p.ScreenshotElement("#box", &proto.PageCaptureScreenshot{
+		Format:           proto.PageCaptureScreenshotFormatWebp,
+		Quality:          gson.Int(50),
+		OptimizeForSpeed: true,
+	})
+	g.E(err)
+	g.Eq(string(data[:4]), "RIFF")
+	g.Eq(string(data[8:12]), "WEBP")
+
+	g.Err(p.Timeout(100*time.Millisecond).ScreenshotElement("#not-exists", nil))
+
+	g.mc.stubErr(1, proto.PageGetLayoutMetrics{})
+	g.Err(p.ScreenshotElement("#box", nil))
+
+	g.mc.stubErr(1, proto.DOMGetContentQuads{})
+	g.Err(p.ScreenshotElement("#box", nil))
+}
+
+func TestPageScreenshotAndCompare(t *testing.T) {
+	g := setup(t)
+
+	p := g.newPage(g.html(`<div id="box" style="width: 100px; height: 100px; background: red"></div>`))
+	baseline := filepath.Join(t.TempDir(), "baseline.png")
+
+	_, err := p.ScreenshotAndCompare(baseline, nil)
+	g.Is(err, os.ErrNotExist)
+	g.False(utils.FileExists(baseline))
+
+	p.MustSaveScreenshotBaseline(baseline)
+	g.PathExists(baseline)
+
+	res := p.MustScreenshotAndCompare(baseline)
+	g.Eq(res.Mismatched, 0)
+	g.False(utils.FileExists(strings.TrimSuffix(baseline, ".png") + ".diff.png"))
+
+	p.MustEval(`() => document.getElementById('box').style.background = 'blue'`)
+	res = p.MustScreenshotAndCompare(baseline)
+	g.Eq(res.Mismatched, 100*100)
+	g.PathExists(strings.TrimSuffix(baseline, ".png") + ".diff.png")
+
+	res, err = p.ScreenshotAndCompare(baseline, &visual.Options{Ignore: []image.Rectangle{image.Rect(0, 0, 200, 200)}})
+	g.E(err)
+	g.Eq(res.Mismatched, 0)
+
+	g.mc.stubErr(1, proto.PageCaptureScreenshot{})
+	g.Err(p.ScreenshotAndCompare(baseline, nil))
+
+	g.mc.stubErr(1, proto.PageCaptureScreenshot{})
+	g.Err(p.SaveScreenshotBaseline(baseline))
+
+	utils.E(utils.OutputFile(baseline, "not image"))
+	g.Err(p.ScreenshotAndCompare(baseline, nil))
+
+	g.Err(p.ScreenshotAndCompare(t.TempDir(), nil))
+}
+
 func TestScreenshotFullPage(t *testing.T) {
 	g := setup(t)
 
@@ -970,3 +1327,23 @@ func TestPageActionAfterClose(t *testing.T) {
 		g.Eq(err, context.Canceled)
 	}
 }
+
+func TestWebGLInfo(t *testing.T) {
+	g := setup(t)
+
+	p := g.page.MustNavigate(g.blank())
+	info := p.MustWebGLInfo()
+	if info.WebGL1 {
+		g.NotZero(info.Renderer)
+		g.Gt(info.MaxTextureSize, 0)
+		g.Gt(len(info.Extensions), 0)
+	} else {
+		g.NotZero(info.FeatureStatus)
+	}
+
+	g.mc.stubErr(1, proto.RuntimeCallFunctionOn{})
+	g.Err(p.WebGLInfo())
+
+	g.mc.stubErr(1, proto.SystemInfoGetInfo{})
+	g.Err(p.WebGLInfo())
+}
@@ -0,0 +1,277 @@
+// This file serves for the PDF printing of Page.
+
+package rod
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Fromsko/rodPro/lib/js"
+	"github.com/Fromsko/rodPro/lib/proto"
+	"github.com/ysmood/gson"
+)
+
+// PaperSize of the PDF, in inches
+type PaperSize struct {
+	Width  float64
+	Height float64
+}
+
+// The common paper sizes
+var (
+	PaperA3      = PaperSize{11.69, 16.54}
+	PaperA4      = PaperSize{8.27, 11.69}
+	PaperA5      = PaperSize{5.83, 8.27}
+	PaperLetter  = PaperSize{8.5, 11}
+	PaperLegal   = PaperSize{8.5, 14}
+	PaperTabloid = PaperSize{11, 17}
+)
+
+// PDFBuilder is a fluent builder for the options of [Page.PDF].
+// The placeholders below can be used in the header and footer templates:
+//
+//	{{page}}  current page number
+//	{{pages}} total pages
+//	{{date}}  formatted print date
+//	{{title}} document title
+//	{{url}}   document location
+type PDFBuilder struct {
+	page      *Page
+	req       *proto.PagePrintToPDF
+	err       error
+	waitReady bool
+}
+
+// PDFBuilder creates a builder to print the page as PDF, the default paper is [PaperA4].
+func (p *Page) PDFBuilder() *PDFBuilder {
+	return (&PDFBuilder{page: p, req: &proto.PagePrintToPDF{}}).Paper(PaperA4)
+}
+
+// Paper size of the PDF
+func (b *PDFBuilder) Paper(size PaperSize) *PDFBuilder {
+	b.req.PaperWidth = gson.Num(size.Width)
+	b.req.PaperHeight = gson.Num(size.Height)
+	return b
+}
+
+// Landscape orientation or not
+func (b *PDFBuilder) Landscape(enable bool) *PDFBuilder {
+	b.req.Landscape = enable
+	return b
+}
+
+// Margin sets all the margins to the same length, such as "1cm", "10mm", "0.5in", "72pt", or "96px".
+// A number without unit is in inches.
+func (b *PDFBuilder) Margin(length string) *PDFBuilder {
+	return b.Margins(length, length, length, length)
+}
+
+// Margins sets the margins in the CSS order, the format of the length is the same as [PDFBuilder.Margin].
+func (b *PDFBuilder) Margins(top, right, bottom, left string) *PDFBuilder {
+	for _, m := range []struct {
+		field  **float64
+		length string
+	}{
+		{&b.req.MarginTop, top},
+		{&b.req.MarginRight, right},
+		{&b.req.MarginBottom, bottom},
+		{&b.req.MarginLeft, left},
+	} {
+		v, err := parseLength(m.length)
+		if err != nil {
+			b.err = err
+			return b
+		}
+		*m.field = gson.Num(v)
+	}
+	return b
+}
+
+// Header HTML template, see [PDFBuilder] for the placeholders.
+func (b *PDFBuilder) Header(html string) *PDFBuilder {
+	b.req.DisplayHeaderFooter = true
+	b.req.HeaderTemplate = pdfTemplate(html)
+	if b.req.FooterTemplate == "" {
+		b.req.FooterTemplate = "<span></span>"
+	}
+	return b
+}
+
+// Footer HTML template, see [PDFBuilder] for the placeholders.
+func (b *PDFBuilder) Footer(html string) *PDFBuilder {
+	b.req.DisplayHeaderFooter = true
+	b.req.FooterTemplate = pdfTemplate(html)
+	if b.req.HeaderTemplate == "" {
+		b.req.HeaderTemplate = "<span></span>"
+	}
+	return b
+}
+
+// PageRanges to print, one based, such as "1-5, 8, 11-13"
+func (b *PDFBuilder) PageRanges(ranges string) *PDFBuilder {
+	b.req.PageRanges = ranges
+	return b
+}
+
+// Scale of the rendering, from 0.1 to 2
+func (b *PDFBuilder) Scale(scale float64) *PDFBuilder {
+	b.req.Scale = gson.Num(scale)
+	return b
+}
+
+// Background graphics printing or not
+func (b *PDFBuilder) Background(enable bool) *PDFBuilder {
+	b.req.PrintBackground = enable
+	return b
+}
+
+// PreferCSSPageSize to use the page size defined by the CSS @page rule
+func (b *PDFBuilder) PreferCSSPageSize(enable bool) *PDFBuilder {
+	b.req.PreferCSSPageSize = enable
+	return b
+}
+
+// Tagged switch to generate tagged (accessible) PDF
+func (b *PDFBuilder) Tagged(enable bool) *PDFBuilder {
+	b.req.GenerateTaggedPDF = enable
+	return b
+}
+
+// Outline switch to embed the document outline, such as the headings, into the PDF
+func (b *PDFBuilder) Outline(enable bool) *PDFBuilder {
+	b.req.GenerateDocumentOutline = enable
+	return b
+}
+
+// WaitReady switch to call [Page.WaitPrintReady] before printing,
+// so the fonts and the lazy-loaded images below the fold won't be missing.
+func (b *PDFBuilder) WaitReady(enable bool) *PDFBuilder {
+	b.waitReady = enable
+	return b
+}
+
+// Request returns the built request
+func (b *PDFBuilder) Request() (*proto.PagePrintToPDF, error) {
+	return b.req, b.err
+}
+
+// Stream prints the PDF, the data is streamed from the browser while reading it.
+func (b *PDFBuilder) Stream() (*StreamReader, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	if b.waitReady {
+		err := b.page.WaitPrintReady()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return b.page.PDF(b.req)
+}
+
+// WriteTo prints the PDF to w chunk by chunk, so huge documents won't be loaded into the memory at once.
+func (b *PDFBuilder) WriteTo(w io.Writer) (int64, error) {
+	r, err := b.Stream()
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := io.Copy(w, r)
+	if err != nil {
+		return n, err
+	}
+
+	return n, r.Close()
+}
+
+// Save the PDF to the file path
+func (b *PDFBuilder) Save(path string) error {
+	err := os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	_, err = b.WriteTo(f)
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// WaitPrintReady forces the lazy-loaded images and iframes to load by switching them to loading=eager and
+// scrolling through the page, then waits for the network to be idle, the images to be loaded, and the
+// document.fonts.ready. Use it before [Page.PDF] or full page screenshots.
+func (p *Page) WaitPrintReady() error {
+	// release the event subscription of the request idle waiting when it returns early
+	idle, cancel := p.WithCancel()
+	defer cancel()
+
+	wait := idle.WaitRequestIdle(300*time.Millisecond, nil, nil, []proto.NetworkResourceType{
+		proto.NetworkResourceTypeWebSocket,
+		proto.NetworkResourceTypeEventSource,
+		proto.NetworkResourceTypeMedia,
+	})
+
+	_, err := p.Evaluate(evalHelper(js.LoadLazy, 100).ByPromise())
+	if err != nil {
+		return err
+	}
+
+	wait()
+
+	_, err = p.Evaluate(evalHelper(js.WaitAssets).ByPromise())
+	return err
+}
+
+var pdfPlaceholders = strings.NewReplacer(
+	"{{page}}", `<span class="pageNumber"></span>`,
+	"{{pages}}", `<span class="totalPages"></span>`,
+	"{{date}}", `<span class="date"></span>`,
+	"{{title}}", `<span class="title"></span>`,
+	"{{url}}", `<span class="url"></span>`,
+)
+
+func pdfTemplate(html string) string {
+	return pdfPlaceholders.Replace(html)
+}
+
+var regLength = regexp.MustCompile(`^\s*([0-9.]+)\s*(in|cm|mm|pt|px)?\s*$`)
+
+// parseLength converts the length to inches
+func parseLength(s string) (float64, error) {
+	m := regLength.FindStringSubmatch(s)
+	if m == nil {
+		return 0, fmt.Errorf("invalid length: %q", s)
+	}
+
+	v, err := strconv.ParseFloat(m[1], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid length: %q", s)
+	}
+
+	switch m[2] {
+	case "cm":
+		v /= 2.54
+	case "mm":
+		v /= 25.4
+	case "pt":
+		v /= 72
+	case "px":
+		v /= 96
+	}
+
+	return v, nil
+}
@@ -0,0 +1,97 @@
+package rod_test
+
+import (
+	"bytes"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Fromsko/rodPro"
+	"github.com/Fromsko/rodPro/lib/proto"
+)
+
+func TestPDFBuilder(t *testing.T) {
+	g := setup(t)
+
+	p := g.page.MustNavigate(g.srcFile("fixtures/click.html"))
+
+	b := p.PDFBuilder().
+		Paper(rod.PaperLetter).
+		Landscape(true).
+		Margins("1in", "2.54cm", "72pt", "96px").
+		Header("<div>{{title}}</div>").
+		Footer("<div>{{page}} / {{pages}}</div>").
+		PageRanges("1").
+		Scale(0.5).
+		Background(true).
+		PreferCSSPageSize(true).
+		Tagged(true).
+		Outline(true)
+
+	req, err := b.Request()
+	g.E(err)
+	g.Eq(*req.PaperWidth, 8.5)
+	g.Eq(*req.MarginTop, 1.0)
+	g.Eq(*req.MarginRight, 1.0)
+	g.Eq(*req.MarginBottom, 1.0)
+	g.Eq(*req.MarginLeft, 1.0)
+	g.True(req.DisplayHeaderFooter)
+	g.Eq(req.HeaderTemplate, `<div><span class="title"></span></div>`)
+	g.Eq(req.FooterTemplate, `<div><span class="pageNumber"></span> / <span class="totalPages"></span></div>`)
+
+	buf := bytes.NewBuffer(nil)
+	g.Gt(b.MustWriteTo(buf), 0)
+	g.Has(buf.String(), "%PDF")
+
+	f := filepath.Join(t.TempDir(), "a", "out.pdf")
+	p.PDFBuilder().Footer("{{url}}").MustSave(f)
+	g.PathExists(f)
+
+	g.Nil(p.PDFBuilder().MustStream().Close())
+
+	{
+		_, err := p.PDFBuilder().Margin("1em").Request()
+		g.Err(err)
+		g.Err(p.PDFBuilder().Margin("x").WriteTo(buf))
+	}
+
+	{
+		g.mc.stubErr(1, proto.PagePrintToPDF{})
+		g.Err(p.PDFBuilder().Save(f))
+	}
+}
+
+func TestWaitPrintReady(t *testing.T) {
+	g := setup(t)
+
+	icon, err := os.ReadFile(slash("fixtures/icon.png"))
+	g.E(err)
+
+	s := g.Serve()
+	s.Route("/", ".html", `<html><body>
+		<div style="height: 3000px"></div>
+		<img id="lazy" loading="lazy" src="/icon.png">
+	</body></html>`)
+	s.Mux.HandleFunc("/icon.png", func(rw http.ResponseWriter, _ *http.Request) {
+		rw.Header().Set("Content-Type", "image/png")
+		_, _ = rw.Write(icon)
+	})
+
+	p := g.newPage(s.URL()).MustWaitLoad()
+	g.False(p.MustEval(`() => document.getElementById('lazy').complete && document.getElementById('lazy').naturalWidth > 0`).Bool())
+
+	p.MustWaitPrintReady()
+	g.True(p.MustEval(`() => document.getElementById('lazy').naturalWidth > 0`).Bool())
+	g.Eq(p.MustEval(`() => window.scrollY`).Int(), 0)
+
+	buf := bytes.NewBuffer(nil)
+	p.PDFBuilder().WaitReady(true).MustWriteTo(buf)
+	g.Has(buf.String(), "%PDF")
+
+	g.mc.stubErr(1, proto.RuntimeCallFunctionOn{})
+	g.Err(p.WaitPrintReady())
+
+	g.mc.stubErr(1, proto.RuntimeCallFunctionOn{})
+	g.Err(p.PDFBuilder().WaitReady(true).Stream())
+}
@@ -0,0 +1,226 @@
+// This file serves for the rate limiting and the robots.txt awareness of the navigations.
+
+package rod
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Fromsko/rodPro/lib/robots"
+)
+
+// RobotsPolicy for [Browser.RobotsPolicy]
+type RobotsPolicy struct {
+	// UserAgent to match the groups of the robots.txt, such as "RodBot", the "*" group is used if it's empty
+	UserAgent string
+
+	// Fetch the robots.txt of the url, the default fetches it via [http.DefaultClient].
+	// If the status code is 4xx, everything is allowed. If it fails, such as a network error or a 5xx status code,
+	// the origin is treated as unreachable, everything is disallowed and the robots.txt will be fetched again
+	// for the next navigation.
+	Fetch func(ctx context.Context, robotsURL string) ([]byte, error)
+
+	// IgnoreDisallow only respects the crawl-delay
+	IgnoreDisallow bool
+
+	// IgnoreCrawlDelay only respects the allow and disallow rules
+	IgnoreCrawlDelay bool
+}
+
+type politeness struct {
+	lock   sync.Mutex
+	limits map[string]time.Duration // domain to the min interval between two navigations
+	next   map[string]time.Time     // bucket to the time of the next navigation
+
+	robots      *RobotsPolicy
+	robotsCache map[string]*robotsEntry // origin to the robots.txt
+}
+
+type robotsEntry struct {
+	lock   sync.Mutex
+	robots *robots.Robots
+}
+
+// the robots of an unreachable robots.txt
+var disallowAll = robots.Parse([]byte("User-agent: *\nDisallow: /\n"))
+
+func newPoliteness() *politeness {
+	return &politeness{
+		limits:      map[string]time.Duration{},
+		next:        map[string]time.Time{},
+		robotsCache: map[string]*robotsEntry{},
+	}
+}
+
+// RateLimit the navigations of all the pages to the domain to rps times per second, the subdomains are included
+// and share the same limit. Use "*" as the domain to limit each of the other hosts separately.
+// Use 0 as the rps to remove the limit. It's applied to [Page.Navigate].
+func (b *Browser) RateLimit(domain string, rps float64) *Browser {
+	b.polite.lock.Lock()
+	defer b.polite.lock.Unlock()
+
+	domain = strings.ToLower(strings.TrimPrefix(domain, "."))
+	if rps <= 0 {
+		delete(b.polite.limits, domain)
+	} else {
+		b.polite.limits[domain] = time.Duration(float64(time.Second) / rps)
+	}
+	return b
+}
+
+// RobotsPolicy makes [Page.Navigate] respect the robots.txt of the sites. The navigation to a disallowed url
+// returns [ErrRobotsDisallowed], the crawl-delay is applied like [Browser.RateLimit] of the host if it's longer.
+// The robots.txt of each origin is only fetched once it's fetched successfully. Use nil to disable it.
+func (b *Browser) RobotsPolicy(policy *RobotsPolicy) *Browser {
+	b.polite.lock.Lock()
+	defer b.polite.lock.Unlock()
+
+	b.polite.robots = policy
+	b.polite.robotsCache = map[string]*robotsEntry{}
+	return b
+}
+
+// RobotsAllowed returns true if the url is allowed by the [Browser.RobotsPolicy].
+// It's useful to filter the links before they are visited. It's always true if the policy is not set.
+func (b *Browser) RobotsAllowed(u string) (bool, error) {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return false, err
+	}
+
+	policy, r := b.polite.robotsOf(b.ctx, parsed)
+	if r == nil || policy.IgnoreDisallow {
+		return true, nil
+	}
+	return r.Allowed(policy.UserAgent, parsed.RequestURI()), nil
+}
+
+// wait until the navigation to the url is allowed
+func (p *politeness) wait(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil
+	}
+
+	policy, r := p.robotsOf(ctx, u)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	var delay time.Duration
+	if r != nil {
+		if !policy.IgnoreDisallow && !r.Allowed(policy.UserAgent, u.RequestURI()) {
+			return &ErrRobotsDisallowed{URL: rawURL}
+		}
+		if !policy.IgnoreCrawlDelay {
+			delay = r.CrawlDelay(policy.UserAgent)
+		}
+	}
+
+	at := p.reserve(strings.ToLower(u.Hostname()), delay)
+
+	t := time.NewTimer(time.Until(at))
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// reserve the next slot of the host, the delay is the min interval of the host from its robots.txt
+func (p *politeness) reserve(host string, delay time.Duration) time.Time {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	// the longest matched domain wins
+	bucket, interval, best := host, time.Duration(0), -1
+	for domain, d := range p.limits {
+		if domain != "*" && (domain == host || strings.HasSuffix(host, "."+domain)) && len(domain) > best {
+			bucket, interval, best = domain, d, len(domain)
+		}
+	}
+	if best < 0 {
+		interval = p.limits["*"]
+	}
+	if delay > interval {
+		interval = delay
+	}
+
+	now := time.Now()
+	at := p.next[bucket]
+	if at.Before(now) {
+		at = now
+	}
+	if interval > 0 {
+		p.next[bucket] = at.Add(interval)
+	}
+	return at
+}
+
+func (p *politeness) robotsOf(ctx context.Context, u *url.URL) (*RobotsPolicy, *robots.Robots) {
+	p.lock.Lock()
+	policy := p.robots
+	if policy == nil {
+		p.lock.Unlock()
+		return nil, nil
+	}
+	origin := u.Scheme + "://" + u.Host
+	entry, has := p.robotsCache[origin]
+	if !has {
+		entry = &robotsEntry{}
+		p.robotsCache[origin] = entry
+	}
+	p.lock.Unlock()
+
+	entry.lock.Lock()
+	defer entry.lock.Unlock()
+
+	if entry.robots != nil {
+		return policy, entry.robots
+	}
+
+	fetch := policy.Fetch
+	if fetch == nil {
+		fetch = fetchRobots
+	}
+	data, err := fetch(ctx, origin+"/robots.txt")
+	if err != nil {
+		// don't cache the transient failures
+		return policy, disallowAll
+	}
+	entry.robots = robots.Parse(data)
+
+	return policy, entry.robots
+}
+
+func fetchRobots(ctx context.Context, u string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	if res.StatusCode >= 500 {
+		return nil, fmt.Errorf("failed to fetch %s: %s", u, res.Status)
+	}
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return nil, nil
+	}
+	return io.ReadAll(io.LimitReader(res.Body, 512*1024))
+}
@@ -0,0 +1,96 @@
+package rod_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Fromsko/rodPro"
+)
+
+func TestRateLimit(t *testing.T) {
+	g := setup(t)
+
+	s := g.Serve().Route("/", ".html", `<html></html>`)
+
+	b := g.browser.RateLimit("127.0.0.1", 10)
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		g.page.MustNavigate(s.URL())
+	}
+	g.Gte(time.Since(start), 200*time.Millisecond)
+
+	// the limit is shared by the pages and the clones of the browser
+	p := g.page.Timeout(50 * time.Millisecond)
+	b.RateLimit("127.0.0.1", 0).RateLimit("*", 0.1)
+	defer b.RateLimit("*", 0)
+	g.page.MustNavigate(s.URL())
+	g.Is(p.Navigate(s.URL()), context.DeadlineExceeded)
+
+	// the non-http urls are not limited
+	g.page.MustNavigate(g.blank())
+	g.page.MustNavigate(g.blank())
+}
+
+func TestRobotsPolicy(t *testing.T) {
+	g := setup(t)
+
+	s := g.Serve().Route("/robots.txt", ".txt", "User-agent: *\nDisallow: /private\nCrawl-delay: 0.1\n\n"+
+		"User-agent: RodBot\nDisallow: /\n")
+	s.Route("/", ".html", `<html></html>`)
+
+	b := g.browser.RobotsPolicy(&rod.RobotsPolicy{})
+	defer b.RobotsPolicy(nil)
+
+	start := time.Now()
+	g.page.MustNavigate(s.URL("/a"))
+	g.page.MustNavigate(s.URL("/b"))
+	g.Gte(time.Since(start), 100*time.Millisecond)
+
+	err := g.page.Navigate(s.URL("/private/a"))
+	g.Is(err, &rod.ErrRobotsDisallowed{})
+	g.Eq(err.Error(), "disallowed by robots.txt: "+s.URL("/private/a"))
+
+	ok, err := b.RobotsAllowed(s.URL("/private"))
+	g.E(err)
+	g.False(ok)
+
+	b.RobotsPolicy(&rod.RobotsPolicy{UserAgent: "RodBot/1.0", IgnoreDisallow: true})
+	ok, err = b.RobotsAllowed(s.URL("/private"))
+	g.E(err)
+	g.True(ok)
+	g.page.MustNavigate(s.URL("/private"))
+
+	_, err = b.RobotsAllowed("://")
+	g.Err(err)
+
+	// the failed fetch disallows everything, and it's not cached
+	fetched := 0
+	b.RobotsPolicy(&rod.RobotsPolicy{UserAgent: "RodBot", Fetch: func(context.Context, string) ([]byte, error) {
+		fetched++
+		if fetched == 1 {
+			return nil, errors.New("err")
+		}
+		return nil, nil
+	}})
+	ok, err = b.RobotsAllowed(s.URL("/"))
+	g.E(err)
+	g.False(ok)
+	ok, err = b.RobotsAllowed(s.URL("/"))
+	g.E(err)
+	g.True(ok)
+	g.Eq(fetched, 2)
+
+	// the 5xx status code disallows everything
+	s5 := g.Serve()
+	s5.Mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	b.RobotsPolicy(&rod.RobotsPolicy{})
+	ok, err = b.RobotsAllowed(s5.URL("/"))
+	g.E(err)
+	g.False(ok)
+}
@@ -5,12 +5,15 @@ package rod
 import (
 	"context"
 	"errors"
+	"fmt"
 	"regexp"
+	"strings"
 
 	"github.com/Fromsko/rodPro/lib/cdp"
 	"github.com/Fromsko/rodPro/lib/js"
 	"github.com/Fromsko/rodPro/lib/proto"
 	"github.com/Fromsko/rodPro/lib/utils"
+	"github.com/ysmood/gson"
 )
 
 // SelectorType enum
@@ -44,11 +47,56 @@ func (els Elements) Last() *Element {
 	return els[len(els)-1]
 }
 
+// Filter returns the elements that fn returns true. To filter a large list, [Page.ElementsFilter]
+// is more efficient, because it filters in the browser before the elements are resolved.
+func (els Elements) Filter(fn func(*Element) bool) Elements {
+	list := Elements{}
+	for _, el := range els {
+		if fn(el) {
+			list = append(list, el)
+		}
+	}
+	return list
+}
+
 // Empty returns true if the list is empty
 func (els Elements) Empty() bool {
 	return len(els) == 0
 }
 
+// EvalAll runs the js function against each element of the list in a single round trip,
+// the "this" of the function is the element, the args are the same for all the elements.
+// It returns the results in the same order as the list. All the elements should belong to the same page,
+// the context of the first element will be used. Such as:
+//
+//	hrefs, err := page.MustElements("a").EvalAll(`() => this.href`)
+func (els Elements) EvalAll(js string, args ...interface{}) ([]gson.JSON, error) {
+	if els.Empty() {
+		return []gson.JSON{}, nil
+	}
+
+	el := els.First()
+	el.page.browser.trySlowMotion("eval.eval")
+
+	jsArgs := []interface{}{len(args)}
+	jsArgs = append(jsArgs, args...)
+	for _, e := range els {
+		jsArgs = append(jsArgs, e.Object)
+	}
+
+	// the js is wrapped like the [EvalOptions], so that the arrow functions can also use the "this"
+	res, err := el.page.Context(el.ctx).Evaluate(Eval(fmt.Sprintf(`(n, ...list) => {
+		const fn = %s
+		const args = list.slice(0, n)
+		return Promise.all(list.slice(n).map(el => fn.apply(el, args)))
+	}`, Eval(js).formatToJSFunc()), jsArgs...).ByPromise())
+	if err != nil {
+		return nil, err
+	}
+
+	return res.Value.Arr(), nil
+}
+
 // Pages provides some helpers to deal with page list
 type Pages []*Page
 
@@ -141,19 +189,52 @@ func (p *Page) HasR(selector, jsRegex string) (bool, *Element, error) {
 // Element retries until an element in the page that matches the CSS selector, then returns
 // the matched element.
 func (p *Page) Element(selector string) (*Element, error) {
-	return p.ElementByJS(evalHelper(js.Element, selector))
+	return p.querySpan("Element", selector, func(p *Page) (*Element, error) {
+		el, err := p.ElementByJS(evalHelper(js.Element, selector).polyfillFor(selector))
+		return el, timeoutErr(p.ctx, err, "Element(`%s`)", selector)
+	})
 }
 
 // ElementR retries until an element in the page that matches the css selector and it's text matches the jsRegex,
 // then returns the matched element.
 func (p *Page) ElementR(selector, jsRegex string) (*Element, error) {
-	return p.ElementByJS(evalHelper(js.ElementR, selector, jsRegex))
+	return p.querySpan("ElementR", selector, func(p *Page) (*Element, error) {
+		el, err := p.ElementByJS(evalHelper(js.ElementR, selector, jsRegex))
+		return el, timeoutErr(p.ctx, err, "ElementR(`%s`, `%s`)", selector, jsRegex)
+	})
 }
 
 // ElementX retries until an element in the page that matches one of the XPath selectors, then returns
 // the matched element.
 func (p *Page) ElementX(xPath string) (*Element, error) {
-	return p.ElementByJS(evalHelper(js.ElementX, xPath))
+	return p.querySpan("ElementX", xPath, func(p *Page) (*Element, error) {
+		el, err := p.ElementByJS(evalHelper(js.ElementX, xPath))
+		return el, timeoutErr(p.ctx, err, "ElementX(`%s`)", xPath)
+	})
+}
+
+// TestIDAttribute is the attribute name used by [Page.ElementByTestID]
+var TestIDAttribute = "data-testid"
+
+// ElementByLabel retries until a form control in the page that is labelled by the text, then returns it.
+// The label can be a <label> element, the aria-labelledby, or the aria-label attribute.
+// The text is matched as a case-insensitive substring, the whitespaces are collapsed.
+func (p *Page) ElementByLabel(text string) (*Element, error) {
+	el, err := p.ElementByJS(evalHelper(js.ElementByLabel, text))
+	return el, timeoutErr(p.ctx, err, "ElementByLabel(`%s`)", text)
+}
+
+// ElementByPlaceholder retries until an element in the page whose placeholder matches the text,
+// then returns it. The text is matched the same way as [Page.ElementByLabel].
+func (p *Page) ElementByPlaceholder(text string) (*Element, error) {
+	el, err := p.ElementByJS(evalHelper(js.ElementByPlaceholder, text))
+	return el, timeoutErr(p.ctx, err, "ElementByPlaceholder(`%s`)", text)
+}
+
+// ElementByTestID retries until an element in the page whose [TestIDAttribute] equals the id, then returns it.
+func (p *Page) ElementByTestID(id string) (*Element, error) {
+	el, err := p.ElementByJS(evalHelper(js.ElementByTestID, TestIDAttribute, id))
+	return el, timeoutErr(p.ctx, err, "ElementByTestID(`%s`)", id)
 }
 
 // ElementByJS returns the element from the return value of the js function.
@@ -164,6 +245,9 @@ func (p *Page) ElementByJS(opts *EvalOptions) (*Element, error) {
 	var res *proto.RuntimeRemoteObject
 	var err error
 
+	record := p.browser.timeline.start(p.ctx, p, TraceTypeQuery, strings.TrimSpace(opts.String()), p.String())
+	defer record()
+
 	removeTrace := func() {}
 	err = utils.Retry(p.ctx, p.sleeper(), func() (bool, error) {
 		remove := p.tryTraceQuery(opts)
@@ -183,7 +267,7 @@ func (p *Page) ElementByJS(opts *EvalOptions) (*Element, error) {
 	})
 	removeTrace()
 	if err != nil {
-		return nil, err
+		return nil, timeoutErr(p.ctx, err, "ElementByJS(%s)", strings.TrimSpace(opts.String()))
 	}
 
 	if res.Subtype != proto.RuntimeRemoteObjectSubtypeNode {
@@ -195,7 +279,29 @@ func (p *Page) ElementByJS(opts *EvalOptions) (*Element, error) {
 
 // Elements returns all elements that match the css selector
 func (p *Page) Elements(selector string) (Elements, error) {
-	return p.ElementsByJS(evalHelper(js.Elements, selector))
+	return p.ElementsByJS(evalHelper(js.Elements, selector).polyfillFor(selector))
+}
+
+// ElementNth retries until the n-th element in the page that matches the css selector exists, then returns it.
+// n starts from 0, a negative n counts from the end, such as -1 is the last one.
+// Only the n-th element will be resolved, so it's cheaper than [Page.Elements] for a large result set.
+func (p *Page) ElementNth(selector string, n int) (*Element, error) {
+	el, err := p.ElementByJS(evalHelper(js.ElementNth, selector, n).polyfillFor(selector))
+	return el, timeoutErr(p.ctx, err, "ElementNth(`%s`, %d)", selector, n)
+}
+
+// ElementsFilter returns the elements that match the css selector and the js predicate. The predicate
+// is a js function like "(el, i) => el.offsetParent !== null", it runs in the browser before the elements are
+// resolved as remote objects, so the unmatched elements won't cost any round trip.
+func (p *Page) ElementsFilter(selector, jsPredicate string) (Elements, error) {
+	return p.ElementsByJS(elementsFilter(selector, jsPredicate))
+}
+
+func elementsFilter(selector, predicate string) *EvalOptions {
+	return Eval(fmt.Sprintf(
+		`s => Array.from((this.querySelectorAll ? this : document).querySelectorAll(s)).filter(%s)`,
+		predicate,
+	), selector)
 }
 
 // ElementsX returns all elements that match the XPath selector
@@ -203,6 +309,53 @@ func (p *Page) ElementsX(xpath string) (Elements, error) {
 	return p.ElementsByJS(evalHelper(js.ElementsX, xpath))
 }
 
+// EachElement calls fn for each element that matches the css selector in the document order, return true
+// to stop. Unlike [Page.Elements], the elements are resolved in small batches and each element will be released
+// after fn returns, so the element can't be used after the fn returns. It's useful for the pages that have
+// tens of thousands of matched elements.
+func (p *Page) EachElement(selector string, fn func(*Element) (stop bool)) error {
+	return p.eachElement(evalHelper(js.Elements, selector), fn)
+}
+
+const eachElementBatch = 100
+
+func (p *Page) eachElement(opts *EvalOptions, fn func(*Element) bool) error {
+	list, err := p.Evaluate(opts.ByObject())
+	if err != nil {
+		return err
+	}
+	defer func() { _ = p.Release(list) }()
+
+	for start := 0; ; start += eachElementBatch {
+		batch, err := p.ElementsByJS(Eval(
+			`(start, end) => Array.prototype.slice.call(this, start, end)`,
+			start, start+eachElementBatch,
+		).This(list))
+		if err != nil {
+			return err
+		}
+		if batch.Empty() {
+			return nil
+		}
+
+		for i, el := range batch {
+			stop := fn(el)
+
+			err = el.Release()
+			if err != nil {
+				return err
+			}
+
+			if stop {
+				for _, rest := range batch[i+1:] {
+					_ = rest.Release()
+				}
+				return nil
+			}
+		}
+	}
+}
+
 // ElementsByJS returns the elements from the return value of the js
 func (p *Page) ElementsByJS(opts *EvalOptions) (Elements, error) {
 	res, err := p.Evaluate(opts.ByObject())
@@ -520,7 +673,7 @@ func (el *Element) HasR(selector, jsRegex string) (bool, *Element, error) {
 
 // Element returns the first child that matches the css selector
 func (el *Element) Element(selector string) (*Element, error) {
-	return el.ElementByJS(evalHelper(js.Element, selector))
+	return el.ElementByJS(evalHelper(js.Element, selector).polyfillFor(selector))
 }
 
 // ElementR returns the first child element that matches the css selector and its text matches the jsRegex.
@@ -533,6 +686,21 @@ func (el *Element) ElementX(xPath string) (*Element, error) {
 	return el.ElementByJS(evalHelper(js.ElementX, xPath))
 }
 
+// ElementByLabel returns the first child that is labelled by the text, check [Page.ElementByLabel] for details.
+func (el *Element) ElementByLabel(text string) (*Element, error) {
+	return el.ElementByJS(evalHelper(js.ElementByLabel, text))
+}
+
+// ElementByPlaceholder returns the first child whose placeholder matches the text.
+func (el *Element) ElementByPlaceholder(text string) (*Element, error) {
+	return el.ElementByJS(evalHelper(js.ElementByPlaceholder, text))
+}
+
+// ElementByTestID returns the first child whose [TestIDAttribute] equals the id.
+func (el *Element) ElementByTestID(id string) (*Element, error) {
+	return el.ElementByJS(evalHelper(js.ElementByTestID, TestIDAttribute, id))
+}
+
 // ElementByJS returns the element from the return value of the js
 func (el *Element) ElementByJS(opts *EvalOptions) (*Element, error) {
 	e, err := el.page.Context(el.ctx).Sleeper(NotFoundSleeper).ElementByJS(opts.This(el.Object))
@@ -564,7 +732,18 @@ func (el *Element) Previous() (*Element, error) {
 
 // Elements returns all elements that match the css selector
 func (el *Element) Elements(selector string) (Elements, error) {
-	return el.ElementsByJS(evalHelper(js.Elements, selector))
+	return el.ElementsByJS(evalHelper(js.Elements, selector).polyfillFor(selector))
+}
+
+// ElementNth returns the n-th child that matches the css selector, check [Page.ElementNth] for details.
+func (el *Element) ElementNth(selector string, n int) (*Element, error) {
+	return el.ElementByJS(evalHelper(js.ElementNth, selector, n).polyfillFor(selector))
+}
+
+// ElementsFilter returns the children that match the css selector and the js predicate,
+// check [Page.ElementsFilter] for details.
+func (el *Element) ElementsFilter(selector, jsPredicate string) (Elements, error) {
+	return el.ElementsByJS(elementsFilter(selector, jsPredicate))
 }
 
 // ElementsX returns all elements that match the XPath selector
@@ -572,6 +751,11 @@ func (el *Element) ElementsX(xpath string) (Elements, error) {
 	return el.ElementsByJS(evalHelper(js.ElementsX, xpath))
 }
 
+// EachElement calls fn for each child that matches the css selector, check [Page.EachElement] for details.
+func (el *Element) EachElement(selector string, fn func(*Element) (stop bool)) error {
+	return el.page.Context(el.ctx).eachElement(evalHelper(js.Elements, selector).This(el.Object), fn)
+}
+
 // ElementsByJS returns the elements from the return value of the js
 func (el *Element) ElementsByJS(opts *EvalOptions) (Elements, error) {
 	return el.page.Context(el.ctx).ElementsByJS(opts.This(el.Object))
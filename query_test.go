@@ -3,6 +3,7 @@ package rod_test
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 
@@ -24,6 +25,26 @@ func TestPageElements(t *testing.T) {
 	g.Eq("submit", list.Last().MustText())
 }
 
+func TestElementsEvalAll(t *testing.T) {
+	g := setup(t)
+
+	p := g.page.MustNavigate(g.srcFile("fixtures/input.html"))
+	list := p.MustElements("option")
+
+	res, err := list.EvalAll(`(prefix, first) => prefix + first.innerText + this.innerText`, "x", list[0].Object)
+	g.E(err)
+	g.Len(res, 4)
+	g.Eq("xAB", res[1].Str())
+
+	res, err = rod.Elements{}.EvalAll(`() => 1`)
+	g.E(err)
+	g.Len(res, 0)
+
+	g.mc.stubErr(1, proto.RuntimeCallFunctionOn{})
+	_, err = list.EvalAll(`() => 1`)
+	g.Err(err)
+}
+
 func TestPagesQuery(t *testing.T) {
 	g := setup(t)
 
@@ -422,6 +443,36 @@ func TestPageElementTimeout(t *testing.T) {
 	g.Gte(time.Since(start), 300*time.Millisecond)
 }
 
+func TestTimeoutErr(t *testing.T) {
+	g := setup(t)
+
+	page := g.page.MustNavigate(g.blank())
+
+	_, err := page.Timeout(100 * time.Millisecond).Element("#login")
+	g.Is(err, &rod.ErrTimeout{})
+	g.Regex(`^timeout after 100ms \(\d+ms elapsed\) waiting Element\(`+"`#login`"+`\)$`, err.Error())
+
+	var e *rod.ErrTimeout
+	g.True(errors.As(err, &e))
+	g.Gte(e.Elapsed, 100*time.Millisecond)
+
+	err = page.WithDeadline(time.Now().Add(100 * time.Millisecond)).Wait(rod.Eval(`() => false`))
+	g.Is(err, context.DeadlineExceeded)
+	g.Has(err.Error(), "waiting Wait(() => false())")
+
+	ctx, cancel := context.WithTimeout(g.Context(), 100*time.Millisecond)
+	defer cancel()
+	_, err = page.Context(ctx).ElementX("//login")
+	g.Eq(err.Error(), "timeout waiting ElementX(`//login`)")
+
+	el := page.MustElement("body")
+	err = el.Timeout(100 * time.Millisecond).WaitInvisible()
+	g.Regex(`^timeout after 100ms \(\d+ms elapsed\) waiting WaitInvisible\(\) on <body>$`, err.Error())
+
+	err = el.WithDeadline(time.Now()).CancelTimeout().WaitVisible()
+	g.E(err)
+}
+
 func TestPageElementMaxRetry(t *testing.T) {
 	g := setup(t)
 
@@ -438,3 +489,110 @@ func TestElementsOthers(t *testing.T) {
 	g.Nil(list.First())
 	g.Nil(list.Last())
 }
+
+func TestElementByLabelPlaceholderTestID(t *testing.T) {
+	g := setup(t)
+
+	p := g.page.MustNavigate(g.srcFile("fixtures/selectors.html"))
+
+	g.Eq(*p.MustElementByLabel("full name").MustAttribute("id"), "name")
+	g.Eq(*p.MustElementByLabel("Email").MustAttribute("id"), "email")
+	g.Eq(*p.MustElementByLabel("phone").MustAttribute("id"), "phone")
+	g.Eq(*p.MustElementByLabel("search the").MustAttribute("id"), "search")
+	g.Eq(*p.MustElementByPlaceholder("to search").MustAttribute("id"), "search")
+	g.Eq(p.MustElementByTestID("submit").MustText(), "Submit")
+	g.Eq(p.MustElementByTestID(`say "hi"`).MustText(), "Quote")
+
+	form := p.MustElement("form")
+	g.Eq(*form.MustElementByLabel("Email").MustAttribute("id"), "email")
+	g.Eq(*form.MustElementByPlaceholder("search").MustAttribute("id"), "search")
+	g.Eq(form.MustElementByTestID("submit").MustText(), "Submit")
+
+	old := rod.TestIDAttribute
+	defer func() { rod.TestIDAttribute = old }()
+	rod.TestIDAttribute = "data-test"
+	g.Eq(p.MustElementByTestID("cancel").MustText(), "Cancel")
+
+	_, err := p.Sleeper(rod.NotFoundSleeper).ElementByLabel("not exists")
+	g.Is(err, &rod.ErrElementNotFound{})
+
+	_, err = p.Sleeper(rod.NotFoundSleeper).ElementByPlaceholder("not exists")
+	g.Is(err, &rod.ErrElementNotFound{})
+
+	_, err = form.Sleeper(rod.NotFoundSleeper).ElementByTestID("not exists")
+	g.Is(err, &rod.ErrElementNotFound{})
+}
+
+func TestElementNthAndFilter(t *testing.T) {
+	g := setup(t)
+
+	p := g.page.MustNavigate(g.html(`<html><body><ul>
+		<li>0</li><li class="odd">1</li><li>2</li><li class="odd">3</li><li hidden>4</li>
+	</ul></body></html>`))
+
+	g.Eq(p.MustElementNth("li", 1).MustText(), "1")
+	g.Eq(p.MustElementNth("li", -2).MustText(), "3")
+
+	ul := p.MustElement("ul")
+	g.Eq(ul.MustElementNth("li", 0).MustText(), "0")
+
+	_, err := p.Sleeper(rod.NotFoundSleeper).ElementNth("li", 10)
+	g.Is(err, &rod.ErrElementNotFound{})
+
+	list := p.MustElementsFilter("li", `el => !el.hidden`)
+	g.Len(list, 4)
+
+	list = ul.MustElementsFilter("li", `(el, i) => i % 2 === 1`)
+	g.Len(list, 2)
+	g.Eq(list.First().MustText(), "1")
+
+	odd := p.MustElements("li").Filter(func(el *rod.Element) bool {
+		return el.MustMatches(".odd")
+	})
+	g.Len(odd, 2)
+	g.Eq(odd.Last().MustText(), "3")
+
+	_, err = p.ElementsFilter("li", `el => {`)
+	g.Err(err)
+}
+
+func TestEachElement(t *testing.T) {
+	g := setup(t)
+
+	p := g.page.MustNavigate(g.blank())
+	p.MustEval(`() => {
+		for (let i = 0; i < 250; i++) {
+			const el = document.createElement('p')
+			el.innerText = i
+			document.body.append(el)
+		}
+	}`)
+
+	count := 0
+	p.MustEachElement("p", func(el *rod.Element) bool {
+		g.Eq(el.MustText(), fmt.Sprint(count))
+		count++
+		return false
+	})
+	g.Eq(count, 250)
+
+	count = 0
+	p.MustElement("body").MustEachElement("p", func(el *rod.Element) bool {
+		count++
+		return count == 120
+	})
+	g.Eq(count, 120)
+
+	g.E(p.EachElement("not-exists", func(el *rod.Element) bool {
+		panic("should not be called")
+	}))
+
+	g.mc.stubErr(1, proto.RuntimeCallFunctionOn{})
+	g.Err(p.EachElement("p", func(el *rod.Element) bool { return false }))
+
+	g.mc.stubErr(2, proto.RuntimeCallFunctionOn{})
+	g.Err(p.EachElement("p", func(el *rod.Element) bool { return false }))
+
+	g.mc.stubErr(2, proto.RuntimeReleaseObject{})
+	g.Err(p.EachElement("p", func(el *rod.Element) bool { return false }))
+}
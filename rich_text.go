@@ -0,0 +1,166 @@
+// This file serves for the input of the contenteditable elements and the rich-text editors.
+
+package rod
+
+import (
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/Fromsko/rodPro/lib/js"
+)
+
+// RichTextFormat of [Element.InputRichText]
+type RichTextFormat string
+
+const (
+	// RichTextPlain is the plain text
+	RichTextPlain RichTextFormat = "text/plain"
+
+	// RichTextHTML is the HTML fragment
+	RichTextHTML RichTextFormat = "text/html"
+
+	// RichTextMarkdown is the markdown, it will be converted to HTML before the input,
+	// only the common syntax is supported: headings, paragraphs, lists, quotes, code blocks, emphasis, code, and links.
+	RichTextMarkdown RichTextFormat = "text/markdown"
+)
+
+// InputRichText inputs the content to the end of a contenteditable element, such as the editors like
+// ProseMirror, Quill, and CKEditor. The plain text is inserted via [Page.InsertText] which triggers the native
+// beforeinput and input events. The HTML and markdown are pasted via a simulated paste event that carries
+// both the text/html and text/plain data, if the editor doesn't handle it, an insertFromPaste beforeinput
+// event is dispatched, then the HTML is inserted via the execCommand as the fallback.
+func (el *Element) InputRichText(content string, format RichTextFormat) error {
+	err := el.Focus()
+	if err != nil {
+		return err
+	}
+
+	err = el.WaitEnabled()
+	if err != nil {
+		return err
+	}
+
+	defer el.tryTrace(TraceTypeInput, "input rich text")()
+
+	_, err = el.Evaluate(evalHelper(js.CaretToEnd).ByUser())
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case RichTextHTML:
+		_, err = el.Evaluate(evalHelper(js.PasteRichText, content, "").ByUser())
+	case RichTextMarkdown:
+		_, err = el.Evaluate(evalHelper(js.PasteRichText, markdownToHTML(content), content).ByUser())
+	default:
+		err = el.page.Context(el.ctx).InsertText(content)
+	}
+	return err
+}
+
+var (
+	regMDHeading = regexp.MustCompile(`^(#{1,6})\s+(.*)$`)
+	regMDUL      = regexp.MustCompile(`^\s*[-*+]\s+(.*)$`)
+	regMDOL      = regexp.MustCompile(`^\s*\d+[.)]\s+(.*)$`)
+	regMDQuote   = regexp.MustCompile(`^>\s?(.*)$`)
+	regMDCode    = regexp.MustCompile("`([^`]+)`")
+	regMDStrong  = regexp.MustCompile(`\*\*(.+?)\*\*|\b__(.+?)__\b`)
+	regMDEm      = regexp.MustCompile(`\*([^*]+)\*|\b_([^_]+)_\b`)
+	regMDLink    = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+)
+
+// markdownToHTML converts the common markdown syntax to HTML
+func markdownToHTML(md string) string {
+	out := &strings.Builder{}
+	paragraph := []string{}
+	list := ""
+
+	flushParagraph := func() {
+		if len(paragraph) > 0 {
+			out.WriteString("<p>" + mdInline(strings.Join(paragraph, " ")) + "</p>")
+			paragraph = nil
+		}
+	}
+	closeList := func() {
+		if list != "" {
+			out.WriteString("</" + list + ">")
+			list = ""
+		}
+	}
+	openList := func(tag string) {
+		if list != tag {
+			closeList()
+			out.WriteString("<" + tag + ">")
+			list = tag
+		}
+	}
+
+	lines := strings.Split(strings.ReplaceAll(md, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			flushParagraph()
+			closeList()
+			code := []string{}
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), "```"); i++ {
+				code = append(code, lines[i])
+			}
+			out.WriteString("<pre><code>" + html.EscapeString(strings.Join(code, "\n")) + "</code></pre>")
+			continue
+		}
+
+		if m := regMDHeading.FindStringSubmatch(line); m != nil {
+			flushParagraph()
+			closeList()
+			tag := "h" + string(rune('0'+len(m[1])))
+			out.WriteString("<" + tag + ">" + mdInline(m[2]) + "</" + tag + ">")
+			continue
+		}
+
+		if m := regMDUL.FindStringSubmatch(line); m != nil {
+			flushParagraph()
+			openList("ul")
+			out.WriteString("<li>" + mdInline(m[1]) + "</li>")
+			continue
+		}
+
+		if m := regMDOL.FindStringSubmatch(line); m != nil {
+			flushParagraph()
+			openList("ol")
+			out.WriteString("<li>" + mdInline(m[1]) + "</li>")
+			continue
+		}
+
+		if m := regMDQuote.FindStringSubmatch(line); m != nil {
+			flushParagraph()
+			closeList()
+			out.WriteString("<blockquote>" + mdInline(m[1]) + "</blockquote>")
+			continue
+		}
+
+		if strings.TrimSpace(line) == "" {
+			flushParagraph()
+			closeList()
+			continue
+		}
+
+		closeList()
+		paragraph = append(paragraph, strings.TrimSpace(line))
+	}
+
+	flushParagraph()
+	closeList()
+
+	return out.String()
+}
+
+func mdInline(s string) string {
+	s = html.EscapeString(s)
+	s = regMDCode.ReplaceAllString(s, "<code>$1</code>")
+	s = regMDLink.ReplaceAllString(s, `<a href="$2">$1</a>`)
+	s = regMDStrong.ReplaceAllString(s, "<strong>$1$2</strong>")
+	s = regMDEm.ReplaceAllString(s, "<em>$1$2</em>")
+	return s
+}
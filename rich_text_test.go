@@ -0,0 +1,56 @@
+package rod_test
+
+import (
+	"testing"
+
+	"github.com/Fromsko/rodPro"
+	"github.com/Fromsko/rodPro/lib/proto"
+)
+
+func TestInputRichText(t *testing.T) {
+	g := setup(t)
+
+	p := g.page.MustNavigate(g.html(`<html><body><div id="editor" contenteditable><p>start</p></div></body></html>`))
+	el := p.MustElement("#editor")
+
+	el.MustInputRichText(" plain", rod.RichTextPlain)
+	g.Eq(el.MustText(), "start plain")
+
+	el.MustInputRichText("<b>bold</b>", rod.RichTextHTML)
+	g.Has(el.MustHTML(), "<b>bold</b>")
+
+	el.MustInputRichText("# Title\n\nsome **strong** and *em* `code` [link](https://a.com)\n\n- a\n- b", rod.RichTextMarkdown)
+	html := el.MustHTML()
+	g.Has(html, "<h1>Title</h1>")
+	g.Has(html, "<strong>strong</strong>")
+	g.Has(html, "<em>em</em>")
+	g.Has(html, "<code>code</code>")
+	g.Has(html, `<a href="https://a.com">link</a>`)
+	g.Has(html, "<li>b</li>")
+
+	g.mc.stubErr(1, proto.RuntimeCallFunctionOn{})
+	g.Err(el.InputRichText("a", rod.RichTextPlain))
+
+	g.mc.stubErr(3, proto.RuntimeCallFunctionOn{})
+	g.Err(el.InputRichText("a", rod.RichTextPlain))
+
+	g.mc.stubErr(4, proto.RuntimeCallFunctionOn{})
+	g.Err(el.InputRichText("a", rod.RichTextPlain))
+}
+
+func TestInputRichTextEditor(t *testing.T) {
+	g := setup(t)
+
+	// an editor that handles the paste event by itself
+	p := g.page.MustNavigate(g.html(`<html><body><div id="editor" contenteditable></div><script>
+		editor.addEventListener('paste', e => {
+			e.preventDefault()
+			editor.dataset.pasted = e.clipboardData.getData('text/plain')
+		})
+	</script></body></html>`))
+	el := p.MustElement("#editor")
+
+	el.MustInputRichText("<i>a</i> b", rod.RichTextHTML)
+	g.Eq(*el.MustAttribute("data-pasted"), "a b")
+	g.Eq(el.MustHTML(), `<div id="editor" contenteditable="" data-pasted="a b"></div>`)
+}
@@ -0,0 +1,351 @@
+// This file serves for the polyfill of the selectors that old browsers don't support.
+
+package rod
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/Fromsko/rodPro/lib/js"
+	"github.com/Fromsko/rodPro/lib/proto"
+	"github.com/ysmood/gson"
+)
+
+// PolyfillSelectors installs a polyfill of the css ":has()" pseudo-class for the browsers that don't support it
+// natively, such as Chromium before 105, so that the same selectors work across the browser revisions you pin.
+// The polyfill lives in an isolated world, the js of the page is untouched. The css queries of rod that use
+// ":has()", such as [Page.Element], [Page.Elements], and [Element.Matches], run in that world, the matched
+// elements are returned as usual. The polyfill survives reloads.
+// If force is false, the polyfill won't be installed when the browser supports ":has()" natively.
+// Call remove to stop injecting it into the new documents.
+//
+// The polyfill evaluates each ":has()" by marking the matched elements with temporary attributes,
+// so the MutationObserver of the page may notice the marks.
+func (p *Page) PolyfillSelectors(force bool) (remove func() error, err error) {
+	code := fmt.Sprintf(`(%s)(%v)`, selectorPolyfill, force)
+
+	s, err := p.addNewDocumentScript("rod-selector-polyfill", code, selectorWorld)
+	if err != nil {
+		if s != nil {
+			_ = s.Remove()
+		}
+		return
+	}
+
+	p.polyfill.enable(code)
+
+	_, _, err = p.selectorWorld()
+	if err != nil {
+		p.polyfill.disable()
+		_ = s.Remove()
+		return
+	}
+
+	return func() error {
+		p.polyfill.disable()
+		return s.Remove()
+	}, nil
+}
+
+// selectorWorld is the name of the isolated world of [Page.PolyfillSelectors]
+const selectorWorld = "rod_selector_polyfill"
+
+// selectorPolyfillState is shared by the clones of a page
+type selectorPolyfillState struct {
+	lock sync.Mutex
+	code string // the polyfill, it's empty when the polyfill is not installed
+
+	// the window objects of the main world and the isolated world of the latest document
+	main, window proto.RuntimeRemoteObjectID
+	ctxID        proto.RuntimeExecutionContextID
+}
+
+func (s *selectorPolyfillState) enable(code string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.code = code
+	s.main = ""
+}
+
+func (s *selectorPolyfillState) disable() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.code = ""
+	s.main = ""
+}
+
+func (s *selectorPolyfillState) isEnabled() bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.code != ""
+}
+
+// polyfillFor marks the query to run in the world of [Page.PolyfillSelectors] if the selector needs it
+func (e *EvalOptions) polyfillFor(selector string) *EvalOptions {
+	e.polyfill = strings.Contains(selector, ":has(")
+	return e
+}
+
+// selectorWorld returns the window object and the execution context of the isolated world of the current
+// document. The world is shared with the new document script, because the cdp reuses the world with the same name,
+// the polyfill is evaluated again in case the document was created before the polyfill was installed.
+func (p *Page) selectorWorld() (proto.RuntimeRemoteObjectID, proto.RuntimeExecutionContextID, error) {
+	main, err := p.getJSCtxID()
+	if err != nil {
+		return "", 0, err
+	}
+
+	s := p.polyfill
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.main == main {
+		return s.window, s.ctxID, nil
+	}
+
+	world, err := proto.PageCreateIsolatedWorld{FrameID: p.FrameID, WorldName: selectorWorld}.Call(p)
+	if err != nil {
+		return "", 0, err
+	}
+
+	res, err := proto.RuntimeEvaluate{
+		Expression: s.code + "; window",
+		ContextID:  world.ExecutionContextID,
+	}.Call(p)
+	if err != nil {
+		return "", 0, err
+	}
+	if res.ExceptionDetails != nil {
+		return "", 0, &ErrEval{res.ExceptionDetails}
+	}
+
+	s.main, s.window, s.ctxID = main, res.Result.ObjectID, world.ExecutionContextID
+
+	return s.window, s.ctxID, nil
+}
+
+// evaluateInWorld evaluates the query in the isolated world of the polyfill,
+// the nodes are moved between the worlds via their backend node ids.
+func (p *Page) evaluateInWorld(opts *EvalOptions) (*proto.RuntimeRemoteObject, error) {
+	window, ctxID, err := p.selectorWorld()
+	if err != nil {
+		return nil, err
+	}
+
+	req := proto.RuntimeCallFunctionOn{
+		ObjectID:            window,
+		AwaitPromise:        opts.AwaitPromise,
+		ReturnByValue:       opts.ByValue,
+		UserGesture:         opts.UserGesture,
+		FunctionDeclaration: opts.formatToJSFunc(),
+	}
+
+	if opts.ThisObj != nil {
+		req.ObjectID, err = p.moveNode(opts.ThisObj.ObjectID, ctxID)
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = p.Release(&proto.RuntimeRemoteObject{ObjectID: req.ObjectID}) }()
+	}
+
+	for _, arg := range opts.JSArgs {
+		if fn, ok := arg.(*js.Function); ok {
+			id, err := p.ensureJSHelperIn(window, fn)
+			if err != nil {
+				return nil, err
+			}
+			req.Arguments = append(req.Arguments, &proto.RuntimeCallArgument{ObjectID: id})
+		} else {
+			req.Arguments = append(req.Arguments, &proto.RuntimeCallArgument{Value: gson.New(arg)})
+		}
+	}
+
+	res, err := req.Call(p)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.ExceptionDetails != nil {
+		return nil, &ErrEval{res.ExceptionDetails}
+	}
+
+	return p.fromWorld(res.Result)
+}
+
+// fromWorld moves the node or the array of nodes from the isolated world to the main world
+func (p *Page) fromWorld(obj *proto.RuntimeRemoteObject) (*proto.RuntimeRemoteObject, error) {
+	switch obj.Subtype {
+	case proto.RuntimeRemoteObjectSubtypeNode:
+		defer func() { _ = p.Release(obj) }()
+
+		id, err := p.moveNode(obj.ObjectID, 0)
+		if err != nil {
+			return nil, err
+		}
+		return &proto.RuntimeRemoteObject{
+			Type:        obj.Type,
+			Subtype:     obj.Subtype,
+			ClassName:   obj.ClassName,
+			Description: obj.Description,
+			ObjectID:    id,
+		}, nil
+
+	case proto.RuntimeRemoteObjectSubtypeArray:
+		defer func() { _ = p.Release(obj) }()
+
+		list, err := proto.RuntimeGetProperties{ObjectID: obj.ObjectID, OwnProperties: true}.Call(p)
+		if err != nil {
+			return nil, err
+		}
+
+		args := []*proto.RuntimeCallArgument{}
+		for _, prop := range list.Result {
+			if prop.Name == "__proto__" || prop.Name == "length" || prop.Value.Subtype != proto.RuntimeRemoteObjectSubtypeNode {
+				continue
+			}
+			id, err := p.moveNode(prop.Value.ObjectID, 0)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, &proto.RuntimeCallArgument{ObjectID: id})
+		}
+
+		main, err := p.getJSCtxID()
+		if err != nil {
+			return nil, err
+		}
+
+		res, err := proto.RuntimeCallFunctionOn{
+			ObjectID:            main,
+			FunctionDeclaration: `function () { return Array.from(arguments) }`,
+			Arguments:           args,
+		}.Call(p)
+		if err != nil {
+			return nil, err
+		}
+		return res.Result, nil
+	}
+
+	return obj, nil
+}
+
+// moveNode resolves the node in the execution context, 0 means the main world of the node's frame
+func (p *Page) moveNode(id proto.RuntimeRemoteObjectID, ctxID proto.RuntimeExecutionContextID) (proto.RuntimeRemoteObjectID, error) {
+	node, err := proto.DOMDescribeNode{ObjectID: id}.Call(p)
+	if err != nil {
+		return "", err
+	}
+
+	res, err := proto.DOMResolveNode{BackendNodeID: node.Node.BackendNodeID, ExecutionContextID: ctxID}.Call(p)
+	if err != nil {
+		return "", err
+	}
+
+	return res.Object.ObjectID, nil
+}
+
+const selectorPolyfill = `function (force) {
+	if (!force && CSS.supports('selector(:has(a))')) return
+	if (Element.prototype.querySelector.rodPolyfill) return
+
+	const natives = [Element, Document, DocumentFragment].map((type) => {
+		const proto = type.prototype
+		return [type, { querySelector: proto.querySelector, querySelectorAll: proto.querySelectorAll }]
+	})
+	const query = (node, name, sel) => {
+		for (const [type, fns] of natives) if (node instanceof type) return fns[name].call(node, sel)
+	}
+
+	// the index of the ")" that closes the "(" before i
+	const closing = (s, i) => {
+		let depth = 1
+		let quote = null
+		for (; i < s.length; i++) {
+			const c = s[i]
+			if (c === '\\') i++
+			else if (quote) quote = c === quote ? null : quote
+			else if (c === '"' || c === "'") quote = c
+			else if (c === '(') depth++
+			else if (c === ')' && --depth === 0) return i
+		}
+		throw new DOMException("'" + s + "' is not a valid selector", 'SyntaxError')
+	}
+
+	// split the selector list by the top-level commas
+	const split = (s) => {
+		const list = []
+		let depth = 0
+		let quote = null
+		let start = 0
+		for (let i = 0; i < s.length; i++) {
+			const c = s[i]
+			if (c === '\\') i++
+			else if (quote) quote = c === quote ? null : quote
+			else if (c === '"' || c === "'") quote = c
+			else if (c === '(' || c === '[') depth++
+			else if (c === ')' || c === ']') depth--
+			else if (c === ',' && depth === 0) {
+				list.push(s.slice(start, i))
+				start = i + 1
+			}
+		}
+		list.push(s.slice(start))
+		return list.map((s) => s.trim())
+	}
+
+	// check if the relative selector list matches any element relative to el
+	const has = (el, relative) =>
+		split(relative).some((s) => {
+			if (s[0] === '+' || s[0] === '~') {
+				const parent = el.parentNode
+				if (!parent) return false
+				const nth = Array.prototype.indexOf.call(parent.children, el) + 1
+				return !!query(parent, 'querySelector', ':scope > :nth-child(' + nth + ') ' + s)
+			}
+			return !!query(el, 'querySelector', ':scope ' + s)
+		})
+
+	let seq = 0
+
+	// replace each ":has(...)" with an attribute selector and mark the matched elements with the attribute
+	const rewrite = (top, sel, marks) => {
+		for (;;) {
+			const i = sel.indexOf(':has(')
+			if (i < 0) return sel
+
+			const end = closing(sel, i + 5)
+			const relative = rewrite(top, sel.slice(i + 5, end), marks)
+			const attr = 'data-rod-has-' + seq++
+			for (const el of query(top, 'querySelectorAll', '*')) {
+				if (has(el, relative)) {
+					el.setAttribute(attr, '')
+					marks.push([el, attr])
+				}
+			}
+			sel = sel.slice(0, i) + '[' + attr + ']' + sel.slice(end + 1)
+		}
+	}
+
+	const patch = (proto, name) => {
+		const native = proto[name]
+		proto[name] = function (sel) {
+			if (typeof sel !== 'string' || !sel.includes(':has(')) return native.apply(this, arguments)
+
+			const marks = []
+			try {
+				return native.call(this, rewrite(this.getRootNode ? this.getRootNode() : this, sel, marks))
+			} finally {
+				for (const [el, attr] of marks) el.removeAttribute(attr)
+			}
+		}
+		proto[name].rodPolyfill = true
+	}
+
+	for (const type of [Element, Document, DocumentFragment]) {
+		patch(type.prototype, 'querySelector')
+		patch(type.prototype, 'querySelectorAll')
+	}
+	patch(Element.prototype, 'matches')
+	patch(Element.prototype, 'closest')
+}`
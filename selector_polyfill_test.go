@@ -0,0 +1,50 @@
+package rod_test
+
+import (
+	"testing"
+
+	"github.com/Fromsko/rodPro/lib/proto"
+)
+
+func TestPolyfillSelectors(t *testing.T) {
+	g := setup(t)
+
+	p := g.newPage(g.srcFile("fixtures/has.html"))
+
+	remove, err := p.PolyfillSelectors(true)
+	g.E(err)
+
+	// the js of the page is untouched
+	g.False(p.MustEval(`() => !!document.querySelector.rodPolyfill`).Bool())
+
+	g.Eq(*p.MustElement("div:has(> .x)").MustAttribute("id"), "a")
+	g.Eq(*p.MustElement("div:has(.y, .z)").MustAttribute("id"), "b")
+	g.Eq(*p.MustElement("div:has(p:has(.y))").MustAttribute("id"), "b")
+	g.Eq(*p.MustElement("h2:has(+ p)").MustAttribute("id"), "c")
+	g.Eq(*p.MustElementNth("div:has(p)", 0).MustAttribute("id"), "b")
+	g.Len(p.MustElements("body > :has(span)"), 2)
+	g.True(p.MustElement("#a").MustMatches(":has(.x)"))
+	g.Eq(*p.MustElement("body").MustElement("div:has(> .x)").MustAttribute("id"), "a")
+	g.Eq(p.MustElement("div:has(> .x)").MustEval(`() => this.id`).Str(), "a")
+	g.Len(p.MustElements("[data-rod-has-0]"), 0)
+
+	p.MustReload()
+	g.Eq(*p.MustElement("div:has(> .x)").MustAttribute("id"), "a")
+
+	g.E(remove())
+	p.MustReload()
+	g.False(p.MustEval(`() => !!document.querySelector.rodPolyfill`).Bool())
+
+	_, err = p.PolyfillSelectors(false)
+	g.E(err)
+	g.Eq(*p.MustElement("div:has(> .x)").MustAttribute("id"), "a")
+
+	g.mc.stubErr(1, proto.PageAddScriptToEvaluateOnNewDocument{})
+	g.Err(p.PolyfillSelectors(true))
+
+	// the new document script is removed when the polyfill can't be evaluated
+	count := len(p.NewDocumentScripts())
+	g.mc.stubErr(1, proto.PageCreateIsolatedWorld{})
+	g.Err(p.PolyfillSelectors(true))
+	g.Eq(len(p.NewDocumentScripts()), count-1)
+}
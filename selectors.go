@@ -0,0 +1,280 @@
+// This file serves for suggesting the selectors of an element and locating the element with the fallback selectors.
+
+package rod
+
+import (
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/Fromsko/rodPro/lib/proto"
+	"github.com/Fromsko/rodPro/lib/utils"
+)
+
+// SelectorKind of [SelectorCandidate]
+type SelectorKind string
+
+const (
+	// SelectorKindTestID uses the test id attributes, such as [TestIDAttribute]
+	SelectorKindTestID SelectorKind = "test-id"
+
+	// SelectorKindID uses the id attribute
+	SelectorKindID SelectorKind = "id"
+
+	// SelectorKindAttribute uses the attributes such as name, aria-label, placeholder, title, and alt
+	SelectorKindAttribute SelectorKind = "attribute"
+
+	// SelectorKindText uses the tag name and the text of the element, use it via [Page.ElementR]
+	SelectorKindText SelectorKind = "text"
+
+	// SelectorKindCSSPath uses the css path from the nearest ancestor that has a unique id
+	SelectorKindCSSPath SelectorKind = "css-path"
+
+	// SelectorKindXPath uses the full XPath from the root, use it via [Page.ElementX]
+	SelectorKindXPath SelectorKind = "xpath"
+)
+
+// SelectorCandidate of [Element.Selectors]
+type SelectorCandidate struct {
+	Kind SelectorKind
+
+	// Selector is the css selector, or the XPath for [SelectorKindXPath]
+	Selector string
+
+	// Text is the js regex of the text for [SelectorKindText]
+	Text string
+
+	// Score of the stability from 0 to 1, higher means the selector is less likely to break when the page changes.
+	// Such as the test ids are more stable than the ids that look generated, and the css paths with
+	// many :nth-of-type are the most fragile.
+	Score float64
+}
+
+// Find the element via the candidate
+func (c SelectorCandidate) Find(p *Page) (*Element, error) {
+	switch c.Kind {
+	case SelectorKindXPath:
+		return p.ElementX(c.Selector)
+	case SelectorKindText:
+		return p.ElementR(c.Selector, c.Text)
+	default:
+		return p.Element(c.Selector)
+	}
+}
+
+// Selectors returns the candidate selectors that match the element, sorted by the [SelectorCandidate.Score]
+// from high to low. Except the css path and the XPath, the candidates that also match other elements are skipped.
+// It's useful to record the actions or to build the self-healing scripts.
+func (el *Element) Selectors() ([]SelectorCandidate, error) {
+	res, err := el.Eval(`(testID) => {
+		const el = this
+		const list = []
+
+		const unique = (s) => {
+			try {
+				const all = document.querySelectorAll(s)
+				return all.length === 1 && all[0] === el
+			} catch (e) {
+				return false
+			}
+		}
+
+		const quote = (v) => '"' + v.replace(/\\/g, '\\\\').replace(/"/g, '\\"') + '"'
+
+		// ids like "ember123" or "a8f3c2d1e9" are usually generated by the frameworks
+		const generated = (v) => /\d{3,}|[0-9a-f]{8,}|^[:_]|:r\w+:/i.test(v)
+
+		for (const attr of new Set([testID, 'data-testid', 'data-test', 'data-qa', 'data-cy'])) {
+			const v = el.getAttribute(attr)
+			if (!v) continue
+			const s = '[' + attr + '=' + quote(v) + ']'
+			if (unique(s)) list.push({ kind: 'test-id', selector: s, score: 1 })
+		}
+
+		if (el.id && unique('#' + CSS.escape(el.id))) {
+			list.push({ kind: 'id', selector: '#' + CSS.escape(el.id), score: generated(el.id) ? 0.5 : 0.9 })
+		}
+
+		const tag = el.tagName.toLowerCase()
+		for (const attr of ['name', 'aria-label', 'placeholder', 'title', 'alt']) {
+			const v = el.getAttribute(attr)
+			if (!v) continue
+			const s = tag + '[' + attr + '=' + quote(v) + ']'
+			if (unique(s)) list.push({ kind: 'attribute', selector: s, score: generated(v) ? 0.4 : 0.8 })
+		}
+
+		const text = (el.innerText || '').trim()
+		if (text && text.length <= 50 && !text.includes('\n')) {
+			const regex = '^\\s*' + text.replace(/[.*+?^${}()|[\]\\]/g, '\\$&') + '\\s*$'
+			const reg = new RegExp(regex)
+			const same = Array.from(document.querySelectorAll(tag)).filter((e) => reg.test((e.innerText || '').trim()))
+			if (same.length === 1 && same[0] === el) list.push({ kind: 'text', selector: tag, text: regex, score: 0.6 })
+		}
+
+		const parts = []
+		let nth = 0
+		for (let e = el; e && e.nodeType === 1; e = e.parentElement) {
+			if (e !== el && e.id && !generated(e.id) && document.querySelectorAll('#' + CSS.escape(e.id)).length === 1) {
+				parts.unshift('#' + CSS.escape(e.id))
+				break
+			}
+			let part = e.tagName.toLowerCase()
+			const same = e.parentElement ? Array.from(e.parentElement.children).filter((c) => c.tagName === e.tagName) : []
+			if (same.length > 1) {
+				part += ':nth-of-type(' + (same.indexOf(e) + 1) + ')'
+				nth++
+			}
+			parts.unshift(part)
+			if (unique(parts.join(' > '))) break
+		}
+		list.push({ kind: 'css-path', selector: parts.join(' > '), score: Math.max(0.1, 0.4 - nth * 0.05) })
+
+		return list
+	}`, TestIDAttribute)
+	if err != nil {
+		return nil, err
+	}
+
+	list := []SelectorCandidate{}
+	for _, c := range res.Value.Arr() {
+		list = append(list, SelectorCandidate{
+			Kind:     SelectorKind(c.Get("kind").Str()),
+			Selector: c.Get("selector").Str(),
+			Text:     c.Get("text").Str(),
+			Score:    c.Get("score").Num(),
+		})
+	}
+
+	xpath, err := el.GetXPath(false)
+	if err != nil {
+		return nil, err
+	}
+	list = append(list, SelectorCandidate{Kind: SelectorKindXPath, Selector: xpath, Score: 0.1})
+
+	sort.SliceStable(list, func(i, j int) bool { return list[i].Score > list[j].Score })
+
+	return list, nil
+}
+
+// BestSelectorFor returns the candidate of [Element.Selectors] that has the highest score
+func (p *Page) BestSelectorFor(el *Element) (*SelectorCandidate, error) {
+	list, err := el.Context(p.ctx).Selectors()
+	if err != nil {
+		return nil, err
+	}
+	return &list[0], nil
+}
+
+// ElementAny retries until an element in the page matches one of the css selectors, the selectors are tried in order
+// in each attempt, so the element of the first selector wins when several of them match.
+// At least one selector is required.
+func (p *Page) ElementAny(selectors ...string) (*Element, error) {
+	el, _, err := p.elementAny(selectors)
+	return el, err
+}
+
+func (p *Page) elementAny(selectors []string) (*Element, int, error) {
+	if len(selectors) == 0 {
+		return nil, -1, errors.New("at least one selector is required")
+	}
+
+	// the element and its index are queried at the same time, so that they are consistent when the page changes
+	var res *proto.RuntimeRemoteObject
+	err := utils.Retry(p.ctx, p.sleeper(), func() (bool, error) {
+		var err error
+		res, err = p.Evaluate(Eval(`(list) => {
+			for (let i = 0; i < list.length; i++) {
+				const el = document.querySelector(list[i])
+				if (el) return [el, i]
+			}
+			return null
+		}`, selectors).ByObject())
+		if err != nil {
+			return true, err
+		}
+		return res.Subtype != proto.RuntimeRemoteObjectSubtypeNull, nil
+	})
+	if err != nil {
+		return nil, -1, timeoutErr(p.ctx, err, "ElementAny(%q)", selectors)
+	}
+
+	props, err := proto.RuntimeGetProperties{ObjectID: res.ObjectID, OwnProperties: true}.Call(p)
+	if err != nil {
+		return nil, -1, err
+	}
+
+	var obj *proto.RuntimeRemoteObject
+	index := -1
+	for _, prop := range props.Result {
+		switch prop.Name {
+		case "0":
+			obj = prop.Value
+		case "1":
+			index = prop.Value.Value.Int()
+		}
+	}
+
+	el, err := p.ElementFromObject(obj)
+	if err != nil {
+		return nil, -1, err
+	}
+
+	return el, index, nil
+}
+
+// Fallback locates an element with a chain of css selectors, the first one is the primary selector,
+// the others are the alternates that are tried in order when the primary one doesn't match.
+// It records which selector matched, so the maintained scrapers can learn when the primary selector broke.
+// It's safe for concurrent use.
+type Fallback struct {
+	// Selectors in order
+	Selectors []string
+
+	// OnDrift is called when an alternate matches instead of the primary selector, it's optional
+	OnDrift func(primary, matched string)
+
+	lock    sync.Mutex
+	matched string
+	drifted bool
+}
+
+// NewFallback creates a [Fallback] with the primary selector and its alternates
+func NewFallback(primary string, alternates ...string) *Fallback {
+	return &Fallback{Selectors: append([]string{primary}, alternates...)}
+}
+
+// Element retries until one of the selectors matches an element in the page
+func (f *Fallback) Element(p *Page) (*Element, error) {
+	el, i, err := p.elementAny(f.Selectors)
+	if err != nil {
+		return nil, err
+	}
+
+	f.lock.Lock()
+	f.matched = f.Selectors[i]
+	f.drifted = i > 0
+	f.lock.Unlock()
+
+	if i > 0 && f.OnDrift != nil {
+		f.OnDrift(f.Selectors[0], f.Selectors[i])
+	}
+
+	return el, nil
+}
+
+// Matched returns the selector that matched in the last [Fallback.Element] call,
+// it's empty if nothing has matched yet.
+func (f *Fallback) Matched() string {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return f.matched
+}
+
+// Drifted returns true if an alternate matched instead of the primary selector in the last [Fallback.Element] call
+func (f *Fallback) Drifted() bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return f.drifted
+}
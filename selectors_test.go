@@ -0,0 +1,110 @@
+package rod_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Fromsko/rodPro"
+	"github.com/Fromsko/rodPro/lib/proto"
+	"github.com/Fromsko/rodPro/lib/utils"
+)
+
+func TestElementSelectors(t *testing.T) {
+	g := setup(t)
+
+	p := g.page.MustNavigate(g.srcFile("fixtures/suggest.html"))
+
+	kinds := func(list []rod.SelectorCandidate) []rod.SelectorKind {
+		out := []rod.SelectorKind{}
+		for _, c := range list {
+			out = append(out, c.Kind)
+		}
+		return out
+	}
+
+	save := p.MustElement("[data-testid=save]")
+	list := save.MustSelectors()
+	g.Eq(kinds(list), []rod.SelectorKind{
+		rod.SelectorKindTestID, rod.SelectorKindID, rod.SelectorKindText, rod.SelectorKindCSSPath, rod.SelectorKindXPath,
+	})
+	g.Eq(list[0].Selector, `[data-testid="save"]`)
+	g.Eq(list[3].Selector, `#main > button:nth-of-type(1)`)
+	for _, c := range list {
+		el, err := c.Find(p)
+		g.E(err)
+		g.True(el.MustEqual(save))
+	}
+
+	cancel := p.MustElement("#ember1234")
+	g.Eq(p.MustBestSelectorFor(cancel).Kind, rod.SelectorKindText)
+	g.Eq(cancel.MustSelectors()[1].Score, 0.5)
+
+	email := p.MustElement("input")
+	g.Eq(p.MustBestSelectorFor(email).Selector, `input[name="email"]`)
+
+	two := p.MustElements("li")[2]
+	list = two.MustSelectors()
+	g.Eq(kinds(list), []rod.SelectorKind{rod.SelectorKindCSSPath, rod.SelectorKindXPath})
+	el, err := list[0].Find(p)
+	g.E(err)
+	g.True(el.MustEqual(two))
+
+	g.mc.stubErr(1, proto.RuntimeCallFunctionOn{})
+	g.Err(save.Selectors())
+
+	g.mc.stubErr(1, proto.RuntimeCallFunctionOn{})
+	g.Err(p.BestSelectorFor(save))
+}
+
+func TestPageElementAny(t *testing.T) {
+	g := setup(t)
+
+	p := g.page.MustNavigate(g.srcFile("fixtures/suggest.html"))
+
+	g.Eq(*p.MustElementAny("#none", "input", "button").MustAttribute("name"), "email")
+
+	go func() {
+		utils.Sleep(0.3)
+		p.MustEval(`() => document.body.insertAdjacentHTML('beforeend', '<p id="later">later</p>')`)
+	}()
+	g.Eq(p.MustElementAny("#none", "#later").MustText(), "later")
+
+	_, err := p.Timeout(300*time.Millisecond).ElementAny("#none", "#none2")
+	g.Is(err, &rod.ErrTimeout{})
+	g.Has(err.Error(), `ElementAny(["#none" "#none2"])`)
+
+	_, err = p.ElementAny()
+	g.Err(err)
+}
+
+func TestFallback(t *testing.T) {
+	g := setup(t)
+
+	p := g.page.MustNavigate(g.srcFile("fixtures/suggest.html"))
+
+	drifts := [][2]string{}
+	f := rod.NewFallback("#save", "[data-testid=save]", "button")
+	f.OnDrift = func(primary, matched string) { drifts = append(drifts, [2]string{primary, matched}) }
+
+	g.Eq(f.Matched(), "")
+	g.False(f.Drifted())
+
+	el, err := f.Element(p)
+	g.E(err)
+	g.Eq(el.MustText(), "Save")
+	g.Eq(f.Matched(), "[data-testid=save]")
+	g.True(f.Drifted())
+	g.Eq(drifts, [][2]string{{"#save", "[data-testid=save]"}})
+
+	f = &rod.Fallback{Selectors: []string{"#save-btn", "button"}}
+	_, err = f.Element(p)
+	g.E(err)
+	g.Eq(f.Matched(), "#save-btn")
+	g.False(f.Drifted())
+
+	g.mc.stubErr(1, proto.RuntimeCallFunctionOn{})
+	g.Err(f.Element(p))
+
+	g.mc.stubErr(2, proto.RuntimeCallFunctionOn{})
+	g.Err(f.Element(p))
+}
@@ -0,0 +1,252 @@
+// This file serves for saving and resuming the sessions of a running browser.
+
+package rod
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"time"
+
+	"github.com/Fromsko/rodPro/lib/cdp"
+	"github.com/Fromsko/rodPro/lib/proto"
+	"github.com/Fromsko/rodPro/lib/utils"
+	"github.com/ysmood/gson"
+)
+
+// Session of a browser, it's created by [Browser.Session] and used by [Browser.RestoreSession]
+type Session struct {
+	// ControlURL is the websocket url of the browser
+	ControlURL string `json:"controlURL"`
+
+	// BrowserContextID of the incognito browser, empty for the default context
+	BrowserContextID proto.BrowserBrowserContextID `json:"browserContextID,omitempty"`
+
+	Pages   []*SessionPage         `json:"pages"`
+	Cookies []*proto.NetworkCookie `json:"cookies"`
+	SavedAt time.Time              `json:"savedAt"`
+}
+
+// SessionPage is a page of [Session]
+type SessionPage struct {
+	TargetID proto.TargetTargetID `json:"targetID"`
+	URL      string               `json:"url"`
+	Title    string               `json:"title"`
+
+	// Origin of the storages, empty if the storages are not accessible, such as "about:blank"
+	Origin         string            `json:"origin,omitempty"`
+	LocalStorage   map[string]string `json:"localStorage,omitempty"`
+	SessionStorage map[string]string `json:"sessionStorage,omitempty"`
+}
+
+// Session captures the control url, the open pages, the cookies, and the storages of the browser.
+func (b *Browser) Session() (*Session, error) {
+	if b.wsURL == "" {
+		return nil, errors.New("the websocket url of the browser is unknown, use Browser.ControlURL to connect")
+	}
+
+	pages, err := b.Pages()
+	if err != nil {
+		return nil, err
+	}
+
+	cookies, err := b.GetCookies()
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Session{
+		ControlURL:       b.wsURL,
+		BrowserContextID: b.BrowserContextID,
+		Pages:            []*SessionPage{},
+		Cookies:          cookies,
+		SavedAt:          time.Now(),
+	}
+
+	for _, p := range pages {
+		sp, err := p.sessionPage()
+		if err != nil {
+			return nil, err
+		}
+		s.Pages = append(s.Pages, sp)
+	}
+
+	return s, nil
+}
+
+func (p *Page) sessionPage() (*SessionPage, error) {
+	info, err := p.Info()
+	if err != nil {
+		return nil, err
+	}
+
+	sp := &SessionPage{TargetID: p.TargetID, URL: info.URL, Title: info.Title}
+
+	res, err := p.Eval(`() => {
+		try {
+			return { origin: location.origin, local: { ...localStorage }, session: { ...sessionStorage } }
+		} catch {
+			return {}
+		}
+	}`)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.Value.Get("origin").Str() != "null" {
+		sp.Origin = res.Value.Get("origin").Str()
+	}
+	sp.LocalStorage = storageMap(res.Value.Get("local").Map())
+	sp.SessionStorage = storageMap(res.Value.Get("session").Map())
+
+	return sp, nil
+}
+
+// SaveSession saves the [Browser.Session] to the path as JSON, use [ResumeSession] to reattach to the browser.
+// The browser should outlive the current process, such as launched via the Leakless(false) of the launcher
+// or a remote browser, and it shouldn't be closed before the exit.
+func (b *Browser) SaveSession(path string) error {
+	s, err := b.Session()
+	if err != nil {
+		return err
+	}
+	return utils.OutputFile(path, s)
+}
+
+// LoadSession from the file that is saved by [Browser.SaveSession]
+func LoadSession(path string) (*Session, error) {
+	bin, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Session{}
+	err = json.Unmarshal(bin, s)
+	return s, err
+}
+
+// ResumeSession reattaches to the still-running browser of the session file that is saved by
+// [Browser.SaveSession], returns the browser and the pages of the session in the same order.
+// Check [Browser.RestoreSession] for how the closed pages are handled.
+func ResumeSession(path string) (*Browser, Pages, error) {
+	s, err := LoadSession(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// hold the websocket, so that the connection can be released on failure without closing the browser
+	ws := &cdp.WebSocket{}
+	err = ws.Connect(context.Background(), s.ControlURL, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	b := New().Client(cdp.New().Start(ws))
+	b.wsURL = s.ControlURL
+	err = b.Connect()
+	if err != nil {
+		_ = ws.Close()
+		return nil, nil, err
+	}
+	b.BrowserContextID = s.BrowserContextID
+
+	pages, err := b.RestoreSession(s)
+	if err != nil {
+		_ = ws.Close()
+		return nil, nil, err
+	}
+
+	return b, pages, nil
+}
+
+// RestoreSession returns the pages of the session in the same order. The page that is still open is reattached
+// as it is, the page that has been closed is reopened with its url, and the cookies and its storages of the session
+// are restored before the page loads, so the session can also be restored to another browser.
+func (b *Browser) RestoreSession(s *Session) (Pages, error) {
+	open := map[proto.TargetTargetID]bool{}
+	res, err := proto.TargetGetTargets{}.Call(b)
+	if err != nil {
+		return nil, err
+	}
+	for _, info := range res.TargetInfos {
+		if info.Type == proto.TargetTargetInfoTypePage {
+			open[info.TargetID] = true
+		}
+	}
+
+	cookiesRestored := false
+	pages := Pages{}
+	for _, sp := range s.Pages {
+		if open[sp.TargetID] {
+			p, err := b.PageFromTarget(sp.TargetID)
+			if err != nil {
+				return nil, err
+			}
+			pages = append(pages, p)
+			continue
+		}
+
+		if !cookiesRestored {
+			err = b.SetCookies(proto.CookiesToParams(s.Cookies))
+			if err != nil {
+				return nil, err
+			}
+			cookiesRestored = true
+		}
+
+		p, err := b.reopenSessionPage(sp)
+		if err != nil {
+			return nil, err
+		}
+		pages = append(pages, p)
+	}
+
+	return pages, nil
+}
+
+func (b *Browser) reopenSessionPage(sp *SessionPage) (*Page, error) {
+	p, err := b.Page(proto.TargetCreateTarget{})
+	if err != nil {
+		return nil, err
+	}
+
+	if sp.Origin != "" {
+		js, err := json.Marshal([]interface{}{sp.Origin, sp.LocalStorage, sp.SessionStorage})
+		if err != nil {
+			return nil, err
+		}
+
+		remove, err := p.EvalOnNewDocument(`((origin, local, session) => {
+			if (window !== top || location.origin !== origin) return
+			for (const k in local || {}) localStorage.setItem(k, local[k])
+			for (const k in session || {}) sessionStorage.setItem(k, session[k])
+		})(...` + string(js) + `)`)
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = remove() }()
+	}
+
+	if sp.URL == "" || sp.URL == "about:blank" {
+		return p, nil
+	}
+
+	err = p.Navigate(sp.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	return p, p.WaitLoad()
+}
+
+func storageMap(m map[string]gson.JSON) map[string]string {
+	if len(m) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		out[k] = v.Str()
+	}
+	return out
+}
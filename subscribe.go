@@ -0,0 +1,388 @@
+// This file serves for the typed event subscriptions of Browser.
+
+package rod
+
+import (
+	"context"
+	"reflect"
+	"sync"
+
+	"github.com/Fromsko/rodPro/lib/cdp"
+	"github.com/Fromsko/rodPro/lib/proto"
+	"github.com/ysmood/goob"
+)
+
+type eventHistory struct {
+	lock *sync.Mutex
+	size int
+	list []*Message
+}
+
+func newEventHistory() *eventHistory {
+	return &eventHistory{lock: &sync.Mutex{}}
+}
+
+func (h *eventHistory) add(msg *Message) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if h.size == 0 {
+		return
+	}
+
+	h.list = append(h.list, msg)
+	if len(h.list) > h.size {
+		h.list = h.list[len(h.list)-h.size:]
+	}
+}
+
+func (h *eventHistory) snapshot() []*Message {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	return append([]*Message{}, h.list...)
+}
+
+// EventReplay sets how many recent events the browser keeps for [Subscribe] to replay, default is 0.
+// Set it before the events you want to replay are fired, such as right after the browser is created.
+func (b *Browser) EventReplay(size int) *Browser {
+	b.history.lock.Lock()
+	b.history.size = size
+	if len(b.history.list) > size {
+		b.history.list = b.history.list[len(b.history.list)-size:]
+	}
+	b.history.lock.Unlock()
+
+	// the replay needs all the events
+	b.demands.lock.Lock()
+	defer b.demands.lock.Unlock()
+	b.filterEvents()
+
+	return b
+}
+
+// Subscribe returns a channel of the events of type T from the entire browser.
+// The replay is the max count of the recent events of type T to send first, the events are kept
+// by the browser only if [Browser.EventReplay] is set, so that the consumers won't miss the events
+// fired between the creation of a page and the subscription.
+// The channel will be closed when the ctx or the browser is done.
+func Subscribe[T proto.Event](ctx context.Context, b *Browser, replay int) <-chan T {
+	return subscribe[T](ctx, b, "", replay)
+}
+
+// SubscribePage is similar to [Subscribe], but only the events of the page will be sent.
+func SubscribePage[T proto.Event](ctx context.Context, p *Page, replay int) <-chan T {
+	return subscribe[T](ctx, p.browser, p.SessionID, replay)
+}
+
+func subscribe[T proto.Event](ctx context.Context, b *Browser, sessionID proto.TargetSessionID, replay int) <-chan T {
+	// T can be either the event struct or the pointer of it
+	tType := reflect.TypeOf((*T)(nil)).Elem()
+	eType := tType
+	if eType.Kind() == reflect.Ptr {
+		eType = eType.Elem()
+	}
+	method := reflect.New(eType).Interface().(proto.Event).ProtoEvent()
+
+	match := func(msg *Message) bool {
+		return msg.Method == method && (sessionID == "" || msg.SessionID == sessionID)
+	}
+
+	// Only enabled domains will emit events to cdp client.
+	var restore func()
+	domain, _ := proto.ParseMethodName(method)
+	if req := proto.GetType(domain + ".enable"); req != nil {
+		restore = b.EnableDomain(sessionID, reflect.New(req).Interface().(proto.Request))
+	}
+
+	// subscribe the live events before the snapshot of history, so that no event will be missed
+	b.demand(ctx, &eventDemand{sessionID: sessionID, methods: []string{method}})
+	live := b.Context(ctx).events()
+
+	replayed := map[*Message]struct{}{}
+	list := []*Message{}
+	for _, msg := range b.history.snapshot() {
+		if match(msg) {
+			list = append(list, msg)
+		}
+		// the events in the history may also arrive via the live channel
+		replayed[msg] = struct{}{}
+	}
+	if len(list) > replay {
+		list = list[len(list)-replay:]
+	}
+
+	ch := make(chan T)
+
+	send := func(msg *Message) bool {
+		val := reflect.New(eType)
+		msg.Load(val.Interface().(proto.Event))
+		if tType.Kind() != reflect.Ptr {
+			val = val.Elem()
+		}
+		e := val.Interface().(T)
+		select {
+		case <-ctx.Done():
+			return false
+		case ch <- e:
+			return true
+		}
+	}
+
+	go func() {
+		defer close(ch)
+		if restore != nil {
+			defer restore()
+		}
+
+		for _, msg := range list {
+			if !send(msg) {
+				return
+			}
+		}
+
+		for msg := range live {
+			if _, has := replayed[msg]; has || !match(msg) {
+				continue
+			}
+			if !send(msg) {
+				return
+			}
+		}
+	}()
+
+	return ch
+}
+
+// the events that rod relies on to track the targets and the navigations, they are never dropped by
+// [Browser.EventBuffer] or [Page.FilterEvents]
+var essentialEvents = map[string]bool{
+	"Page.lifecycleEvent":               true,
+	"Page.frameNavigated":               true,
+	"Page.navigatedWithinDocument":      true,
+	"Page.frameStartedLoading":          true,
+	"Page.frameStoppedLoading":          true,
+	"Page.domContentEventFired":         true,
+	"Page.loadEventFired":               true,
+	"Page.javascriptDialogOpening":      true,
+	"Runtime.executionContextCreated":   true,
+	"Runtime.executionContextDestroyed": true,
+	"Runtime.executionContextsCleared":  true,
+	"Inspector.detached":                true,
+	"Inspector.targetCrashed":           true,
+	"Target.attachedToTarget":           true,
+	"Target.detachedFromTarget":         true,
+	"Target.targetCreated":              true,
+	"Target.targetDestroyed":            true,
+	"Target.targetInfoChanged":          true,
+	"Target.targetCrashed":              true,
+	"Target.receivedMessageFromTarget":  true,
+}
+
+type eventBuffer struct {
+	size       int
+	policy     cdp.EventDropPolicy
+	onOverflow func(*Message)
+}
+
+// EventBuffer bounds the queue of each subscriber of the browser events, such as [Browser.Event], [Page.Event],
+// [Browser.EachEvent] and [Subscribe], so that the high-frequency events, such as the Network events of heavy pages
+// or the screencast frames, won't cause unbounded memory growth when a consumer is slow.
+// When the queue is full the policy decides which event to drop, the onOverflow is called with each dropped event,
+// it's optional and it shouldn't block. The Target, lifecycle and navigation events are never dropped.
+// With [cdp.EventBlock] the subscriber stops reading until its queue has room.
+// Set it before the pages are created, the size must be greater than 0.
+func (b *Browser) EventBuffer(size int, policy cdp.EventDropPolicy, onOverflow func(*Message)) *Browser {
+	if size < 1 {
+		size = 1
+	}
+	b.eventBuffer = &eventBuffer{size: size, policy: policy, onOverflow: onOverflow}
+	return b
+}
+
+// forwardEvents forwards the events from src to the returned channel until the ctx is done,
+// the pending events are bounded by the [Browser.EventBuffer] if it's set.
+// If the methods isn't nil, only the events that match them and the essential events are forwarded.
+func (b *Browser) forwardEvents(ctx context.Context, src <-chan goob.Event, methods []string) <-chan *Message {
+	buf := b.eventBuffer
+	if buf == nil {
+		buf = &eventBuffer{size: 1, policy: cdp.EventBlock}
+	}
+
+	dst := make(chan *Message)
+	go func() {
+		defer close(dst)
+
+		queue := []*Message{}
+		for {
+			in := src
+			if buf.policy == cdp.EventBlock && len(queue) >= buf.size {
+				in = nil
+			}
+
+			var out chan *Message
+			var next *Message
+			if len(queue) > 0 {
+				out = dst
+				next = queue[0]
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case e, ok := <-in:
+				if !ok {
+					return
+				}
+				msg := e.(*Message)
+				if methods == nil || essentialEvents[msg.Method] || matchEvent(methods, msg.Method) {
+					queue = buf.push(queue, msg)
+				}
+			case out <- next:
+				queue = queue[1:]
+			}
+		}
+	}()
+	return dst
+}
+
+// push the msg to the queue with the drop policy
+func (buf *eventBuffer) push(queue []*Message, msg *Message) []*Message {
+	if len(queue) < buf.size || essentialEvents[msg.Method] {
+		return append(queue, msg)
+	}
+
+	switch buf.policy {
+	case cdp.EventDropNewest:
+		buf.overflow(msg)
+		return queue
+
+	case cdp.EventDropOldest:
+		for i, old := range queue {
+			if !essentialEvents[old.Method] {
+				buf.overflow(old)
+				queue = append(queue[:i], queue[i+1:]...)
+				break
+			}
+		}
+	}
+
+	return append(queue, msg)
+}
+
+func (buf *eventBuffer) overflow(msg *Message) {
+	if buf.onOverflow != nil {
+		buf.onOverflow(msg)
+	}
+}
+
+// eventDemand is the event methods that a subscriber needs, nil methods means all the events,
+// the sessionID is empty if the subscriber needs the events of all the sessions
+type eventDemand struct {
+	sessionID proto.TargetSessionID
+	methods   []string
+	filter    bool // the demand is from [Page.FilterEvents]
+}
+
+// eventDemands tracks the demands of the subscribers, so that the events of a page that none of its subscribers
+// needs can be dropped by the cdp client before their params are decoded
+type eventDemands struct {
+	lock     sync.Mutex
+	list     map[*eventDemand]struct{}
+	filtered map[proto.TargetSessionID]struct{} // the sessions that have the filter of the cdp client
+}
+
+func newEventDemands() *eventDemands {
+	return &eventDemands{
+		list:     map[*eventDemand]struct{}{},
+		filtered: map[proto.TargetSessionID]struct{}{},
+	}
+}
+
+// demand registers the demand until the ctx is done
+func (b *Browser) demand(ctx context.Context, d *eventDemand) {
+	b.demands.lock.Lock()
+	b.demands.list[d] = struct{}{}
+	b.filterEvents()
+	b.demands.lock.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		b.demands.lock.Lock()
+		defer b.demands.lock.Unlock()
+
+		delete(b.demands.list, d)
+		b.filterEvents()
+	}()
+}
+
+// filterEvents sets the filters of the cdp client for the sessions that have the demands from [Page.FilterEvents].
+// A session is only filtered when all the subscribers of it have told what they need, the events that rod
+// relies on always pass. The lock of the demands must be held.
+func (b *Browser) filterEvents() {
+	client, ok := b.client.(interface {
+		FilterEvents(sessionID string, methods ...string) *cdp.Client
+	})
+	if !ok {
+		return
+	}
+
+	b.history.lock.Lock()
+	all := map[proto.TargetSessionID]bool{"": b.history.size > 0}
+	b.history.lock.Unlock()
+
+	global := []string{}
+	filters := map[proto.TargetSessionID][]string{}
+	for d := range b.demands.list {
+		switch {
+		case d.methods == nil:
+			all[d.sessionID] = true
+		case d.sessionID == "":
+			global = append(global, d.methods...)
+		case d.filter:
+			filters[d.sessionID] = append(filters[d.sessionID], d.methods...)
+		}
+	}
+	for d := range b.demands.list {
+		if _, has := filters[d.sessionID]; has && d.methods != nil && !d.filter {
+			filters[d.sessionID] = append(filters[d.sessionID], d.methods...)
+		}
+	}
+
+	for id := range b.demands.filtered {
+		if _, has := filters[id]; !has || all[""] || all[id] {
+			client.FilterEvents(string(id))
+			delete(b.demands.filtered, id)
+		}
+	}
+
+	if all[""] {
+		return
+	}
+
+	for id, methods := range filters {
+		if all[id] {
+			continue
+		}
+
+		for m := range essentialEvents {
+			methods = append(methods, m)
+		}
+		methods = append(methods, global...)
+
+		client.FilterEvents(string(id), methods...)
+		b.demands.filtered[id] = struct{}{}
+	}
+}
+
+// matchEvent returns true if the method is in the methods, a method of the methods can be a domain wildcard
+func matchEvent(methods []string, method string) bool {
+	domain, _ := proto.ParseMethodName(method)
+	for _, m := range methods {
+		if m == method || m == domain+".*" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,203 @@
+// This file serves for exporting the traced actions as a timeline.
+
+package rod
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Fromsko/rodPro/lib/proto"
+	"github.com/Fromsko/rodPro/lib/utils"
+	"github.com/ysmood/gson"
+)
+
+// TimelineEntry is a traced action of the [Timeline]
+type TimelineEntry struct {
+	Type TraceType `json:"type"`
+
+	// Message of the action, for the queries it's the js function and the selector
+	Message string `json:"message"`
+
+	// Target is the page or element of the action
+	Target string `json:"target"`
+
+	Start    time.Time     `json:"start"`
+	Duration time.Duration `json:"duration"`
+
+	// Before and After are the jpeg screenshots of the viewport, they are empty if [Timeline.Screenshots] is false
+	Before []byte `json:"before,omitempty"`
+	After  []byte `json:"after,omitempty"`
+}
+
+// Timeline records the traced actions, such as the inputs, waits, and queries. Use it to inspect what
+// happened after a failed run, similar to the trace viewer of other tools:
+//
+//	timeline := &rod.Timeline{Screenshots: true}
+//	browser := rod.New().Timeline(timeline).MustConnect()
+//	defer timeline.MustSave("timeline.html")
+//
+// It works with or without [Browser.Trace].
+type Timeline struct {
+	// Screenshots of the viewport will be taken before and after each action, it slows down the actions
+	Screenshots bool
+
+	// Writer, if not nil, each entry will be written to it as a line of JSON once the action is done,
+	// so the entries before a crash won't be lost.
+	Writer io.Writer
+
+	lock    sync.Mutex
+	entries []*TimelineEntry
+}
+
+// Timeline sets the timeline to record the traced actions, set it to nil to disable.
+func (b *Browser) Timeline(t *Timeline) *Browser {
+	b.timeline = t
+	return b
+}
+
+// Entries returns the recorded entries
+func (t *Timeline) Entries() []TimelineEntry {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	list := make([]TimelineEntry, len(t.entries))
+	for i, e := range t.entries {
+		list[i] = *e
+	}
+	return list
+}
+
+// WriteJSON writes the entries as a JSON array
+func (t *Timeline) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(t.Entries())
+}
+
+// WriteHTML writes the entries as a standalone HTML page
+func (t *Timeline) WriteHTML(w io.Writer) error {
+	return timelineTemplate.Execute(w, t.Entries())
+}
+
+// Save the timeline to the file, the format is HTML if the extension is ".html" or ".htm", JSON otherwise.
+func (t *Timeline) Save(path string) error {
+	err := os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".html", ".htm":
+		return t.WriteHTML(f)
+	default:
+		return t.WriteJSON(f)
+	}
+}
+
+// MustSave is similar to [Timeline.Save]
+func (t *Timeline) MustSave(path string) {
+	utils.E(t.Save(path))
+}
+
+// start recording an action of the page with the context of the action, call the returned function when the action is done
+func (t *Timeline) start(ctx context.Context, p *Page, typ TraceType, msg, target string) func() {
+	if t == nil {
+		return func() {}
+	}
+
+	e := &TimelineEntry{Type: typ, Message: msg, Target: target, Start: time.Now()}
+	if t.Screenshots {
+		e.Before = timelineScreenshot(ctx, p)
+	}
+
+	return func() {
+		e.Duration = time.Since(e.Start)
+		if t.Screenshots {
+			// the context of the action may be done, such as the action timed out, which is when the
+			// screenshot is the most useful, so a detached context is used
+			ctx, cancel := context.WithTimeout(context.Background(), timelineScreenshotTimeout)
+			e.After = timelineScreenshot(ctx, p)
+			cancel()
+		}
+
+		t.lock.Lock()
+		defer t.lock.Unlock()
+
+		t.entries = append(t.entries, e)
+		if t.Writer != nil {
+			_ = json.NewEncoder(t.Writer).Encode(e)
+		}
+	}
+}
+
+// timelineScreenshotTimeout limits the "After" screenshot of the [Timeline]
+const timelineScreenshotTimeout = 5 * time.Second
+
+func timelineScreenshot(ctx context.Context, p *Page) []byte {
+	res, err := proto.PageCaptureScreenshot{
+		Format:  proto.PageCaptureScreenshotFormatJpeg,
+		Quality: gson.Int(60),
+	}.Call(p.root.Context(ctx))
+	if err != nil {
+		return nil
+	}
+	return res.Data
+}
+
+func traceMessage(msg []interface{}) string {
+	return strings.TrimSpace(fmt.Sprintln(msg...))
+}
+
+var timelineTemplate = template.Must(template.New("timeline").Funcs(template.FuncMap{
+	"img": func(b []byte) template.URL {
+		return template.URL("data:image/jpeg;base64," + base64.StdEncoding.EncodeToString(b))
+	},
+	"time": func(t time.Time) string {
+		return t.Format("15:04:05.000")
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Rod Timeline</title>
+<style>
+body { font-family: sans-serif; margin: 20px; }
+table { border-collapse: collapse; width: 100%; }
+td, th { border: 1px solid #ddd; padding: 6px; vertical-align: top; text-align: left; }
+code { white-space: pre-wrap; word-break: break-all; }
+img { max-width: 320px; }
+</style>
+</head>
+<body>
+<h1>Timeline</h1>
+<table>
+<tr><th>#</th><th>Start</th><th>Duration</th><th>Type</th><th>Message</th><th>Target</th><th>Before</th><th>After</th></tr>
+{{range $i, $e := .}}<tr>
+<td>{{$i}}</td>
+<td>{{time $e.Start}}</td>
+<td>{{$e.Duration}}</td>
+<td>{{$e.Type}}</td>
+<td><code>{{$e.Message}}</code></td>
+<td><code>{{$e.Target}}</code></td>
+<td>{{if $e.Before}}<img src="{{img $e.Before}}">{{end}}</td>
+<td>{{if $e.After}}<img src="{{img $e.After}}">{{end}}</td>
+</tr>
+{{end}}</table>
+</body>
+</html>
+`))
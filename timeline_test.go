@@ -0,0 +1,66 @@
+package rod_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Fromsko/rodPro"
+)
+
+func TestTimeline(t *testing.T) {
+	g := setup(t)
+
+	buf := bytes.NewBuffer(nil)
+	timeline := &rod.Timeline{Screenshots: true, Writer: buf}
+	g.browser.Timeline(timeline)
+	defer g.browser.Timeline(nil)
+
+	p := g.page.MustNavigate(g.srcFile("fixtures/click.html")).MustWaitLoad()
+	el := p.MustElement("button")
+	el.MustClick()
+
+	list := timeline.Entries()
+	g.Gte(len(list), 3)
+
+	g.Eq(list[0].Type, rod.TraceTypeWait)
+	g.Eq(list[0].Message, "load")
+	g.Eq(list[0].Target, p.String())
+	g.Gt(len(list[0].Before), 0)
+	g.Gt(len(list[0].After), 0)
+
+	g.Eq(list[1].Type, rod.TraceTypeQuery)
+	g.Has(list[1].Message, `"button"`)
+
+	last := list[len(list)-1]
+	g.Eq(last.Type, rod.TraceTypeInput)
+	g.Eq(last.Message, "left click")
+	g.Eq(last.Target, el.String())
+
+	var first rod.TimelineEntry
+	g.E(json.NewDecoder(buf).Decode(&first))
+	g.Eq(first.Message, "load")
+
+	dir := t.TempDir()
+
+	timeline.MustSave(filepath.Join(dir, "timeline.json"))
+	var saved []rod.TimelineEntry
+	g.E(json.Unmarshal(g.Read(filepath.Join(dir, "timeline.json")).Bytes(), &saved))
+	g.Len(saved, len(list))
+
+	timeline.MustSave(filepath.Join(dir, "timeline.html"))
+	html := g.Read(filepath.Join(dir, "timeline.html")).String()
+	g.Has(html, "left click")
+	g.Has(html, "data:image/jpeg;base64,")
+
+	g.Err(timeline.Save(filepath.Join(dir, "timeline.html", "x")))
+
+	// the "After" screenshot is still taken when the action times out
+	g.Err(p.Timeout(300 * time.Millisecond).MustElement("button").WaitInvisible())
+	list = timeline.Entries()
+	last = list[len(list)-1]
+	g.Eq(last.Message, "invisible")
+	g.Gt(len(last.After), 0)
+}
@@ -0,0 +1,394 @@
+// This file serves for the auto-traversal of the paginations, the sitemaps, and the infinite scrolling lists.
+
+package rod
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Fromsko/rodPro/lib/proto"
+)
+
+// PaginationTimeout is the max duration to wait for the page to change after the next button is clicked
+// in [Page.FollowPagination], the pagination ends if the page doesn't change.
+var PaginationTimeout = 10 * time.Second
+
+// jsPageState returns the url and the hash of the text content of the page
+const jsPageState = `() => {
+	const text = document.body ? document.body.innerText : ''
+	let h = 5381
+	for (let i = 0; i < text.length; i++) h = (h * 33) ^ text.charCodeAt(i)
+	return location.href + '#' + (h >>> 0).toString(16) + ':' + text.length
+}`
+
+// FollowPagination calls the handler with each page of the pagination, n starts from 1.
+// After each call it clicks the element that matches the nextSelector, then waits for the url or the content
+// of the page to change. It stops when the next element doesn't exist or is disabled, the page doesn't change
+// within [PaginationTimeout], the page is the same as a visited one (the url and content hash are the same),
+// or maxPages pages have been handled. Use 0 as the maxPages for unlimited.
+func (p *Page) FollowPagination(nextSelector string, handler func(p *Page, n int) error, maxPages int) error {
+	seen := map[string]bool{}
+
+	for n := 1; ; n++ {
+		err := p.WaitLoad()
+		if err != nil {
+			return err
+		}
+
+		state, err := p.pageState()
+		if err != nil {
+			return err
+		}
+		if seen[state] {
+			return nil
+		}
+		seen[state] = true
+
+		err = handler(p, n)
+		if err != nil {
+			return err
+		}
+
+		if maxPages > 0 && n >= maxPages {
+			return nil
+		}
+
+		next, err := p.nextPageButton(nextSelector)
+		if err != nil || next == nil {
+			return err
+		}
+
+		err = next.Click(proto.InputMouseButtonLeft, 1)
+		if err != nil {
+			return err
+		}
+
+		err = p.Timeout(PaginationTimeout).Wait(Eval(`s => (`+jsPageState+`)() !== s`, state))
+		// only the timeout of the step ends the pagination, the deadline of the caller is an error
+		if errors.Is(err, context.DeadlineExceeded) && p.ctx.Err() == nil {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+func (p *Page) pageState() (string, error) {
+	res, err := p.Eval(jsPageState)
+	if err != nil {
+		return "", err
+	}
+	return res.Value.Str(), nil
+}
+
+// nextPageButton returns nil if the next button doesn't exist or is disabled
+func (p *Page) nextPageButton(selector string) (*Element, error) {
+	has, el, err := p.Has(selector)
+	if err != nil || !has {
+		return nil, err
+	}
+
+	res, err := el.Eval(`() => this.disabled === true ||
+		this.getAttribute('aria-disabled') === 'true' ||
+		this.classList.contains('disabled') ||
+		getComputedStyle(this).pointerEvents === 'none'`)
+	if err != nil {
+		return nil, err
+	}
+	if res.Value.Bool() {
+		return nil, nil
+	}
+	return el, nil
+}
+
+// SitemapURL is an entry of the sitemap
+type SitemapURL struct {
+	Loc        string `xml:"loc"`
+	LastMod    string `xml:"lastmod"`
+	ChangeFreq string `xml:"changefreq"`
+	Priority   string `xml:"priority"`
+}
+
+type sitemapDoc struct {
+	XMLName  xml.Name
+	URLs     []SitemapURL `xml:"url"`
+	Sitemaps []SitemapURL `xml:"sitemap"`
+}
+
+// CrawlSitemap visits each url of the sitemap in order with a new page, and calls the handler after the page is loaded.
+// The sitemap index is followed recursively, the gzipped sitemap is supported. The duplicated urls are skipped,
+// so are the urls that redirect to a visited url. The page is closed after the crawling.
+func (b *Browser) CrawlSitemap(sitemapURL string, handler func(p *Page, u SitemapURL) error) error {
+	urls, err := b.sitemapURLs(sitemapURL, map[string]bool{})
+	if err != nil {
+		return err
+	}
+
+	p, err := b.Page(proto.TargetCreateTarget{})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = p.Close() }()
+
+	visited := map[string]bool{}
+	for _, u := range urls {
+		if visited[u.Loc] {
+			continue
+		}
+		visited[u.Loc] = true
+
+		err = p.Navigate(u.Loc)
+		if errors.Is(err, &ErrRobotsDisallowed{}) {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		err = p.WaitLoad()
+		if err != nil {
+			return err
+		}
+
+		info, err := p.Info()
+		if err != nil {
+			return err
+		}
+		if info.URL != u.Loc {
+			if visited[info.URL] {
+				continue
+			}
+			visited[info.URL] = true
+		}
+
+		err = handler(p, u)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// sitemapURLs returns all the urls of the sitemap, the seen prevents the loop of the sitemap indexes
+func (b *Browser) sitemapURLs(u string, seen map[string]bool) ([]SitemapURL, error) {
+	if seen[u] {
+		return nil, nil
+	}
+	seen[u] = true
+
+	doc, err := b.fetchSitemap(u)
+	if err != nil {
+		return nil, &ErrSitemap{u, err}
+	}
+
+	list := doc.URLs
+	for _, s := range doc.Sitemaps {
+		sub, err := b.sitemapURLs(strings.TrimSpace(s.Loc), seen)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, sub...)
+	}
+
+	for i := range list {
+		list[i].Loc = strings.TrimSpace(list[i].Loc)
+	}
+	return list, nil
+}
+
+func (b *Browser) fetchSitemap(u string) (*sitemapDoc, error) {
+	req, err := http.NewRequestWithContext(b.ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", res.Status)
+	}
+
+	var body io.Reader = res.Body
+	if strings.HasSuffix(req.URL.Path, ".gz") || strings.Contains(res.Header.Get("Content-Type"), "gzip") {
+		gz, err := gzip.NewReader(res.Body)
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = gz.Close() }()
+		body = gz
+	}
+
+	doc := &sitemapDoc{}
+	err = xml.NewDecoder(body).Decode(doc)
+	if err != nil {
+		return nil, err
+	}
+	if doc.XMLName.Local != "urlset" && doc.XMLName.Local != "sitemapindex" {
+		return nil, fmt.Errorf("unknown root element <%s>", doc.XMLName.Local)
+	}
+	return doc, nil
+}
+
+// ScrollStopReason of [ScrollResult]
+type ScrollStopReason string
+
+const (
+	// ScrollStopIdle means no more content is loaded after [ScrollUntilOptions.IdleRounds] rounds
+	ScrollStopIdle ScrollStopReason = "idle"
+
+	// ScrollStopSentinel means the [ScrollUntilOptions.StopSelector] appears
+	ScrollStopSentinel ScrollStopReason = "sentinel"
+
+	// ScrollStopMaxRounds means the [ScrollUntilOptions.MaxRounds] is reached
+	ScrollStopMaxRounds ScrollStopReason = "max rounds"
+
+	// ScrollStopMaxItems means the [ScrollUntilOptions.MaxItems] is reached
+	ScrollStopMaxItems ScrollStopReason = "max items"
+)
+
+// ScrollUntilOptions for [Page.ScrollUntil]
+type ScrollUntilOptions struct {
+	// Container is the css selector of the scrollable element, the default is the document
+	Container string
+
+	// ItemSelector is the css selector of the loaded items, if set the growth of the items counts as new content
+	ItemSelector string
+
+	// StopSelector is the css selector of the sentinel, such as "the end of the list", the scrolling stops once it appears
+	StopSelector string
+
+	// IdleRounds is the number of the rounds without new content to stop, the default is 3
+	IdleRounds int
+
+	// RoundTimeout is the max duration to wait for new content after each scroll, the default is 2s
+	RoundTimeout time.Duration
+
+	// MaxRounds to scroll, 0 for unlimited
+	MaxRounds int
+
+	// MaxItems to load, it requires the ItemSelector, 0 for unlimited
+	MaxItems int
+}
+
+// ScrollResult of [Page.ScrollUntil]
+type ScrollResult struct {
+	// Rounds of the scrolls
+	Rounds int
+
+	// Items is the number of the items that match the [ScrollUntilOptions.ItemSelector] at the end
+	Items int
+
+	// Loaded is the number of the items loaded by the scrolling
+	Loaded int
+
+	// Height of the scrollable content at the end
+	Height float64
+
+	Reason ScrollStopReason
+}
+
+const jsScrollState = `(container, item) => {
+	const el = container ? document.querySelector(container) : document.scrollingElement
+	return {
+		height: el ? el.scrollHeight : 0,
+		items: item ? document.querySelectorAll(item).length : 0,
+	}
+}`
+
+// ScrollUntil scrolls the page to the bottom repeatedly to harvest an infinite scrolling list. After each scroll it
+// waits until the height of the content or the number of the items grows, it stops when there's no more content
+// for a few rounds, the sentinel appears, or a limit is reached. If opts is nil, the default options are used.
+func (p *Page) ScrollUntil(opts *ScrollUntilOptions) (*ScrollResult, error) {
+	if opts == nil {
+		opts = &ScrollUntilOptions{}
+	}
+	idleRounds := opts.IdleRounds
+	if idleRounds <= 0 {
+		idleRounds = 3
+	}
+	roundTimeout := opts.RoundTimeout
+	if roundTimeout <= 0 {
+		roundTimeout = 2 * time.Second
+	}
+
+	defer p.tryTrace(TraceTypeWait, "scroll until")()
+
+	state, err := p.Eval(jsScrollState, opts.Container, opts.ItemSelector)
+	if err != nil {
+		return nil, err
+	}
+	initial := state.Value.Get("items").Int()
+
+	res := &ScrollResult{}
+	update := func() {
+		res.Items = state.Value.Get("items").Int()
+		res.Loaded = res.Items - initial
+		res.Height = state.Value.Get("height").Num()
+	}
+	update()
+
+	idle := 0
+	for {
+		if opts.StopSelector != "" {
+			has, _, err := p.Has(opts.StopSelector)
+			if err != nil {
+				return nil, err
+			}
+			if has {
+				res.Reason = ScrollStopSentinel
+				return res, nil
+			}
+		}
+		if opts.MaxItems > 0 && res.Items >= opts.MaxItems {
+			res.Reason = ScrollStopMaxItems
+			return res, nil
+		}
+		if idle >= idleRounds {
+			res.Reason = ScrollStopIdle
+			return res, nil
+		}
+		if opts.MaxRounds > 0 && res.Rounds >= opts.MaxRounds {
+			res.Reason = ScrollStopMaxRounds
+			return res, nil
+		}
+
+		p.browser.trySlowMotion("input.scroll")
+
+		_, err = p.Evaluate(Eval(`container => {
+			const el = container ? document.querySelector(container) : document.scrollingElement
+			if (el) el.scrollTop = el.scrollHeight
+		}`, opts.Container).ByUser())
+		if err != nil {
+			return nil, err
+		}
+		res.Rounds++
+
+		err = p.Timeout(roundTimeout).Wait(Eval(`(container, item, height, items) => {
+			const s = (`+jsScrollState+`)(container, item)
+			return s.height > height || s.items > items
+		}`, opts.Container, opts.ItemSelector, res.Height, res.Items))
+		if errors.Is(err, context.DeadlineExceeded) {
+			idle++
+		} else if err != nil {
+			return nil, err
+		} else {
+			idle = 0
+		}
+
+		state, err = p.Eval(jsScrollState, opts.Container, opts.ItemSelector)
+		if err != nil {
+			return nil, err
+		}
+		update()
+	}
+}
@@ -0,0 +1,181 @@
+package rod_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Fromsko/rodPro"
+	"github.com/Fromsko/rodPro/lib/proto"
+)
+
+func TestFollowPagination(t *testing.T) {
+	g := setup(t)
+
+	s := g.Serve()
+	s.Route("/", ".html", `<html><body>
+		<ul id="list"></ul>
+		<button id="next">next</button>
+		<script>
+			let page = 1
+			const render = () => {
+				list.innerHTML = '<li>item ' + page + '</li>'
+				next.disabled = page === 3
+			}
+			next.onclick = () => setTimeout(() => { page++; render() }, 50)
+			render()
+		</script>
+	</body></html>`)
+
+	p := g.page.MustNavigate(s.URL())
+
+	items := []string{}
+	p.MustFollowPagination("#next", func(p *rod.Page, n int) {
+		items = append(items, p.MustElement("#list").MustText())
+	}, 0)
+	g.Eq(items, []string{"item 1", "item 2", "item 3"})
+
+	items = []string{}
+	p.MustReload().MustFollowPagination("#next", func(p *rod.Page, n int) {
+		items = append(items, p.MustElement("#list").MustText())
+	}, 2)
+	g.Eq(items, []string{"item 1", "item 2"})
+
+	// the loop back to the first page
+	s.Route("/a", ".html", `<html><body>a <a id="next" href="/b">next</a></body></html>`)
+	s.Route("/b", ".html", `<html><body>b <a id="next" href="/a">next</a></body></html>`)
+	count := 0
+	g.E(p.MustNavigate(s.URL("/a")).FollowPagination("#next", func(p *rod.Page, n int) error {
+		count = n
+		return nil
+	}, 10))
+	g.Eq(count, 2)
+
+	// the next button does nothing
+	old := rod.PaginationTimeout
+	rod.PaginationTimeout = 100 * time.Millisecond
+	defer func() { rod.PaginationTimeout = old }()
+	s.Route("/c", ".html", `<html><body>c <button id="next">next</button></body></html>`)
+	count = 0
+	g.E(p.MustNavigate(s.URL("/c")).FollowPagination("#next", func(p *rod.Page, n int) error {
+		count = n
+		return nil
+	}, 0))
+	g.Eq(count, 1)
+
+	// the deadline of the caller isn't treated as the end of the pagination
+	rod.PaginationTimeout = 10 * time.Second
+	err := p.MustNavigate(s.URL("/c")).Timeout(time.Second).FollowPagination("#next", func(p *rod.Page, n int) error {
+		return nil
+	}, 0)
+	g.Is(err, context.DeadlineExceeded)
+	rod.PaginationTimeout = 100 * time.Millisecond
+
+	g.Eq(p.FollowPagination("#next", func(p *rod.Page, n int) error {
+		return http.ErrAbortHandler
+	}, 0), http.ErrAbortHandler)
+}
+
+func TestCrawlSitemap(t *testing.T) {
+	g := setup(t)
+
+	s := g.Serve()
+	s.Route("/a", ".html", `<html><title>a</title></html>`)
+	s.Route("/b", ".html", `<html><title>b</title></html>`)
+	s.Mux.HandleFunc("/redirect", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/a", http.StatusFound)
+	})
+
+	s.Route("/sitemap.xml", ".xml", `<?xml version="1.0" encoding="UTF-8"?>
+		<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+			<sitemap><loc>`+s.URL("/sitemap-1.xml")+`</loc></sitemap>
+			<sitemap><loc>`+s.URL("/sitemap-2.xml.gz")+`</loc></sitemap>
+			<sitemap><loc>`+s.URL("/sitemap.xml")+`</loc></sitemap>
+		</sitemapindex>`)
+	s.Route("/sitemap-1.xml", ".xml", `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+			<url><loc> `+s.URL("/a")+` </loc><lastmod>2024-01-01</lastmod></url>
+			<url><loc>`+s.URL("/redirect")+`</loc></url>
+		</urlset>`)
+
+	buf := bytes.NewBuffer(nil)
+	gz := gzip.NewWriter(buf)
+	_, _ = gz.Write([]byte(`<urlset><url><loc>` + s.URL("/b") + `</loc></url><url><loc>` + s.URL("/a") + `</loc></url></urlset>`))
+	g.E(gz.Close())
+	s.Mux.HandleFunc("/sitemap-2.xml.gz", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(buf.Bytes())
+	})
+
+	visited := []string{}
+	g.browser.MustCrawlSitemap(s.URL("/sitemap.xml"), func(p *rod.Page, u rod.SitemapURL) {
+		visited = append(visited, p.MustInfo().Title+" "+u.LastMod)
+	})
+	g.Eq(visited, []string{"a 2024-01-01", "b "})
+
+	s.Route("/bad.xml", ".xml", `<html></html>`)
+	err := g.browser.CrawlSitemap(s.URL("/bad.xml"), nil)
+	g.Is(err, &rod.ErrSitemap{})
+	g.Has(err.Error(), "unknown root element <html>")
+
+	g.Is(g.browser.CrawlSitemap(s.URL("/not-found.xml"), nil), &rod.ErrSitemap{})
+}
+
+func TestScrollUntil(t *testing.T) {
+	g := setup(t)
+
+	s := g.Serve().Route("/", ".html", `<html><body style="margin:0">
+		<div id="list"></div>
+		<script>
+			let count = 0
+			const load = () => {
+				if (count >= 30) return
+				for (let i = 0; i < 10; i++) {
+					const el = document.createElement('div')
+					el.className = 'item'
+					el.style.height = '100px'
+					el.textContent = 'item ' + count++
+					list.appendChild(el)
+				}
+				if (count >= 30 && location.hash === '#sentinel') {
+					list.insertAdjacentHTML('beforeend', '<p id="end">end</p>')
+				}
+			}
+			addEventListener('scroll', () => {
+				if (innerHeight + scrollY >= document.body.scrollHeight - 10) setTimeout(load, 30)
+			})
+			load()
+		</script>
+	</body></html>`)
+
+	p := g.page.MustNavigate(s.URL())
+	res := p.MustScrollUntil(&rod.ScrollUntilOptions{
+		ItemSelector: ".item",
+		IdleRounds:   2,
+		RoundTimeout: 300 * time.Millisecond,
+	})
+	g.Eq(res.Reason, rod.ScrollStopIdle)
+	g.Eq(res.Items, 30)
+	g.Eq(res.Loaded, 20)
+	g.Eq(res.Rounds, 4)
+	g.Gte(res.Height, 3000.0)
+
+	p.MustNavigate(s.URL("/#sentinel")).MustReload()
+	res = p.MustScrollUntil(&rod.ScrollUntilOptions{StopSelector: "#end", RoundTimeout: 300 * time.Millisecond})
+	g.Eq(res.Reason, rod.ScrollStopSentinel)
+	g.Eq(res.Rounds, 2)
+
+	p.MustReload()
+	res = p.MustScrollUntil(&rod.ScrollUntilOptions{ItemSelector: ".item", MaxItems: 20})
+	g.Eq(res.Reason, rod.ScrollStopMaxItems)
+	g.Eq(res.Items, 20)
+
+	p.MustReload()
+	res = p.MustScrollUntil(&rod.ScrollUntilOptions{MaxRounds: 1})
+	g.Eq(res.Reason, rod.ScrollStopMaxRounds)
+	g.Eq(res.Rounds, 1)
+
+	g.mc.stubErr(1, proto.RuntimeCallFunctionOn{})
+	g.Err(p.ScrollUntil(nil))
+}
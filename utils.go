@@ -6,7 +6,6 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
-	"io"
 	"log"
 	"net/http"
 	"os"
@@ -14,6 +13,8 @@ import (
 	"reflect"
 	"regexp"
 	"runtime/debug"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -66,15 +67,17 @@ func (msg *Message) Load(e proto.Event) bool {
 // DefaultLogger for rod
 var DefaultLogger = log.New(os.Stdout, "[rod] ", log.LstdFlags)
 
-// DefaultSleeper generates the default sleeper for retry, it uses backoff to grow the interval.
+// DefaultSleeper generates the default sleeper for retry, it uses exponential backoff to grow the interval.
 // The growth looks like:
 //
-//	A(0) = 100ms, A(n) = A(n-1) * random[1.9, 2.1), A(n) < 1s
+//	A(1) = 100ms, A(n) = A(n-1) * 2, A(n) <= 1s, each interval is scaled by random[0.95, 1.05)
 //
 // Why the default is not RequestAnimationFrame or DOM change events is because of if a retry never
 // ends it can easily flood the program. But you can always easily config it into what you want.
 var DefaultSleeper = func() utils.Sleeper {
-	return utils.BackoffSleeper(100*time.Millisecond, time.Second, nil)
+	return utils.SleeperPolicy{
+		Backoff: utils.ExponentialBackoff(100*time.Millisecond, time.Second, 2, 0.05),
+	}.Sleeper()
 }
 
 // PagePool to thread-safely limit the number of pages at the same time.
@@ -155,55 +158,12 @@ func (bp BrowserPool) Cleanup(iteratee func(*Browser)) {
 	}
 }
 
-var _ io.ReadCloser = &StreamReader{}
-
 // StreamReader for browser data stream
-type StreamReader struct {
-	Offset *int
-
-	c      proto.Client
-	handle proto.IOStreamHandle
-	buf    *bytes.Buffer
-}
+type StreamReader = proto.IOStreamReader
 
 // NewStreamReader instance
 func NewStreamReader(c proto.Client, h proto.IOStreamHandle) *StreamReader {
-	return &StreamReader{
-		c:      c,
-		handle: h,
-		buf:    &bytes.Buffer{},
-	}
-}
-
-func (sr *StreamReader) Read(p []byte) (n int, err error) {
-	res, err := proto.IORead{
-		Handle: sr.handle,
-		Offset: sr.Offset,
-	}.Call(sr.c)
-	if err != nil {
-		return 0, err
-	}
-
-	if !res.EOF {
-		var bin []byte
-		if res.Base64Encoded {
-			bin, err = base64.StdEncoding.DecodeString(res.Data)
-			if err != nil {
-				return 0, err
-			}
-		} else {
-			bin = []byte(res.Data)
-		}
-
-		_, _ = sr.buf.Write(bin)
-	}
-
-	return sr.buf.Read(p)
-}
-
-// Close the stream, discard any temporary backing storage.
-func (sr *StreamReader) Close() error {
-	return proto.IOClose{Handle: sr.handle}.Call(sr.c)
+	return h.Reader(c)
 }
 
 // Try try fn with recover, return the panic as rod.ErrTry
@@ -295,3 +255,23 @@ func parseDataURI(uri string) (string, []byte) {
 	bin, _ := base64.StdEncoding.DecodeString(uri[l:])
 	return contentType, bin
 }
+
+// majorVersion of the product, such as "HeadlessChrome/115.0.5790.0" returns 115.
+func majorVersion(product string) int {
+	_, ver, _ := strings.Cut(product, "/")
+	major, _, _ := strings.Cut(ver, ".")
+	n, _ := strconv.Atoi(major)
+	return n
+}
+
+// callRaw calls the method and decodes the response into result if it's not nil.
+func callRaw(ctx context.Context, c proto.Client, sessionID proto.TargetSessionID, method string, params, result interface{}) error {
+	res, err := c.Call(ctx, string(sessionID), method, params)
+	if err != nil {
+		return err
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(res, result)
+}
@@ -0,0 +1,137 @@
+// This file serves for monitoring the content changes of the page.
+
+package rod
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Fromsko/rodPro/lib/js"
+	"github.com/Fromsko/rodPro/lib/utils"
+	"github.com/ysmood/gson"
+)
+
+// Watch monitors the content of the spec and calls fn with the old and the new values when the content changes.
+// The spec has the same format as the values of [Page.Extract], such as ".price", "a@href", or ".item[]".
+// If interval is zero, the changes are pushed by a MutationObserver of the page, the observer is debounced by 100ms
+// and it survives reloads. Otherwise, the content is polled at the interval.
+// The initial content is extracted before Watch returns, fn won't be called for it. Such as:
+//
+//	stop := page.MustWatch(".price", time.Minute, func(old, new gson.JSON) {
+//		fmt.Println("price changed from", old.Str(), "to", new.Str())
+//	})
+//	defer stop()
+func (p *Page) Watch(spec string, interval time.Duration, fn func(old, new gson.JSON)) (stop func(), err error) {
+	res, err := p.Extract(map[string]string{"v": spec})
+	if err != nil {
+		return nil, err
+	}
+
+	w := &contentWatcher{last: res["v"], fn: fn}
+
+	if interval > 0 {
+		return p.pollContent(w, spec, interval), nil
+	}
+	return p.observeContent(w, spec)
+}
+
+type contentWatcher struct {
+	lock sync.Mutex
+	last gson.JSON
+	fn   func(old, new gson.JSON)
+}
+
+func (w *contentWatcher) update(val gson.JSON) {
+	w.lock.Lock()
+	old := w.last
+	if old.JSON("", "") == val.JSON("", "") {
+		w.lock.Unlock()
+		return
+	}
+	w.last = val
+	w.lock.Unlock()
+
+	w.fn(old, val)
+}
+
+func (p *Page) pollContent(w *contentWatcher, spec string, interval time.Duration) func() {
+	ctx, cancel := context.WithCancel(p.ctx)
+	page := p.Context(ctx)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(interval):
+			}
+
+			// the errors are usually caused by the navigations, the next poll will retry
+			res, err := page.Extract(map[string]string{"v": spec})
+			if err == nil {
+				w.update(res["v"])
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+func (p *Page) observeContent(w *contentWatcher, spec string) (func(), error) {
+	name := "_rodWatch" + utils.RandString(8)
+
+	stopExpose, err := p.Expose(name, func(val gson.JSON) (interface{}, error) {
+		w.update(val)
+		return nil, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	fields := map[string]extractSpec{"v": parseExtractSpec(spec)}
+
+	remove, err := p.EvalOnNewDocument(fmt.Sprintf(`(%s)(%q, %s)`, observeContentJS, name, utils.MustToJSON(fields)))
+	if err != nil {
+		_ = stopExpose()
+		return nil, err
+	}
+
+	_, err = p.Evaluate(Eval(observeContentJS, name, fields))
+	if err != nil {
+		_, _ = p.Evaluate(Eval(`(name) => window[name + '_stop'] && window[name + '_stop']()`, name))
+		_ = remove()
+		_ = stopExpose()
+		return nil, err
+	}
+
+	return func() {
+		_, _ = p.Evaluate(Eval(`(name) => window[name + '_stop'] && window[name + '_stop']()`, name))
+		_ = remove()
+		_ = stopExpose()
+	}, nil
+}
+
+var observeContentJS = `function (name, fields) {
+	const extract = ` + js.Extract.Definition + `
+	const get = () => JSON.stringify(extract.call(document, fields).v)
+	let last = get()
+	let timer
+	const observer = new MutationObserver(() => {
+		clearTimeout(timer)
+		timer = setTimeout(() => {
+			const v = get()
+			if (v === last) return
+			last = v
+			window[name](JSON.parse(v))
+		}, 100)
+	})
+	observer.observe(document, { subtree: true, childList: true, characterData: true, attributes: true })
+	window[name + '_stop'] = () => observer.disconnect()
+}`
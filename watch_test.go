@@ -0,0 +1,68 @@
+package rod_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Fromsko/rodPro/lib/proto"
+	"github.com/Fromsko/rodPro/lib/utils"
+	"github.com/ysmood/gson"
+)
+
+func TestPageWatch(t *testing.T) {
+	g := setup(t)
+
+	for _, interval := range []time.Duration{0, 30 * time.Millisecond} {
+		p := g.newPage(g.srcFile("fixtures/extract.html"))
+
+		changes := make(chan [2]string, 10)
+		stop := p.MustWatch(".price", interval, func(old, new gson.JSON) {
+			changes <- [2]string{old.Str(), new.Str()}
+		})
+
+		p.MustEval(`() => document.querySelector('.price').innerText = '2.5'`)
+		g.Eq(<-changes, [2]string{"1.5", "2.5"})
+
+		p.MustEval(`() => document.querySelector('.price').innerText = '2.5'`)
+		p.MustEval(`() => document.querySelector('h1').innerText = 'other'`)
+		p.MustEval(`() => document.querySelector('.price').innerText = '3'`)
+		g.Eq(<-changes, [2]string{"2.5", "3"})
+
+		stop()
+		p.MustEval(`() => document.querySelector('.price').innerText = '4'`)
+		utils.Sleep(0.3)
+		g.Len(changes, 0)
+	}
+}
+
+func TestPageWatchReload(t *testing.T) {
+	g := setup(t)
+
+	p := g.newPage(g.srcFile("fixtures/extract.html"))
+
+	changes := make(chan [2]string, 10)
+	stop := p.MustWatch(".price[]", 0, func(old, new gson.JSON) {
+		changes <- [2]string{old.JSON("", ""), new.JSON("", "")}
+	})
+	defer stop()
+
+	p.MustReload().MustWaitLoad()
+	p.MustEval(`() => document.querySelector('.price').remove()`)
+	g.Eq(<-changes, [2]string{`["1.5","0.8"]`, `["0.8"]`})
+}
+
+func TestPageWatchErr(t *testing.T) {
+	g := setup(t)
+
+	p := g.newPage(g.srcFile("fixtures/extract.html"))
+	fn := func(old, new gson.JSON) {}
+
+	g.mc.stubErr(1, proto.RuntimeCallFunctionOn{})
+	g.Err(p.Watch(".price", 0, fn))
+
+	g.mc.stubErr(1, proto.RuntimeAddBinding{})
+	g.Err(p.Watch(".price", 0, fn))
+
+	g.mc.stubErr(2, proto.PageAddScriptToEvaluateOnNewDocument{})
+	g.Err(p.Watch(".price", 0, fn))
+}
@@ -0,0 +1,151 @@
+// This file serves for the WebAuthn virtual authenticators of Page.
+
+package rod
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+
+	"github.com/Fromsko/rodPro/lib/proto"
+)
+
+// WebAuthn helps to test the passkey (WebAuthn) flows of a page with virtual authenticators,
+// the browser will use them instead of the real security keys or platform authenticators.
+type WebAuthn struct {
+	page *Page
+}
+
+// VirtualAuthenticator is a virtual authenticator added by [WebAuthn.AddAuthenticator].
+type VirtualAuthenticator struct {
+	ID proto.WebAuthnAuthenticatorID
+
+	page *Page
+}
+
+// WebAuthn returns the helper to manage the virtual authenticators of the page.
+func (p *Page) WebAuthn() *WebAuthn {
+	return &WebAuthn{page: p}
+}
+
+// AddAuthenticator enables the WebAuthn domain and adds a virtual authenticator.
+// If opts is nil, a ctap2 internal authenticator with resident keys and user verification will be used,
+// which works like a platform passkey provider.
+func (w *WebAuthn) AddAuthenticator(opts *proto.WebAuthnVirtualAuthenticatorOptions) (*VirtualAuthenticator, error) {
+	if opts == nil {
+		opts = &proto.WebAuthnVirtualAuthenticatorOptions{
+			Protocol:                    proto.WebAuthnAuthenticatorProtocolCtap2,
+			Ctap2Version:                proto.WebAuthnCtap2VersionCtap21,
+			Transport:                   proto.WebAuthnAuthenticatorTransportInternal,
+			HasResidentKey:              true,
+			HasUserVerification:         true,
+			AutomaticPresenceSimulation: true,
+			IsUserVerified:              true,
+		}
+	}
+
+	err := proto.WebAuthnEnable{}.Call(w.page)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := proto.WebAuthnAddVirtualAuthenticator{Options: opts}.Call(w.page)
+	if err != nil {
+		return nil, err
+	}
+
+	return &VirtualAuthenticator{ID: res.AuthenticatorID, page: w.page}, nil
+}
+
+// Disable the WebAuthn domain, all the virtual authenticators will be removed.
+func (w *WebAuthn) Disable() error {
+	return proto.WebAuthnDisable{}.Call(w.page)
+}
+
+// AddCredential to the authenticator. If the [proto.WebAuthnCredential.CredentialID] is empty, a random one
+// will be used. If the [proto.WebAuthnCredential.PrivateKey] is empty, a new ECDSA P-256 key will be generated.
+// The credential with the generated fields will be returned.
+func (a *VirtualAuthenticator) AddCredential(c *proto.WebAuthnCredential) (*proto.WebAuthnCredential, error) {
+	cred := *c
+
+	if len(cred.CredentialID) == 0 {
+		cred.CredentialID = make([]byte, 16)
+		_, err := rand.Read(cred.CredentialID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(cred.PrivateKey) == 0 {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		cred.PrivateKey, err = x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err := proto.WebAuthnAddCredential{AuthenticatorID: a.ID, Credential: &cred}.Call(a.page)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cred, nil
+}
+
+// Credentials returns all the credentials stored in the authenticator.
+func (a *VirtualAuthenticator) Credentials() ([]*proto.WebAuthnCredential, error) {
+	res, err := proto.WebAuthnGetCredentials{AuthenticatorID: a.ID}.Call(a.page)
+	if err != nil {
+		return nil, err
+	}
+	return res.Credentials, nil
+}
+
+// Credential returns the credential with the id.
+func (a *VirtualAuthenticator) Credential(id []byte) (*proto.WebAuthnCredential, error) {
+	res, err := proto.WebAuthnGetCredential{AuthenticatorID: a.ID, CredentialID: id}.Call(a.page)
+	if err != nil {
+		return nil, err
+	}
+	return res.Credential, nil
+}
+
+// SignCount returns the signature counter of the credential, it's incremented by one for each successful assertion.
+// Use it to check how many times the credential has been used to login.
+func (a *VirtualAuthenticator) SignCount(id []byte) (int, error) {
+	list, err := a.Credentials()
+	if err != nil {
+		return 0, err
+	}
+	for _, c := range list {
+		if bytes.Equal(c.CredentialID, id) {
+			return c.SignCount, nil
+		}
+	}
+	return 0, &ErrCredentialNotFound{ID: id}
+}
+
+// RemoveCredential removes the credential with the id from the authenticator.
+func (a *VirtualAuthenticator) RemoveCredential(id []byte) error {
+	return proto.WebAuthnRemoveCredential{AuthenticatorID: a.ID, CredentialID: id}.Call(a.page)
+}
+
+// ClearCredentials removes all the credentials from the authenticator.
+func (a *VirtualAuthenticator) ClearCredentials() error {
+	return proto.WebAuthnClearCredentials{AuthenticatorID: a.ID}.Call(a.page)
+}
+
+// SetUserVerified sets whether the user verification of the authenticator succeeds or fails.
+func (a *VirtualAuthenticator) SetUserVerified(verified bool) error {
+	return proto.WebAuthnSetUserVerified{AuthenticatorID: a.ID, IsUserVerified: verified}.Call(a.page)
+}
+
+// Remove the authenticator.
+func (a *VirtualAuthenticator) Remove() error {
+	return proto.WebAuthnRemoveVirtualAuthenticator{AuthenticatorID: a.ID}.Call(a.page)
+}
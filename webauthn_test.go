@@ -0,0 +1,57 @@
+package rod_test
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/Fromsko/rodPro"
+	"github.com/Fromsko/rodPro/lib/proto"
+)
+
+func TestWebAuthn(t *testing.T) {
+	g := setup(t)
+
+	s := g.Serve()
+	s.Route("/", ".html", `<html></html>`)
+
+	p := g.newPage(s.URL())
+	wa := p.WebAuthn()
+	defer wa.MustDisable()
+
+	a := wa.MustAddAuthenticator(nil)
+
+	cred := a.MustAddCredential(&proto.WebAuthnCredential{
+		IsResidentCredential: true,
+		RpID:                 "127.0.0.1",
+		UserHandle:           []byte("user"),
+	})
+	g.Len(cred.CredentialID, 16)
+	g.Len(a.MustCredentials(), 1)
+	g.Eq(a.MustCredential(cred.CredentialID).RpID, "127.0.0.1")
+	g.Eq(a.MustSignCount(cred.CredentialID), 0)
+
+	id := p.MustEval(`async (id) => {
+		const c = await navigator.credentials.get({ publicKey: {
+			challenge: new Uint8Array(16),
+			allowCredentials: [{ type: 'public-key', id: Uint8Array.from(atob(id), c => c.charCodeAt(0)) }],
+		}})
+		return c.id
+	}`, base64.StdEncoding.EncodeToString(cred.CredentialID)).Str()
+	g.Eq(id, base64.RawURLEncoding.EncodeToString(cred.CredentialID))
+	g.Eq(a.MustSignCount(cred.CredentialID), 1)
+
+	a.MustSetUserVerified(false).MustRemoveCredential(cred.CredentialID)
+	_, err := a.SignCount(cred.CredentialID)
+	g.Is(err, &rod.ErrCredentialNotFound{})
+
+	a.MustAddCredential(&proto.WebAuthnCredential{RpID: "127.0.0.1"})
+	g.Len(a.MustClearCredentials().MustCredentials(), 0)
+
+	a.MustRemove()
+
+	g.mc.stubErr(1, proto.WebAuthnEnable{})
+	g.Err(wa.AddAuthenticator(nil))
+
+	g.mc.stubErr(1, proto.WebAuthnGetCredentials{})
+	g.Err(a.SignCount(nil))
+}
@@ -0,0 +1,113 @@
+// This file serves for reporting the WebGL capability of the browser.
+
+package rod
+
+import (
+	"regexp"
+	"sort"
+
+	"github.com/Fromsko/rodPro/lib/proto"
+)
+
+// WebGLInfo of [Page.WebGLInfo]
+type WebGLInfo struct {
+	// WebGL1 and WebGL2 are true if the contexts can be created
+	WebGL1, WebGL2 bool
+
+	// Vendor and Renderer are the unmasked ones if the WEBGL_debug_renderer_info is available,
+	// such as "Google Inc. (Google)" and "ANGLE (Google, Vulkan 1.3.0 (SwiftShader Device ...))"
+	Vendor, Renderer string
+
+	Version                string
+	ShadingLanguageVersion string
+	MaxTextureSize         int
+	Extensions             []string
+
+	// Software is true if the renderer is a software rasterizer, such as SwiftShader or llvmpipe,
+	// WebGL works but is slow, and the browser may block it.
+	Software bool
+
+	// Error is the status message of the webglcontextcreationerror event when no context can be created
+	Error string
+
+	// FeatureStatus of the gpu features of the browser, such as {"webgl": "unavailable_off"},
+	// it tells why the WebGL is disabled, such as the gpu is blocklisted or the --disable-gpu is set.
+	FeatureStatus map[string]string
+}
+
+var regSoftwareGL = regexp.MustCompile(`(?i)swiftshader|llvmpipe|softpipe|software|basic render`)
+
+// WebGLInfo reports the WebGL capability of the page, it's useful to find out why a WebGL-heavy page renders
+// blank in CI, such as the browser has no gpu and the software fallback is disabled.
+// Use the GL presets of the launcher to switch the backend.
+func (p *Page) WebGLInfo() (*WebGLInfo, error) {
+	res, err := p.Eval(`() => {
+		let error = ''
+		const contexts = []
+		const context = (type) => {
+			const canvas = document.createElement('canvas')
+			canvas.addEventListener('webglcontextcreationerror', e => { error = e.statusMessage || 'unknown error' })
+			const gl = canvas.getContext(type)
+			if (gl) contexts.push(gl)
+			return gl
+		}
+
+		try {
+			const gl2 = context('webgl2')
+			const gl = gl2 || context('webgl')
+			if (!gl) return { error }
+
+			const info = gl.getExtension('WEBGL_debug_renderer_info')
+			return {
+				webgl1: true,
+				webgl2: !!gl2,
+				vendor: gl.getParameter(info ? info.UNMASKED_VENDOR_WEBGL : gl.VENDOR),
+				renderer: gl.getParameter(info ? info.UNMASKED_RENDERER_WEBGL : gl.RENDERER),
+				version: gl.getParameter(gl.VERSION),
+				shading: gl.getParameter(gl.SHADING_LANGUAGE_VERSION),
+				maxTexture: gl.getParameter(gl.MAX_TEXTURE_SIZE),
+				extensions: gl.getSupportedExtensions() || [],
+			}
+		} finally {
+			// the browser limits the number of the live contexts, release them at once instead of waiting for the gc
+			for (const gl of contexts) {
+				const lose = gl.getExtension('WEBGL_lose_context')
+				if (lose) lose.loseContext()
+			}
+		}
+	}`)
+	if err != nil {
+		return nil, err
+	}
+	v := res.Value
+
+	info := &WebGLInfo{
+		WebGL1:                 v.Get("webgl1").Bool(),
+		WebGL2:                 v.Get("webgl2").Bool(),
+		Vendor:                 v.Get("vendor").Str(),
+		Renderer:               v.Get("renderer").Str(),
+		Version:                v.Get("version").Str(),
+		ShadingLanguageVersion: v.Get("shading").Str(),
+		MaxTextureSize:         v.Get("maxTexture").Int(),
+		Extensions:             []string{},
+		Error:                  v.Get("error").Str(),
+		FeatureStatus:          map[string]string{},
+	}
+	for _, ext := range v.Get("extensions").Arr() {
+		info.Extensions = append(info.Extensions, ext.Str())
+	}
+	sort.Strings(info.Extensions)
+	info.Software = regSoftwareGL.MatchString(info.Renderer)
+
+	sys, err := proto.SystemInfoGetInfo{}.Call(p.browser)
+	if err != nil {
+		return nil, err
+	}
+	if sys.Gpu != nil {
+		for k, s := range sys.Gpu.FeatureStatus {
+			info.FeatureStatus[k] = s.Str()
+		}
+	}
+
+	return info, nil
+}
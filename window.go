@@ -0,0 +1,185 @@
+// This file serves for the window management of Browser.
+
+package rod
+
+import (
+	"sort"
+
+	"github.com/Fromsko/rodPro/lib/proto"
+)
+
+// Window of the browser, a window may contain multiple pages as tabs.
+type Window struct {
+	ID proto.BrowserWindowID
+
+	browser *Browser
+}
+
+// Windows returns the windows that have pages of the browser, ordered by id.
+// For an incognito browser only the windows of its own pages will be returned.
+func (b *Browser) Windows() ([]*Window, error) {
+	list, err := proto.TargetGetTargets{}.Call(b)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := map[proto.BrowserWindowID]struct{}{}
+	for _, info := range list.TargetInfos {
+		if info.Type != proto.TargetTargetInfoTypePage {
+			continue
+		}
+		if b.BrowserContextID != "" && info.BrowserContextID != b.BrowserContextID {
+			continue
+		}
+
+		res, err := proto.BrowserGetWindowForTarget{TargetID: info.TargetID}.Call(b)
+		if err != nil {
+			return nil, err
+		}
+		ids[res.WindowID] = struct{}{}
+	}
+
+	windows := []*Window{}
+	for id := range ids {
+		windows = append(windows, &Window{ID: id, browser: b})
+	}
+	sort.Slice(windows, func(i, j int) bool { return windows[i].ID < windows[j].ID })
+
+	return windows, nil
+}
+
+// NewWindow opens the url in a new window, it returns the window and the page of the url.
+func (b *Browser) NewWindow(url string) (*Window, *Page, error) {
+	p, err := b.Page(proto.TargetCreateTarget{URL: url, NewWindow: true})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	w, err := p.Window()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return w, p, nil
+}
+
+// Window returns the window that contains the page.
+func (p *Page) Window() (*Window, error) {
+	id, err := p.getWindowID()
+	if err != nil {
+		return nil, err
+	}
+	return &Window{ID: id, browser: p.browser}, nil
+}
+
+// Pages of the window
+func (w *Window) Pages() (Pages, error) {
+	pages, err := w.browser.Pages()
+	if err != nil {
+		return nil, err
+	}
+
+	list := Pages{}
+	for _, p := range pages {
+		id, err := p.getWindowID()
+		if err != nil {
+			return nil, err
+		}
+		if id == w.ID {
+			list = append(list, p)
+		}
+	}
+	return list, nil
+}
+
+// Bounds of the window, such as the position, size, and state.
+func (w *Window) Bounds() (*proto.BrowserBounds, error) {
+	res, err := proto.BrowserGetWindowBounds{WindowID: w.ID}.Call(w.browser)
+	if err != nil {
+		return nil, err
+	}
+	return res.Bounds, nil
+}
+
+// SetBounds of the window. The position and size can only be set when the state is normal,
+// so if the bounds changes them, the window will be restored to normal state first.
+func (w *Window) SetBounds(bounds *proto.BrowserBounds) error {
+	sized := bounds.Left != nil || bounds.Top != nil || bounds.Width != nil || bounds.Height != nil
+
+	if sized {
+		err := w.SetState(proto.BrowserWindowStateNormal)
+		if err != nil {
+			return err
+		}
+	}
+
+	return proto.BrowserSetWindowBounds{WindowID: w.ID, Bounds: bounds}.Call(w.browser)
+}
+
+// SetState of the window, such as minimized, maximized, or fullscreen.
+func (w *Window) SetState(state proto.BrowserWindowState) error {
+	return proto.BrowserSetWindowBounds{
+		WindowID: w.ID,
+		Bounds:   &proto.BrowserBounds{WindowState: state},
+	}.Call(w.browser)
+}
+
+// Minimize the window
+func (w *Window) Minimize() error {
+	return w.SetState(proto.BrowserWindowStateMinimized)
+}
+
+// Maximize the window
+func (w *Window) Maximize() error {
+	return w.SetState(proto.BrowserWindowStateMaximized)
+}
+
+// Fullscreen the window
+func (w *Window) Fullscreen() error {
+	return w.SetState(proto.BrowserWindowStateFullscreen)
+}
+
+// Restore the window to normal state
+func (w *Window) Restore() error {
+	return w.SetState(proto.BrowserWindowStateNormal)
+}
+
+// MovePage moves the page to the window by reopening the url of the page as a tab of the window,
+// then closes the original page. Devtools protocol can't reparent an existing tab,
+// so the states of the document, such as the js variables, won't be kept.
+// It returns the new page.
+func (w *Window) MovePage(p *Page) (*Page, error) {
+	info, err := p.Info()
+	if err != nil {
+		return nil, err
+	}
+
+	pages, err := w.Pages()
+	if err != nil {
+		return nil, err
+	}
+	if len(pages) == 0 {
+		return nil, &ErrPageNotFound{}
+	}
+
+	// a new tab opened by a page is placed in the window of the page
+	opener := pages.First()
+	wait := opener.WaitOpen()
+
+	_, err = opener.Evaluate(Eval(`u => { window.open(u, '_blank') }`, info.URL).ByUser())
+	if err != nil {
+		return nil, err
+	}
+
+	np, err := wait()
+	if err != nil {
+		return nil, err
+	}
+
+	err = np.WaitLoad()
+	if err != nil {
+		return nil, err
+	}
+
+	return np, p.Close()
+}
@@ -0,0 +1,49 @@
+package rod_test
+
+import (
+	"testing"
+
+	"github.com/Fromsko/rodPro/lib/proto"
+	"github.com/ysmood/gson"
+)
+
+func TestBrowserWindows(t *testing.T) {
+	g := setup(t)
+
+	b := g.browser.MustIncognito()
+	defer b.MustClose()
+
+	p := b.MustPage(g.blank())
+	w := p.MustWindow()
+
+	w2, p2 := b.MustNewWindow(g.blank())
+	g.Neq(w.ID, w2.ID)
+
+	list := b.MustWindows()
+	g.Len(list, 2)
+
+	g.Eq(w2.MustPages()[0].TargetID, p2.TargetID)
+
+	w2.MustSetBounds(&proto.BrowserBounds{Left: gson.Int(10), Top: gson.Int(20), Width: gson.Int(400), Height: gson.Int(300)})
+	bounds := w2.MustBounds()
+	g.Eq(*bounds.Width, 400)
+	g.Eq(*bounds.Height, 300)
+
+	w2.MustSetState(proto.BrowserWindowStateMaximized)
+	g.Eq(w2.MustBounds().WindowState, proto.BrowserWindowStateMaximized)
+	g.E(w2.Restore())
+	g.Eq(w2.MustBounds().WindowState, proto.BrowserWindowStateNormal)
+
+	moved := w2.MustMovePage(p)
+	g.Eq(moved.MustWindow().ID, w2.ID)
+	g.Len(w2.MustPages(), 2)
+
+	g.mc.stubErr(1, proto.TargetGetTargets{})
+	g.Err(b.Windows())
+
+	g.mc.stubErr(1, proto.BrowserGetWindowForTarget{})
+	g.Err(b.Windows())
+
+	g.mc.stubErr(1, proto.BrowserGetWindowBounds{})
+	g.Err(w.Bounds())
+}